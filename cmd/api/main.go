@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"expvar"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,6 +20,7 @@ import (
 
 	"github.com/mwork/mwork-api/internal/config"
 	"github.com/mwork/mwork-api/internal/domain/admin"
+	"github.com/mwork/mwork-api/internal/domain/apikey"
 	attachmentDomain "github.com/mwork/mwork-api/internal/domain/attachment"
 	"github.com/mwork/mwork-api/internal/domain/auth"
 	"github.com/mwork/mwork-api/internal/domain/casting"
@@ -28,6 +31,7 @@ import (
 	"github.com/mwork/mwork-api/internal/domain/experience"
 	"github.com/mwork/mwork-api/internal/domain/favorite"
 	"github.com/mwork/mwork-api/internal/domain/lead"
+	"github.com/mwork/mwork-api/internal/domain/ledger"
 	"github.com/mwork/mwork-api/internal/domain/moderation"
 	"github.com/mwork/mwork-api/internal/domain/notification"
 	"github.com/mwork/mwork-api/internal/domain/organization"
@@ -42,14 +46,18 @@ import (
 	uploadDomain "github.com/mwork/mwork-api/internal/domain/upload"
 	"github.com/mwork/mwork-api/internal/domain/user"
 	"github.com/mwork/mwork-api/internal/domain/wallet"
+	"github.com/mwork/mwork-api/internal/domain/webhook"
 	"github.com/mwork/mwork-api/internal/middleware"
 	"github.com/mwork/mwork-api/internal/pkg/database"
 	emailpkg "github.com/mwork/mwork-api/internal/pkg/email"
+	"github.com/mwork/mwork-api/internal/pkg/events"
 	"github.com/mwork/mwork-api/internal/pkg/featurepayment"
 	"github.com/mwork/mwork-api/internal/pkg/jwt"
 	"github.com/mwork/mwork-api/internal/pkg/logger"
+	paymentprovider "github.com/mwork/mwork-api/internal/pkg/payment"
 	"github.com/mwork/mwork-api/internal/pkg/photostudio"
 	pkgresponse "github.com/mwork/mwork-api/internal/pkg/response"
+	"github.com/mwork/mwork-api/internal/pkg/robokassa"
 	"github.com/mwork/mwork-api/internal/pkg/storage"
 
 	_ "github.com/mwork/mwork-api/docs"
@@ -99,6 +107,10 @@ func main() {
 		FromName:  cfg.SendGridFromName,
 	})
 	defer emailService.Close()
+	emailEventRepo := notification.NewEmailEventRepository(db)
+	emailUnsubscribeSigner := emailpkg.NewUnsubscribeSigner(cfg.EmailUnsubscribeSecret, cfg.EmailUnsubscribeURL)
+	emailService.SetSuppressionChecker(emailEventRepo)
+	emailService.SetUnsubscribeSigner(emailUnsubscribeSigner)
 
 	// ---------- Repositories ----------
 	userRepo := user.NewRepository(db)
@@ -237,18 +249,105 @@ func main() {
 		IsTest:        cfg.RobokassaIsTest,
 		BaseURL:       cfg.RobokassaBaseURL,
 		HashAlgo:      cfg.RobokassaHashAlgorithm,
+
+		ReceiptEnabled: cfg.RobokassaReceiptEnabled,
+		ReceiptSno:     cfg.RobokassaReceiptSno,
+		ReceiptTax:     cfg.RobokassaReceiptTax,
+	})
+	paymentService.SetProductRepository(payment.NewProductRepository(db))
+	paymentService.SetInvoicing(payment.NewInvoiceRepository(db), localStorage, emailService, payment.InvoiceConfig{
+		Seller: payment.InvoiceParty{
+			Name:    cfg.InvoiceSellerName,
+			BinIIN:  cfg.InvoiceSellerBIN,
+			Address: cfg.InvoiceSellerAddress,
+			Email:   cfg.InvoiceSellerEmail,
+			Bank:    cfg.InvoiceSellerBank,
+			IBAN:    cfg.InvoiceSellerIBAN,
+			BIK:     cfg.InvoiceSellerBIK,
+			Kbe:     cfg.InvoiceSellerKbe,
+		},
+		NumberPrefix: cfg.InvoiceNumberPrefix,
+		VATRate:      cfg.InvoiceVATRate,
 	})
 
+	// Refunds go through the provider abstraction
+	robokassaClientConfig := robokassa.Config{
+		MerchantLogin:  cfg.RobokassaMerchantLogin,
+		Password1:      cfg.RobokassaPassword1,
+		Password2:      cfg.RobokassaPassword2,
+		Password3:      cfg.RobokassaPassword3,
+		RefundURL:      cfg.RobokassaRefundURL,
+		RefundStateURL: cfg.RobokassaRefundStateURL,
+		OpStateURL:     cfg.RobokassaOpStateURL,
+		RecurringURL:   cfg.RobokassaRecurringURL,
+		TestMode:       cfg.RobokassaIsTest,
+		BaseURL:        cfg.RobokassaBaseURL,
+		HashAlgo:       robokassa.HashAlgorithm(cfg.RobokassaHashAlgorithm),
+	}
+	paymentProviders := paymentprovider.NewProviderFactory()
+	paymentProviders.Register(paymentprovider.ProviderRoboKassa, paymentprovider.NewRoboKassaProvider(robokassaClientConfig))
+	paymentService.SetRefunds(payment.NewRefundRepository(db), paymentProviders)
+	paymentService.SetPromoRepository(payment.NewPromoRepository(db))
+
+	// Nightly reconciliation of stale pending payments and credit balances
+	paymentService.SetReconciliation(payment.NewReconcileRepository(db), robokassa.NewClient(robokassaClientConfig), payment.ReconcileConfig{
+		StaleAfter:  cfg.PaymentReconcileStaleAfter,
+		GiveUpAfter: cfg.PaymentReconcileGiveUpAfter,
+	})
+	paymentReconcileWorker := payment.NewReconcileWorker(paymentService, cfg.PaymentReconcileInterval)
+	paymentReconcileWorker.Start()
+
 	// Adapter for subscription payment service (must use configured paymentService instance)
 	subscriptionPaymentService := &subscriptionPaymentAdapter{service: paymentService}
 	limitChecker := subscription.NewLimitChecker(subscriptionService)
 	chatService = chat.NewService(chatRepo, userRepo, chatHub, accessChecker, limitChecker, uploadResolver)
 	notificationService.SetRealtimePublisher(notification.NewWSPublisher(chatHub))
+	profileService.SetViewNotifier(notificationService)
 	notificationModelRepo := &notificationProfileAdapter{modelRepo: modelRepo}
 	notificationEmployerRepo := &notificationProfileAdapter{employerRepo: employerRepo}
 	notificationIntegratedService := notification.NewIntegratedService(notificationService, emailService, nil, userRepo, notificationModelRepo, notificationEmployerRepo)
-	responseService.SetNotificationService(notificationIntegratedService)
-	chatService.SetNotificationService(notificationIntegratedService)
+	notificationDispatcher := notification.NewDispatcher(notification.DispatcherConfig{
+		Repo:         notificationRepo,
+		PrefsRepo:    notification.NewPreferencesRepository(db),
+		DeviceRepo:   notification.NewDeviceTokenRepository(db),
+		DeferredRepo: notification.NewDeferredRepository(db),
+		Policy: notification.NewDeliveryPolicy(notification.DeliveryPolicyConfig{
+			CoalesceWindow:  cfg.NotificationCoalesceWindow,
+			Throttles:       notification.ParseThrottleRules(cfg.NotificationThrottles),
+			DefaultTimezone: cfg.NotificationDefaultTimezone,
+		}),
+		EmailService: emailService,
+		Interval:     1 * time.Minute,
+	})
+	notificationDispatcher.Start()
+	notificationIntegratedService.SetDispatcher(notificationDispatcher)
+
+	notificationCleanupJob := notification.NewCleanupJob(db, notification.RetentionPolicy{
+		ReadRetention:   time.Duration(cfg.NotificationReadRetention) * 24 * time.Hour,
+		UnreadRetention: time.Duration(cfg.NotificationUnreadRetention) * 24 * time.Hour,
+		TypeRetention:   notification.ParseTypeRetention(cfg.NotificationTypeRetention),
+		MaxPerUser:      cfg.NotificationMaxPerUser,
+	})
+	notificationCleanupCtx, stopNotificationCleanup := context.WithCancel(context.Background())
+	go notificationCleanupJob.Start(notificationCleanupCtx, cfg.NotificationCleanupInterval)
+
+	// Outbound webhooks are fed by the same hooks as notifications
+	webhookService := webhook.NewService(webhook.NewRepository(db), webhook.Config{
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		Timeout:      cfg.WebhookTimeout,
+		AllowPrivate: cfg.WebhookAllowPrivate,
+		RequireHTTPS: cfg.IsProduction(),
+	})
+	webhookResponseHooks := webhook.NewResponseHooks(notificationIntegratedService, webhookService)
+	responseService.SetNotificationService(webhookResponseHooks)
+	castingService.SetClosedNotifier(webhookResponseHooks)
+	chatService.SetNotificationService(webhook.NewChatHooks(notificationIntegratedService, webhookService))
+	webhookWorker := webhook.NewWorker(webhookService, cfg.WebhookWorkerInterval)
+	webhookWorker.Start()
+	notificationService.SetActionPerformers(
+		&notificationResponseDecider{service: responseService},
+		&notificationMessageReplier{service: chatService},
+	)
 
 	// Adapter for chat service to response service
 	chatServiceAdapter := &chatServiceAdapter{service: chatService}
@@ -259,10 +358,28 @@ func main() {
 
 	// Credit service initialization
 	creditService := credit.NewService(db)
+	creditService.ConfigureLots(credit.LotConfig{
+		GrantExpiry:         time.Duration(cfg.CreditGrantExpiryDays) * 24 * time.Hour,
+		ExpiringSoonWithin:  time.Duration(cfg.CreditExpiringSoonDays) * 24 * time.Hour,
+		LowBalanceThreshold: cfg.CreditLowBalanceThreshold,
+	}, notificationService)
+	creditExpiryWorker := credit.NewExpiryWorker(creditService, cfg.CreditExpiryInterval)
+	creditExpiryWorker.Start()
 	subscriptionService.SetCreditService(creditService)
 	subscriptionService.SetUserRepo(userRepo)
 
-	featurePayProvider, err := featurepayment.NewPaymentProvider(cfg.PaymentMode, walletService, creditService)
+	// Unified ledger over credits, connects and the demo wallet
+	ledgerService := ledger.NewService(ledger.NewRepository(db))
+	ledgerService.SetHoldTTL(cfg.LedgerHoldTTL)
+	ledgerWorker := ledger.NewWorker(ledgerService, cfg.LedgerMaintenanceInterval)
+	ledgerWorker.Start()
+
+	var featurePayProvider featurepayment.PaymentProvider
+	if cfg.PaymentLedgerEnabled {
+		featurePayProvider, err = featurepayment.NewLedgerPaymentProvider(cfg.PaymentMode, ledgerService)
+	} else {
+		featurePayProvider, err = featurepayment.NewPaymentProvider(cfg.PaymentMode, walletService, creditService)
+	}
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize payment provider")
 	}
@@ -316,24 +433,132 @@ func main() {
 	paymentHandler := payment.NewHandler(paymentService, cfg)
 
 	dashboardHandler := dashboard.NewHandler(dashboardRepo, dashboardSvc)
+	analyticsSvc := dashboard.NewAnalyticsService(dashboard.NewAnalyticsRepository(db), castingRepo, modelRepo, dashboard.AnalyticsConfig{
+		Window: cfg.EmployerAnalyticsWindow,
+	})
+	dashboardHandler.SetAnalytics(analyticsSvc)
+	dashboardHandler.SetViewerAccess(limitChecker)
 	promotionHandler := promotion.NewHandler(promotionRepo)
 	castingPromotionRepo := promotion.NewCastingRepository(db)
 	castingPromotionHandler := promotion.NewCastingPromotionHandler(castingPromotionRepo, creditService)
 
-	// Start promotion background expiration worker (runs every hour)
-	promoWorker := promotion.NewWorker(promotionRepo, castingPromotionRepo, 1*time.Hour)
+	// Promotion impression/click/response tracking, deduplicated per viewer and flushed in batches
+	var promoDeduper promotion.Deduper
+	var promoCapper promotion.FrequencyCapper
+	if redis != nil {
+		promoDeduper = promotion.NewRedisDeduper(redis)
+		promoCapper = promotion.NewRedisFrequencyCapper(redis)
+	}
+	promoSpendRepo := promotion.NewTrackingRepository(db, promotion.Pricing{CPM: cfg.PromotionCPM, CPC: cfg.PromotionCPC})
+	promoTracker := promotion.NewTracker(promoSpendRepo, promoDeduper, promotion.TrackingConfig{
+		FlushInterval:    cfg.PromotionTrackingFlushInterval,
+		ImpressionWindow: cfg.PromotionImpressionDedupeWindow,
+		ClickWindow:      cfg.PromotionClickDedupeWindow,
+	})
+	promotionHandler.SetTracker(promoTracker)
+	profileHandler.SetImpressionTracker(promoTracker)
+	castingHandler.SetImpressionTracker(promoTracker)
+	responseService.SetConversionTracker(promoTracker)
+	promoTracker.Start()
+
+	// Sponsored slots in model and casting lists, targeted by viewer role and city
+	adServer := promotion.NewAdServer(promotion.NewAdRepository(db), promoCapper, promoTracker, promotion.AdConfig{
+		Slots:           cfg.PromotionSponsoredSlots,
+		FrequencyCap:    cfg.PromotionFrequencyCap,
+		FrequencyWindow: cfg.PromotionFrequencyWindow,
+	})
+	profileHandler.SetSponsoredSelector(adServer)
+	castingHandler.SetSponsoredSelector(adServer)
+
+	// Promotion worker: expiry, budget pacing, auto-pause and refunds of unspent budgets
+	promoWorker := promotion.NewWorker(promotionRepo, castingPromotionRepo, cfg.PromotionWorkerInterval)
+	promoWorker.SetBudget(promoSpendRepo, creditService)
 	promoWorker.Start()
 
+	// Employer hiring analytics: scores responses and rebuilds daily aggregates
+	analyticsWorker := dashboard.NewAnalyticsWorker(analyticsSvc, cfg.EmployerAnalyticsInterval)
+	analyticsWorker.Start()
+
+	// Subscription lifecycle: grace period, expiry downgrades and renewal reminders
+	subscriptionService.SetLifecycle(subscription.LifecycleConfig{
+		GracePeriod:  cfg.SubscriptionGracePeriod,
+		ReminderDays: subscription.ParseReminderDays(cfg.SubscriptionReminderDays),
+	}, notificationService)
+	subscriptionLifecycleWorker := subscription.NewLifecycleWorker(subscriptionService, cfg.SubscriptionLifecycleInterval)
+	subscriptionLifecycleWorker.Start()
+
+	// Auto-renewal: charge saved cards before expiry, retry failures with dunning notices
+	paymentService.SetRenewals(payment.NewRenewalRepository(db), subscriptionService, robokassa.NewClient(robokassaClientConfig), notificationService, payment.RenewalConfig{
+		Lead:           cfg.SubscriptionRenewalLead,
+		RetryIntervals: payment.ParseRetryIntervals(cfg.SubscriptionRenewalRetryIntervals),
+		ConfirmTimeout: cfg.SubscriptionRenewalConfirmTimeout,
+	})
+	subscriptionRenewalWorker := payment.NewRenewalWorker(paymentService, cfg.SubscriptionRenewalInterval)
+	subscriptionRenewalWorker.Start()
+
 	favoriteHandler := favorite.NewHandler(favoriteRepo)
 	walletHandler := wallet.NewHandler(walletService)
+	ledgerHandler := ledger.NewHandler(ledgerService)
 
 	reviewRepo := review.NewRepository(db)
 	reviewHandler := review.NewHandler(reviewRepo)
+	reviewHandler.SetNotifier(webhookService)
 	faqHandler := content.NewFAQHandler(db)
 
 	creditHandler := admin.NewCreditHandler(creditService, adminService)
 	photoStudioAdminHandler := admin.NewPhotoStudioHandler(db, photoStudioClient, photoStudioSyncEnabled, photoStudioTimeout)
 	adminHandler := admin.NewHandler(adminService, adminJWTService, photoStudioAdminHandler, creditHandler)
+	adminMetricsService := admin.NewMetricsService(admin.NewMetricsRepository(db))
+	adminHandler.SetMetricsHandler(admin.NewMetricsHandler(adminMetricsService, adminService))
+
+	// Admin business metrics: refreshes cohort, revenue and liquidity materialized views
+	adminMetricsWorker := admin.NewMetricsRefreshWorker(adminMetricsService, cfg.AdminMetricsRefreshInterval)
+	adminMetricsWorker.Start()
+
+	// Product event stream: domain events go to the events table and are exported to the configured sinks
+	eventStore := events.NewStore(db)
+	adminHandler.SetEventsHandler(admin.NewEventsHandler(eventStore, adminService))
+	var eventBus *events.Bus
+	var eventExporters []*events.Exporter
+	if cfg.EventsEnabled {
+		eventBus = events.NewBus(eventStore, events.BusConfig{
+			BatchSize:     cfg.EventsBatchSize,
+			FlushInterval: cfg.EventsFlushInterval,
+		})
+		authService.SetEventEmitter(eventBus)
+		castingService.SetEventEmitter(eventBus)
+		responseService.SetEventEmitter(eventBus)
+		chatService.SetEventEmitter(eventBus)
+		paymentService.SetEventEmitter(eventBus)
+		eventBus.Start()
+
+		redactRules, err := events.ParseRules(cfg.EventsRedactRules)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid EVENTS_REDACT_RULES")
+		}
+		var redactor *events.Redactor
+		// Events only leave the system through an export sink, so only then is the salt required
+		if cfg.EventsFileDir != "" || cfg.EventsWebhookURL != "" {
+			if redactor, err = events.NewRedactor(redactRules, cfg.EventsRedactSalt); err != nil {
+				log.Fatal().Err(err).Msg("EVENTS_REDACT_SALT is required for hash rules in EVENTS_REDACT_RULES")
+			}
+		}
+		exportCfg := events.ExporterConfig{Interval: cfg.EventsExportInterval}
+		if cfg.EventsFileDir != "" {
+			fileSink, err := events.NewFileSink(cfg.EventsFileDir, cfg.EventsFileMaxBytes)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to open event export directory")
+			}
+			eventExporters = append(eventExporters, events.NewExporter(eventStore, fileSink, redactor, exportCfg))
+		}
+		if cfg.EventsWebhookURL != "" {
+			webhookSink := events.NewWebhookSink(cfg.EventsWebhookURL, cfg.EventsWebhookSecret)
+			eventExporters = append(eventExporters, events.NewExporter(eventStore, webhookSink, redactor, exportCfg))
+		}
+		for _, x := range eventExporters {
+			x.Start()
+		}
+	}
 	adminModerationHandler := admin.NewModerationHandler(db, adminService)
 	leadHandler := lead.NewHandler(leadService)
 	userAdminHandler := admin.NewUserHandler(db, adminService, creditHandler, subscriptionService)
@@ -342,7 +567,34 @@ func main() {
 	photoStudioBookingService := photostudio_booking.NewService(photoStudioConcreteClient, photoStudioSyncEnabled)
 	photoStudioBookingHandler := photostudio_booking.NewHandler(photoStudioBookingService)
 
-	authMiddleware := middleware.Auth(jwtService)
+	// Rate limits are shared across instances through Redis when it is available
+	var rateLimitStore middleware.RateLimitStore
+	if redis != nil {
+		rateLimitStore = middleware.NewRedisRateLimitStore(redis)
+	}
+	rateLimitPolicies, err := middleware.ParseRateLimitPolicies(cfg.RateLimitPolicies, middleware.DefaultRateLimitPolicies())
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid RATE_LIMIT_POLICIES")
+	}
+	if !cfg.RateLimitEnabled {
+		rateLimitPolicies = nil
+	}
+	rateLimiter := middleware.NewRateLimiter(rateLimitStore, rateLimitPolicies)
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid TRUSTED_PROXIES")
+	}
+
+	// Organization API keys are accepted wherever user tokens are
+	apiKeyService := apikey.NewService(apikey.NewRepository(db), rateLimitStore, apikey.Config{
+		DefaultRateLimit: cfg.APIKeyDefaultRateLimit,
+		MaxRateLimit:     cfg.APIKeyMaxRateLimit,
+		MaxPerOrg:        cfg.APIKeyMaxPerOrg,
+	})
+
+	authMiddleware := middleware.Auth(jwtService, apiKeyService)
+	// Identifies signed-in viewers on public routes (sponsored slot targeting)
+	optionalAuthMiddleware := middleware.OptionalAuth(jwtService)
 	emailVerificationWhitelist := []string{
 		"/api/v1/auth/login",
 		"/api/v1/auth/register",
@@ -358,8 +610,9 @@ func main() {
 		"/docs",
 	}
 	emailVerifiedMiddleware := middleware.RequireVerifiedEmail(userRepo, emailVerificationWhitelist)
+	apiRateLimitMiddleware := rateLimiter.LimitUsers("api")
 	authWithVerifiedEmailMiddleware := func(next http.Handler) http.Handler {
-		return authMiddleware(emailVerifiedMiddleware(next))
+		return authMiddleware(apiRateLimitMiddleware(emailVerifiedMiddleware(next)))
 	}
 	responseLimitMiddleware := middleware.RequireResponseLimit(limitChecker, &responseLimitCounter{repo: responseRepo})
 	chatLimitMiddleware := middleware.RequireChatLimit(limitChecker)
@@ -367,9 +620,10 @@ func main() {
 	// ---------- Router ----------
 	r := chi.NewRouter()
 
-	r.Use(chimw.RealIP)
+	r.Use(middleware.RealIP(trustedProxies))
 	r.Use(middleware.RequestID)
 	r.Use(middleware.CORSHandler(cfg.AllowedOrigins))
+	r.Use(rateLimiter.Routes())
 	r.Use(chimw.Compress(5))
 
 	// Swagger будет доступен по адресу: http://localhost:PORT/swagger/index.html
@@ -409,7 +663,7 @@ func main() {
 		})
 
 		r.Mount("/auth", authHandler.Routes(authWithVerifiedEmailMiddleware))
-		r.Mount("/profiles", profileHandler.Routes(authWithVerifiedEmailMiddleware))
+		r.With(optionalAuthMiddleware).Mount("/profiles", profileHandler.Routes(authWithVerifiedEmailMiddleware))
 		mountProfileExperienceRoutes(
 			r,
 			authWithVerifiedEmailMiddleware,
@@ -417,7 +671,7 @@ func main() {
 			experienceHandler.Create,
 			experienceHandler.Delete,
 		)
-		r.Mount("/castings", castingHandler.Routes(authWithVerifiedEmailMiddleware))
+		r.With(optionalAuthMiddleware).Mount("/castings", castingHandler.Routes(authWithVerifiedEmailMiddleware))
 
 		r.Route("/castings/{id}/responses", func(r chi.Router) {
 			r.Use(authWithVerifiedEmailMiddleware)
@@ -468,11 +722,14 @@ func main() {
 		r.Mount("/payments", paymentHandler.Routes(authWithVerifiedEmailMiddleware))
 
 		r.Mount("/dashboard", dashboard.Routes(dashboardHandler, authWithVerifiedEmailMiddleware))
-		r.Mount("/promotions", promotion.Routes(promotionHandler, authWithVerifiedEmailMiddleware))
+		r.With(optionalAuthMiddleware).Mount("/promotions", promotion.Routes(promotionHandler, authWithVerifiedEmailMiddleware, rateLimiter.Limit("promotion_click")))
 		r.Mount("/casting-promotions", promotion.CastingPromotionRoutes(castingPromotionHandler, authWithVerifiedEmailMiddleware))
 		r.Mount("/favorites", favorite.Routes(favoriteHandler, authWithVerifiedEmailMiddleware))
 		r.Mount("/demo/wallet", walletHandler.Routes(authWithVerifiedEmailMiddleware))
+		r.Mount("/ledger", ledgerHandler.Routes(authWithVerifiedEmailMiddleware))
 		r.Mount("/reviews", review.Routes(reviewHandler, authWithVerifiedEmailMiddleware))
+		r.Mount("/integrations/webhooks", webhook.NewHandler(webhookService).Routes(authWithVerifiedEmailMiddleware))
+		r.Mount("/integrations/api-keys", apikey.NewHandler(apiKeyService).Routes(authWithVerifiedEmailMiddleware))
 		r.Mount("/faq", faqHandler.Routes())
 
 		// PhotoStudio booking integration
@@ -480,6 +737,7 @@ func main() {
 	})
 
 	r.Mount("/webhooks", paymentHandler.WebhookRoutes())
+	r.Mount("/webhooks/email", notification.NewEmailWebhookHandler(emailEventRepo, emailUnsubscribeSigner, cfg.SendGridWebhookKey).WebhookRoutes())
 	r.Mount("/api/v1/leads", leadHandler.PublicRoutes())
 
 	r.Route("/api/v1/admin", func(r chi.Router) {
@@ -493,6 +751,11 @@ func main() {
 
 		r.Mount("/leads", leadHandler.AdminRoutes(adminJWTService, adminService))
 		r.Mount("/users", userAdminHandler.Routes(adminJWTService, adminService))
+		r.Mount("/products", payment.NewProductHandler(paymentService, adminService).AdminRoutes(adminJWTService, adminService))
+		r.Mount("/promo-codes", payment.NewPromoHandler(paymentService, adminService).AdminRoutes(adminJWTService, adminService))
+		r.Mount("/payments", payment.NewRefundHandler(paymentService, adminService).AdminRoutes(adminJWTService, adminService))
+		r.Mount("/reconciliation", payment.NewReconcileHandler(paymentService, adminService).AdminRoutes(adminJWTService, adminService))
+		r.Mount("/notifications/cleanup", notification.NewCleanupHandler(notificationCleanupJob, adminService).AdminRoutes(adminJWTService, adminService))
 	})
 	rootHandler := middleware.Logger(middleware.Recover(r))
 	server := &http.Server{
@@ -516,6 +779,17 @@ func main() {
 
 	log.Info().Msg("Shutting down server...")
 	promoWorker.Stop()
+	analyticsWorker.Stop()
+	adminMetricsWorker.Stop()
+	webhookWorker.Stop()
+	promoTracker.Stop()
+	subscriptionLifecycleWorker.Stop()
+	subscriptionRenewalWorker.Stop()
+	paymentReconcileWorker.Stop()
+	ledgerWorker.Stop()
+	creditExpiryWorker.Stop()
+	notificationDispatcher.Stop()
+	stopNotificationCleanup()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -524,6 +798,14 @@ func main() {
 		log.Fatal().Err(err).Msg("Server forced to shutdown")
 	}
 
+	// Flush events emitted by the last requests
+	for _, x := range eventExporters {
+		x.Stop()
+	}
+	if eventBus != nil {
+		eventBus.Stop()
+	}
+
 	log.Info().Msg("Server exited properly")
 }
 
@@ -642,6 +924,47 @@ func (a *chatServiceAdapter) CreateOrGetRoom(ctx context.Context, userID uuid.UU
 	}, nil
 }
 
+// notificationResponseDecider adapts response.Service to notification.ResponseDecider
+type notificationResponseDecider struct {
+	service *response.Service
+}
+
+func (a *notificationResponseDecider) DecideResponse(ctx context.Context, userID, responseID uuid.UUID, accept bool) error {
+	status := response.StatusRejected
+	if accept {
+		status = response.StatusAccepted
+	}
+	_, err := a.service.UpdateStatus(ctx, userID, responseID, status)
+	switch {
+	case errors.Is(err, response.ErrResponseNotFound),
+		errors.Is(err, response.ErrCastingNotFound),
+		errors.Is(err, response.ErrNotCastingOwner),
+		errors.Is(err, response.ErrCastingNotActive),
+		errors.Is(err, response.ErrInvalidStatusTransition):
+		return fmt.Errorf("%w: %v", notification.ErrActionRejected, err)
+	}
+	return err
+}
+
+// notificationMessageReplier adapts chat.Service to notification.MessageReplier
+type notificationMessageReplier struct {
+	service *chat.Service
+}
+
+func (a *notificationMessageReplier) ReplyToRoom(ctx context.Context, userID, roomID uuid.UUID, text string) error {
+	_, err := a.service.SendMessage(ctx, userID, roomID, &chat.SendMessageRequest{Content: text, MessageType: "text"})
+	switch {
+	case errors.Is(err, chat.ErrRoomNotFound),
+		errors.Is(err, chat.ErrNotRoomMember),
+		errors.Is(err, chat.ErrUserBlocked),
+		errors.Is(err, chat.ErrUserBanned),
+		errors.Is(err, chat.ErrChatNotAvailable),
+		errors.Is(err, chat.ErrEmployerNotVerified):
+		return fmt.Errorf("%w: %v", notification.ErrActionRejected, err)
+	}
+	return err
+}
+
 // authEmployerProfileAdapter adapts profile.EmployerRepository to auth.EmployerProfileRepository
 type authEmployerProfileAdapter struct {
 	repo profile.EmployerRepository
@@ -740,8 +1063,14 @@ func (a *subscriptionPaymentAdapter) InitRobokassaPayment(ctx context.Context, r
 		SubscriptionID: req.SubscriptionID,
 		Amount:         req.Amount,
 		Description:    req.Description,
+		Plan:           string(req.PlanID),
+		PromoCode:      req.PromoCode,
+		Recurring:      req.Recurring,
 	})
 	if err != nil {
+		if payment.IsPromoRejection(err) {
+			return nil, fmt.Errorf("%w: %v", subscription.ErrPromoCodeRejected, err)
+		}
 		return nil, err
 	}
 	return &subscription.InitRobokassaPaymentResponse{
@@ -749,6 +1078,8 @@ func (a *subscriptionPaymentAdapter) InitRobokassaPayment(ctx context.Context, r
 		InvID:      out.InvID,
 		PaymentURL: out.PaymentURL,
 		Status:     out.Status,
+		Amount:     out.Amount,
+		Discount:   out.Discount,
 	}, nil
 }
 
@@ -41,6 +41,9 @@ type Config struct {
 	SendGridFromName       string
 	VerificationCodePepper string
 	AllowLegacyRefresh     bool
+	SendGridWebhookKey     string
+	EmailUnsubscribeURL    string
+	EmailUnsubscribeSecret string
 
 	// Robokassa Payment
 	PaymentMode                 string
@@ -52,8 +55,101 @@ type Config struct {
 	RobokassaIsTest             bool
 	RobokassaHashAlgorithm      string
 	RobokassaBaseURL            string
+	RobokassaPassword3          string // refund API
+	RobokassaRefundURL          string
+	RobokassaRefundStateURL     string
 	RobokassaFrontendSuccessURL string
 	RobokassaFrontendFailURL    string
+	RobokassaReceiptEnabled     bool
+	RobokassaReceiptSno         string
+	RobokassaReceiptTax         string
+	RobokassaOpStateURL         string
+	RobokassaRecurringURL       string
+
+	// Credit lots
+	CreditGrantExpiryDays     int // admin-granted credits expire after this many days; 0 keeps them
+	CreditExpiringSoonDays    int
+	CreditLowBalanceThreshold int
+	CreditExpiryInterval      time.Duration
+
+	// Promotion tracking
+	PromotionTrackingFlushInterval  time.Duration
+	PromotionImpressionDedupeWindow time.Duration // one impression per viewer and item within this window
+	PromotionClickDedupeWindow      time.Duration
+	PromotionCPM                    int64 // credits per 1000 served impressions
+	PromotionCPC                    int64 // credits per click
+	PromotionWorkerInterval         time.Duration
+	PromotionSponsoredSlots         int // sponsored slots injected per list page
+	PromotionFrequencyCap           int // times one viewer sees the same promotion within the window
+	PromotionFrequencyWindow        time.Duration
+
+	// Employer hiring analytics
+	EmployerAnalyticsInterval time.Duration // how often daily aggregates are rebuilt
+	EmployerAnalyticsWindow   time.Duration // trailing period re-aggregated on every run
+
+	// Admin business metrics
+	AdminMetricsRefreshInterval time.Duration // how often the materialized views are refreshed
+
+	// Product event stream
+	EventsEnabled        bool
+	EventsBatchSize      int
+	EventsFlushInterval  time.Duration
+	EventsExportInterval time.Duration
+	EventsFileDir        string // NDJSON export directory, empty disables the file sink
+	EventsFileMaxBytes   int64  // rotate NDJSON files beyond this size
+	EventsWebhookURL     string // empty disables the webhook sink
+	EventsWebhookSecret  string
+	EventsRedactRules    string // e.g. "email=hash,user.registered:role=drop"
+	EventsRedactSalt     string
+
+	// Outbound webhooks
+	WebhookWorkerInterval time.Duration
+	WebhookMaxAttempts    int
+	WebhookTimeout        time.Duration
+	WebhookAllowPrivate   bool // allow endpoints on internal addresses, for local development
+
+	// Organization API keys
+	APIKeyDefaultRateLimit int // requests per minute for keys created without a limit
+	APIKeyMaxRateLimit     int
+	APIKeyMaxPerOrg        int
+
+	// Rate limiting
+	RateLimitEnabled  bool
+	RateLimitPolicies string   // overrides and additions, e.g. "auth_login=20/1m,search=120/1m:ip:token_bucket"
+	TrustedProxies    []string // CIDRs of proxies whose X-Forwarded-For is trusted for the client IP
+
+	// Unified ledger
+	PaymentLedgerEnabled      bool // charge paid features through the ledger instead of wallet/credits directly
+	LedgerHoldTTL             time.Duration
+	LedgerMaintenanceInterval time.Duration
+
+	// Payment reconciliation
+	PaymentReconcileInterval    time.Duration
+	PaymentReconcileStaleAfter  time.Duration
+	PaymentReconcileGiveUpAfter time.Duration
+
+	// Invoices (seller requisites)
+	InvoiceSellerName    string
+	InvoiceSellerBIN     string
+	InvoiceSellerAddress string
+	InvoiceSellerBank    string
+	InvoiceSellerIBAN    string
+	InvoiceSellerBIK     string
+	InvoiceSellerKbe     string
+	InvoiceSellerEmail   string
+	InvoiceNumberPrefix  string
+	InvoiceVATRate       float64 // percent included in prices
+
+	// Subscriptions
+	SubscriptionLifecycleInterval time.Duration
+	SubscriptionGracePeriod       time.Duration
+	SubscriptionReminderDays      string
+
+	// Subscription auto-renewal
+	SubscriptionRenewalInterval       time.Duration
+	SubscriptionRenewalLead           time.Duration
+	SubscriptionRenewalRetryIntervals string // e.g. "24h,72h": waits after each failed charge
+	SubscriptionRenewalConfirmTimeout time.Duration
 
 	// PhotoStudio
 	PhotoStudioBaseURL        string
@@ -61,6 +157,16 @@ type Config struct {
 	PhotoStudioSyncEnabled    bool
 	PhotoStudioTimeoutSeconds int
 
+	// Notifications
+	NotificationCoalesceWindow  time.Duration
+	NotificationThrottles       string
+	NotificationDefaultTimezone string
+	NotificationCleanupInterval time.Duration
+	NotificationReadRetention   int // days
+	NotificationUnreadRetention int // days
+	NotificationTypeRetention   string
+	NotificationMaxPerUser      int
+
 	// Logging
 	LogLevel string
 }
@@ -102,6 +208,9 @@ func Load() *Config {
 		SendGridFromName:       getEnv("SENDGRID_FROM_NAME", "MWork"),
 		VerificationCodePepper: getEnv("VERIFICATION_CODE_PEPPER", "dev-only-change-me"),
 		AllowLegacyRefresh:     parseBool(getEnv("ALLOW_LEGACY_REFRESH", "false"), false),
+		SendGridWebhookKey:     getEnv("SENDGRID_WEBHOOK_PUBLIC_KEY", ""),
+		EmailUnsubscribeURL:    getEnv("EMAIL_UNSUBSCRIBE_URL", "http://localhost:8080/webhooks/email/unsubscribe"),
+		EmailUnsubscribeSecret: firstNonEmpty(getEnv("EMAIL_UNSUBSCRIBE_SECRET", ""), getEnv("JWT_SECRET", "super-secret-key-change-me")),
 
 		// Robokassa Payment
 		PaymentMode:                 getEnv("PAYMENT_MODE", "real"),
@@ -113,8 +222,101 @@ func Load() *Config {
 		RobokassaIsTest:             parseRobokassaTestFlag(firstNonEmpty(getEnv("ROBOKASSA_IS_TEST", ""), getEnv("ROBOKASSA_TEST_MODE", "0"))),
 		RobokassaHashAlgorithm:      strings.ToUpper(strings.TrimSpace(getEnv("ROBOKASSA_HASH_ALGORITHM", "SHA256"))),
 		RobokassaBaseURL:            getEnv("ROBOKASSA_BASE_URL", "https://auth.robokassa.kz/Merchant/Index.aspx"),
+		RobokassaPassword3:          firstNonEmpty(getEnv("ROBOKASSA_PASSWORD_3", ""), getEnv("ROBOKASSA_PASSWORD3", "")),
+		RobokassaRefundURL:          getEnv("ROBOKASSA_REFUND_URL", "https://services.robokassa.ru/RefundService/Refund/Create"),
+		RobokassaRefundStateURL:     getEnv("ROBOKASSA_REFUND_STATE_URL", "https://services.robokassa.ru/RefundService/Refund/GetState"),
 		RobokassaFrontendSuccessURL: getEnv("ROBOKASSA_FRONTEND_SUCCESS_URL", ""),
 		RobokassaFrontendFailURL:    getEnv("ROBOKASSA_FRONTEND_FAIL_URL", ""),
+		RobokassaReceiptEnabled:     parseBool(getEnv("ROBOKASSA_RECEIPT_ENABLED", "true"), true),
+		RobokassaReceiptSno:         getEnv("ROBOKASSA_RECEIPT_SNO", ""),
+		RobokassaReceiptTax:         getEnv("ROBOKASSA_RECEIPT_TAX", "none"),
+		RobokassaOpStateURL:         getEnv("ROBOKASSA_OPSTATE_URL", "https://auth.robokassa.ru/Merchant/WebService/Service.asmx/OpStateExt"),
+		RobokassaRecurringURL:       getEnv("ROBOKASSA_RECURRING_URL", "https://auth.robokassa.ru/Merchant/Recurring"),
+
+		// Credit lots
+		CreditGrantExpiryDays:     parseInt(getEnv("CREDIT_GRANT_EXPIRY_DAYS", "90"), 90),
+		CreditExpiringSoonDays:    parseInt(getEnv("CREDIT_EXPIRING_SOON_DAYS", "7"), 7),
+		CreditLowBalanceThreshold: parseInt(getEnv("CREDIT_LOW_BALANCE_THRESHOLD", "3"), 3),
+		CreditExpiryInterval:      parseDuration(getEnv("CREDIT_EXPIRY_INTERVAL", "1h")),
+
+		// Promotion tracking
+		PromotionTrackingFlushInterval:  parseDuration(getEnv("PROMOTION_TRACKING_FLUSH_INTERVAL", "30s")),
+		PromotionImpressionDedupeWindow: parseDuration(getEnv("PROMOTION_IMPRESSION_DEDUPE_WINDOW", "1h")),
+		PromotionClickDedupeWindow:      parseDuration(getEnv("PROMOTION_CLICK_DEDUPE_WINDOW", "24h")),
+		PromotionCPM:                    int64(parseInt(getEnv("PROMOTION_CPM", "50"), 50)),
+		PromotionCPC:                    int64(parseInt(getEnv("PROMOTION_CPC", "5"), 5)),
+		PromotionWorkerInterval:         parseDuration(getEnv("PROMOTION_WORKER_INTERVAL", "5m")),
+		PromotionSponsoredSlots:         parseInt(getEnv("PROMOTION_SPONSORED_SLOTS", "2"), 2),
+		PromotionFrequencyCap:           parseInt(getEnv("PROMOTION_FREQUENCY_CAP", "5"), 5),
+		PromotionFrequencyWindow:        parseDuration(getEnv("PROMOTION_FREQUENCY_WINDOW", "1h")),
+
+		// Employer hiring analytics
+		EmployerAnalyticsInterval: parseDuration(getEnv("EMPLOYER_ANALYTICS_INTERVAL", "1h")),
+		EmployerAnalyticsWindow:   parseDuration(getEnv("EMPLOYER_ANALYTICS_WINDOW", "840h")),
+
+		// Admin business metrics
+		AdminMetricsRefreshInterval: parseDuration(getEnv("ADMIN_METRICS_REFRESH_INTERVAL", "1h")),
+
+		// Product event stream
+		EventsEnabled:        parseBool(getEnv("EVENTS_ENABLED", "true"), true),
+		EventsBatchSize:      parseInt(getEnv("EVENTS_BATCH_SIZE", "200"), 200),
+		EventsFlushInterval:  parseDuration(getEnv("EVENTS_FLUSH_INTERVAL", "5s")),
+		EventsExportInterval: parseDuration(getEnv("EVENTS_EXPORT_INTERVAL", "30s")),
+		EventsFileDir:        getEnv("EVENTS_FILE_DIR", ""),
+		EventsFileMaxBytes:   int64(parseInt(getEnv("EVENTS_FILE_MAX_BYTES", "104857600"), 104857600)),
+		EventsWebhookURL:     getEnv("EVENTS_WEBHOOK_URL", ""),
+		EventsWebhookSecret:  getEnv("EVENTS_WEBHOOK_SECRET", ""),
+		EventsRedactRules:    getEnv("EVENTS_REDACT_RULES", "email=hash"),
+		EventsRedactSalt:     getEnv("EVENTS_REDACT_SALT", ""),
+
+		// Outbound webhooks
+		WebhookWorkerInterval: parseDuration(getEnv("WEBHOOK_WORKER_INTERVAL", "10s")),
+		WebhookMaxAttempts:    parseInt(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"), 8),
+		WebhookTimeout:        parseDuration(getEnv("WEBHOOK_TIMEOUT", "10s")),
+		WebhookAllowPrivate:   parseBool(getEnv("WEBHOOK_ALLOW_PRIVATE", "false"), false),
+
+		// Organization API keys
+		APIKeyDefaultRateLimit: parseInt(getEnv("API_KEY_DEFAULT_RATE_LIMIT", "120"), 120),
+		APIKeyMaxRateLimit:     parseInt(getEnv("API_KEY_MAX_RATE_LIMIT", "1200"), 1200),
+		APIKeyMaxPerOrg:        parseInt(getEnv("API_KEY_MAX_PER_ORG", "25"), 25),
+
+		// Rate limiting
+		RateLimitEnabled:  parseBool(getEnv("RATE_LIMIT_ENABLED", "true"), true),
+		RateLimitPolicies: getEnv("RATE_LIMIT_POLICIES", ""),
+		TrustedProxies:    parseStringSlice(getEnv("TRUSTED_PROXIES", "127.0.0.1/32,::1/128")),
+
+		// Unified ledger
+		PaymentLedgerEnabled:      parseBool(getEnv("PAYMENT_LEDGER_ENABLED", "false"), false),
+		LedgerHoldTTL:             parseDuration(getEnv("LEDGER_HOLD_TTL", "15m")),
+		LedgerMaintenanceInterval: parseDuration(getEnv("LEDGER_MAINTENANCE_INTERVAL", "1h")),
+
+		// Payment reconciliation
+		PaymentReconcileInterval:    parseDuration(getEnv("PAYMENT_RECONCILE_INTERVAL", "24h")),
+		PaymentReconcileStaleAfter:  parseDuration(getEnv("PAYMENT_RECONCILE_STALE_AFTER", "30m")),
+		PaymentReconcileGiveUpAfter: parseDuration(getEnv("PAYMENT_RECONCILE_GIVE_UP_AFTER", "72h")),
+
+		// Invoices
+		InvoiceSellerName:    getEnv("INVOICE_SELLER_NAME", "ТОО «MWork»"),
+		InvoiceSellerBIN:     getEnv("INVOICE_SELLER_BIN", ""),
+		InvoiceSellerAddress: getEnv("INVOICE_SELLER_ADDRESS", ""),
+		InvoiceSellerBank:    getEnv("INVOICE_SELLER_BANK", ""),
+		InvoiceSellerIBAN:    getEnv("INVOICE_SELLER_IBAN", ""),
+		InvoiceSellerBIK:     getEnv("INVOICE_SELLER_BIK", ""),
+		InvoiceSellerKbe:     getEnv("INVOICE_SELLER_KBE", ""),
+		InvoiceSellerEmail:   getEnv("INVOICE_SELLER_EMAIL", ""),
+		InvoiceNumberPrefix:  getEnv("INVOICE_NUMBER_PREFIX", "MW"),
+		InvoiceVATRate:       parseFloat(getEnv("INVOICE_VAT_RATE", "0"), 0),
+
+		// Subscriptions
+		SubscriptionLifecycleInterval: parseDuration(getEnv("SUBSCRIPTION_LIFECYCLE_INTERVAL", "1h")),
+		SubscriptionGracePeriod:       parseDuration(getEnv("SUBSCRIPTION_GRACE_PERIOD", "72h")),
+		SubscriptionReminderDays:      getEnv("SUBSCRIPTION_REMINDER_DAYS", "7,3,1"),
+
+		// Subscription auto-renewal
+		SubscriptionRenewalInterval:       parseDuration(getEnv("SUBSCRIPTION_RENEWAL_INTERVAL", "1h")),
+		SubscriptionRenewalLead:           parseDuration(getEnv("SUBSCRIPTION_RENEWAL_LEAD", "24h")),
+		SubscriptionRenewalRetryIntervals: getEnv("SUBSCRIPTION_RENEWAL_RETRY_INTERVALS", "24h,72h"),
+		SubscriptionRenewalConfirmTimeout: parseDuration(getEnv("SUBSCRIPTION_RENEWAL_CONFIRM_TIMEOUT", "1h")),
 
 		// PhotoStudio
 		PhotoStudioBaseURL:        getEnv("PHOTOSTUDIO_BASE_URL", ""),
@@ -122,6 +324,16 @@ func Load() *Config {
 		PhotoStudioSyncEnabled:    parseBool(getEnv("PHOTOSTUDIO_SYNC_ENABLED", "false"), false),
 		PhotoStudioTimeoutSeconds: parseInt(getEnv("PHOTOSTUDIO_TIMEOUT_SECONDS", "10"), 10),
 
+		// Notifications
+		NotificationCoalesceWindow:  parseDuration(getEnv("NOTIFICATION_COALESCE_WINDOW", "15m")),
+		NotificationThrottles:       getEnv("NOTIFICATION_THROTTLES", ""),
+		NotificationDefaultTimezone: getEnv("NOTIFICATION_DEFAULT_TIMEZONE", "Asia/Almaty"),
+		NotificationCleanupInterval: parseDuration(getEnv("NOTIFICATION_CLEANUP_INTERVAL", "6h")),
+		NotificationReadRetention:   parseInt(getEnv("NOTIFICATION_READ_RETENTION_DAYS", "90"), 90),
+		NotificationUnreadRetention: parseInt(getEnv("NOTIFICATION_UNREAD_RETENTION_DAYS", "180"), 180),
+		NotificationTypeRetention:   getEnv("NOTIFICATION_TYPE_RETENTION", ""),
+		NotificationMaxPerUser:      parseInt(getEnv("NOTIFICATION_MAX_PER_USER", "1000"), 1000),
+
 		// Logging
 		LogLevel: getEnv("LOG_LEVEL", "debug"),
 	}
@@ -158,6 +370,14 @@ func parseInt(s string, defaultValue int) int {
 	return value
 }
 
+func parseFloat(s string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func parseStringSlice(s string) []string {
 	if s == "" {
 		return []string{}
@@ -151,6 +151,7 @@ func (h *CreditHandler) GrantCredits(w http.ResponseWriter, r *http.Request) {
 
 // GetUserCredits handles GET /admin/users/{id}/credits
 // @Summary Баланс кредитов пользователя
+// @Description Баланс с разбивкой по партиям (lots): источник, остаток и срок сгорания. Списание идет с партий, сгорающих раньше.
 // @Tags Admin Credits
 // @Produce json
 // @Security BearerAuth
@@ -167,8 +168,7 @@ func (h *CreditHandler) GetUserCredits(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get balance
-	balance, err := h.creditService.GetBalance(r.Context(), userID)
+	breakdown, err := h.creditService.GetLotBreakdown(r.Context(), userID)
 	if err != nil {
 		if err == credit.ErrUserNotFound {
 			response.NotFound(w, "User not found")
@@ -179,7 +179,11 @@ func (h *CreditHandler) GetUserCredits(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.OK(w, map[string]interface{}{
-		"user_id": userID,
-		"balance": balance,
+		"user_id":       userID,
+		"balance":       breakdown.Balance,
+		"untracked":     breakdown.Untracked,
+		"expiring_soon": breakdown.ExpiringSoon,
+		"next_expiry":   breakdown.NextExpiry,
+		"lots":          breakdown.Lots,
 	})
 }
@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/pkg/events"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// EventStream is the product event store as seen by admins
+type EventStream interface {
+	Checkpoints(ctx context.Context) ([]events.Checkpoint, int64, error)
+	Replay(ctx context.Context, sink string, fromSeq int64) error
+}
+
+// EventSink is the export position of one sink
+type EventSink struct {
+	events.Checkpoint
+	Pending int64 `json:"pending"` // events written but not yet exported
+}
+
+// EventStreamStatus is the head of the event log and the position of each sink
+type EventStreamStatus struct {
+	HeadSeq int64       `json:"head_seq"`
+	Sinks   []EventSink `json:"sinks"`
+}
+
+// ReplayEventsRequest moves a sink back to re-export events after from_seq
+type ReplayEventsRequest struct {
+	FromSeq int64  `json:"from_seq"`
+	Reason  string `json:"reason"`
+}
+
+// EventsHandler serves the product event stream export status and replay
+type EventsHandler struct {
+	stream       EventStream
+	auditService *Service
+}
+
+// NewEventsHandler creates event stream handler
+func NewEventsHandler(stream EventStream, auditService *Service) *EventsHandler {
+	return &EventsHandler{stream: stream, auditService: auditService}
+}
+
+// Status returns the export checkpoint of every sink
+// @Summary Статус экспорта событий
+// @Tags Admin Analytics
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=EventStreamStatus}
+// @Failure 500 {object} response.Response
+// @Router /admin/analytics/events/sinks [get]
+func (h *EventsHandler) Status(w http.ResponseWriter, r *http.Request) {
+	checkpoints, head, err := h.stream.Checkpoints(r.Context())
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	status := EventStreamStatus{HeadSeq: head, Sinks: make([]EventSink, 0, len(checkpoints))}
+	for _, cp := range checkpoints {
+		status.Sinks = append(status.Sinks, EventSink{Checkpoint: cp, Pending: max(head-cp.LastSeq, 0)})
+	}
+	response.OK(w, status)
+}
+
+// Replay re-exports events to a sink starting after from_seq
+// @Summary Повторная выгрузка событий
+// @Tags Admin Analytics
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param sink path string true "Приемник: file, webhook"
+// @Param request body ReplayEventsRequest true "Позиция"
+// @Success 204
+// @Failure 400,404,500 {object} response.Response
+// @Router /admin/analytics/events/sinks/{sink}/replay [post]
+func (h *EventsHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	var req ReplayEventsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+	if req.FromSeq < 0 {
+		response.BadRequest(w, "from_seq must not be negative")
+		return
+	}
+
+	sink := chi.URLParam(r, "sink")
+	if err := h.stream.Replay(r.Context(), sink, req.FromSeq); err != nil {
+		if errors.Is(err, events.ErrUnknownSink) {
+			response.NotFound(w, "Event sink not found")
+			return
+		}
+		response.InternalError(w)
+		return
+	}
+
+	if h.auditService != nil {
+		h.auditService.LogActionWithReason(r.Context(), GetAdminID(r.Context()), "events.replay", "event_sink", uuid.Nil, req.Reason,
+			nil, map[string]any{"sink": sink, "from_seq": req.FromSeq})
+	}
+	response.NoContent(w)
+}
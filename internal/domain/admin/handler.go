@@ -19,6 +19,8 @@ type Handler struct {
 	jwtSvc             *JWTService
 	photoStudioHandler *PhotoStudioHandler
 	creditHandler      *CreditHandler // ✅ FIXED: Added credit handler
+	metricsHandler     *MetricsHandler
+	eventsHandler      *EventsHandler
 }
 
 // NewHandler creates admin handler
@@ -31,6 +33,16 @@ func NewHandler(service *Service, jwtSvc *JWTService, photoStudioHandler *PhotoS
 	}
 }
 
+// SetMetricsHandler enables the business metrics endpoints under /analytics
+func (h *Handler) SetMetricsHandler(m *MetricsHandler) {
+	h.metricsHandler = m
+}
+
+// SetEventsHandler enables the event stream export endpoints under /analytics/events
+func (h *Handler) SetEventsHandler(e *EventsHandler) {
+	h.eventsHandler = e
+}
+
 // ResyncPhotoStudioUsers handles POST /admin/photostudio/resync
 // @Summary Ресинхронизация пользователей с PhotoStudio
 // @Tags Admin PhotoStudio
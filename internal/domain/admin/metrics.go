@@ -0,0 +1,489 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// metricsLockKey is the pg advisory lock key guarding materialized view refreshes
+const metricsLockKey int64 = 0x61646d5f6d657472 // "adm_metr"
+
+// ErrMetricsRefreshInProgress is returned when another instance is refreshing the views
+var ErrMetricsRefreshInProgress = errors.New("analytics refresh already running")
+
+// metricsViews are refreshed in this order
+var metricsViews = []string{
+	"mv_admin_registration_cohorts",
+	"mv_admin_cohort_retention",
+	"mv_admin_subscription_monthly",
+	"mv_admin_revenue_monthly",
+	"mv_admin_casting_liquidity",
+}
+
+// Revenue product types
+const (
+	ProductSubscription = "subscription"
+	ProductConnects     = "connects"
+	ProductCredits      = "credits"
+	ProductPromotions   = "promotions"
+	ProductOther        = "other"
+)
+
+// ViewRefresh is the last refresh of one materialized view
+type ViewRefresh struct {
+	ViewName    string    `json:"view_name" db:"view_name"`
+	RefreshedAt time.Time `json:"refreshed_at" db:"refreshed_at"`
+	DurationMs  int       `json:"duration_ms" db:"duration_ms"`
+}
+
+// Cohort is one weekly registration cohort with its retention curve
+type Cohort struct {
+	CohortWeek        time.Time `json:"cohort_week" db:"cohort_week"`
+	Role              string    `json:"role" db:"role"`
+	Registered        int       `json:"registered" db:"registered"`
+	Converted         int       `json:"converted" db:"converted"`
+	AvgDaysToConvert  float64   `json:"avg_days_to_convert" db:"avg_days_to_convert"`
+	Retention         []float64 `json:"retention" db:"-"` // percent active in week N after registration, N = index
+	ConversionPercent float64   `json:"conversion_percent" db:"-"`
+}
+
+// RetentionRow is the number of a cohort's users active N weeks after registering
+type RetentionRow struct {
+	CohortWeek  time.Time `db:"cohort_week"`
+	Role        string    `db:"role"`
+	WeekOffset  int       `db:"week_offset"`
+	ActiveUsers int       `db:"active_users"`
+}
+
+// AudienceConversion is free-to-paid conversion of one audience
+type AudienceConversion struct {
+	Audience         string  `json:"audience" db:"audience"`
+	Registered       int     `json:"registered" db:"registered"`
+	Converted        int     `json:"converted" db:"converted"`
+	Percent          float64 `json:"conversion_percent" db:"-"`
+	AvgDaysToConvert float64 `json:"avg_days_to_convert" db:"avg_days_to_convert"`
+}
+
+// SubscriptionMonth is paid subscription health in one month
+type SubscriptionMonth struct {
+	Month       time.Time `json:"month" db:"month"`
+	Audience    string    `json:"audience" db:"audience"`
+	ActiveStart int       `json:"active_start" db:"active_start"`
+	ActiveEnd   int       `json:"active_end" db:"active_end"`
+	NewPaid     int       `json:"new_paid" db:"new_paid"`
+	Churned     int       `json:"churned" db:"churned"`
+	MRR         float64   `json:"mrr" db:"mrr"`
+	ChurnRate   float64   `json:"churn_rate" db:"-"` // percent of users active at month start
+}
+
+// SubscriptionMetrics is MRR, ARR and churn derived from subscriptions
+type SubscriptionMetrics struct {
+	MRR           float64             `json:"mrr"`
+	ARR           float64             `json:"arr"`
+	ActivePaid    int                 `json:"active_paid"`
+	LastChurnRate float64             `json:"last_month_churn_rate"`
+	Months        []SubscriptionMonth `json:"months"`
+}
+
+// RevenueRow is revenue of one product in one month
+type RevenueRow struct {
+	Month    time.Time `json:"month" db:"month"`
+	Product  string    `json:"product" db:"product"`
+	Payments int       `json:"payments" db:"payments"`
+	Gross    float64   `json:"gross" db:"gross"`
+	Refunded float64   `json:"refunded" db:"refunded"`
+	Net      float64   `json:"net" db:"-"`
+}
+
+// RevenueBreakdown is revenue by product type
+type RevenueBreakdown struct {
+	Totals map[string]float64 `json:"totals"` // net revenue per product over the period
+	Net    float64            `json:"net"`
+	Months []RevenueRow       `json:"months"`
+}
+
+// LiquidityMonth is casting liquidity of castings published in one month
+type LiquidityMonth struct {
+	Month                  time.Time `json:"month" db:"month"`
+	Published              int       `json:"published" db:"published"`
+	WithResponse           int       `json:"with_response" db:"with_response"`
+	AvgHoursToFirstResp    float64   `json:"avg_hours_to_first_response" db:"avg_hours_to_first_response"`
+	MedianHoursToFirstResp float64   `json:"median_hours_to_first_response" db:"median_hours_to_first_response"`
+	Filled                 int       `json:"filled" db:"filled"`
+	ResponseRate           float64   `json:"response_rate" db:"-"` // percent of castings with at least one response
+	FillRate               float64   `json:"fill_rate" db:"-"`
+}
+
+// MetricsRepository reads and refreshes the business metrics materialized views
+type MetricsRepository struct {
+	db *sqlx.DB
+}
+
+// NewMetricsRepository creates business metrics repository
+func NewMetricsRepository(db *sqlx.DB) *MetricsRepository {
+	return &MetricsRepository{db: db}
+}
+
+// Refresh refreshes every metrics view. Only one instance refreshes at a time.
+func (r *MetricsRepository) Refresh(ctx context.Context) ([]ViewRefresh, error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1)`, metricsLockKey); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrMetricsRefreshInProgress
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, metricsLockKey); err != nil {
+			log.Warn().Err(err).Msg("Failed to release analytics refresh lock")
+		}
+	}()
+
+	refreshed := make([]ViewRefresh, 0, len(metricsViews))
+	for _, view := range metricsViews {
+		started := time.Now()
+		// Views are fixed identifiers from metricsViews, never user input
+		if _, err := conn.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY `+view); err != nil {
+			return refreshed, err
+		}
+		rf := ViewRefresh{ViewName: view, RefreshedAt: time.Now(), DurationMs: int(time.Since(started).Milliseconds())}
+		_, err := conn.ExecContext(ctx, `
+			INSERT INTO admin_analytics_refreshes (view_name, refreshed_at, duration_ms)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (view_name) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at, duration_ms = EXCLUDED.duration_ms
+		`, rf.ViewName, rf.RefreshedAt, rf.DurationMs)
+		if err != nil {
+			return refreshed, err
+		}
+		refreshed = append(refreshed, rf)
+	}
+	return refreshed, nil
+}
+
+// ListRefreshes returns the last refresh of each view
+func (r *MetricsRepository) ListRefreshes(ctx context.Context) ([]ViewRefresh, error) {
+	refreshes := []ViewRefresh{}
+	err := r.db.SelectContext(ctx, &refreshes, `SELECT view_name, refreshed_at, duration_ms FROM admin_analytics_refreshes ORDER BY view_name`)
+	return refreshes, err
+}
+
+// ListCohorts returns registration cohorts since the given week, optionally for one role
+func (r *MetricsRepository) ListCohorts(ctx context.Context, since time.Time, role string) ([]Cohort, error) {
+	cohorts := []Cohort{}
+	err := r.db.SelectContext(ctx, &cohorts, `
+		SELECT cohort_week, role, registered, converted, avg_days_to_convert
+		FROM mv_admin_registration_cohorts
+		WHERE cohort_week >= $1 AND ($2 = '' OR role = $2)
+		ORDER BY cohort_week, role
+	`, since, role)
+	return cohorts, err
+}
+
+// ListRetention returns active users per cohort and week offset
+func (r *MetricsRepository) ListRetention(ctx context.Context, since time.Time, role string) ([]RetentionRow, error) {
+	var rows []RetentionRow
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT cohort_week, role, week_offset, active_users
+		FROM mv_admin_cohort_retention
+		WHERE cohort_week >= $1 AND ($2 = '' OR role = $2)
+	`, since, role)
+	return rows, err
+}
+
+// ConversionByAudience sums free-to-paid conversion of cohorts since the given week
+func (r *MetricsRepository) ConversionByAudience(ctx context.Context, since time.Time) ([]AudienceConversion, error) {
+	conversions := []AudienceConversion{}
+	err := r.db.SelectContext(ctx, &conversions, `
+		SELECT audience, SUM(registered) AS registered, SUM(converted) AS converted,
+			COALESCE(SUM(avg_days_to_convert * converted) / NULLIF(SUM(converted), 0), 0) AS avg_days_to_convert
+		FROM mv_admin_registration_cohorts
+		WHERE cohort_week >= $1
+		GROUP BY audience
+		ORDER BY audience
+	`, since)
+	return conversions, err
+}
+
+// ListSubscriptionMonths returns paid subscription health per month and audience
+func (r *MetricsRepository) ListSubscriptionMonths(ctx context.Context, since time.Time) ([]SubscriptionMonth, error) {
+	months := []SubscriptionMonth{}
+	err := r.db.SelectContext(ctx, &months, `
+		SELECT month, audience, active_start, active_end, new_paid, churned, mrr
+		FROM mv_admin_subscription_monthly
+		WHERE month >= $1
+		ORDER BY month, audience
+	`, since)
+	return months, err
+}
+
+// ListRevenue returns revenue per month and product
+func (r *MetricsRepository) ListRevenue(ctx context.Context, since time.Time) ([]RevenueRow, error) {
+	rows := []RevenueRow{}
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT month, product, payments, gross, refunded
+		FROM mv_admin_revenue_monthly
+		WHERE month >= $1
+		ORDER BY month, product
+	`, since)
+	return rows, err
+}
+
+// ListLiquidity returns casting liquidity per publication month
+func (r *MetricsRepository) ListLiquidity(ctx context.Context, since time.Time) ([]LiquidityMonth, error) {
+	months := []LiquidityMonth{}
+	err := r.db.SelectContext(ctx, &months, `
+		SELECT month, published, with_response, avg_hours_to_first_response, median_hours_to_first_response, filled
+		FROM mv_admin_casting_liquidity
+		WHERE month >= $1
+		ORDER BY month
+	`, since)
+	return months, err
+}
+
+// MetricsService builds management business metrics from the materialized views
+type MetricsService struct {
+	repo *MetricsRepository
+}
+
+// NewMetricsService creates business metrics service
+func NewMetricsService(repo *MetricsRepository) *MetricsService {
+	return &MetricsService{repo: repo}
+}
+
+// Refresh refreshes the materialized views now
+func (s *MetricsService) Refresh(ctx context.Context) ([]ViewRefresh, error) {
+	return s.repo.Refresh(ctx)
+}
+
+// Refreshes returns when each view was last refreshed
+func (s *MetricsService) Refreshes(ctx context.Context) ([]ViewRefresh, error) {
+	return s.repo.ListRefreshes(ctx)
+}
+
+// Cohorts returns weekly registration cohorts of the last weeks with retention curves
+func (s *MetricsService) Cohorts(ctx context.Context, weeks int, role string) ([]Cohort, error) {
+	since := weeksAgo(time.Now(), weeks)
+	cohorts, err := s.repo.ListCohorts(ctx, since, role)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ListRetention(ctx, since, role)
+	if err != nil {
+		return nil, err
+	}
+	attachRetention(cohorts, rows, time.Now())
+	return cohorts, nil
+}
+
+// Conversion returns free-to-paid conversion by audience for cohorts of the last weeks
+func (s *MetricsService) Conversion(ctx context.Context, weeks int) ([]AudienceConversion, error) {
+	conversions, err := s.repo.ConversionByAudience(ctx, weeksAgo(time.Now(), weeks))
+	if err != nil {
+		return nil, err
+	}
+	for i := range conversions {
+		conversions[i].Percent = percentOf(conversions[i].Converted, conversions[i].Registered)
+	}
+	return conversions, nil
+}
+
+// Subscriptions returns MRR, ARR and churn of the last months
+func (s *MetricsService) Subscriptions(ctx context.Context, months int) (*SubscriptionMetrics, error) {
+	rows, err := s.repo.ListSubscriptionMonths(ctx, monthsAgo(time.Now(), months))
+	if err != nil {
+		return nil, err
+	}
+	return summarizeSubscriptions(rows), nil
+}
+
+// Revenue returns revenue by product type for the last months
+func (s *MetricsService) Revenue(ctx context.Context, months int) (*RevenueBreakdown, error) {
+	rows, err := s.repo.ListRevenue(ctx, monthsAgo(time.Now(), months))
+	if err != nil {
+		return nil, err
+	}
+	return summarizeRevenue(rows), nil
+}
+
+// Liquidity returns casting liquidity for the last months
+func (s *MetricsService) Liquidity(ctx context.Context, months int) ([]LiquidityMonth, error) {
+	rows, err := s.repo.ListLiquidity(ctx, monthsAgo(time.Now(), months))
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].ResponseRate = percentOf(rows[i].WithResponse, rows[i].Published)
+		rows[i].FillRate = percentOf(rows[i].Filled, rows[i].Published)
+		rows[i].AvgHoursToFirstResp = round2(rows[i].AvgHoursToFirstResp)
+		rows[i].MedianHoursToFirstResp = round2(rows[i].MedianHoursToFirstResp)
+	}
+	return rows, nil
+}
+
+// attachRetention builds each cohort's retention curve up to the current week
+func attachRetention(cohorts []Cohort, rows []RetentionRow, now time.Time) {
+	type key struct {
+		week time.Time
+		role string
+	}
+	active := make(map[key]map[int]int)
+	for _, row := range rows {
+		k := key{row.CohortWeek.UTC(), row.Role}
+		if active[k] == nil {
+			active[k] = make(map[int]int)
+		}
+		active[k][row.WeekOffset] = row.ActiveUsers
+	}
+	for i := range cohorts {
+		c := &cohorts[i]
+		c.ConversionPercent = percentOf(c.Converted, c.Registered)
+		c.AvgDaysToConvert = round2(c.AvgDaysToConvert)
+		elapsed := int(now.Sub(c.CohortWeek).Hours() / (24 * 7))
+		c.Retention = make([]float64, elapsed+1)
+		for offset := range c.Retention {
+			c.Retention[offset] = percentOf(active[key{c.CohortWeek.UTC(), c.Role}][offset], c.Registered)
+		}
+	}
+}
+
+// summarizeSubscriptions derives churn rates and the current MRR and ARR
+func summarizeSubscriptions(rows []SubscriptionMonth) *SubscriptionMetrics {
+	out := &SubscriptionMetrics{Months: rows}
+	var last time.Time
+	for i := range rows {
+		rows[i].ChurnRate = percentOf(rows[i].Churned, rows[i].ActiveStart)
+		rows[i].MRR = round2(rows[i].MRR)
+		if rows[i].Month.After(last) {
+			last = rows[i].Month
+		}
+	}
+	// Current MRR is the latest month; churn is reported for the last complete month
+	prev := last.AddDate(0, -1, 0)
+	var churned, activeStart int
+	for _, m := range rows {
+		switch {
+		case m.Month.Equal(last):
+			out.MRR += m.MRR
+			out.ActivePaid += m.ActiveEnd
+		case m.Month.Equal(prev):
+			churned += m.Churned
+			activeStart += m.ActiveStart
+		}
+	}
+	out.MRR = round2(out.MRR)
+	out.ARR = round2(out.MRR * 12)
+	out.LastChurnRate = percentOf(churned, activeStart)
+	return out
+}
+
+// summarizeRevenue adds net revenue and per-product totals
+func summarizeRevenue(rows []RevenueRow) *RevenueBreakdown {
+	out := &RevenueBreakdown{
+		Totals: map[string]float64{ProductSubscription: 0, ProductConnects: 0, ProductCredits: 0, ProductPromotions: 0},
+		Months: rows,
+	}
+	for i := range rows {
+		rows[i].Net = round2(rows[i].Gross - rows[i].Refunded)
+		out.Totals[rows[i].Product] = round2(out.Totals[rows[i].Product] + rows[i].Net)
+		out.Net += rows[i].Net
+	}
+	out.Net = round2(out.Net)
+	sort.SliceStable(out.Months, func(i, j int) bool { return out.Months[i].Month.Before(out.Months[j].Month) })
+	return out
+}
+
+func weeksAgo(now time.Time, weeks int) time.Time {
+	return now.AddDate(0, 0, -7*weeks)
+}
+
+func monthsAgo(now time.Time, months int) time.Time {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start.AddDate(0, -(months - 1), 0)
+}
+
+func percentOf(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return round2(float64(part) / float64(whole) * 100)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// MetricsRefreshWorker refreshes the business metrics views periodically
+type MetricsRefreshWorker struct {
+	service  *MetricsService
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewMetricsRefreshWorker creates a new metrics refresh worker
+func NewMetricsRefreshWorker(service *MetricsService, interval time.Duration) *MetricsRefreshWorker {
+	if interval == 0 {
+		interval = time.Hour
+	}
+	return &MetricsRefreshWorker{
+		service:  service,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the background worker
+func (w *MetricsRefreshWorker) Start() {
+	log.Info().Msg("Starting admin metrics refresh worker...")
+	go w.loop()
+}
+
+// Stop gracefully stops the background worker
+func (w *MetricsRefreshWorker) Stop() {
+	log.Info().Msg("Stopping admin metrics refresh worker...")
+	close(w.stopCh)
+}
+
+func (w *MetricsRefreshWorker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run once immediately on startup
+	w.run()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.run()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *MetricsRefreshWorker) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	refreshed, err := w.service.Refresh(ctx)
+	if errors.Is(err, ErrMetricsRefreshInProgress) {
+		log.Debug().Msg("Admin metrics refresh skipped: another instance is refreshing")
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Admin metrics refresh failed")
+		return
+	}
+	log.Info().Int("views", len(refreshed)).Msg("Admin metrics refreshed")
+}
@@ -0,0 +1,173 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// MetricsHandler serves management business metrics
+type MetricsHandler struct {
+	service      *MetricsService
+	auditService *Service
+}
+
+// NewMetricsHandler creates business metrics handler
+func NewMetricsHandler(service *MetricsService, auditService *Service) *MetricsHandler {
+	return &MetricsHandler{service: service, auditService: auditService}
+}
+
+// queryInt parses a positive integer query parameter capped at max
+func queryInt(r *http.Request, name string, def, max int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || v <= 0 {
+		return def
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// Cohorts returns weekly registration cohorts with retention curves
+// @Summary Когорты регистраций и удержание
+// @Tags Admin Analytics
+// @Produce json
+// @Security BearerAuth
+// @Param weeks query int false "Количество недель (по умолчанию 12, до 52)"
+// @Param role query string false "Роль: model, employer, agency"
+// @Success 200 {object} response.Response{data=[]Cohort}
+// @Failure 500 {object} response.Response
+// @Router /admin/analytics/cohorts [get]
+func (h *MetricsHandler) Cohorts(w http.ResponseWriter, r *http.Request) {
+	role := r.URL.Query().Get("role")
+	if role != "" && role != "model" && role != "employer" && role != "agency" {
+		response.BadRequest(w, "Invalid role")
+		return
+	}
+
+	cohorts, err := h.service.Cohorts(r.Context(), queryInt(r, "weeks", 12, 52), role)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, cohorts)
+}
+
+// Conversion returns free-to-paid conversion by audience
+// @Summary Конверсия в платные тарифы
+// @Tags Admin Analytics
+// @Produce json
+// @Security BearerAuth
+// @Param weeks query int false "Когорты за последние недели (по умолчанию 12, до 52)"
+// @Success 200 {object} response.Response{data=[]AudienceConversion}
+// @Failure 500 {object} response.Response
+// @Router /admin/analytics/conversion [get]
+func (h *MetricsHandler) Conversion(w http.ResponseWriter, r *http.Request) {
+	conversions, err := h.service.Conversion(r.Context(), queryInt(r, "weeks", 12, 52))
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, conversions)
+}
+
+// Subscriptions returns MRR, ARR and churn
+// @Summary MRR, ARR и отток подписок
+// @Tags Admin Analytics
+// @Produce json
+// @Security BearerAuth
+// @Param months query int false "Количество месяцев (по умолчанию 12, до 24)"
+// @Success 200 {object} response.Response{data=SubscriptionMetrics}
+// @Failure 500 {object} response.Response
+// @Router /admin/analytics/subscriptions [get]
+func (h *MetricsHandler) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	metrics, err := h.service.Subscriptions(r.Context(), queryInt(r, "months", 12, 24))
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, metrics)
+}
+
+// RevenueByProduct returns revenue by product type
+// @Summary Выручка по типам продуктов
+// @Tags Admin Analytics
+// @Produce json
+// @Security BearerAuth
+// @Param months query int false "Количество месяцев (по умолчанию 12, до 24)"
+// @Success 200 {object} response.Response{data=RevenueBreakdown}
+// @Failure 500 {object} response.Response
+// @Router /admin/analytics/revenue/products [get]
+func (h *MetricsHandler) RevenueByProduct(w http.ResponseWriter, r *http.Request) {
+	breakdown, err := h.service.Revenue(r.Context(), queryInt(r, "months", 12, 24))
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, breakdown)
+}
+
+// Liquidity returns casting liquidity: time to first response and fill rate
+// @Summary Ликвидность кастингов
+// @Tags Admin Analytics
+// @Produce json
+// @Security BearerAuth
+// @Param months query int false "Количество месяцев (по умолчанию 12, до 24)"
+// @Success 200 {object} response.Response{data=[]LiquidityMonth}
+// @Failure 500 {object} response.Response
+// @Router /admin/analytics/liquidity [get]
+func (h *MetricsHandler) Liquidity(w http.ResponseWriter, r *http.Request) {
+	months, err := h.service.Liquidity(r.Context(), queryInt(r, "months", 12, 24))
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, months)
+}
+
+// RefreshStatus returns when each analytics view was last refreshed
+// @Summary Статус обновления аналитики
+// @Tags Admin Analytics
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]ViewRefresh}
+// @Failure 500 {object} response.Response
+// @Router /admin/analytics/refresh [get]
+func (h *MetricsHandler) RefreshStatus(w http.ResponseWriter, r *http.Request) {
+	refreshes, err := h.service.Refreshes(r.Context())
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, refreshes)
+}
+
+// Refresh refreshes the analytics views now
+// @Summary Обновить аналитику
+// @Tags Admin Analytics
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]ViewRefresh}
+// @Failure 409,500 {object} response.Response
+// @Router /admin/analytics/refresh [post]
+func (h *MetricsHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	refreshed, err := h.service.Refresh(r.Context())
+	if errors.Is(err, ErrMetricsRefreshInProgress) {
+		response.Error(w, http.StatusConflict, "REFRESH_IN_PROGRESS", "Analytics refresh is already running")
+		return
+	}
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	if h.auditService != nil {
+		h.auditService.LogActionWithReason(r.Context(), GetAdminID(r.Context()), "analytics.refresh", "analytics", uuid.Nil, "", nil, nil)
+	}
+	response.OK(w, refreshed)
+}
@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttachRetentionBuildsCurveToCurrentWeek(t *testing.T) {
+	week := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	cohorts := []Cohort{{CohortWeek: week, Role: "model", Registered: 40, Converted: 2}}
+	rows := []RetentionRow{
+		{CohortWeek: week, Role: "model", WeekOffset: 0, ActiveUsers: 40},
+		{CohortWeek: week, Role: "model", WeekOffset: 1, ActiveUsers: 10},
+		{CohortWeek: week, Role: "employer", WeekOffset: 1, ActiveUsers: 99},
+	}
+
+	attachRetention(cohorts, rows, week.AddDate(0, 0, 15))
+
+	got := cohorts[0].Retention
+	if len(got) != 3 || got[0] != 100 || got[1] != 25 || got[2] != 0 {
+		t.Fatalf("unexpected retention curve %v", got)
+	}
+	if cohorts[0].ConversionPercent != 5 {
+		t.Fatalf("expected 5%% conversion, got %v", cohorts[0].ConversionPercent)
+	}
+}
+
+func TestSummarizeSubscriptions(t *testing.T) {
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	metrics := summarizeSubscriptions([]SubscriptionMonth{
+		{Month: feb, Audience: "model", ActiveStart: 50, ActiveEnd: 48, Churned: 5, MRR: 191520},
+		{Month: feb, Audience: "employer", ActiveStart: 10, ActiveEnd: 10, Churned: 1, MRR: 149900},
+		{Month: mar, Audience: "model", ActiveStart: 48, ActiveEnd: 52, Churned: 1, MRR: 207480},
+		{Month: mar, Audience: "employer", ActiveStart: 10, ActiveEnd: 11, MRR: 164890},
+	})
+
+	if metrics.MRR != 372370 || metrics.ARR != 372370*12 || metrics.ActivePaid != 63 {
+		t.Fatalf("unexpected current totals %+v", metrics)
+	}
+	if metrics.LastChurnRate != 10 {
+		t.Fatalf("expected 10%% churn for February, got %v", metrics.LastChurnRate)
+	}
+	if metrics.Months[0].ChurnRate != 10 {
+		t.Fatalf("expected per-month churn rate, got %v", metrics.Months[0].ChurnRate)
+	}
+}
+
+func TestSummarizeRevenueNetsRefunds(t *testing.T) {
+	month := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	breakdown := summarizeRevenue([]RevenueRow{
+		{Month: month, Product: ProductSubscription, Gross: 10000, Refunded: 1000},
+		{Month: month, Product: ProductCredits, Gross: 2500},
+	})
+
+	if breakdown.Totals[ProductSubscription] != 9000 || breakdown.Totals[ProductCredits] != 2500 {
+		t.Fatalf("unexpected totals %v", breakdown.Totals)
+	}
+	if _, ok := breakdown.Totals[ProductPromotions]; !ok {
+		t.Fatalf("expected every product type in totals")
+	}
+	if breakdown.Net != 11500 {
+		t.Fatalf("expected 11500 net, got %v", breakdown.Net)
+	}
+}
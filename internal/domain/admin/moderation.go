@@ -601,6 +601,11 @@ func (h *ModerationHandler) UpgradeUser(w http.ResponseWriter, r *http.Request)
 	expiresAt := now.AddDate(0, 0, req.Days)
 
 	// Cancel existing active subscription
+	h.db.ExecContext(r.Context(),
+		`INSERT INTO subscription_history (id, subscription_id, user_id, event, from_status, to_status, from_plan, to_plan, reason, actor_id, created_at)
+		 SELECT gen_random_uuid(), id, user_id, 'cancelled', status, 'cancelled', plan_id, plan_id, $2, $3, NOW()
+		 FROM subscriptions WHERE user_id = $1 AND status = 'active'`,
+		userID, "Replaced by admin grant", adminID)
 	h.db.ExecContext(r.Context(), `UPDATE subscriptions SET status = 'cancelled' WHERE user_id = $1 AND status = 'active'`, userID)
 
 	// Create new subscription
@@ -613,6 +618,10 @@ func (h *ModerationHandler) UpgradeUser(w http.ResponseWriter, r *http.Request)
 		response.InternalError(w)
 		return
 	}
+	h.db.ExecContext(r.Context(),
+		`INSERT INTO subscription_history (id, subscription_id, user_id, event, to_status, to_plan, reason, actor_id, created_at)
+		 VALUES ($1, $2, $3, 'admin_granted', 'active', $4, $5, $6, $7)`,
+		uuid.New(), subID, userID, req.PlanID, req.Reason, adminID, now)
 
 	h.adminSvc.LogActionWithReason(r.Context(), adminID, "subscription.upgrade", "user", userID, req.Reason,
 		nil, map[string]interface{}{"plan_id": req.PlanID, "days": req.Days})
@@ -24,6 +24,8 @@ const (
 	PermViewSubscriptions   Permission = "subscriptions.view"
 	PermManageSubscriptions Permission = "subscriptions.manage"
 	PermRefundPayments      Permission = "payments.refund"
+	PermManageProducts      Permission = "products.manage"
+	PermManagePromoCodes    Permission = "promo_codes.manage"
 
 	// Credits (B3: New permission for admin credit grants)
 	PermGrantCredits Permission = "credits.grant"
@@ -34,6 +36,9 @@ const (
 	PermManageAdmins     Permission = "admins.manage"
 	PermViewAuditLogs    Permission = "audit.view"
 	PermReconcileCredits Permission = "credits.reconcile"
+
+	// Notifications
+	PermManageNotifications Permission = "notifications.manage"
 )
 
 // RolePermissions maps roles to their permissions
@@ -44,18 +49,20 @@ var RolePermissions = map[Role][]Permission{
 		PermVerifyOrganizations,
 		PermViewOrganizations,
 		PermViewContent, PermModerateContent, PermDeleteContent,
-		PermViewSubscriptions, PermManageSubscriptions, PermRefundPayments,
+		PermViewSubscriptions, PermManageSubscriptions, PermRefundPayments, PermManageProducts, PermManagePromoCodes,
 		PermGrantCredits, // B3: SuperAdmin can grant credits
 		PermViewAnalytics, PermManageFeatures, PermManageAdmins, PermViewAuditLogs, PermReconcileCredits,
+		PermManageNotifications,
 	},
 	RoleAdmin: {
 		PermViewUsers, PermBanUsers, PermVerifyUsers,
 		PermViewContent, PermModerateContent, PermDeleteContent,
-		PermViewSubscriptions, PermManageSubscriptions,
+		PermViewSubscriptions, PermManageSubscriptions, PermManageProducts, PermManagePromoCodes,
 		PermGrantCredits, // B3: Admin can grant credits
 		PermViewAnalytics, PermManageFeatures, PermViewAuditLogs, PermReconcileCredits,
 		PermViewOrganizations,
 		PermVerifyOrganizations,
+		PermManageNotifications,
 	},
 	RoleModerator: {
 		PermViewUsers, PermBanUsers,
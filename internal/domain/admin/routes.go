@@ -38,6 +38,20 @@ func (h *Handler) Routes() chi.Router {
 			r.Use(RequirePermission(PermViewAnalytics))
 			r.Get("/dashboard", h.Dashboard)
 			r.Get("/revenue", h.Revenue)
+
+			if m := h.metricsHandler; m != nil {
+				r.Get("/cohorts", m.Cohorts)
+				r.Get("/conversion", m.Conversion)
+				r.Get("/subscriptions", m.Subscriptions)
+				r.Get("/revenue/products", m.RevenueByProduct)
+				r.Get("/liquidity", m.Liquidity)
+				r.Get("/refresh", m.RefreshStatus)
+				r.Post("/refresh", m.Refresh)
+			}
+			if e := h.eventsHandler; e != nil {
+				r.Get("/events/sinks", e.Status)
+				r.Post("/events/sinks/{sink}/replay", e.Replay)
+			}
 		})
 
 		// Audit logs
@@ -49,6 +49,10 @@ func (h *UserHandler) Routes(jwtSvc *JWTService, adminSvc *Service) chi.Router {
 		r.Post("/{limitKey}/adjust", h.AdjustUserLimit)
 		r.Post("/{limitKey}/set", h.SetUserLimit)
 	})
+	r.Route("/{id}/subscription-history", func(r chi.Router) {
+		r.Use(RequirePermission(PermViewSubscriptions))
+		r.Get("/", h.GetSubscriptionHistory)
+	})
 
 	return r
 }
@@ -187,6 +191,37 @@ func (h *UserHandler) GetUserLimits(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, items)
 }
 
+// GetSubscriptionHistory handles GET /admin/users/{id}/subscription-history
+// @Summary История подписки пользователя
+// @Tags Admin Users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} response.Response{data=[]subscription.HistoryEntryResponse}
+// @Failure 400,401,403,500 {object} response.Response
+// @Router /admin/users/{id}/subscription-history [get]
+func (h *UserHandler) GetSubscriptionHistory(w http.ResponseWriter, r *http.Request) {
+	if h.limits == nil {
+		response.InternalError(w)
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid user ID")
+		return
+	}
+	entries, err := h.limits.GetHistory(r.Context(), userID, 100, 0)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	items := make([]*subscription.HistoryEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, subscription.HistoryEntryResponseFromEntity(e))
+	}
+	response.OK(w, items)
+}
+
 // GrantCredits handles POST /admin/users/{id}/credits/grant
 func (h *UserHandler) GrantCredits(w http.ResponseWriter, r *http.Request) {
 	if h.credits == nil {
@@ -296,7 +331,81 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		response.NotFound(w, "User not found")
 		return
 	}
-	response.OK(w, &UserListResponse{ID: user.ID, Email: user.Email, Role: user.Role, EmailVerified: user.EmailVerified, IsBanned: user.IsBanned, UserVerificationStatus: user.UserVerificationStatus, CreatedAt: user.CreatedAt, LastLoginAt: user.LastLoginAt})
+	health, err := h.emailHealth(r, id)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, &UserDetailResponse{
+		UserListResponse: UserListResponse{ID: user.ID, Email: user.Email, Role: user.Role, EmailVerified: user.EmailVerified, IsBanned: user.IsBanned, UserVerificationStatus: user.UserVerificationStatus, CreatedAt: user.CreatedAt, LastLoginAt: user.LastLoginAt},
+		EmailHealth:      health,
+	})
+}
+
+// UserDetailResponse represents a single user in admin view
+type UserDetailResponse struct {
+	UserListResponse
+	EmailHealth *EmailHealthResponse `json:"email_health"`
+}
+
+// EmailHealthResponse summarizes deliverability of a user's address
+type EmailHealthResponse struct {
+	Suppressed        bool    `json:"suppressed"`
+	SuppressionReason *string `json:"suppression_reason,omitempty"`
+	SuppressedAt      *string `json:"suppressed_at,omitempty"`
+	EmailEnabled      bool    `json:"email_enabled"`
+	Delivered30d      int     `json:"delivered_30d"`
+	Bounces           int     `json:"bounces"`
+	Complaints        int     `json:"complaints"`
+	LastEvent         *string `json:"last_event,omitempty"`
+	LastEventAt       *string `json:"last_event_at,omitempty"`
+}
+
+func (h *UserHandler) emailHealth(r *http.Request, userID uuid.UUID) (*EmailHealthResponse, error) {
+	var health EmailHealthResponse
+	err := h.db.GetContext(r.Context(), &health.EmailEnabled, `
+		SELECT COALESCE((SELECT email_enabled FROM user_notification_preferences WHERE user_id = $1), true)
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var row struct {
+		Reason       *string `db:"email_suppression_reason"`
+		SuppressedAt *string `db:"email_suppressed_at"`
+		Delivered    int     `db:"delivered_30d"`
+		Bounces      int     `db:"bounces"`
+		Complaints   int     `db:"complaints"`
+		LastEvent    *string `db:"last_event"`
+		LastEventAt  *string `db:"last_event_at"`
+	}
+	err = h.db.GetContext(r.Context(), &row, `
+		SELECT
+			u.email_suppression_reason,
+			u.email_suppressed_at::text AS email_suppressed_at,
+			COUNT(e.id) FILTER (WHERE e.event = 'delivered' AND e.occurred_at > NOW() - INTERVAL '30 days') AS delivered_30d,
+			COUNT(e.id) FILTER (WHERE e.event = 'bounced') AS bounces,
+			COUNT(e.id) FILTER (WHERE e.event = 'complained') AS complaints,
+			(ARRAY_AGG(e.event ORDER BY e.occurred_at DESC) FILTER (WHERE e.id IS NOT NULL))[1] AS last_event,
+			MAX(e.occurred_at)::text AS last_event_at
+		FROM users u
+		LEFT JOIN email_events e ON e.user_id = u.id
+		WHERE u.id = $1
+		GROUP BY u.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	health.Suppressed = row.Reason != nil
+	health.SuppressionReason = row.Reason
+	health.SuppressedAt = row.SuppressedAt
+	health.Delivered30d = row.Delivered
+	health.Bounces = row.Bounces
+	health.Complaints = row.Complaints
+	health.LastEvent = row.LastEvent
+	health.LastEventAt = row.LastEventAt
+	return &health, nil
 }
 
 func (h *UserHandler) Ban(w http.ResponseWriter, r *http.Request) {
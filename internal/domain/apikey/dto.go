@@ -0,0 +1,67 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateKeyRequest issues a key for an organization
+type CreateKeyRequest struct {
+	OrganizationID     uuid.UUID  `json:"organization_id"`
+	Name               string     `json:"name"`
+	Scopes             []string   `json:"scopes"`
+	ActingUserID       *uuid.UUID `json:"acting_user_id,omitempty"`        // organization member requests act as, defaults to the creator
+	RateLimitPerMinute *int       `json:"rate_limit_per_minute,omitempty"` // defaults to the server limit
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdateKeyRequest changes a key; omitted fields are kept
+type UpdateKeyRequest struct {
+	Name               *string  `json:"name,omitempty"`
+	Scopes             []string `json:"scopes,omitempty"`
+	RateLimitPerMinute *int     `json:"rate_limit_per_minute,omitempty"`
+}
+
+// KeyResponse is an API key in API responses
+type KeyResponse struct {
+	ID                 uuid.UUID  `json:"id"`
+	OrganizationID     uuid.UUID  `json:"organization_id"`
+	ActingUserID       uuid.UUID  `json:"acting_user_id"`
+	Name               string     `json:"name"`
+	Prefix             string     `json:"prefix"`
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP         string     `json:"last_used_ip,omitempty"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	Key                string     `json:"key,omitempty"` // only on create and rotation
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// ToResponse converts a key; the plaintext is included only right after issue
+func (k *Key) ToResponse() *KeyResponse {
+	resp := &KeyResponse{
+		ID:                 k.ID,
+		OrganizationID:     k.OrganizationID,
+		ActingUserID:       k.ActingUserID,
+		Name:               k.Name,
+		Prefix:             k.Prefix,
+		Scopes:             k.Scopes,
+		RateLimitPerMinute: k.RateLimitPerMinute,
+		LastUsedIP:         k.LastUsedIP.String,
+		Key:                k.Plaintext,
+		CreatedAt:          k.CreatedAt,
+	}
+	if k.LastUsedAt.Valid {
+		resp.LastUsedAt = &k.LastUsedAt.Time
+	}
+	if k.ExpiresAt.Valid {
+		resp.ExpiresAt = &k.ExpiresAt.Time
+	}
+	if k.RevokedAt.Valid {
+		resp.RevokedAt = &k.RevokedAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,47 @@
+package apikey
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Key is an organization API key. Only the SHA-256 hash of the secret is
+// stored; the prefix identifies the key in listings and logs.
+type Key struct {
+	ID                 uuid.UUID      `db:"id"`
+	OrganizationID     uuid.UUID      `db:"organization_id"`
+	ActingUserID       uuid.UUID      `db:"acting_user_id"`
+	CreatedBy          uuid.NullUUID  `db:"created_by"`
+	Name               string         `db:"name"`
+	Prefix             string         `db:"prefix"`
+	KeyHash            string         `db:"key_hash"`
+	Scopes             pq.StringArray `db:"scopes"`
+	RateLimitPerMinute int            `db:"rate_limit_per_minute"`
+	LastUsedAt         sql.NullTime   `db:"last_used_at"`
+	LastUsedIP         sql.NullString `db:"last_used_ip"`
+	ExpiresAt          sql.NullTime   `db:"expires_at"`
+	RevokedAt          sql.NullTime   `db:"revoked_at"`
+	CreatedAt          time.Time      `db:"created_at"`
+	UpdatedAt          time.Time      `db:"updated_at"`
+
+	Plaintext string `db:"-"` // set only when the key is issued or rotated
+}
+
+// IsActive reports whether the key can authenticate at the given time
+func (k *Key) IsActive(now time.Time) bool {
+	if k.RevokedAt.Valid {
+		return false
+	}
+	return !k.ExpiresAt.Valid || now.Before(k.ExpiresAt.Time)
+}
+
+// keyOwner is the acting user of a key as needed for authentication
+type keyOwner struct {
+	Key
+	Role     string `db:"role"`
+	IsBanned bool   `db:"is_banned"`
+	IsMember bool   `db:"is_member"`
+}
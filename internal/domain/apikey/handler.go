@@ -0,0 +1,233 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/middleware"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// Handler handles API key management HTTP requests
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates API key handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Routes returns API key routes for organization owners and admins
+func (h *Handler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Use(authMiddleware)
+	r.Use(middleware.RequireEmployer())
+
+	r.Get("/scopes", h.ListScopes)
+	r.Get("/", h.List)
+	r.Post("/", h.Create)
+	r.Get("/{id}", h.Get)
+	r.Patch("/{id}", h.Update)
+	r.Post("/{id}/rotate", h.Rotate)
+	r.Delete("/{id}", h.Revoke)
+
+	return r
+}
+
+// ListScopes returns the scopes a key can be granted
+// @Summary Доступные права API-ключей
+// @Tags API Keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]string}
+// @Router /integrations/api-keys/scopes [get]
+func (h *Handler) ListScopes(w http.ResponseWriter, r *http.Request) {
+	response.OK(w, middleware.Scopes)
+}
+
+// List returns keys of organizations the user administers
+// @Summary Список API-ключей
+// @Tags API Keys
+// @Produce json
+// @Security BearerAuth
+// @Param organization_id query string false "Organization ID"
+// @Success 200 {object} response.Response{data=[]KeyResponse}
+// @Failure 400,401,403,500 {object} response.Response
+// @Router /integrations/api-keys [get]
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	var orgID *uuid.UUID
+	if raw := r.URL.Query().Get("organization_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.BadRequest(w, "Invalid organization_id")
+			return
+		}
+		orgID = &id
+	}
+
+	keys, err := h.service.List(r.Context(), middleware.GetUserID(r.Context()), orgID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	items := make([]*KeyResponse, len(keys))
+	for i := range keys {
+		items[i] = keys[i].ToResponse()
+	}
+	response.OK(w, items)
+}
+
+// Create issues a key and returns it in plaintext once
+// @Summary Создать API-ключ
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateKeyRequest true "Организация, название и права"
+// @Success 201 {object} response.Response{data=KeyResponse}
+// @Failure 400,401,403,409,500 {object} response.Response
+// @Router /integrations/api-keys [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+	if req.OrganizationID == uuid.Nil {
+		response.BadRequest(w, "organization_id is required")
+		return
+	}
+
+	k, err := h.service.Create(r.Context(), middleware.GetUserID(r.Context()), &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.Created(w, k.ToResponse())
+}
+
+// Get returns a key
+// @Summary Получить API-ключ
+// @Tags API Keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} response.Response{data=KeyResponse}
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/api-keys/{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	k, err := h.service.Get(r.Context(), middleware.GetUserID(r.Context()), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.OK(w, k.ToResponse())
+}
+
+// Update changes name, scopes or rate limit of a key
+// @Summary Изменить API-ключ
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Param request body UpdateKeyRequest true "Изменения"
+// @Success 200 {object} response.Response{data=KeyResponse}
+// @Failure 400,401,404,409,500 {object} response.Response
+// @Router /integrations/api-keys/{id} [patch]
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	var req UpdateKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	k, err := h.service.Update(r.Context(), middleware.GetUserID(r.Context()), id, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.OK(w, k.ToResponse())
+}
+
+// Rotate issues a new secret; the previous key stops working immediately
+// @Summary Перевыпустить API-ключ
+// @Tags API Keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} response.Response{data=KeyResponse}
+// @Failure 400,401,404,409,500 {object} response.Response
+// @Router /integrations/api-keys/{id}/rotate [post]
+func (h *Handler) Rotate(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	k, err := h.service.Rotate(r.Context(), middleware.GetUserID(r.Context()), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.OK(w, k.ToResponse())
+}
+
+// Revoke disables a key permanently
+// @Summary Отозвать API-ключ
+// @Tags API Keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} response.Response{data=KeyResponse}
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/api-keys/{id} [delete]
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	k, err := h.service.Revoke(r.Context(), middleware.GetUserID(r.Context()), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.OK(w, k.ToResponse())
+}
+
+func parseID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid id")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		response.NotFound(w, "API key not found")
+	case errors.Is(err, ErrNotOrganizationAdmin):
+		response.Forbidden(w, err.Error())
+	case errors.Is(err, ErrActingUserNotMember), errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidScopes),
+		errors.Is(err, ErrInvalidRateLimit), errors.Is(err, ErrInvalidExpiry):
+		response.BadRequest(w, err.Error())
+	case errors.Is(err, ErrKeyRevoked), errors.Is(err, ErrTooManyKeys):
+		response.Conflict(w, err.Error())
+	default:
+		response.InternalError(w)
+	}
+}
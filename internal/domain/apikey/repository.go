@@ -0,0 +1,143 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// errPrefixTaken is returned when a generated key prefix collides with another key's
+var errPrefixTaken = errors.New("api key prefix already exists")
+
+// Repository handles API key persistence
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates API key repository
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const keyColumns = `id, organization_id, acting_user_id, created_by, name, prefix, key_hash, scopes, rate_limit_per_minute, last_used_at, last_used_ip, expires_at, revoked_at, created_at, updated_at`
+
+// Create stores a new key
+func (r *Repository) Create(ctx context.Context, k *Key) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (`+keyColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`, k.ID, k.OrganizationID, k.ActingUserID, k.CreatedBy, k.Name, k.Prefix, k.KeyHash, k.Scopes,
+		k.RateLimitPerMinute, k.LastUsedAt, k.LastUsedIP, k.ExpiresAt, k.RevokedAt, k.CreatedAt, k.UpdatedAt)
+	return prefixConflict(err)
+}
+
+// GetByID returns a key or nil
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*Key, error) {
+	var k Key
+	err := r.db.GetContext(ctx, &k, `SELECT `+keyColumns+` FROM api_keys WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// getOwnerByPrefix returns a key with its acting user, or nil
+func (r *Repository) getOwnerByPrefix(ctx context.Context, prefix string) (*keyOwner, error) {
+	var k keyOwner
+	err := r.db.GetContext(ctx, &k, `
+		SELECT k.id, k.organization_id, k.acting_user_id, k.created_by, k.name, k.prefix, k.key_hash, k.scopes,
+			k.rate_limit_per_minute, k.last_used_at, k.last_used_ip, k.expires_at, k.revoked_at, k.created_at, k.updated_at,
+			u.role, COALESCE(u.is_banned, FALSE) AS is_banned,
+			EXISTS (
+				SELECT 1 FROM organization_members m
+				WHERE m.organization_id = k.organization_id AND m.user_id = k.acting_user_id
+			) AS is_member
+		FROM api_keys k
+		JOIN users u ON u.id = k.acting_user_id
+		WHERE k.prefix = $1
+	`, prefix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// ListByOrganizations returns keys of the organizations, newest first
+func (r *Repository) ListByOrganizations(ctx context.Context, orgIDs []uuid.UUID) ([]Key, error) {
+	keys := []Key{}
+	if len(orgIDs) == 0 {
+		return keys, nil
+	}
+	query, args, err := sqlx.In(`SELECT `+keyColumns+` FROM api_keys WHERE organization_id IN (?) ORDER BY created_at DESC`, orgIDs)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.SelectContext(ctx, &keys, r.db.Rebind(query), args...)
+	return keys, err
+}
+
+// CountActive returns the number of unrevoked keys of an organization
+func (r *Repository) CountActive(ctx context.Context, orgID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.GetContext(ctx, &n, `SELECT COUNT(*) FROM api_keys WHERE organization_id = $1 AND revoked_at IS NULL`, orgID)
+	return n, err
+}
+
+// Update saves name, scopes, limit, secret and revocation of a key
+func (r *Repository) Update(ctx context.Context, k *Key) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys
+		SET name = $2, scopes = $3, rate_limit_per_minute = $4, prefix = $5, key_hash = $6,
+			expires_at = $7, revoked_at = $8, updated_at = $9
+		WHERE id = $1
+	`, k.ID, k.Name, k.Scopes, k.RateLimitPerMinute, k.Prefix, k.KeyHash, k.ExpiresAt, k.RevokedAt, k.UpdatedAt)
+	return prefixConflict(err)
+}
+
+func prefixConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_api_keys_prefix" {
+		return errPrefixTaken
+	}
+	return err
+}
+
+// TouchLastUsed records the last use of a key
+func (r *Repository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time, ip string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET last_used_at = $2, last_used_ip = NULLIF($3, '') WHERE id = $1
+	`, id, at, ip)
+	return err
+}
+
+// AdminOrganizations returns organizations where the user is owner or admin
+func (r *Repository) AdminOrganizations(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT organization_id FROM organization_members WHERE user_id = $1 AND role IN ('owner', 'admin')
+	`, userID)
+	return ids, err
+}
+
+// OrganizationRole returns the user's role in the organization, empty if not a member
+func (r *Repository) OrganizationRole(ctx context.Context, orgID, userID uuid.UUID) (string, error) {
+	var role string
+	err := r.db.GetContext(ctx, &role, `
+		SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2
+	`, orgID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
@@ -0,0 +1,397 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/middleware"
+)
+
+var (
+	ErrKeyNotFound          = errors.New("api key not found")
+	ErrKeyRevoked           = errors.New("api key is revoked")
+	ErrNotOrganizationAdmin = errors.New("only organization owners and admins can manage its api keys")
+	ErrActingUserNotMember  = errors.New("acting user must be a member of the organization")
+	ErrInvalidName          = errors.New("api key name is required and must be at most 100 characters")
+	ErrInvalidScopes        = errors.New("unknown or empty api key scopes")
+	ErrInvalidRateLimit     = errors.New("api key rate limit is out of range")
+	ErrInvalidExpiry        = errors.New("api key expiry must be in the future")
+	ErrTooManyKeys          = errors.New("api key limit for the organization reached")
+)
+
+const (
+	publicIDBytes = 4  // hex prefix after "mwk_", visible in listings
+	secretBytes   = 24 // hex secret, never stored
+	touchInterval = time.Minute
+	keyAttempts   = 3 // new keys generated when the prefix collides
+)
+
+// Config controls issued keys
+type Config struct {
+	DefaultRateLimit int // requests per minute when none is requested
+	MaxRateLimit     int
+	MaxPerOrg        int // active keys per organization
+}
+
+// Service issues organization API keys and authenticates requests made with them
+type Service struct {
+	repo    *Repository
+	limiter middleware.RateLimitStore
+	cfg     Config
+	now     func() time.Time
+
+	mu      sync.Mutex
+	touched map[uuid.UUID]time.Time // last persisted use per key
+}
+
+// NewService creates API key service; without a store counts are kept in process
+func NewService(repo *Repository, limiter middleware.RateLimitStore, cfg Config) *Service {
+	if cfg.DefaultRateLimit <= 0 {
+		cfg.DefaultRateLimit = 120
+	}
+	if cfg.MaxRateLimit < cfg.DefaultRateLimit {
+		cfg.MaxRateLimit = cfg.DefaultRateLimit
+	}
+	if cfg.MaxPerOrg <= 0 {
+		cfg.MaxPerOrg = 25
+	}
+	if limiter == nil {
+		limiter = middleware.NewMemoryRateLimitStore()
+	}
+	return &Service{
+		repo:    repo,
+		limiter: limiter,
+		cfg:     cfg,
+		now:     time.Now,
+		touched: make(map[uuid.UUID]time.Time),
+	}
+}
+
+// Create issues a key for an organization. The plaintext key is only returned here and on rotation.
+func (s *Service) Create(ctx context.Context, userID uuid.UUID, req *CreateKeyRequest) (*Key, error) {
+	if err := s.requireOrgAdmin(ctx, req.OrganizationID, userID); err != nil {
+		return nil, err
+	}
+
+	actingUserID := userID
+	if req.ActingUserID != nil && *req.ActingUserID != userID {
+		role, err := s.repo.OrganizationRole(ctx, req.OrganizationID, *req.ActingUserID)
+		if err != nil {
+			return nil, err
+		}
+		if role == "" {
+			return nil, ErrActingUserNotMember
+		}
+		actingUserID = *req.ActingUserID
+	}
+
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := parseScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	limit := s.cfg.DefaultRateLimit
+	if req.RateLimitPerMinute != nil {
+		limit = *req.RateLimitPerMinute
+	}
+	if limit <= 0 || limit > s.cfg.MaxRateLimit {
+		return nil, ErrInvalidRateLimit
+	}
+	now := s.now()
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, ErrInvalidExpiry
+		}
+		expiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	active, err := s.repo.CountActive(ctx, req.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if active >= s.cfg.MaxPerOrg {
+		return nil, ErrTooManyKeys
+	}
+
+	k := &Key{
+		ID:                 uuid.New(),
+		OrganizationID:     req.OrganizationID,
+		ActingUserID:       actingUserID,
+		CreatedBy:          uuid.NullUUID{UUID: userID, Valid: true},
+		Name:               name,
+		Scopes:             scopes,
+		RateLimitPerMinute: limit,
+		ExpiresAt:          expiresAt,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := withNewKey(ctx, k, s.repo.Create); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// List returns keys of the organization, or of every organization the user administers
+func (s *Service) List(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID) ([]Key, error) {
+	if orgID != nil {
+		if err := s.requireOrgAdmin(ctx, *orgID, userID); err != nil {
+			return nil, err
+		}
+		return s.repo.ListByOrganizations(ctx, []uuid.UUID{*orgID})
+	}
+	orgIDs, err := s.repo.AdminOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListByOrganizations(ctx, orgIDs)
+}
+
+// Get returns a key the user can manage
+func (s *Service) Get(ctx context.Context, userID, id uuid.UUID) (*Key, error) {
+	return s.getManageable(ctx, userID, id)
+}
+
+// Update changes name, scopes or rate limit of an active key
+func (s *Service) Update(ctx context.Context, userID, id uuid.UUID, req *UpdateKeyRequest) (*Key, error) {
+	k, err := s.getManageable(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if k.RevokedAt.Valid {
+		return nil, ErrKeyRevoked
+	}
+	if req.Name != nil {
+		if k.Name, err = validateName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.Scopes != nil {
+		if k.Scopes, err = parseScopes(req.Scopes); err != nil {
+			return nil, err
+		}
+	}
+	if req.RateLimitPerMinute != nil {
+		if *req.RateLimitPerMinute <= 0 || *req.RateLimitPerMinute > s.cfg.MaxRateLimit {
+			return nil, ErrInvalidRateLimit
+		}
+		k.RateLimitPerMinute = *req.RateLimitPerMinute
+	}
+	k.UpdatedAt = s.now()
+	if err := s.repo.Update(ctx, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Rotate replaces the secret and prefix of a key; the old key stops working immediately
+func (s *Service) Rotate(ctx context.Context, userID, id uuid.UUID) (*Key, error) {
+	k, err := s.getManageable(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if k.RevokedAt.Valid {
+		return nil, ErrKeyRevoked
+	}
+	k.UpdatedAt = s.now()
+	if err := withNewKey(ctx, k, s.repo.Update); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Revoke disables a key permanently
+func (s *Service) Revoke(ctx context.Context, userID, id uuid.UUID) (*Key, error) {
+	k, err := s.getManageable(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if k.RevokedAt.Valid {
+		return k, nil
+	}
+	now := s.now()
+	k.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	k.UpdatedAt = now
+	if err := s.repo.Update(ctx, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// AuthenticateAPIKey resolves a presented key to its acting user and applies
+// the per-key rate limit; implements middleware.APIKeyAuthenticator
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string, ip string) (*middleware.APIKeyPrincipal, error) {
+	prefix, ok := splitKey(key)
+	if !ok {
+		return nil, middleware.ErrInvalidAPIKey
+	}
+	k, err := s.repo.getOwnerByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if k == nil || subtle.ConstantTimeCompare([]byte(hashKey(key)), []byte(k.KeyHash)) != 1 {
+		return nil, middleware.ErrInvalidAPIKey
+	}
+	// The acting user must still be an unbanned member of the organization
+	if !k.IsActive(now) || !k.IsMember || k.IsBanned {
+		return nil, middleware.ErrInvalidAPIKey
+	}
+
+	decision, err := s.limiter.Allow(ctx, "ratelimit:api_key:"+k.ID.String(), middleware.RateLimitPolicy{
+		Name:      "api_key",
+		Limit:     k.RateLimitPerMinute,
+		Window:    time.Minute,
+		Algorithm: middleware.SlidingWindow,
+	}, now)
+	if err != nil {
+		log.Warn().Err(err).Str("api_key", k.Prefix).Msg("api key rate limiter unavailable")
+	} else if !decision.Allowed {
+		return nil, middleware.ErrAPIKeyRateLimited
+	}
+
+	s.touch(ctx, k.ID, now, ip)
+
+	return &middleware.APIKeyPrincipal{
+		KeyID:          k.ID,
+		OrganizationID: k.OrganizationID,
+		UserID:         k.ActingUserID,
+		Role:           k.Role,
+		Scopes:         k.Scopes,
+	}, nil
+}
+
+// touch records last use at most once per touchInterval per key
+func (s *Service) touch(ctx context.Context, id uuid.UUID, now time.Time, ip string) {
+	s.mu.Lock()
+	if last, ok := s.touched[id]; ok && now.Sub(last) < touchInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.touched[id] = now
+	s.mu.Unlock()
+
+	if err := s.repo.TouchLastUsed(ctx, id, now, ip); err != nil {
+		log.Warn().Err(err).Str("api_key_id", id.String()).Msg("failed to record api key use")
+	}
+}
+
+func (s *Service) getManageable(ctx context.Context, userID, id uuid.UUID) (*Key, error) {
+	k, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, ErrKeyNotFound
+	}
+	if err := s.requireOrgAdmin(ctx, k.OrganizationID, userID); err != nil {
+		if errors.Is(err, ErrNotOrganizationAdmin) {
+			// Do not reveal keys of other organizations
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+func (s *Service) requireOrgAdmin(ctx context.Context, orgID, userID uuid.UUID) error {
+	role, err := s.repo.OrganizationRole(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if role != "owner" && role != "admin" {
+		return ErrNotOrganizationAdmin
+	}
+	return nil
+}
+
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > 100 {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+func parseScopes(raw []string) (pq.StringArray, error) {
+	seen := make(map[string]bool, len(raw))
+	scopes := pq.StringArray{}
+	for _, scope := range raw {
+		scope = strings.TrimSpace(scope)
+		valid := false
+		for _, known := range middleware.Scopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, ErrInvalidScopes
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScopes
+	}
+	return scopes, nil
+}
+
+// withNewKey generates a key for k and saves it, generating another one when the
+// random prefix is already taken
+func withNewKey(ctx context.Context, k *Key, save func(context.Context, *Key) error) error {
+	for attempt := 1; ; attempt++ {
+		k.Plaintext, k.Prefix, k.KeyHash = generateKey()
+		err := save(ctx, k)
+		if errors.Is(err, errPrefixTaken) && attempt < keyAttempts {
+			continue
+		}
+		return err
+	}
+}
+
+// generateKey returns a new key "mwk_<public id>_<secret>", its prefix and hash
+func generateKey() (plaintext, prefix, hash string) {
+	id := make([]byte, publicIDBytes)
+	secret := make([]byte, secretBytes)
+	_, _ = rand.Read(id)
+	_, _ = rand.Read(secret)
+	prefix = middleware.APIKeyPrefix + hex.EncodeToString(id)
+	plaintext = prefix + "_" + hex.EncodeToString(secret)
+	return plaintext, prefix, hashKey(plaintext)
+}
+
+// splitKey returns the prefix of a well-formed key
+func splitKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, middleware.APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(id) != publicIDBytes*2 || len(secret) != secretBytes*2 {
+		return "", false
+	}
+	return middleware.APIKeyPrefix + id, true
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,58 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateKeyRoundTrip(t *testing.T) {
+	plaintext, prefix, hash := generateKey()
+	if !strings.HasPrefix(plaintext, prefix+"_") || !strings.HasPrefix(prefix, "mwk_") {
+		t.Fatalf("unexpected key layout %q / %q", plaintext, prefix)
+	}
+	got, ok := splitKey(plaintext)
+	if !ok || got != prefix {
+		t.Fatalf("expected prefix %q, got %q %v", prefix, got, ok)
+	}
+	if hashKey(plaintext) != hash || strings.Contains(hash, plaintext) {
+		t.Fatalf("unexpected hash %q", hash)
+	}
+	if _, ok := splitKey(prefix + "_short"); ok {
+		t.Fatalf("expected malformed key to be rejected")
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := parseScopes([]string{"castings:write", "responses:read", "castings:write"})
+	if err != nil || len(scopes) != 2 {
+		t.Fatalf("expected deduplicated scopes, got %v %v", scopes, err)
+	}
+	if _, err := parseScopes([]string{"admin:all"}); !errors.Is(err, ErrInvalidScopes) {
+		t.Fatalf("expected unknown scope error, got %v", err)
+	}
+	if _, err := parseScopes(nil); !errors.Is(err, ErrInvalidScopes) {
+		t.Fatalf("expected empty scopes error, got %v", err)
+	}
+}
+
+func TestWithNewKeyRetriesTakenPrefix(t *testing.T) {
+	var prefixes []string
+	k := &Key{}
+	err := withNewKey(context.Background(), k, func(ctx context.Context, k *Key) error {
+		prefixes = append(prefixes, k.Prefix)
+		if len(prefixes) == 1 {
+			return errPrefixTaken
+		}
+		return nil
+	})
+	if err != nil || len(prefixes) != 2 || k.Prefix != prefixes[1] || prefixes[0] == prefixes[1] {
+		t.Fatalf("expected a second key after the collision, got %v %v", prefixes, err)
+	}
+
+	err = withNewKey(context.Background(), k, func(ctx context.Context, k *Key) error { return errPrefixTaken })
+	if !errors.Is(err, errPrefixTaken) {
+		t.Fatalf("expected to give up after %d attempts, got %v", keyAttempts, err)
+	}
+}
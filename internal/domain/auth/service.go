@@ -18,6 +18,7 @@ import (
 	"github.com/mwork/mwork-api/internal/domain/user"
 	"github.com/mwork/mwork-api/internal/middleware"
 	"github.com/mwork/mwork-api/internal/pkg/email"
+	"github.com/mwork/mwork-api/internal/pkg/events"
 	"github.com/mwork/mwork-api/internal/pkg/jwt"
 	"github.com/mwork/mwork-api/internal/pkg/password"
 	"github.com/mwork/mwork-api/internal/pkg/photostudio"
@@ -38,6 +39,7 @@ type Service struct {
 	verificationCodeLogInDev bool
 	allowLegacyRefresh       bool
 	emailService             *email.Service
+	events                   events.Emitter
 }
 
 type RefreshTokenStore interface {
@@ -177,6 +179,8 @@ func (s *Service) Register(ctx context.Context, req *RegisterRequest) (*Register
 		return nil, wrappedErr
 	}
 
+	s.emitRegistered(ctx, u)
+
 	s.syncPhotoStudioUser(photostudio.SyncUserPayload{
 		MWorkUserID: u.ID.String(),
 		Email:       u.Email,
@@ -272,6 +276,8 @@ func (s *Service) RegisterAgency(ctx context.Context, req *AgencyRegisterRequest
 		return nil, wrappedErr
 	}
 
+	s.emitRegistered(ctx, u)
+
 	s.syncPhotoStudioUser(photostudio.SyncUserPayload{
 		MWorkUserID: u.ID.String(),
 		Email:       u.Email,
@@ -289,6 +295,17 @@ func (s *Service) RegisterAgency(ctx context.Context, req *AgencyRegisterRequest
 	}, nil
 }
 
+// SetEventEmitter enables product events for sign-ups
+func (s *Service) SetEventEmitter(e events.Emitter) {
+	s.events = e
+}
+
+func (s *Service) emitRegistered(ctx context.Context, u *user.User) {
+	if s.events != nil {
+		s.events.Emit(ctx, events.UserRegistered{UserID: u.ID, Role: string(u.Role), Email: u.Email})
+	}
+}
+
 // Login authenticates user
 func (s *Service) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error) {
 	log.Info().Str("email", req.Email).Msg("Login attempt")
@@ -90,6 +90,9 @@ func (f *fakeCastingRepo) List(ctx context.Context, filter *Filter, sortBy SortB
 	return nil, 0, nil
 }
 func (f *fakeCastingRepo) IncrementViewCount(ctx context.Context, id uuid.UUID) error { return nil }
+func (f *fakeCastingRepo) RecordView(ctx context.Context, id uuid.UUID, viewerID uuid.NullUUID) error {
+	return nil
+}
 func (f *fakeCastingRepo) IncrementAcceptedAndMaybeClose(ctx context.Context, id uuid.UUID) (int, Status, error) {
 	return 0, "", nil
 }
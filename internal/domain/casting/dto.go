@@ -138,6 +138,7 @@ type CastingResponse struct {
 
 	Status           string   `json:"status"`
 	IsPromoted       bool     `json:"is_promoted"`
+	IsSponsored      bool     `json:"is_sponsored"`
 	ModerationStatus string   `json:"moderation_status"`
 	Tags             []string `json:"tags"`
 	ViewCount        int      `json:"view_count"`
@@ -13,6 +13,7 @@ import (
 
 	"github.com/mwork/mwork-api/internal/middleware"
 	"github.com/mwork/mwork-api/internal/pkg/response"
+	"github.com/mwork/mwork-api/internal/pkg/sponsored"
 	"github.com/mwork/mwork-api/internal/pkg/validator"
 )
 
@@ -20,6 +21,18 @@ import (
 type Handler struct {
 	service        *Service
 	profileService ProfileService
+	impressions    ImpressionTracker
+	sponsored      SponsoredSelector
+}
+
+// ImpressionTracker records impressions of promoted castings
+type ImpressionTracker interface {
+	TrackCastingImpressions(r *http.Request, castingIDs []uuid.UUID)
+}
+
+// SponsoredSelector picks promoted castings for sponsored slots in list results
+type SponsoredSelector interface {
+	SponsoredCastings(r *http.Request, city string) []uuid.UUID
 }
 
 // ProfileService defines profile operations needed by casting
@@ -41,6 +54,34 @@ func NewHandler(service *Service, profileService ProfileService) *Handler {
 	}
 }
 
+// SetImpressionTracker enables impression tracking for promoted castings
+func (h *Handler) SetImpressionTracker(t ImpressionTracker) {
+	h.impressions = t
+}
+
+// SetSponsoredSelector enables sponsored slots in the casting list
+func (h *Handler) SetSponsoredSelector(s SponsoredSelector) {
+	h.sponsored = s
+}
+
+// sponsoredCastings loads the castings picked for sponsored slots
+func (h *Handler) sponsoredCastings(r *http.Request, city string) []*CastingResponse {
+	if h.sponsored == nil {
+		return nil
+	}
+	var out []*CastingResponse
+	for _, id := range h.sponsored.SponsoredCastings(r, city) {
+		c, err := h.service.GetByID(r.Context(), id)
+		if err != nil {
+			continue
+		}
+		resp := CastingResponseFromEntity(c)
+		resp.IsSponsored = true
+		out = append(out, resp)
+	}
+	return out
+}
+
 // Create handles POST /castings
 // @Summary Создать кастинг
 // @Description Создать новый кастинг. Доступно только для работодателей (Employer).
@@ -166,8 +207,10 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Increment view count (async)
-	go h.service.IncrementViewCount(context.Background(), id)
+	// Record the view (async); the casting's own creator is not counted
+	if viewerID := middleware.GetUserID(r.Context()); viewerID != casting.CreatorID {
+		go h.service.RecordView(context.Background(), id, viewerID)
+	}
 
 	response.OK(w, CastingResponseFromEntity(casting))
 }
@@ -404,8 +447,18 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	items := make([]*CastingResponse, 0, len(castings))
+	var promoted []uuid.UUID
 	for _, c := range castings {
 		items = append(items, CastingResponseFromEntity(c))
+		if c.IsPromoted {
+			promoted = append(promoted, c.ID)
+		}
+	}
+	if h.impressions != nil && len(promoted) > 0 {
+		h.impressions.TrackCastingImpressions(r, promoted)
+	}
+	if len(items) > 0 {
+		items = sponsored.Inject(items, h.sponsoredCastings(r, q.Get("city")), func(c *CastingResponse) uuid.UUID { return c.ID })
 	}
 
 	pages := total / limit
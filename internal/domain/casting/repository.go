@@ -52,6 +52,7 @@ type Repository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, filter *Filter, sortBy SortBy, pagination *Pagination) ([]*Casting, int, error)
 	IncrementViewCount(ctx context.Context, id uuid.UUID) error
+	RecordView(ctx context.Context, id uuid.UUID, viewerID uuid.NullUUID) error
 	IncrementAcceptedAndMaybeClose(ctx context.Context, id uuid.UUID) (int, Status, error)
 	IncrementAcceptedAndMaybeCloseTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (int, Status, error)
 	IncrementResponseCount(ctx context.Context, id uuid.UUID, delta int) error
@@ -360,6 +361,19 @@ func (r *repository) IncrementViewCount(ctx context.Context, id uuid.UUID) error
 	return err
 }
 
+// RecordView increments the view counter and stores a view event for analytics
+func (r *repository) RecordView(ctx context.Context, id uuid.UUID, viewerID uuid.NullUUID) error {
+	query := `
+		WITH counted AS (
+			UPDATE castings SET view_count = view_count + 1 WHERE id = $1 RETURNING id
+		)
+		INSERT INTO casting_view_events (casting_id, viewer_id)
+		SELECT id, $2 FROM counted
+	`
+	_, err := r.db.ExecContext(ctx, query, id, viewerID)
+	return err
+}
+
 func (r *repository) IncrementAcceptedAndMaybeClose(ctx context.Context, id uuid.UUID) (int, Status, error) {
 	return r.incrementAcceptedAndMaybeClose(ctx, r.db, id)
 }
@@ -7,8 +7,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
 
 	"github.com/mwork/mwork-api/internal/domain/user"
+	"github.com/mwork/mwork-api/internal/pkg/events"
 )
 
 // NotificationService interface for notification operations
@@ -16,6 +18,11 @@ type NotificationService interface {
 	NotifyAgencyFollowersNewCasting(ctx context.Context, organizationID uuid.UUID, castingID uuid.UUID, castingTitle string) error
 }
 
+// ClosedNotifier is notified when an owner closes a casting
+type ClosedNotifier interface {
+	NotifyCastingClosed(ctx context.Context, employerUserID uuid.UUID, castingID uuid.UUID, castingTitle string, reason string) error
+}
+
 // PlanChecker checks subscription plan limits for casting creation.
 type PlanChecker interface {
 	// MaxActiveCastings returns the maximum number of active castings the user's plan allows.
@@ -29,6 +36,8 @@ type Service struct {
 	userRepo     user.Repository
 	notifService NotificationService
 	planChecker  PlanChecker
+	events       events.Emitter
+	closed       ClosedNotifier
 }
 
 // NewService creates casting service
@@ -49,6 +58,22 @@ func (s *Service) SetPlanChecker(pc PlanChecker) {
 	s.planChecker = pc
 }
 
+// SetEventEmitter enables product events for published castings (optional)
+func (s *Service) SetEventEmitter(e events.Emitter) {
+	s.events = e
+}
+
+// SetClosedNotifier sets the hook called when a casting is closed (optional)
+func (s *Service) SetClosedNotifier(n ClosedNotifier) {
+	s.closed = n
+}
+
+func (s *Service) emitPublished(ctx context.Context, c *Casting) {
+	if s.events != nil && c.IsActive() {
+		s.events.Emit(ctx, events.CastingPublished{CastingID: c.ID, CreatorID: c.CreatorID, Title: c.Title, City: c.City})
+	}
+}
+
 func validateCreateCastingRequest(req *CreateCastingRequest) ValidationErrors {
 	errs := ValidationErrors{}
 
@@ -304,6 +329,7 @@ func (s *Service) Create(ctx context.Context, userID uuid.UUID, req *CreateCasti
 	if err := s.repo.Create(ctx, casting); err != nil {
 		return nil, err
 	}
+	s.emitPublished(ctx, casting)
 
 	return casting, nil
 }
@@ -440,7 +466,16 @@ func (s *Service) UpdateStatus(ctx context.Context, id uuid.UUID, userID uuid.UU
 		return nil, err
 	}
 
+	wasActive, wasClosed := casting.IsActive(), casting.Status == StatusClosed
 	casting.Status = status
+	if !wasActive {
+		s.emitPublished(ctx, casting)
+	}
+	if s.closed != nil && status == StatusClosed && !wasClosed {
+		if err := s.closed.NotifyCastingClosed(ctx, casting.CreatorID, casting.ID, casting.Title, "closed"); err != nil {
+			log.Error().Err(err).Str("casting_id", casting.ID.String()).Msg("failed to notify casting closed")
+		}
+	}
 	return casting, nil
 }
 
@@ -463,6 +498,12 @@ func (s *Service) List(ctx context.Context, filter *Filter, sortBy SortBy, pagin
 	return s.repo.List(ctx, filter, sortBy, pagination)
 }
 
+// RecordView counts a casting view and keeps the event for employer analytics.
+// viewerID is uuid.Nil for anonymous viewers.
+func (s *Service) RecordView(ctx context.Context, id, viewerID uuid.UUID) error {
+	return s.repo.RecordView(ctx, id, uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil})
+}
+
 // IncrementViewCount increments view count
 func (s *Service) IncrementViewCount(ctx context.Context, id uuid.UUID) error {
 	return s.repo.IncrementViewCount(ctx, id)
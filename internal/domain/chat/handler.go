@@ -43,7 +43,8 @@ type ProfileFetcher interface {
 
 // NotificationQuery defines read-only notification access for WS sync
 type NotificationQuery interface {
-	List(ctx context.Context, userID uuid.UUID, limit, offset int, unreadOnly bool) ([]*notification.NotificationResponse, error)
+	List(ctx context.Context, userID uuid.UUID, filter notification.ListFilter) ([]*notification.NotificationResponse, error)
+	Groups(ctx context.Context, userID uuid.UUID, filter notification.ListFilter) ([]*notification.NotificationGroupResponse, error)
 	UnreadCount(ctx context.Context, userID uuid.UUID) (int, error)
 }
 
@@ -640,22 +641,25 @@ func (h *Handler) broadcastNotificationStateRead(userID uuid.UUID, readIDs []uui
 }
 
 type notificationSyncRequest struct {
-	Limit      *int `json:"limit"`
-	Offset     *int `json:"offset"`
-	UnreadOnly bool `json:"unread_only"`
+	Limit      *int     `json:"limit"`
+	Offset     *int     `json:"offset"`
+	UnreadOnly bool     `json:"unread_only"`
+	Types      []string `json:"types"`
+	Archived   bool     `json:"archived"`
+	Grouped    bool     `json:"grouped"`
 }
 
 func (h *Handler) sendInitialNotificationSync(userID uuid.UUID, client *Connection) {
-	h.sendNotificationSync(context.Background(), userID, client, notificationSyncDefaultLimit, 0, false)
+	h.sendNotificationSync(context.Background(), userID, client, notification.ListFilter{Limit: notificationSyncDefaultLimit}, false)
 }
 
 func (h *Handler) handleNotificationSyncRequest(client *Connection, raw json.RawMessage) {
 	limit := notificationSyncDefaultLimit
 	offset := 0
 	unreadOnly := false
+	var req notificationSyncRequest
 
 	if len(raw) > 0 {
-		var req notificationSyncRequest
 		if err := json.Unmarshal(raw, &req); err != nil {
 			log.Warn().Str("user_id", client.UserID.String()).Str("action", "notification:sync").Str("result", "invalid_payload").Msg("Notification WS sync rejected")
 			h.sendWSError(client, "notification_invalid_payload")
@@ -680,10 +684,22 @@ func (h *Handler) handleNotificationSyncRequest(client *Connection, raw json.Raw
 		offset = 0
 	}
 
-	h.sendNotificationSync(context.Background(), client.UserID, client, limit, offset, unreadOnly)
+	types, err := notification.ParseTypes(strings.Join(req.Types, ","))
+	if err != nil {
+		h.sendWSError(client, "notification_invalid_type")
+		return
+	}
+	filter := notification.ListFilter{
+		Types:      types,
+		UnreadOnly: unreadOnly,
+		Archived:   req.Archived,
+		Limit:      limit,
+		Offset:     offset,
+	}
+	h.sendNotificationSync(context.Background(), client.UserID, client, filter, req.Grouped)
 }
 
-func (h *Handler) sendNotificationSync(ctx context.Context, userID uuid.UUID, client *Connection, limit, offset int, unreadOnly bool) {
+func (h *Handler) sendNotificationSync(ctx context.Context, userID uuid.UUID, client *Connection, filter notification.ListFilter, grouped bool) {
 	if h.notificationQuery == nil {
 		return
 	}
@@ -694,22 +710,38 @@ func (h *Handler) sendNotificationSync(ctx context.Context, userID uuid.UUID, cl
 		return
 	}
 
-	items, err := h.notificationQuery.List(ctx, userID, limit, offset, unreadOnly)
-	if err != nil {
-		h.sendWSError(client, "notification_sync_failed")
-		return
+	data := map[string]interface{}{
+		"unread_count": unreadCount,
+		"limit":        filter.Limit,
+		"offset":       filter.Offset,
+		"archived":     filter.Archived,
+	}
+	if len(filter.Types) > 0 {
+		data["types"] = filter.Types
 	}
 
-	log.Debug().Str("user_id", userID.String()).Int("limit", limit).Int("offset", offset).Bool("unread_only", unreadOnly).Str("action", "notification:sync").Str("result", "ok").Msg("Notification WS sync served")
+	if grouped {
+		groups, err := h.notificationQuery.Groups(ctx, userID, filter)
+		if err != nil {
+			h.sendWSError(client, "notification_sync_failed")
+			return
+		}
+		data["grouped"] = true
+		data["groups"] = groups
+	} else {
+		items, err := h.notificationQuery.List(ctx, userID, filter)
+		if err != nil {
+			h.sendWSError(client, "notification_sync_failed")
+			return
+		}
+		data["items"] = items
+	}
+
+	log.Debug().Str("user_id", userID.String()).Int("limit", filter.Limit).Int("offset", filter.Offset).Bool("unread_only", filter.UnreadOnly).Bool("grouped", grouped).Str("action", "notification:sync").Str("result", "ok").Msg("Notification WS sync served")
 
 	h.sendWSJSON(client, map[string]interface{}{
 		"type": "notification:sync",
-		"data": map[string]interface{}{
-			"unread_count": unreadCount,
-			"items":        items,
-			"limit":        limit,
-			"offset":       offset,
-		},
+		"data": data,
 	})
 }
 
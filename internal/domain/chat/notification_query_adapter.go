@@ -9,7 +9,8 @@ import (
 )
 
 type notificationListService interface {
-	List(ctx context.Context, userID uuid.UUID, limit, offset int, unreadOnly bool) ([]*notification.Notification, error)
+	ListFiltered(ctx context.Context, userID uuid.UUID, filter notification.ListFilter) ([]*notification.Notification, error)
+	ListGroups(ctx context.Context, userID uuid.UUID, filter notification.ListFilter) ([]*notification.InboxGroup, error)
 	GetUnreadCount(ctx context.Context, userID uuid.UUID) (int, error)
 }
 
@@ -22,8 +23,8 @@ func NewNotificationQueryAdapter(svc notificationListService) *NotificationQuery
 	return &NotificationQueryAdapter{svc: svc}
 }
 
-func (a *NotificationQueryAdapter) List(ctx context.Context, userID uuid.UUID, limit, offset int, unreadOnly bool) ([]*notification.NotificationResponse, error) {
-	items, err := a.svc.List(ctx, userID, limit, offset, unreadOnly)
+func (a *NotificationQueryAdapter) List(ctx context.Context, userID uuid.UUID, filter notification.ListFilter) ([]*notification.NotificationResponse, error) {
+	items, err := a.svc.ListFiltered(ctx, userID, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +36,19 @@ func (a *NotificationQueryAdapter) List(ctx context.Context, userID uuid.UUID, l
 	return resp, nil
 }
 
+func (a *NotificationQueryAdapter) Groups(ctx context.Context, userID uuid.UUID, filter notification.ListFilter) ([]*notification.NotificationGroupResponse, error) {
+	groups, err := a.svc.ListGroups(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*notification.NotificationGroupResponse, 0, len(groups))
+	for _, g := range groups {
+		resp = append(resp, notification.NotificationGroupResponseFromGroup(g))
+	}
+	return resp, nil
+}
+
 func (a *NotificationQueryAdapter) UnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
 	return a.svc.GetUnreadCount(ctx, userID)
 }
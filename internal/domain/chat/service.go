@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/mwork/mwork-api/internal/domain/user"
+	"github.com/mwork/mwork-api/internal/pkg/events"
 )
 
 // AccessChecker defines interface for checking communication access between users
@@ -39,6 +40,7 @@ type Service struct {
 	limitChecker   LimitChecker
 	uploadResolver UploadResolver
 	notifService   NotificationService
+	events         events.Emitter
 }
 
 // NewService creates chat service
@@ -58,6 +60,11 @@ func (s *Service) SetNotificationService(notifService NotificationService) {
 	s.notifService = notifService
 }
 
+// SetEventEmitter enables product events for sent messages (optional)
+func (s *Service) SetEventEmitter(e events.Emitter) {
+	s.events = e
+}
+
 // CreateOrGetRoom creates a room or returns existing one (router method)
 func (s *Service) CreateOrGetRoom(ctx context.Context, userID uuid.UUID, req *CreateRoomRequest) (*Room, error) {
 	switch RoomType(req.RoomType) {
@@ -253,6 +260,10 @@ func (s *Service) SendMessage(ctx context.Context, userID, roomID uuid.UUID, req
 	// Update room's last message
 	_ = s.repo.UpdateRoomLastMessage(ctx, roomID, req.Content)
 
+	if s.events != nil {
+		s.events.Emit(ctx, events.MessageSent{MessageID: msg.ID, RoomID: roomID, SenderID: userID, MessageType: string(msgType), Attachments: len(attachments)})
+	}
+
 	// Broadcast to WebSocket clients
 	if s.hub != nil {
 		members, membersErr := s.repo.GetMembers(ctx, roomID)
@@ -10,6 +10,7 @@ const (
 	TxTypeRefund     TxType = "refund"
 	TxTypePurchase   TxType = "purchase"
 	TxTypeAdminGrant TxType = "admin_grant"
+	TxTypeExpiration TxType = "expiration"
 )
 
 // TxMeta represents optional metadata attached to a credit transaction.
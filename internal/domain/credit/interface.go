@@ -15,6 +15,7 @@ const (
 	TransactionTypeRefund     TransactionType = "refund"
 	TransactionTypePurchase   TransactionType = "purchase"
 	TransactionTypeAdminGrant TransactionType = "admin_grant"
+	TransactionTypeExpiration TransactionType = "expiration"
 )
 
 // TransactionMeta contains metadata for credit transactions
@@ -51,4 +52,13 @@ type Service interface {
 
 	// SearchTransactions returns filtered transactions (for admin use)
 	SearchTransactions(ctx context.Context, filters SearchFilters) ([]CreditTransaction, error)
+
+	// ConfigureLots sets lot expiry rules and the notifier used by RunExpiry
+	ConfigureLots(cfg LotConfig, notifier Notifier)
+
+	// GetLotBreakdown splits the balance into lots, soonest-expiring first
+	GetLotBreakdown(ctx context.Context, userID uuid.UUID) (*LotBreakdown, error)
+
+	// RunExpiry expires lots past their expiry and sends expiring-soon and low-balance notifications
+	RunExpiry(ctx context.Context) (*ExpiryResult, error)
 }
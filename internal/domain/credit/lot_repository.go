@@ -0,0 +1,251 @@
+package credit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ExpiringCredits is one user's credits that expire soon
+type ExpiringCredits struct {
+	UserID    uuid.UUID
+	Amount    int
+	ExpiresAt time.Time // earliest expiry among the lots
+}
+
+// LowBalance is a user whose balance dropped below the threshold
+type LowBalance struct {
+	UserID  uuid.UUID `db:"id"`
+	Balance int       `db:"credit_balance"`
+}
+
+const lotColumns = `id, user_id, source, amount, remaining, expires_at, created_at`
+
+// insertLot records credits added by a grant, purchase or refund
+func (r *CreditRepository) insertLot(ctx context.Context, tx *sqlx.Tx, userID string, amount int, txType string) error {
+	expiresAt := r.lots.expiryFor(txType, time.Now())
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO credit_lots (user_id, source, amount, remaining, expires_at)
+		VALUES ($1, $2, $3, $3, $4)
+	`, userID, txType, amount, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%w: insert lot", ErrInternal)
+	}
+	return nil
+}
+
+// lockOpenLots locks the user's lots that still hold credits
+func (r *CreditRepository) lockOpenLots(ctx context.Context, tx *sqlx.Tx, userID string) ([]CreditLot, error) {
+	lots := make([]CreditLot, 0)
+	err := tx.SelectContext(ctx, &lots, `
+		SELECT `+lotColumns+` FROM credit_lots
+		WHERE user_id = $1 AND remaining > 0
+		ORDER BY expires_at ASC NULLS LAST, created_at ASC
+		FOR UPDATE
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: lock lots", ErrInternal)
+	}
+	return lots, nil
+}
+
+func (r *CreditRepository) applyDraws(ctx context.Context, tx *sqlx.Tx, draws []lotDraw) error {
+	for _, d := range draws {
+		if _, err := tx.ExecContext(ctx, `UPDATE credit_lots SET remaining = remaining - $2 WHERE id = $1`, d.LotID, d.Amount); err != nil {
+			return fmt.Errorf("%w: consume lot", ErrInternal)
+		}
+	}
+	return nil
+}
+
+// consumeLots takes a deduction from the soonest-expiring lots.
+// The caller holds the user row lock.
+func (r *CreditRepository) consumeLots(ctx context.Context, tx *sqlx.Tx, userID string, amount int) error {
+	lots, err := r.lockOpenLots(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	return r.applyDraws(ctx, tx, planConsumption(lots, amount))
+}
+
+// ListOpenLots returns the user's lots that still hold credits
+func (r *CreditRepository) ListOpenLots(ctx context.Context, userID string) ([]CreditLot, error) {
+	ctx2, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	lots := make([]CreditLot, 0)
+	err := r.db.SelectContext(ctx2, &lots, `
+		SELECT `+lotColumns+` FROM credit_lots
+		WHERE user_id = $1 AND remaining > 0
+		ORDER BY expires_at ASC NULLS LAST, created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: list lots", ErrInternal)
+	}
+	return lots, nil
+}
+
+// ListUsersWithExpiredLots returns users holding credits in lots past their expiry
+func (r *CreditRepository) ListUsersWithExpiredLots(ctx context.Context, limit int) ([]string, error) {
+	ctx2, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	users := make([]string, 0)
+	err := r.db.SelectContext(ctx2, &users, `
+		SELECT DISTINCT user_id FROM credit_lots
+		WHERE remaining > 0 AND expires_at IS NOT NULL AND expires_at <= NOW()
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: list expired lots", ErrInternal)
+	}
+	return users, nil
+}
+
+// ExpireUserLots removes the remaining credits of the user's expired lots from the
+// balance, writing an expiration transaction per lot. Returns the credits expired.
+func (r *CreditRepository) ExpireUserLots(ctx context.Context, userID string) (int, error) {
+	ctx2, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx2, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("%w: begin tx", ErrInternal)
+	}
+	defer tx.Rollback()
+
+	// Same lock order as Deduct: user row, then lots
+	var balance int
+	if err := tx.QueryRowContext(ctx2, `SELECT credit_balance FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&balance); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("%w: lock user row", ErrInternal)
+	}
+	lots, err := r.lockOpenLots(ctx2, tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	draws, _ := alignLots(lots, balance)
+	if err := r.applyDraws(ctx2, tx, draws); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	total := 0
+	for _, lot := range lots {
+		if !lot.ExpiresAt.Valid || lot.ExpiresAt.Time.After(now) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx2, `UPDATE credit_lots SET remaining = 0, expired_at = NOW() WHERE id = $1`, lot.ID); err != nil {
+			return 0, fmt.Errorf("%w: expire lot", ErrInternal)
+		}
+		if lot.Remaining <= 0 {
+			continue
+		}
+		entityType, entityID := "credit_lot", lot.ID
+		meta := TxMeta{
+			RelatedEntityType: &entityType,
+			RelatedEntityID:   &entityID,
+			Description:       fmt.Sprintf("%d credits from %s expired", lot.Remaining, lot.Source),
+		}
+		if err := r.insertLedger(ctx2, tx, userID, -lot.Remaining, string(TxTypeExpiration), meta); err != nil {
+			return 0, err
+		}
+		total += lot.Remaining
+	}
+
+	if total > 0 {
+		if _, err := tx.ExecContext(ctx2, `UPDATE users SET credit_balance = credit_balance - $2 WHERE id = $1`, userID, total); err != nil {
+			return 0, fmt.Errorf("%w: update user balance", ErrInternal)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%w: commit tx", ErrInternal)
+	}
+	return total, nil
+}
+
+// ClaimExpiringLots marks lots expiring within the window as notified and
+// returns them grouped by user
+func (r *CreditRepository) ClaimExpiringLots(ctx context.Context, within time.Duration, limit int) ([]ExpiringCredits, error) {
+	ctx2, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var rows []struct {
+		UserID    uuid.UUID `db:"user_id"`
+		Remaining int       `db:"remaining"`
+		ExpiresAt time.Time `db:"expires_at"`
+	}
+	err := r.db.SelectContext(ctx2, &rows, `
+		WITH due AS (
+			SELECT id FROM credit_lots
+			WHERE remaining > 0 AND expiry_notified_at IS NULL
+				AND expires_at > NOW() AND expires_at <= NOW() + make_interval(secs => $1)
+			ORDER BY expires_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE credit_lots l SET expiry_notified_at = NOW()
+		FROM due WHERE l.id = due.id
+		RETURNING l.user_id, l.remaining, l.expires_at
+	`, within.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: claim expiring lots", ErrInternal)
+	}
+
+	byUser := map[uuid.UUID]int{}
+	out := make([]ExpiringCredits, 0)
+	for _, row := range rows {
+		i, ok := byUser[row.UserID]
+		if !ok {
+			byUser[row.UserID] = len(out)
+			out = append(out, ExpiringCredits{UserID: row.UserID, Amount: row.Remaining, ExpiresAt: row.ExpiresAt})
+			continue
+		}
+		out[i].Amount += row.Remaining
+		if row.ExpiresAt.Before(out[i].ExpiresAt) {
+			out[i].ExpiresAt = row.ExpiresAt
+		}
+	}
+	return out, nil
+}
+
+// ClaimLowBalanceUsers returns users who spent credits in the last 30 days and
+// are now below threshold, once until the balance is topped up again
+func (r *CreditRepository) ClaimLowBalanceUsers(ctx context.Context, threshold, limit int) ([]LowBalance, error) {
+	ctx2, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx2, `
+		UPDATE users SET credits_low_notified_at = NULL
+		WHERE credits_low_notified_at IS NOT NULL AND credit_balance >= $1
+	`, threshold); err != nil {
+		return nil, fmt.Errorf("%w: reset low balance flags", ErrInternal)
+	}
+
+	low := make([]LowBalance, 0)
+	err := r.db.SelectContext(ctx2, &low, `
+		UPDATE users u SET credits_low_notified_at = NOW()
+		WHERE u.id IN (
+			SELECT x.id FROM users x
+			WHERE x.credit_balance < $1 AND x.credits_low_notified_at IS NULL
+				AND EXISTS (
+					SELECT 1 FROM credit_transactions ct
+					WHERE ct.user_id = x.id AND ct.tx_type IN ('deduction', 'expiration')
+						AND ct.created_at > NOW() - INTERVAL '30 days'
+				)
+			LIMIT $2
+		)
+		RETURNING u.id, u.credit_balance
+	`, threshold, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: claim low balance users", ErrInternal)
+	}
+	return low, nil
+}
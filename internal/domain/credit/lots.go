@@ -0,0 +1,294 @@
+package credit
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// CreditLot is a batch of credits from one grant, purchase or refund.
+// Spending draws from the soonest-expiring lots first; lots without expiry go last.
+type CreditLot struct {
+	ID        string       `db:"id" json:"id"`
+	UserID    string       `db:"user_id" json:"-"`
+	Source    string       `db:"source" json:"source"`
+	Amount    int          `db:"amount" json:"amount"`
+	Remaining int          `db:"remaining" json:"remaining"`
+	ExpiresAt sql.NullTime `db:"expires_at" json:"-"`
+	CreatedAt time.Time    `db:"created_at" json:"created_at"`
+}
+
+// LotConfig controls lot expiry and balance notifications
+type LotConfig struct {
+	GrantExpiry         time.Duration // admin-granted (promotional) credits expire after this; 0 keeps them forever
+	ExpiringSoonWithin  time.Duration // notify about lots expiring within this window
+	LowBalanceThreshold int           // notify when an active user's balance drops below this; 0 disables
+	BatchSize           int
+}
+
+// expiryFor returns when a new lot of the given type expires
+func (c LotConfig) expiryFor(txType string, now time.Time) sql.NullTime {
+	if txType == string(TxTypeAdminGrant) && c.GrantExpiry > 0 {
+		return sql.NullTime{Time: now.Add(c.GrantExpiry), Valid: true}
+	}
+	return sql.NullTime{}
+}
+
+// Notifier tells users about expiring credits and low balance
+type Notifier interface {
+	NotifyCreditsExpiring(ctx context.Context, userID uuid.UUID, amount int, expiresAt time.Time)
+	NotifyCreditsLow(ctx context.Context, userID uuid.UUID, balance int)
+}
+
+// LotView is a lot as shown in the balance breakdown
+type LotView struct {
+	ID        string     `json:"id"`
+	Source    string     `json:"source"`
+	Amount    int        `json:"amount"`
+	Remaining int        `json:"remaining"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LotBreakdown splits a balance into its lots
+type LotBreakdown struct {
+	Balance      int        `json:"balance"`
+	Untracked    int        `json:"untracked"` // balance not covered by any lot, never expires
+	ExpiringSoon int        `json:"expiring_soon"`
+	NextExpiry   *time.Time `json:"next_expiry,omitempty"`
+	Lots         []LotView  `json:"lots"`
+}
+
+// ExpiryResult summarises one expiry pass
+type ExpiryResult struct {
+	ExpiredCredits   int
+	UsersExpired     int
+	ExpiringNotified int
+	LowNotified      int
+}
+
+// lotDraw is an amount taken from one lot
+type lotDraw struct {
+	LotID  string
+	Amount int
+}
+
+// sortBySpendPriority orders lots soonest-expiring first, then oldest first
+func sortBySpendPriority(lots []CreditLot) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, b := lots[i], lots[j]
+		if a.ExpiresAt.Valid != b.ExpiresAt.Valid {
+			return a.ExpiresAt.Valid
+		}
+		if a.ExpiresAt.Valid && !a.ExpiresAt.Time.Equal(b.ExpiresAt.Time) {
+			return a.ExpiresAt.Time.Before(b.ExpiresAt.Time)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
+
+// planConsumption draws amount from lots in spend priority. Whatever the lots
+// don't cover comes from the untracked part of the balance.
+func planConsumption(lots []CreditLot, amount int) []lotDraw {
+	sortBySpendPriority(lots)
+	var draws []lotDraw
+	for _, lot := range lots {
+		if amount <= 0 {
+			break
+		}
+		take := lot.Remaining
+		if take > amount {
+			take = amount
+		}
+		if take <= 0 {
+			continue
+		}
+		draws = append(draws, lotDraw{LotID: lot.ID, Amount: take})
+		amount -= take
+	}
+	return draws
+}
+
+// alignLots trims lots so they never hold more than the balance. Credits spent
+// outside the lot-aware paths are taken from the soonest-expiring lots.
+// It returns the trimming draws and the untracked part of the balance.
+func alignLots(lots []CreditLot, balance int) ([]lotDraw, int) {
+	tracked := 0
+	for _, lot := range lots {
+		tracked += lot.Remaining
+	}
+	if tracked <= balance {
+		return nil, balance - tracked
+	}
+	draws := planConsumption(lots, tracked-balance)
+	byID := make(map[string]int, len(draws))
+	for _, d := range draws {
+		byID[d.LotID] = d.Amount
+	}
+	for i := range lots {
+		lots[i].Remaining -= byID[lots[i].ID]
+	}
+	return draws, 0
+}
+
+func (s *service) ConfigureLots(cfg LotConfig, notifier Notifier) {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 200
+	}
+	s.lots = cfg
+	s.notifier = notifier
+	s.repo.lots = cfg
+}
+
+// GetLotBreakdown splits the balance into lots, soonest-expiring first
+func (s *service) GetLotBreakdown(ctx context.Context, userID uuid.UUID) (*LotBreakdown, error) {
+	balance, err := s.repo.GetBalance(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	lots, err := s.repo.ListOpenLots(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+	_, untracked := alignLots(lots, balance)
+	sortBySpendPriority(lots)
+
+	now := time.Now()
+	out := &LotBreakdown{Balance: balance, Untracked: untracked, Lots: make([]LotView, 0, len(lots))}
+	for _, lot := range lots {
+		if lot.Remaining <= 0 {
+			continue
+		}
+		view := LotView{ID: lot.ID, Source: lot.Source, Amount: lot.Amount, Remaining: lot.Remaining, CreatedAt: lot.CreatedAt}
+		if lot.ExpiresAt.Valid {
+			expiresAt := lot.ExpiresAt.Time
+			view.ExpiresAt = &expiresAt
+			if out.NextExpiry == nil {
+				out.NextExpiry = &expiresAt
+			}
+			if s.lots.ExpiringSoonWithin > 0 && expiresAt.Before(now.Add(s.lots.ExpiringSoonWithin)) {
+				out.ExpiringSoon += lot.Remaining
+			}
+		}
+		out.Lots = append(out.Lots, view)
+	}
+	return out, nil
+}
+
+// RunExpiry expires lots past their expiry and sends expiring-soon and low-balance notifications
+func (s *service) RunExpiry(ctx context.Context) (*ExpiryResult, error) {
+	result := &ExpiryResult{}
+
+	users, err := s.repo.ListUsersWithExpiredLots(ctx, s.lots.BatchSize)
+	if err != nil {
+		return result, err
+	}
+	for _, userID := range users {
+		expired, err := s.repo.ExpireUserLots(ctx, userID)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", userID).Msg("Failed to expire credit lots")
+			continue
+		}
+		if expired > 0 {
+			result.ExpiredCredits += expired
+			result.UsersExpired++
+		}
+	}
+
+	if s.notifier == nil {
+		return result, nil
+	}
+
+	if s.lots.ExpiringSoonWithin > 0 {
+		expiring, err := s.repo.ClaimExpiringLots(ctx, s.lots.ExpiringSoonWithin, s.lots.BatchSize)
+		if err != nil {
+			return result, err
+		}
+		for _, e := range expiring {
+			s.notifier.NotifyCreditsExpiring(ctx, e.UserID, e.Amount, e.ExpiresAt)
+		}
+		result.ExpiringNotified = len(expiring)
+	}
+
+	if s.lots.LowBalanceThreshold > 0 {
+		low, err := s.repo.ClaimLowBalanceUsers(ctx, s.lots.LowBalanceThreshold, s.lots.BatchSize)
+		if err != nil {
+			return result, err
+		}
+		for _, l := range low {
+			s.notifier.NotifyCreditsLow(ctx, l.UserID, l.Balance)
+		}
+		result.LowNotified = len(low)
+	}
+	return result, nil
+}
+
+// ExpiryWorker periodically expires credit lots and sends balance notifications
+type ExpiryWorker struct {
+	service  Service
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewExpiryWorker creates a new credit expiry worker
+func NewExpiryWorker(service Service, interval time.Duration) *ExpiryWorker {
+	if interval == 0 {
+		interval = 1 * time.Hour
+	}
+	return &ExpiryWorker{
+		service:  service,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the background worker
+func (w *ExpiryWorker) Start() {
+	log.Info().Msg("Starting credit expiry worker...")
+	go w.loop()
+}
+
+// Stop gracefully stops the background worker
+func (w *ExpiryWorker) Stop() {
+	log.Info().Msg("Stopping credit expiry worker...")
+	close(w.stopCh)
+}
+
+func (w *ExpiryWorker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run once immediately on startup
+	w.run()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.run()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *ExpiryWorker) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	result, err := w.service.RunExpiry(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Credit expiry run failed")
+	}
+	if result != nil && (result.ExpiredCredits > 0 || result.ExpiringNotified > 0 || result.LowNotified > 0) {
+		log.Info().
+			Int("expired_credits", result.ExpiredCredits).
+			Int("users_expired", result.UsersExpired).
+			Int("expiring_notified", result.ExpiringNotified).
+			Int("low_notified", result.LowNotified).
+			Msg("Credit expiry processed")
+	}
+}
@@ -0,0 +1,75 @@
+package credit
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func lot(id string, remaining int, expiresIn time.Duration, age time.Duration) CreditLot {
+	now := time.Now()
+	l := CreditLot{ID: id, Amount: remaining, Remaining: remaining, CreatedAt: now.Add(-age)}
+	if expiresIn != 0 {
+		l.ExpiresAt = sql.NullTime{Time: now.Add(expiresIn), Valid: true}
+	}
+	return l
+}
+
+func TestPlanConsumption_SoonestExpiringFirst(t *testing.T) {
+	lots := []CreditLot{
+		lot("purchased", 10, 0, 48*time.Hour),
+		lot("grant-late", 3, 30*24*time.Hour, time.Hour),
+		lot("grant-soon", 2, 24*time.Hour, time.Hour),
+	}
+
+	draws := planConsumption(lots, 6)
+	want := []lotDraw{{"grant-soon", 2}, {"grant-late", 3}, {"purchased", 1}}
+	if len(draws) != len(want) {
+		t.Fatalf("expected %v, got %v", want, draws)
+	}
+	for i := range want {
+		if draws[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, draws)
+		}
+	}
+}
+
+func TestPlanConsumption_UntrackedRemainder(t *testing.T) {
+	draws := planConsumption([]CreditLot{lot("a", 2, 0, time.Hour)}, 5)
+	if len(draws) != 1 || draws[0].Amount != 2 {
+		t.Fatalf("lots cover only what they hold, got %v", draws)
+	}
+}
+
+func TestAlignLots(t *testing.T) {
+	lots := []CreditLot{lot("grant", 4, 24*time.Hour, time.Hour), lot("purchased", 5, 0, time.Hour)}
+
+	// Balance spent outside lot tracking comes off the expiring lot first
+	draws, untracked := alignLots(lots, 6)
+	if untracked != 0 || len(draws) != 1 || draws[0] != (lotDraw{"grant", 3}) {
+		t.Fatalf("unexpected alignment: %v untracked=%d", draws, untracked)
+	}
+	if lots[0].Remaining != 1 || lots[1].Remaining != 5 {
+		t.Fatalf("unexpected lots after alignment: %+v", lots)
+	}
+
+	if draws, untracked := alignLots(lots, 10); draws != nil || untracked != 4 {
+		t.Fatalf("expected 4 untracked credits, got %v %d", draws, untracked)
+	}
+}
+
+func TestLotConfig_OnlyGrantsExpire(t *testing.T) {
+	cfg := LotConfig{GrantExpiry: 90 * 24 * time.Hour}
+	now := time.Now()
+	if got := cfg.expiryFor(string(TxTypeAdminGrant), now); !got.Valid || !got.Time.Equal(now.Add(cfg.GrantExpiry)) {
+		t.Fatalf("admin grants must expire, got %+v", got)
+	}
+	for _, txType := range []TxType{TxTypePurchase, TxTypeRefund} {
+		if cfg.expiryFor(string(txType), now).Valid {
+			t.Fatalf("%s lots must not expire", txType)
+		}
+	}
+	if (LotConfig{}).expiryFor(string(TxTypeAdminGrant), now).Valid {
+		t.Fatal("zero grant expiry keeps grants forever")
+	}
+}
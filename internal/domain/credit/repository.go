@@ -24,7 +24,8 @@ type Repository interface {
 
 // CreditRepository provides credit ledger and balance operations.
 type CreditRepository struct {
-	db *sqlx.DB
+	db   *sqlx.DB
+	lots LotConfig
 }
 
 func NewRepository(db *sqlx.DB) *CreditRepository {
@@ -66,6 +67,10 @@ func (r *CreditRepository) Deduct(ctx context.Context, userID string, amount int
 		return err
 	}
 
+	if err := r.consumeLots(ctx2, tx, userID, amount); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("%w: commit tx", ErrInternal)
 	}
@@ -105,7 +110,7 @@ func (r *CreditRepository) DeductTx(ctx context.Context, tx *sqlx.Tx, userID str
 		return err
 	}
 
-	return nil
+	return r.consumeLots(ctx, tx, userID, amount)
 }
 
 func (r *CreditRepository) Add(ctx context.Context, userID string, amount int, txType string, meta TxMeta) error {
@@ -143,6 +148,10 @@ func (r *CreditRepository) Add(ctx context.Context, userID string, amount int, t
 		return err
 	}
 
+	if err := r.insertLot(ctx2, tx, userID, amount, txType); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("%w: commit tx", ErrInternal)
 	}
@@ -254,7 +263,7 @@ func (r *CreditRepository) insertLedger(ctx context.Context, tx *sqlx.Tx, userID
 		txType = string(TxTypeAdminGrant)
 	}
 
-	if txType != string(TxTypeDeduction) && txType != string(TxTypeRefund) && txType != string(TxTypePurchase) && txType != string(TxTypeAdminGrant) {
+	if txType != string(TxTypeDeduction) && txType != string(TxTypeRefund) && txType != string(TxTypePurchase) && txType != string(TxTypeAdminGrant) && txType != string(TxTypeExpiration) {
 		return ErrInternal
 	}
 
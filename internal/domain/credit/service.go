@@ -10,7 +10,9 @@ import (
 
 // service implements the Service interface
 type service struct {
-	repo *CreditRepository
+	repo     *CreditRepository
+	lots     LotConfig
+	notifier Notifier
 }
 
 // NewService creates a new credit service
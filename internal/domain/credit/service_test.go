@@ -211,6 +211,7 @@ func cleanupTestDB(db *sqlx.DB) {
 	if db == nil {
 		return
 	}
+	db.Exec("DELETE FROM credit_lots")
 	db.Exec("DELETE FROM credit_transactions")
 	db.Exec("DELETE FROM users")
 	db.Close()
@@ -0,0 +1,471 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/casting"
+	"github.com/mwork/mwork-api/internal/domain/profile"
+	"github.com/mwork/mwork-api/internal/domain/response"
+)
+
+// ErrCastingNotOwned is returned when an employer asks for another employer's casting
+var ErrCastingNotOwned = errors.New("casting does not belong to this employer")
+
+// Funnel is the hiring funnel: views → responses → viewed → shortlisted → accepted.
+// Response stages count responses by their current status.
+type Funnel struct {
+	Views         int `json:"views" db:"views"`
+	UniqueViewers int `json:"unique_viewers" db:"unique_viewers"`
+	Responses     int `json:"responses" db:"responses"`
+	Viewed        int `json:"viewed" db:"viewed"`
+	Shortlisted   int `json:"shortlisted" db:"shortlisted"`
+	Accepted      int `json:"accepted" db:"accepted"`
+	Rejected      int `json:"rejected" db:"rejected"`
+}
+
+// Rates returns the step conversion rates of the funnel in percent
+func (f Funnel) Rates() FunnelRates {
+	return FunnelRates{
+		ViewToResponse:        percent(f.Responses, f.Views),
+		ResponseToViewed:      percent(f.Viewed, f.Responses),
+		ViewedToShortlisted:   percent(f.Shortlisted, f.Viewed),
+		ShortlistedToAccepted: percent(f.Accepted, f.Shortlisted),
+	}
+}
+
+// FunnelRates are step conversion rates in percent
+type FunnelRates struct {
+	ViewToResponse        float64 `json:"view_to_response"`
+	ResponseToViewed      float64 `json:"response_to_viewed"`
+	ViewedToShortlisted   float64 `json:"viewed_to_shortlisted"`
+	ShortlistedToAccepted float64 `json:"shortlisted_to_accepted"`
+}
+
+// CastingAnalytics is the funnel and quality of one casting over a period
+type CastingAnalytics struct {
+	CastingID               uuid.UUID   `json:"casting_id"`
+	Title                   string      `json:"title"`
+	Status                  string      `json:"status"`
+	CreatedAt               time.Time   `json:"created_at"`
+	FirstResponseAt         *time.Time  `json:"first_response_at,omitempty"`
+	TimeToFirstResponseHour *float64    `json:"time_to_first_response_hours,omitempty"`
+	MatchRate               *float64    `json:"match_rate,omitempty"` // average requirement match of applicants, percent
+	Funnel                  Funnel      `json:"funnel"`
+	Rates                   FunnelRates `json:"rates"`
+
+	matchScoreSum float64
+	matchScored   int
+}
+
+// DailyPoint is one day of an employer's or casting's funnel
+type DailyPoint struct {
+	Date time.Time `json:"date" db:"date"`
+	Funnel
+}
+
+// CityTraffic is views and responses from one city
+type CityTraffic struct {
+	City      string `json:"city" db:"city"`
+	Views     int    `json:"views" db:"views"`
+	Responses int    `json:"responses" db:"responses"`
+}
+
+// Trend compares the last 7 days with the 7 days before
+type Trend struct {
+	ThisWeek          Funnel  `json:"this_week"`
+	LastWeek          Funnel  `json:"last_week"`
+	ViewsChange       float64 `json:"views_change_percent"`
+	ResponsesChange   float64 `json:"responses_change_percent"`
+	ShortlistedChange float64 `json:"shortlisted_change_percent"`
+	AcceptedChange    float64 `json:"accepted_change_percent"`
+}
+
+// EmployerAnalytics is the hiring analytics dashboard of an employer
+type EmployerAnalytics struct {
+	From                    time.Time          `json:"from"`
+	To                      time.Time          `json:"to"`
+	Funnel                  Funnel             `json:"funnel"`
+	Rates                   FunnelRates        `json:"rates"`
+	AvgTimeToFirstResponseH *float64           `json:"avg_time_to_first_response_hours,omitempty"`
+	MatchRate               *float64           `json:"match_rate,omitempty"`
+	Cities                  []CityTraffic      `json:"cities"`
+	WeekOverWeek            Trend              `json:"week_over_week"`
+	Daily                   []DailyPoint       `json:"daily"`
+	Castings                []CastingAnalytics `json:"castings"`
+}
+
+// CastingReport is the analytics of a single casting
+type CastingReport struct {
+	CastingAnalytics
+	Cities []CityTraffic `json:"cities"`
+	Daily  []DailyPoint  `json:"daily"`
+}
+
+// AnalyticsConfig controls the aggregation job
+type AnalyticsConfig struct {
+	Window    time.Duration // recent days re-aggregated on every run, so status changes are picked up
+	BatchSize int           // responses scored per batch
+}
+
+// AnalyticsService builds employer hiring analytics from daily aggregates
+type AnalyticsService struct {
+	repo        *AnalyticsRepository
+	castingRepo casting.Repository
+	modelRepo   profile.ModelRepository
+	cfg         AnalyticsConfig
+}
+
+// NewAnalyticsService creates employer analytics service
+func NewAnalyticsService(repo *AnalyticsRepository, castingRepo casting.Repository, modelRepo profile.ModelRepository, cfg AnalyticsConfig) *AnalyticsService {
+	if cfg.Window <= 0 {
+		cfg.Window = 35 * 24 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	return &AnalyticsService{repo: repo, castingRepo: castingRepo, modelRepo: modelRepo, cfg: cfg}
+}
+
+// period returns the first and last day of a period of days ending today
+func period(days int, now time.Time) (time.Time, time.Time) {
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return to.AddDate(0, 0, -(days - 1)), to
+}
+
+// GetEmployerAnalytics returns the employer's funnel, quality, traffic and trends over the last days
+func (s *AnalyticsService) GetEmployerAnalytics(ctx context.Context, userID uuid.UUID, days int) (*EmployerAnalytics, error) {
+	now := time.Now()
+	from, to := period(days, now)
+
+	castings, err := s.repo.EmployerCastings(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	cities, err := s.repo.EmployerCities(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	// Trends always look at two full weeks, whatever the period
+	trendFrom, _ := period(14, now)
+	dailyFrom := from
+	if trendFrom.Before(dailyFrom) {
+		dailyFrom = trendFrom
+	}
+	daily, err := s.repo.EmployerDaily(ctx, userID, dailyFrom, to)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &EmployerAnalytics{From: from, To: to, Cities: cities, Castings: castings, WeekOverWeek: weekOverWeek(daily, to)}
+	var ttfrSum float64
+	var ttfrCount int
+	var matchSum float64
+	var matchCount int
+	for i := range out.Castings {
+		c := &out.Castings[i]
+		finish(c)
+		out.Funnel = out.Funnel.add(c.Funnel)
+		if c.TimeToFirstResponseHour != nil {
+			ttfrSum += *c.TimeToFirstResponseHour
+			ttfrCount++
+		}
+		matchSum += c.matchScoreSum
+		matchCount += c.matchScored
+	}
+	// Distinct viewers do not add up across castings or days, so they are counted separately
+	if out.Funnel.UniqueViewers, err = s.repo.EmployerUniqueViewers(ctx, userID, from, to); err != nil {
+		return nil, err
+	}
+	thisFrom, lastFrom := to.AddDate(0, 0, -6), to.AddDate(0, 0, -13)
+	if out.WeekOverWeek.ThisWeek.UniqueViewers, err = s.repo.EmployerUniqueViewers(ctx, userID, thisFrom, to); err != nil {
+		return nil, err
+	}
+	if out.WeekOverWeek.LastWeek.UniqueViewers, err = s.repo.EmployerUniqueViewers(ctx, userID, lastFrom, thisFrom.AddDate(0, 0, -1)); err != nil {
+		return nil, err
+	}
+	out.Rates = out.Funnel.Rates()
+	if ttfrCount > 0 {
+		avg := round1(ttfrSum / float64(ttfrCount))
+		out.AvgTimeToFirstResponseH = &avg
+	}
+	if matchCount > 0 {
+		rate := round1(matchSum / float64(matchCount) * 100)
+		out.MatchRate = &rate
+	}
+	for _, d := range daily {
+		if !d.Date.Before(from) {
+			out.Daily = append(out.Daily, d)
+		}
+	}
+	return out, nil
+}
+
+// GetCastingAnalytics returns one casting's analytics; the casting must belong to the employer
+func (s *AnalyticsService) GetCastingAnalytics(ctx context.Context, userID, castingID uuid.UUID, days int) (*CastingReport, error) {
+	from, to := period(days, time.Now())
+
+	c, creatorID, err := s.repo.CastingSummary(ctx, castingID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, casting.ErrCastingNotFound
+	}
+	if creatorID != userID {
+		return nil, ErrCastingNotOwned
+	}
+	finish(c)
+
+	report := &CastingReport{CastingAnalytics: *c}
+	if report.Cities, err = s.repo.CastingCities(ctx, castingID, from, to); err != nil {
+		return nil, err
+	}
+	if report.Daily, err = s.repo.CastingDaily(ctx, castingID, from, to); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// finish derives the computed fields of a casting summary
+func finish(c *CastingAnalytics) {
+	c.Rates = c.Funnel.Rates()
+	if c.FirstResponseAt != nil {
+		hours := round1(c.FirstResponseAt.Sub(c.CreatedAt).Hours())
+		c.TimeToFirstResponseHour = &hours
+	}
+	if c.matchScored > 0 {
+		rate := round1(c.matchScoreSum / float64(c.matchScored) * 100)
+		c.MatchRate = &rate
+	}
+}
+
+// WriteCSV writes the per-casting analytics as CSV
+func WriteCSV(w io.Writer, a *EmployerAnalytics) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"casting_id", "title", "status", "created_at", "views", "unique_viewers", "responses",
+		"viewed", "shortlisted", "accepted", "rejected", "view_to_response_pct",
+		"time_to_first_response_hours", "match_rate_pct",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, c := range a.Castings {
+		row := []string{
+			c.CastingID.String(), c.Title, c.Status, c.CreatedAt.Format(time.RFC3339),
+			strconv.Itoa(c.Funnel.Views), strconv.Itoa(c.Funnel.UniqueViewers), strconv.Itoa(c.Funnel.Responses),
+			strconv.Itoa(c.Funnel.Viewed), strconv.Itoa(c.Funnel.Shortlisted), strconv.Itoa(c.Funnel.Accepted), strconv.Itoa(c.Funnel.Rejected),
+			formatFloat(&c.Rates.ViewToResponse), formatFloat(c.TimeToFirstResponseHour), formatFloat(c.MatchRate),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// AggregationResult summarises one aggregation run
+type AggregationResult struct {
+	Scored      int
+	CastingDays int64
+	CityDays    int64
+}
+
+// RunAggregation scores new responses against casting requirements and
+// rebuilds the daily aggregates of the recent window
+func (s *AnalyticsService) RunAggregation(ctx context.Context) (*AggregationResult, error) {
+	result := &AggregationResult{}
+
+	scored, err := s.scoreResponses(ctx)
+	result.Scored = scored
+	if err != nil {
+		return result, err
+	}
+
+	from, to := period(int(s.cfg.Window/(24*time.Hour)), time.Now())
+	result.CastingDays, result.CityDays, err = s.repo.Aggregate(ctx, from, to)
+	return result, err
+}
+
+// scoreResponses stores the requirement match of responses not scored yet
+func (s *AnalyticsService) scoreResponses(ctx context.Context) (int, error) {
+	castings := make(map[uuid.UUID]*casting.Casting)
+	scored := 0
+	for {
+		pending, err := s.repo.ListUnscoredResponses(ctx, s.cfg.BatchSize)
+		if err != nil {
+			return scored, err
+		}
+		if len(pending) == 0 {
+			return scored, nil
+		}
+		for _, p := range pending {
+			score := 0.0
+			c, ok := castings[p.CastingID]
+			if !ok {
+				if c, err = s.castingRepo.GetByID(ctx, p.CastingID); err != nil {
+					return scored, err
+				}
+				castings[p.CastingID] = c
+			}
+			prof, err := s.modelRepo.GetByID(ctx, p.ModelID)
+			if err != nil {
+				return scored, err
+			}
+			if c != nil && prof != nil {
+				score = response.RequirementMatch(c, prof)
+			}
+			// Responses whose casting or profile is gone score 0 so they are not retried forever
+			if err := s.repo.SetRequirementMatch(ctx, p.ID, score); err != nil {
+				return scored, err
+			}
+			scored++
+		}
+		if len(pending) < s.cfg.BatchSize {
+			return scored, nil
+		}
+	}
+}
+
+// weekOverWeek sums the 7 days ending at today and the 7 days before
+func weekOverWeek(daily []DailyPoint, today time.Time) Trend {
+	var t Trend
+	thisFrom := today.AddDate(0, 0, -6)
+	lastFrom := today.AddDate(0, 0, -13)
+	for _, d := range daily {
+		switch {
+		case !d.Date.Before(thisFrom) && !d.Date.After(today):
+			t.ThisWeek = t.ThisWeek.add(d.Funnel)
+		case !d.Date.Before(lastFrom) && d.Date.Before(thisFrom):
+			t.LastWeek = t.LastWeek.add(d.Funnel)
+		}
+	}
+	t.ViewsChange = change(t.ThisWeek.Views, t.LastWeek.Views)
+	t.ResponsesChange = change(t.ThisWeek.Responses, t.LastWeek.Responses)
+	t.ShortlistedChange = change(t.ThisWeek.Shortlisted, t.LastWeek.Shortlisted)
+	t.AcceptedChange = change(t.ThisWeek.Accepted, t.LastWeek.Accepted)
+	return t
+}
+
+func (f Funnel) add(o Funnel) Funnel {
+	return Funnel{
+		Views:         f.Views + o.Views,
+		UniqueViewers: f.UniqueViewers + o.UniqueViewers,
+		Responses:     f.Responses + o.Responses,
+		Viewed:        f.Viewed + o.Viewed,
+		Shortlisted:   f.Shortlisted + o.Shortlisted,
+		Accepted:      f.Accepted + o.Accepted,
+		Rejected:      f.Rejected + o.Rejected,
+	}
+}
+
+// change returns the relative change in percent; growth from zero counts as 100%
+func change(current, previous int) float64 {
+	if previous == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 100
+	}
+	return round1(float64(current-previous) / float64(previous) * 100)
+}
+
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return round1(float64(part) / float64(whole) * 100)
+}
+
+func round1(v float64) float64 {
+	return float64(int64(v*10+0.5*sign(v))) / 10
+}
+
+func sign(v float64) float64 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", *v)
+}
+
+// AnalyticsWorker rebuilds employer analytics aggregates periodically
+type AnalyticsWorker struct {
+	service  *AnalyticsService
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewAnalyticsWorker creates a new employer analytics worker
+func NewAnalyticsWorker(service *AnalyticsService, interval time.Duration) *AnalyticsWorker {
+	if interval == 0 {
+		interval = time.Hour
+	}
+	return &AnalyticsWorker{
+		service:  service,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the background worker
+func (w *AnalyticsWorker) Start() {
+	log.Info().Msg("Starting employer analytics worker...")
+	go w.loop()
+}
+
+// Stop gracefully stops the background worker
+func (w *AnalyticsWorker) Stop() {
+	log.Info().Msg("Stopping employer analytics worker...")
+	close(w.stopCh)
+}
+
+func (w *AnalyticsWorker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run once immediately on startup
+	w.run()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.run()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *AnalyticsWorker) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	result, err := w.service.RunAggregation(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Employer analytics aggregation failed")
+	}
+	if result != nil {
+		log.Info().
+			Int("scored_responses", result.Scored).
+			Int64("casting_days", result.CastingDays).
+			Int64("city_days", result.CityDays).
+			Msg("Employer analytics aggregated")
+	}
+}
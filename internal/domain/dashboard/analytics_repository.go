@@ -0,0 +1,289 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// AnalyticsRepository reads and rebuilds employer analytics aggregates
+type AnalyticsRepository struct {
+	db *sqlx.DB
+}
+
+// NewAnalyticsRepository creates employer analytics repository
+func NewAnalyticsRepository(db *sqlx.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+type castingRow struct {
+	CastingID       uuid.UUID  `db:"casting_id"`
+	CreatorID       uuid.UUID  `db:"creator_id"`
+	Title           string     `db:"title"`
+	Status          string     `db:"status"`
+	CreatedAt       time.Time  `db:"created_at"`
+	FirstResponseAt *time.Time `db:"first_response_at"`
+	MatchScoreSum   float64    `db:"match_score_sum"`
+	MatchScored     int        `db:"match_scored"`
+	Funnel
+}
+
+func (r castingRow) toAnalytics() CastingAnalytics {
+	return CastingAnalytics{
+		CastingID:       r.CastingID,
+		Title:           r.Title,
+		Status:          r.Status,
+		CreatedAt:       r.CreatedAt,
+		FirstResponseAt: r.FirstResponseAt,
+		Funnel:          r.Funnel,
+		matchScoreSum:   r.MatchScoreSum,
+		matchScored:     r.MatchScored,
+	}
+}
+
+// castingSummarySelect sums the daily aggregates of castings between $2 and $3.
+// Unique viewers are counted over the whole period from the view events, since daily
+// distinct counts do not add up. Time to first response is measured live since it
+// does not depend on the period.
+const castingSummarySelect = `
+	SELECT c.id AS casting_id, c.creator_id, c.title, c.status, c.created_at,
+		(SELECT MIN(cr.created_at) FROM casting_responses cr WHERE cr.casting_id = c.id) AS first_response_at,
+		COALESCE(SUM(d.views), 0) AS views,
+		(SELECT COUNT(DISTINCT e.viewer_id) FROM casting_view_events e
+			WHERE e.casting_id = c.id AND e.viewed_at >= $2 AND e.viewed_at < $3::date + 1) AS unique_viewers,
+		COALESCE(SUM(d.responses), 0) AS responses,
+		COALESCE(SUM(d.viewed), 0) AS viewed,
+		COALESCE(SUM(d.shortlisted), 0) AS shortlisted,
+		COALESCE(SUM(d.accepted), 0) AS accepted,
+		COALESCE(SUM(d.rejected), 0) AS rejected,
+		COALESCE(SUM(d.match_score_sum), 0) AS match_score_sum,
+		COALESCE(SUM(d.match_scored), 0) AS match_scored
+	FROM castings c
+	LEFT JOIN casting_analytics_daily d ON d.casting_id = c.id AND d.date BETWEEN $2 AND $3
+`
+
+// EmployerCastings returns the funnel of every casting of the employer
+func (r *AnalyticsRepository) EmployerCastings(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]CastingAnalytics, error) {
+	var rows []castingRow
+	err := r.db.SelectContext(ctx, &rows, castingSummarySelect+`
+		WHERE c.creator_id = $1 AND c.status != 'deleted'
+		GROUP BY c.id
+		ORDER BY c.created_at DESC
+	`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CastingAnalytics, len(rows))
+	for i, row := range rows {
+		out[i] = row.toAnalytics()
+	}
+	return out, nil
+}
+
+// CastingSummary returns the funnel of one casting and its creator
+func (r *AnalyticsRepository) CastingSummary(ctx context.Context, castingID uuid.UUID, from, to time.Time) (*CastingAnalytics, uuid.UUID, error) {
+	var row castingRow
+	err := r.db.GetContext(ctx, &row, castingSummarySelect+`
+		WHERE c.id = $1 AND c.status != 'deleted'
+		GROUP BY c.id
+	`, castingID, from, to)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, uuid.Nil, nil
+	}
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	a := row.toAnalytics()
+	return &a, row.CreatorID, nil
+}
+
+const dailySelect = `
+	SELECT date,
+		SUM(views) AS views, SUM(unique_viewers) AS unique_viewers,
+		SUM(responses) AS responses, SUM(viewed) AS viewed,
+		SUM(shortlisted) AS shortlisted, SUM(accepted) AS accepted, SUM(rejected) AS rejected
+	FROM casting_analytics_daily
+`
+
+// EmployerDaily returns the employer's funnel per day.
+// Unique viewers are distinct across all castings, so a model viewing several castings counts once.
+func (r *AnalyticsRepository) EmployerDaily(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]DailyPoint, error) {
+	var points []DailyPoint
+	err := r.db.SelectContext(ctx, &points, `
+		SELECT d.date,
+			SUM(d.views) AS views,
+			(SELECT COUNT(DISTINCT e.viewer_id) FROM casting_view_events e
+				JOIN castings c ON c.id = e.casting_id
+				WHERE c.creator_id = $1 AND e.viewed_at >= d.date AND e.viewed_at < d.date + 1) AS unique_viewers,
+			SUM(d.responses) AS responses, SUM(d.viewed) AS viewed,
+			SUM(d.shortlisted) AS shortlisted, SUM(d.accepted) AS accepted, SUM(d.rejected) AS rejected
+		FROM casting_analytics_daily d
+		WHERE d.creator_id = $1 AND d.date BETWEEN $2 AND $3
+		GROUP BY d.date ORDER BY d.date
+	`, userID, from, to)
+	return points, err
+}
+
+// EmployerUniqueViewers counts distinct viewers of the employer's castings between two days
+func (r *AnalyticsRepository) EmployerUniqueViewers(ctx context.Context, userID uuid.UUID, from, to time.Time) (int, error) {
+	var n int
+	err := r.db.GetContext(ctx, &n, `
+		SELECT COUNT(DISTINCT e.viewer_id)
+		FROM casting_view_events e
+		JOIN castings c ON c.id = e.casting_id
+		WHERE c.creator_id = $1 AND c.status != 'deleted'
+			AND e.viewed_at >= $2 AND e.viewed_at < $3::date + 1
+	`, userID, from, to)
+	return n, err
+}
+
+// CastingDaily returns one casting's funnel per day
+func (r *AnalyticsRepository) CastingDaily(ctx context.Context, castingID uuid.UUID, from, to time.Time) ([]DailyPoint, error) {
+	var points []DailyPoint
+	err := r.db.SelectContext(ctx, &points, dailySelect+`
+		WHERE casting_id = $1 AND date BETWEEN $2 AND $3
+		GROUP BY date ORDER BY date
+	`, castingID, from, to)
+	return points, err
+}
+
+// EmployerCities returns views and responses by city across the employer's castings
+func (r *AnalyticsRepository) EmployerCities(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]CityTraffic, error) {
+	var cities []CityTraffic
+	err := r.db.SelectContext(ctx, &cities, `
+		SELECT city, SUM(views) AS views, SUM(responses) AS responses
+		FROM casting_analytics_city_daily
+		WHERE creator_id = $1 AND date BETWEEN $2 AND $3
+		GROUP BY city ORDER BY SUM(views) + SUM(responses) DESC, city
+	`, userID, from, to)
+	return cities, err
+}
+
+// CastingCities returns views and responses by city for one casting
+func (r *AnalyticsRepository) CastingCities(ctx context.Context, castingID uuid.UUID, from, to time.Time) ([]CityTraffic, error) {
+	var cities []CityTraffic
+	err := r.db.SelectContext(ctx, &cities, `
+		SELECT city, SUM(views) AS views, SUM(responses) AS responses
+		FROM casting_analytics_city_daily
+		WHERE casting_id = $1 AND date BETWEEN $2 AND $3
+		GROUP BY city ORDER BY SUM(views) + SUM(responses) DESC, city
+	`, castingID, from, to)
+	return cities, err
+}
+
+// UnscoredResponse is a response without a requirement match score yet
+type UnscoredResponse struct {
+	ID        uuid.UUID `db:"id"`
+	CastingID uuid.UUID `db:"casting_id"`
+	ModelID   uuid.UUID `db:"model_id"`
+}
+
+// ListUnscoredResponses returns the oldest responses without a requirement match score
+func (r *AnalyticsRepository) ListUnscoredResponses(ctx context.Context, limit int) ([]UnscoredResponse, error) {
+	var rows []UnscoredResponse
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT id, casting_id, model_id FROM casting_responses
+		WHERE requirement_match IS NULL
+		ORDER BY created_at
+		LIMIT $1
+	`, limit)
+	return rows, err
+}
+
+// SetRequirementMatch stores the requirement match score of a response
+func (r *AnalyticsRepository) SetRequirementMatch(ctx context.Context, responseID uuid.UUID, score float64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE casting_responses SET requirement_match = $2 WHERE id = $1`, responseID, score)
+	return err
+}
+
+// Aggregate rebuilds the daily and city aggregates for the days between from and to.
+// Responses are counted on the day they were created, by their current status.
+func (r *AnalyticsRepository) Aggregate(ctx context.Context, from, to time.Time) (int64, int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM casting_analytics_daily WHERE date BETWEEN $1 AND $2`, from, to); err != nil {
+		return 0, 0, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		WITH v AS (
+			SELECT casting_id, viewed_at::date AS date,
+				COUNT(*) AS views, COUNT(DISTINCT viewer_id) AS unique_viewers
+			FROM casting_view_events
+			WHERE viewed_at >= $1 AND viewed_at < $2::date + 1
+			GROUP BY 1, 2
+		), r AS (
+			SELECT casting_id, created_at::date AS date,
+				COUNT(*) AS responses,
+				COUNT(*) FILTER (WHERE status <> 'pending') AS viewed,
+				COUNT(*) FILTER (WHERE status IN ('shortlisted', 'accepted')) AS shortlisted,
+				COUNT(*) FILTER (WHERE status = 'accepted') AS accepted,
+				COUNT(*) FILTER (WHERE status = 'rejected') AS rejected,
+				COALESCE(SUM(requirement_match), 0) AS match_score_sum,
+				COUNT(requirement_match) AS match_scored
+			FROM casting_responses
+			WHERE created_at >= $1 AND created_at < $2::date + 1
+			GROUP BY 1, 2
+		)
+		INSERT INTO casting_analytics_daily (
+			casting_id, creator_id, date, views, unique_viewers, responses,
+			viewed, shortlisted, accepted, rejected, match_score_sum, match_scored
+		)
+		SELECT c.id, c.creator_id, COALESCE(v.date, r.date),
+			COALESCE(v.views, 0), COALESCE(v.unique_viewers, 0), COALESCE(r.responses, 0),
+			COALESCE(r.viewed, 0), COALESCE(r.shortlisted, 0), COALESCE(r.accepted, 0), COALESCE(r.rejected, 0),
+			COALESCE(r.match_score_sum, 0), COALESCE(r.match_scored, 0)
+		FROM v
+		FULL OUTER JOIN r ON r.casting_id = v.casting_id AND r.date = v.date
+		JOIN castings c ON c.id = COALESCE(v.casting_id, r.casting_id)
+	`, from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+	days, _ := res.RowsAffected()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM casting_analytics_city_daily WHERE date BETWEEN $1 AND $2`, from, to); err != nil {
+		return 0, 0, err
+	}
+	// Viewers are placed by their own profile city, applicants by the responding profile's city
+	res, err = tx.ExecContext(ctx, `
+		WITH v AS (
+			SELECT e.casting_id, e.viewed_at::date AS date,
+				COALESCE(NULLIF(COALESCE(
+					(SELECT city FROM model_profiles WHERE user_id = e.viewer_id LIMIT 1),
+					(SELECT city FROM employer_profiles WHERE user_id = e.viewer_id LIMIT 1)
+				), ''), 'unknown') AS city,
+				COUNT(*) AS views
+			FROM casting_view_events e
+			WHERE e.viewed_at >= $1 AND e.viewed_at < $2::date + 1
+			GROUP BY 1, 2, 3
+		), r AS (
+			SELECT cr.casting_id, cr.created_at::date AS date,
+				COALESCE(NULLIF(mp.city, ''), 'unknown') AS city,
+				COUNT(*) AS responses
+			FROM casting_responses cr
+			LEFT JOIN model_profiles mp ON mp.id = cr.model_id
+			WHERE cr.created_at >= $1 AND cr.created_at < $2::date + 1
+			GROUP BY 1, 2, 3
+		)
+		INSERT INTO casting_analytics_city_daily (casting_id, creator_id, date, city, views, responses)
+		SELECT c.id, c.creator_id, COALESCE(v.date, r.date), COALESCE(v.city, r.city),
+			COALESCE(v.views, 0), COALESCE(r.responses, 0)
+		FROM v
+		FULL OUTER JOIN r ON r.casting_id = v.casting_id AND r.date = v.date AND r.city = v.city
+		JOIN castings c ON c.id = COALESCE(v.casting_id, r.casting_id)
+	`, from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+	cityDays, _ := res.RowsAffected()
+
+	return days, cityDays, tx.Commit()
+}
@@ -0,0 +1,97 @@
+package dashboard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFunnelRates(t *testing.T) {
+	f := Funnel{Views: 200, Responses: 20, Viewed: 15, Shortlisted: 5, Accepted: 1}
+	rates := f.Rates()
+	if rates.ViewToResponse != 10 || rates.ResponseToViewed != 75 || rates.ViewedToShortlisted != 33.3 || rates.ShortlistedToAccepted != 20 {
+		t.Fatalf("unexpected rates %+v", rates)
+	}
+	if got := (Funnel{}).Rates(); got != (FunnelRates{}) {
+		t.Fatalf("expected zero rates for an empty funnel, got %+v", got)
+	}
+}
+
+func TestWeekOverWeek(t *testing.T) {
+	today := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	daily := []DailyPoint{
+		{Date: today.AddDate(0, 0, -13), Funnel: Funnel{Views: 40, Responses: 4}},
+		{Date: today.AddDate(0, 0, -7), Funnel: Funnel{Views: 60, Responses: 6}},
+		{Date: today.AddDate(0, 0, -6), Funnel: Funnel{Views: 100, Responses: 5, Accepted: 1}},
+		{Date: today, Funnel: Funnel{Views: 50, Responses: 10}},
+		{Date: today.AddDate(0, 0, -20), Funnel: Funnel{Views: 1000}},
+	}
+	trend := weekOverWeek(daily, today)
+	if trend.ThisWeek.Views != 150 || trend.LastWeek.Views != 100 {
+		t.Fatalf("unexpected weekly views %d/%d", trend.ThisWeek.Views, trend.LastWeek.Views)
+	}
+	if trend.ViewsChange != 50 || trend.ResponsesChange != 50 || trend.AcceptedChange != 100 {
+		t.Fatalf("unexpected changes %+v", trend)
+	}
+}
+
+func TestFinishComputesQuality(t *testing.T) {
+	created := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	first := created.Add(90 * time.Minute)
+	c := CastingAnalytics{CreatedAt: created, FirstResponseAt: &first, matchScoreSum: 2.5, matchScored: 4}
+	finish(&c)
+	if c.TimeToFirstResponseHour == nil || *c.TimeToFirstResponseHour != 1.5 {
+		t.Fatalf("expected 1.5h to first response, got %v", c.TimeToFirstResponseHour)
+	}
+	if c.MatchRate == nil || *c.MatchRate != 62.5 {
+		t.Fatalf("expected 62.5%% match rate, got %v", c.MatchRate)
+	}
+}
+
+func TestModelAnalyticsSummarize(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	a := ModelAnalytics{
+		Daily: []ProfileDay{
+			{Date: day, Views: 3, SearchAppearances: 40},
+			{Date: day.AddDate(0, 0, 1)},
+			{Date: day.AddDate(0, 0, 2), Views: 7, SearchAppearances: 60},
+		},
+		Responses: 10, Accepted: 2, Rejected: 6,
+	}
+	a.summarize()
+	if a.Views != 10 || a.SearchAppearances != 100 {
+		t.Fatalf("unexpected totals %d views / %d appearances", a.Views, a.SearchAppearances)
+	}
+	// Two pending responses are not decided and don't lower the rate
+	if a.AcceptanceRate != 25 {
+		t.Fatalf("expected 25%% acceptance, got %v", a.AcceptanceRate)
+	}
+
+	empty := ModelAnalytics{Responses: 3}
+	empty.summarize()
+	if empty.AcceptanceRate != 0 {
+		t.Fatalf("expected no rate without decided responses, got %v", empty.AcceptanceRate)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	a := &EmployerAnalytics{Castings: []CastingAnalytics{{
+		CastingID: uuid.New(),
+		Title:     "Съёмка, реклама",
+		Funnel:    Funnel{Views: 10, Responses: 2},
+	}}}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, a); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %d lines", len(lines))
+	}
+	if !strings.Contains(lines[1], `"Съёмка, реклама"`) {
+		t.Fatalf("expected quoted title, got %q", lines[1])
+	}
+}
@@ -2,20 +2,33 @@ package dashboard
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/casting"
 	"github.com/mwork/mwork-api/internal/middleware"
 	"github.com/mwork/mwork-api/internal/pkg/response"
 )
 
 // Handler handles dashboard HTTP requests
 type Handler struct {
-	repo *Repository
-	svc  *Service
+	repo      *Repository
+	svc       *Service
+	analytics *AnalyticsService
+	viewers   ViewerAccessChecker
+}
+
+// ViewerAccessChecker checks whether the user's plan shows who viewed their profile
+type ViewerAccessChecker interface {
+	CanSeeViewers(ctx context.Context, userID uuid.UUID) error
 }
 
 // NewHandler creates new dashboard handler
@@ -88,14 +101,221 @@ func (h *Handler) GetEmployerStats(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SetAnalytics enables the employer hiring analytics endpoints
+func (h *Handler) SetAnalytics(svc *AnalyticsService) {
+	h.analytics = svc
+}
+
+// SetViewerAccess enables the profile viewers endpoint, gated on the user's plan
+func (h *Handler) SetViewerAccess(c ViewerAccessChecker) {
+	h.viewers = c
+}
+
+// GetModelAnalytics returns profile views per day, search appearances and response acceptance
+// @Summary Аналитика профиля модели
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Период в днях (по умолчанию 30)"
+// @Success 200 {object} response.Response{data=ModelAnalytics}
+// @Failure 401,403,500 {object} response.Response
+// @Router /dashboard/model/analytics [get]
+func (h *Handler) GetModelAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+
+	analytics, err := h.repo.GetModelAnalytics(r.Context(), userID, analyticsDays(r))
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	response.OK(w, analytics)
+}
+
+// GetProfileViewers returns who viewed the model's profile; requires a plan with viewer insights
+// @Summary Кто смотрел мой профиль
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Страница"
+// @Param limit query int false "Количество на странице (до 100)"
+// @Success 200 {object} response.Response{data=[]ProfileViewer}
+// @Failure 401,403,429,500 {object} response.Response
+// @Router /dashboard/model/viewers [get]
+func (h *Handler) GetProfileViewers(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+
+	if err := h.viewers.CanSeeViewers(r.Context(), userID); err != nil {
+		if middleware.WriteLimitExceeded(w, err) {
+			return
+		}
+		response.InternalError(w)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	viewers, total, err := h.repo.ListProfileViewers(r.Context(), userID, limit, (page-1)*limit)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	response.WithMeta(w, viewers, response.Meta{
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		Pages:   (total + limit - 1) / limit,
+		HasNext: page*limit < total,
+		HasPrev: page > 1,
+	})
+}
+
+// analyticsDays parses the days query parameter (default 30, at most 365)
+func analyticsDays(r *http.Request) int {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days <= 0 {
+		return 30
+	}
+	if days > 365 {
+		return 365
+	}
+	return days
+}
+
+// GetEmployerAnalytics returns hiring funnels, response quality, traffic by city and trends
+// @Summary Аналитика найма работодателя
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Период в днях (по умолчанию 30)"
+// @Success 200 {object} response.Response{data=EmployerAnalytics}
+// @Failure 401,403,500 {object} response.Response
+// @Router /dashboard/employer/analytics [get]
+func (h *Handler) GetEmployerAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+
+	analytics, err := h.analytics.GetEmployerAnalytics(r.Context(), userID, analyticsDays(r))
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	response.OK(w, analytics)
+}
+
+// GetCastingAnalytics returns the hiring funnel of one casting
+// @Summary Аналитика кастинга
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Casting ID"
+// @Param days query int false "Период в днях (по умолчанию 30)"
+// @Success 200 {object} response.Response{data=CastingReport}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /dashboard/employer/analytics/castings/{id} [get]
+func (h *Handler) GetCastingAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+
+	castingID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid casting ID")
+		return
+	}
+
+	report, err := h.analytics.GetCastingAnalytics(r.Context(), userID, castingID, analyticsDays(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, casting.ErrCastingNotFound):
+			response.NotFound(w, "Casting not found")
+		case errors.Is(err, ErrCastingNotOwned):
+			response.Forbidden(w, "You can only view analytics of your own castings")
+		default:
+			response.InternalError(w)
+		}
+		return
+	}
+
+	response.OK(w, report)
+}
+
+// ExportEmployerAnalytics downloads per-casting analytics as CSV
+// @Summary Экспорт аналитики в CSV
+// @Tags Dashboard
+// @Produce text/csv
+// @Security BearerAuth
+// @Param days query int false "Период в днях (по умолчанию 30)"
+// @Success 200 {file} file
+// @Failure 401,403,500 {object} response.Response
+// @Router /dashboard/employer/analytics/export [get]
+func (h *Handler) ExportEmployerAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+
+	analytics, err := h.analytics.GetEmployerAnalytics(r.Context(), userID, analyticsDays(r))
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="hiring-analytics-%s.csv"`, analytics.To.Format("2006-01-02")))
+	if err := WriteCSV(w, analytics); err != nil {
+		log.Error().Err(err).Msg("Failed to write analytics CSV")
+	}
+}
+
 // Routes returns dashboard routes
 func Routes(h *Handler, authMiddleware func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 	r.Use(authMiddleware)
 
 	r.Get("/model/stats", h.GetModelStats)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireModel())
+		r.Get("/model/analytics", h.GetModelAnalytics)
+		if h.viewers != nil {
+			r.Get("/model/viewers", h.GetProfileViewers)
+		}
+	})
 	r.Get("/employer", h.GetEmployerStats)
 
+	if h.analytics != nil {
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireEmployer())
+			r.Get("/employer/analytics", h.GetEmployerAnalytics)
+			r.Get("/employer/analytics/castings/{id}", h.GetCastingAnalytics)
+			r.Get("/employer/analytics/export", h.ExportEmployerAnalytics)
+		})
+	}
+
 	return r
 }
 
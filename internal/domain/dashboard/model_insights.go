@@ -0,0 +1,140 @@
+package dashboard
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProfileViewer is one viewer of a model profile on one day
+type ProfileViewer struct {
+	ViewerID     *uuid.UUID `json:"viewer_id,omitempty" db:"viewer_id"`
+	Name         string     `json:"name" db:"name"`
+	Role         string     `json:"role" db:"role"`
+	Organization *string    `json:"organization,omitempty" db:"organization"`
+	City         *string    `json:"city,omitempty" db:"city"`
+	Source       string     `json:"source" db:"source"`
+	ViewedAt     time.Time  `json:"viewed_at" db:"viewed_at"`
+}
+
+// ProfileDay is one day of profile views and search appearances
+type ProfileDay struct {
+	Date              time.Time `json:"date" db:"date"`
+	Views             int       `json:"views" db:"views"`
+	SearchAppearances int       `json:"search_appearances" db:"search_appearances"`
+}
+
+// SourceViews is the number of views from one source
+type SourceViews struct {
+	Source string `json:"source" db:"source"`
+	Views  int    `json:"views" db:"views"`
+}
+
+// ModelAnalytics is the profile analytics chart data available on every plan
+type ModelAnalytics struct {
+	From              time.Time     `json:"from"`
+	To                time.Time     `json:"to"`
+	Views             int           `json:"views"`
+	SearchAppearances int           `json:"search_appearances"`
+	Daily             []ProfileDay  `json:"daily"`
+	Sources           []SourceViews `json:"sources"`
+	Responses         int           `json:"responses"`
+	Accepted          int           `json:"accepted"`
+	Rejected          int           `json:"rejected"`
+	AcceptanceRate    float64       `json:"acceptance_rate"` // accepted share of decided responses, percent
+}
+
+// GetModelAnalytics returns views per day, search appearances and response acceptance of a model
+func (r *Repository) GetModelAnalytics(ctx context.Context, userID uuid.UUID, days int) (*ModelAnalytics, error) {
+	from, to := period(days, time.Now())
+	out := &ModelAnalytics{From: from, To: to}
+
+	err := r.db.SelectContext(ctx, &out.Daily, `
+		WITH p AS (SELECT id FROM model_profiles WHERE user_id = $1),
+		d AS (SELECT generate_series($2::date, $3::date, interval '1 day')::date AS date)
+		SELECT d.date,
+			(SELECT COUNT(*) FROM profile_view_events e WHERE e.profile_id IN (SELECT id FROM p) AND e.view_date = d.date) AS views,
+			COALESCE((SELECT SUM(a.appearances) FROM profile_search_appearances a WHERE a.profile_id IN (SELECT id FROM p) AND a.date = d.date), 0) AS search_appearances
+		FROM d
+		ORDER BY d.date
+	`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.SelectContext(ctx, &out.Sources, `
+		SELECT e.source, COUNT(*) AS views
+		FROM profile_view_events e
+		JOIN model_profiles p ON p.id = e.profile_id
+		WHERE p.user_id = $1 AND e.view_date BETWEEN $2 AND $3
+		GROUP BY e.source
+		ORDER BY views DESC
+	`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses struct {
+		Total    int `db:"responses"`
+		Accepted int `db:"accepted"`
+		Rejected int `db:"rejected"`
+	}
+	err = r.db.GetContext(ctx, &responses, `
+		SELECT COUNT(*) AS responses,
+			COUNT(*) FILTER (WHERE status = 'accepted') AS accepted,
+			COUNT(*) FILTER (WHERE status = 'rejected') AS rejected
+		FROM casting_responses
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3::date + 1
+	`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	out.Responses, out.Accepted, out.Rejected = responses.Total, responses.Accepted, responses.Rejected
+	out.summarize()
+	return out, nil
+}
+
+// summarize derives the period totals and the acceptance rate; pending responses
+// are not decided yet and don't count against it
+func (a *ModelAnalytics) summarize() {
+	a.Views, a.SearchAppearances = 0, 0
+	for _, d := range a.Daily {
+		a.Views += d.Views
+		a.SearchAppearances += d.SearchAppearances
+	}
+	a.AcceptanceRate = percent(a.Accepted, a.Accepted+a.Rejected)
+}
+
+// ListProfileViewers returns the signed-in viewers of a model's profile, newest first
+func (r *Repository) ListProfileViewers(ctx context.Context, userID uuid.UUID, limit, offset int) ([]ProfileViewer, int, error) {
+	var total int
+	err := r.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM profile_view_events e
+		JOIN model_profiles p ON p.id = e.profile_id
+		WHERE p.user_id = $1 AND e.viewer_id IS NOT NULL
+	`, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	viewers := []ProfileViewer{}
+	err = r.db.SelectContext(ctx, &viewers, `
+		SELECT e.viewer_id,
+			COALESCE(NULLIF(ep.company_name, ''), mp.name, '') AS name,
+			COALESCE(e.viewer_role, u.role::text, '') AS role,
+			COALESCE(NULLIF(o.brand_name, ''), o.legal_name) AS organization,
+			COALESCE(ep.city, mp.city) AS city,
+			e.source, e.viewed_at
+		FROM profile_view_events e
+		JOIN model_profiles p ON p.id = e.profile_id
+		LEFT JOIN users u ON u.id = e.viewer_id
+		LEFT JOIN organizations o ON o.id = e.organization_id
+		LEFT JOIN LATERAL (SELECT company_name, city FROM employer_profiles WHERE user_id = e.viewer_id LIMIT 1) ep ON true
+		LEFT JOIN LATERAL (SELECT name, city FROM model_profiles WHERE user_id = e.viewer_id LIMIT 1) mp ON true
+		WHERE p.user_id = $1 AND e.viewer_id IS NOT NULL
+		ORDER BY e.viewed_at DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	return viewers, total, err
+}
@@ -0,0 +1,144 @@
+package ledger
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Asset is a currency-like unit kept in its own set of accounts
+type Asset string
+
+const (
+	AssetCredits  Asset = "credits"  // response credits (users.credit_balance)
+	AssetConnects Asset = "connects" // model connects (free + purchased buckets)
+	AssetWallet   Asset = "wallet"   // demo wallet (user_wallets)
+)
+
+// Valid reports whether the asset is known
+func (a Asset) Valid() bool {
+	switch a {
+	case AssetCredits, AssetConnects, AssetWallet:
+		return true
+	}
+	return false
+}
+
+// System accounts balance user accounts: every posting moves value between
+// a user account and one of these, so each asset always sums to zero.
+const (
+	SystemIssuance = "issuance" // source of grants, purchases and top-ups
+	SystemRevenue  = "revenue"  // sink of spends, source of refunds
+	SystemOpening  = "opening"  // counterpart of migrated and legacy-synced balances
+)
+
+// Kind describes why value moved
+type Kind string
+
+const (
+	KindGrant      Kind = "grant"
+	KindPurchase   Kind = "purchase"
+	KindTopUp      Kind = "topup"
+	KindSpend      Kind = "spend"
+	KindRefund     Kind = "refund"
+	KindCapture    Kind = "capture"
+	KindOpening    Kind = "opening"
+	KindLegacySync Kind = "legacy_sync"
+)
+
+// HoldStatus is the state of a hold
+type HoldStatus string
+
+const (
+	HoldPending  HoldStatus = "pending"
+	HoldCaptured HoldStatus = "captured"
+	HoldReleased HoldStatus = "released"
+)
+
+// Account holds one asset for one user or system role
+type Account struct {
+	ID         uuid.UUID      `db:"id" json:"id"`
+	UserID     uuid.NullUUID  `db:"user_id" json:"-"`
+	SystemCode sql.NullString `db:"system_code" json:"-"`
+	Asset      Asset          `db:"asset" json:"asset"`
+	Balance    int64          `db:"balance" json:"balance"`
+	Held       int64          `db:"held" json:"held"`
+	CreatedAt  time.Time      `db:"created_at" json:"-"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// Available is the balance not reserved by pending holds
+func (a *Account) Available() int64 {
+	return a.Balance - a.Held
+}
+
+// Transaction groups balanced entries under one idempotency key
+type Transaction struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	IdempotencyKey string    `db:"idempotency_key" json:"-"`
+	UserID         uuid.UUID `db:"user_id" json:"-"`
+	Asset          Asset     `db:"asset" json:"asset"`
+	Kind           Kind      `db:"kind" json:"kind"`
+	Amount         int64     `db:"amount" json:"amount"`
+	Reference      string    `db:"reference" json:"reference,omitempty"`
+	Description    string    `db:"description" json:"description,omitempty"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// Entry is one side of a transaction on one account
+type Entry struct {
+	ID            int64     `db:"id" json:"-"`
+	TransactionID uuid.UUID `db:"transaction_id" json:"transaction_id"`
+	AccountID     uuid.UUID `db:"account_id" json:"-"`
+	Amount        int64     `db:"amount" json:"amount"`
+	BalanceAfter  int64     `db:"balance_after" json:"balance_after"`
+	Kind          Kind      `db:"kind" json:"kind"`
+	Reference     string    `db:"reference" json:"reference,omitempty"`
+	Description   string    `db:"description" json:"description,omitempty"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// Hold reserves part of a user balance for a pending operation
+type Hold struct {
+	ID                   uuid.UUID     `db:"id" json:"id"`
+	AccountID            uuid.UUID     `db:"account_id" json:"-"`
+	UserID               uuid.UUID     `db:"user_id" json:"-"`
+	Asset                Asset         `db:"asset" json:"asset"`
+	Amount               int64         `db:"amount" json:"amount"`
+	Status               HoldStatus    `db:"status" json:"status"`
+	IdempotencyKey       string        `db:"idempotency_key" json:"-"`
+	Reference            string        `db:"reference" json:"reference,omitempty"`
+	ExpiresAt            time.Time     `db:"expires_at" json:"expires_at"`
+	CaptureTransactionID uuid.NullUUID `db:"capture_transaction_id" json:"-"`
+	CreatedAt            time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+// Snapshot is a point-in-time copy of an account balance
+type Snapshot struct {
+	AccountID uuid.UUID `db:"account_id" json:"account_id"`
+	Balance   int64     `db:"balance" json:"balance"`
+	Held      int64     `db:"held" json:"held"`
+	TakenAt   time.Time `db:"taken_at" json:"taken_at"`
+}
+
+// Posting moves Amount of Asset between a user account and a system account
+type Posting struct {
+	UserID         uuid.UUID
+	Asset          Asset
+	Amount         int64
+	Kind           Kind
+	IdempotencyKey string
+	Reference      string
+	Description    string
+}
+
+// Transfer is a validated posting resolved to its two sides.
+// Exactly one of the sides is the user account.
+type Transfer struct {
+	Posting
+	System       string // system account code
+	ToUser       bool   // value flows system -> user
+	RequireFunds bool   // user side must have Amount available
+}
@@ -0,0 +1,15 @@
+package ledger
+
+import "errors"
+
+var (
+	ErrInvalidAmount       = errors.New("invalid amount")
+	ErrUnknownAsset        = errors.New("unknown ledger asset")
+	ErrMissingKey          = errors.New("idempotency key is required")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrIdempotencyConflict = errors.New("idempotency key reused with different parameters")
+	ErrAccountNotFound     = errors.New("ledger account not found")
+	ErrHoldNotFound        = errors.New("hold not found")
+	ErrHoldNotPending      = errors.New("hold is not pending")
+	ErrCaptureExceedsHold  = errors.New("capture amount exceeds hold")
+)
@@ -0,0 +1,134 @@
+package ledger
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/middleware"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// Handler exposes the user's ledger balances and history
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates ledger handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Routes returns ledger routes
+func (h *Handler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Use(authMiddleware)
+	r.Get("/balances", h.Balances)
+	r.Get("/{asset}/entries", h.Entries)
+	r.Get("/{asset}/snapshots", h.Snapshots)
+	return r
+}
+
+// Balances handles GET /ledger/balances
+// @Summary Балансы пользователя
+// @Description Балансы по всем активам (credits, connects, wallet) с учетом зарезервированных сумм.
+// @Tags Ledger
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]Account}
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /ledger/balances [get]
+func (h *Handler) Balances(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+	accounts, err := h.service.ListBalances(r.Context(), userID)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	out := make([]map[string]interface{}, 0, len(accounts))
+	for _, a := range accounts {
+		out = append(out, map[string]interface{}{
+			"asset":      a.Asset,
+			"balance":    a.Balance,
+			"held":       a.Held,
+			"available":  a.Available(),
+			"updated_at": a.UpdatedAt,
+		})
+	}
+	response.OK(w, out)
+}
+
+// Entries handles GET /ledger/{asset}/entries
+// @Summary История операций по активу
+// @Tags Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param asset path string true "Актив: credits, connects, wallet"
+// @Param limit query int false "Лимит (по умолчанию 20)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} response.Response{data=[]Entry}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /ledger/{asset}/entries [get]
+func (h *Handler) Entries(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	entries, err := h.service.ListEntries(r.Context(), userID, Asset(chi.URLParam(r, "asset")), limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.OK(w, entries)
+}
+
+// Snapshots handles GET /ledger/{asset}/snapshots
+// @Summary Снимки баланса по активу
+// @Tags Ledger
+// @Produce json
+// @Security BearerAuth
+// @Param asset path string true "Актив: credits, connects, wallet"
+// @Param limit query int false "Лимит (по умолчанию 30)"
+// @Success 200 {object} response.Response{data=[]Snapshot}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /ledger/{asset}/snapshots [get]
+func (h *Handler) Snapshots(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	snapshots, err := h.service.ListSnapshots(r.Context(), userID, Asset(chi.URLParam(r, "asset")), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.OK(w, snapshots)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownAsset):
+		response.BadRequest(w, "Unknown asset")
+	default:
+		response.InternalError(w)
+	}
+}
@@ -0,0 +1,591 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Repository defines ledger data access. Every write runs in one database
+// transaction that locks the user account, so postings for a user are serialised.
+type Repository interface {
+	Post(ctx context.Context, t *Transfer) (*Transaction, error)
+	CreateHold(ctx context.Context, h *Hold) (*Hold, error)
+	CaptureHold(ctx context.Context, holdID uuid.UUID, t *Transfer) (*Transaction, error)
+	ReleaseHold(ctx context.Context, holdID uuid.UUID) (*Hold, error)
+	GetHold(ctx context.Context, id uuid.UUID) (*Hold, error)
+	ListExpiredHolds(ctx context.Context, limit int) ([]*Hold, error)
+	GetAccount(ctx context.Context, userID uuid.UUID, asset Asset) (*Account, error)
+	ListAccounts(ctx context.Context, userID uuid.UUID) ([]*Account, error)
+	ListEntries(ctx context.Context, userID uuid.UUID, asset Asset, limit, offset int) ([]*Entry, error)
+	ListSnapshots(ctx context.Context, userID uuid.UUID, asset Asset, limit int) ([]*Snapshot, error)
+	TakeSnapshots(ctx context.Context) (int, error)
+	Imbalances(ctx context.Context) (map[Asset]int64, error)
+}
+
+type repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates ledger repository
+func NewRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+const accountColumns = `id, user_id, system_code, asset, balance, held, created_at, updated_at`
+
+const holdColumns = `id, account_id, user_id, asset, amount, status, idempotency_key, reference,
+	expires_at, capture_transaction_id, created_at, updated_at`
+
+func (r *repository) Post(ctx context.Context, t *Transfer) (*Transaction, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if existing, err := r.getTransactionByKey(ctx, tx, t.IdempotencyKey); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return matchTransaction(existing, t)
+	}
+
+	acct, err := r.lockUserAccount(ctx, tx, t.UserID, t.Asset)
+	if err != nil {
+		return nil, err
+	}
+	if t.RequireFunds && acct.Available() < t.Amount {
+		return nil, ErrInsufficientBalance
+	}
+	txn, err := r.post(ctx, tx, acct, t, true)
+	if err != nil {
+		if isUniqueViolation(err) {
+			tx.Rollback()
+			return r.resolveDuplicate(ctx, t)
+		}
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+func (r *repository) CreateHold(ctx context.Context, h *Hold) (*Hold, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var existing Hold
+	err = tx.GetContext(ctx, &existing, `SELECT `+holdColumns+` FROM ledger_holds WHERE idempotency_key = $1`, h.IdempotencyKey)
+	if err == nil {
+		if existing.UserID != h.UserID || existing.Asset != h.Asset || existing.Amount != h.Amount {
+			return nil, ErrIdempotencyConflict
+		}
+		return &existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	acct, err := r.lockUserAccount(ctx, tx, h.UserID, h.Asset)
+	if err != nil {
+		return nil, err
+	}
+	if acct.Available() < h.Amount {
+		return nil, ErrInsufficientBalance
+	}
+
+	h.AccountID = acct.ID
+	h.Status = HoldPending
+	err = tx.GetContext(ctx, h, `
+		INSERT INTO ledger_holds (id, account_id, user_id, asset, amount, status, idempotency_key, reference, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+holdColumns,
+		h.ID, h.AccountID, h.UserID, h.Asset, h.Amount, h.Status, h.IdempotencyKey, h.Reference, h.ExpiresAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrIdempotencyConflict
+		}
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE ledger_accounts SET held = held + $2, updated_at = NOW() WHERE id = $1`, acct.ID, h.Amount); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (r *repository) CaptureHold(ctx context.Context, holdID uuid.UUID, t *Transfer) (*Transaction, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the account before the hold, the same order CreateHold uses
+	var accountID uuid.UUID
+	if err := tx.GetContext(ctx, &accountID, `SELECT account_id FROM ledger_holds WHERE id = $1`, holdID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, err
+	}
+	acct, err := r.lockUserAccount(ctx, tx, t.UserID, t.Asset)
+	if err != nil {
+		return nil, err
+	}
+	if acct.ID != accountID {
+		return nil, ErrHoldNotFound
+	}
+	hold, err := r.lockHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	switch hold.Status {
+	case HoldCaptured:
+		if !hold.CaptureTransactionID.Valid {
+			return nil, ErrHoldNotPending
+		}
+		return r.getTransaction(ctx, tx, hold.CaptureTransactionID.UUID)
+	case HoldReleased:
+		return nil, ErrHoldNotPending
+	}
+	if t.Amount > hold.Amount {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE ledger_accounts SET held = held - $2 WHERE id = $1`, acct.ID, hold.Amount); err != nil {
+		return nil, err
+	}
+	acct.Held -= hold.Amount
+	// Legacy writers may have spent the reserved funds meanwhile
+	if acct.Available() < t.Amount {
+		return nil, ErrInsufficientBalance
+	}
+	txn, err := r.post(ctx, tx, acct, t, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE ledger_holds SET status = $2, capture_transaction_id = $3, updated_at = NOW() WHERE id = $1`,
+		hold.ID, HoldCaptured, txn.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+func (r *repository) ReleaseHold(ctx context.Context, holdID uuid.UUID) (*Hold, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var accountID uuid.UUID
+	if err := tx.GetContext(ctx, &accountID, `SELECT account_id FROM ledger_holds WHERE id = $1`, holdID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM ledger_accounts WHERE id = $1 FOR UPDATE`, accountID); err != nil {
+		return nil, err
+	}
+	hold, err := r.lockHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	switch hold.Status {
+	case HoldReleased:
+		return hold, nil
+	case HoldCaptured:
+		return nil, ErrHoldNotPending
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE ledger_accounts SET held = held - $2, updated_at = NOW() WHERE id = $1`, accountID, hold.Amount); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE ledger_holds SET status = $2, updated_at = NOW() WHERE id = $1`, hold.ID, HoldReleased); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	hold.Status = HoldReleased
+	return hold, nil
+}
+
+func (r *repository) GetHold(ctx context.Context, id uuid.UUID) (*Hold, error) {
+	var h Hold
+	err := r.db.GetContext(ctx, &h, `SELECT `+holdColumns+` FROM ledger_holds WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *repository) ListExpiredHolds(ctx context.Context, limit int) ([]*Hold, error) {
+	var holds []*Hold
+	err := r.db.SelectContext(ctx, &holds, `
+		SELECT `+holdColumns+` FROM ledger_holds
+		WHERE status = 'pending' AND expires_at < NOW()
+		ORDER BY expires_at
+		LIMIT $1`, limit)
+	return holds, err
+}
+
+func (r *repository) GetAccount(ctx context.Context, userID uuid.UUID, asset Asset) (*Account, error) {
+	var a Account
+	err := r.db.GetContext(ctx, &a, `SELECT `+accountColumns+` FROM ledger_accounts WHERE user_id = $1 AND asset = $2`, userID, asset)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *repository) ListAccounts(ctx context.Context, userID uuid.UUID) ([]*Account, error) {
+	var accounts []*Account
+	err := r.db.SelectContext(ctx, &accounts, `SELECT `+accountColumns+` FROM ledger_accounts WHERE user_id = $1 ORDER BY asset`, userID)
+	return accounts, err
+}
+
+func (r *repository) ListEntries(ctx context.Context, userID uuid.UUID, asset Asset, limit, offset int) ([]*Entry, error) {
+	var entries []*Entry
+	err := r.db.SelectContext(ctx, &entries, `
+		SELECT e.id, e.transaction_id, e.account_id, e.amount, e.balance_after, e.created_at,
+			t.kind, COALESCE(t.reference, '') AS reference, COALESCE(t.description, '') AS description
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE a.user_id = $1 AND a.asset = $2
+		ORDER BY e.id DESC
+		LIMIT $3 OFFSET $4`, userID, asset, limit, offset)
+	return entries, err
+}
+
+func (r *repository) ListSnapshots(ctx context.Context, userID uuid.UUID, asset Asset, limit int) ([]*Snapshot, error) {
+	var snapshots []*Snapshot
+	err := r.db.SelectContext(ctx, &snapshots, `
+		SELECT s.account_id, s.balance, s.held, s.taken_at
+		FROM ledger_balance_snapshots s
+		JOIN ledger_accounts a ON a.id = s.account_id
+		WHERE a.user_id = $1 AND a.asset = $2
+		ORDER BY s.taken_at DESC
+		LIMIT $3`, userID, asset, limit)
+	return snapshots, err
+}
+
+// TakeSnapshots records the balance of every account changed since its last snapshot
+func (r *repository) TakeSnapshots(ctx context.Context) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO ledger_balance_snapshots (account_id, balance, held, taken_at)
+		SELECT a.id, a.balance, a.held, NOW()
+		FROM ledger_accounts a
+		WHERE a.updated_at > COALESCE(
+			(SELECT MAX(s.taken_at) FROM ledger_balance_snapshots s WHERE s.account_id = a.id),
+			'-infinity'::timestamptz)`)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// Imbalances returns assets whose accounts don't sum to zero
+func (r *repository) Imbalances(ctx context.Context) (map[Asset]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT asset, SUM(balance) FROM ledger_accounts GROUP BY asset HAVING SUM(balance) <> 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[Asset]int64{}
+	for rows.Next() {
+		var asset Asset
+		var sum int64
+		if err := rows.Scan(&asset, &sum); err != nil {
+			return nil, err
+		}
+		out[asset] = sum
+	}
+	return out, rows.Err()
+}
+
+// lockUserAccount opens the account on first use, locks it and brings it in
+// line with the legacy balance it replaces
+func (r *repository) lockUserAccount(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, asset Asset) (*Account, error) {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO ledger_accounts (user_id, asset) VALUES ($1, $2)
+		ON CONFLICT (user_id, asset) WHERE user_id IS NOT NULL DO NOTHING`, userID, asset); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	var acct Account
+	if err := tx.GetContext(ctx, &acct, `
+		SELECT `+accountColumns+` FROM ledger_accounts WHERE user_id = $1 AND asset = $2 FOR UPDATE`, userID, asset); err != nil {
+		return nil, err
+	}
+	if err := r.syncLegacy(ctx, tx, &acct); err != nil {
+		return nil, err
+	}
+	return &acct, nil
+}
+
+func (r *repository) lockHold(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Hold, error) {
+	var h Hold
+	err := tx.GetContext(ctx, &h, `SELECT `+holdColumns+` FROM ledger_holds WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// syncLegacy posts the difference between the legacy balance column and the
+// account as a legacy_sync transaction. Until every writer moves to the ledger,
+// legacy credits and debits are picked up here before the account is used.
+func (r *repository) syncLegacy(ctx context.Context, tx *sqlx.Tx, acct *Account) error {
+	legacy, err := legacyBalance(ctx, tx, acct.Asset, acct.UserID.UUID)
+	if err != nil {
+		return err
+	}
+	diff := legacy - acct.Balance
+	if diff == 0 {
+		return nil
+	}
+	amount, toUser := diff, true
+	if diff < 0 {
+		amount, toUser = -diff, false
+	}
+	t := &Transfer{
+		Posting: Posting{
+			UserID:         acct.UserID.UUID,
+			Asset:          acct.Asset,
+			Amount:         amount,
+			Kind:           KindLegacySync,
+			IdempotencyKey: fmt.Sprintf("legacy_sync:%s:%s", acct.ID, uuid.New()),
+			Description:    "Balance changed outside the ledger",
+		},
+		System: SystemOpening,
+		ToUser: toUser,
+	}
+	_, err = r.post(ctx, tx, acct, t, false)
+	return err
+}
+
+// post writes the transaction and both entries; acct must be locked
+func (r *repository) post(ctx context.Context, tx *sqlx.Tx, acct *Account, t *Transfer, project bool) (*Transaction, error) {
+	var txn Transaction
+	err := tx.GetContext(ctx, &txn, `
+		INSERT INTO ledger_transactions (id, idempotency_key, user_id, asset, kind, amount, reference, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, idempotency_key, user_id, asset, kind, amount, COALESCE(reference, '') AS reference,
+			COALESCE(description, '') AS description, created_at`,
+		uuid.New(), t.IdempotencyKey, t.UserID, t.Asset, t.Kind, t.Amount, nullString(t.Reference), nullString(t.Description))
+	if err != nil {
+		return nil, err
+	}
+
+	userDelta := t.Amount
+	if !t.ToUser {
+		userDelta = -t.Amount
+	}
+	balance, err := r.insertEntry(ctx, tx, txn.ID, acct.ID, userDelta)
+	if err != nil {
+		return nil, err
+	}
+	acct.Balance = balance
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO ledger_accounts (system_code, asset) VALUES ($1, $2)
+		ON CONFLICT (system_code, asset) WHERE system_code IS NOT NULL DO NOTHING`, t.System, t.Asset); err != nil {
+		return nil, err
+	}
+	var systemID uuid.UUID
+	if err := tx.GetContext(ctx, &systemID, `SELECT id FROM ledger_accounts WHERE system_code = $1 AND asset = $2`, t.System, t.Asset); err != nil {
+		return nil, err
+	}
+	if _, err := r.insertEntry(ctx, tx, txn.ID, systemID, -userDelta); err != nil {
+		return nil, err
+	}
+
+	if project {
+		if err := projectLegacy(ctx, tx, t, txn.ID, userDelta); err != nil {
+			return nil, err
+		}
+	}
+	return &txn, nil
+}
+
+func (r *repository) insertEntry(ctx context.Context, tx *sqlx.Tx, txnID, accountID uuid.UUID, amount int64) (int64, error) {
+	var balance int64
+	if err := tx.GetContext(ctx, &balance, `
+		UPDATE ledger_accounts SET balance = balance + $2, updated_at = NOW() WHERE id = $1 RETURNING balance`,
+		accountID, amount); err != nil {
+		return 0, err
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ledger_entries (transaction_id, account_id, amount, balance_after) VALUES ($1, $2, $3, $4)`,
+		txnID, accountID, amount, balance)
+	return balance, err
+}
+
+func (r *repository) getTransactionByKey(ctx context.Context, q sqlx.QueryerContext, key string) (*Transaction, error) {
+	var txn Transaction
+	err := sqlx.GetContext(ctx, q, &txn, `
+		SELECT id, idempotency_key, user_id, asset, kind, amount, COALESCE(reference, '') AS reference,
+			COALESCE(description, '') AS description, created_at
+		FROM ledger_transactions WHERE idempotency_key = $1`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+func (r *repository) getTransaction(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*Transaction, error) {
+	var txn Transaction
+	err := tx.GetContext(ctx, &txn, `
+		SELECT id, idempotency_key, user_id, asset, kind, amount, COALESCE(reference, '') AS reference,
+			COALESCE(description, '') AS description, created_at
+		FROM ledger_transactions WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// resolveDuplicate handles a concurrent posting that won the idempotency key
+func (r *repository) resolveDuplicate(ctx context.Context, t *Transfer) (*Transaction, error) {
+	existing, err := r.getTransactionByKey(ctx, r.db, t.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrIdempotencyConflict
+	}
+	return matchTransaction(existing, t)
+}
+
+// matchTransaction returns the earlier transaction if it was made with the same parameters
+func matchTransaction(existing *Transaction, t *Transfer) (*Transaction, error) {
+	if existing.UserID != t.UserID || existing.Asset != t.Asset || existing.Kind != t.Kind || existing.Amount != t.Amount {
+		return nil, ErrIdempotencyConflict
+	}
+	return existing, nil
+}
+
+// legacyBalance reads (and locks) the pre-ledger balance an account replaces
+func legacyBalance(ctx context.Context, tx *sqlx.Tx, asset Asset, userID uuid.UUID) (int64, error) {
+	var query string
+	switch asset {
+	case AssetCredits:
+		query = `SELECT credit_balance FROM users WHERE id = $1 FOR UPDATE`
+	case AssetConnects:
+		query = `SELECT model_free_response_connects + model_purchased_response_connects FROM users WHERE id = $1 FOR UPDATE`
+	case AssetWallet:
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_wallets (user_id, balance) VALUES ($1, 0)
+			ON CONFLICT (user_id) DO NOTHING`, userID); err != nil {
+			return 0, err
+		}
+		query = `SELECT balance FROM user_wallets WHERE user_id = $1 FOR UPDATE`
+	default:
+		return 0, ErrUnknownAsset
+	}
+	var balance int64
+	if err := tx.GetContext(ctx, &balance, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrAccountNotFound
+		}
+		return 0, err
+	}
+	return balance, nil
+}
+
+// projectLegacy mirrors a user posting onto the legacy balance columns so code
+// that still reads them sees the same number. Credit postings also get a
+// credit_transactions row to keep the credit reconciliation balanced.
+func projectLegacy(ctx context.Context, tx *sqlx.Tx, t *Transfer, txnID uuid.UUID, delta int64) error {
+	var err error
+	switch t.Asset {
+	case AssetCredits:
+		if _, err = tx.ExecContext(ctx, `UPDATE users SET credit_balance = credit_balance + $2 WHERE id = $1`, t.UserID, delta); err != nil {
+			return err
+		}
+		description := t.Description
+		if description == "" {
+			description = "ledger " + string(t.Kind)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO credit_transactions (user_id, amount_delta, tx_type, related_entity_type, related_entity_id, description)
+			VALUES ($1, $2, $3, 'ledger', $4, $5)`, t.UserID, delta, legacyCreditType(t.Kind, delta), txnID, description)
+	case AssetConnects:
+		if delta < 0 {
+			// Spend free connects first, like DeductModelConnect
+			_, err = tx.ExecContext(ctx, `
+				UPDATE users SET
+					model_free_response_connects = GREATEST(model_free_response_connects + $2, 0),
+					model_purchased_response_connects = model_purchased_response_connects + LEAST(model_free_response_connects + $2, 0)
+				WHERE id = $1`, t.UserID, delta)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE users SET model_purchased_response_connects = model_purchased_response_connects + $2 WHERE id = $1`, t.UserID, delta)
+		}
+	case AssetWallet:
+		_, err = tx.ExecContext(ctx, `UPDATE user_wallets SET balance = balance + $2, updated_at = NOW() WHERE user_id = $1`, t.UserID, delta)
+	default:
+		err = ErrUnknownAsset
+	}
+	return err
+}
+
+// legacyCreditType maps a ledger kind onto the credit_transactions types
+func legacyCreditType(kind Kind, delta int64) string {
+	switch {
+	case delta < 0:
+		return "deduction"
+	case kind == KindRefund:
+		return "refund"
+	case kind == KindPurchase:
+		return "purchase"
+	default:
+		return "admin_grant"
+	}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
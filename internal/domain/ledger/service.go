@@ -0,0 +1,240 @@
+package ledger
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultHoldTTL is how long a hold reserves funds when the caller sets no TTL
+const DefaultHoldTTL = 15 * time.Minute
+
+// Service is the single double-entry ledger for user balances
+type Service struct {
+	repo    Repository
+	holdTTL time.Duration
+}
+
+// NewService creates ledger service
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, holdTTL: DefaultHoldTTL}
+}
+
+// SetHoldTTL overrides the default hold lifetime
+func (s *Service) SetHoldTTL(ttl time.Duration) {
+	if ttl > 0 {
+		s.holdTTL = ttl
+	}
+}
+
+// Credit adds value to a user account from the issuance account
+// (grants, purchases, top-ups)
+func (s *Service) Credit(ctx context.Context, p Posting) (*Transaction, error) {
+	if p.Kind == "" {
+		p.Kind = KindGrant
+	}
+	return s.post(ctx, p, SystemIssuance, true, false)
+}
+
+// Debit spends value from a user account into the revenue account.
+// Returns ErrInsufficientBalance if the available balance is too low.
+func (s *Service) Debit(ctx context.Context, p Posting) (*Transaction, error) {
+	if p.Kind == "" {
+		p.Kind = KindSpend
+	}
+	return s.post(ctx, p, SystemRevenue, false, true)
+}
+
+// Refund returns previously spent value from the revenue account
+func (s *Service) Refund(ctx context.Context, p Posting) (*Transaction, error) {
+	p.Kind = KindRefund
+	return s.post(ctx, p, SystemRevenue, true, false)
+}
+
+func (s *Service) post(ctx context.Context, p Posting, system string, toUser, requireFunds bool) (*Transaction, error) {
+	if err := validate(p.Asset, p.Amount, p.IdempotencyKey); err != nil {
+		return nil, err
+	}
+	txn, err := s.repo.Post(ctx, &Transfer{Posting: p, System: system, ToUser: toUser, RequireFunds: requireFunds})
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("user_id", p.UserID.String()).
+		Str("asset", string(p.Asset)).
+		Str("kind", string(p.Kind)).
+		Int64("amount", p.Amount).
+		Str("transaction_id", txn.ID.String()).
+		Msg("ledger posting applied")
+	return txn, nil
+}
+
+// HoldRequest reserves funds for an operation that completes later
+type HoldRequest struct {
+	UserID         uuid.UUID
+	Asset          Asset
+	Amount         int64
+	IdempotencyKey string
+	Reference      string
+	TTL            time.Duration // defaults to the service hold TTL
+}
+
+// Hold reserves funds. The available balance drops but the balance does not
+// change until the hold is captured; expired holds are released by the worker.
+func (s *Service) Hold(ctx context.Context, req HoldRequest) (*Hold, error) {
+	if err := validate(req.Asset, req.Amount, req.IdempotencyKey); err != nil {
+		return nil, err
+	}
+	ttl := req.TTL
+	if ttl <= 0 {
+		ttl = s.holdTTL
+	}
+	return s.repo.CreateHold(ctx, &Hold{
+		ID:             uuid.New(),
+		UserID:         req.UserID,
+		Asset:          req.Asset,
+		Amount:         req.Amount,
+		IdempotencyKey: req.IdempotencyKey,
+		Reference:      req.Reference,
+		ExpiresAt:      time.Now().Add(ttl),
+	})
+}
+
+// Capture spends amount of a pending hold (0 means the full hold) and releases
+// the rest. Capturing an already captured hold returns its transaction.
+func (s *Service) Capture(ctx context.Context, holdID uuid.UUID, amount int64, description string) (*Transaction, error) {
+	hold, err := s.repo.GetHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, ErrHoldNotFound
+	}
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount < 0 {
+		return nil, ErrInvalidAmount
+	}
+	return s.repo.CaptureHold(ctx, holdID, &Transfer{
+		Posting: Posting{
+			UserID:         hold.UserID,
+			Asset:          hold.Asset,
+			Amount:         amount,
+			Kind:           KindCapture,
+			IdempotencyKey: "capture:" + holdID.String(),
+			Reference:      hold.Reference,
+			Description:    description,
+		},
+		System: SystemRevenue,
+	})
+}
+
+// Release cancels a pending hold; releasing twice is a no-op
+func (s *Service) Release(ctx context.Context, holdID uuid.UUID) (*Hold, error) {
+	return s.repo.ReleaseHold(ctx, holdID)
+}
+
+// ReleaseExpiredHolds releases pending holds past their expiry
+func (s *Service) ReleaseExpiredHolds(ctx context.Context, limit int) (int, error) {
+	holds, err := s.repo.ListExpiredHolds(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	released := 0
+	for _, h := range holds {
+		if _, err := s.repo.ReleaseHold(ctx, h.ID); err != nil {
+			log.Error().Err(err).Str("hold_id", h.ID.String()).Msg("Failed to release expired ledger hold")
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
+
+// GetBalance returns the user's account for an asset; a never-used account is empty
+func (s *Service) GetBalance(ctx context.Context, userID uuid.UUID, asset Asset) (*Account, error) {
+	if !asset.Valid() {
+		return nil, ErrUnknownAsset
+	}
+	acct, err := s.repo.GetAccount(ctx, userID, asset)
+	if err != nil {
+		return nil, err
+	}
+	if acct == nil {
+		acct = &Account{UserID: uuid.NullUUID{UUID: userID, Valid: true}, Asset: asset}
+	}
+	return acct, nil
+}
+
+// ListBalances returns all ledger accounts of a user
+func (s *Service) ListBalances(ctx context.Context, userID uuid.UUID) ([]*Account, error) {
+	return s.repo.ListAccounts(ctx, userID)
+}
+
+// ListEntries returns the user's account history for an asset, newest first
+func (s *Service) ListEntries(ctx context.Context, userID uuid.UUID, asset Asset, limit, offset int) ([]*Entry, error) {
+	if !asset.Valid() {
+		return nil, ErrUnknownAsset
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.ListEntries(ctx, userID, asset, limit, offset)
+}
+
+// ListSnapshots returns recorded balance snapshots, newest first
+func (s *Service) ListSnapshots(ctx context.Context, userID uuid.UUID, asset Asset, limit int) ([]*Snapshot, error) {
+	if !asset.Valid() {
+		return nil, ErrUnknownAsset
+	}
+	if limit <= 0 || limit > 365 {
+		limit = 30
+	}
+	return s.repo.ListSnapshots(ctx, userID, asset, limit)
+}
+
+// MaintenanceResult summarises one maintenance pass
+type MaintenanceResult struct {
+	Released   int
+	Snapshots  int
+	Imbalances map[Asset]int64
+}
+
+// RunMaintenance releases expired holds, snapshots changed balances and
+// checks that every asset still sums to zero
+func (s *Service) RunMaintenance(ctx context.Context) (*MaintenanceResult, error) {
+	result := &MaintenanceResult{}
+
+	released, err := s.ReleaseExpiredHolds(ctx, 500)
+	result.Released = released
+	if err != nil {
+		return result, err
+	}
+	snapshots, err := s.repo.TakeSnapshots(ctx)
+	result.Snapshots = snapshots
+	if err != nil {
+		return result, err
+	}
+	result.Imbalances, err = s.repo.Imbalances(ctx)
+	return result, err
+}
+
+func validate(asset Asset, amount int64, key string) error {
+	if !asset.Valid() {
+		return ErrUnknownAsset
+	}
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if strings.TrimSpace(key) == "" {
+		return ErrMissingKey
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type repoStub struct {
+	Repository
+	posted   []*Transfer
+	holds    map[uuid.UUID]*Hold
+	captured *Transfer
+	released []uuid.UUID
+}
+
+func (r *repoStub) Post(_ context.Context, t *Transfer) (*Transaction, error) {
+	r.posted = append(r.posted, t)
+	return &Transaction{ID: uuid.New(), UserID: t.UserID, Asset: t.Asset, Kind: t.Kind, Amount: t.Amount}, nil
+}
+func (r *repoStub) CreateHold(_ context.Context, h *Hold) (*Hold, error) {
+	if r.holds == nil {
+		r.holds = map[uuid.UUID]*Hold{}
+	}
+	h.Status = HoldPending
+	r.holds[h.ID] = h
+	return h, nil
+}
+func (r *repoStub) GetHold(_ context.Context, id uuid.UUID) (*Hold, error) {
+	return r.holds[id], nil
+}
+func (r *repoStub) CaptureHold(_ context.Context, id uuid.UUID, t *Transfer) (*Transaction, error) {
+	r.captured = t
+	return &Transaction{ID: uuid.New(), Amount: t.Amount}, nil
+}
+func (r *repoStub) ListExpiredHolds(context.Context, int) ([]*Hold, error) {
+	var out []*Hold
+	for _, h := range r.holds {
+		if h.Status == HoldPending && h.ExpiresAt.Before(time.Now()) {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+func (r *repoStub) ReleaseHold(_ context.Context, id uuid.UUID) (*Hold, error) {
+	r.released = append(r.released, id)
+	r.holds[id].Status = HoldReleased
+	return r.holds[id], nil
+}
+
+func TestDebit_ValidatesAndRoutesToRevenue(t *testing.T) {
+	repo := &repoStub{}
+	svc := NewService(repo)
+	userID := uuid.New()
+
+	cases := []struct {
+		p    Posting
+		want error
+	}{
+		{Posting{UserID: userID, Asset: "gold", Amount: 1, IdempotencyKey: "k"}, ErrUnknownAsset},
+		{Posting{UserID: userID, Asset: AssetCredits, Amount: 0, IdempotencyKey: "k"}, ErrInvalidAmount},
+		{Posting{UserID: userID, Asset: AssetCredits, Amount: 1, IdempotencyKey: " "}, ErrMissingKey},
+	}
+	for _, c := range cases {
+		if _, err := svc.Debit(context.Background(), c.p); !errors.Is(err, c.want) {
+			t.Fatalf("expected %v, got %v", c.want, err)
+		}
+	}
+	if len(repo.posted) != 0 {
+		t.Fatal("invalid postings must not reach the repository")
+	}
+
+	if _, err := svc.Debit(context.Background(), Posting{UserID: userID, Asset: AssetConnects, Amount: 2, IdempotencyKey: "apply:1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := repo.posted[0]
+	if got.System != SystemRevenue || got.ToUser || !got.RequireFunds || got.Kind != KindSpend {
+		t.Fatalf("unexpected debit transfer: %+v", got)
+	}
+}
+
+func TestCreditAndRefund_FlowToUser(t *testing.T) {
+	repo := &repoStub{}
+	svc := NewService(repo)
+	userID := uuid.New()
+
+	if _, err := svc.Credit(context.Background(), Posting{UserID: userID, Asset: AssetCredits, Amount: 5, Kind: KindPurchase, IdempotencyKey: "payment:1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Refund(context.Background(), Posting{UserID: userID, Asset: AssetCredits, Amount: 1, IdempotencyKey: "refund:1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	credit, refund := repo.posted[0], repo.posted[1]
+	if credit.System != SystemIssuance || !credit.ToUser || credit.RequireFunds || credit.Kind != KindPurchase {
+		t.Fatalf("unexpected credit transfer: %+v", credit)
+	}
+	if refund.System != SystemRevenue || !refund.ToUser || refund.Kind != KindRefund {
+		t.Fatalf("unexpected refund transfer: %+v", refund)
+	}
+}
+
+func TestCapture_DefaultsToFullHoldWithStableKey(t *testing.T) {
+	repo := &repoStub{}
+	svc := NewService(repo)
+
+	hold, err := svc.Hold(context.Background(), HoldRequest{UserID: uuid.New(), Asset: AssetWallet, Amount: 300, IdempotencyKey: "booking:1", Reference: "booking:1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Until(hold.ExpiresAt) < DefaultHoldTTL-time.Minute {
+		t.Fatalf("expected default hold ttl, got expiry %v", hold.ExpiresAt)
+	}
+
+	if _, err := svc.Capture(context.Background(), hold.ID, 0, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := repo.captured
+	if c.Amount != 300 || c.Kind != KindCapture || c.IdempotencyKey != "capture:"+hold.ID.String() || c.UserID != hold.UserID || c.Asset != AssetWallet {
+		t.Fatalf("unexpected capture transfer: %+v", c)
+	}
+
+	if _, err := svc.Capture(context.Background(), uuid.New(), 0, ""); !errors.Is(err, ErrHoldNotFound) {
+		t.Fatalf("expected ErrHoldNotFound, got %v", err)
+	}
+}
+
+func TestReleaseExpiredHolds(t *testing.T) {
+	repo := &repoStub{}
+	svc := NewService(repo)
+	expired, _ := svc.Hold(context.Background(), HoldRequest{UserID: uuid.New(), Asset: AssetCredits, Amount: 1, IdempotencyKey: "a"})
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := svc.Hold(context.Background(), HoldRequest{UserID: uuid.New(), Asset: AssetCredits, Amount: 1, IdempotencyKey: "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	n, err := svc.ReleaseExpiredHolds(context.Background(), 10)
+	if err != nil || n != 1 || len(repo.released) != 1 || repo.released[0] != expired.ID {
+		t.Fatalf("expected only the expired hold released, got %d %v %v", n, repo.released, err)
+	}
+}
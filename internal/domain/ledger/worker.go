@@ -0,0 +1,78 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Worker periodically releases expired holds and snapshots balances
+type Worker struct {
+	service  *Service
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewWorker creates a new ledger maintenance worker
+func NewWorker(service *Service, interval time.Duration) *Worker {
+	if interval == 0 {
+		interval = 1 * time.Hour
+	}
+	return &Worker{
+		service:  service,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the background worker
+func (w *Worker) Start() {
+	log.Info().Msg("Starting ledger maintenance worker...")
+	go w.loop()
+}
+
+// Stop gracefully stops the background worker
+func (w *Worker) Stop() {
+	log.Info().Msg("Stopping ledger maintenance worker...")
+	close(w.stopCh)
+}
+
+func (w *Worker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run once immediately on startup
+	w.run()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.run()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *Worker) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	result, err := w.service.RunMaintenance(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Ledger maintenance run failed")
+	}
+	if result == nil {
+		return
+	}
+	for asset, sum := range result.Imbalances {
+		log.Error().Str("asset", string(asset)).Int64("sum", sum).Msg("Ledger accounts do not balance")
+	}
+	if result.Released > 0 || result.Snapshots > 0 {
+		log.Info().
+			Int("released_holds", result.Released).
+			Int("snapshots", result.Snapshots).
+			Msg("Ledger maintenance processed")
+	}
+}
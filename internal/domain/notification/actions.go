@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Action is an operation a user can perform directly from a notification
+type Action string
+
+const (
+	ActionAccept Action = "accept" // new_response: accept the casting response
+	ActionReject Action = "reject" // new_response: reject the casting response
+	ActionReply  Action = "reply"  // new_message: reply in the chat room
+)
+
+var (
+	ErrActionNotAvailable = errors.New("action is not available for this notification")
+	ErrActionAlreadyTaken = errors.New("action has already been taken")
+	ErrReplyTextRequired  = errors.New("reply text is required")
+	// ErrActionRejected wraps domain refusals (e.g. invalid status transition) from performers
+	ErrActionRejected = errors.New("action rejected")
+)
+
+// ResponseDecider accepts or rejects a casting response on behalf of its employer
+type ResponseDecider interface {
+	DecideResponse(ctx context.Context, userID, responseID uuid.UUID, accept bool) error
+}
+
+// MessageReplier posts a chat message on behalf of a room member
+type MessageReplier interface {
+	ReplyToRoom(ctx context.Context, userID, roomID uuid.UUID, text string) error
+}
+
+// AvailableActions returns the actions still offered on a notification.
+// Accept/Reject are one-shot; Reply stays available.
+func AvailableActions(n *Notification) []Action {
+	data := n.GetData()
+	switch n.Type {
+	case TypeNewResponse:
+		if data.ResponseID == nil || n.ActionTaken.Valid {
+			return nil
+		}
+		return []Action{ActionAccept, ActionReject}
+	case TypeNewMessage:
+		if data.RoomID == nil {
+			return nil
+		}
+		return []Action{ActionReply}
+	}
+	return nil
+}
+
+// SetActionPerformers wires the domain operations behind notification actions
+func (s *Service) SetActionPerformers(responses ResponseDecider, messages MessageReplier) {
+	s.responseDecider = responses
+	s.messageReplier = messages
+}
+
+// ExecuteAction runs the domain operation behind an action and records it on the notification
+func (s *Service) ExecuteAction(ctx context.Context, userID, id uuid.UUID, action Action, text string) (*Notification, error) {
+	n, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || n.UserID != userID {
+		return nil, ErrNotificationNotFound
+	}
+
+	if !hasAction(AvailableActions(n), action) {
+		if n.ActionTaken.Valid && n.Type == TypeNewResponse {
+			return nil, ErrActionAlreadyTaken
+		}
+		return nil, ErrActionNotAvailable
+	}
+
+	data := n.GetData()
+	switch action {
+	case ActionAccept, ActionReject:
+		if s.responseDecider == nil {
+			return nil, ErrActionNotAvailable
+		}
+		if err := s.responseDecider.DecideResponse(ctx, userID, *data.ResponseID, action == ActionAccept); err != nil {
+			return nil, err
+		}
+	case ActionReply:
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, ErrReplyTextRequired
+		}
+		if s.messageReplier == nil {
+			return nil, ErrActionNotAvailable
+		}
+		if err := s.messageReplier.ReplyToRoom(ctx, userID, *data.RoomID, text); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.repo.RecordAction(ctx, userID, id, action)
+	if err != nil {
+		return nil, err
+	}
+	s.publishState(ctx, userID, &StateChange{Updated: NotificationResponseFromEntity(updated)})
+	return updated, nil
+}
+
+func hasAction(actions []Action, action Action) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type actionRepoStub struct {
+	Repository
+	n        *Notification
+	recorded Action
+}
+
+func (r *actionRepoStub) GetByID(ctx context.Context, id uuid.UUID) (*Notification, error) {
+	if r.n == nil || r.n.ID != id {
+		return nil, nil
+	}
+	return r.n, nil
+}
+
+func (r *actionRepoStub) RecordAction(ctx context.Context, userID, id uuid.UUID, action Action) (*Notification, error) {
+	r.recorded = action
+	r.n.ActionTaken = sql.NullString{String: string(action), Valid: true}
+	r.n.IsRead = true
+	return r.n, nil
+}
+
+type deciderStub struct {
+	responseID uuid.UUID
+	accept     bool
+	err        error
+}
+
+func (d *deciderStub) DecideResponse(ctx context.Context, userID, responseID uuid.UUID, accept bool) error {
+	d.responseID, d.accept = responseID, accept
+	return d.err
+}
+
+func newResponseNotification(userID uuid.UUID) *Notification {
+	castingID, responseID := uuid.New(), uuid.New()
+	n := &Notification{ID: uuid.New(), UserID: userID, Type: TypeNewResponse, Title: "Новый отклик"}
+	n.SetData(&NotificationData{CastingID: &castingID, ResponseID: &responseID})
+	return n
+}
+
+func TestExecuteActionAcceptsResponseOnce(t *testing.T) {
+	userID := uuid.New()
+	n := newResponseNotification(userID)
+	repo := &actionRepoStub{n: n}
+	decider := &deciderStub{}
+
+	svc := NewService(repo)
+	svc.SetActionPerformers(decider, nil)
+
+	updated, err := svc.ExecuteAction(context.Background(), userID, n.ID, ActionAccept, "")
+	if err != nil {
+		t.Fatalf("ExecuteAction: %v", err)
+	}
+	if !decider.accept || decider.responseID != *n.GetData().ResponseID {
+		t.Fatalf("decider called with accept=%v response=%v", decider.accept, decider.responseID)
+	}
+	if repo.recorded != ActionAccept || len(AvailableActions(updated)) != 0 {
+		t.Fatalf("expected accept to be recorded and actions to be exhausted, got %+v", AvailableActions(updated))
+	}
+
+	if _, err := svc.ExecuteAction(context.Background(), userID, n.ID, ActionReject, ""); !errors.Is(err, ErrActionAlreadyTaken) {
+		t.Fatalf("expected ErrActionAlreadyTaken, got %v", err)
+	}
+}
+
+func TestExecuteActionValidation(t *testing.T) {
+	userID := uuid.New()
+	n := newResponseNotification(userID)
+	svc := NewService(&actionRepoStub{n: n})
+	svc.SetActionPerformers(&deciderStub{}, nil)
+
+	if _, err := svc.ExecuteAction(context.Background(), uuid.New(), n.ID, ActionAccept, ""); !errors.Is(err, ErrNotificationNotFound) {
+		t.Fatalf("expected ErrNotificationNotFound for another user, got %v", err)
+	}
+	if _, err := svc.ExecuteAction(context.Background(), userID, n.ID, ActionReply, "hi"); !errors.Is(err, ErrActionNotAvailable) {
+		t.Fatalf("expected reply to be unavailable on new_response, got %v", err)
+	}
+}
+
+func TestFilterWhere(t *testing.T) {
+	where, args := filterWhere(uuid.New(), ListFilter{Types: []Type{TypeNewMessage}, UnreadOnly: true})
+	for _, part := range []string{"archived_at IS NULL", "is_read = false", "type = ANY($2)"} {
+		if !strings.Contains(where, part) {
+			t.Fatalf("expected %q in %q", part, where)
+		}
+	}
+	if len(args) != 2 {
+		t.Fatalf("expected 2 args, got %d", len(args))
+	}
+
+	if where, _ := filterWhere(uuid.New(), ListFilter{Archived: true}); !strings.Contains(where, "archived_at IS NOT NULL") {
+		t.Fatalf("expected archive filter, got %q", where)
+	}
+}
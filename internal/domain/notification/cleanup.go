@@ -1,37 +1,141 @@
 package notification
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
+// cleanupLockKey is the pg advisory lock key guarding notification retention runs
+const cleanupLockKey int64 = 0x6e6f7469665f636c // "notif_cl"
+
+// ErrCleanupInProgress is returned when another instance holds the cleanup lock
+var ErrCleanupInProgress = errors.New("notification cleanup already running")
+
+// Cleanup run triggers and statuses
+const (
+	CleanupTriggerSchedule = "schedule"
+	CleanupTriggerAdmin    = "admin"
+
+	CleanupStatusRunning   = "running"
+	CleanupStatusCompleted = "completed"
+	CleanupStatusFailed    = "failed"
+)
+
+// Archive reasons recorded per pruned batch
+const (
+	archiveReasonRead      = "read_age"
+	archiveReasonUnread    = "unread_age"
+	archiveReasonType      = "type_age"
+	archiveReasonOverLimit = "over_limit"
+)
+
+// RetentionPolicy configures which notifications the cleanup job prunes
+type RetentionPolicy struct {
+	ReadRetention   time.Duration          // Read notifications older than this are pruned
+	UnreadRetention time.Duration          // Any notification older than this is pruned
+	TypeRetention   map[Type]time.Duration // Per-type age limit, replaces read/unread ages for that type
+	MaxPerUser      int                    // Keep only the newest N per user (0 = unlimited)
+	BatchSize       int
+}
+
+// DefaultRetentionPolicy keeps read notifications for 90 days and unread for 180
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		ReadRetention:   90 * 24 * time.Hour,
+		UnreadRetention: 180 * 24 * time.Hour,
+		MaxPerUser:      1000,
+		BatchSize:       1000,
+	}
+}
+
+// ParseTypeRetention parses "new_message=30,profile_viewed=14" (days per type)
+func ParseTypeRetention(raw string) map[Type]time.Duration {
+	out := make(map[Type]time.Duration)
+	for _, entry := range strings.Split(raw, ",") {
+		name, daysRaw, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(daysRaw))
+		if err != nil || days <= 0 {
+			continue
+		}
+		out[Type(strings.TrimSpace(name))] = time.Duration(days) * 24 * time.Hour
+	}
+	return out
+}
+
+// CleanupRun records one execution of the retention job
+type CleanupRun struct {
+	ID               uuid.UUID      `db:"id" json:"id"`
+	Trigger          string         `db:"trigger" json:"trigger"`
+	TriggeredBy      uuid.NullUUID  `db:"triggered_by" json:"triggered_by,omitempty"`
+	Status           string         `db:"status" json:"status"`
+	DeletedRead      int64          `db:"deleted_read" json:"deleted_read"`
+	DeletedUnread    int64          `db:"deleted_unread" json:"deleted_unread"`
+	DeletedByType    int64          `db:"deleted_by_type" json:"deleted_by_type"`
+	DeletedOverLimit int64          `db:"deleted_over_limit" json:"deleted_over_limit"`
+	ArchivedBatches  int64          `db:"archived_batches" json:"archived_batches"`
+	Error            sql.NullString `db:"error" json:"error,omitempty"`
+	StartedAt        time.Time      `db:"started_at" json:"started_at"`
+	FinishedAt       sql.NullTime   `db:"finished_at" json:"finished_at,omitempty"`
+}
+
+// TotalDeleted sums notifications pruned by all policies
+func (r *CleanupRun) TotalDeleted() int64 {
+	return r.DeletedRead + r.DeletedUnread + r.DeletedByType + r.DeletedOverLimit
+}
+
 // CleanupJob handles notification retention cleanup
 type CleanupJob struct {
-	db            *sqlx.DB
-	retentionDays int
+	db     *sqlx.DB
+	policy RetentionPolicy
 }
 
 // NewCleanupJob creates a cleanup job
-func NewCleanupJob(db *sqlx.DB, retentionDays int) *CleanupJob {
-	if retentionDays <= 0 {
-		retentionDays = 90 // Default 90 days
+func NewCleanupJob(db *sqlx.DB, policy RetentionPolicy) *CleanupJob {
+	defaults := DefaultRetentionPolicy()
+	if policy.ReadRetention <= 0 {
+		policy.ReadRetention = defaults.ReadRetention
+	}
+	if policy.UnreadRetention <= 0 {
+		policy.UnreadRetention = defaults.UnreadRetention
+	}
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = defaults.BatchSize
 	}
 	return &CleanupJob{
-		db:            db,
-		retentionDays: retentionDays,
+		db:     db,
+		policy: policy,
 	}
 }
 
+// Policy returns the active retention policy
+func (j *CleanupJob) Policy() RetentionPolicy {
+	return j.policy
+}
+
 // Start starts the cleanup job with the given interval
 func (j *CleanupJob) Start(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	// Run immediately on start
-	j.run(ctx)
+	j.runScheduled(ctx)
 
 	for {
 		select {
@@ -39,70 +143,347 @@ func (j *CleanupJob) Start(ctx context.Context, interval time.Duration) {
 			log.Info().Msg("Notification cleanup job stopped")
 			return
 		case <-ticker.C:
-			j.run(ctx)
+			j.runScheduled(ctx)
 		}
 	}
 }
 
-// run executes the cleanup
-func (j *CleanupJob) run(ctx context.Context) {
-	cutoff := time.Now().AddDate(0, 0, -j.retentionDays)
-
-	result, err := j.db.ExecContext(ctx, `
-		DELETE FROM notifications 
-		WHERE created_at < $1 AND is_read = true
-	`, cutoff)
-
+func (j *CleanupJob) runScheduled(ctx context.Context) {
+	run, err := j.Run(ctx, CleanupTriggerSchedule, nil)
+	if errors.Is(err, ErrCleanupInProgress) {
+		log.Debug().Msg("Notification cleanup skipped: another instance holds the lock")
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to cleanup old notifications")
 		return
 	}
-
-	rows, _ := result.RowsAffected()
-	if rows > 0 {
+	if run.TotalDeleted() > 0 {
 		log.Info().
-			Int64("deleted", rows).
-			Int("retention_days", j.retentionDays).
+			Int64("deleted_read", run.DeletedRead).
+			Int64("deleted_unread", run.DeletedUnread).
+			Int64("deleted_by_type", run.DeletedByType).
+			Int64("deleted_over_limit", run.DeletedOverLimit).
 			Msg("Cleaned up old notifications")
 	}
+}
+
+// Run executes cleanup synchronously
+func (j *CleanupJob) Run(ctx context.Context, trigger string, triggeredBy *uuid.UUID) (*CleanupRun, error) {
+	run, execute, err := j.begin(ctx, trigger, triggeredBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := execute(ctx); err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+// Trigger acquires the lock, records the run and executes it in the background
+func (j *CleanupJob) Trigger(trigger string, triggeredBy *uuid.UUID) (*CleanupRun, error) {
+	ctx := context.Background()
+	run, execute, err := j.begin(ctx, trigger, triggeredBy)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := *run
+	go func() {
+		if err := execute(ctx); err != nil {
+			log.Error().Err(err).Str("run_id", run.ID.String()).Msg("Notification cleanup run failed")
+		}
+	}()
+	return &snapshot, nil
+}
+
+// begin takes the advisory lock on a dedicated connection and inserts the run record.
+// The returned execute func releases the lock when done.
+func (j *CleanupJob) begin(ctx context.Context, trigger string, triggeredBy *uuid.UUID) (*CleanupRun, func(context.Context) error, error) {
+	conn, err := j.db.Connx(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1)`, cleanupLockKey); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil, ErrCleanupInProgress
+	}
+
+	release := func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, cleanupLockKey); err != nil {
+			log.Warn().Err(err).Msg("Failed to release notification cleanup lock")
+		}
+		conn.Close()
+	}
 
-	// Also cleanup old unread (older than 180 days)
-	unreadCutoff := time.Now().AddDate(0, 0, -180)
-	result2, _ := j.db.ExecContext(ctx, `
-		DELETE FROM notifications 
-		WHERE created_at < $1
-	`, unreadCutoff)
+	run := &CleanupRun{
+		ID:        uuid.New(),
+		Trigger:   trigger,
+		Status:    CleanupStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if triggeredBy != nil {
+		run.TriggeredBy = uuid.NullUUID{UUID: *triggeredBy, Valid: true}
+	}
 
-	if unreadRows, _ := result2.RowsAffected(); unreadRows > 0 {
-		log.Info().Int64("deleted_unread", unreadRows).Msg("Cleaned up very old unread notifications")
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO notification_cleanup_runs (id, trigger, triggered_by, status, started_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, run.ID, run.Trigger, run.TriggeredBy, run.Status, run.StartedAt)
+	if err != nil {
+		release()
+		return nil, nil, err
 	}
 
-	// Cleanup old notification groups
-	j.db.ExecContext(ctx, `
-		DELETE FROM notification_groups 
-		WHERE created_at < $1
-	`, cutoff)
+	execute := func(ctx context.Context) error {
+		defer release()
 
-	// Cleanup inactive device tokens (not used for 90 days)
-	j.db.ExecContext(ctx, `
-		DELETE FROM device_tokens 
-		WHERE last_used_at < $1 OR is_active = false
-	`, cutoff)
+		execErr := j.execute(ctx, conn, run)
+
+		run.Status = CleanupStatusCompleted
+		if execErr != nil {
+			run.Status = CleanupStatusFailed
+			run.Error = sql.NullString{String: execErr.Error(), Valid: true}
+		}
+		run.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		_, err := conn.ExecContext(context.Background(), `
+			UPDATE notification_cleanup_runs SET
+				status = $2, deleted_read = $3, deleted_unread = $4, deleted_by_type = $5,
+				deleted_over_limit = $6, archived_batches = $7, error = $8, finished_at = $9
+			WHERE id = $1
+		`, run.ID, run.Status, run.DeletedRead, run.DeletedUnread, run.DeletedByType,
+			run.DeletedOverLimit, run.ArchivedBatches, run.Error, run.FinishedAt)
+		if err != nil {
+			log.Error().Err(err).Str("run_id", run.ID.String()).Msg("Failed to record notification cleanup run")
+		}
+		return execErr
+	}
+
+	return run, execute, nil
 }
 
-// RunOnce runs cleanup once (for manual trigger or testing)
-func (j *CleanupJob) RunOnce(ctx context.Context) (int64, error) {
-	cutoff := time.Now().AddDate(0, 0, -j.retentionDays)
+// execute applies every retention rule in batches
+func (j *CleanupJob) execute(ctx context.Context, conn *sqlx.Conn, run *CleanupRun) error {
+	now := time.Now()
 
-	result, err := j.db.ExecContext(ctx, `
-		DELETE FROM notifications 
-		WHERE created_at < $1 AND is_read = true
-	`, cutoff)
+	typed := make([]string, 0, len(j.policy.TypeRetention))
+	for t := range j.policy.TypeRetention {
+		typed = append(typed, string(t))
+	}
+	sort.Strings(typed)
 
+	// 1. Per-type retention
+	for _, t := range typed {
+		cutoff := now.Add(-j.policy.TypeRetention[Type(t)])
+		n, err := j.pruneLoop(ctx, conn, run, archiveReasonType, `
+			SELECT id FROM notifications WHERE type = $1 AND created_at < $2 LIMIT $3
+		`, t, cutoff)
+		run.DeletedByType += n
+		if err != nil {
+			return err
+		}
+	}
+
+	// 2. Read notifications
+	n, err := j.pruneLoop(ctx, conn, run, archiveReasonRead, `
+		SELECT id FROM notifications
+		WHERE is_read = true AND created_at < $1 AND NOT (type = ANY($2))
+		LIMIT $3
+	`, now.Add(-j.policy.ReadRetention), pq.Array(typed))
+	run.DeletedRead += n
+	if err != nil {
+		return err
+	}
+
+	// 3. Everything older than the unread horizon
+	n, err = j.pruneLoop(ctx, conn, run, archiveReasonUnread, `
+		SELECT id FROM notifications
+		WHERE created_at < $1 AND NOT (type = ANY($2))
+		LIMIT $3
+	`, now.Add(-j.policy.UnreadRetention), pq.Array(typed))
+	run.DeletedUnread += n
+	if err != nil {
+		return err
+	}
+
+	// 4. Per-user cap
+	if j.policy.MaxPerUser > 0 {
+		n, err = j.pruneLoop(ctx, conn, run, archiveReasonOverLimit, `
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC) AS rn
+				FROM notifications
+			) ranked
+			WHERE rn > $1
+			LIMIT $2
+		`, j.policy.MaxPerUser)
+		run.DeletedOverLimit += n
+		if err != nil {
+			return err
+		}
+	}
+
+	// Housekeeping for related tables
+	cutoff := now.Add(-j.policy.ReadRetention)
+	if _, err := conn.ExecContext(ctx, `DELETE FROM notification_groups WHERE created_at < $1`, cutoff); err != nil {
+		log.Warn().Err(err).Msg("Failed to cleanup old notification groups")
+	}
+	if _, err := conn.ExecContext(ctx, `DELETE FROM device_tokens WHERE last_used_at < $1 OR is_active = false`, cutoff); err != nil {
+		log.Warn().Err(err).Msg("Failed to cleanup inactive device tokens")
+	}
+	if _, err := conn.ExecContext(ctx, `DELETE FROM notification_deferred_deliveries WHERE delivered_at < $1`, cutoff); err != nil {
+		log.Warn().Err(err).Msg("Failed to cleanup delivered deferred notifications")
+	}
+
+	return nil
+}
+
+// pruneLoop repeatedly archives and deletes batches selected by selectQuery.
+// selectQuery receives args followed by the batch size as its last parameter.
+func (j *CleanupJob) pruneLoop(ctx context.Context, conn *sqlx.Conn, run *CleanupRun, reason, selectQuery string, args ...interface{}) (int64, error) {
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := j.pruneBatch(ctx, conn, run, reason, selectQuery, append(args, j.policy.BatchSize)...)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < int64(j.policy.BatchSize) {
+			return total, nil
+		}
+	}
+}
+
+// pruneBatch deletes one batch and writes it to the archive in the same transaction
+func (j *CleanupJob) pruneBatch(ctx context.Context, conn *sqlx.Conn, run *CleanupRun, reason, selectQuery string, args ...interface{}) (int64, error) {
+	tx, err := conn.BeginTxx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
+	defer tx.Rollback()
+
+	var deleted []*Notification
+	err = tx.SelectContext(ctx, &deleted, `
+		DELETE FROM notifications WHERE id IN (`+selectQuery+`)
+		RETURNING *
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+	if len(deleted) == 0 {
+		return 0, nil
+	}
 
-	rows, _ := result.RowsAffected()
-	return rows, nil
+	batches, err := archiveBatches(deleted)
+	if err != nil {
+		return 0, err
+	}
+	for _, b := range batches {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO notification_archive (id, run_id, user_id, reason, notification_count, oldest_at, newest_at, payload)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, uuid.New(), run.ID, b.userID, reason, b.count, b.oldest, b.newest, b.payload)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	run.ArchivedBatches += int64(len(batches))
+	return int64(len(deleted)), nil
+}
+
+type archiveBatch struct {
+	userID  uuid.UUID
+	count   int
+	oldest  time.Time
+	newest  time.Time
+	payload []byte
+}
+
+// archiveBatches groups pruned rows per user as gzip-compressed JSON arrays
+func archiveBatches(items []*Notification) ([]archiveBatch, error) {
+	byUser := make(map[uuid.UUID][]*Notification)
+	order := make([]uuid.UUID, 0)
+	for _, n := range items {
+		if _, ok := byUser[n.UserID]; !ok {
+			order = append(order, n.UserID)
+		}
+		byUser[n.UserID] = append(byUser[n.UserID], n)
+	}
+
+	out := make([]archiveBatch, 0, len(order))
+	for _, userID := range order {
+		group := byUser[userID]
+		b := archiveBatch{userID: userID, count: len(group), oldest: group[0].CreatedAt, newest: group[0].CreatedAt}
+		for _, n := range group {
+			if n.CreatedAt.Before(b.oldest) {
+				b.oldest = n.CreatedAt
+			}
+			if n.CreatedAt.After(b.newest) {
+				b.newest = n.CreatedAt
+			}
+		}
+
+		rows := make([]*NotificationResponse, len(group))
+		for i, n := range group {
+			rows[i] = NotificationResponseFromEntity(n)
+		}
+		raw, err := json.Marshal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("marshal notification archive: %w", err)
+		}
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		b.payload = buf.Bytes()
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// ListRuns returns recent cleanup runs, newest first
+func (j *CleanupJob) ListRuns(ctx context.Context, limit int) ([]*CleanupRun, error) {
+	var runs []*CleanupRun
+	err := j.db.SelectContext(ctx, &runs, `
+		SELECT id, trigger, triggered_by, status, deleted_read, deleted_unread, deleted_by_type,
+			deleted_over_limit, archived_batches, error, started_at, finished_at
+		FROM notification_cleanup_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	return runs, err
+}
+
+// GetRun returns a cleanup run by ID
+func (j *CleanupJob) GetRun(ctx context.Context, id uuid.UUID) (*CleanupRun, error) {
+	var run CleanupRun
+	err := j.db.GetContext(ctx, &run, `
+		SELECT id, trigger, triggered_by, status, deleted_read, deleted_unread, deleted_by_type,
+			deleted_over_limit, archived_batches, error, started_at, finished_at
+		FROM notification_cleanup_runs
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
 }
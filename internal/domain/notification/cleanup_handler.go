@@ -0,0 +1,157 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/domain/admin"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// CleanupHandler exposes notification retention runs to admins
+type CleanupHandler struct {
+	job      *CleanupJob
+	adminSvc *admin.Service
+}
+
+// NewCleanupHandler creates cleanup admin handler
+func NewCleanupHandler(job *CleanupJob, adminSvc *admin.Service) *CleanupHandler {
+	return &CleanupHandler{job: job, adminSvc: adminSvc}
+}
+
+// AdminRoutes returns admin routes for notification retention
+func (h *CleanupHandler) AdminRoutes(jwtSvc *admin.JWTService, adminSvc *admin.Service) chi.Router {
+	r := chi.NewRouter()
+	r.Use(admin.AuthMiddleware(jwtSvc, adminSvc))
+	r.Use(admin.RequirePermission(admin.PermManageNotifications))
+
+	r.Get("/policy", h.GetPolicy)
+	r.Post("/runs", h.TriggerRun)
+	r.Get("/runs", h.ListRuns)
+	r.Get("/runs/{id}", h.GetRun)
+
+	return r
+}
+
+// RetentionPolicyResponse describes the active retention policy
+type RetentionPolicyResponse struct {
+	ReadRetentionDays   int          `json:"read_retention_days"`
+	UnreadRetentionDays int          `json:"unread_retention_days"`
+	TypeRetentionDays   map[Type]int `json:"type_retention_days"`
+	MaxPerUser          int          `json:"max_per_user"`
+	BatchSize           int          `json:"batch_size"`
+}
+
+// GetPolicy handles GET /admin/notifications/cleanup/policy
+// @Summary Политика хранения уведомлений
+// @Tags Admin Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=RetentionPolicyResponse}
+// @Failure 401,403 {object} response.Response
+// @Router /admin/notifications/cleanup/policy [get]
+func (h *CleanupHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	policy := h.job.Policy()
+	const day = 24 * 60 * 60
+
+	resp := RetentionPolicyResponse{
+		ReadRetentionDays:   int(policy.ReadRetention.Seconds()) / day,
+		UnreadRetentionDays: int(policy.UnreadRetention.Seconds()) / day,
+		TypeRetentionDays:   make(map[Type]int, len(policy.TypeRetention)),
+		MaxPerUser:          policy.MaxPerUser,
+		BatchSize:           policy.BatchSize,
+	}
+	for t, d := range policy.TypeRetention {
+		resp.TypeRetentionDays[t] = int(d.Seconds()) / day
+	}
+
+	response.OK(w, resp)
+}
+
+// TriggerRun handles POST /admin/notifications/cleanup/runs
+// @Summary Запустить очистку уведомлений
+// @Tags Admin Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} response.Response{data=CleanupRun}
+// @Failure 401,403,409,500 {object} response.Response
+// @Router /admin/notifications/cleanup/runs [post]
+func (h *CleanupHandler) TriggerRun(w http.ResponseWriter, r *http.Request) {
+	adminID := admin.GetAdminID(r.Context())
+
+	run, err := h.job.Trigger(CleanupTriggerAdmin, &adminID)
+	if err != nil {
+		if errors.Is(err, ErrCleanupInProgress) {
+			response.Conflict(w, "Notification cleanup is already running")
+			return
+		}
+		response.InternalError(w)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), adminID, "notifications.cleanup", "notification_cleanup_run", run.ID, "Manual retention run", nil, nil)
+
+	response.JSON(w, http.StatusAccepted, run)
+}
+
+// ListRuns handles GET /admin/notifications/cleanup/runs
+// @Summary История запусков очистки уведомлений
+// @Tags Admin Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Лимит"
+// @Success 200 {object} response.Response{data=[]CleanupRun}
+// @Failure 401,403,500 {object} response.Response
+// @Router /admin/notifications/cleanup/runs [get]
+func (h *CleanupHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	runs, err := h.job.ListRuns(r.Context(), limit)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	if runs == nil {
+		runs = []*CleanupRun{}
+	}
+
+	response.OK(w, runs)
+}
+
+// GetRun handles GET /admin/notifications/cleanup/runs/{id}
+// @Summary Запуск очистки уведомлений
+// @Tags Admin Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID запуска"
+// @Success 200 {object} response.Response{data=CleanupRun}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /admin/notifications/cleanup/runs/{id} [get]
+func (h *CleanupHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid run ID")
+		return
+	}
+
+	run, err := h.job.GetRun(r.Context(), id)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	if run == nil {
+		response.NotFound(w, "Cleanup run not found")
+		return
+	}
+
+	response.OK(w, run)
+}
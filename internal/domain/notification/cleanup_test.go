@@ -0,0 +1,64 @@
+package notification
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseTypeRetention(t *testing.T) {
+	got := ParseTypeRetention("new_message=30, profile_viewed=14,broken,bad=-1")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(got))
+	}
+	if got[TypeNewMessage] != 30*24*time.Hour {
+		t.Fatalf("unexpected new_message retention: %v", got[TypeNewMessage])
+	}
+}
+
+func TestArchiveBatchesGroupsPerUser(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []*Notification{
+		{ID: uuid.New(), UserID: alice, Type: TypeNewMessage, Title: "a1", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: uuid.New(), UserID: bob, Type: TypeNewResponse, Title: "b1", CreatedAt: base},
+		{ID: uuid.New(), UserID: alice, Type: TypeNewMessage, Title: "a2", CreatedAt: base},
+	}
+
+	batches, err := archiveBatches(items)
+	if err != nil {
+		t.Fatalf("archiveBatches: %v", err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(batches))
+	}
+
+	first := batches[0]
+	if first.userID != alice || first.count != 2 {
+		t.Fatalf("unexpected first batch: user=%v count=%d", first.userID, first.count)
+	}
+	if !first.oldest.Equal(base) || !first.newest.Equal(base.Add(2*time.Hour)) {
+		t.Fatalf("unexpected bounds: %v..%v", first.oldest, first.newest)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(first.payload))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read payload: %v", err)
+	}
+	var rows []NotificationResponse
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Title != "a1" {
+		t.Fatalf("unexpected archived rows: %+v", rows)
+	}
+}
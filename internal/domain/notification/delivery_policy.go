@@ -0,0 +1,191 @@
+package notification
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ThrottleRule caps push/email deliveries of one type per user within a window
+type ThrottleRule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// DefaultThrottleRules are applied when no rules are configured
+func DefaultThrottleRules() map[Type]ThrottleRule {
+	return map[Type]ThrottleRule{
+		TypeNewMessage:    {Limit: 10, Window: time.Hour},
+		TypeNewResponse:   {Limit: 20, Window: time.Hour},
+		TypeProfileViewed: {Limit: 5, Window: 24 * time.Hour},
+	}
+}
+
+// ParseThrottleRules parses "new_message=10/1h,profile_viewed=5/24h".
+// Malformed entries are skipped.
+func ParseThrottleRules(raw string) map[Type]ThrottleRule {
+	rules := make(map[Type]ThrottleRule)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		limitRaw, windowRaw, ok := strings.Cut(spec, "/")
+		if !ok {
+			continue
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(limitRaw))
+		if err != nil || limit <= 0 {
+			continue
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(windowRaw))
+		if err != nil || window <= 0 {
+			continue
+		}
+		rules[Type(strings.TrimSpace(name))] = ThrottleRule{Limit: limit, Window: window}
+	}
+	return rules
+}
+
+// DeliveryPolicyConfig configures quiet hours, coalescing and throttling
+type DeliveryPolicyConfig struct {
+	CoalesceWindow  time.Duration
+	Throttles       map[Type]ThrottleRule
+	DefaultTimezone string
+}
+
+// DeliveryPlan is the outcome of applying preferences and policy to one notification
+type DeliveryPlan struct {
+	Channels   ChannelSettings
+	DeferUntil time.Time // Non-zero when push/email must wait for quiet hours to end
+	Throttled  bool      // Push/email dropped by per-type throttle
+}
+
+// Deferred reports whether push/email must be postponed
+func (p DeliveryPlan) Deferred() bool {
+	return !p.DeferUntil.IsZero()
+}
+
+// DeliveryPolicy decides which channels a notification goes out on and when
+type DeliveryPolicy struct {
+	coalesceWindow time.Duration
+	throttles      map[Type]ThrottleRule
+	defaultLoc     *time.Location
+	limiter        *typeWindowLimiter
+	now            func() time.Time
+}
+
+// NewDeliveryPolicy creates a delivery policy
+func NewDeliveryPolicy(cfg DeliveryPolicyConfig) *DeliveryPolicy {
+	throttles := cfg.Throttles
+	if len(throttles) == 0 {
+		throttles = DefaultThrottleRules()
+	}
+
+	loc := time.UTC
+	if cfg.DefaultTimezone != "" {
+		if l, err := time.LoadLocation(cfg.DefaultTimezone); err == nil {
+			loc = l
+		}
+	}
+
+	return &DeliveryPolicy{
+		coalesceWindow: cfg.CoalesceWindow,
+		throttles:      throttles,
+		defaultLoc:     loc,
+		limiter:        newTypeWindowLimiter(throttles),
+		now:            time.Now,
+	}
+}
+
+// CoalesceWindow returns the window within which new_message notifications per room are merged
+func (p *DeliveryPolicy) CoalesceWindow() time.Duration {
+	return p.coalesceWindow
+}
+
+// Plan resolves channels for a notification. In-app is never deferred or throttled;
+// push and email are throttled per type and postponed during quiet hours.
+func (p *DeliveryPolicy) Plan(prefs *UserPreferences, userID uuid.UUID, notifType Type) DeliveryPlan {
+	plan := DeliveryPlan{Channels: prefs.GetChannelsForType(notifType)}
+	if !plan.Channels.Email && !plan.Channels.Push {
+		return plan
+	}
+
+	now := p.now()
+	if rule, ok := p.throttles[notifType]; ok {
+		if !p.limiter.Allow(userID.String()+":"+string(notifType), rule, now) {
+			plan.Channels.Email = false
+			plan.Channels.Push = false
+			plan.Throttled = true
+			return plan
+		}
+	}
+
+	if until, quiet := prefs.QuietHoursEndAt(now, p.defaultLoc); quiet {
+		plan.DeferUntil = until
+	}
+
+	return plan
+}
+
+// typeWindowLimiter is a sliding-window counter keyed by user and type
+type typeWindowLimiter struct {
+	mu        sync.Mutex
+	calls     map[string][]time.Time
+	maxWindow time.Duration // longest rule window; older keys are swept
+	lastSweep time.Time
+}
+
+func newTypeWindowLimiter(rules map[Type]ThrottleRule) *typeWindowLimiter {
+	l := &typeWindowLimiter{calls: make(map[string][]time.Time)}
+	for _, rule := range rules {
+		if rule.Window > l.maxWindow {
+			l.maxWindow = rule.Window
+		}
+	}
+	return l
+}
+
+func (l *typeWindowLimiter) Allow(key string, rule ThrottleRule, now time.Time) bool {
+	cutoff := now.Add(-rule.Window)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= l.maxWindow {
+		l.sweep(now)
+	}
+
+	timestamps := l.calls[key]
+	kept := timestamps[:0]
+	for _, ts := range timestamps {
+		if ts.After(cutoff) {
+			kept = append(kept, ts)
+		}
+	}
+	if len(kept) >= rule.Limit {
+		l.calls[key] = kept
+		return false
+	}
+
+	l.calls[key] = append(kept, now)
+	return true
+}
+
+// sweep drops keys whose newest call is outside every rule window
+func (l *typeWindowLimiter) sweep(now time.Time) {
+	cutoff := now.Add(-l.maxWindow)
+	for key, timestamps := range l.calls {
+		if len(timestamps) == 0 || !timestamps[len(timestamps)-1].After(cutoff) {
+			delete(l.calls, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,131 @@
+package notification
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func quietPrefs(start, end string) *UserPreferences {
+	return &UserPreferences{
+		EmailEnabled:       true,
+		PushEnabled:        true,
+		InAppEnabled:       true,
+		Channels:           TypeChannels{TypeNewMessage: {InApp: true, Email: true, Push: true}},
+		QuietHoursStart:    sql.NullString{String: start, Valid: true},
+		QuietHoursEnd:      sql.NullString{String: end, Valid: true},
+		QuietHoursTimezone: "Asia/Almaty",
+	}
+}
+
+func TestQuietHoursEndAtWrapsMidnight(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Almaty")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	prefs := quietPrefs("22:00", "08:00")
+
+	lateEvening := time.Date(2026, 3, 10, 23, 30, 0, 0, loc)
+	until, quiet := prefs.QuietHoursEndAt(lateEvening, time.UTC)
+	if !quiet {
+		t.Fatal("expected 23:30 to be inside quiet hours")
+	}
+	if want := time.Date(2026, 3, 11, 8, 0, 0, 0, loc); !until.Equal(want) {
+		t.Fatalf("expected quiet hours to end at %v, got %v", want, until)
+	}
+
+	earlyMorning := time.Date(2026, 3, 11, 6, 0, 0, 0, loc)
+	until, quiet = prefs.QuietHoursEndAt(earlyMorning, time.UTC)
+	if !quiet || !until.Equal(time.Date(2026, 3, 11, 8, 0, 0, 0, loc)) {
+		t.Fatalf("expected 06:00 to be quiet until 08:00, got quiet=%v until=%v", quiet, until)
+	}
+
+	if _, quiet := prefs.QuietHoursEndAt(time.Date(2026, 3, 11, 12, 0, 0, 0, loc), time.UTC); quiet {
+		t.Fatal("expected noon to be outside quiet hours")
+	}
+}
+
+func TestDeliveryPolicyThrottlesPushAndEmail(t *testing.T) {
+	policy := NewDeliveryPolicy(DeliveryPolicyConfig{
+		Throttles: map[Type]ThrottleRule{TypeNewMessage: {Limit: 2, Window: time.Hour}},
+	})
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	policy.now = func() time.Time { return now }
+
+	prefs := quietPrefs("", "")
+	prefs.QuietHoursStart.Valid = false
+	userID := uuid.New()
+
+	for i := 0; i < 2; i++ {
+		if plan := policy.Plan(prefs, userID, TypeNewMessage); plan.Throttled || !plan.Channels.Push {
+			t.Fatalf("delivery %d should pass, got %+v", i+1, plan)
+		}
+	}
+
+	plan := policy.Plan(prefs, userID, TypeNewMessage)
+	if !plan.Throttled || plan.Channels.Push || plan.Channels.Email {
+		t.Fatalf("third delivery should be throttled, got %+v", plan)
+	}
+	if !plan.Channels.InApp {
+		t.Fatal("throttling must not disable in-app")
+	}
+
+	now = now.Add(61 * time.Minute)
+	if plan := policy.Plan(prefs, userID, TypeNewMessage); plan.Throttled {
+		t.Fatal("expected throttle window to reset")
+	}
+}
+
+func TestDeliveryPolicyDefersDuringQuietHours(t *testing.T) {
+	policy := NewDeliveryPolicy(DeliveryPolicyConfig{DefaultTimezone: "UTC"})
+	policy.now = func() time.Time { return time.Date(2026, 3, 11, 23, 0, 0, 0, time.UTC) }
+
+	prefs := quietPrefs("22:00", "07:00")
+	prefs.QuietHoursTimezone = "UTC"
+
+	plan := policy.Plan(prefs, uuid.New(), TypeNewMessage)
+	if !plan.Deferred() {
+		t.Fatal("expected push/email to be deferred")
+	}
+	if want := time.Date(2026, 3, 12, 7, 0, 0, 0, time.UTC); !plan.DeferUntil.Equal(want) {
+		t.Fatalf("expected defer until %v, got %v", want, plan.DeferUntil)
+	}
+}
+
+func TestParseThrottleRules(t *testing.T) {
+	rules := ParseThrottleRules("new_message=10/1h, profile_viewed=3/24h,broken,bad=x/1h")
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+	if r := rules[TypeProfileViewed]; r.Limit != 3 || r.Window != 24*time.Hour {
+		t.Fatalf("unexpected profile_viewed rule: %+v", r)
+	}
+}
+
+func TestCoalescedMessageTitle(t *testing.T) {
+	cases := map[int]string{
+		2:  "2 новых сообщения от Anna",
+		5:  "5 новых сообщений от Anna",
+		11: "11 новых сообщений от Anna",
+		21: "21 новое сообщение от Anna",
+	}
+	for count, want := range cases {
+		if got := coalescedMessageTitle(count, "Anna"); got != want {
+			t.Fatalf("count %d: expected %q, got %q", count, want, got)
+		}
+	}
+}
+
+func TestTypeWindowLimiterSweepsIdleKeys(t *testing.T) {
+	rule := ThrottleRule{Limit: 1, Window: time.Hour}
+	l := newTypeWindowLimiter(map[Type]ThrottleRule{TypeNewMessage: rule})
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	l.Allow("a", rule, now)
+	l.Allow("b", rule, now.Add(90*time.Minute))
+	if _, ok := l.calls["a"]; ok || len(l.calls) != 1 {
+		t.Fatalf("expected idle key to be swept, got %v", l.calls)
+	}
+}
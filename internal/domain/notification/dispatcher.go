@@ -0,0 +1,321 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/pkg/email"
+	"github.com/mwork/mwork-api/internal/pkg/push"
+)
+
+// Delivery channels stored for deferred deliveries
+const (
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+// EmailDelivery describes a templated email to send
+type EmailDelivery struct {
+	To       string            `json:"to"`
+	ToName   string            `json:"to_name"`
+	Template string            `json:"template"`
+	Subject  string            `json:"subject"`
+	Data     map[string]string `json:"data"`
+}
+
+// PushDelivery describes a push message to send to all active devices of a user
+type PushDelivery struct {
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data,omitempty"`
+}
+
+// DeferredDelivery is a push/email held back until quiet hours end
+type DeferredDelivery struct {
+	ID             uuid.UUID       `db:"id"`
+	UserID         uuid.UUID       `db:"user_id"`
+	NotificationID uuid.NullUUID   `db:"notification_id"`
+	Type           Type            `db:"type"`
+	Channel        string          `db:"channel"`
+	Payload        json.RawMessage `db:"payload"`
+	DeliverAfter   time.Time       `db:"deliver_after"`
+	DeliveredAt    sql.NullTime    `db:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at"`
+}
+
+// DeferredRepository handles deferred deliveries
+type DeferredRepository struct {
+	db *sqlx.DB
+}
+
+// NewDeferredRepository creates deferred delivery repository
+func NewDeferredRepository(db *sqlx.DB) *DeferredRepository {
+	return &DeferredRepository{db: db}
+}
+
+// Create stores a deferred delivery
+func (r *DeferredRepository) Create(ctx context.Context, d *DeferredDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_deferred_deliveries (id, user_id, notification_id, type, channel, payload, deliver_after, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, d.ID, d.UserID, d.NotificationID, d.Type, d.Channel, d.Payload, d.DeliverAfter, d.CreatedAt)
+	return err
+}
+
+// ClaimDue marks due deliveries delivered and returns them. Claiming before sending
+// means each delivery goes out at most once, even with several instances flushing
+// or a crash mid-send.
+func (r *DeferredRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]*DeferredDelivery, error) {
+	var items []*DeferredDelivery
+	err := r.db.SelectContext(ctx, &items, `
+		UPDATE notification_deferred_deliveries SET delivered_at = NOW()
+		WHERE id IN (
+			SELECT id FROM notification_deferred_deliveries
+			WHERE delivered_at IS NULL AND deliver_after <= $1
+			ORDER BY deliver_after
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, notification_id, type, channel, payload, deliver_after, delivered_at, created_at
+	`, now, limit)
+	return items, err
+}
+
+// DispatcherConfig holds dispatcher dependencies
+type DispatcherConfig struct {
+	Repo         Repository
+	PrefsRepo    *PreferencesRepository
+	DeviceRepo   *DeviceTokenRepository
+	DeferredRepo *DeferredRepository
+	Policy       *DeliveryPolicy
+	EmailService *email.Service
+	PushClient   *push.FCMClient
+	Interval     time.Duration
+}
+
+// Dispatcher routes push/email through user preferences and the delivery policy,
+// and flushes deliveries deferred by quiet hours in the background.
+type Dispatcher struct {
+	repo         Repository
+	prefsRepo    *PreferencesRepository
+	deviceRepo   *DeviceTokenRepository
+	deferredRepo *DeferredRepository
+	policy       *DeliveryPolicy
+	emailService *email.Service
+	pushClient   *push.FCMClient
+	interval     time.Duration
+	stopCh       chan struct{}
+}
+
+// NewDispatcher creates a notification dispatcher
+func NewDispatcher(cfg DispatcherConfig) *Dispatcher {
+	interval := cfg.Interval
+	if interval == 0 {
+		interval = 1 * time.Minute
+	}
+	policy := cfg.Policy
+	if policy == nil {
+		policy = NewDeliveryPolicy(DeliveryPolicyConfig{})
+	}
+	return &Dispatcher{
+		repo:         cfg.Repo,
+		prefsRepo:    cfg.PrefsRepo,
+		deviceRepo:   cfg.DeviceRepo,
+		deferredRepo: cfg.DeferredRepo,
+		policy:       policy,
+		emailService: cfg.EmailService,
+		pushClient:   cfg.PushClient,
+		interval:     interval,
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// CoalesceWindow exposes the policy's new_message coalescing window
+func (d *Dispatcher) CoalesceWindow() time.Duration {
+	return d.policy.CoalesceWindow()
+}
+
+// Preferences loads user preferences, falling back to all-channels-on defaults
+func (d *Dispatcher) Preferences(ctx context.Context, userID uuid.UUID) *UserPreferences {
+	prefs, err := d.prefsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", userID.String()).Msg("Failed to get notification preferences, using defaults")
+		return &UserPreferences{InAppEnabled: true, EmailEnabled: true, PushEnabled: true}
+	}
+	return prefs
+}
+
+// Plan resolves the delivery plan for a notification
+func (d *Dispatcher) Plan(prefs *UserPreferences, userID uuid.UUID, notifType Type) DeliveryPlan {
+	return d.policy.Plan(prefs, userID, notifType)
+}
+
+// Deliver sends push/email according to plan, deferring them during quiet hours
+func (d *Dispatcher) Deliver(ctx context.Context, plan DeliveryPlan, userID uuid.UUID, notifType Type, notificationID *uuid.UUID, emailMsg *EmailDelivery, pushMsg *PushDelivery) {
+	if plan.Throttled {
+		log.Debug().Str("user_id", userID.String()).Str("notification_type", string(notifType)).Msg("Notification push/email throttled")
+		return
+	}
+
+	if plan.Channels.Email && emailMsg != nil && emailMsg.To != "" {
+		if plan.Deferred() {
+			d.deferDelivery(ctx, userID, notifType, notificationID, ChannelEmail, emailMsg, plan.DeferUntil)
+		} else {
+			d.sendEmail(emailMsg)
+		}
+	}
+
+	if plan.Channels.Push && pushMsg != nil {
+		if plan.Deferred() {
+			d.deferDelivery(ctx, userID, notifType, notificationID, ChannelPush, pushMsg, plan.DeferUntil)
+		} else {
+			go d.sendPush(context.Background(), userID, pushMsg)
+		}
+	}
+}
+
+func (d *Dispatcher) deferDelivery(ctx context.Context, userID uuid.UUID, notifType Type, notificationID *uuid.UUID, channel string, payload interface{}, until time.Time) {
+	if d.deferredRepo == nil {
+		return
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	item := &DeferredDelivery{
+		ID:           uuid.New(),
+		UserID:       userID,
+		Type:         notifType,
+		Channel:      channel,
+		Payload:      raw,
+		DeliverAfter: until,
+		CreatedAt:    time.Now(),
+	}
+	if notificationID != nil {
+		item.NotificationID = uuid.NullUUID{UUID: *notificationID, Valid: true}
+	}
+
+	if err := d.deferredRepo.Create(ctx, item); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Str("channel", channel).Msg("Failed to defer notification delivery")
+		return
+	}
+	log.Debug().Str("user_id", userID.String()).Str("channel", channel).Time("deliver_after", until).Msg("Notification delivery deferred by quiet hours")
+}
+
+func (d *Dispatcher) sendEmail(msg *EmailDelivery) {
+	if d.emailService == nil {
+		return
+	}
+	d.emailService.Queue(msg.To, msg.ToName, msg.Template, msg.Subject, msg.Data)
+}
+
+func (d *Dispatcher) sendPush(ctx context.Context, userID uuid.UUID, msg *PushDelivery) {
+	if d.deviceRepo == nil || d.pushClient == nil {
+		return
+	}
+
+	tokens, err := d.deviceRepo.GetActiveByUserID(ctx, userID)
+	if err != nil || len(tokens) == 0 {
+		return
+	}
+
+	for _, token := range tokens {
+		err := d.pushClient.Send(ctx, &push.PushMessage{
+			Token: token,
+			Title: msg.Title,
+			Body:  msg.Body,
+			Data:  msg.Data,
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("user_id", userID.String()).Msg("Failed to send push")
+			if errors.Is(err, push.ErrUnregisteredToken) {
+				d.deviceRepo.Deactivate(ctx, userID, token)
+			}
+		}
+	}
+}
+
+// Start begins flushing deferred deliveries in the background
+func (d *Dispatcher) Start() {
+	log.Info().Msg("Starting notification dispatcher...")
+	go d.loop()
+}
+
+// Stop gracefully stops the background flush
+func (d *Dispatcher) Stop() {
+	log.Info().Msg("Stopping notification dispatcher...")
+	close(d.stopCh)
+}
+
+func (d *Dispatcher) loop() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.flushDeferred()
+		case <-d.stopCh:
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) flushDeferred() {
+	if d.deferredRepo == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	items, err := d.deferredRepo.ClaimDue(ctx, time.Now(), 500)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to claim deferred notification deliveries")
+		return
+	}
+
+	for _, item := range items {
+		switch item.Channel {
+		case ChannelEmail:
+			var msg EmailDelivery
+			if err := json.Unmarshal(item.Payload, &msg); err == nil {
+				d.sendEmail(&msg)
+			}
+		case ChannelPush:
+			var msg PushDelivery
+			if err := json.Unmarshal(item.Payload, &msg); err == nil {
+				d.refreshPush(ctx, item, &msg)
+				d.sendPush(ctx, item.UserID, &msg)
+			}
+		}
+	}
+
+	if len(items) > 0 {
+		log.Info().Int("count", len(items)).Msg("Flushed deferred notification deliveries")
+	}
+}
+
+// refreshPush takes title/body from the linked notification so coalesced
+// messages are pushed once with their final summary ("5 новых сообщений от X").
+func (d *Dispatcher) refreshPush(ctx context.Context, item *DeferredDelivery, msg *PushDelivery) {
+	if !item.NotificationID.Valid || d.repo == nil {
+		return
+	}
+	n, err := d.repo.GetByID(ctx, item.NotificationID.UUID)
+	if err != nil || n == nil {
+		return
+	}
+	msg.Title = n.Title
+	if n.Body.Valid {
+		msg.Body = n.Body.String
+	}
+}
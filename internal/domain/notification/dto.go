@@ -1,6 +1,9 @@
 package notification
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +18,10 @@ type NotificationResponse struct {
 	Data      *NotificationData `json:"data,omitempty"`
 	IsRead    bool              `json:"is_read"`
 	CreatedAt string            `json:"created_at"`
+
+	Actions     []Action `json:"actions,omitempty"`
+	ActionTaken *string  `json:"action_taken,omitempty"`
+	ArchivedAt  *string  `json:"archived_at,omitempty"`
 }
 
 // NotificationResponseFromEntity converts entity to response
@@ -35,9 +42,93 @@ func NotificationResponseFromEntity(n *Notification) *NotificationResponse {
 		resp.Data = n.GetData()
 	}
 
+	resp.Actions = AvailableActions(n)
+	if n.ActionTaken.Valid {
+		resp.ActionTaken = &n.ActionTaken.String
+	}
+	if n.ArchivedAt.Valid {
+		archivedAt := n.ArchivedAt.Time.Format(time.RFC3339)
+		resp.ArchivedAt = &archivedAt
+	}
+
 	return resp
 }
 
+// NotificationGroupResponse is a grouped entry of GET /notifications?grouped=true
+type NotificationGroupResponse struct {
+	Key             string                `json:"key"`
+	Type            string                `json:"type"`
+	Count           int                   `json:"count"`
+	UnreadCount     int                   `json:"unread_count"`
+	NotificationIDs []uuid.UUID           `json:"notification_ids"`
+	Latest          *NotificationResponse `json:"latest,omitempty"`
+}
+
+// NotificationGroupResponseFromGroup converts a group to response
+func NotificationGroupResponseFromGroup(g *InboxGroup) *NotificationGroupResponse {
+	resp := &NotificationGroupResponse{
+		Key:             g.Key,
+		Type:            string(g.Type),
+		Count:           g.Count,
+		UnreadCount:     g.UnreadCount,
+		NotificationIDs: g.IDs,
+	}
+	if resp.NotificationIDs == nil {
+		resp.NotificationIDs = []uuid.UUID{}
+	}
+	if g.Latest != nil {
+		resp.Latest = NotificationResponseFromEntity(g.Latest)
+	}
+	return resp
+}
+
+// ActionRequest for POST /notifications/{id}/actions
+type ActionRequest struct {
+	Action Action `json:"action"`
+	Text   string `json:"text,omitempty"` // Reply text for "reply"
+}
+
+// Bulk operations
+const (
+	BulkDelete    = "delete"
+	BulkArchive   = "archive"
+	BulkUnarchive = "unarchive"
+)
+
+// maxBulkIDs caps IDs per bulk request
+const maxBulkIDs = 200
+
+// BulkRequest for POST /notifications/bulk
+type BulkRequest struct {
+	Operation string      `json:"operation"` // delete, archive, unarchive
+	IDs       []uuid.UUID `json:"ids"`
+}
+
+// BulkResponse reports how many notifications were affected
+type BulkResponse struct {
+	Affected    int64 `json:"affected"`
+	UnreadCount int   `json:"unread_count"`
+}
+
+// ErrUnknownType is returned for type filters naming no known notification type
+var ErrUnknownType = errors.New("unknown notification type")
+
+// ParseTypes parses a comma-separated type list; empty input means no filter
+func ParseTypes(raw string) ([]Type, error) {
+	var out []Type
+	for _, part := range strings.Split(raw, ",") {
+		t := Type(strings.TrimSpace(part))
+		if t == "" {
+			continue
+		}
+		if !IsKnownType(t) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownType, t)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
 // UnreadCountResponse for unread count endpoint
 type UnreadCountResponse struct {
 	UnreadCount int `json:"unread_count"`
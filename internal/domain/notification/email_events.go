@@ -0,0 +1,145 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/mwork/mwork-api/internal/pkg/email"
+)
+
+// EmailEventRepository stores provider delivery events and address suppression
+type EmailEventRepository struct {
+	db *sqlx.DB
+}
+
+// NewEmailEventRepository creates email event repository
+func NewEmailEventRepository(db *sqlx.DB) *EmailEventRepository {
+	return &EmailEventRepository{db: db}
+}
+
+// Apply records a provider event and applies its suppression in one transaction,
+// so a failed suppression is retried with the provider's redelivery.
+// Returns false if the event was already applied.
+func (r *EmailEventRepository) Apply(ctx context.Context, ev email.Event) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	fresh, err := recordEvent(ctx, tx, ev)
+	if err != nil || !fresh {
+		return false, err
+	}
+
+	switch ev.Type {
+	case email.EventBounced:
+		err = suppress(ctx, tx, ev.Email, email.SuppressionBounced)
+	case email.EventComplained:
+		if err = suppress(ctx, tx, ev.Email, email.SuppressionComplained); err == nil {
+			_, err = disableEmail(ctx, tx, ev.Email)
+		}
+	case email.EventUnsubscribed:
+		_, err = disableEmail(ctx, tx, ev.Email)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// DisableEmail turns off UserPreferences.EmailEnabled for the owner of an address.
+// Returns false if no user has that address.
+func (r *EmailEventRepository) DisableEmail(ctx context.Context, address string) (bool, error) {
+	return disableEmail(ctx, r.db, address)
+}
+
+// recordEvent stores an event; returns false if the provider event was already seen
+func recordEvent(ctx context.Context, db sqlx.ExtContext, ev email.Event) (bool, error) {
+	var providerID sql.NullString
+	if ev.ProviderEventID != "" {
+		providerID = sql.NullString{String: ev.ProviderEventID, Valid: true}
+	}
+
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO email_events (id, user_id, email, event, reason, provider_event_id, occurred_at)
+		VALUES ($1, (SELECT id FROM users WHERE LOWER(email) = $2 LIMIT 1), $2, $3, NULLIF($4, ''), $5, $6)
+		ON CONFLICT (provider_event_id) WHERE provider_event_id IS NOT NULL DO NOTHING
+	`, uuid.New(), strings.ToLower(ev.Email), ev.Type, ev.Reason, providerID, ev.OccurredAt)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// suppress marks an address as suppressed. A bounce overrides an earlier complaint.
+func suppress(ctx context.Context, db sqlx.ExtContext, address, reason string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE users SET
+			email_suppressed_at = NOW(),
+			email_suppression_reason = $2,
+			updated_at = NOW()
+		WHERE LOWER(email) = $1
+			AND (email_suppression_reason IS NULL OR $2 = 'bounced')
+	`, strings.ToLower(address), reason)
+	return err
+}
+
+func disableEmail(ctx context.Context, db sqlx.ExtContext, address string) (bool, error) {
+	var userID uuid.UUID
+	err := sqlx.GetContext(ctx, db, &userID, `SELECT id FROM users WHERE LOWER(email) = $1 LIMIT 1`, strings.ToLower(address))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO user_notification_preferences (
+			id, user_id, email_enabled, push_enabled, in_app_enabled,
+			channels, digest_enabled, digest_frequency
+		) VALUES ($1, $2, false, true, true, '{}'::jsonb, true, 'weekly')
+		ON CONFLICT (user_id) DO UPDATE SET email_enabled = false, updated_at = NOW()
+	`, uuid.New(), userID)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SuppressionReason implements email.SuppressionChecker. Addresses with
+// EmailEnabled turned off are reported as unsubscribed.
+func (r *EmailEventRepository) SuppressionReason(ctx context.Context, address string) (string, error) {
+	var row struct {
+		Reason       sql.NullString `db:"email_suppression_reason"`
+		EmailEnabled bool           `db:"email_enabled"`
+	}
+	err := r.db.GetContext(ctx, &row, `
+		SELECT u.email_suppression_reason, COALESCE(p.email_enabled, true) AS email_enabled
+		FROM users u
+		LEFT JOIN user_notification_preferences p ON p.user_id = u.id
+		WHERE LOWER(u.email) = $1
+		LIMIT 1
+	`, strings.ToLower(address))
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if row.Reason.Valid {
+		return row.Reason.String, nil
+	}
+	if !row.EmailEnabled {
+		return email.SuppressionUnsubscribed, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,138 @@
+package notification
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/pkg/email"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// maxEmailWebhookBody caps provider webhook payloads
+const maxEmailWebhookBody = 5 << 20
+
+// EmailWebhookHandler ingests provider delivery events and unsubscribe clicks
+type EmailWebhookHandler struct {
+	repo      *EmailEventRepository
+	signer    *email.UnsubscribeSigner
+	publicKey string
+}
+
+// NewEmailWebhookHandler creates email webhook handler.
+// publicKey is the SendGrid signed-webhook key; without it delivery events are not accepted.
+func NewEmailWebhookHandler(repo *EmailEventRepository, signer *email.UnsubscribeSigner, publicKey string) *EmailWebhookHandler {
+	return &EmailWebhookHandler{repo: repo, signer: signer, publicKey: publicKey}
+}
+
+// WebhookRoutes returns email webhook router (no auth, signature verification)
+func (h *EmailWebhookHandler) WebhookRoutes() chi.Router {
+	r := chi.NewRouter()
+	// Unsigned events could suppress any address, so the endpoint only exists with a key
+	if h.publicKey != "" {
+		r.Post("/sendgrid", h.SendGridEvents)
+	} else {
+		log.Warn().Msg("SENDGRID_WEBHOOK_PUBLIC_KEY is not set, email delivery events are not accepted")
+	}
+	r.Get("/unsubscribe", h.UnsubscribePage)
+	r.Post("/unsubscribe", h.Unsubscribe)
+	return r
+}
+
+// SendGridEvents handles POST /webhooks/email/sendgrid
+// @Summary Webhook событий доставки email (SendGrid)
+// @Tags Email Webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response
+// @Failure 400,401,500 {object} response.Response
+// @Router /webhooks/email/sendgrid [post]
+func (h *EmailWebhookHandler) SendGridEvents(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEmailWebhookBody))
+	if err != nil {
+		response.BadRequest(w, "Invalid body")
+		return
+	}
+
+	if h.publicKey == "" {
+		response.Unauthorized(w, "Email webhook is not configured")
+		return
+	}
+	timestamp := r.Header.Get("X-Twilio-Email-Event-Webhook-Timestamp")
+	err = email.VerifySendGridSignature(h.publicKey, r.Header.Get("X-Twilio-Email-Event-Webhook-Signature"), timestamp, body)
+	if err == nil {
+		err = email.VerifyWebhookTimestamp(timestamp, time.Now())
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Rejected email webhook with invalid signature")
+		response.Unauthorized(w, "Invalid signature")
+		return
+	}
+
+	events, err := email.ParseSendGridEvents(body)
+	if err != nil {
+		response.BadRequest(w, "Invalid events payload")
+		return
+	}
+
+	for _, ev := range events {
+		if _, err := h.repo.Apply(r.Context(), ev); err != nil {
+			log.Error().Err(err).Str("event", string(ev.Type)).Msg("Failed to process email event")
+			// Non-2xx makes SendGrid retry the whole batch; already applied events are skipped by id
+			response.InternalError(w)
+			return
+		}
+	}
+
+	response.OK(w, map[string]int{"processed": len(events)})
+}
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="ru"><head><meta charset="utf-8"><title>MWork</title></head>
+<body style="font-family: sans-serif; text-align: center; padding: 40px;">
+{{if .Done}}<p>Вы отписались от email-уведомлений MWork. Включить их снова можно в настройках профиля.</p>
+{{else}}<p>Отписаться от email-уведомлений MWork для {{.Email}}?</p>
+<form method="post"><button type="submit">Отписаться</button></form>{{end}}
+</body></html>`))
+
+// UnsubscribePage handles GET /webhooks/email/unsubscribe.
+// It only renders a confirmation form so link scanners don't unsubscribe users.
+func (h *EmailWebhookHandler) UnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("email")
+	if h.signer == nil || !h.signer.Verify(address, r.URL.Query().Get("sig")) {
+		response.BadRequest(w, "Invalid unsubscribe link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(w, map[string]interface{}{"Email": address, "Done": false})
+}
+
+// Unsubscribe handles POST /webhooks/email/unsubscribe (RFC 8058 one-click)
+// @Summary Отписка от email-уведомлений
+// @Tags Email Webhooks
+// @Param email query string true "Email"
+// @Param sig query string true "Подпись"
+// @Success 200
+// @Failure 400,500 {object} response.Response
+// @Router /webhooks/email/unsubscribe [post]
+func (h *EmailWebhookHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("email")
+	if h.signer == nil || !h.signer.Verify(address, r.URL.Query().Get("sig")) {
+		response.BadRequest(w, "Invalid unsubscribe link")
+		return
+	}
+
+	if _, err := h.repo.DisableEmail(r.Context(), address); err != nil {
+		log.Error().Err(err).Msg("Failed to unsubscribe email")
+		response.InternalError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(w, map[string]interface{}{"Done": true})
+}
@@ -18,6 +18,18 @@ const (
 	TypeNewMessage       Type = "new_message"       // Both: new chat message
 	TypeProfileViewed    Type = "profile_viewed"    // Model: someone viewed profile (Pro)
 	TypeCastingExpiring  Type = "casting_expiring"  // Employer: casting expires soon
+
+	TypeCastingMatchedSearch Type = "casting_matched_search" // Model: new casting matches a saved search
+	TypeShortlisted          Type = "shortlisted"            // Model: response moved to shortlist
+	TypeResponseViewed       Type = "response_viewed"        // Model: employer opened the response
+	TypeReviewReceived       Type = "review_received"        // Both: new review about the user
+	TypeSubscriptionExpiring Type = "subscription_expiring"  // Both: paid plan ends soon
+	TypePaymentSucceeded     Type = "payment_succeeded"      // Both: payment completed
+	TypePaymentFailed        Type = "payment_failed"         // Both: payment failed
+	TypeCreditsLow           Type = "credits_low"            // Both: credit balance below threshold
+	TypeOrganizationInvite   Type = "organization_invite"    // Both: invited to an organization
+	TypeVerificationDecision Type = "verification_decision"  // Both: profile/organization verification reviewed
+	TypePromotionFinished    Type = "promotion_finished"     // Both: promotion completed or budget exhausted
 )
 
 // Notification represents a user notification
@@ -31,6 +43,10 @@ type Notification struct {
 	IsRead    bool            `db:"is_read" json:"is_read"`
 	ReadAt    sql.NullTime    `db:"read_at" json:"read_at,omitempty"`
 	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+
+	ArchivedAt  sql.NullTime   `db:"archived_at" json:"archived_at,omitempty"`
+	ActionTaken sql.NullString `db:"action_taken" json:"action_taken,omitempty"`
+	ActionAt    sql.NullTime   `db:"action_at" json:"action_at,omitempty"`
 }
 
 // NotificationData for linking to entities
@@ -40,6 +56,7 @@ type NotificationData struct {
 	ProfileID  *uuid.UUID `json:"profile_id,omitempty"`
 	RoomID     *uuid.UUID `json:"room_id,omitempty"`
 	MessageID  *uuid.UUID `json:"message_id,omitempty"`
+	Count      int        `json:"count,omitempty"` // Number of coalesced events (e.g. chat messages)
 }
 
 // SetData encodes data to JSON
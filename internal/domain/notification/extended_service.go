@@ -3,6 +3,7 @@ package notification
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -171,7 +172,7 @@ func (s *ExtendedService) sendPush(ctx context.Context, params SendParams) {
 		if err := s.pushClient.Send(context.Background(), msg); err != nil {
 			log.Warn().Err(err).Str("token", token[:20]+"...").Msg("Failed to send push")
 			// Deactivate invalid tokens
-			if err.Error() == "FCM returned status 404" {
+			if errors.Is(err, push.ErrUnregisteredToken) {
 				s.deviceRepo.Deactivate(context.Background(), params.UserID, token)
 			}
 		}
@@ -0,0 +1,190 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ListFilter narrows notification list queries
+type ListFilter struct {
+	Types      []Type
+	UnreadOnly bool
+	Archived   bool // true lists the archive instead of the inbox
+	Limit      int
+	Offset     int
+}
+
+// groupKeyFields defines how similar notifications are grouped in the list view:
+// by a NotificationData field, or "" to fold every notification of the type together.
+// Types not listed are never grouped.
+var groupKeyFields = map[Type]string{
+	TypeNewResponse:          "casting_id",
+	TypeNewMessage:           "room_id",
+	TypeResponseViewed:       "casting_id",
+	TypeProfileViewed:        "",
+	TypeCastingMatchedSearch: "",
+}
+
+// maxGroupIDs caps notification IDs returned per group
+const maxGroupIDs = 50
+
+// groupKeySQL renders the SQL expression computing a notification's group key
+func groupKeySQL() string {
+	var b strings.Builder
+	b.WriteString("CASE type")
+	for _, t := range knownTypes {
+		field, ok := groupKeyFields[t]
+		if !ok {
+			continue
+		}
+		if field == "" {
+			fmt.Fprintf(&b, " WHEN '%s' THEN '%s'", t, t)
+		} else {
+			fmt.Fprintf(&b, " WHEN '%s' THEN '%s:' || COALESCE(data->>'%s', id::text)", t, t, field)
+		}
+	}
+	b.WriteString(" ELSE id::text END")
+	return b.String()
+}
+
+// filterWhere builds the WHERE clause shared by list queries
+func filterWhere(userID uuid.UUID, f ListFilter) (string, []interface{}) {
+	clauses := []string{"user_id = $1"}
+	args := []interface{}{userID}
+
+	if f.Archived {
+		clauses = append(clauses, "archived_at IS NOT NULL")
+	} else {
+		clauses = append(clauses, "archived_at IS NULL")
+	}
+	if f.UnreadOnly {
+		clauses = append(clauses, "is_read = false")
+	}
+	if len(f.Types) > 0 {
+		types := make([]string, len(f.Types))
+		for i, t := range f.Types {
+			types[i] = string(t)
+		}
+		args = append(args, pq.Array(types))
+		clauses = append(clauses, "type = ANY($"+strconv.Itoa(len(args))+")")
+	}
+
+	return strings.Join(clauses, " AND "), args
+}
+
+// GroupRow is an aggregated group of similar notifications
+type GroupRow struct {
+	Type        Type           `db:"type"`
+	Key         string         `db:"group_key"`
+	Count       int            `db:"count"`
+	UnreadCount int            `db:"unread_count"`
+	LatestAt    time.Time      `db:"latest_at"`
+	IDs         pq.StringArray `db:"ids"`
+}
+
+// InboxGroup is a grouped entry of the list view
+type InboxGroup struct {
+	Key         string
+	Type        Type
+	Count       int
+	UnreadCount int
+	IDs         []uuid.UUID
+	Latest      *Notification
+}
+
+// ListFiltered returns notifications matching a filter
+func (s *Service) ListFiltered(ctx context.Context, userID uuid.UUID, filter ListFilter) ([]*Notification, error) {
+	return s.repo.ListByFilter(ctx, userID, filter)
+}
+
+// ListGroups returns similar notifications grouped, newest group first
+func (s *Service) ListGroups(ctx context.Context, userID uuid.UUID, filter ListFilter) ([]*InboxGroup, error) {
+	rows, err := s.repo.ListGroups(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	latestIDs := make([]uuid.UUID, 0, len(rows))
+	groups := make([]*InboxGroup, 0, len(rows))
+	for _, row := range rows {
+		g := &InboxGroup{Key: row.Key, Type: row.Type, Count: row.Count, UnreadCount: row.UnreadCount}
+		for _, raw := range row.IDs {
+			if id, err := uuid.Parse(raw); err == nil {
+				g.IDs = append(g.IDs, id)
+			}
+		}
+		if len(g.IDs) > 0 {
+			latestIDs = append(latestIDs, g.IDs[0])
+		}
+		groups = append(groups, g)
+	}
+
+	latest, err := s.repo.GetByIDs(ctx, userID, latestIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*Notification, len(latest))
+	for _, n := range latest {
+		byID[n.ID] = n
+	}
+	for _, g := range groups {
+		if len(g.IDs) > 0 {
+			g.Latest = byID[g.IDs[0]]
+		}
+	}
+	return groups, nil
+}
+
+// BulkDelete deletes the user's notifications by ID
+func (s *Service) BulkDelete(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	affected, err := s.repo.DeleteByIDs(ctx, userID, ids)
+	if err != nil {
+		return 0, err
+	}
+	if affected > 0 {
+		s.publishState(ctx, userID, &StateChange{DeletedIDs: ids})
+	}
+	return affected, nil
+}
+
+// BulkArchive moves the user's notifications to (or out of) the archive.
+// Archived notifications are marked read.
+func (s *Service) BulkArchive(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, archive bool) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	affected, err := s.repo.SetArchived(ctx, userID, ids, archive)
+	if err != nil {
+		return 0, err
+	}
+	if affected > 0 {
+		state := &StateChange{ArchivedIDs: ids}
+		if !archive {
+			state = &StateChange{RestoredIDs: ids}
+		}
+		s.publishState(ctx, userID, state)
+	}
+	return affected, nil
+}
+
+// publishState pushes notification:state with the fresh unread counter
+func (s *Service) publishState(ctx context.Context, userID uuid.UUID, state *StateChange) {
+	if s.realtimePublisher == nil {
+		return
+	}
+	unreadCount, err := s.repo.CountUnreadByUser(ctx, userID)
+	if err != nil {
+		return
+	}
+	state.UnreadCount = unreadCount
+	_ = s.realtimePublisher.NotifyState(ctx, userID, state)
+}
@@ -25,31 +25,60 @@ func NewHandler(service *Service) *Handler {
 
 // List handles GET /notifications
 // @Summary Список уведомлений
+// @Description С параметром grouped=true возвращает сгруппированные уведомления (NotificationGroupResponse)
 // @Tags Notification
 // @Produce json
 // @Security BearerAuth
 // @Param limit query int false "Лимит"
 // @Param offset query int false "Смещение"
+// @Param type query string false "Типы через запятую (new_response,new_message,...)"
+// @Param unread_only query bool false "Только непрочитанные"
+// @Param archived query bool false "Архив вместо входящих"
+// @Param grouped query bool false "Группировать похожие уведомления"
 // @Success 200 {object} response.Response{data=[]NotificationResponse}
-// @Failure 500 {object} response.Response
+// @Failure 400,500 {object} response.Response
 // @Router /notifications [get]
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.GetUserID(r.Context())
+	q := r.URL.Query()
 
-	limit := 20
-	offset := 0
-	if l := r.URL.Query().Get("limit"); l != "" {
+	types, err := ParseTypes(q.Get("type"))
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	filter := ListFilter{
+		Types:      types,
+		UnreadOnly: q.Get("unread_only") == "true",
+		Archived:   q.Get("archived") == "true",
+		Limit:      20,
+	}
+	if l := q.Get("limit"); l != "" {
 		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
-			limit = v
+			filter.Limit = v
 		}
 	}
-	if o := r.URL.Query().Get("offset"); o != "" {
+	if o := q.Get("offset"); o != "" {
 		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
-			offset = v
+			filter.Offset = v
 		}
 	}
 
-	notifications, err := h.service.List(r.Context(), userID, limit, offset, false)
+	if q.Get("grouped") == "true" {
+		groups, err := h.service.ListGroups(r.Context(), userID, filter)
+		if err != nil {
+			errorhandler.HandleError(r.Context(), w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", err)
+			return
+		}
+		items := make([]*NotificationGroupResponse, len(groups))
+		for i, g := range groups {
+			items[i] = NotificationGroupResponseFromGroup(g)
+		}
+		response.OK(w, items)
+		return
+	}
+
+	notifications, err := h.service.ListFiltered(r.Context(), userID, filter)
 	if err != nil {
 		errorhandler.HandleError(r.Context(), w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", err)
 		return
@@ -128,6 +157,97 @@ func (h *Handler) MarkAllAsRead(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, map[string]string{"status": "ok"})
 }
 
+// ExecuteAction handles POST /notifications/{id}/actions
+// @Summary Выполнить действие из уведомления
+// @Description accept/reject для new_response, reply (с text) для new_message
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID уведомления"
+// @Param request body ActionRequest true "Действие"
+// @Success 200 {object} response.Response{data=NotificationResponse}
+// @Failure 400,401,404,409,422,500 {object} response.Response
+// @Router /notifications/{id}/actions [post]
+func (h *Handler) ExecuteAction(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid notification ID")
+		return
+	}
+
+	var req ActionRequest
+	if err := response.DecodeJSON(r.Body, &req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	n, err := h.service.ExecuteAction(r.Context(), userID, id, req.Action, req.Text)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotificationNotFound):
+			response.NotFound(w, "Notification not found")
+		case errors.Is(err, ErrActionNotAvailable), errors.Is(err, ErrReplyTextRequired):
+			response.BadRequest(w, err.Error())
+		case errors.Is(err, ErrActionAlreadyTaken):
+			response.Conflict(w, err.Error())
+		case errors.Is(err, ErrActionRejected):
+			response.Error(w, http.StatusUnprocessableEntity, "ACTION_REJECTED", err.Error())
+		default:
+			errorhandler.HandleError(r.Context(), w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", err)
+		}
+		return
+	}
+
+	response.OK(w, NotificationResponseFromEntity(n))
+}
+
+// Bulk handles POST /notifications/bulk
+// @Summary Массовое удаление/архивация уведомлений
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BulkRequest true "Операция и ID уведомлений"
+// @Success 200 {object} response.Response{data=BulkResponse}
+// @Failure 400,401,500 {object} response.Response
+// @Router /notifications/bulk [post]
+func (h *Handler) Bulk(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+
+	var req BulkRequest
+	if err := response.DecodeJSON(r.Body, &req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+	if len(req.IDs) == 0 || len(req.IDs) > maxBulkIDs {
+		response.BadRequest(w, "ids must contain 1 to "+strconv.Itoa(maxBulkIDs)+" notification IDs")
+		return
+	}
+
+	var affected int64
+	var err error
+	switch req.Operation {
+	case BulkDelete:
+		affected, err = h.service.BulkDelete(r.Context(), userID, req.IDs)
+	case BulkArchive:
+		affected, err = h.service.BulkArchive(r.Context(), userID, req.IDs, true)
+	case BulkUnarchive:
+		affected, err = h.service.BulkArchive(r.Context(), userID, req.IDs, false)
+	default:
+		response.BadRequest(w, "operation must be one of: delete, archive, unarchive")
+		return
+	}
+	if err != nil {
+		errorhandler.HandleError(r.Context(), w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", err)
+		return
+	}
+
+	count, _ := h.service.GetUnreadCount(r.Context(), userID)
+	response.OK(w, BulkResponse{Affected: affected, UnreadCount: count})
+}
+
 // Routes returns notification router
 func (h *Handler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
@@ -138,6 +258,8 @@ func (h *Handler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Rou
 	r.Get("/unread", h.GetUnreadCount) // Alias for frontend compatibility
 	r.Post("/{id}/read", h.MarkAsRead)
 	r.Post("/read-all", h.MarkAllAsRead)
+	r.Post("/bulk", h.Bulk)
+	r.Post("/{id}/actions", h.ExecuteAction)
 
 	return r
 }
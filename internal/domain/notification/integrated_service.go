@@ -24,6 +24,7 @@ type IntegratedService struct {
 	userRepo     user.Repository
 	modelRepo    ProfileRepository
 	employerRepo ProfileRepository
+	dispatcher   *Dispatcher
 }
 
 // NewIntegratedService creates an integrated notification service
@@ -45,6 +46,37 @@ func NewIntegratedService(
 	}
 }
 
+// SetDispatcher enables preference-aware delivery: per-type channels, quiet hours,
+// throttles and new_message coalescing. Without it, in-app and email are always sent.
+func (s *IntegratedService) SetDispatcher(dispatcher *Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// planDelivery resolves channels for a notification
+func (s *IntegratedService) planDelivery(ctx context.Context, userID uuid.UUID, notifType Type) DeliveryPlan {
+	if s.dispatcher == nil {
+		return DeliveryPlan{Channels: ChannelSettings{InApp: true, Email: true}}
+	}
+	prefs := s.dispatcher.Preferences(ctx, userID)
+	return s.dispatcher.Plan(prefs, userID, notifType)
+}
+
+// deliver routes email and push for an already planned notification
+func (s *IntegratedService) deliver(ctx context.Context, plan DeliveryPlan, userID uuid.UUID, notifType Type, n *Notification, emailMsg *EmailDelivery, pushMsg *PushDelivery) {
+	if s.dispatcher == nil {
+		if plan.Channels.Email && emailMsg != nil {
+			s.emailService.Queue(emailMsg.To, emailMsg.ToName, emailMsg.Template, emailMsg.Subject, emailMsg.Data)
+		}
+		return
+	}
+
+	var notificationID *uuid.UUID
+	if n != nil {
+		notificationID = &n.ID
+	}
+	s.dispatcher.Deliver(ctx, plan, userID, notifType, notificationID, emailMsg, pushMsg)
+}
+
 // SendWelcomeEmail sends welcome email to new user
 func (s *IntegratedService) SendWelcomeEmail(ctx context.Context, userID uuid.UUID) error {
 	user, err := s.userRepo.GetByID(ctx, userID)
@@ -103,35 +135,53 @@ func (s *IntegratedService) NotifyNewResponse(ctx context.Context, employerUserI
 		}
 	}
 
+	plan := s.planDelivery(ctx, employerUserID, TypeNewResponse)
+	body := fmt.Sprintf("%s откликнулся на \"%s\"", modelName, castingTitle)
+
 	// Create in-app notification
-	_, err = s.notifService.Create(
-		ctx,
-		employerUserID,
-		TypeNewResponse,
-		"Новый отклик на кастинг",
-		fmt.Sprintf("%s откликнулся на \"%s\"", modelName, castingTitle),
-		&NotificationData{
-			CastingID:  &castingID,
-			ResponseID: &responseID,
-		},
-	)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create in-app notification")
+	var n *Notification
+	if plan.Channels.InApp {
+		n, err = s.notifService.Create(
+			ctx,
+			employerUserID,
+			TypeNewResponse,
+			"Новый отклик на кастинг",
+			body,
+			&NotificationData{
+				CastingID:  &castingID,
+				ResponseID: &responseID,
+			},
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create in-app notification")
+		}
 	}
 
-	// Send email notification
+	// Email and push notifications
 	responseURL := fmt.Sprintf("https://mwork.kz/castings/%s/responses/%s", castingID.String(), responseID.String())
-	s.emailService.SendNewResponse(
-		employer.Email,
-		employerName,
-		castingTitle,
-		modelName,
-		responseURL,
+	s.deliver(ctx, plan, employerUserID, TypeNewResponse, n,
+		&EmailDelivery{
+			To:       employer.Email,
+			ToName:   employerName,
+			Template: "new_response",
+			Subject:  "📩 Новый отклик на кастинг",
+			Data: map[string]string{
+				"CastingTitle": castingTitle,
+				"ModelName":    modelName,
+				"ResponseURL":  responseURL,
+			},
+		},
+		&PushDelivery{
+			Title: "📩 Новый отклик на кастинг",
+			Body:  body,
+			Data: map[string]string{
+				"type":        string(TypeNewResponse),
+				"casting_id":  castingID.String(),
+				"response_id": responseID.String(),
+			},
+		},
 	)
 
-	// Send push notification if user has FCM token
-	// TODO: Get user's FCM tokens from database
-	// For now, we'll just log
 	log.Info().
 		Str("employer_id", employerUserID.String()).
 		Str("casting_id", castingID.String()).
@@ -177,57 +227,75 @@ func (s *IntegratedService) NotifyResponseStatusChange(ctx context.Context, mode
 
 	var notifType Type
 	var title, body string
+	var emailMsg *EmailDelivery
 
 	switch status {
 	case "accepted":
 		notifType = TypeResponseAccepted
 		title = "Ваша заявка принята!"
 		body = fmt.Sprintf("Вас приняли на кастинг \"%s\"", castingTitle)
-
-		// Send email
-		castingURL := fmt.Sprintf("https://mwork.kz/castings/%s", castingID.String())
-		s.emailService.SendResponseAccepted(
-			model.Email,
-			modelName,
-			modelName,
-			castingTitle,
-			"Работодатель", // TODO: Get actual employer name
-			castingURL,
-		)
+		emailMsg = &EmailDelivery{
+			To:       model.Email,
+			ToName:   modelName,
+			Template: "response_accepted",
+			Subject:  "🎉 Вас приняли на кастинг!",
+			Data: map[string]string{
+				"ModelName":    modelName,
+				"CastingTitle": castingTitle,
+				"EmployerName": "Работодатель", // TODO: Get actual employer name
+				"CastingURL":   fmt.Sprintf("https://mwork.kz/castings/%s", castingID.String()),
+			},
+		}
 
 	case "rejected":
 		notifType = TypeResponseRejected
 		title = "Заявка отклонена"
 		body = fmt.Sprintf("К сожалению, ваша заявка на \"%s\" отклонена", castingTitle)
-
-		// Send email
-		s.emailService.SendResponseRejected(
-			model.Email,
-			modelName,
-			castingTitle,
-			"https://mwork.kz/castings",
-		)
+		emailMsg = &EmailDelivery{
+			To:       model.Email,
+			ToName:   modelName,
+			Template: "response_rejected",
+			Subject:  "Заявка на кастинг",
+			Data: map[string]string{
+				"CastingTitle": castingTitle,
+				"CastingsURL":  "https://mwork.kz/castings",
+			},
+		}
 
 	default:
 		return nil // Unknown status, skip notification
 	}
 
+	plan := s.planDelivery(ctx, modelUserID, notifType)
+
 	// Create in-app notification
-	_, err = s.notifService.Create(
-		ctx,
-		modelUserID,
-		notifType,
-		title,
-		body,
-		&NotificationData{
-			CastingID:  &castingID,
-			ResponseID: &responseID,
-		},
-	)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create in-app notification")
+	var n *Notification
+	if plan.Channels.InApp {
+		n, err = s.notifService.Create(
+			ctx,
+			modelUserID,
+			notifType,
+			title,
+			body,
+			&NotificationData{
+				CastingID:  &castingID,
+				ResponseID: &responseID,
+			},
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create in-app notification")
+		}
 	}
 
+	s.deliver(ctx, plan, modelUserID, notifType, n, emailMsg, &PushDelivery{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"type":       string(notifType),
+			"casting_id": castingID.String(),
+		},
+	})
+
 	log.Info().
 		Str("model_id", modelUserID.String()).
 		Str("status", status).
@@ -259,31 +327,55 @@ func (s *IntegratedService) NotifyNewMessage(ctx context.Context, recipientUserI
 		}
 	}
 
-	// Create in-app notification
-	_, err = s.notifService.Create(
-		ctx,
-		recipientUserID,
-		TypeNewMessage,
-		fmt.Sprintf("Новое сообщение от %s", senderName),
-		messagePreview,
-		&NotificationData{
-			RoomID:    &roomID,
-			MessageID: &messageID,
+	chatURL := fmt.Sprintf("https://mwork.kz/chat/%s", roomID.String())
+	emailMsg := &EmailDelivery{
+		To:       recipient.Email,
+		ToName:   recipientName,
+		Template: "new_message",
+		Subject:  "💬 Новое сообщение от " + senderName,
+		Data: map[string]string{
+			"SenderName":     senderName,
+			"MessagePreview": messagePreview,
+			"ChatURL":        chatURL,
 		},
-	)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create in-app notification")
 	}
 
-	// Send email notification
-	chatURL := fmt.Sprintf("https://mwork.kz/chat/%s", roomID.String())
-	s.emailService.SendNewMessage(
-		recipient.Email,
-		recipientName,
-		senderName,
-		messagePreview,
-		chatURL,
-	)
+	if s.dispatcher == nil {
+		if _, _, err := s.notifService.CoalesceNewMessage(ctx, recipientUserID, senderName, messagePreview, roomID, messageID, 0); err != nil {
+			log.Error().Err(err).Msg("Failed to create in-app notification")
+		}
+		s.deliver(ctx, s.planDelivery(ctx, recipientUserID, TypeNewMessage), recipientUserID, TypeNewMessage, nil, emailMsg, nil)
+		return nil
+	}
+
+	// Repeated messages in the same room within the coalescing window update one
+	// in-app notification and do not trigger another push/email.
+	prefs := s.dispatcher.Preferences(ctx, recipientUserID)
+	var n *Notification
+	if prefs.GetChannelsForType(TypeNewMessage).InApp {
+		var coalesced bool
+		n, coalesced, err = s.notifService.CoalesceNewMessage(ctx, recipientUserID, senderName, messagePreview, roomID, messageID, s.dispatcher.CoalesceWindow())
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create in-app notification")
+		}
+		if coalesced {
+			log.Debug().
+				Str("recipient_id", recipientUserID.String()).
+				Str("room_id", roomID.String()).
+				Msg("New message notification coalesced")
+			return nil
+		}
+	}
+
+	plan := s.dispatcher.Plan(prefs, recipientUserID, TypeNewMessage)
+	s.deliver(ctx, plan, recipientUserID, TypeNewMessage, n, emailMsg, &PushDelivery{
+		Title: "💬 Сообщение от " + senderName,
+		Body:  messagePreview,
+		Data: map[string]string{
+			"type":    string(TypeNewMessage),
+			"room_id": roomID.String(),
+		},
+	})
 
 	log.Info().
 		Str("recipient_id", recipientUserID.String()).
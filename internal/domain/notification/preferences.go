@@ -5,9 +5,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+
+	"github.com/mwork/mwork-api/internal/domain/user"
 )
 
 // ChannelSettings represents notification settings per channel
@@ -27,17 +30,18 @@ type UserPreferences struct {
 	PushEnabled  bool `db:"push_enabled" json:"push_enabled"`
 	InAppEnabled bool `db:"in_app_enabled" json:"in_app_enabled"`
 
-	// Per-type settings (stored as JSONB)
-	NewResponseChannels      json.RawMessage `db:"new_response_channels" json:"new_response_channels"`
-	ResponseAcceptedChannels json.RawMessage `db:"response_accepted_channels" json:"response_accepted_channels"`
-	ResponseRejectedChannels json.RawMessage `db:"response_rejected_channels" json:"response_rejected_channels"`
-	NewMessageChannels       json.RawMessage `db:"new_message_channels" json:"new_message_channels"`
-	ProfileViewedChannels    json.RawMessage `db:"profile_viewed_channels" json:"profile_viewed_channels"`
-	CastingExpiringChannels  json.RawMessage `db:"casting_expiring_channels" json:"casting_expiring_channels"`
+	// Per-type overrides (JSONB map type -> channels); missing types use role defaults
+	Channels TypeChannels `db:"channels" json:"channels"`
+	Role     user.Role    `db:"role" json:"-"`
 
 	// Digest settings
 	DigestEnabled   bool   `db:"digest_enabled" json:"digest_enabled"`
 	DigestFrequency string `db:"digest_frequency" json:"digest_frequency"`
+
+	// Quiet hours ("HH:MM" in QuietHoursTimezone); push and email are deferred inside the window
+	QuietHoursStart    sql.NullString `db:"quiet_hours_start" json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd      sql.NullString `db:"quiet_hours_end" json:"quiet_hours_end,omitempty"`
+	QuietHoursTimezone string         `db:"quiet_hours_timezone" json:"quiet_hours_timezone"`
 }
 
 // DeviceToken represents a push notification device token
@@ -76,21 +80,21 @@ func (r *PreferencesRepository) GetByUserID(ctx context.Context, userID uuid.UUI
 	var prefs UserPreferences
 	const selectPreferencesQuery = `
 		SELECT
-			id,
-			user_id,
-			email_enabled,
-			push_enabled,
-			in_app_enabled,
-			new_response_channels,
-			response_accepted_channels,
-			response_rejected_channels,
-			new_message_channels,
-			profile_viewed_channels,
-			casting_expiring_channels,
-			digest_enabled,
-			digest_frequency
-		FROM user_notification_preferences
-		WHERE user_id = $1
+			p.id,
+			p.user_id,
+			p.email_enabled,
+			p.push_enabled,
+			p.in_app_enabled,
+			p.channels,
+			p.digest_enabled,
+			p.digest_frequency,
+			to_char(p.quiet_hours_start, 'HH24:MI') AS quiet_hours_start,
+			to_char(p.quiet_hours_end, 'HH24:MI') AS quiet_hours_end,
+			COALESCE(p.quiet_hours_timezone, '') AS quiet_hours_timezone,
+			u.role
+		FROM user_notification_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.user_id = $1
 	`
 	err := r.db.GetContext(ctx, &prefs, selectPreferencesQuery, userID)
 
@@ -99,40 +103,14 @@ func (r *PreferencesRepository) GetByUserID(ctx context.Context, userID uuid.UUI
 			return nil, err
 		}
 
-		// Create default preferences
-		prefs = UserPreferences{
-			ID:              uuid.New(),
-			UserID:          userID,
-			EmailEnabled:    true,
-			PushEnabled:     true,
-			InAppEnabled:    true,
-			DigestEnabled:   true,
-			DigestFrequency: "weekly",
-		}
-
-		// Set default channel settings
-		defaultOn := []byte(`{"in_app": true, "email": true, "push": true}`)
-		defaultOff := []byte(`{"in_app": true, "email": false, "push": false}`)
-
-		prefs.NewResponseChannels = defaultOn
-		prefs.ResponseAcceptedChannels = defaultOn
-		prefs.ResponseRejectedChannels = []byte(`{"in_app": true, "email": true, "push": false}`)
-		prefs.NewMessageChannels = []byte(`{"in_app": true, "email": false, "push": true}`)
-		prefs.ProfileViewedChannels = defaultOff
-		prefs.CastingExpiringChannels = []byte(`{"in_app": true, "email": true, "push": false}`)
-
+		// Create default preferences; per-type channels come from role defaults
 		_, err = r.db.ExecContext(ctx, `
 			INSERT INTO user_notification_preferences (
 				id, user_id, email_enabled, push_enabled, in_app_enabled,
-				new_response_channels, response_accepted_channels, response_rejected_channels,
-				new_message_channels, profile_viewed_channels, casting_expiring_channels,
-				digest_enabled, digest_frequency
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				channels, digest_enabled, digest_frequency
+			) VALUES ($1, $2, true, true, true, '{}'::jsonb, true, 'weekly')
 			ON CONFLICT (user_id) DO NOTHING
-		`, prefs.ID, prefs.UserID, prefs.EmailEnabled, prefs.PushEnabled, prefs.InAppEnabled,
-			prefs.NewResponseChannels, prefs.ResponseAcceptedChannels, prefs.ResponseRejectedChannels,
-			prefs.NewMessageChannels, prefs.ProfileViewedChannels, prefs.CastingExpiringChannels,
-			prefs.DigestEnabled, prefs.DigestFrequency)
+		`, uuid.New(), userID)
 
 		if err != nil {
 			return nil, err
@@ -153,48 +131,39 @@ func (r *PreferencesRepository) Update(ctx context.Context, prefs *UserPreferenc
 			email_enabled = $2,
 			push_enabled = $3,
 			in_app_enabled = $4,
-			new_response_channels = $5,
-			response_accepted_channels = $6,
-			response_rejected_channels = $7,
-			new_message_channels = $8,
-			profile_viewed_channels = $9,
-			casting_expiring_channels = $10,
-			digest_enabled = $11,
-			digest_frequency = $12,
+			channels = $5,
+			digest_enabled = $6,
+			digest_frequency = $7,
+			quiet_hours_start = $8::time,
+			quiet_hours_end = $9::time,
+			quiet_hours_timezone = NULLIF($10, ''),
 			updated_at = NOW()
 		WHERE user_id = $1
 	`, prefs.UserID, prefs.EmailEnabled, prefs.PushEnabled, prefs.InAppEnabled,
-		prefs.NewResponseChannels, prefs.ResponseAcceptedChannels, prefs.ResponseRejectedChannels,
-		prefs.NewMessageChannels, prefs.ProfileViewedChannels, prefs.CastingExpiringChannels,
-		prefs.DigestEnabled, prefs.DigestFrequency)
+		prefs.Channels, prefs.DigestEnabled, prefs.DigestFrequency,
+		prefs.QuietHoursStart, prefs.QuietHoursEnd, prefs.QuietHoursTimezone)
 	return err
 }
 
-// GetChannelsForType returns enabled channels for a notification type
-func (prefs *UserPreferences) GetChannelsForType(notifType Type) ChannelSettings {
-	var raw json.RawMessage
-
-	switch notifType {
-	case TypeNewResponse:
-		raw = prefs.NewResponseChannels
-	case TypeResponseAccepted:
-		raw = prefs.ResponseAcceptedChannels
-	case TypeResponseRejected:
-		raw = prefs.ResponseRejectedChannels
-	case TypeNewMessage:
-		raw = prefs.NewMessageChannels
-	case TypeProfileViewed:
-		raw = prefs.ProfileViewedChannels
-	case TypeCastingExpiring:
-		raw = prefs.CastingExpiringChannels
-	default:
-		return ChannelSettings{InApp: true, Email: false, Push: false}
+// TypeSettings returns the stored or role-default channels for a type, before global toggles
+func (prefs *UserPreferences) TypeSettings(notifType Type) ChannelSettings {
+	if settings, ok := prefs.Channels[notifType]; ok {
+		return settings
 	}
+	return DefaultChannels(prefs.Role, notifType)
+}
 
-	var settings ChannelSettings
-	if err := json.Unmarshal(raw, &settings); err != nil {
-		return ChannelSettings{InApp: true, Email: false, Push: false}
+// SetTypeSettings stores a per-type override
+func (prefs *UserPreferences) SetTypeSettings(notifType Type, settings ChannelSettings) {
+	if prefs.Channels == nil {
+		prefs.Channels = TypeChannels{}
 	}
+	prefs.Channels[notifType] = settings
+}
+
+// GetChannelsForType returns enabled channels for a notification type
+func (prefs *UserPreferences) GetChannelsForType(notifType Type) ChannelSettings {
+	settings := prefs.TypeSettings(notifType)
 
 	// Apply global toggles
 	if !prefs.InAppEnabled {
@@ -210,6 +179,58 @@ func (prefs *UserPreferences) GetChannelsForType(notifType Type) ChannelSettings
 	return settings
 }
 
+// QuietHoursEndAt reports whether now falls inside the user's quiet hours and,
+// if so, when they end. Windows may wrap midnight (e.g. 22:00–08:00).
+func (prefs *UserPreferences) QuietHoursEndAt(now time.Time, fallback *time.Location) (time.Time, bool) {
+	if !prefs.QuietHoursStart.Valid || !prefs.QuietHoursEnd.Valid {
+		return time.Time{}, false
+	}
+	start, errStart := parseClock(prefs.QuietHoursStart.String)
+	end, errEnd := parseClock(prefs.QuietHoursEnd.String)
+	if errStart != nil || errEnd != nil || start == end {
+		return time.Time{}, false
+	}
+
+	loc := fallback
+	if prefs.QuietHoursTimezone != "" {
+		if l, err := time.LoadLocation(prefs.QuietHoursTimezone); err == nil {
+			loc = l
+		}
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	current := local.Sub(midnight)
+
+	if start < end {
+		if current >= start && current < end {
+			return midnight.Add(end), true
+		}
+		return time.Time{}, false
+	}
+
+	// Window wraps midnight
+	if current >= start {
+		return midnight.AddDate(0, 0, 1).Add(end), true
+	}
+	if current < end {
+		return midnight.Add(end), true
+	}
+	return time.Time{}, false
+}
+
+// parseClock parses "HH:MM" into an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
 // DeviceTokenRepository handles device tokens
 type DeviceTokenRepository struct {
 	db *sqlx.DB
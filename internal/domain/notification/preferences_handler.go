@@ -2,8 +2,10 @@ package notification
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -74,6 +76,10 @@ type UpdatePreferencesRequest struct {
 	PushEnabled  *bool `json:"push_enabled"`
 	InAppEnabled *bool `json:"in_app_enabled"`
 
+	// Per-type channels keyed by notification type (see KnownTypes)
+	Channels map[Type]ChannelSettings `json:"channels"`
+
+	// Deprecated: legacy per-type fields, use Channels
 	NewResponseChannels      *ChannelSettings `json:"new_response_channels"`
 	ResponseAcceptedChannels *ChannelSettings `json:"response_accepted_channels"`
 	ResponseRejectedChannels *ChannelSettings `json:"response_rejected_channels"`
@@ -83,6 +89,11 @@ type UpdatePreferencesRequest struct {
 
 	DigestEnabled   *bool   `json:"digest_enabled"`
 	DigestFrequency *string `json:"digest_frequency"`
+
+	// Quiet hours as "HH:MM"; empty string disables the window
+	QuietHoursStart    *string `json:"quiet_hours_start"`
+	QuietHoursEnd      *string `json:"quiet_hours_end"`
+	QuietHoursTimezone *string `json:"quiet_hours_timezone"`
 }
 
 // UpdatePreferences handles PUT /api/v1/notifications/preferences
@@ -132,24 +143,53 @@ func (h *PreferencesHandler) UpdatePreferences(w http.ResponseWriter, r *http.Re
 		prefs.DigestFrequency = *req.DigestFrequency
 	}
 
-	// Update channel settings
-	if req.NewResponseChannels != nil {
-		prefs.NewResponseChannels, _ = json.Marshal(req.NewResponseChannels)
+	if req.QuietHoursStart != nil {
+		value, ok := parseQuietHoursClock(*req.QuietHoursStart)
+		if !ok {
+			response.BadRequest(w, "quiet_hours_start must be HH:MM")
+			return
+		}
+		prefs.QuietHoursStart = value
 	}
-	if req.ResponseAcceptedChannels != nil {
-		prefs.ResponseAcceptedChannels, _ = json.Marshal(req.ResponseAcceptedChannels)
+	if req.QuietHoursEnd != nil {
+		value, ok := parseQuietHoursClock(*req.QuietHoursEnd)
+		if !ok {
+			response.BadRequest(w, "quiet_hours_end must be HH:MM")
+			return
+		}
+		prefs.QuietHoursEnd = value
 	}
-	if req.ResponseRejectedChannels != nil {
-		prefs.ResponseRejectedChannels, _ = json.Marshal(req.ResponseRejectedChannels)
+	if req.QuietHoursTimezone != nil {
+		if *req.QuietHoursTimezone != "" {
+			if _, err := time.LoadLocation(*req.QuietHoursTimezone); err != nil {
+				response.BadRequest(w, "Invalid quiet_hours_timezone")
+				return
+			}
+		}
+		prefs.QuietHoursTimezone = *req.QuietHoursTimezone
 	}
-	if req.NewMessageChannels != nil {
-		prefs.NewMessageChannels, _ = json.Marshal(req.NewMessageChannels)
+
+	// Update channel settings
+	for notifType, settings := range req.Channels {
+		if !IsKnownType(notifType) {
+			response.BadRequest(w, "Unknown notification type: "+string(notifType))
+			return
+		}
+		prefs.SetTypeSettings(notifType, settings)
 	}
-	if req.ProfileViewedChannels != nil {
-		prefs.ProfileViewedChannels, _ = json.Marshal(req.ProfileViewedChannels)
+
+	legacy := map[Type]*ChannelSettings{
+		TypeNewResponse:      req.NewResponseChannels,
+		TypeResponseAccepted: req.ResponseAcceptedChannels,
+		TypeResponseRejected: req.ResponseRejectedChannels,
+		TypeNewMessage:       req.NewMessageChannels,
+		TypeProfileViewed:    req.ProfileViewedChannels,
+		TypeCastingExpiring:  req.CastingExpiringChannels,
 	}
-	if req.CastingExpiringChannels != nil {
-		prefs.CastingExpiringChannels, _ = json.Marshal(req.CastingExpiringChannels)
+	for notifType, settings := range legacy {
+		if settings != nil {
+			prefs.SetTypeSettings(notifType, *settings)
+		}
 	}
 
 	if err := h.prefsRepo.Update(r.Context(), prefs); err != nil {
@@ -253,6 +293,9 @@ type PreferencesResponse struct {
 	PushEnabled  bool `json:"push_enabled"`
 	InAppEnabled bool `json:"in_app_enabled"`
 
+	// Effective per-type channels for every known type (stored overrides merged with role defaults)
+	Channels map[Type]ChannelSettings `json:"channels"`
+
 	NewResponseChannels      ChannelSettings `json:"new_response_channels"`
 	ResponseAcceptedChannels ChannelSettings `json:"response_accepted_channels"`
 	ResponseRejectedChannels ChannelSettings `json:"response_rejected_channels"`
@@ -262,6 +305,10 @@ type PreferencesResponse struct {
 
 	DigestEnabled   bool   `json:"digest_enabled"`
 	DigestFrequency string `json:"digest_frequency"`
+
+	QuietHoursStart    *string `json:"quiet_hours_start"`
+	QuietHoursEnd      *string `json:"quiet_hours_end"`
+	QuietHoursTimezone string  `json:"quiet_hours_timezone,omitempty"`
 }
 
 func prefsToResponse(p *UserPreferences) *PreferencesResponse {
@@ -271,18 +318,42 @@ func prefsToResponse(p *UserPreferences) *PreferencesResponse {
 		InAppEnabled:    p.InAppEnabled,
 		DigestEnabled:   p.DigestEnabled,
 		DigestFrequency: p.DigestFrequency,
+
+		QuietHoursTimezone: p.QuietHoursTimezone,
+	}
+	if p.QuietHoursStart.Valid {
+		resp.QuietHoursStart = &p.QuietHoursStart.String
+	}
+	if p.QuietHoursEnd.Valid {
+		resp.QuietHoursEnd = &p.QuietHoursEnd.String
+	}
+
+	resp.Channels = make(map[Type]ChannelSettings, len(knownTypes))
+	for _, notifType := range knownTypes {
+		resp.Channels[notifType] = p.TypeSettings(notifType)
 	}
 
-	json.Unmarshal(p.NewResponseChannels, &resp.NewResponseChannels)
-	json.Unmarshal(p.ResponseAcceptedChannels, &resp.ResponseAcceptedChannels)
-	json.Unmarshal(p.ResponseRejectedChannels, &resp.ResponseRejectedChannels)
-	json.Unmarshal(p.NewMessageChannels, &resp.NewMessageChannels)
-	json.Unmarshal(p.ProfileViewedChannels, &resp.ProfileViewedChannels)
-	json.Unmarshal(p.CastingExpiringChannels, &resp.CastingExpiringChannels)
+	resp.NewResponseChannels = p.TypeSettings(TypeNewResponse)
+	resp.ResponseAcceptedChannels = p.TypeSettings(TypeResponseAccepted)
+	resp.ResponseRejectedChannels = p.TypeSettings(TypeResponseRejected)
+	resp.NewMessageChannels = p.TypeSettings(TypeNewMessage)
+	resp.ProfileViewedChannels = p.TypeSettings(TypeProfileViewed)
+	resp.CastingExpiringChannels = p.TypeSettings(TypeCastingExpiring)
 
 	return resp
 }
 
+// parseQuietHoursClock validates "HH:MM"; empty input clears the value
+func parseQuietHoursClock(value string) (sql.NullString, bool) {
+	if value == "" {
+		return sql.NullString{}, true
+	}
+	if _, err := parseClock(value); err != nil {
+		return sql.NullString{}, false
+	}
+	return sql.NullString{String: value, Valid: true}, true
+}
+
 // Helper to get user ID from context
 func getUserIDFromContext(ctx context.Context) uuid.UUID {
 	return middleware.GetUserID(ctx)
@@ -9,4 +9,15 @@ import (
 // RealtimePublisher publishes in-app notification realtime events.
 type RealtimePublisher interface {
 	NotifyNew(ctx context.Context, userID uuid.UUID, notification *NotificationResponse, unreadCount int) error
+	NotifyState(ctx context.Context, userID uuid.UUID, state *StateChange) error
+}
+
+// StateChange describes notifications changed outside notification:new
+// (actions, bulk delete/archive) so other sessions can update their lists.
+type StateChange struct {
+	UnreadCount int                   `json:"unread_count"`
+	Updated     *NotificationResponse `json:"updated,omitempty"`
+	DeletedIDs  []uuid.UUID           `json:"deleted_ids,omitempty"`
+	ArchivedIDs []uuid.UUID           `json:"archived_ids,omitempty"`
+	RestoredIDs []uuid.UUID           `json:"restored_ids,omitempty"`
 }
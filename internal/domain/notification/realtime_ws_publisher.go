@@ -35,3 +35,17 @@ func (p *WSPublisher) NotifyNew(ctx context.Context, userID uuid.UUID, notificat
 
 	return p.sender.SendToUserJSON(userID, payload)
 }
+
+// NotifyState publishes notification:state for actions and bulk changes.
+func (p *WSPublisher) NotifyState(ctx context.Context, userID uuid.UUID, state *StateChange) error {
+	if p == nil || p.sender == nil {
+		return nil
+	}
+
+	payload := map[string]interface{}{
+		"type": "notification:state",
+		"data": state,
+	}
+
+	return p.sender.SendToUserJSON(userID, payload)
+}
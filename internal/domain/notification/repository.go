@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // Repository defines notification data access
@@ -21,6 +23,14 @@ type Repository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteOldByUser(ctx context.Context, userID uuid.UUID, days int) (int, error)
 	DeleteOlderThan(ctx context.Context, age time.Duration) (int64, error)
+	FindLatestUnreadByRoom(ctx context.Context, userID uuid.UUID, notifType Type, roomID uuid.UUID, since time.Time) (*Notification, error)
+	UpdateContent(ctx context.Context, n *Notification) error
+	ListByFilter(ctx context.Context, userID uuid.UUID, filter ListFilter) ([]*Notification, error)
+	ListGroups(ctx context.Context, userID uuid.UUID, filter ListFilter) ([]*GroupRow, error)
+	GetByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*Notification, error)
+	DeleteByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error)
+	SetArchived(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, archived bool) (int64, error)
+	RecordAction(ctx context.Context, userID, id uuid.UUID, action Action) (*Notification, error)
 }
 
 type repository struct {
@@ -66,7 +76,7 @@ func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Notification,
 func buildListByUserQuery(unreadOnly bool) string {
 	query := `
 		SELECT * FROM notifications 
-		WHERE user_id = $1
+		WHERE user_id = $1 AND archived_at IS NULL
 	`
 	if unreadOnly {
 		query += ` AND is_read = false`
@@ -142,3 +152,121 @@ func (r *repository) DeleteOlderThan(ctx context.Context, age time.Duration) (in
 	}
 	return result.RowsAffected()
 }
+
+// FindLatestUnreadByRoom returns the newest unread notification of a type for a chat room created after since
+func (r *repository) FindLatestUnreadByRoom(ctx context.Context, userID uuid.UUID, notifType Type, roomID uuid.UUID, since time.Time) (*Notification, error) {
+	query := `
+		SELECT * FROM notifications
+		WHERE user_id = $1 AND type = $2 AND data->>'room_id' = $3
+			AND is_read = false AND created_at >= $4
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	var n Notification
+	err := r.db.GetContext(ctx, &n, query, userID, notifType, roomID.String(), since)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &n, nil
+}
+
+// UpdateContent rewrites title, body, data and timestamp of an existing notification
+func (r *repository) UpdateContent(ctx context.Context, n *Notification) error {
+	query := `UPDATE notifications SET title = $2, body = $3, data = $4, created_at = $5 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, n.ID, n.Title, n.Body, n.Data, n.CreatedAt)
+	return err
+}
+
+// ListByFilter returns notifications matching filter, newest first
+func (r *repository) ListByFilter(ctx context.Context, userID uuid.UUID, filter ListFilter) ([]*Notification, error) {
+	where, args := filterWhere(userID, filter)
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT * FROM notifications WHERE ` + where +
+		` ORDER BY created_at DESC LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	var notifications []*Notification
+	err := r.db.SelectContext(ctx, &notifications, query, args...)
+	return notifications, err
+}
+
+// ListGroups aggregates notifications matching filter by group key, newest group first
+func (r *repository) ListGroups(ctx context.Context, userID uuid.UUID, filter ListFilter) ([]*GroupRow, error) {
+	where, args := filterWhere(userID, filter)
+	args = append(args, filter.Limit, filter.Offset)
+	query := `
+		SELECT type, group_key,
+			COUNT(*) AS count,
+			COUNT(*) FILTER (WHERE NOT is_read) AS unread_count,
+			MAX(created_at) AS latest_at,
+			(ARRAY_AGG(id::text ORDER BY created_at DESC))[1:` + strconv.Itoa(maxGroupIDs) + `] AS ids
+		FROM (
+			SELECT id, type, is_read, created_at, ` + groupKeySQL() + ` AS group_key
+			FROM notifications
+			WHERE ` + where + `
+		) g
+		GROUP BY type, group_key
+		ORDER BY latest_at DESC
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	var rows []*GroupRow
+	err := r.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// GetByIDs returns the user's notifications with the given IDs
+func (r *repository) GetByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]*Notification, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var notifications []*Notification
+	err := r.db.SelectContext(ctx, &notifications,
+		`SELECT * FROM notifications WHERE user_id = $1 AND id = ANY($2)`, userID, pq.Array(ids))
+	return notifications, err
+}
+
+// DeleteByIDs deletes the user's notifications with the given IDs
+func (r *repository) DeleteByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notifications WHERE user_id = $1 AND id = ANY($2)`, userID, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SetArchived archives (marking read) or restores the user's notifications
+func (r *repository) SetArchived(ctx context.Context, userID uuid.UUID, ids []uuid.UUID, archived bool) (int64, error) {
+	query := `
+		UPDATE notifications SET archived_at = NOW(), is_read = true, read_at = COALESCE(read_at, NOW())
+		WHERE user_id = $1 AND id = ANY($2) AND archived_at IS NULL
+	`
+	if !archived {
+		query = `UPDATE notifications SET archived_at = NULL WHERE user_id = $1 AND id = ANY($2) AND archived_at IS NOT NULL`
+	}
+	result, err := r.db.ExecContext(ctx, query, userID, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// RecordAction stores the action taken on a notification and marks it read
+func (r *repository) RecordAction(ctx context.Context, userID, id uuid.UUID, action Action) (*Notification, error) {
+	query := `
+		UPDATE notifications SET
+			action_taken = $3, action_at = NOW(),
+			is_read = true, read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING *
+	`
+	var n Notification
+	if err := r.db.GetContext(ctx, &n, query, id, userID, action); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotificationNotFound
+		}
+		return nil, err
+	}
+	return &n, nil
+}
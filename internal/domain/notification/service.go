@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +18,8 @@ var ErrNotificationNotFound = errors.New("notification not found")
 type Service struct {
 	repo              Repository
 	realtimePublisher RealtimePublisher
+	responseDecider   ResponseDecider
+	messageReplier    MessageReplier
 }
 
 // NewService creates notification service
@@ -48,16 +52,73 @@ func (s *Service) Create(ctx context.Context, userID uuid.UUID, notifType Type,
 		return nil, err
 	}
 
-	if s.realtimePublisher != nil {
-		if unreadCount, err := s.repo.CountUnreadByUser(ctx, userID); err == nil {
-			_ = s.realtimePublisher.NotifyNew(ctx, userID, NotificationResponseFromEntity(n), unreadCount)
-			log.Debug().Str("user_id", userID.String()).Str("notification_type", string(n.Type)).Str("source", "notification_service_create").Msg("Published notification:new WS event")
-		}
-	}
+	s.publishNew(ctx, n, "notification_service_create")
 
 	return n, nil
 }
 
+// CoalesceNewMessage folds a chat message into the latest unread new_message
+// notification for the same room created within window ("5 новых сообщений от X").
+// Returns coalesced=true when an existing notification was updated instead of a new one created.
+func (s *Service) CoalesceNewMessage(ctx context.Context, userID uuid.UUID, senderName, preview string, roomID, messageID uuid.UUID, window time.Duration) (*Notification, bool, error) {
+	data := &NotificationData{RoomID: &roomID, MessageID: &messageID, Count: 1}
+	if window <= 0 {
+		n, err := s.Create(ctx, userID, TypeNewMessage, "Новое сообщение от "+senderName, preview, data)
+		return n, false, err
+	}
+
+	existing, err := s.repo.FindLatestUnreadByRoom(ctx, userID, TypeNewMessage, roomID, time.Now().Add(-window))
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		n, err := s.Create(ctx, userID, TypeNewMessage, "Новое сообщение от "+senderName, preview, data)
+		return n, false, err
+	}
+
+	count := existing.GetData().Count
+	if count < 1 {
+		count = 1
+	}
+	data.Count = count + 1
+
+	existing.Title = coalescedMessageTitle(data.Count, senderName)
+	existing.Body = sql.NullString{String: preview, Valid: preview != ""}
+	existing.CreatedAt = time.Now()
+	existing.SetData(data)
+
+	if err := s.repo.UpdateContent(ctx, existing); err != nil {
+		return nil, false, err
+	}
+
+	s.publishNew(ctx, existing, "notification_service_coalesce")
+
+	return existing, true, nil
+}
+
+// publishNew pushes notification:new with the fresh unread counter
+func (s *Service) publishNew(ctx context.Context, n *Notification, source string) {
+	if s.realtimePublisher == nil {
+		return
+	}
+	if unreadCount, err := s.repo.CountUnreadByUser(ctx, n.UserID); err == nil {
+		_ = s.realtimePublisher.NotifyNew(ctx, n.UserID, NotificationResponseFromEntity(n), unreadCount)
+		log.Debug().Str("user_id", n.UserID.String()).Str("notification_type", string(n.Type)).Str("source", source).Msg("Published notification:new WS event")
+	}
+}
+
+// coalescedMessageTitle renders "N новых сообщений от X" with Russian plural forms
+func coalescedMessageTitle(count int, senderName string) string {
+	noun := "новых сообщений"
+	switch mod10, mod100 := count%10, count%100; {
+	case mod10 == 1 && mod100 != 11:
+		noun = "новое сообщение"
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		noun = "новых сообщения"
+	}
+	return strconv.Itoa(count) + " " + noun + " от " + senderName
+}
+
 // List returns notifications for user
 func (s *Service) List(ctx context.Context, userID uuid.UUID, limit, offset int, unreadOnly bool) ([]*Notification, error) {
 	return s.repo.ListByUser(ctx, userID, limit, offset, unreadOnly)
@@ -121,6 +182,25 @@ func (s *Service) NotifyResponseRejected(ctx context.Context, modelID uuid.UUID,
 	)
 }
 
+// NotifyProfileViewed tells a model that someone viewed their profile.
+// Who viewed is shown on the viewers page for plans that include it.
+func (s *Service) NotifyProfileViewed(ctx context.Context, modelUserID, profileID uuid.UUID, viewerRole string) {
+	body := "Ваш профиль кто-то просмотрел"
+	switch viewerRole {
+	case "employer":
+		body = "Ваш профиль просмотрел работодатель"
+	case "agency":
+		body = "Ваш профиль просмотрело агентство"
+	case "model":
+		body = "Ваш профиль просмотрела другая модель"
+	}
+	s.Create(ctx, modelUserID, TypeProfileViewed,
+		"Новый просмотр профиля",
+		body,
+		&NotificationData{ProfileID: &profileID},
+	)
+}
+
 // NotifyNewMessage notifies user about new message
 func (s *Service) NotifyNewMessage(ctx context.Context, userID uuid.UUID, senderName, preview string, roomID, messageID uuid.UUID) {
 	s.Create(ctx, userID, TypeNewMessage,
@@ -129,3 +209,58 @@ func (s *Service) NotifyNewMessage(ctx context.Context, userID uuid.UUID, sender
 		&NotificationData{RoomID: &roomID, MessageID: &messageID},
 	)
 }
+
+// NotifySubscriptionExpiring reminds user that the paid plan ends soon
+func (s *Service) NotifySubscriptionExpiring(ctx context.Context, userID uuid.UUID, planName string, expiresAt time.Time, daysLeft int) {
+	s.Create(ctx, userID, TypeSubscriptionExpiring,
+		"Подписка скоро закончится",
+		fmt.Sprintf("Тариф \"%s\" действует до %s (осталось дней: %d). Продлите подписку, чтобы сохранить доступ.", planName, expiresAt.Format("02.01.2006"), daysLeft),
+		nil,
+	)
+}
+
+// NotifySubscriptionRenewed confirms an automatic renewal charge
+func (s *Service) NotifySubscriptionRenewed(ctx context.Context, userID uuid.UUID, planName string, expiresAt time.Time) {
+	s.Create(ctx, userID, TypePaymentSucceeded,
+		"Подписка продлена",
+		fmt.Sprintf("Тариф \"%s\" автоматически продлен до %s", planName, expiresAt.Format("02.01.2006")),
+		nil,
+	)
+}
+
+// NotifyRenewalFailed tells user that the auto-renewal charge failed. A zero retryAt
+// means no more attempts: auto-renewal is off and the plan will lapse.
+func (s *Service) NotifyRenewalFailed(ctx context.Context, userID uuid.UUID, planName string, retryAt time.Time) {
+	body := fmt.Sprintf("Не удалось списать оплату за тариф \"%s\". Повторная попытка %s. Проверьте карту или оплатите подписку вручную.", planName, retryAt.Format("02.01.2006"))
+	if retryAt.IsZero() {
+		body = fmt.Sprintf("Не удалось списать оплату за тариф \"%s\", автопродление отключено. Оплатите подписку вручную, чтобы сохранить доступ.", planName)
+	}
+	s.Create(ctx, userID, TypePaymentFailed, "Не удалось продлить подписку", body, nil)
+}
+
+// NotifyCreditsExpiring warns user that granted credits expire soon
+func (s *Service) NotifyCreditsExpiring(ctx context.Context, userID uuid.UUID, amount int, expiresAt time.Time) {
+	s.Create(ctx, userID, TypeCreditsLow,
+		"Кредиты скоро сгорят",
+		fmt.Sprintf("%d кредитов сгорят %s. Используйте их до этой даты.", amount, expiresAt.Format("02.01.2006")),
+		nil,
+	)
+}
+
+// NotifyCreditsLow tells user that the credit balance is running out
+func (s *Service) NotifyCreditsLow(ctx context.Context, userID uuid.UUID, balance int) {
+	s.Create(ctx, userID, TypeCreditsLow,
+		"Кредиты заканчиваются",
+		fmt.Sprintf("На балансе осталось кредитов: %d. Пополните баланс, чтобы продолжать откликаться на кастинги.", balance),
+		nil,
+	)
+}
+
+// NotifySubscriptionDowngraded notifies user that the paid plan expired and the free plan is active
+func (s *Service) NotifySubscriptionDowngraded(ctx context.Context, userID uuid.UUID, planName string) {
+	s.Create(ctx, userID, TypeSubscriptionExpiring,
+		"Подписка закончилась",
+		"Тариф \""+planName+"\" истек, аккаунт переведен на бесплатный план",
+		nil,
+	)
+}
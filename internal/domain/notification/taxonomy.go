@@ -0,0 +1,143 @@
+package notification
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mwork/mwork-api/internal/domain/user"
+)
+
+// knownTypes lists every notification type users can configure, in display order
+var knownTypes = []Type{
+	TypeNewResponse,
+	TypeResponseAccepted,
+	TypeResponseRejected,
+	TypeShortlisted,
+	TypeResponseViewed,
+	TypeNewMessage,
+	TypeProfileViewed,
+	TypeCastingExpiring,
+	TypeCastingMatchedSearch,
+	TypeReviewReceived,
+	TypeSubscriptionExpiring,
+	TypePaymentSucceeded,
+	TypePaymentFailed,
+	TypeCreditsLow,
+	TypeOrganizationInvite,
+	TypeVerificationDecision,
+	TypePromotionFinished,
+}
+
+// KnownTypes returns all configurable notification types
+func KnownTypes() []Type {
+	out := make([]Type, len(knownTypes))
+	copy(out, knownTypes)
+	return out
+}
+
+// IsKnownType checks whether t is a registered notification type
+func IsKnownType(t Type) bool {
+	for _, known := range knownTypes {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	channelsAll       = ChannelSettings{InApp: true, Email: true, Push: true}
+	channelsInApp     = ChannelSettings{InApp: true}
+	channelsInAppMail = ChannelSettings{InApp: true, Email: true}
+	channelsInAppPush = ChannelSettings{InApp: true, Push: true}
+)
+
+// baseDefaults apply to every role unless overridden in roleDefaults
+var baseDefaults = map[Type]ChannelSettings{
+	TypeNewResponse:          channelsAll,
+	TypeResponseAccepted:     channelsAll,
+	TypeResponseRejected:     channelsInAppMail,
+	TypeShortlisted:          channelsAll,
+	TypeResponseViewed:       channelsInAppPush,
+	TypeNewMessage:           channelsInAppPush,
+	TypeProfileViewed:        channelsInApp,
+	TypeCastingExpiring:      channelsInAppMail,
+	TypeCastingMatchedSearch: channelsInAppPush,
+	TypeReviewReceived:       channelsInAppMail,
+	TypeSubscriptionExpiring: channelsAll,
+	TypePaymentSucceeded:     channelsInAppMail,
+	TypePaymentFailed:        channelsAll,
+	TypeCreditsLow:           channelsInAppMail,
+	TypeOrganizationInvite:   channelsAll,
+	TypeVerificationDecision: channelsAll,
+	TypePromotionFinished:    channelsInAppMail,
+}
+
+// roleDefaults override baseDefaults per role
+var roleDefaults = map[user.Role]map[Type]ChannelSettings{
+	user.RoleModel: {
+		TypeCastingMatchedSearch: channelsAll,
+		TypeProfileViewed:        channelsInAppPush,
+	},
+	user.RoleEmployer: {
+		TypeCastingExpiring: channelsAll,
+	},
+	// Agencies receive many responses; email per response is too noisy
+	user.RoleAgency: {
+		TypeNewResponse:    channelsInAppPush,
+		TypeReviewReceived: channelsInApp,
+	},
+}
+
+// DefaultChannels returns the default channel settings of a type for a role.
+// Unknown types fall back to in-app only.
+func DefaultChannels(role user.Role, t Type) ChannelSettings {
+	if overrides, ok := roleDefaults[role]; ok {
+		if settings, ok := overrides[t]; ok {
+			return settings
+		}
+	}
+	if settings, ok := baseDefaults[t]; ok {
+		return settings
+	}
+	return channelsInApp
+}
+
+// TypeChannels maps notification types to user-chosen channel settings.
+// Only overrides are stored; missing types use role defaults.
+type TypeChannels map[Type]ChannelSettings
+
+// Value implements driver.Valuer so sqlx can serialize TypeChannels → JSONB.
+func (c TypeChannels) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("marshal notification channels: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner so sqlx can deserialize JSONB → TypeChannels.
+func (c *TypeChannels) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	case nil:
+		*c = TypeChannels{}
+		return nil
+	default:
+		return fmt.Errorf("unexpected type for notification channels: %T", src)
+	}
+	out := TypeChannels{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return err
+	}
+	*c = out
+	return nil
+}
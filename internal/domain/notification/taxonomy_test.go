@@ -0,0 +1,36 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/mwork/mwork-api/internal/domain/user"
+)
+
+func TestGetChannelsForTypeUsesRoleDefaults(t *testing.T) {
+	agency := &UserPreferences{Role: user.RoleAgency, EmailEnabled: true, PushEnabled: true, InAppEnabled: true}
+	if got := agency.GetChannelsForType(TypeNewResponse); got.Email {
+		t.Fatalf("agency new_response should not email by default, got %+v", got)
+	}
+
+	employer := &UserPreferences{Role: user.RoleEmployer, EmailEnabled: true, PushEnabled: true, InAppEnabled: true}
+	if got := employer.GetChannelsForType(TypeNewResponse); !got.Email || !got.Push {
+		t.Fatalf("employer new_response should use all channels, got %+v", got)
+	}
+
+	employer.SetTypeSettings(TypeNewResponse, ChannelSettings{InApp: true})
+	if got := employer.GetChannelsForType(TypeNewResponse); got.Email || got.Push {
+		t.Fatalf("stored override should win over defaults, got %+v", got)
+	}
+}
+
+func TestGetChannelsForTypeAppliesGlobalToggles(t *testing.T) {
+	prefs := &UserPreferences{Role: user.RoleModel, EmailEnabled: false, PushEnabled: true, InAppEnabled: true}
+	got := prefs.GetChannelsForType(TypePaymentFailed)
+	if got.Email || !got.Push || !got.InApp {
+		t.Fatalf("expected email disabled by global toggle, got %+v", got)
+	}
+
+	if got := prefs.GetChannelsForType(Type("unknown_type")); got.Push || got.Email || !got.InApp {
+		t.Fatalf("unknown types should be in-app only, got %+v", got)
+	}
+}
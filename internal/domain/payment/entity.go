@@ -64,12 +64,14 @@ type Payment struct {
 	Plan               sql.NullString `db:"plan" json:"plan,omitempty"`
 	InvID              sql.NullString `db:"inv_id" json:"inv_id,omitempty"`
 	ResponsePackage    sql.NullInt64  `db:"response_package" json:"response_package,omitempty"`
+	SKU                sql.NullString `db:"sku" json:"sku,omitempty"`
 	Amount             float64        `db:"amount" json:"amount"`
 	RobokassaInvID     sql.NullInt64  `db:"robokassa_inv_id" json:"robokassa_inv_id,omitempty"`
 	Currency           string         `db:"currency" json:"currency"`
 	Status             Status         `db:"status" json:"status"`
 	Provider           sql.NullString `db:"provider" json:"provider,omitempty"`
 	ExternalID         sql.NullString `db:"external_id" json:"external_id,omitempty"`
+	OpKey              sql.NullString `db:"op_key" json:"-"` // Robokassa operation key, needed for refunds
 	Description        sql.NullString `db:"description" json:"description,omitempty"`
 	Metadata           JSONRawMessage `db:"metadata" json:"metadata,omitempty"`
 	RawInitPayload     JSONRawMessage `db:"raw_init_payload" json:"raw_init_payload,omitempty"`
@@ -77,9 +79,19 @@ type Payment struct {
 	PaidAt             sql.NullTime   `db:"paid_at" json:"paid_at,omitempty"`
 	FailedAt           sql.NullTime   `db:"failed_at" json:"failed_at,omitempty"`
 	RefundedAt         sql.NullTime   `db:"refunded_at" json:"refunded_at,omitempty"`
+	RefundedAmount     float64        `db:"refunded_amount" json:"refunded_amount"`
 	CreatedAt          time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt          time.Time      `db:"updated_at" json:"updated_at"`
 	PromotionID        uuid.NullUUID  `db:"promotion_id" json:"promotion_id,omitempty"`
+
+	// Promo code discount captured at checkout; Amount is what was charged
+	PromoCodeID    uuid.NullUUID   `db:"promo_code_id" json:"promo_code_id,omitempty"`
+	OriginalAmount sql.NullFloat64 `db:"original_amount" json:"original_amount,omitempty"`
+	DiscountAmount float64         `db:"discount_amount" json:"discount_amount"`
+
+	// Recurring payments save the card; renewal charges reference their parent InvID
+	Recurring   bool          `db:"recurring" json:"recurring"`
+	ParentInvID sql.NullInt64 `db:"parent_inv_id" json:"parent_inv_id,omitempty"`
 }
 
 // IsPaid checks if payment is completed
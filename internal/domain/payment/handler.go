@@ -2,6 +2,7 @@ package payment
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
@@ -30,11 +31,29 @@ type InitRobokassaRequest struct {
 }
 
 type CreateSubscriptionPaymentRequest struct {
-	Plan string `json:"plan"`
+	Plan          string `json:"plan"`
+	BillingPeriod string `json:"billing_period,omitempty"` // monthly (default) or yearly
+	PromoCode     string `json:"promo_code,omitempty"`
+	AutoRenew     bool   `json:"auto_renew,omitempty"` // save the card and renew automatically
 }
 
 type CreateResponsePaymentRequest struct {
-	Package int `json:"package"`
+	Package   int    `json:"package"`
+	SKU       string `json:"sku,omitempty"`
+	PromoCode string `json:"promo_code,omitempty"`
+}
+
+type CreateProductPaymentRequest struct {
+	SKU       string `json:"sku"`
+	PromoCode string `json:"promo_code,omitempty"`
+}
+
+// ValidatePromoRequest previews a promo code for a product SKU or a plan
+type ValidatePromoRequest struct {
+	Code          string `json:"code"`
+	SKU           string `json:"sku,omitempty"`
+	Plan          string `json:"plan,omitempty"`
+	BillingPeriod string `json:"billing_period,omitempty"`
 }
 
 // InitRobokassaPayment handles POST /payments/robokassa/init
@@ -138,7 +157,7 @@ func (h *Handler) CreateRobokassaSubscriptionPayment(w http.ResponseWriter, r *h
 		response.BadRequest(w, "invalid request body")
 		return
 	}
-	out, err := h.service.CreateSubscriptionPayment(r.Context(), userID, req.Plan)
+	out, err := h.service.CreateSubscriptionPayment(r.Context(), userID, req.Plan, req.BillingPeriod, req.PromoCode, req.AutoRenew)
 	if err != nil {
 		response.BadRequest(w, err.Error())
 		return
@@ -153,7 +172,16 @@ func (h *Handler) CreateRobokassaResponsePayment(w http.ResponseWriter, r *http.
 		response.BadRequest(w, "invalid request body")
 		return
 	}
-	out, err := h.service.CreateResponsePayment(r.Context(), userID, req.Package)
+	role := middleware.GetRole(r.Context())
+	var (
+		out *InitRobokassaPaymentResponse
+		err error
+	)
+	if strings.TrimSpace(req.SKU) != "" {
+		out, err = h.service.CreateProductPayment(r.Context(), userID, role, req.SKU, req.PromoCode)
+	} else {
+		out, err = h.service.CreateResponsePayment(r.Context(), userID, role, req.Package, req.PromoCode)
+	}
 	if err != nil {
 		response.BadRequest(w, err.Error())
 		return
@@ -161,6 +189,87 @@ func (h *Handler) CreateRobokassaResponsePayment(w http.ResponseWriter, r *http.
 	response.OK(w, out)
 }
 
+// CreateRobokassaProductPayment handles POST /payments/robokassa/products
+// @Summary Оплата пакета из каталога через Robokassa
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateProductPaymentRequest true "SKU пакета"
+// @Success 200 {object} response.Response{data=InitRobokassaPaymentResponse}
+// @Failure 400,401 {object} response.Response
+// @Router /payments/robokassa/products [post]
+func (h *Handler) CreateRobokassaProductPayment(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	var req CreateProductPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+	out, err := h.service.CreateProductPayment(r.Context(), userID, middleware.GetRole(r.Context()), req.SKU, req.PromoCode)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	response.OK(w, out)
+}
+
+// ValidatePromo handles POST /payments/promo/validate
+// @Summary Проверка промокода
+// @Description Проверяет промокод для пакета (sku) или тарифа (plan) и возвращает сумму со скидкой
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ValidatePromoRequest true "Промокод и покупка"
+// @Success 200 {object} response.Response{data=AppliedPromo}
+// @Failure 400,401,404,422 {object} response.Response
+// @Router /payments/promo/validate [post]
+func (h *Handler) ValidatePromo(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	var req ValidatePromoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		response.BadRequest(w, "code is required")
+		return
+	}
+	applied, err := h.service.PreviewPromo(r.Context(), userID, middleware.GetRole(r.Context()), req.Code, req.SKU, req.Plan, req.BillingPeriod)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrPromoNotFound):
+			response.NotFound(w, err.Error())
+		case IsPromoRejection(err):
+			response.Error(w, http.StatusUnprocessableEntity, "PROMO_REJECTED", err.Error())
+		default:
+			response.BadRequest(w, err.Error())
+		}
+		return
+	}
+	response.OK(w, applied)
+}
+
+// ListProducts handles GET /payments/products
+// @Summary Каталог пакетов
+// @Description Возвращает пакеты откликов и кредитов, доступные текущему пользователю
+// @Tags Payment
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]ProductResponse}
+// @Failure 401,500 {object} response.Response
+// @Router /payments/products [get]
+func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.service.ListProducts(r.Context(), middleware.GetRole(r.Context()))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list products")
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, ProductResponsesFromEntities(products))
+}
+
 // RobokassaSuccess handles GET/POST /payments/robokassa/success
 // @Summary Страница успешной оплаты Robokassa
 // @Description Обрабатывает редирект пользователя после успешной оплаты (Success URL)
@@ -257,6 +366,45 @@ func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, payments)
 }
 
+// GetInvoice handles GET /payments/{id}/invoice
+// @Summary Счет по платежу (PDF)
+// @Description Возвращает PDF-счет по завершенному платежу текущего пользователя. Реквизиты покупателя берутся из организации (БИН/ИИН, юридическое название), если она есть.
+// @Tags Payment
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path string true "ID платежа"
+// @Success 200 {file} file
+// @Failure 400,401,404,409,500 {object} response.Response
+// @Router /payments/{id}/invoice [get]
+func (h *Handler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	paymentID, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid payment ID")
+		return
+	}
+
+	inv, pdf, err := h.service.GetInvoice(r.Context(), middleware.GetUserID(r.Context()), paymentID)
+	switch {
+	case errors.Is(err, ErrPaymentNotFound):
+		response.NotFound(w, "Payment not found")
+		return
+	case errors.Is(err, ErrInvoiceNotAvailable):
+		response.Conflict(w, err.Error())
+		return
+	case err != nil:
+		log.Error().Err(err).Str("payment_id", paymentID.String()).Msg("failed to get invoice")
+		response.InternalError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="invoice-%s.pdf"`, inv.Number))
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(pdf)
+}
+
 // Webhook handles POST /webhooks/payment/{provider}
 // @Summary Webhook от платежного провайдера
 // @Description Обрабатывает webhook-уведомления от различных платежных провайдеров
@@ -309,6 +457,10 @@ func (h *Handler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Rou
 		r.Post("/robokassa/init", h.InitRobokassaPayment)
 		r.Post("/robokassa/subscriptions", h.CreateRobokassaSubscriptionPayment)
 		r.Post("/robokassa/responses", h.CreateRobokassaResponsePayment)
+		r.Post("/robokassa/products", h.CreateRobokassaProductPayment)
+		r.Get("/products", h.ListProducts)
+		r.Post("/promo/validate", h.ValidatePromo)
+		r.Get("/{id}/invoice", h.GetInvoice)
 	})
 
 	// Robokassa user redirects should be publicly accessible
@@ -0,0 +1,358 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/pkg/storage"
+)
+
+// ErrInvoiceNotAvailable is returned for payments that were never completed
+var ErrInvoiceNotAvailable = errors.New("invoice is available only for completed payments")
+
+// InvoiceParty holds the requisites of a seller or buyer as printed on the invoice
+type InvoiceParty struct {
+	Name    string `json:"name"`
+	BinIIN  string `json:"bin_iin,omitempty"`
+	Address string `json:"address,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Bank    string `json:"bank,omitempty"`
+	IBAN    string `json:"iban,omitempty"`
+	BIK     string `json:"bik,omitempty"`
+	Kbe     string `json:"kbe,omitempty"`
+}
+
+func (p InvoiceParty) Value() (driver.Value, error) { return json.Marshal(p) }
+
+func (p *InvoiceParty) Scan(src any) error { return scanJSON(src, p) }
+
+// InvoiceLine is a single invoice position
+type InvoiceLine struct {
+	Name     string  `json:"name"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+	Sum      float64 `json:"sum"`
+}
+
+// InvoiceLines is stored as a JSON array
+type InvoiceLines []InvoiceLine
+
+func (l InvoiceLines) Value() (driver.Value, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(l)
+}
+
+func (l *InvoiceLines) Scan(src any) error { return scanJSON(src, l) }
+
+// Invoice is the numbered accounting document issued for a completed payment.
+// Seller and buyer are snapshots taken at issue time.
+type Invoice struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	PaymentID uuid.UUID      `db:"payment_id" json:"payment_id"`
+	UserID    uuid.UUID      `db:"user_id" json:"user_id"`
+	Number    string         `db:"number" json:"number"`
+	Seller    InvoiceParty   `db:"seller" json:"seller"`
+	Buyer     InvoiceParty   `db:"buyer" json:"buyer"`
+	Lines     InvoiceLines   `db:"lines" json:"lines"`
+	Subtotal  float64        `db:"subtotal" json:"subtotal"`
+	Discount  float64        `db:"discount" json:"discount"`
+	Total     float64        `db:"total" json:"total"`
+	VATRate   float64        `db:"vat_rate" json:"vat_rate"`
+	VATAmount float64        `db:"vat_amount" json:"vat_amount"`
+	Currency  string         `db:"currency" json:"currency"`
+	FilePath  sql.NullString `db:"file_path" json:"-"`
+	EmailedAt sql.NullTime   `db:"emailed_at" json:"emailed_at,omitempty"`
+	IssuedAt  time.Time      `db:"issued_at" json:"issued_at"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+// InvoiceConfig holds the seller requisites and numbering settings
+type InvoiceConfig struct {
+	Seller       InvoiceParty
+	NumberPrefix string
+	VATRate      float64 // percent included in prices; 0 issues invoices without VAT
+}
+
+// InvoiceMailer sends an issued invoice to the buyer
+type InvoiceMailer interface {
+	SendInvoice(to, toName, number, amount string, pdf []byte)
+}
+
+// InvoiceRepository defines invoice data access
+type InvoiceRepository interface {
+	NextNumber(ctx context.Context) (int64, error)
+	// Create stores the invoice unless the payment already has one; it reports whether a row was inserted
+	Create(ctx context.Context, inv *Invoice) (bool, error)
+	GetByPayment(ctx context.Context, paymentID uuid.UUID) (*Invoice, error)
+	SetFile(ctx context.Context, id uuid.UUID, path string) error
+	MarkEmailed(ctx context.Context, id uuid.UUID) error
+	// Buyer returns the payer's requisites, taken from their organization when there is one
+	Buyer(ctx context.Context, userID uuid.UUID) (*InvoiceParty, error)
+}
+
+type invoiceRepository struct {
+	db *sqlx.DB
+}
+
+// NewInvoiceRepository creates invoice repository
+func NewInvoiceRepository(db *sqlx.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) NextNumber(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.db.GetContext(ctx, &n, `SELECT nextval('invoice_number_seq')`)
+	return n, err
+}
+
+func (r *invoiceRepository) Create(ctx context.Context, inv *Invoice) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO invoices (id, payment_id, user_id, number, seller, buyer, lines, subtotal, discount, total, vat_rate, vat_amount, currency, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (payment_id) DO NOTHING`,
+		inv.ID, inv.PaymentID, inv.UserID, inv.Number, inv.Seller, inv.Buyer, inv.Lines,
+		inv.Subtotal, inv.Discount, inv.Total, inv.VATRate, inv.VATAmount, inv.Currency, inv.IssuedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *invoiceRepository) GetByPayment(ctx context.Context, paymentID uuid.UUID) (*Invoice, error) {
+	var inv Invoice
+	err := r.db.GetContext(ctx, &inv, `SELECT * FROM invoices WHERE payment_id = $1`, paymentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *invoiceRepository) SetFile(ctx context.Context, id uuid.UUID, path string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE invoices SET file_path = $2 WHERE id = $1`, id, path)
+	return err
+}
+
+func (r *invoiceRepository) MarkEmailed(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE invoices SET emailed_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func (r *invoiceRepository) Buyer(ctx context.Context, userID uuid.UUID) (*InvoiceParty, error) {
+	var row struct {
+		Email       string         `db:"email"`
+		LegalName   sql.NullString `db:"legal_name"`
+		BinIIN      sql.NullString `db:"bin_iin"`
+		Address     sql.NullString `db:"legal_address"`
+		CompanyName sql.NullString `db:"company_name"`
+	}
+	err := r.db.GetContext(ctx, &row, `
+		SELECT u.email, o.legal_name, o.bin_iin, o.legal_address, ep.company_name
+		FROM users u
+		LEFT JOIN LATERAL (
+			SELECT org.legal_name, org.bin_iin, org.legal_address
+			FROM organizations org
+			WHERE org.id = u.organization_id
+			   OR org.id IN (SELECT m.organization_id FROM organization_members m WHERE m.user_id = u.id)
+			ORDER BY (org.id = u.organization_id) DESC NULLS LAST, org.created_at
+			LIMIT 1
+		) o ON TRUE
+		LEFT JOIN employer_profiles ep ON ep.user_id = u.id
+		WHERE u.id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	party := &InvoiceParty{Email: row.Email, Name: row.Email}
+	switch {
+	case row.LegalName.Valid && row.LegalName.String != "":
+		party.Name = row.LegalName.String
+		party.BinIIN = row.BinIIN.String
+		party.Address = row.Address.String
+	case row.CompanyName.Valid && row.CompanyName.String != "":
+		party.Name = row.CompanyName.String
+	}
+	return party, nil
+}
+
+// SetInvoicing enables invoices for completed payments. Store and mailer are optional.
+func (s *Service) SetInvoicing(repo InvoiceRepository, store storage.Storage, mailer InvoiceMailer, cfg InvoiceConfig) {
+	s.invoices = repo
+	s.invoiceStore = store
+	s.invoiceMailer = mailer
+	s.invoiceConfig = cfg
+}
+
+// issueInvoice issues and emails the invoice for a just-completed payment.
+// Failures are logged: the payment itself has already succeeded.
+func (s *Service) issueInvoice(ctx context.Context, payment *Payment) {
+	if s.invoices == nil {
+		return
+	}
+	if _, err := s.IssueInvoice(ctx, payment, true); err != nil {
+		log.Error().Err(err).Str("payment_id", payment.ID.String()).Msg("failed to issue invoice")
+	}
+}
+
+// IssueInvoice creates the invoice for a completed payment, renders it to PDF and
+// archives it in storage. It is idempotent: an existing invoice is returned as is.
+func (s *Service) IssueInvoice(ctx context.Context, payment *Payment, sendEmail bool) (*Invoice, error) {
+	if s.invoices == nil {
+		return nil, fmt.Errorf("invoicing is not configured")
+	}
+	if existing, err := s.invoices.GetByPayment(ctx, payment.ID); err != nil || existing != nil {
+		return existing, err
+	}
+
+	buyer, err := s.invoices.Buyer(ctx, payment.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if buyer == nil {
+		buyer = &InvoiceParty{}
+	}
+	seq, err := s.invoices.NextNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := buildInvoice(payment, s.invoiceConfig, *buyer, seq, time.Now())
+	created, err := s.invoices.Create(ctx, inv)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Issued concurrently by another callback
+		return s.invoices.GetByPayment(ctx, payment.ID)
+	}
+
+	pdf, err := RenderInvoicePDF(inv)
+	if err != nil {
+		return inv, err
+	}
+	if s.invoiceStore != nil {
+		path := fmt.Sprintf("invoices/%d/%s.pdf", inv.IssuedAt.Year(), inv.ID)
+		if err := s.invoiceStore.Save(ctx, path, bytes.NewReader(pdf), "application/pdf"); err != nil {
+			log.Error().Err(err).Str("invoice", inv.Number).Msg("failed to store invoice pdf")
+		} else if err := s.invoices.SetFile(ctx, inv.ID, path); err != nil {
+			log.Error().Err(err).Str("invoice", inv.Number).Msg("failed to save invoice file path")
+		} else {
+			inv.FilePath = sql.NullString{String: path, Valid: true}
+		}
+	}
+	if sendEmail && s.invoiceMailer != nil && buyer.Email != "" {
+		s.invoiceMailer.SendInvoice(buyer.Email, buyer.Name, inv.Number, formatMoney(inv.Total, inv.Currency), pdf)
+		if err := s.invoices.MarkEmailed(ctx, inv.ID); err != nil {
+			log.Error().Err(err).Str("invoice", inv.Number).Msg("failed to mark invoice emailed")
+		}
+	}
+	return inv, nil
+}
+
+// GetInvoice returns the invoice of the user's payment with its PDF. Payments completed
+// before invoicing was enabled get their invoice issued on first download.
+func (s *Service) GetInvoice(ctx context.Context, userID, paymentID uuid.UUID) (*Invoice, []byte, error) {
+	payment, err := s.repo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if payment == nil || payment.UserID != userID {
+		return nil, nil, ErrPaymentNotFound
+	}
+	if !payment.IsPaid() && payment.Status != StatusRefunded {
+		return nil, nil, ErrInvoiceNotAvailable
+	}
+
+	inv, err := s.IssueInvoice(ctx, payment, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	pdf, err := RenderInvoicePDF(inv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inv, pdf, nil
+}
+
+// buildInvoice computes the invoice for a payment. The line shows the list price and
+// the promo discount is shown separately, so the total equals the charged amount.
+func buildInvoice(payment *Payment, cfg InvoiceConfig, buyer InvoiceParty, seq int64, now time.Time) *Invoice {
+	subtotal := payment.Amount
+	if payment.OriginalAmount.Valid && payment.OriginalAmount.Float64 > payment.Amount {
+		subtotal = payment.OriginalAmount.Float64
+	}
+	prefix := cfg.NumberPrefix
+	if prefix == "" {
+		prefix = "INV"
+	}
+	currency := payment.Currency
+	if currency == "" {
+		currency = "KZT"
+	}
+
+	inv := &Invoice{
+		ID:        uuid.New(),
+		PaymentID: payment.ID,
+		UserID:    payment.UserID,
+		Number:    fmt.Sprintf("%s-%d-%06d", prefix, now.Year(), seq),
+		Seller:    cfg.Seller,
+		Buyer:     buyer,
+		Lines:     InvoiceLines{{Name: paymentItemName(payment), Quantity: 1, Price: subtotal, Sum: subtotal}},
+		Subtotal:  subtotal,
+		Discount:  roundAmount(subtotal - payment.Amount),
+		Total:     payment.Amount,
+		VATRate:   cfg.VATRate,
+		Currency:  currency,
+		IssuedAt:  now,
+	}
+	if cfg.VATRate > 0 {
+		inv.VATAmount = roundAmount(inv.Total * cfg.VATRate / (100 + cfg.VATRate))
+	}
+	return inv
+}
+
+// paymentItemName names what was paid for on invoices and fiscal receipts
+func paymentItemName(payment *Payment) string {
+	if payment.Plan.Valid && payment.Plan.String != "" {
+		return planItemName(payment.Plan.String)
+	}
+	if payment.Description.Valid && payment.Description.String != "" {
+		return payment.Description.String
+	}
+	return "Услуги платформы MWork"
+}
+
+func planItemName(plan string) string {
+	return fmt.Sprintf("Подписка MWork «%s»", plan)
+}
+
+func scanJSON(src any, dst any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	default:
+		return fmt.Errorf("unsupported type: %T", src)
+	}
+}
@@ -0,0 +1,124 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mwork/mwork-api/internal/pkg/pdf"
+)
+
+const (
+	invoiceMargin   = 40.0
+	invoiceNameCols = 58 // characters that fit the item name column
+)
+
+// RenderInvoicePDF renders the invoice as a one-page A4 PDF
+func RenderInvoicePDF(inv *Invoice) ([]byte, error) {
+	doc := pdf.New()
+	doc.SetTitle("Счет " + inv.Number)
+	page := doc.AddPage()
+	left, right := invoiceMargin, pdf.PageWidth-invoiceMargin
+	y := pdf.PageHeight - 60
+
+	page.Text(left, y, 16, pdf.Bold, fmt.Sprintf("Счет № %s от %s", inv.Number, inv.IssuedAt.Format("02.01.2006")))
+	y -= 18
+	page.Text(left, y, 10, pdf.Regular, "Оплачен "+inv.IssuedAt.Format("02.01.2006"))
+	y -= 28
+
+	y = partyBlock(page, left, y, "Поставщик", inv.Seller, true)
+	y -= 10
+	y = partyBlock(page, left, y, "Покупатель", inv.Buyer, false)
+	y -= 16
+
+	cols := []float64{left, left + 25, left + 330, left + 380, left + 450}
+	page.Line(left, y+12, right, y+12, 0.8)
+	for i, h := range []string{"№", "Наименование", "Кол-во", "Цена", "Сумма"} {
+		page.Text(cols[i], y, 10, pdf.Bold, h)
+	}
+	y -= 8
+	page.Line(left, y, right, y, 0.5)
+	y -= 14
+	for i, line := range inv.Lines {
+		page.Text(cols[0], y, 10, pdf.Regular, fmt.Sprintf("%d", i+1))
+		page.Text(cols[1], y, 10, pdf.Regular, truncateRunes(line.Name, invoiceNameCols))
+		page.Text(cols[2], y, 10, pdf.Regular, fmt.Sprintf("%d", line.Quantity))
+		page.Text(cols[3], y, 10, pdf.Regular, formatMoney(line.Price, ""))
+		page.Text(cols[4], y, 10, pdf.Regular, formatMoney(line.Sum, ""))
+		y -= 16
+	}
+	page.Line(left, y+8, right, y+8, 0.8)
+	y -= 12
+
+	totals := [][2]string{{"Итого:", formatMoney(inv.Subtotal, inv.Currency)}}
+	if inv.Discount > 0 {
+		totals = append(totals, [2]string{"Скидка:", "-" + formatMoney(inv.Discount, inv.Currency)})
+	}
+	totals = append(totals, [2]string{"Всего оплачено:", formatMoney(inv.Total, inv.Currency)})
+	if inv.VATRate > 0 {
+		totals = append(totals, [2]string{fmt.Sprintf("В том числе НДС %s%%:", trimZeros(inv.VATRate)), formatMoney(inv.VATAmount, inv.Currency)})
+	} else {
+		totals = append(totals, [2]string{"Без НДС", ""})
+	}
+	for _, t := range totals {
+		page.Text(cols[2], y, 10, pdf.Bold, t[0])
+		page.Text(cols[4]-10, y, 10, pdf.Regular, t[1])
+		y -= 16
+	}
+
+	y -= 20
+	page.Text(left, y, 9, pdf.Regular, "Платеж "+inv.PaymentID.String())
+	return doc.Bytes()
+}
+
+// partyBlock prints the requisites of one side and returns the next baseline
+func partyBlock(page *pdf.Page, x, y float64, title string, p InvoiceParty, bank bool) float64 {
+	page.Text(x, y, 10, pdf.Bold, title+": "+p.Name)
+	y -= 14
+	rows := [][2]string{{"БИН/ИИН", p.BinIIN}, {"Адрес", p.Address}}
+	if bank {
+		rows = append(rows, [2]string{"Банк", p.Bank}, [2]string{"ИИК", p.IBAN}, [2]string{"БИК", p.BIK}, [2]string{"Кбе", p.Kbe})
+	}
+	rows = append(rows, [2]string{"Email", p.Email})
+	for _, r := range rows {
+		if r[1] == "" {
+			continue
+		}
+		page.Text(x, y, 9, pdf.Regular, r[0]+": "+r[1])
+		y -= 12
+	}
+	return y
+}
+
+// formatMoney formats an amount with space-separated thousands, e.g. "12 990.00 KZT"
+func formatMoney(v float64, currency string) string {
+	s := fmt.Sprintf("%.2f", v)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(c)
+	}
+	out := sign + b.String() + frac
+	if currency != "" {
+		out += " " + currency
+	}
+	return out
+}
+
+func trimZeros(v float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,160 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type invoiceRepoStub struct {
+	seq     int64
+	byPay   map[uuid.UUID]*Invoice
+	buyer   *InvoiceParty
+	emailed int
+}
+
+func (r *invoiceRepoStub) NextNumber(ctx context.Context) (int64, error) {
+	r.seq++
+	return r.seq, nil
+}
+func (r *invoiceRepoStub) Create(ctx context.Context, inv *Invoice) (bool, error) {
+	if r.byPay[inv.PaymentID] != nil {
+		return false, nil
+	}
+	r.byPay[inv.PaymentID] = inv
+	return true, nil
+}
+func (r *invoiceRepoStub) GetByPayment(ctx context.Context, paymentID uuid.UUID) (*Invoice, error) {
+	return r.byPay[paymentID], nil
+}
+func (r *invoiceRepoStub) SetFile(ctx context.Context, id uuid.UUID, path string) error { return nil }
+func (r *invoiceRepoStub) MarkEmailed(ctx context.Context, id uuid.UUID) error {
+	r.emailed++
+	return nil
+}
+func (r *invoiceRepoStub) Buyer(ctx context.Context, userID uuid.UUID) (*InvoiceParty, error) {
+	return r.buyer, nil
+}
+
+type storeStub struct{ saved map[string][]byte }
+
+func (s *storeStub) Save(ctx context.Context, path string, r io.Reader, contentType string) error {
+	data, _ := io.ReadAll(r)
+	s.saved[path] = data
+	return nil
+}
+func (s *storeStub) Delete(ctx context.Context, path string) error { return nil }
+func (s *storeStub) GetURL(path string) string                     { return path }
+
+type mailerStub struct{ sent []string }
+
+func (m *mailerStub) SendInvoice(to, toName, number, amount string, pdf []byte) {
+	m.sent = append(m.sent, to+" "+number+" "+amount)
+}
+
+func TestBuildInvoice_DiscountAndVAT(t *testing.T) {
+	p := &Payment{
+		ID:             uuid.New(),
+		Amount:         792,
+		OriginalAmount: sql.NullFloat64{Float64: 990, Valid: true},
+		DiscountAmount: 198,
+		Plan:           sql.NullString{String: "pro", Valid: true},
+	}
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	inv := buildInvoice(p, InvoiceConfig{NumberPrefix: "MW", VATRate: 12}, InvoiceParty{Name: "ТОО «Ромашка»", BinIIN: "123456789012"}, 42, now)
+
+	if inv.Number != "MW-2026-000042" {
+		t.Fatalf("unexpected number %s", inv.Number)
+	}
+	if inv.Subtotal != 990 || inv.Discount != 198 || inv.Total != 792 || inv.Currency != "KZT" {
+		t.Fatalf("unexpected totals: %+v", inv)
+	}
+	if inv.VATAmount != 84.86 {
+		t.Fatalf("unexpected included VAT %.2f", inv.VATAmount)
+	}
+	if len(inv.Lines) != 1 || inv.Lines[0].Name != "Подписка MWork «pro»" {
+		t.Fatalf("unexpected lines: %+v", inv.Lines)
+	}
+}
+
+func TestIssueInvoice_StoresEmailsOnce(t *testing.T) {
+	repo := &invoiceRepoStub{byPay: map[uuid.UUID]*Invoice{}, buyer: &InvoiceParty{Name: "ИП Иванов", BinIIN: "900101300123", Email: "buyer@example.com"}}
+	store := &storeStub{saved: map[string][]byte{}}
+	mailer := &mailerStub{}
+	svc := NewService(&captureRepo{}, nil)
+	svc.SetInvoicing(repo, store, mailer, InvoiceConfig{Seller: InvoiceParty{Name: "ТОО «MWork»"}, NumberPrefix: "MW"})
+
+	p := &Payment{ID: uuid.New(), UserID: uuid.New(), Amount: 12990, Currency: "KZT", Status: StatusCompleted}
+	inv, err := svc.IssueInvoice(context.Background(), p, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	again, err := svc.IssueInvoice(context.Background(), p, true)
+	if err != nil || again.ID != inv.ID {
+		t.Fatalf("invoice must be issued once, got %v %v", again, err)
+	}
+
+	if len(store.saved) != 1 || !inv.FilePath.Valid {
+		t.Fatalf("pdf not archived: %v", inv.FilePath)
+	}
+	for _, data := range store.saved {
+		if !bytes.HasPrefix(data, []byte("%PDF-")) {
+			t.Fatal("stored file is not a PDF")
+		}
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0] != "buyer@example.com "+inv.Number+" 12 990.00 KZT" || repo.emailed != 1 {
+		t.Fatalf("unexpected emails: %v", mailer.sent)
+	}
+}
+
+func TestGetInvoice_OnlyOwnCompletedPayments(t *testing.T) {
+	p := &Payment{ID: uuid.New(), UserID: uuid.New(), Amount: 500, Status: StatusPending}
+	svc := NewService(&refundPaymentRepo{payment: p}, nil)
+	svc.SetInvoicing(&invoiceRepoStub{byPay: map[uuid.UUID]*Invoice{}}, nil, nil, InvoiceConfig{})
+
+	if _, _, err := svc.GetInvoice(context.Background(), uuid.New(), p.ID); !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("expected ErrPaymentNotFound for another user, got %v", err)
+	}
+	if _, _, err := svc.GetInvoice(context.Background(), p.UserID, p.ID); !errors.Is(err, ErrInvoiceNotAvailable) {
+		t.Fatalf("expected ErrInvoiceNotAvailable, got %v", err)
+	}
+
+	p.Status = StatusCompleted
+	inv, pdf, err := svc.GetInvoice(context.Background(), p.UserID, p.ID)
+	if err != nil || inv == nil || !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Fatalf("expected invoice pdf, got %v", err)
+	}
+}
+
+func TestCheckoutProduct_IncludesSignedReceipt(t *testing.T) {
+	repo := &captureRepo{}
+	svc := NewService(repo, nil)
+	svc.SetRobokassaConfig(RobokassaConfig{MerchantLogin: "merchant", Password1: "p1", Password2: "p2", HashAlgo: "sha256", ReceiptEnabled: true, ReceiptSno: "usn_income"})
+	svc.SetProductRepository(&productStub{items: []*Product{
+		{SKU: "credits_10", Kind: ProductKindCredits, Title: "10 кредитов", Quantity: 10, Price: 1000, Currency: "KZT", Audience: AudienceAll, IsActive: true},
+	}})
+
+	out, err := svc.CreateProductPayment(context.Background(), uuid.New(), "employer", "credits_10", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := url.Parse(out.PaymentURL)
+	if err != nil {
+		t.Fatalf("invalid payment url: %v", err)
+	}
+	receipt, err := url.QueryUnescape(parsed.Query().Get("Receipt"))
+	if err != nil || !strings.Contains(receipt, `"name":"10 кредитов"`) || !strings.Contains(receipt, `"sum":1000`) {
+		t.Fatalf("receipt missing from payment link: %q", receipt)
+	}
+	if !strings.Contains(string(repo.created.RawInitPayload), "Receipt") {
+		t.Fatal("receipt must be kept in the init payload")
+	}
+}
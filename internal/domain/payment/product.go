@@ -0,0 +1,304 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ProductKind defines what a product grants when paid
+type ProductKind string
+
+const (
+	ProductKindResponses ProductKind = "responses" // response pack
+	ProductKindCredits   ProductKind = "credits"   // credit pack
+)
+
+// Audience restricts a product to a user role
+type Audience string
+
+const (
+	AudienceAll      Audience = "all"
+	AudienceModel    Audience = "model"
+	AudienceEmployer Audience = "employer"
+	AudienceAgency   Audience = "agency"
+)
+
+var (
+	ErrProductNotFound    = errors.New("product not found")
+	ErrProductUnavailable = errors.New("product is not available")
+	ErrSKUTaken           = errors.New("sku already exists")
+	ErrInvalidProduct     = errors.New("invalid product")
+)
+
+// Product is a purchasable package from the catalog.
+// SKU, kind and quantity are immutable so pending payments are fulfilled as sold.
+type Product struct {
+	ID          uuid.UUID    `db:"id" json:"id"`
+	SKU         string       `db:"sku" json:"sku"`
+	Kind        ProductKind  `db:"kind" json:"kind"`
+	Title       string       `db:"title" json:"title"`
+	Quantity    int          `db:"quantity" json:"quantity"`
+	Price       float64      `db:"price" json:"price"`
+	Currency    string       `db:"currency" json:"currency"`
+	Audience    Audience     `db:"audience" json:"audience"`
+	ActiveFrom  sql.NullTime `db:"active_from" json:"-"`
+	ActiveUntil sql.NullTime `db:"active_until" json:"-"`
+	IsActive    bool         `db:"is_active" json:"is_active"`
+	SortOrder   int          `db:"sort_order" json:"sort_order"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+// AvailableAt checks the active flag, sale window and audience
+func (p *Product) AvailableAt(now time.Time, role string) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.ActiveFrom.Valid && now.Before(p.ActiveFrom.Time) {
+		return false
+	}
+	if p.ActiveUntil.Valid && !now.Before(p.ActiveUntil.Time) {
+		return false
+	}
+	return p.Audience == AudienceAll || string(p.Audience) == role
+}
+
+// Validate checks product fields
+func (p *Product) Validate() error {
+	switch {
+	case strings.TrimSpace(p.SKU) == "":
+		return fmt.Errorf("%w: sku is required", ErrInvalidProduct)
+	case p.Kind != ProductKindResponses && p.Kind != ProductKindCredits:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidProduct, p.Kind)
+	case strings.TrimSpace(p.Title) == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidProduct)
+	case p.Quantity <= 0:
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidProduct)
+	case p.Price <= 0:
+		return fmt.Errorf("%w: price must be positive", ErrInvalidProduct)
+	case len(p.Currency) != 3:
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", ErrInvalidProduct)
+	}
+	switch p.Audience {
+	case AudienceAll, AudienceModel, AudienceEmployer, AudienceAgency:
+	default:
+		return fmt.Errorf("%w: unknown audience %q", ErrInvalidProduct, p.Audience)
+	}
+	if p.ActiveFrom.Valid && p.ActiveUntil.Valid && !p.ActiveUntil.Time.After(p.ActiveFrom.Time) {
+		return fmt.Errorf("%w: active_until must be after active_from", ErrInvalidProduct)
+	}
+	return nil
+}
+
+// ProductRepository defines product catalog data access
+type ProductRepository interface {
+	List(ctx context.Context, activeOnly bool) ([]*Product, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*Product, error)
+	GetBySKU(ctx context.Context, sku string) (*Product, error)
+	Create(ctx context.Context, p *Product) error
+	Update(ctx context.Context, p *Product) error
+}
+
+type productRepository struct {
+	db *sqlx.DB
+}
+
+// NewProductRepository creates product catalog repository
+func NewProductRepository(db *sqlx.DB) ProductRepository {
+	return &productRepository{db: db}
+}
+
+func (r *productRepository) List(ctx context.Context, activeOnly bool) ([]*Product, error) {
+	query := `SELECT * FROM products`
+	if activeOnly {
+		query += ` WHERE is_active = TRUE`
+	}
+	query += ` ORDER BY kind, sort_order, quantity`
+
+	var products []*Product
+	if err := r.db.SelectContext(ctx, &products, query); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *productRepository) GetByID(ctx context.Context, id uuid.UUID) (*Product, error) {
+	var p Product
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM products WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *productRepository) GetBySKU(ctx context.Context, sku string) (*Product, error) {
+	var p Product
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM products WHERE sku = $1`, sku)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *productRepository) Create(ctx context.Context, p *Product) error {
+	query := `
+		INSERT INTO products (id, sku, kind, title, quantity, price, currency, audience,
+			active_from, active_until, is_active, sort_order, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW())
+		RETURNING created_at, updated_at`
+	err := r.db.QueryRowxContext(ctx, query,
+		p.ID, p.SKU, p.Kind, p.Title, p.Quantity, p.Price, p.Currency, p.Audience,
+		p.ActiveFrom, p.ActiveUntil, p.IsActive, p.SortOrder,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrSKUTaken
+	}
+	return err
+}
+
+// Update saves the mutable fields; SKU, kind and quantity are never rewritten
+func (r *productRepository) Update(ctx context.Context, p *Product) error {
+	query := `
+		UPDATE products SET
+			title = $2, price = $3, currency = $4, audience = $5,
+			active_from = $6, active_until = $7, is_active = $8, sort_order = $9,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+	err := r.db.QueryRowxContext(ctx, query,
+		p.ID, p.Title, p.Price, p.Currency, p.Audience,
+		p.ActiveFrom, p.ActiveUntil, p.IsActive, p.SortOrder,
+	).Scan(&p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// SetProductRepository wires the product catalog used by package checkouts
+func (s *Service) SetProductRepository(products ProductRepository) {
+	s.products = products
+}
+
+// ListProducts returns the products currently on sale for a role
+func (s *Service) ListProducts(ctx context.Context, role string) ([]*Product, error) {
+	if s.products == nil {
+		return nil, nil
+	}
+	all, err := s.products.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]*Product, 0, len(all))
+	for _, p := range all {
+		if p.AvailableAt(now, role) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// ResolveProduct returns a product on sale for the role by SKU
+func (s *Service) ResolveProduct(ctx context.Context, sku, role string) (*Product, error) {
+	if s.products == nil {
+		return nil, ErrProductNotFound
+	}
+	p, err := s.products.GetBySKU(ctx, strings.TrimSpace(sku))
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrProductNotFound
+	}
+	if !p.AvailableAt(time.Now(), role) {
+		return nil, ErrProductUnavailable
+	}
+	return p, nil
+}
+
+// findPackage picks the product on sale for a kind and quantity,
+// preferring one targeted at the role over an all-audience one
+func (s *Service) findPackage(ctx context.Context, kind ProductKind, quantity int, role string) (*Product, error) {
+	available, err := s.ListProducts(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	var found *Product
+	for _, p := range available {
+		if p.Kind != kind || p.Quantity != quantity {
+			continue
+		}
+		if found == nil || (found.Audience == AudienceAll && p.Audience != AudienceAll) {
+			found = p
+		}
+	}
+	if found == nil {
+		return nil, ErrProductNotFound
+	}
+	return found, nil
+}
+
+// GetProduct returns a catalog product by ID
+func (s *Service) GetProduct(ctx context.Context, id uuid.UUID) (*Product, error) {
+	if s.products == nil {
+		return nil, ErrProductNotFound
+	}
+	p, err := s.products.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrProductNotFound
+	}
+	return p, nil
+}
+
+// ListAllProducts returns the whole catalog including inactive products
+func (s *Service) ListAllProducts(ctx context.Context) ([]*Product, error) {
+	if s.products == nil {
+		return nil, nil
+	}
+	return s.products.List(ctx, false)
+}
+
+// CreateProduct validates and adds a product to the catalog
+func (s *Service) CreateProduct(ctx context.Context, p *Product) error {
+	if s.products == nil {
+		return fmt.Errorf("product catalog is not configured")
+	}
+	p.SKU = strings.TrimSpace(p.SKU)
+	p.Currency = strings.ToUpper(strings.TrimSpace(p.Currency))
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	p.ID = uuid.New()
+	return s.products.Create(ctx, p)
+}
+
+// UpdateProduct validates and saves the mutable fields of a product
+func (s *Service) UpdateProduct(ctx context.Context, p *Product) error {
+	if s.products == nil {
+		return fmt.Errorf("product catalog is not configured")
+	}
+	p.Currency = strings.ToUpper(strings.TrimSpace(p.Currency))
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	return s.products.Update(ctx, p)
+}
@@ -0,0 +1,288 @@
+package payment
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/admin"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// ProductResponse represents a catalog product in API responses
+type ProductResponse struct {
+	ID          uuid.UUID   `json:"id"`
+	SKU         string      `json:"sku"`
+	Kind        ProductKind `json:"kind"`
+	Title       string      `json:"title"`
+	Quantity    int         `json:"quantity"`
+	Price       float64     `json:"price"`
+	Currency    string      `json:"currency"`
+	Audience    Audience    `json:"audience"`
+	ActiveFrom  *time.Time  `json:"active_from,omitempty"`
+	ActiveUntil *time.Time  `json:"active_until,omitempty"`
+	IsActive    bool        `json:"is_active"`
+	SortOrder   int         `json:"sort_order"`
+}
+
+// ProductResponseFromEntity converts a product to its response
+func ProductResponseFromEntity(p *Product) *ProductResponse {
+	resp := &ProductResponse{
+		ID:        p.ID,
+		SKU:       p.SKU,
+		Kind:      p.Kind,
+		Title:     p.Title,
+		Quantity:  p.Quantity,
+		Price:     p.Price,
+		Currency:  p.Currency,
+		Audience:  p.Audience,
+		IsActive:  p.IsActive,
+		SortOrder: p.SortOrder,
+	}
+	if p.ActiveFrom.Valid {
+		resp.ActiveFrom = &p.ActiveFrom.Time
+	}
+	if p.ActiveUntil.Valid {
+		resp.ActiveUntil = &p.ActiveUntil.Time
+	}
+	return resp
+}
+
+// ProductResponsesFromEntities converts a product list
+func ProductResponsesFromEntities(products []*Product) []*ProductResponse {
+	out := make([]*ProductResponse, 0, len(products))
+	for _, p := range products {
+		out = append(out, ProductResponseFromEntity(p))
+	}
+	return out
+}
+
+// CreateProductRequest is the admin payload for a new catalog product
+type CreateProductRequest struct {
+	SKU         string      `json:"sku"`
+	Kind        ProductKind `json:"kind"`
+	Title       string      `json:"title"`
+	Quantity    int         `json:"quantity"`
+	Price       float64     `json:"price"`
+	Currency    string      `json:"currency"`
+	Audience    Audience    `json:"audience"`
+	ActiveFrom  *time.Time  `json:"active_from"`
+	ActiveUntil *time.Time  `json:"active_until"`
+	IsActive    *bool       `json:"is_active"`
+	SortOrder   int         `json:"sort_order"`
+}
+
+// UpdateProductRequest replaces the mutable fields of a product.
+// Omitted active_from/active_until leave the sale window open on that side.
+type UpdateProductRequest struct {
+	Title       string     `json:"title"`
+	Price       float64    `json:"price"`
+	Currency    string     `json:"currency"`
+	Audience    Audience   `json:"audience"`
+	ActiveFrom  *time.Time `json:"active_from"`
+	ActiveUntil *time.Time `json:"active_until"`
+	IsActive    bool       `json:"is_active"`
+	SortOrder   int        `json:"sort_order"`
+}
+
+// ProductHandler exposes catalog management to admins
+type ProductHandler struct {
+	service  *Service
+	adminSvc *admin.Service
+}
+
+// NewProductHandler creates product admin handler
+func NewProductHandler(service *Service, adminSvc *admin.Service) *ProductHandler {
+	return &ProductHandler{service: service, adminSvc: adminSvc}
+}
+
+// AdminRoutes returns admin routes for the product catalog
+func (h *ProductHandler) AdminRoutes(jwtSvc *admin.JWTService, adminSvc *admin.Service) chi.Router {
+	r := chi.NewRouter()
+	r.Use(admin.AuthMiddleware(jwtSvc, adminSvc))
+	r.Use(admin.RequirePermission(admin.PermManageProducts))
+
+	r.Get("/", h.List)
+	r.Post("/", h.Create)
+	r.Put("/{id}", h.Update)
+	r.Delete("/{id}", h.Deactivate)
+
+	return r
+}
+
+// List handles GET /admin/products
+// @Summary Каталог пакетов (админ)
+// @Tags Admin Products
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]ProductResponse}
+// @Failure 401,403,500 {object} response.Response
+// @Router /admin/products [get]
+func (h *ProductHandler) List(w http.ResponseWriter, r *http.Request) {
+	products, err := h.service.ListAllProducts(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list products")
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, ProductResponsesFromEntities(products))
+}
+
+// Create handles POST /admin/products
+// @Summary Создать пакет
+// @Tags Admin Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateProductRequest true "Пакет"
+// @Success 201 {object} response.Response{data=ProductResponse}
+// @Failure 400,401,403,409,500 {object} response.Response
+// @Router /admin/products [post]
+func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateProductRequest
+	if err := response.DecodeJSON(r.Body, &req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	p := &Product{
+		SKU:         req.SKU,
+		Kind:        req.Kind,
+		Title:       req.Title,
+		Quantity:    req.Quantity,
+		Price:       req.Price,
+		Currency:    req.Currency,
+		Audience:    req.Audience,
+		ActiveFrom:  nullTime(req.ActiveFrom),
+		ActiveUntil: nullTime(req.ActiveUntil),
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		SortOrder:   req.SortOrder,
+	}
+	if p.Currency == "" {
+		p.Currency = "KZT"
+	}
+	if p.Audience == "" {
+		p.Audience = AudienceAll
+	}
+
+	if err := h.service.CreateProduct(r.Context(), p); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), admin.GetAdminID(r.Context()), "product.create", "product", p.ID, "", nil, ProductResponseFromEntity(p))
+	response.Created(w, ProductResponseFromEntity(p))
+}
+
+// Update handles PUT /admin/products/{id}
+// @Summary Изменить пакет
+// @Description SKU, тип и количество неизменяемы; для другого состава создайте новый SKU
+// @Tags Admin Products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пакета"
+// @Param request body UpdateProductRequest true "Изменяемые поля"
+// @Success 200 {object} response.Response{data=ProductResponse}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /admin/products/{id} [put]
+func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid product ID")
+		return
+	}
+	var req UpdateProductRequest
+	if err := response.DecodeJSON(r.Body, &req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	p, err := h.service.GetProduct(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	old := ProductResponseFromEntity(p)
+
+	p.Title = req.Title
+	p.Price = req.Price
+	p.Currency = req.Currency
+	p.Audience = req.Audience
+	p.ActiveFrom = nullTime(req.ActiveFrom)
+	p.ActiveUntil = nullTime(req.ActiveUntil)
+	p.IsActive = req.IsActive
+	p.SortOrder = req.SortOrder
+	if p.Currency == "" {
+		p.Currency = "KZT"
+	}
+	if p.Audience == "" {
+		p.Audience = AudienceAll
+	}
+
+	if err := h.service.UpdateProduct(r.Context(), p); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), admin.GetAdminID(r.Context()), "product.update", "product", p.ID, "", old, ProductResponseFromEntity(p))
+	response.OK(w, ProductResponseFromEntity(p))
+}
+
+// Deactivate handles DELETE /admin/products/{id}.
+// Products are never deleted since payments reference their SKU.
+// @Summary Снять пакет с продажи
+// @Tags Admin Products
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пакета"
+// @Success 200 {object} response.Response{data=ProductResponse}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /admin/products/{id} [delete]
+func (h *ProductHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid product ID")
+		return
+	}
+
+	p, err := h.service.GetProduct(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	p.IsActive = false
+	if err := h.service.UpdateProduct(r.Context(), p); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), admin.GetAdminID(r.Context()), "product.deactivate", "product", p.ID, "", nil, nil)
+	response.OK(w, ProductResponseFromEntity(p))
+}
+
+func (h *ProductHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidProduct):
+		response.BadRequest(w, err.Error())
+	case errors.Is(err, ErrProductNotFound):
+		response.NotFound(w, "Product not found")
+	case errors.Is(err, ErrSKUTaken):
+		response.Conflict(w, "SKU already exists")
+	default:
+		log.Error().Err(err).Msg("product catalog operation failed")
+		response.InternalError(w)
+	}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,50 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestProductAvailableAt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	p := &Product{IsActive: true, Audience: AudienceEmployer,
+		ActiveFrom:  sql.NullTime{Time: now.Add(-time.Hour), Valid: true},
+		ActiveUntil: sql.NullTime{Time: now.Add(time.Hour), Valid: true},
+	}
+
+	if !p.AvailableAt(now, "employer") {
+		t.Fatal("expected product available inside window for its audience")
+	}
+	if p.AvailableAt(now, "model") {
+		t.Fatal("expected product hidden from other roles")
+	}
+	if p.AvailableAt(now.Add(time.Hour), "employer") {
+		t.Fatal("expected active_until to be exclusive")
+	}
+	if p.AvailableAt(now.Add(-2*time.Hour), "employer") {
+		t.Fatal("expected product unavailable before active_from")
+	}
+}
+
+func TestFindPackage_PrefersTargetedAudience(t *testing.T) {
+	svc := NewService(nil, nil)
+	svc.SetProductRepository(&productStub{items: []*Product{
+		{SKU: "responses_10", Kind: ProductKindResponses, Quantity: 10, Price: 990, Audience: AudienceAll, IsActive: true},
+		{SKU: "responses_10_model", Kind: ProductKindResponses, Quantity: 10, Price: 790, Audience: AudienceModel, IsActive: true},
+		{SKU: "credits_10", Kind: ProductKindCredits, Quantity: 10, Price: 900, Audience: AudienceAll, IsActive: true},
+	}})
+
+	p, err := svc.findPackage(context.Background(), ProductKindResponses, 10, "model")
+	if err != nil || p.SKU != "responses_10_model" {
+		t.Fatalf("expected model-targeted package, got %+v, %v", p, err)
+	}
+	p, err = svc.findPackage(context.Background(), ProductKindResponses, 10, "employer")
+	if err != nil || p.SKU != "responses_10" {
+		t.Fatalf("expected all-audience package, got %+v, %v", p, err)
+	}
+	if _, err := svc.findPackage(context.Background(), ProductKindResponses, 30, "model"); err != ErrProductNotFound {
+		t.Fatalf("expected ErrProductNotFound, got %v", err)
+	}
+}
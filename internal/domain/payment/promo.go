@@ -0,0 +1,651 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/subscription"
+)
+
+// DiscountType defines how a promo code discount is computed
+type DiscountType string
+
+const (
+	DiscountPercent DiscountType = "percent" // percentage of the price, 0 < value < 100
+	DiscountFixed   DiscountType = "fixed"   // fixed amount in KZT
+)
+
+const (
+	// minChargeAmount is the smallest amount Robokassa can charge; discounts never go below it
+	minChargeAmount = 1.0
+	// promoReservationTTL is how long a pending checkout holds a promo code use
+	promoReservationTTL = time.Hour
+)
+
+var (
+	ErrPromoNotFound          = errors.New("promo code not found")
+	ErrPromoInactive          = errors.New("promo code is not active")
+	ErrPromoNotApplicable     = errors.New("promo code does not apply to this purchase")
+	ErrPromoExhausted         = errors.New("promo code usage limit reached")
+	ErrPromoUserLimit         = errors.New("promo code already used")
+	ErrPromoFirstPurchaseOnly = errors.New("promo code is valid for the first purchase only")
+	ErrInvalidPromo           = errors.New("invalid promo code")
+	ErrPromoCodeTaken         = errors.New("promo code already exists")
+)
+
+// IsPromoRejection reports whether err means the promo code can't be used for a checkout
+func IsPromoRejection(err error) bool {
+	for _, target := range []error{ErrPromoNotFound, ErrPromoInactive, ErrPromoNotApplicable, ErrPromoExhausted, ErrPromoUserLimit, ErrPromoFirstPurchaseOnly} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProductTarget and PlanTarget name what a promo code applies to
+func ProductTarget(sku string) string { return sku }
+func PlanTarget(planID string) string { return "plan:" + planID }
+
+// PromoCode is a marketing campaign code
+type PromoCode struct {
+	ID                uuid.UUID      `db:"id" json:"id"`
+	Code              string         `db:"code" json:"code"`
+	Description       string         `db:"description" json:"description"`
+	DiscountType      DiscountType   `db:"discount_type" json:"discount_type"`
+	DiscountValue     float64        `db:"discount_value" json:"discount_value"`
+	AppliesTo         pq.StringArray `db:"applies_to" json:"applies_to"` // SKUs and plan:<id>; empty applies to everything
+	Audience          Audience       `db:"audience" json:"audience"`
+	MaxRedemptions    sql.NullInt64  `db:"max_redemptions" json:"-"`
+	PerUserLimit      sql.NullInt64  `db:"per_user_limit" json:"-"`
+	ValidFrom         sql.NullTime   `db:"valid_from" json:"-"`
+	ValidUntil        sql.NullTime   `db:"valid_until" json:"-"`
+	FirstPurchaseOnly bool           `db:"first_purchase_only" json:"first_purchase_only"`
+	IsActive          bool           `db:"is_active" json:"is_active"`
+	CreatedBy         uuid.NullUUID  `db:"created_by" json:"-"`
+	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// ActiveAt checks the active flag and validity window
+func (p *PromoCode) ActiveAt(now time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.ValidFrom.Valid && now.Before(p.ValidFrom.Time) {
+		return false
+	}
+	return !p.ValidUntil.Valid || now.Before(p.ValidUntil.Time)
+}
+
+// AppliesToTarget checks the target list and audience
+func (p *PromoCode) AppliesToTarget(target, role string) bool {
+	if p.Audience != AudienceAll && string(p.Audience) != role {
+		return false
+	}
+	if len(p.AppliesTo) == 0 {
+		return true
+	}
+	for _, t := range p.AppliesTo {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount returns the discount for a price, never leaving less than minChargeAmount to pay
+func (p *PromoCode) Discount(price float64) float64 {
+	var d float64
+	switch p.DiscountType {
+	case DiscountPercent:
+		d = price * p.DiscountValue / 100
+	case DiscountFixed:
+		d = p.DiscountValue
+	}
+	if limit := price - minChargeAmount; d > limit {
+		d = limit
+	}
+	if d < 0 {
+		return 0
+	}
+	return math.Round(d*100) / 100
+}
+
+// Validate checks promo code fields
+func (p *PromoCode) Validate() error {
+	switch {
+	case strings.TrimSpace(p.Code) == "":
+		return fmt.Errorf("%w: code is required", ErrInvalidPromo)
+	case len(p.Code) > 50:
+		return fmt.Errorf("%w: code is too long", ErrInvalidPromo)
+	case p.DiscountType != DiscountPercent && p.DiscountType != DiscountFixed:
+		return fmt.Errorf("%w: unknown discount type %q", ErrInvalidPromo, p.DiscountType)
+	case p.DiscountValue <= 0:
+		return fmt.Errorf("%w: discount must be positive", ErrInvalidPromo)
+	case p.DiscountType == DiscountPercent && p.DiscountValue >= 100:
+		return fmt.Errorf("%w: percent discount must be below 100", ErrInvalidPromo)
+	case p.MaxRedemptions.Valid && p.MaxRedemptions.Int64 <= 0:
+		return fmt.Errorf("%w: max_redemptions must be positive", ErrInvalidPromo)
+	case p.PerUserLimit.Valid && p.PerUserLimit.Int64 <= 0:
+		return fmt.Errorf("%w: per_user_limit must be positive", ErrInvalidPromo)
+	}
+	switch p.Audience {
+	case AudienceAll, AudienceModel, AudienceEmployer, AudienceAgency:
+	default:
+		return fmt.Errorf("%w: unknown audience %q", ErrInvalidPromo, p.Audience)
+	}
+	if p.ValidFrom.Valid && p.ValidUntil.Valid && !p.ValidUntil.Time.After(p.ValidFrom.Time) {
+		return fmt.Errorf("%w: valid_until must be after valid_from", ErrInvalidPromo)
+	}
+	return nil
+}
+
+// PromoRedemption is a use of a promo code by a checkout
+type PromoRedemption struct {
+	ID             uuid.UUID    `db:"id"`
+	PromoCodeID    uuid.UUID    `db:"promo_code_id"`
+	PaymentID      uuid.UUID    `db:"payment_id"`
+	UserID         uuid.UUID    `db:"user_id"`
+	Target         string       `db:"target"`
+	OriginalAmount float64      `db:"original_amount"`
+	DiscountAmount float64      `db:"discount_amount"`
+	Status         string       `db:"status"` // pending, redeemed, cancelled
+	RedeemedAt     sql.NullTime `db:"redeemed_at"`
+	CreatedAt      time.Time    `db:"created_at"`
+}
+
+// PromoStats summarises the redemptions of a promo code
+type PromoStats struct {
+	Redeemed      int     `db:"redeemed" json:"redeemed"`
+	Pending       int     `db:"pending" json:"pending"`
+	OverLimit     int     `db:"over_limit" json:"over_limit"` // redeemed by late payments past a limit
+	UniqueUsers   int     `db:"unique_users" json:"unique_users"`
+	TotalDiscount float64 `db:"total_discount" json:"total_discount"`
+	Revenue       float64 `db:"revenue" json:"revenue"`
+}
+
+// AppliedPromo is the outcome of applying a promo code to a price
+type AppliedPromo struct {
+	CodeID         uuid.UUID `json:"-"`
+	Code           string    `json:"code"`
+	OriginalAmount float64   `json:"original_amount"`
+	Discount       float64   `json:"discount"`
+	Amount         float64   `json:"amount"`
+	target         string
+}
+
+// PromoRepository defines promo code data access
+type PromoRepository interface {
+	List(ctx context.Context) ([]*PromoCode, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*PromoCode, error)
+	GetByCode(ctx context.Context, code string) (*PromoCode, error)
+	Create(ctx context.Context, p *PromoCode) error
+	Update(ctx context.Context, p *PromoCode) error
+	UserRole(ctx context.Context, userID uuid.UUID) (string, error)
+	HasCompletedPayment(ctx context.Context, userID uuid.UUID) (bool, error)
+	// CountUses returns uses counting towards the limits, overall and by the user
+	CountUses(ctx context.Context, codeID, userID uuid.UUID) (total, byUser int, err error)
+	// Reserve re-checks the limits under a row lock on the code and stores the redemption
+	Reserve(ctx context.Context, r *PromoRedemption, maxTotal, perUser sql.NullInt64) error
+	// MarkRedeemed redeems the use of a paid payment. A use that was cancelled or expired
+	// meanwhile is re-checked against the limits under the code's row lock and redeemed
+	// anyway, since the discount was already paid; overLimit reports it broke a limit.
+	MarkRedeemed(ctx context.Context, paymentID uuid.UUID) (overLimit bool, err error)
+	Cancel(ctx context.Context, paymentID uuid.UUID) error
+	Stats(ctx context.Context, codeID uuid.UUID) (*PromoStats, error)
+}
+
+type promoRepository struct {
+	db *sqlx.DB
+}
+
+// NewPromoRepository creates promo code repository
+func NewPromoRepository(db *sqlx.DB) PromoRepository {
+	return &promoRepository{db: db}
+}
+
+// usesCondition selects redemptions counting towards limits
+const usesCondition = `(status = 'redeemed' OR (status = 'pending' AND created_at > NOW() - $2::interval))`
+
+func (r *promoRepository) List(ctx context.Context) ([]*PromoCode, error) {
+	var codes []*PromoCode
+	if err := r.db.SelectContext(ctx, &codes, `SELECT * FROM promo_codes ORDER BY created_at DESC`); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (r *promoRepository) GetByID(ctx context.Context, id uuid.UUID) (*PromoCode, error) {
+	var p PromoCode
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM promo_codes WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *promoRepository) GetByCode(ctx context.Context, code string) (*PromoCode, error) {
+	var p PromoCode
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM promo_codes WHERE UPPER(code) = UPPER($1)`, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *promoRepository) Create(ctx context.Context, p *PromoCode) error {
+	query := `
+		INSERT INTO promo_codes (id, code, description, discount_type, discount_value, applies_to, audience,
+			max_redemptions, per_user_limit, valid_from, valid_until, first_purchase_only, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING created_at, updated_at`
+	err := r.db.QueryRowxContext(ctx, query,
+		p.ID, p.Code, p.Description, p.DiscountType, p.DiscountValue, p.AppliesTo, p.Audience,
+		p.MaxRedemptions, p.PerUserLimit, p.ValidFrom, p.ValidUntil, p.FirstPurchaseOnly, p.IsActive, p.CreatedBy,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrPromoCodeTaken
+	}
+	return err
+}
+
+// Update saves everything but the code itself, which redemptions and campaigns refer to
+func (r *promoRepository) Update(ctx context.Context, p *PromoCode) error {
+	query := `
+		UPDATE promo_codes SET
+			description = $2, discount_type = $3, discount_value = $4, applies_to = $5, audience = $6,
+			max_redemptions = $7, per_user_limit = $8, valid_from = $9, valid_until = $10,
+			first_purchase_only = $11, is_active = $12, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+	err := r.db.QueryRowxContext(ctx, query,
+		p.ID, p.Description, p.DiscountType, p.DiscountValue, p.AppliesTo, p.Audience,
+		p.MaxRedemptions, p.PerUserLimit, p.ValidFrom, p.ValidUntil, p.FirstPurchaseOnly, p.IsActive,
+	).Scan(&p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPromoNotFound
+	}
+	return err
+}
+
+func (r *promoRepository) UserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	var role string
+	err := r.db.GetContext(ctx, &role, `SELECT role FROM users WHERE id = $1`, userID)
+	return role, err
+}
+
+func (r *promoRepository) HasCompletedPayment(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `
+		SELECT EXISTS(SELECT 1 FROM payments WHERE user_id = $1 AND status IN ('completed', 'paid', 'refunded'))`, userID)
+	return exists, err
+}
+
+func (r *promoRepository) CountUses(ctx context.Context, codeID, userID uuid.UUID) (int, int, error) {
+	var counts struct {
+		Total  int `db:"total"`
+		ByUser int `db:"by_user"`
+	}
+	err := r.db.GetContext(ctx, &counts, `
+		SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE user_id = $3) AS by_user
+		FROM promo_redemptions
+		WHERE promo_code_id = $1 AND `+usesCondition,
+		codeID, ttlInterval(), userID)
+	return counts.Total, counts.ByUser, err
+}
+
+func (r *promoRepository) Reserve(ctx context.Context, red *PromoRedemption, maxTotal, perUser sql.NullInt64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM promo_codes WHERE id = $1 FOR UPDATE`, red.PromoCodeID); err != nil {
+		return err
+	}
+	var counts struct {
+		Total  int `db:"total"`
+		ByUser int `db:"by_user"`
+	}
+	err = tx.GetContext(ctx, &counts, `
+		SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE user_id = $3) AS by_user
+		FROM promo_redemptions
+		WHERE promo_code_id = $1 AND `+usesCondition,
+		red.PromoCodeID, ttlInterval(), red.UserID)
+	if err != nil {
+		return err
+	}
+	if maxTotal.Valid && int64(counts.Total) >= maxTotal.Int64 {
+		return ErrPromoExhausted
+	}
+	if perUser.Valid && int64(counts.ByUser) >= perUser.Int64 {
+		return ErrPromoUserLimit
+	}
+
+	err = tx.QueryRowxContext(ctx, `
+		INSERT INTO promo_redemptions (id, promo_code_id, payment_id, user_id, target, original_amount, discount_amount, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending')
+		RETURNING created_at`,
+		red.ID, red.PromoCodeID, red.PaymentID, red.UserID, red.Target, red.OriginalAmount, red.DiscountAmount,
+	).Scan(&red.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *promoRepository) MarkRedeemed(ctx context.Context, paymentID uuid.UUID) (bool, error) {
+	// A live reservation already counts towards the limits
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE promo_redemptions SET status = 'redeemed', redeemed_at = NOW()
+		WHERE payment_id = $1 AND status = 'pending' AND created_at > NOW() - $2::interval`,
+		paymentID, ttlInterval())
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return false, nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var red struct {
+		ID          uuid.UUID `db:"id"`
+		PromoCodeID uuid.UUID `db:"promo_code_id"`
+		UserID      uuid.UUID `db:"user_id"`
+	}
+	err = tx.GetContext(ctx, &red, `
+		SELECT id, promo_code_id, user_id FROM promo_redemptions
+		WHERE payment_id = $1 AND status <> 'redeemed'`, paymentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The slot was given up; someone else may hold it by now
+	var limits struct {
+		MaxRedemptions sql.NullInt64 `db:"max_redemptions"`
+		PerUserLimit   sql.NullInt64 `db:"per_user_limit"`
+	}
+	if err := tx.GetContext(ctx, &limits, `
+		SELECT max_redemptions, per_user_limit FROM promo_codes WHERE id = $1 FOR UPDATE`, red.PromoCodeID); err != nil {
+		return false, err
+	}
+	var counts struct {
+		Total  int `db:"total"`
+		ByUser int `db:"by_user"`
+	}
+	if err := tx.GetContext(ctx, &counts, `
+		SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE user_id = $3) AS by_user
+		FROM promo_redemptions
+		WHERE promo_code_id = $1 AND id <> $4 AND `+usesCondition,
+		red.PromoCodeID, ttlInterval(), red.UserID, red.ID); err != nil {
+		return false, err
+	}
+	overLimit := (limits.MaxRedemptions.Valid && int64(counts.Total) >= limits.MaxRedemptions.Int64) ||
+		(limits.PerUserLimit.Valid && int64(counts.ByUser) >= limits.PerUserLimit.Int64)
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE promo_redemptions SET status = 'redeemed', redeemed_at = NOW(), over_limit = $2
+		WHERE id = $1`, red.ID, overLimit); err != nil {
+		return false, err
+	}
+	return overLimit, tx.Commit()
+}
+
+func (r *promoRepository) Cancel(ctx context.Context, paymentID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE promo_redemptions SET status = 'cancelled'
+		WHERE payment_id = $1 AND status = 'pending'`, paymentID)
+	return err
+}
+
+func (r *promoRepository) Stats(ctx context.Context, codeID uuid.UUID) (*PromoStats, error) {
+	var stats PromoStats
+	err := r.db.GetContext(ctx, &stats, `
+		SELECT
+			COUNT(*) FILTER (WHERE pr.status = 'redeemed') AS redeemed,
+			COUNT(*) FILTER (WHERE pr.status = 'pending' AND pr.created_at > NOW() - $2::interval) AS pending,
+			COUNT(*) FILTER (WHERE pr.over_limit) AS over_limit,
+			COUNT(DISTINCT pr.user_id) FILTER (WHERE pr.status = 'redeemed') AS unique_users,
+			COALESCE(SUM(pr.discount_amount) FILTER (WHERE pr.status = 'redeemed'), 0) AS total_discount,
+			COALESCE(SUM(p.amount) FILTER (WHERE pr.status = 'redeemed'), 0) AS revenue
+		FROM promo_redemptions pr
+		LEFT JOIN payments p ON p.id = pr.payment_id
+		WHERE pr.promo_code_id = $1`, codeID, ttlInterval())
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func ttlInterval() string {
+	return fmt.Sprintf("%d seconds", int(promoReservationTTL.Seconds()))
+}
+
+// SetPromoRepository wires promo codes into checkout
+func (s *Service) SetPromoRepository(promos PromoRepository) {
+	s.promos = promos
+}
+
+// QuotePromo validates a promo code for a user and target and prices it. Limits are
+// checked here for feedback and re-checked atomically when the checkout reserves the code.
+func (s *Service) QuotePromo(ctx context.Context, userID uuid.UUID, code, target string, price float64) (*AppliedPromo, error) {
+	if s.promos == nil {
+		return nil, ErrPromoNotFound
+	}
+	promo, err := s.promos.GetByCode(ctx, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+	if promo == nil {
+		return nil, ErrPromoNotFound
+	}
+	if !promo.ActiveAt(time.Now()) {
+		return nil, ErrPromoInactive
+	}
+	role, err := s.promos.UserRole(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !promo.AppliesToTarget(target, role) {
+		return nil, ErrPromoNotApplicable
+	}
+	if promo.FirstPurchaseOnly {
+		paid, err := s.promos.HasCompletedPayment(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if paid {
+			return nil, ErrPromoFirstPurchaseOnly
+		}
+	}
+	total, byUser, err := s.promos.CountUses(ctx, promo.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if promo.MaxRedemptions.Valid && int64(total) >= promo.MaxRedemptions.Int64 {
+		return nil, ErrPromoExhausted
+	}
+	if promo.PerUserLimit.Valid && int64(byUser) >= promo.PerUserLimit.Int64 {
+		return nil, ErrPromoUserLimit
+	}
+
+	discount := promo.Discount(price)
+	if discount <= 0 {
+		return nil, ErrPromoNotApplicable
+	}
+	return &AppliedPromo{
+		CodeID:         promo.ID,
+		Code:           promo.Code,
+		OriginalAmount: price,
+		Discount:       discount,
+		Amount:         math.Round((price-discount)*100) / 100,
+		target:         target,
+	}, nil
+}
+
+// PreviewPromo prices a promo code for a catalog SKU or a plan checkout
+func (s *Service) PreviewPromo(ctx context.Context, userID uuid.UUID, role, code, sku, plan, period string) (*AppliedPromo, error) {
+	switch {
+	case strings.TrimSpace(sku) != "":
+		product, err := s.ResolveProduct(ctx, sku, role)
+		if err != nil {
+			return nil, err
+		}
+		return s.QuotePromo(ctx, userID, code, ProductTarget(product.SKU), product.Price)
+	case strings.TrimSpace(plan) != "":
+		if period == "" {
+			period = string(subscription.BillingMonthly)
+		}
+		plan = strings.ToLower(strings.TrimSpace(plan))
+		quote, err := s.subSvc.QuoteChange(ctx, userID, subscription.PlanID(plan), subscription.BillingPeriod(period))
+		if err != nil {
+			return nil, err
+		}
+		return s.QuotePromo(ctx, userID, code, PlanTarget(plan), quote.Amount)
+	}
+	return nil, fmt.Errorf("sku or plan is required")
+}
+
+// applyPromo quotes a promo code and reserves a use of it for the payment
+func (s *Service) applyPromo(ctx context.Context, userID, paymentID uuid.UUID, code, target string, price float64) (*AppliedPromo, error) {
+	applied, err := s.QuotePromo(ctx, userID, code, target, price)
+	if err != nil {
+		return nil, err
+	}
+	promo, err := s.promos.GetByID(ctx, applied.CodeID)
+	if err != nil || promo == nil {
+		return nil, ErrPromoNotFound
+	}
+	err = s.promos.Reserve(ctx, &PromoRedemption{
+		ID:             uuid.New(),
+		PromoCodeID:    applied.CodeID,
+		PaymentID:      paymentID,
+		UserID:         userID,
+		Target:         applied.target,
+		OriginalAmount: applied.OriginalAmount,
+		DiscountAmount: applied.Discount,
+	}, promo.MaxRedemptions, promo.PerUserLimit)
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// withPromo records an applied promo on a payment
+func withPromo(p *Payment, applied *AppliedPromo) {
+	if applied == nil {
+		return
+	}
+	p.PromoCodeID = uuid.NullUUID{UUID: applied.CodeID, Valid: true}
+	p.OriginalAmount = sql.NullFloat64{Float64: applied.OriginalAmount, Valid: true}
+	p.DiscountAmount = applied.Discount
+	p.Amount = applied.Amount
+}
+
+// releasePromo frees the use reserved by a checkout that did not go through
+func (s *Service) releasePromo(ctx context.Context, paymentID uuid.UUID) {
+	if s.promos == nil {
+		return
+	}
+	if err := s.promos.Cancel(ctx, paymentID); err != nil {
+		log.Error().Err(err).Str("payment_id", paymentID.String()).Msg("Failed to release promo code reservation")
+	}
+}
+
+// redeemPromo marks the promo use of a successful payment as redeemed
+func (s *Service) redeemPromo(ctx context.Context, payment *Payment) {
+	if s.promos == nil || !payment.PromoCodeID.Valid {
+		return
+	}
+	overLimit, err := s.promos.MarkRedeemed(ctx, payment.ID)
+	if err != nil {
+		log.Error().Err(err).Str("payment_id", payment.ID.String()).Msg("Failed to mark promo code redeemed")
+		return
+	}
+	if overLimit {
+		log.Warn().Str("payment_id", payment.ID.String()).Str("promo_code_id", payment.PromoCodeID.UUID.String()).
+			Msg("Late payment redeemed a released promo code use over its limit")
+	}
+}
+
+// ListPromoCodes returns all promo codes
+func (s *Service) ListPromoCodes(ctx context.Context) ([]*PromoCode, error) {
+	if s.promos == nil {
+		return nil, nil
+	}
+	return s.promos.List(ctx)
+}
+
+// GetPromoCode returns a promo code by ID
+func (s *Service) GetPromoCode(ctx context.Context, id uuid.UUID) (*PromoCode, error) {
+	if s.promos == nil {
+		return nil, ErrPromoNotFound
+	}
+	p, err := s.promos.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrPromoNotFound
+	}
+	return p, nil
+}
+
+// CreatePromoCode validates and stores a new promo code
+func (s *Service) CreatePromoCode(ctx context.Context, p *PromoCode) error {
+	if s.promos == nil {
+		return fmt.Errorf("promo codes are not configured")
+	}
+	p.Code = strings.ToUpper(strings.TrimSpace(p.Code))
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	p.ID = uuid.New()
+	return s.promos.Create(ctx, p)
+}
+
+// UpdatePromoCode validates and saves a promo code
+func (s *Service) UpdatePromoCode(ctx context.Context, p *PromoCode) error {
+	if s.promos == nil {
+		return fmt.Errorf("promo codes are not configured")
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	return s.promos.Update(ctx, p)
+}
+
+// GetPromoStats returns redemption stats of a promo code
+func (s *Service) GetPromoStats(ctx context.Context, id uuid.UUID) (*PromoStats, error) {
+	if _, err := s.GetPromoCode(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.promos.Stats(ctx, id)
+}
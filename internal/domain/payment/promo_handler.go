@@ -0,0 +1,295 @@
+package payment
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/admin"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// PromoCodeResponse represents a promo code in API responses
+type PromoCodeResponse struct {
+	ID                uuid.UUID    `json:"id"`
+	Code              string       `json:"code"`
+	Description       string       `json:"description"`
+	DiscountType      DiscountType `json:"discount_type"`
+	DiscountValue     float64      `json:"discount_value"`
+	AppliesTo         []string     `json:"applies_to"`
+	Audience          Audience     `json:"audience"`
+	MaxRedemptions    *int64       `json:"max_redemptions,omitempty"`
+	PerUserLimit      *int64       `json:"per_user_limit,omitempty"`
+	ValidFrom         *time.Time   `json:"valid_from,omitempty"`
+	ValidUntil        *time.Time   `json:"valid_until,omitempty"`
+	FirstPurchaseOnly bool         `json:"first_purchase_only"`
+	IsActive          bool         `json:"is_active"`
+	CreatedAt         time.Time    `json:"created_at"`
+}
+
+// PromoCodeResponseFromEntity converts a promo code to its response
+func PromoCodeResponseFromEntity(p *PromoCode) *PromoCodeResponse {
+	resp := &PromoCodeResponse{
+		ID:                p.ID,
+		Code:              p.Code,
+		Description:       p.Description,
+		DiscountType:      p.DiscountType,
+		DiscountValue:     p.DiscountValue,
+		AppliesTo:         []string(p.AppliesTo),
+		Audience:          p.Audience,
+		FirstPurchaseOnly: p.FirstPurchaseOnly,
+		IsActive:          p.IsActive,
+		CreatedAt:         p.CreatedAt,
+	}
+	if resp.AppliesTo == nil {
+		resp.AppliesTo = []string{}
+	}
+	if p.MaxRedemptions.Valid {
+		resp.MaxRedemptions = &p.MaxRedemptions.Int64
+	}
+	if p.PerUserLimit.Valid {
+		resp.PerUserLimit = &p.PerUserLimit.Int64
+	}
+	if p.ValidFrom.Valid {
+		resp.ValidFrom = &p.ValidFrom.Time
+	}
+	if p.ValidUntil.Valid {
+		resp.ValidUntil = &p.ValidUntil.Time
+	}
+	return resp
+}
+
+// PromoCodeRequest is the admin payload for creating or updating a promo code.
+// Code is ignored on update. Applies_to lists SKUs and plan:<id> targets; empty means everything.
+type PromoCodeRequest struct {
+	Code              string       `json:"code"`
+	Description       string       `json:"description"`
+	DiscountType      DiscountType `json:"discount_type"`
+	DiscountValue     float64      `json:"discount_value"`
+	AppliesTo         []string     `json:"applies_to"`
+	Audience          Audience     `json:"audience"`
+	MaxRedemptions    *int64       `json:"max_redemptions"`
+	PerUserLimit      *int64       `json:"per_user_limit"`
+	ValidFrom         *time.Time   `json:"valid_from"`
+	ValidUntil        *time.Time   `json:"valid_until"`
+	FirstPurchaseOnly bool         `json:"first_purchase_only"`
+	IsActive          *bool        `json:"is_active"`
+}
+
+func (req *PromoCodeRequest) applyTo(p *PromoCode) {
+	p.Description = req.Description
+	p.DiscountType = req.DiscountType
+	p.DiscountValue = req.DiscountValue
+	p.AppliesTo = pq.StringArray(req.AppliesTo)
+	if p.AppliesTo == nil {
+		p.AppliesTo = pq.StringArray{}
+	}
+	p.Audience = req.Audience
+	if p.Audience == "" {
+		p.Audience = AudienceAll
+	}
+	p.MaxRedemptions = nullInt64(req.MaxRedemptions)
+	p.PerUserLimit = nullInt64(req.PerUserLimit)
+	p.ValidFrom = nullTime(req.ValidFrom)
+	p.ValidUntil = nullTime(req.ValidUntil)
+	p.FirstPurchaseOnly = req.FirstPurchaseOnly
+	p.IsActive = req.IsActive == nil || *req.IsActive
+}
+
+// PromoHandler exposes promo code management to admins
+type PromoHandler struct {
+	service  *Service
+	adminSvc *admin.Service
+}
+
+// NewPromoHandler creates promo code admin handler
+func NewPromoHandler(service *Service, adminSvc *admin.Service) *PromoHandler {
+	return &PromoHandler{service: service, adminSvc: adminSvc}
+}
+
+// AdminRoutes returns admin routes for promo codes
+func (h *PromoHandler) AdminRoutes(jwtSvc *admin.JWTService, adminSvc *admin.Service) chi.Router {
+	r := chi.NewRouter()
+	r.Use(admin.AuthMiddleware(jwtSvc, adminSvc))
+	r.Use(admin.RequirePermission(admin.PermManagePromoCodes))
+
+	r.Get("/", h.List)
+	r.Post("/", h.Create)
+	r.Put("/{id}", h.Update)
+	r.Delete("/{id}", h.Deactivate)
+	r.Get("/{id}/stats", h.Stats)
+
+	return r
+}
+
+// List handles GET /admin/promo-codes
+// @Summary Промокоды
+// @Tags Admin Promo Codes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]PromoCodeResponse}
+// @Failure 401,403,500 {object} response.Response
+// @Router /admin/promo-codes [get]
+func (h *PromoHandler) List(w http.ResponseWriter, r *http.Request) {
+	codes, err := h.service.ListPromoCodes(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	out := make([]*PromoCodeResponse, 0, len(codes))
+	for _, c := range codes {
+		out = append(out, PromoCodeResponseFromEntity(c))
+	}
+	response.OK(w, out)
+}
+
+// Create handles POST /admin/promo-codes
+// @Summary Создать промокод
+// @Tags Admin Promo Codes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body PromoCodeRequest true "Промокод"
+// @Success 201 {object} response.Response{data=PromoCodeResponse}
+// @Failure 400,401,403,409,500 {object} response.Response
+// @Router /admin/promo-codes [post]
+func (h *PromoHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req PromoCodeRequest
+	if err := response.DecodeJSON(r.Body, &req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	adminID := admin.GetAdminID(r.Context())
+	p := &PromoCode{Code: req.Code, CreatedBy: uuid.NullUUID{UUID: adminID, Valid: adminID != uuid.Nil}}
+	req.applyTo(p)
+	if err := h.service.CreatePromoCode(r.Context(), p); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), adminID, "promo_code.create", "promo_code", p.ID, "", nil, PromoCodeResponseFromEntity(p))
+	response.Created(w, PromoCodeResponseFromEntity(p))
+}
+
+// Update handles PUT /admin/promo-codes/{id}
+// @Summary Изменить промокод
+// @Description Сам код неизменяем
+// @Tags Admin Promo Codes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID промокода"
+// @Param request body PromoCodeRequest true "Промокод"
+// @Success 200 {object} response.Response{data=PromoCodeResponse}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /admin/promo-codes/{id} [put]
+func (h *PromoHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid promo code ID")
+		return
+	}
+	var req PromoCodeRequest
+	if err := response.DecodeJSON(r.Body, &req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	p, err := h.service.GetPromoCode(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	old := PromoCodeResponseFromEntity(p)
+	req.applyTo(p)
+	if err := h.service.UpdatePromoCode(r.Context(), p); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), admin.GetAdminID(r.Context()), "promo_code.update", "promo_code", p.ID, "", old, PromoCodeResponseFromEntity(p))
+	response.OK(w, PromoCodeResponseFromEntity(p))
+}
+
+// Deactivate handles DELETE /admin/promo-codes/{id}.
+// Codes are kept for redemption history.
+// @Summary Отключить промокод
+// @Tags Admin Promo Codes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID промокода"
+// @Success 200 {object} response.Response{data=PromoCodeResponse}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /admin/promo-codes/{id} [delete]
+func (h *PromoHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid promo code ID")
+		return
+	}
+	p, err := h.service.GetPromoCode(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	p.IsActive = false
+	if err := h.service.UpdatePromoCode(r.Context(), p); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), admin.GetAdminID(r.Context()), "promo_code.deactivate", "promo_code", p.ID, "", nil, nil)
+	response.OK(w, PromoCodeResponseFromEntity(p))
+}
+
+// Stats handles GET /admin/promo-codes/{id}/stats
+// @Summary Статистика промокода
+// @Description Погашения, резервы неоплаченных заказов, уникальные пользователи, сумма скидок и выручка
+// @Tags Admin Promo Codes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID промокода"
+// @Success 200 {object} response.Response{data=PromoStats}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /admin/promo-codes/{id}/stats [get]
+func (h *PromoHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid promo code ID")
+		return
+	}
+	stats, err := h.service.GetPromoStats(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	response.OK(w, stats)
+}
+
+func (h *PromoHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidPromo):
+		response.BadRequest(w, err.Error())
+	case errors.Is(err, ErrPromoNotFound):
+		response.NotFound(w, "Promo code not found")
+	case errors.Is(err, ErrPromoCodeTaken):
+		response.Conflict(w, "Promo code already exists")
+	default:
+		log.Error().Err(err).Msg("promo code operation failed")
+		response.InternalError(w)
+	}
+}
+
+func nullInt64(v *int64) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v, Valid: true}
+}
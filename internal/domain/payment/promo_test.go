@@ -0,0 +1,137 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type promoStub struct {
+	PromoRepository
+	code     *PromoCode
+	role     string
+	paid     bool
+	total    int
+	byUser   int
+	reserved []*PromoRedemption
+}
+
+func (r *promoStub) GetByCode(ctx context.Context, code string) (*PromoCode, error) {
+	if r.code != nil && r.code.Code == code {
+		return r.code, nil
+	}
+	return nil, nil
+}
+func (r *promoStub) GetByID(ctx context.Context, id uuid.UUID) (*PromoCode, error) {
+	return r.code, nil
+}
+func (r *promoStub) UserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	return r.role, nil
+}
+func (r *promoStub) HasCompletedPayment(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return r.paid, nil
+}
+func (r *promoStub) CountUses(ctx context.Context, codeID, userID uuid.UUID) (int, int, error) {
+	return r.total, r.byUser, nil
+}
+func (r *promoStub) Reserve(ctx context.Context, red *PromoRedemption, maxTotal, perUser sql.NullInt64) error {
+	r.reserved = append(r.reserved, red)
+	return nil
+}
+
+func springCode() *PromoCode {
+	return &PromoCode{
+		ID:            uuid.New(),
+		Code:          "SPRING",
+		DiscountType:  DiscountPercent,
+		DiscountValue: 20,
+		AppliesTo:     pq.StringArray{"responses_10", PlanTarget("pro")},
+		Audience:      AudienceModel,
+		IsActive:      true,
+	}
+}
+
+func TestPromoCode_Discount(t *testing.T) {
+	p := &PromoCode{DiscountType: DiscountPercent, DiscountValue: 15}
+	if d := p.Discount(990); d != 148.5 {
+		t.Fatalf("unexpected percent discount %.2f", d)
+	}
+	p = &PromoCode{DiscountType: DiscountFixed, DiscountValue: 5000}
+	if d := p.Discount(990); d != 989 {
+		t.Fatalf("fixed discount must leave the minimum charge, got %.2f", d)
+	}
+}
+
+func TestPromoCode_ActiveAndApplicable(t *testing.T) {
+	now := time.Now()
+	p := springCode()
+	p.ValidUntil = sql.NullTime{Time: now.Add(-time.Minute), Valid: true}
+	if p.ActiveAt(now) {
+		t.Fatal("expired code must not be active")
+	}
+	if !p.AppliesToTarget(PlanTarget("pro"), "model") || p.AppliesToTarget(PlanTarget("agency"), "model") {
+		t.Fatal("unexpected target matching")
+	}
+	if p.AppliesToTarget("responses_10", "employer") {
+		t.Fatal("audience must be enforced")
+	}
+}
+
+func TestQuotePromo_Rejections(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := &promoStub{code: springCode(), role: "model"}
+	svc := NewService(nil, nil)
+	svc.SetPromoRepository(repo)
+
+	if _, err := svc.QuotePromo(ctx, userID, "NOPE", "responses_10", 990); !errors.Is(err, ErrPromoNotFound) {
+		t.Fatalf("expected ErrPromoNotFound, got %v", err)
+	}
+	if _, err := svc.QuotePromo(ctx, userID, "SPRING", "credits_5", 500); !errors.Is(err, ErrPromoNotApplicable) {
+		t.Fatalf("expected ErrPromoNotApplicable, got %v", err)
+	}
+
+	repo.code.MaxRedemptions = sql.NullInt64{Int64: 3, Valid: true}
+	repo.total = 3
+	if _, err := svc.QuotePromo(ctx, userID, "SPRING", "responses_10", 990); !errors.Is(err, ErrPromoExhausted) {
+		t.Fatalf("expected ErrPromoExhausted, got %v", err)
+	}
+
+	repo.total = 0
+	repo.code.FirstPurchaseOnly = true
+	repo.paid = true
+	if _, err := svc.QuotePromo(ctx, userID, "SPRING", "responses_10", 990); !errors.Is(err, ErrPromoFirstPurchaseOnly) {
+		t.Fatalf("expected ErrPromoFirstPurchaseOnly, got %v", err)
+	}
+}
+
+func TestCreateProductPayment_AppliesPromo(t *testing.T) {
+	repo := &captureRepo{}
+	svc := NewService(repo, nil)
+	svc.SetRobokassaConfig(RobokassaConfig{MerchantLogin: "merchant", Password1: "p1", Password2: "p2", HashAlgo: "sha256"})
+	svc.SetProductRepository(&productStub{items: []*Product{
+		{SKU: "responses_10", Kind: ProductKindResponses, Title: "10", Quantity: 10, Price: 990, Currency: "KZT", Audience: AudienceAll, IsActive: true},
+	}})
+	promos := &promoStub{code: springCode(), role: "model"}
+	svc.SetPromoRepository(promos)
+
+	out, err := svc.CreateProductPayment(context.Background(), uuid.New(), "model", "responses_10", "SPRING")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Amount != 792 || out.Discount != 198 {
+		t.Fatalf("unexpected amounts: %+v", out)
+	}
+	created := repo.created
+	if created.Amount != 792 || created.DiscountAmount != 198 || created.OriginalAmount.Float64 != 990 || !created.PromoCodeID.Valid {
+		t.Fatalf("discount not captured on payment: %+v", created)
+	}
+	if len(promos.reserved) != 1 || promos.reserved[0].PaymentID != created.ID {
+		t.Fatalf("promo use not reserved for the payment: %+v", promos.reserved)
+	}
+}
@@ -0,0 +1,480 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/pkg/robokassa"
+)
+
+// ReconcileAction is what reconciliation did with a stale pending payment
+type ReconcileAction string
+
+const (
+	ReconcileCompleted      ReconcileAction = "completed"       // paid at the provider, callback was lost
+	ReconcileFailed         ReconcileAction = "failed"          // cancelled or abandoned
+	ReconcilePending        ReconcileAction = "still_pending"   // provider has not settled yet
+	ReconcileAmountMismatch ReconcileAction = "amount_mismatch" // paid a different sum, needs manual review
+	ReconcileError          ReconcileAction = "error"
+)
+
+// Report triggers
+const (
+	ReconcileTriggerScheduled = "scheduled"
+	ReconcileTriggerManual    = "manual"
+)
+
+// ErrReportNotFound is returned for unknown reconciliation reports
+var ErrReportNotFound = errors.New("reconciliation report not found")
+
+// ErrReconcileInProgress is returned while another instance or admin runs reconciliation
+var ErrReconcileInProgress = errors.New("reconciliation is already running")
+
+// reconcileLockKey is the pg advisory lock key guarding reconciliation runs
+const reconcileLockKey int64 = 0x7061795f7265636f // "pay_reco"
+
+// ReconciledPayment is one report row for a stale pending payment
+type ReconciledPayment struct {
+	PaymentID     uuid.UUID       `json:"payment_id"`
+	UserID        uuid.UUID       `json:"user_id"`
+	InvID         int64           `json:"inv_id"`
+	Amount        float64         `json:"amount"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ProviderState int             `json:"provider_state,omitempty"`
+	ProviderSum   string          `json:"provider_sum,omitempty"`
+	Action        ReconcileAction `json:"action"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// CreditMismatch is a user whose stored credit balance differs from the ledger sum
+type CreditMismatch struct {
+	UserID          uuid.UUID `db:"user_id" json:"user_id"`
+	StoredBalance   int       `db:"stored_balance" json:"stored_balance"`
+	ComputedBalance int       `db:"computed_balance" json:"computed_balance"`
+	Diff            int       `db:"diff" json:"diff"`
+}
+
+// ReconciledPayments is stored as a JSON array
+type ReconciledPayments []ReconciledPayment
+
+func (p ReconciledPayments) Value() (driver.Value, error) { return jsonArrayValue(p, len(p)) }
+
+func (p *ReconciledPayments) Scan(src any) error { return scanJSON(src, p) }
+
+// CreditMismatches is stored as a JSON array
+type CreditMismatches []CreditMismatch
+
+func (m CreditMismatches) Value() (driver.Value, error) { return jsonArrayValue(m, len(m)) }
+
+func (m *CreditMismatches) Scan(src any) error { return scanJSON(src, m) }
+
+// ReconciliationReport is the outcome of one reconciliation run
+type ReconciliationReport struct {
+	ID                  uuid.UUID          `db:"id" json:"id"`
+	Trigger             string             `db:"trigger" json:"trigger"`
+	AdminID             uuid.NullUUID      `db:"admin_id" json:"admin_id,omitempty"`
+	PaymentsChecked     int                `db:"payments_checked" json:"payments_checked"`
+	PaymentsCompleted   int                `db:"payments_completed" json:"payments_completed"`
+	PaymentsFailed      int                `db:"payments_failed" json:"payments_failed"`
+	PaymentsPending     int                `db:"payments_pending" json:"payments_pending"`
+	PaymentsMismatched  int                `db:"payments_mismatched" json:"payments_mismatched"`
+	PaymentsErrored     int                `db:"payments_errored" json:"payments_errored"`
+	CreditMismatchCount int                `db:"credit_mismatch_count" json:"credit_mismatch_count"`
+	RefundsSettled      int                `db:"refunds_settled" json:"refunds_settled"`
+	RefundsFailed       int                `db:"refunds_failed" json:"refunds_failed"`
+	RefundsOpen         int                `db:"refunds_open" json:"refunds_open"`
+	Payments            ReconciledPayments `db:"payments" json:"payments"`
+	CreditMismatches    CreditMismatches   `db:"credit_mismatches" json:"credit_mismatches"`
+	StartedAt           time.Time          `db:"started_at" json:"started_at"`
+	FinishedAt          time.Time          `db:"finished_at" json:"finished_at"`
+}
+
+func (r *ReconciliationReport) count(action ReconcileAction) {
+	switch action {
+	case ReconcileCompleted:
+		r.PaymentsCompleted++
+	case ReconcileFailed:
+		r.PaymentsFailed++
+	case ReconcilePending:
+		r.PaymentsPending++
+	case ReconcileAmountMismatch:
+		r.PaymentsMismatched++
+	default:
+		r.PaymentsErrored++
+	}
+}
+
+// ReconcileConfig controls which pending payments are checked and when they are given up
+type ReconcileConfig struct {
+	StaleAfter  time.Duration // pending longer than this is checked with the provider
+	GiveUpAfter time.Duration // unpaid invoices older than this are failed
+	BatchSize   int           // payments checked per run
+	MaxMismatch int           // credit mismatches kept in a report
+}
+
+// PaymentStateClient queries the provider for the state of an invoice
+type PaymentStateClient interface {
+	OpState(ctx context.Context, invID int64) (*robokassa.OpStateResponse, error)
+}
+
+// ReconcileRepository defines reconciliation data access
+type ReconcileRepository interface {
+	// Lock takes the reconciliation lock, failing with ErrReconcileInProgress when it is
+	// held elsewhere; the returned func releases it
+	Lock(ctx context.Context) (func(), error)
+	ListStalePending(ctx context.Context, createdBefore time.Time, limit int) ([]*Payment, error)
+	// CreditMismatches compares users.credit_balance with the sum of credit_transactions
+	CreditMismatches(ctx context.Context, limit int) ([]CreditMismatch, int, error)
+	SaveReport(ctx context.Context, report *ReconciliationReport) error
+	ListReports(ctx context.Context, limit int) ([]*ReconciliationReport, error)
+	GetReport(ctx context.Context, id uuid.UUID) (*ReconciliationReport, error)
+}
+
+type reconcileRepository struct {
+	db *sqlx.DB
+}
+
+// NewReconcileRepository creates reconciliation repository
+func NewReconcileRepository(db *sqlx.DB) ReconcileRepository {
+	return &reconcileRepository{db: db}
+}
+
+func (r *reconcileRepository) Lock(ctx context.Context) (func(), error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1)`, reconcileLockKey); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrReconcileInProgress
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, reconcileLockKey); err != nil {
+			log.Warn().Err(err).Msg("Failed to release reconciliation lock")
+		}
+		conn.Close()
+	}, nil
+}
+
+func (r *reconcileRepository) ListStalePending(ctx context.Context, createdBefore time.Time, limit int) ([]*Payment, error) {
+	var out []*Payment
+	err := r.db.SelectContext(ctx, &out, `
+		SELECT * FROM payments
+		WHERE status = 'pending' AND provider = 'robokassa' AND robokassa_inv_id IS NOT NULL AND created_at < $1
+		ORDER BY created_at
+		LIMIT $2`, createdBefore, limit)
+	return out, err
+}
+
+func (r *reconcileRepository) CreditMismatches(ctx context.Context, limit int) ([]CreditMismatch, int, error) {
+	var rows []struct {
+		CreditMismatch
+		Total int `db:"total"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+		WITH ledger AS (
+			SELECT u.id AS user_id,
+				u.credit_balance AS stored_balance,
+				COALESCE(SUM(ct.amount_delta), 0)::INTEGER AS computed_balance
+			FROM users u
+			LEFT JOIN credit_transactions ct ON ct.user_id = u.id
+			GROUP BY u.id, u.credit_balance
+		)
+		SELECT user_id, stored_balance, computed_balance, stored_balance - computed_balance AS diff,
+			COUNT(*) OVER () AS total
+		FROM ledger
+		WHERE stored_balance <> computed_balance
+		ORDER BY ABS(stored_balance - computed_balance) DESC, user_id
+		LIMIT $1`, limit)
+	if err != nil || len(rows) == 0 {
+		return nil, 0, err
+	}
+	out := make([]CreditMismatch, len(rows))
+	for i, row := range rows {
+		out[i] = row.CreditMismatch
+	}
+	return out, rows[0].Total, nil
+}
+
+func (r *reconcileRepository) SaveReport(ctx context.Context, report *ReconciliationReport) error {
+	_, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO payment_reconciliation_reports (
+			id, trigger, admin_id, payments_checked, payments_completed, payments_failed, payments_pending,
+			payments_mismatched, payments_errored, credit_mismatch_count, refunds_settled, refunds_failed, refunds_open,
+			payments, credit_mismatches, started_at, finished_at
+		) VALUES (
+			:id, :trigger, :admin_id, :payments_checked, :payments_completed, :payments_failed, :payments_pending,
+			:payments_mismatched, :payments_errored, :credit_mismatch_count, :refunds_settled, :refunds_failed, :refunds_open,
+			:payments, :credit_mismatches, :started_at, :finished_at
+		)`, report)
+	return err
+}
+
+func (r *reconcileRepository) ListReports(ctx context.Context, limit int) ([]*ReconciliationReport, error) {
+	var out []*ReconciliationReport
+	err := r.db.SelectContext(ctx, &out, `
+		SELECT * FROM payment_reconciliation_reports ORDER BY started_at DESC LIMIT $1`, limit)
+	return out, err
+}
+
+func (r *reconcileRepository) GetReport(ctx context.Context, id uuid.UUID) (*ReconciliationReport, error) {
+	var report ReconciliationReport
+	err := r.db.GetContext(ctx, &report, `SELECT * FROM payment_reconciliation_reports WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// SetReconciliation enables payment reconciliation
+func (s *Service) SetReconciliation(repo ReconcileRepository, states PaymentStateClient, cfg ReconcileConfig) {
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = 30 * time.Minute
+	}
+	if cfg.GiveUpAfter <= 0 {
+		cfg.GiveUpAfter = 72 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.MaxMismatch <= 0 {
+		cfg.MaxMismatch = 1000
+	}
+	s.reconcile = repo
+	s.paymentStates = states
+	s.reconcileConfig = cfg
+}
+
+// Reconcile resolves stale pending payments against the provider, settles pending and
+// unknown refunds, cross-checks credit balances with the credit ledger and stores the
+// report. A zero adminID marks a scheduled run. Only one run happens at a time
+// across instances.
+func (s *Service) Reconcile(ctx context.Context, adminID uuid.UUID) (*ReconciliationReport, error) {
+	if s.reconcile == nil || s.paymentStates == nil {
+		return nil, fmt.Errorf("reconciliation is not configured")
+	}
+	unlock, err := s.reconcile.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	now := time.Now()
+	report := &ReconciliationReport{
+		ID:               uuid.New(),
+		Trigger:          ReconcileTriggerScheduled,
+		Payments:         ReconciledPayments{},
+		CreditMismatches: CreditMismatches{},
+		StartedAt:        now,
+	}
+	if adminID != uuid.Nil {
+		report.Trigger = ReconcileTriggerManual
+		report.AdminID = uuid.NullUUID{UUID: adminID, Valid: true}
+	}
+
+	stale, err := s.reconcile.ListStalePending(ctx, now.Add(-s.reconcileConfig.StaleAfter), s.reconcileConfig.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stale {
+		if ctx.Err() != nil {
+			break
+		}
+		row := s.reconcilePayment(ctx, p, now)
+		report.Payments = append(report.Payments, row)
+		report.PaymentsChecked++
+		report.count(row.Action)
+	}
+
+	refunds, err := s.SettleRefunds(ctx, s.reconcileConfig.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	report.RefundsSettled = refunds.Settled
+	report.RefundsFailed = refunds.Failed
+	report.RefundsOpen = refunds.Open
+
+	mismatches, total, err := s.reconcile.CreditMismatches(ctx, s.reconcileConfig.MaxMismatch)
+	if err != nil {
+		return nil, err
+	}
+	if mismatches != nil {
+		report.CreditMismatches = mismatches
+	}
+	report.CreditMismatchCount = total
+
+	report.FinishedAt = time.Now()
+	if err := s.reconcile.SaveReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// reconcilePayment settles one stale payment according to the provider state
+func (s *Service) reconcilePayment(ctx context.Context, p *Payment, now time.Time) ReconciledPayment {
+	row := ReconciledPayment{
+		PaymentID: p.ID,
+		UserID:    p.UserID,
+		InvID:     p.RobokassaInvID.Int64,
+		Amount:    p.Amount,
+		CreatedAt: p.CreatedAt,
+	}
+	fail := func(msg string) {
+		row.Action = ReconcileError
+		row.Error = msg
+		log.Warn().Str("payment_id", p.ID.String()).Str("error", msg).Msg("payment reconciliation failed")
+	}
+
+	state, err := s.paymentStates.OpState(ctx, p.RobokassaInvID.Int64)
+	if err != nil {
+		fail(err.Error())
+		return row
+	}
+	row.ProviderState = state.StateCode
+	row.ProviderSum = state.OutSum
+
+	switch {
+	case state.IsPaid():
+		expected, _ := robokassa.ParseAmount(fmt.Sprintf("%.2f", p.Amount))
+		paid, err := normalizeAmount(state.OutSum)
+		if err != nil || expected == nil || !robokassa.AmountsEqual(expected, paid) {
+			row.Action = ReconcileAmountMismatch
+			return row
+		}
+		if err := s.ConfirmPayment(ctx, p.ID); err != nil {
+			fail(err.Error())
+			return row
+		}
+		if state.OpKey != "" && s.refunds != nil {
+			if err := s.refunds.SaveOpKey(ctx, p.ID, state.OpKey); err != nil {
+				log.Warn().Err(err).Str("payment_id", p.ID.String()).Msg("Failed to save operation key")
+			}
+		}
+		row.Action = ReconcileCompleted
+	case state.IsFailed(),
+		(state.NotFound() || state.StateCode == robokassa.OpStateInitiated) && now.Sub(p.CreatedAt) > s.reconcileConfig.GiveUpAfter:
+		if err := s.FailPayment(ctx, p.ID); err != nil {
+			fail(err.Error())
+			return row
+		}
+		row.Action = ReconcileFailed
+	default:
+		row.Action = ReconcilePending
+	}
+	return row
+}
+
+// ListReconciliationReports returns the latest reports first
+func (s *Service) ListReconciliationReports(ctx context.Context, limit int) ([]*ReconciliationReport, error) {
+	if s.reconcile == nil {
+		return nil, fmt.Errorf("reconciliation is not configured")
+	}
+	return s.reconcile.ListReports(ctx, limit)
+}
+
+// GetReconciliationReport returns one report
+func (s *Service) GetReconciliationReport(ctx context.Context, id uuid.UUID) (*ReconciliationReport, error) {
+	if s.reconcile == nil {
+		return nil, fmt.Errorf("reconciliation is not configured")
+	}
+	return s.reconcile.GetReport(ctx, id)
+}
+
+// ReconcileWorker runs reconciliation periodically
+type ReconcileWorker struct {
+	service  *Service
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewReconcileWorker creates reconciliation worker
+func NewReconcileWorker(service *Service, interval time.Duration) *ReconcileWorker {
+	if interval == 0 {
+		interval = 24 * time.Hour
+	}
+	return &ReconcileWorker{
+		service:  service,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the background worker
+func (w *ReconcileWorker) Start() {
+	log.Info().Msg("Starting payment reconciliation worker...")
+	go w.loop()
+}
+
+// Stop gracefully stops the background worker
+func (w *ReconcileWorker) Stop() {
+	log.Info().Msg("Stopping payment reconciliation worker...")
+	close(w.stopCh)
+}
+
+func (w *ReconcileWorker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run once immediately on startup
+	w.run()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.run()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *ReconcileWorker) run() {
+	// Provider calls are sequential, so allow more time than other workers
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report, err := w.service.Reconcile(ctx, uuid.Nil)
+	if errors.Is(err, ErrReconcileInProgress) {
+		log.Debug().Msg("Payment reconciliation is running on another instance")
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Payment reconciliation failed")
+		return
+	}
+	log.Info().
+		Str("report_id", report.ID.String()).
+		Int("checked", report.PaymentsChecked).
+		Int("completed", report.PaymentsCompleted).
+		Int("failed", report.PaymentsFailed).
+		Int("mismatched", report.PaymentsMismatched).
+		Int("errored", report.PaymentsErrored).
+		Int("credit_mismatches", report.CreditMismatchCount).
+		Int("refunds_settled", report.RefundsSettled).
+		Int("refunds_open", report.RefundsOpen).
+		Msg("Payment reconciliation processed")
+}
+
+func jsonArrayValue(v any, n int) (driver.Value, error) {
+	if n == 0 {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,124 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/admin"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// ReconcileHandler exposes payment and credit reconciliation reports to admins
+type ReconcileHandler struct {
+	service  *Service
+	adminSvc *admin.Service
+}
+
+// NewReconcileHandler creates reconciliation admin handler
+func NewReconcileHandler(service *Service, adminSvc *admin.Service) *ReconcileHandler {
+	return &ReconcileHandler{service: service, adminSvc: adminSvc}
+}
+
+// AdminRoutes returns admin routes for reconciliation
+func (h *ReconcileHandler) AdminRoutes(jwtSvc *admin.JWTService, adminSvc *admin.Service) chi.Router {
+	r := chi.NewRouter()
+	r.Use(admin.AuthMiddleware(jwtSvc, adminSvc))
+	r.Use(admin.RequirePermission(admin.PermReconcileCredits))
+
+	r.Get("/", h.List)
+	r.Post("/run", h.Run)
+	r.Get("/{id}", h.Get)
+
+	return r
+}
+
+// List handles GET /admin/reconciliation
+// @Summary Отчеты сверки платежей и кредитов
+// @Tags Admin Reconciliation
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Количество отчетов (по умолчанию 20, максимум 100)"
+// @Success 200 {object} response.Response{data=[]ReconciliationReport}
+// @Failure 401,403,500 {object} response.Response
+// @Router /admin/reconciliation [get]
+func (h *ReconcileHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+	reports, err := h.service.ListReconciliationReports(r.Context(), limit)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if reports == nil {
+		reports = []*ReconciliationReport{}
+	}
+	response.OK(w, reports)
+}
+
+// Get handles GET /admin/reconciliation/{id}
+// @Summary Отчет сверки
+// @Tags Admin Reconciliation
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID отчета"
+// @Success 200 {object} response.Response{data=ReconciliationReport}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /admin/reconciliation/{id} [get]
+func (h *ReconcileHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid report ID")
+		return
+	}
+	report, err := h.service.GetReconciliationReport(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	response.OK(w, report)
+}
+
+// Run handles POST /admin/reconciliation/run
+// @Summary Запустить сверку
+// @Description Проверяет зависшие pending-платежи в Robokassa (OpState), завершает или отклоняет их и сверяет балансы кредитов с журналом транзакций
+// @Tags Admin Reconciliation
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=ReconciliationReport}
+// @Failure 401,403,409,500 {object} response.Response
+// @Router /admin/reconciliation/run [post]
+func (h *ReconcileHandler) Run(w http.ResponseWriter, r *http.Request) {
+	adminID := admin.GetAdminID(r.Context())
+	report, err := h.service.Reconcile(r.Context(), adminID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), adminID, "payments.reconcile", "reconciliation_report", report.ID, "", nil, map[string]interface{}{
+		"payments_checked":      report.PaymentsChecked,
+		"payments_completed":    report.PaymentsCompleted,
+		"payments_failed":       report.PaymentsFailed,
+		"credit_mismatch_count": report.CreditMismatchCount,
+	})
+	response.OK(w, report)
+}
+
+func (h *ReconcileHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrReportNotFound):
+		response.NotFound(w, "Report not found")
+	case errors.Is(err, ErrReconcileInProgress):
+		response.Conflict(w, "Reconciliation is already running")
+	default:
+		log.Error().Err(err).Msg("reconciliation request failed")
+		response.InternalError(w)
+	}
+}
@@ -0,0 +1,141 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/pkg/robokassa"
+)
+
+type statusRepo struct {
+	captureRepo
+	payments map[uuid.UUID]*Payment
+}
+
+func (r *statusRepo) GetByID(ctx context.Context, id uuid.UUID) (*Payment, error) {
+	return r.payments[id], nil
+}
+
+func (r *statusRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status Status) error {
+	p := r.payments[id]
+	if p.Status != StatusPending {
+		return ErrPaymentStateChanged
+	}
+	p.Status = status
+	return nil
+}
+
+type reconcileRepoStub struct {
+	stale    []*Payment
+	mismatch []CreditMismatch
+	saved    *ReconciliationReport
+}
+
+func (r *reconcileRepoStub) Lock(ctx context.Context) (func(), error) {
+	return func() {}, nil
+}
+func (r *reconcileRepoStub) ListStalePending(ctx context.Context, createdBefore time.Time, limit int) ([]*Payment, error) {
+	return r.stale, nil
+}
+func (r *reconcileRepoStub) CreditMismatches(ctx context.Context, limit int) ([]CreditMismatch, int, error) {
+	return r.mismatch, len(r.mismatch), nil
+}
+func (r *reconcileRepoStub) SaveReport(ctx context.Context, report *ReconciliationReport) error {
+	r.saved = report
+	return nil
+}
+func (r *reconcileRepoStub) ListReports(ctx context.Context, limit int) ([]*ReconciliationReport, error) {
+	return nil, nil
+}
+func (r *reconcileRepoStub) GetReport(ctx context.Context, id uuid.UUID) (*ReconciliationReport, error) {
+	return nil, ErrReportNotFound
+}
+
+type stateStub map[int64]*robokassa.OpStateResponse
+
+func (s stateStub) OpState(ctx context.Context, invID int64) (*robokassa.OpStateResponse, error) {
+	if st, ok := s[invID]; ok {
+		return st, nil
+	}
+	return nil, errors.New("timeout")
+}
+
+func pendingPayment(invID int64, amount float64, age time.Duration) *Payment {
+	return &Payment{
+		ID:             uuid.New(),
+		UserID:         uuid.New(),
+		Amount:         amount,
+		Status:         StatusPending,
+		RobokassaInvID: sql.NullInt64{Int64: invID, Valid: true},
+		CreatedAt:      time.Now().Add(-age),
+	}
+}
+
+func TestReconcile_ResolvesStalePayments(t *testing.T) {
+	paid := pendingPayment(1, 990, time.Hour)
+	cancelled := pendingPayment(2, 500, time.Hour)
+	abandoned := pendingPayment(3, 500, 100*time.Hour)
+	waiting := pendingPayment(4, 500, time.Hour)
+	wrongSum := pendingPayment(5, 990, time.Hour)
+	unreachable := pendingPayment(6, 100, time.Hour)
+	all := []*Payment{paid, cancelled, abandoned, waiting, wrongSum, unreachable}
+
+	repo := &statusRepo{payments: map[uuid.UUID]*Payment{}}
+	for _, p := range all {
+		repo.payments[p.ID] = p
+	}
+	rec := &reconcileRepoStub{stale: all, mismatch: []CreditMismatch{{UserID: uuid.New(), StoredBalance: 10, ComputedBalance: 7, Diff: 3}}}
+	states := stateStub{
+		1: {StateCode: robokassa.OpStatePaid, OutSum: "990.00"},
+		2: {StateCode: robokassa.OpStateCancelled},
+		3: {ResultCode: robokassa.OpResultNotFound},
+		4: {ResultCode: robokassa.OpResultNotFound},
+		5: {StateCode: robokassa.OpStatePaid, OutSum: "100.00"},
+	}
+	svc := NewService(repo, nil)
+	svc.SetReconciliation(rec, states, ReconcileConfig{GiveUpAfter: 72 * time.Hour})
+
+	report, err := svc.Reconcile(context.Background(), uuid.Nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.saved != report || report.Trigger != ReconcileTriggerScheduled {
+		t.Fatalf("report not saved as scheduled run")
+	}
+	if report.PaymentsChecked != 6 || report.PaymentsCompleted != 1 || report.PaymentsFailed != 2 ||
+		report.PaymentsPending != 1 || report.PaymentsMismatched != 1 || report.PaymentsErrored != 1 {
+		t.Fatalf("unexpected counts: %+v", report)
+	}
+	if paid.Status != StatusCompleted || cancelled.Status != StatusFailed || abandoned.Status != StatusFailed {
+		t.Fatalf("payments not settled: %s %s %s", paid.Status, cancelled.Status, abandoned.Status)
+	}
+	if waiting.Status != StatusPending || wrongSum.Status != StatusPending {
+		t.Fatal("unsettled payments must stay pending")
+	}
+	if report.CreditMismatchCount != 1 || report.CreditMismatches[0].Diff != 3 {
+		t.Fatalf("credit mismatches missing: %+v", report.CreditMismatches)
+	}
+}
+
+func TestReconcile_ManualRunRecordsAdmin(t *testing.T) {
+	rec := &reconcileRepoStub{}
+	svc := NewService(&statusRepo{}, nil)
+	svc.SetReconciliation(rec, stateStub{}, ReconcileConfig{})
+
+	adminID := uuid.New()
+	report, err := svc.Reconcile(context.Background(), adminID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Trigger != ReconcileTriggerManual || report.AdminID.UUID != adminID {
+		t.Fatalf("unexpected trigger: %+v", report)
+	}
+	if report.Payments == nil || report.CreditMismatches == nil {
+		t.Fatal("empty report lists must serialize as arrays")
+	}
+}
@@ -0,0 +1,554 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/credit"
+	paymentprovider "github.com/mwork/mwork-api/internal/pkg/payment"
+	"github.com/mwork/mwork-api/internal/pkg/robokassa"
+)
+
+// RefundStatus represents the state of a refund
+type RefundStatus string
+
+const (
+	RefundProcessing RefundStatus = "processing" // reserved, provider not answered yet
+	RefundPending    RefundStatus = "pending"    // accepted by the provider, settled asynchronously
+	RefundUnknown    RefundStatus = "unknown"    // provider call failed midway; stays reserved until reconciled
+	RefundCompleted  RefundStatus = "completed"
+	RefundFailed     RefundStatus = "failed"
+)
+
+var (
+	ErrPaymentNotRefundable = errors.New("payment is not refundable")
+	ErrRefundExceedsPaid    = errors.New("refund amount exceeds the refundable amount")
+	ErrInvalidRefundAmount  = errors.New("refund amount must be positive")
+	ErrRefundProvider       = errors.New("payment provider refund failed")
+)
+
+// Refund is a full or partial refund of a payment
+type Refund struct {
+	ID                    uuid.UUID      `db:"id" json:"id"`
+	PaymentID             uuid.UUID      `db:"payment_id" json:"payment_id"`
+	AdminID               uuid.NullUUID  `db:"admin_id" json:"admin_id,omitempty"`
+	Amount                float64        `db:"amount" json:"amount"`
+	Reason                string         `db:"reason" json:"reason"`
+	Status                RefundStatus   `db:"status" json:"status"`
+	Provider              sql.NullString `db:"provider" json:"provider,omitempty"`
+	ProviderRefundID      sql.NullString `db:"provider_refund_id" json:"provider_refund_id,omitempty"`
+	CreditsReversed       int            `db:"credits_reversed" json:"credits_reversed"`
+	SubscriptionCancelled bool           `db:"subscription_cancelled" json:"subscription_cancelled"`
+	Error                 sql.NullString `db:"error" json:"error,omitempty"`
+	CreatedAt             time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt             time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// RefundRepository defines refund data access
+type RefundRepository interface {
+	// Reserve adds the refund to payments.refunded_amount, failing with
+	// ErrRefundExceedsPaid if that would exceed the paid amount, and stores it
+	Reserve(ctx context.Context, refund *Refund) error
+	// Release marks an unsettled refund failed and returns its amount to the
+	// refundable balance; a refund already settled or released is left alone
+	Release(ctx context.Context, refund *Refund) error
+	// ClaimSettlement marks an unsettled refund completed; false means another
+	// run settled or released it first
+	ClaimSettlement(ctx context.Context, id uuid.UUID) (bool, error)
+	// Complete saves the refund state; a completed refund is logged as a payment
+	// event and marks the payment refunded once fully refunded
+	Complete(ctx context.Context, refund *Refund, fullyRefunded bool) error
+	ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*Refund, error)
+	// ListUnsettled returns pending and unknown refunds, oldest first
+	ListUnsettled(ctx context.Context, limit int) ([]*Refund, error)
+	// CompletedAmount sums the completed refunds of a payment
+	CompletedAmount(ctx context.Context, paymentID uuid.UUID) (float64, error)
+	SaveOpKey(ctx context.Context, paymentID uuid.UUID, opKey string) error
+}
+
+type refundRepository struct {
+	db *sqlx.DB
+}
+
+// NewRefundRepository creates refund repository
+func NewRefundRepository(db *sqlx.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) Reserve(ctx context.Context, refund *Refund) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE payments SET refunded_amount = refunded_amount + $2, updated_at = NOW()
+		WHERE id = $1 AND status IN ('completed', 'paid') AND refunded_amount + $2 <= amount`,
+		refund.PaymentID, refund.Amount)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRefundExceedsPaid
+	}
+
+	err = tx.QueryRowxContext(ctx, `
+		INSERT INTO payment_refunds (id, payment_id, admin_id, amount, reason, status, provider)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at`,
+		refund.ID, refund.PaymentID, refund.AdminID, refund.Amount, refund.Reason, refund.Status, refund.Provider,
+	).Scan(&refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *refundRepository) Release(ctx context.Context, refund *Refund) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE payment_refunds SET status = 'failed', error = $2, updated_at = NOW()
+		WHERE id = $1 AND status IN ('processing', 'pending', 'unknown')`, refund.ID, refund.Error)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE payments SET refunded_amount = GREATEST(refunded_amount - $2, 0), updated_at = NOW()
+		WHERE id = $1`, refund.PaymentID, refund.Amount); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *refundRepository) ClaimSettlement(ctx context.Context, id uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE payment_refunds SET status = 'completed', updated_at = NOW()
+		WHERE id = $1 AND status IN ('processing', 'pending', 'unknown')`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *refundRepository) Complete(ctx context.Context, refund *Refund, fullyRefunded bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE payment_refunds SET
+			status = $2, provider_refund_id = $3, credits_reversed = $4,
+			subscription_cancelled = $5, error = $6, updated_at = NOW()
+		WHERE id = $1`,
+		refund.ID, refund.Status, refund.ProviderRefundID, refund.CreditsReversed,
+		refund.SubscriptionCancelled, refund.Error); err != nil {
+		return err
+	}
+	if refund.Status != RefundCompleted {
+		return tx.Commit()
+	}
+	if fullyRefunded {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE payments SET status = 'refunded', refunded_at = NOW(), updated_at = NOW()
+			WHERE id = $1`, refund.PaymentID); err != nil {
+			return err
+		}
+	}
+	payload, err := json.Marshal(refund)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO payment_events (payment_id, event_type, payload, created_at)
+		VALUES ($1, 'refund', $2, NOW())`, refund.PaymentID, payload); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *refundRepository) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*Refund, error) {
+	var refunds []*Refund
+	err := r.db.SelectContext(ctx, &refunds, `
+		SELECT * FROM payment_refunds WHERE payment_id = $1 ORDER BY created_at DESC`, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *refundRepository) ListUnsettled(ctx context.Context, limit int) ([]*Refund, error) {
+	var refunds []*Refund
+	err := r.db.SelectContext(ctx, &refunds, `
+		SELECT * FROM payment_refunds WHERE status IN ('pending', 'unknown')
+		ORDER BY created_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *refundRepository) CompletedAmount(ctx context.Context, paymentID uuid.UUID) (float64, error) {
+	var amount float64
+	err := r.db.GetContext(ctx, &amount, `
+		SELECT COALESCE(SUM(amount), 0) FROM payment_refunds
+		WHERE payment_id = $1 AND status = 'completed'`, paymentID)
+	return amount, err
+}
+
+func (r *refundRepository) SaveOpKey(ctx context.Context, paymentID uuid.UUID, opKey string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE payments SET op_key = $2, updated_at = NOW() WHERE id = $1`, paymentID, opKey)
+	return err
+}
+
+// SetRefunds wires refund storage and the providers refunds are sent through
+func (s *Service) SetRefunds(refunds RefundRepository, providers *paymentprovider.ProviderFactory) {
+	s.refunds = refunds
+	s.providers = providers
+}
+
+// RefundPayment refunds a paid payment in full (amount 0) or in part. The amount is
+// reserved first so concurrent refunds can't exceed the payment, then sent to the
+// provider. The reservation is released only if the provider rejects the refund;
+// when its answer is lost the refund stays reserved as unknown until reconciled.
+// Granted credits are reversed pro rata, and a fully refunded subscription payment
+// cancels its subscription, once the provider confirms the refund.
+func (s *Service) RefundPayment(ctx context.Context, paymentID, adminID uuid.UUID, amount float64, reason string) (*Refund, error) {
+	if s.refunds == nil || s.providers == nil {
+		return nil, fmt.Errorf("refunds are not configured")
+	}
+	payment, err := s.repo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, ErrPaymentNotFound
+	}
+	if !payment.IsPaid() {
+		return nil, ErrPaymentNotRefundable
+	}
+
+	remaining := roundAmount(payment.Amount - payment.RefundedAmount)
+	if amount == 0 {
+		amount = remaining
+	}
+	amount = roundAmount(amount)
+	if amount <= 0 {
+		return nil, ErrInvalidRefundAmount
+	}
+	if amount > remaining {
+		return nil, ErrRefundExceedsPaid
+	}
+
+	providerName := payment.Provider.String
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentNotRefundable, err)
+	}
+	opKey, err := s.operationKey(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &Refund{
+		ID:        uuid.New(),
+		PaymentID: payment.ID,
+		AdminID:   uuid.NullUUID{UUID: adminID, Valid: adminID != uuid.Nil},
+		Amount:    amount,
+		Reason:    reason,
+		Status:    RefundProcessing,
+		Provider:  payment.Provider,
+	}
+	if err := s.refunds.Reserve(ctx, refund); err != nil {
+		return nil, err
+	}
+
+	resp, err := provider.Refund(ctx, paymentprovider.RefundRequest{
+		PaymentID:  payment.ID.String(),
+		InvoiceID:  payment.RobokassaInvID.Int64,
+		ExternalID: opKey,
+		Amount:     amount,
+		Reason:     reason,
+	})
+	if err != nil {
+		refund.Error = sql.NullString{String: err.Error(), Valid: true}
+		if errors.Is(err, paymentprovider.ErrRefundRejected) {
+			refund.Status = RefundFailed
+			if releaseErr := s.refunds.Release(ctx, refund); releaseErr != nil {
+				log.Error().Err(releaseErr).Str("refund_id", refund.ID.String()).Msg("Failed to release refund reservation")
+			}
+		} else {
+			// The provider may have accepted it; releasing now would allow a second refund
+			refund.Status = RefundUnknown
+			if saveErr := s.refunds.Complete(ctx, refund, false); saveErr != nil {
+				log.Error().Err(saveErr).Str("refund_id", refund.ID.String()).Msg("Failed to save unknown refund")
+			}
+		}
+		return refund, fmt.Errorf("%w: %v", ErrRefundProvider, err)
+	}
+
+	if resp.RefundID != "" {
+		refund.ProviderRefundID = sql.NullString{String: resp.RefundID, Valid: true}
+	}
+	if resp.Status == string(RefundPending) {
+		refund.Status = RefundPending
+		return refund, s.refunds.Complete(ctx, refund, false)
+	}
+	_, err = s.settleRefund(ctx, payment, refund)
+	return refund, err
+}
+
+// settleRefund completes a refund the provider confirmed and takes back what it paid for.
+// The refund is claimed first so that concurrent settlements reverse the grant once;
+// false means it was already settled or released elsewhere.
+func (s *Service) settleRefund(ctx context.Context, payment *Payment, refund *Refund) (bool, error) {
+	claimed, err := s.refunds.ClaimSettlement(ctx, refund.ID)
+	if err != nil || !claimed {
+		return false, err
+	}
+	refund.Status = RefundCompleted
+	completed, err := s.refunds.CompletedAmount(ctx, payment.ID)
+	if err != nil {
+		return true, err
+	}
+	refundedBefore := roundAmount(completed - refund.Amount)
+	fully := roundAmount(completed) >= roundAmount(payment.Amount)
+	s.reverseGrant(ctx, payment, refund, refundedBefore, fully)
+	return true, s.refunds.Complete(ctx, refund, fully)
+}
+
+// RefundSettlement counts what SettleRefunds did
+type RefundSettlement struct {
+	Settled int // confirmed by the provider
+	Failed  int // rejected, amount released
+	Open    int // still pending or unknown
+}
+
+// SettleRefunds checks pending and unknown refunds with the provider. Confirmed refunds
+// reverse what the payment granted; rejected ones return the amount to the refundable
+// balance. An unknown refund without a provider ID is confirmed only once OpState shows
+// the payment refunded; until then it stays reserved for manual review.
+func (s *Service) SettleRefunds(ctx context.Context, limit int) (RefundSettlement, error) {
+	var out RefundSettlement
+	if s.refunds == nil || s.providers == nil {
+		return out, nil
+	}
+	refunds, err := s.refunds.ListUnsettled(ctx, limit)
+	if err != nil {
+		return out, err
+	}
+	for _, refund := range refunds {
+		if ctx.Err() != nil {
+			break
+		}
+		status, err := s.refundStatus(ctx, refund)
+		if err != nil {
+			log.Warn().Err(err).Str("refund_id", refund.ID.String()).Msg("refund settlement check failed")
+			out.Open++
+			continue
+		}
+		switch status {
+		case RefundCompleted:
+			payment, err := s.repo.GetByID(ctx, refund.PaymentID)
+			if err == nil && payment == nil {
+				err = ErrPaymentNotFound
+			}
+			settled := false
+			if err == nil {
+				settled, err = s.settleRefund(ctx, payment, refund)
+			}
+			if err != nil {
+				log.Error().Err(err).Str("refund_id", refund.ID.String()).Msg("Failed to settle refund")
+				out.Open++
+				continue
+			}
+			if settled {
+				out.Settled++
+			}
+		case RefundFailed:
+			refund.Status = RefundFailed
+			refund.Error = sql.NullString{String: "rejected by provider", Valid: true}
+			if err := s.refunds.Release(ctx, refund); err != nil {
+				log.Error().Err(err).Str("refund_id", refund.ID.String()).Msg("Failed to release refund reservation")
+				out.Open++
+				continue
+			}
+			out.Failed++
+		default:
+			out.Open++
+		}
+	}
+	return out, nil
+}
+
+// refundStatus asks the provider how an unsettled refund ended
+func (s *Service) refundStatus(ctx context.Context, refund *Refund) (RefundStatus, error) {
+	if refund.ProviderRefundID.Valid && refund.ProviderRefundID.String != "" {
+		provider, err := s.providers.Get(refund.Provider.String)
+		if err != nil {
+			return "", err
+		}
+		resp, err := provider.RefundStatus(ctx, refund.ProviderRefundID.String)
+		if err != nil {
+			return "", err
+		}
+		return RefundStatus(resp.Status), nil
+	}
+
+	if s.paymentStates == nil {
+		return RefundUnknown, nil
+	}
+	payment, err := s.repo.GetByID(ctx, refund.PaymentID)
+	if err != nil || payment == nil || !payment.RobokassaInvID.Valid {
+		return RefundUnknown, err
+	}
+	state, err := s.paymentStates.OpState(ctx, payment.RobokassaInvID.Int64)
+	if err != nil {
+		return "", err
+	}
+	if state.ResultCode == robokassa.OpResultOK && state.StateCode == robokassa.OpStateRefunded {
+		return RefundCompleted, nil
+	}
+	return RefundUnknown, nil
+}
+
+// ListRefunds returns the refunds of a payment, newest first
+func (s *Service) ListRefunds(ctx context.Context, paymentID uuid.UUID) ([]*Refund, error) {
+	if s.refunds == nil {
+		return nil, nil
+	}
+	return s.refunds.ListByPayment(ctx, paymentID)
+}
+
+// GetPayment returns a payment by ID
+func (s *Service) GetPayment(ctx context.Context, paymentID uuid.UUID) (*Payment, error) {
+	payment, err := s.repo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		return nil, ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+// reverseGrant takes back what the refunded share of the payment granted.
+// Failures are recorded on the refund rather than undoing the provider refund.
+func (s *Service) reverseGrant(ctx context.Context, payment *Payment, refund *Refund, refundedBefore float64, fully bool) {
+	if payment.SubscriptionID.Valid {
+		if !fully {
+			return
+		}
+		if err := s.subSvc.Revoke(ctx, payment.SubscriptionID.UUID, "payment refunded"); err != nil {
+			refund.Error = sql.NullString{String: "subscription: " + err.Error(), Valid: true}
+			return
+		}
+		refund.SubscriptionCancelled = true
+		return
+	}
+
+	if !payment.SKU.Valid || s.creditSvc == nil || s.products == nil {
+		return
+	}
+	product, err := s.products.GetBySKU(ctx, payment.SKU.String)
+	if err != nil || product == nil {
+		refund.Error = sql.NullString{String: fmt.Sprintf("unknown sku %q", payment.SKU.String), Valid: true}
+		return
+	}
+
+	credits := creditsToReverse(product.Quantity, payment.Amount, refundedBefore, refund.Amount, fully)
+	if credits <= 0 {
+		return
+	}
+	// Credits already spent can't be taken back; reverse what is left
+	balance, err := s.creditSvc.GetBalance(ctx, payment.UserID)
+	if err != nil {
+		refund.Error = sql.NullString{String: "credits: " + err.Error(), Valid: true}
+		return
+	}
+	if credits > balance {
+		refund.Error = sql.NullString{String: fmt.Sprintf("credits: only %d of %d could be reversed", balance, credits), Valid: true}
+		credits = balance
+	}
+	if credits <= 0 {
+		return
+	}
+
+	paymentIDStr := payment.ID.String()
+	err = s.creditSvc.Deduct(ctx, payment.UserID, credits, credit.TransactionMeta{
+		RelatedEntityType: "payment_refund",
+		RelatedEntityID:   refund.ID,
+		Description:       fmt.Sprintf("refund of %s package (%s)", product.Kind, product.SKU),
+		PaymentID:         &paymentIDStr,
+	})
+	if err != nil {
+		refund.Error = sql.NullString{String: "credits: " + err.Error(), Valid: true}
+		return
+	}
+	refund.CreditsReversed = credits
+}
+
+// creditsToReverse returns the credits matching a refund of amount on top of
+// refundedBefore, so that partial refunds add up to the whole package
+func creditsToReverse(quantity int, paid, refundedBefore, amount float64, fully bool) int {
+	if paid <= 0 {
+		return 0
+	}
+	before := int(math.Floor(float64(quantity) * refundedBefore / paid))
+	after := quantity
+	if !fully {
+		after = int(math.Floor(float64(quantity) * (refundedBefore + amount) / paid))
+	}
+	return after - before
+}
+
+// operationKey returns the Robokassa operation key of a payment, fetching it with
+// OpState the first time; other providers refund by the payment itself
+func (s *Service) operationKey(ctx context.Context, payment *Payment) (string, error) {
+	if payment.OpKey.Valid && payment.OpKey.String != "" {
+		return payment.OpKey.String, nil
+	}
+	if payment.Provider.String != string(ProviderRobokassa) {
+		return "", nil
+	}
+	if s.paymentStates == nil || !payment.RobokassaInvID.Valid {
+		return "", fmt.Errorf("%w: operation key is unknown", ErrPaymentNotRefundable)
+	}
+	state, err := s.paymentStates.OpState(ctx, payment.RobokassaInvID.Int64)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRefundProvider, err)
+	}
+	if state.OpKey == "" {
+		return "", fmt.Errorf("%w: provider returned no operation key", ErrPaymentNotRefundable)
+	}
+	if err := s.refunds.SaveOpKey(ctx, payment.ID, state.OpKey); err != nil {
+		log.Error().Err(err).Str("payment_id", payment.ID.String()).Msg("Failed to save operation key")
+	}
+	payment.OpKey = sql.NullString{String: state.OpKey, Valid: true}
+	return state.OpKey, nil
+}
+
+func roundAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
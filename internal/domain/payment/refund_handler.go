@@ -0,0 +1,145 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/admin"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// RefundPaymentRequest is the admin payload for a refund. Amount 0 refunds the rest of the payment.
+type RefundPaymentRequest struct {
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+// RefundHandler exposes payment refunds to admins
+type RefundHandler struct {
+	service  *Service
+	adminSvc *admin.Service
+}
+
+// NewRefundHandler creates refund admin handler
+func NewRefundHandler(service *Service, adminSvc *admin.Service) *RefundHandler {
+	return &RefundHandler{service: service, adminSvc: adminSvc}
+}
+
+// AdminRoutes returns admin routes for payment refunds
+func (h *RefundHandler) AdminRoutes(jwtSvc *admin.JWTService, adminSvc *admin.Service) chi.Router {
+	r := chi.NewRouter()
+	r.Use(admin.AuthMiddleware(jwtSvc, adminSvc))
+	r.Use(admin.RequirePermission(admin.PermRefundPayments))
+
+	r.Post("/{id}/refund", h.Refund)
+	r.Get("/{id}/refunds", h.List)
+
+	return r
+}
+
+// Refund handles POST /admin/payments/{id}/refund
+// @Summary Возврат платежа
+// @Description Полный (amount = 0) или частичный возврат через платежного провайдера. После подтверждения провайдером начисленные кредиты списываются пропорционально, подписка отменяется при полном возврате.
+// @Tags Admin Payments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID платежа"
+// @Param request body RefundPaymentRequest true "Сумма и причина"
+// @Success 200 {object} response.Response{data=Refund}
+// @Failure 400,401,403,404,409,500 {object} response.Response
+// @Failure 502 {object} response.Response
+// @Router /admin/payments/{id}/refund [post]
+func (h *RefundHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid payment ID")
+		return
+	}
+	var req RefundPaymentRequest
+	if err := response.DecodeJSON(r.Body, &req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		response.BadRequest(w, "reason is required")
+		return
+	}
+	if req.Amount < 0 {
+		response.BadRequest(w, ErrInvalidRefundAmount.Error())
+		return
+	}
+
+	adminID := admin.GetAdminID(r.Context())
+	before, err := h.service.GetPayment(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	refund, err := h.service.RefundPayment(r.Context(), id, adminID, req.Amount, req.Reason)
+	if err != nil {
+		// Failed refunds are released; unknown ones stay reserved until reconciliation settles them
+		if refund != nil && (refund.Status == RefundFailed || refund.Status == RefundUnknown) {
+			h.adminSvc.LogActionWithReason(r.Context(), adminID, "payment.refund_"+string(refund.Status), "payment", id, req.Reason, nil, refund)
+		}
+		h.writeError(w, err)
+		return
+	}
+
+	h.adminSvc.LogActionWithReason(r.Context(), adminID, "payment.refund", "payment", id, req.Reason,
+		map[string]interface{}{"status": before.Status, "refunded_amount": before.RefundedAmount}, refund)
+	response.OK(w, refund)
+}
+
+// List handles GET /admin/payments/{id}/refunds
+// @Summary Возвраты по платежу
+// @Tags Admin Payments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID платежа"
+// @Success 200 {object} response.Response{data=[]Refund}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /admin/payments/{id}/refunds [get]
+func (h *RefundHandler) List(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "Invalid payment ID")
+		return
+	}
+	if _, err := h.service.GetPayment(r.Context(), id); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	refunds, err := h.service.ListRefunds(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if refunds == nil {
+		refunds = []*Refund{}
+	}
+	response.OK(w, refunds)
+}
+
+func (h *RefundHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrPaymentNotFound):
+		response.NotFound(w, "Payment not found")
+	case errors.Is(err, ErrInvalidRefundAmount):
+		response.BadRequest(w, err.Error())
+	case errors.Is(err, ErrPaymentNotRefundable), errors.Is(err, ErrRefundExceedsPaid):
+		response.Conflict(w, err.Error())
+	case errors.Is(err, ErrRefundProvider):
+		response.Error(w, http.StatusBadGateway, "GATEWAY_ERROR", err.Error())
+	default:
+		log.Error().Err(err).Msg("payment refund failed")
+		response.InternalError(w)
+	}
+}
@@ -0,0 +1,265 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/domain/credit"
+	paymentprovider "github.com/mwork/mwork-api/internal/pkg/payment"
+)
+
+type refundPaymentRepo struct {
+	captureRepo
+	payment *Payment
+}
+
+func (r *refundPaymentRepo) GetByID(ctx context.Context, id uuid.UUID) (*Payment, error) {
+	return r.payment, nil
+}
+
+type refundRepoStub struct {
+	reserved  []*Refund
+	released  []*Refund
+	saved     []*Refund // not yet completed: pending or unknown
+	completed []*Refund
+	fully     bool
+}
+
+func (r *refundRepoStub) Reserve(ctx context.Context, refund *Refund) error {
+	r.reserved = append(r.reserved, refund)
+	return nil
+}
+func (r *refundRepoStub) Release(ctx context.Context, refund *Refund) error {
+	r.released = append(r.released, refund)
+	return nil
+}
+func (r *refundRepoStub) ClaimSettlement(ctx context.Context, id uuid.UUID) (bool, error) {
+	for _, refund := range r.completed {
+		if refund.ID == id {
+			return false, nil
+		}
+	}
+	for _, refund := range r.reserved {
+		if refund.ID == id {
+			r.completed = append(r.completed, refund)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+func (r *refundRepoStub) Complete(ctx context.Context, refund *Refund, fully bool) error {
+	if refund.Status != RefundCompleted {
+		r.saved = append(r.saved, refund)
+		return nil
+	}
+	r.fully = fully
+	return nil
+}
+func (r *refundRepoStub) ListByPayment(ctx context.Context, paymentID uuid.UUID) ([]*Refund, error) {
+	return nil, nil
+}
+func (r *refundRepoStub) ListUnsettled(ctx context.Context, limit int) ([]*Refund, error) {
+	var out []*Refund
+	for _, refund := range r.saved {
+		if refund.Status == RefundPending || refund.Status == RefundUnknown {
+			out = append(out, refund)
+		}
+	}
+	return out, nil
+}
+func (r *refundRepoStub) CompletedAmount(ctx context.Context, paymentID uuid.UUID) (float64, error) {
+	var sum float64
+	for _, refund := range r.completed {
+		sum += refund.Amount
+	}
+	return sum, nil
+}
+func (r *refundRepoStub) SaveOpKey(ctx context.Context, paymentID uuid.UUID, opKey string) error {
+	return nil
+}
+
+type creditStub struct {
+	credit.Service
+	balance  int
+	deducted int
+}
+
+func (c *creditStub) GetBalance(ctx context.Context, userID uuid.UUID) (int, error) {
+	return c.balance, nil
+}
+func (c *creditStub) Deduct(ctx context.Context, userID uuid.UUID, amount int, meta credit.TransactionMeta) error {
+	c.deducted += amount
+	c.balance -= amount
+	return nil
+}
+
+func newRefundService(p *Payment, balance int) (*Service, *refundRepoStub, *creditStub, *paymentprovider.FakeProvider) {
+	svc := NewService(&refundPaymentRepo{payment: p}, nil)
+	svc.SetProductRepository(&productStub{items: []*Product{{SKU: "credits_10", Kind: ProductKindCredits, Quantity: 10, Price: 1000}}})
+	credits := &creditStub{balance: balance}
+	svc.SetCreditService(credits)
+
+	refunds := &refundRepoStub{}
+	fake := paymentprovider.NewFakeProvider()
+	providers := paymentprovider.NewProviderFactory()
+	providers.Register(string(ProviderRobokassa), fake)
+	svc.SetRefunds(refunds, providers)
+	return svc, refunds, credits, fake
+}
+
+func paidCreditPayment() *Payment {
+	return &Payment{
+		ID:       uuid.New(),
+		UserID:   uuid.New(),
+		SKU:      sql.NullString{String: "credits_10", Valid: true},
+		Amount:   1000,
+		Status:   StatusCompleted,
+		Provider: sql.NullString{String: string(ProviderRobokassa), Valid: true},
+		OpKey:    sql.NullString{String: "op-key", Valid: true},
+	}
+}
+
+func TestRefundPayment_PartialReversesCreditsProRata(t *testing.T) {
+	p := paidCreditPayment()
+	svc, refunds, credits, fake := newRefundService(p, 10)
+
+	refund, err := svc.RefundPayment(context.Background(), p.ID, uuid.New(), 500, "duplicate charge")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refund.Status != RefundCompleted || refund.CreditsReversed != 5 || credits.deducted != 5 {
+		t.Fatalf("unexpected refund: %+v, deducted %d", refund, credits.deducted)
+	}
+	if len(fake.Refunds()) != 1 || fake.Refunds()[0].Amount != 500 || fake.Refunds()[0].ExternalID != "op-key" {
+		t.Fatalf("provider refund not sent: %+v", fake.Refunds())
+	}
+	if len(refunds.completed) != 1 || refunds.fully {
+		t.Fatalf("expected partial completion, got fully=%v", refunds.fully)
+	}
+}
+
+func TestRefundPayment_FullCapsReversalAtBalance(t *testing.T) {
+	p := paidCreditPayment()
+	svc, refunds, credits, _ := newRefundService(p, 3)
+
+	refund, err := svc.RefundPayment(context.Background(), p.ID, uuid.New(), 0, "customer request")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refund.Amount != 1000 || !refunds.fully {
+		t.Fatalf("expected full refund, got %+v", refund)
+	}
+	if credits.deducted != 3 || !refund.Error.Valid {
+		t.Fatalf("expected reversal capped at balance with a note, got %+v", refund)
+	}
+}
+
+func TestRefundPayment_RejectionReleasesReservation(t *testing.T) {
+	p := paidCreditPayment()
+	svc, refunds, credits, fake := newRefundService(p, 10)
+	fake.RefundErr = fmt.Errorf("%w: not enough funds", paymentprovider.ErrRefundRejected)
+
+	refund, err := svc.RefundPayment(context.Background(), p.ID, uuid.New(), 100, "test")
+	if !errors.Is(err, ErrRefundProvider) {
+		t.Fatalf("expected ErrRefundProvider, got %v", err)
+	}
+	if refund.Status != RefundFailed || len(refunds.released) != 1 || len(refunds.completed) != 0 {
+		t.Fatalf("reservation not released: %+v", refunds)
+	}
+	if credits.deducted != 0 {
+		t.Fatalf("credits must not be reversed on failure")
+	}
+}
+
+func TestRefundPayment_ProviderErrorKeepsReservation(t *testing.T) {
+	p := paidCreditPayment()
+	svc, refunds, credits, fake := newRefundService(p, 10)
+	fake.RefundErr = errors.New("gateway timeout")
+
+	refund, err := svc.RefundPayment(context.Background(), p.ID, uuid.New(), 100, "test")
+	if !errors.Is(err, ErrRefundProvider) {
+		t.Fatalf("expected ErrRefundProvider, got %v", err)
+	}
+	if refund.Status != RefundUnknown || len(refunds.released) != 0 || len(refunds.saved) != 1 {
+		t.Fatalf("unknown refund must stay reserved: %+v", refunds)
+	}
+	if credits.deducted != 0 {
+		t.Fatalf("credits must not be reversed before the refund is confirmed")
+	}
+}
+
+func TestRefundPayment_PendingReversesOnSettlement(t *testing.T) {
+	p := paidCreditPayment()
+	svc, refunds, credits, fake := newRefundService(p, 10)
+	fake.RefundPending = true
+
+	refund, err := svc.RefundPayment(context.Background(), p.ID, uuid.New(), 0, "customer request")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refund.Status != RefundPending || credits.deducted != 0 || len(refunds.completed) != 0 {
+		t.Fatalf("pending refund must not reverse credits yet: %+v, deducted %d", refund, credits.deducted)
+	}
+
+	fake.RefundState = "pending"
+	if got, err := svc.SettleRefunds(context.Background(), 10); err != nil || got.Open != 1 {
+		t.Fatalf("expected the refund to stay open, got %+v, %v", got, err)
+	}
+
+	fake.RefundState = "completed"
+	got, err := svc.SettleRefunds(context.Background(), 10)
+	if err != nil || got.Settled != 1 {
+		t.Fatalf("expected the refund to settle, got %+v, %v", got, err)
+	}
+	if refund.Status != RefundCompleted || credits.deducted != 10 || !refunds.fully {
+		t.Fatalf("settlement should reverse the package: %+v, deducted %d", refund, credits.deducted)
+	}
+}
+
+func TestSettleRefund_ReversesOnce(t *testing.T) {
+	p := paidCreditPayment()
+	svc, refunds, credits, fake := newRefundService(p, 10)
+	fake.RefundPending = true
+
+	refund, err := svc.RefundPayment(context.Background(), p.ID, uuid.New(), 0, "customer request")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Two instances confirming the same refund
+	for i := 0; i < 2; i++ {
+		if _, err := svc.settleRefund(context.Background(), p, refund); err != nil {
+			t.Fatalf("settle: %v", err)
+		}
+	}
+	if credits.deducted != 10 || len(refunds.completed) != 1 {
+		t.Fatalf("expected one reversal, deducted %d over %d settlements", credits.deducted, len(refunds.completed))
+	}
+}
+
+func TestRefundPayment_RejectsExcessAndUnpaid(t *testing.T) {
+	p := paidCreditPayment()
+	p.RefundedAmount = 900
+	svc, _, _, _ := newRefundService(p, 10)
+	if _, err := svc.RefundPayment(context.Background(), p.ID, uuid.Nil, 200, "x"); !errors.Is(err, ErrRefundExceedsPaid) {
+		t.Fatalf("expected ErrRefundExceedsPaid, got %v", err)
+	}
+
+	p.Status = StatusPending
+	if _, err := svc.RefundPayment(context.Background(), p.ID, uuid.Nil, 0, "x"); !errors.Is(err, ErrPaymentNotRefundable) {
+		t.Fatalf("expected ErrPaymentNotRefundable, got %v", err)
+	}
+}
+
+func TestCreditsToReverse_PartialsAddUp(t *testing.T) {
+	total := creditsToReverse(25, 2000, 0, 700, false) +
+		creditsToReverse(25, 2000, 700, 700, false) +
+		creditsToReverse(25, 2000, 1400, 600, true)
+	if total != 25 {
+		t.Fatalf("partial reversals should add up to the package, got %d", total)
+	}
+}
@@ -0,0 +1,392 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/subscription"
+	"github.com/mwork/mwork-api/internal/pkg/robokassa"
+)
+
+// PaymentTypeRenewal marks an auto-renewal charge of an existing subscription
+const PaymentTypeRenewal = "subscription_renewal"
+
+// RecurringCharger charges the card saved by a recurring parent payment
+type RecurringCharger interface {
+	Recurring(ctx context.Context, req robokassa.RecurringRequest) error
+}
+
+// RenewalSubscriptions is the subscription side of auto-renewal
+type RenewalSubscriptions interface {
+	ListDueForRenewal(ctx context.Context, lead time.Duration, limit int) ([]*subscription.Subscription, error)
+	GetPlan(ctx context.Context, planID subscription.PlanID) (*subscription.Plan, error)
+	RecordRenewalFailure(ctx context.Context, subscriptionID uuid.UUID, retryIntervals []time.Duration, reason string) (*subscription.Subscription, error)
+}
+
+// RenewalNotifier tells users about auto-renewal charges (dunning)
+type RenewalNotifier interface {
+	NotifySubscriptionRenewed(ctx context.Context, userID uuid.UUID, planName string, expiresAt time.Time)
+	// A zero retryAt means auto-renewal was turned off after the last attempt
+	NotifyRenewalFailed(ctx context.Context, userID uuid.UUID, planName string, retryAt time.Time)
+}
+
+// RenewalConfig controls auto-renewal charges
+type RenewalConfig struct {
+	Lead           time.Duration   // charge this long before expires_at
+	RetryIntervals []time.Duration // waits after each failed attempt; attempts = len+1
+	ConfirmTimeout time.Duration   // charges without a callback are checked with the provider after this
+	BatchSize      int
+}
+
+// RenewalRepository defines auto-renewal payment queries
+type RenewalRepository interface {
+	ListPendingRenewals(ctx context.Context, olderThan time.Duration, limit int) ([]*Payment, error)
+	HasPendingRenewal(ctx context.Context, subscriptionID uuid.UUID) (bool, error)
+}
+
+type renewalRepository struct {
+	db *sqlx.DB
+}
+
+// NewRenewalRepository creates auto-renewal repository
+func NewRenewalRepository(db *sqlx.DB) RenewalRepository {
+	return &renewalRepository{db: db}
+}
+
+func (r *renewalRepository) ListPendingRenewals(ctx context.Context, olderThan time.Duration, limit int) ([]*Payment, error) {
+	query := `
+		SELECT * FROM payments
+		WHERE type = $1 AND status = 'pending' AND robokassa_inv_id IS NOT NULL
+			AND created_at < NOW() - make_interval(secs => $2)
+		ORDER BY created_at
+		LIMIT $3`
+	var payments []*Payment
+	if err := r.db.SelectContext(ctx, &payments, query, PaymentTypeRenewal, olderThan.Seconds(), limit); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *renewalRepository) HasPendingRenewal(ctx context.Context, subscriptionID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `
+		SELECT EXISTS (
+			SELECT 1 FROM payments WHERE subscription_id = $1 AND type = $2 AND status = 'pending'
+		)`, subscriptionID, PaymentTypeRenewal)
+	return exists, err
+}
+
+// ParseRetryIntervals parses "24h,72h" into retry waits, skipping invalid entries
+func ParseRetryIntervals(raw string) []time.Duration {
+	var out []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// SetRenewals enables auto-renewal charges
+func (s *Service) SetRenewals(repo RenewalRepository, subs RenewalSubscriptions, charger RecurringCharger, notifier RenewalNotifier, cfg RenewalConfig) {
+	if cfg.Lead <= 0 {
+		cfg.Lead = 24 * time.Hour
+	}
+	if cfg.ConfirmTimeout <= 0 {
+		cfg.ConfirmTimeout = time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 200
+	}
+	s.renewals = repo
+	s.renewalSubs = subs
+	s.recurring = charger
+	s.renewalNotifier = notifier
+	s.renewalConfig = cfg
+}
+
+// RenewalResult summarises one renewal pass
+type RenewalResult struct {
+	Charged   int // charges accepted by the provider, awaiting the result callback
+	Failed    int
+	Confirmed int // charges settled via the provider state check
+}
+
+// RunRenewals settles renewal charges that never got a callback and charges
+// the saved cards of subscriptions due for renewal
+func (s *Service) RunRenewals(ctx context.Context) (*RenewalResult, error) {
+	if s.renewals == nil || s.renewalSubs == nil || s.recurring == nil {
+		return nil, fmt.Errorf("auto-renewal is not configured")
+	}
+	if s.robokassaErr != nil {
+		return nil, s.robokassaErr
+	}
+	result := &RenewalResult{}
+
+	stale, err := s.renewals.ListPendingRenewals(ctx, s.renewalConfig.ConfirmTimeout, s.renewalConfig.BatchSize)
+	if err != nil {
+		return result, err
+	}
+	for _, p := range stale {
+		s.settleRenewal(ctx, p, result)
+	}
+
+	due, err := s.renewalSubs.ListDueForRenewal(ctx, s.renewalConfig.Lead, s.renewalConfig.BatchSize)
+	if err != nil {
+		return result, err
+	}
+	for _, sub := range due {
+		if pending, err := s.renewals.HasPendingRenewal(ctx, sub.ID); err != nil || pending {
+			continue
+		}
+		if err := s.chargeRenewal(ctx, sub); err != nil {
+			// Another instance claimed this renewal between the check and the insert
+			if errors.Is(err, ErrRenewalInProgress) {
+				continue
+			}
+			log.Warn().Err(err).Str("subscription_id", sub.ID.String()).Msg("subscription renewal charge failed")
+			result.Failed++
+			continue
+		}
+		result.Charged++
+	}
+	return result, nil
+}
+
+// settleRenewal resolves a renewal charge whose result callback never arrived.
+// Without a provider state client the nightly reconciliation settles it instead.
+func (s *Service) settleRenewal(ctx context.Context, p *Payment, result *RenewalResult) {
+	if s.paymentStates == nil {
+		return
+	}
+	state, err := s.paymentStates.OpState(ctx, p.RobokassaInvID.Int64)
+	if err != nil {
+		log.Warn().Err(err).Str("payment_id", p.ID.String()).Msg("renewal state check failed")
+		return
+	}
+	switch {
+	case state.IsPaid():
+		expected, _ := robokassa.ParseAmount(fmt.Sprintf("%.2f", p.Amount))
+		paid, err := normalizeAmount(state.OutSum)
+		if err != nil || expected == nil || !robokassa.AmountsEqual(expected, paid) {
+			log.Warn().Str("payment_id", p.ID.String()).Str("out_sum", state.OutSum).Msg("renewal amount mismatch, left for reconciliation")
+			return
+		}
+		if err := s.ConfirmPayment(ctx, p.ID); err == nil {
+			result.Confirmed++
+		}
+	case state.IsFailed(), state.NotFound():
+		if err := s.FailPayment(ctx, p.ID); err == nil {
+			result.Failed++
+		}
+	}
+}
+
+// chargeRenewal creates a pending renewal payment and submits the recurring charge.
+// The subscription is extended when the result callback confirms the payment.
+func (s *Service) chargeRenewal(ctx context.Context, sub *subscription.Subscription) error {
+	plan, err := s.renewalSubs.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		s.renewalFailed(ctx, sub.ID, sub.UserID, string(sub.PlanID), "plan unavailable")
+		return err
+	}
+	price, err := subscription.PlanPrice(plan, sub.BillingPeriod)
+	if err != nil || price <= 0 {
+		s.renewalFailed(ctx, sub.ID, sub.UserID, plan.Name, "no price for billing period")
+		return fmt.Errorf("plan %s has no %s price", plan.ID, sub.BillingPeriod)
+	}
+
+	invID, err := s.repo.NextRobokassaInvID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate invoice id: %w", err)
+	}
+	amount, err := normalizeAmount(fmt.Sprintf("%.2f", price))
+	if err != nil {
+		return fmt.Errorf("invalid amount")
+	}
+	outSum := amount.FloatString(2)
+	receipt, err := s.robokassaReceipt(planItemName(string(sub.PlanID)), ratToFloat64(amount))
+	if err != nil {
+		return err
+	}
+	shp := buildRobokassaShp(sub.UserID, invID)
+	initPayload := map[string]string{"OutSum": outSum, "InvId": invIDString(invID), "PreviousInvoiceID": invIDString(sub.RecurringInvID.Int64), "Shp_user": shp["Shp_user"], "Shp_nonce": shp["Shp_nonce"]}
+	if receipt != "" {
+		initPayload["Receipt"] = receipt
+	}
+	rawInit, err := json.Marshal(initPayload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal init payload: %w", err)
+	}
+
+	description := "MWork " + string(sub.PlanID) + " subscription renewal"
+	payment := &Payment{
+		ID:             uuid.New(),
+		UserID:         sub.UserID,
+		SubscriptionID: uuid.NullUUID{UUID: sub.ID, Valid: true},
+		Type:           PaymentTypeRenewal,
+		Plan:           sql.NullString{String: string(sub.PlanID), Valid: true},
+		Amount:         ratToFloat64(amount),
+		Currency:       "KZT",
+		Status:         StatusPending,
+		InvID:          sql.NullString{String: invIDString(invID), Valid: true},
+		Provider:       sql.NullString{String: "robokassa", Valid: true},
+		ExternalID:     sql.NullString{String: invIDString(invID), Valid: true},
+		RobokassaInvID: sql.NullInt64{Int64: invID, Valid: true},
+		ParentInvID:    sub.RecurringInvID,
+		Description:    sql.NullString{String: description, Valid: true},
+		RawInitPayload: rawInit,
+		Metadata:       JSONRawMessage(rawInit),
+	}
+	if err := s.repo.CreateRobokassaPending(ctx, payment); err != nil {
+		return err
+	}
+
+	err = s.recurring.Recurring(ctx, robokassa.RecurringRequest{
+		InvID:         invID,
+		PreviousInvID: sub.RecurringInvID.Int64,
+		OutSum:        outSum,
+		Description:   description,
+		Receipt:       receipt,
+		Shp:           shp,
+	})
+	if err != nil {
+		// Rejected up front: no result callback will follow
+		if failErr := s.FailPayment(ctx, payment.ID); failErr != nil {
+			log.Error().Err(failErr).Str("payment_id", payment.ID.String()).Msg("failed to mark renewal payment failed")
+		}
+		return err
+	}
+	log.Info().Str("subscription_id", sub.ID.String()).Int64("inv_id", invID).Msg("subscription renewal charge submitted")
+	return nil
+}
+
+// fulfilSubscription applies a paid subscription payment: renewals extend the period,
+// other payments activate the subscription and, when flagged recurring, save the card.
+func (s *Service) fulfilSubscription(ctx context.Context, payment *Payment) error {
+	if payment.Type == PaymentTypeRenewal {
+		sub, err := s.subSvc.RenewSubscription(ctx, payment.SubscriptionID.UUID)
+		if err != nil {
+			return err
+		}
+		if s.renewalNotifier != nil && sub.ExpiresAt.Valid {
+			s.renewalNotifier.NotifySubscriptionRenewed(ctx, sub.UserID, s.renewalPlanName(ctx, sub.PlanID), sub.ExpiresAt.Time)
+		}
+		return nil
+	}
+	if err := s.subSvc.ActivateSubscription(ctx, payment.SubscriptionID.UUID); err != nil {
+		return err
+	}
+	if payment.Recurring && payment.RobokassaInvID.Valid {
+		if err := s.subSvc.EnableRecurring(ctx, payment.SubscriptionID.UUID, payment.RobokassaInvID.Int64); err != nil {
+			log.Error().Err(err).Str("payment_id", payment.ID.String()).Msg("failed to save recurring parent payment")
+		}
+	}
+	return nil
+}
+
+// renewalFailed records a failed renewal charge and sends the dunning notification
+func (s *Service) renewalFailed(ctx context.Context, subscriptionID, userID uuid.UUID, planName, reason string) {
+	if s.renewalSubs == nil {
+		return
+	}
+	sub, err := s.renewalSubs.RecordRenewalFailure(ctx, subscriptionID, s.renewalConfig.RetryIntervals, reason)
+	if err != nil {
+		log.Error().Err(err).Str("subscription_id", subscriptionID.String()).Msg("failed to record renewal failure")
+		return
+	}
+	if s.renewalNotifier == nil {
+		return
+	}
+	var retryAt time.Time
+	if sub.AutoRenew && sub.NextRenewalAt.Valid {
+		retryAt = sub.NextRenewalAt.Time
+	}
+	s.renewalNotifier.NotifyRenewalFailed(ctx, userID, planName, retryAt)
+}
+
+func (s *Service) renewalPlanName(ctx context.Context, id subscription.PlanID) string {
+	if s.renewalSubs != nil {
+		if plan, err := s.renewalSubs.GetPlan(ctx, id); err == nil && plan != nil {
+			return plan.Name
+		}
+	}
+	return string(id)
+}
+
+// RenewalWorker periodically charges subscriptions due for auto-renewal
+type RenewalWorker struct {
+	service  *Service
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewRenewalWorker creates auto-renewal worker
+func NewRenewalWorker(service *Service, interval time.Duration) *RenewalWorker {
+	if interval == 0 {
+		interval = 1 * time.Hour
+	}
+	return &RenewalWorker{
+		service:  service,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the background worker
+func (w *RenewalWorker) Start() {
+	log.Info().Msg("Starting subscription renewal worker...")
+	go w.loop()
+}
+
+// Stop gracefully stops the background worker
+func (w *RenewalWorker) Stop() {
+	log.Info().Msg("Stopping subscription renewal worker...")
+	close(w.stopCh)
+}
+
+func (w *RenewalWorker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run once immediately on startup
+	w.run()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.run()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *RenewalWorker) run() {
+	// Each charge is a provider call, so allow more time than the lifecycle worker
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := w.service.RunRenewals(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Subscription renewal run failed")
+	}
+	if result != nil && (result.Charged > 0 || result.Failed > 0 || result.Confirmed > 0) {
+		log.Info().
+			Int("charged", result.Charged).
+			Int("failed", result.Failed).
+			Int("confirmed", result.Confirmed).
+			Msg("Subscription renewals processed")
+	}
+}
@@ -0,0 +1,167 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/domain/subscription"
+	"github.com/mwork/mwork-api/internal/pkg/robokassa"
+)
+
+type renewalPaymentRepo struct {
+	statusRepo
+	createErr error
+}
+
+func (r *renewalPaymentRepo) CreateRobokassaPending(ctx context.Context, p *Payment) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.created = p
+	r.payments[p.ID] = p
+	return nil
+}
+
+type renewalRepoStub struct{}
+
+func (renewalRepoStub) ListPendingRenewals(ctx context.Context, olderThan time.Duration, limit int) ([]*Payment, error) {
+	return nil, nil
+}
+func (renewalRepoStub) HasPendingRenewal(ctx context.Context, subscriptionID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+type renewalSubsStub struct {
+	due      []*subscription.Subscription
+	plan     *subscription.Plan
+	failures int
+}
+
+func (s *renewalSubsStub) ListDueForRenewal(ctx context.Context, lead time.Duration, limit int) ([]*subscription.Subscription, error) {
+	return s.due, nil
+}
+func (s *renewalSubsStub) GetPlan(ctx context.Context, planID subscription.PlanID) (*subscription.Plan, error) {
+	return s.plan, nil
+}
+func (s *renewalSubsStub) RecordRenewalFailure(ctx context.Context, id uuid.UUID, retryIntervals []time.Duration, reason string) (*subscription.Subscription, error) {
+	s.failures++
+	return &subscription.Subscription{ID: id, AutoRenew: true, NextRenewalAt: sql.NullTime{Time: time.Now().Add(retryIntervals[0]), Valid: true}}, nil
+}
+
+type chargerStub struct {
+	req robokassa.RecurringRequest
+	err error
+}
+
+func (c *chargerStub) Recurring(ctx context.Context, req robokassa.RecurringRequest) error {
+	c.req = req
+	return c.err
+}
+
+type renewalNotifierStub struct{ failed []time.Time }
+
+func (n *renewalNotifierStub) NotifySubscriptionRenewed(ctx context.Context, userID uuid.UUID, planName string, expiresAt time.Time) {
+}
+func (n *renewalNotifierStub) NotifyRenewalFailed(ctx context.Context, userID uuid.UUID, planName string, retryAt time.Time) {
+	n.failed = append(n.failed, retryAt)
+}
+
+func newRenewalService(charger *chargerStub, subs *renewalSubsStub, notifier *renewalNotifierStub) (*Service, *renewalPaymentRepo) {
+	repo := &renewalPaymentRepo{statusRepo: statusRepo{payments: map[uuid.UUID]*Payment{}}}
+	svc := NewService(repo, nil)
+	svc.SetRobokassaConfig(RobokassaConfig{MerchantLogin: "merchant", Password1: "p1", Password2: "p2", HashAlgo: "sha256"})
+	svc.SetRenewals(renewalRepoStub{}, subs, charger, notifier, RenewalConfig{RetryIntervals: []time.Duration{24 * time.Hour}})
+	return svc, repo
+}
+
+func dueSubscription() *subscription.Subscription {
+	return &subscription.Subscription{
+		ID:             uuid.New(),
+		UserID:         uuid.New(),
+		PlanID:         subscription.PlanPro,
+		Status:         subscription.StatusActive,
+		BillingPeriod:  subscription.BillingMonthly,
+		ExpiresAt:      sql.NullTime{Time: time.Now().Add(6 * time.Hour), Valid: true},
+		AutoRenew:      true,
+		RecurringInvID: sql.NullInt64{Int64: 777, Valid: true},
+	}
+}
+
+func TestRunRenewals_ChargesSavedCardAtFullPrice(t *testing.T) {
+	charger := &chargerStub{}
+	subs := &renewalSubsStub{due: []*subscription.Subscription{dueSubscription()}, plan: &subscription.Plan{ID: subscription.PlanPro, Name: "Pro", PriceMonthly: 4990}}
+	svc, repo := newRenewalService(charger, subs, &renewalNotifierStub{})
+
+	result, err := svc.RunRenewals(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Charged != 1 || result.Failed != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	p := repo.created
+	if p == nil || p.Type != PaymentTypeRenewal || p.Amount != 4990 || p.ParentInvID.Int64 != 777 || p.Status != StatusPending {
+		t.Fatalf("unexpected renewal payment: %+v", p)
+	}
+	if charger.req.PreviousInvID != 777 || charger.req.InvID != 1001 || charger.req.OutSum != "4990.00" {
+		t.Fatalf("unexpected recurring request: %+v", charger.req)
+	}
+	if !reflect.DeepEqual(expectedShpFromPayment(p), charger.req.Shp) {
+		t.Fatal("shp sent with the charge must match the stored payment for callback validation")
+	}
+}
+
+func TestRunRenewals_RejectedChargeSchedulesRetry(t *testing.T) {
+	charger := &chargerStub{err: errors.New("robokassa recurring rejected: card expired")}
+	subs := &renewalSubsStub{due: []*subscription.Subscription{dueSubscription()}, plan: &subscription.Plan{ID: subscription.PlanPro, Name: "Pro", PriceMonthly: 4990}}
+	notifier := &renewalNotifierStub{}
+	svc, repo := newRenewalService(charger, subs, notifier)
+
+	result, err := svc.RunRenewals(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Failed != 1 || repo.created.Status != StatusFailed {
+		t.Fatalf("expected a failed renewal payment, got %+v / %s", result, repo.created.Status)
+	}
+	if subs.failures != 1 || len(notifier.failed) != 1 || notifier.failed[0].IsZero() {
+		t.Fatalf("expected one failure with a retry notice, got %d / %v", subs.failures, notifier.failed)
+	}
+}
+
+func TestRunRenewals_SkipsRenewalClaimedElsewhere(t *testing.T) {
+	charger := &chargerStub{}
+	subs := &renewalSubsStub{due: []*subscription.Subscription{dueSubscription()}, plan: &subscription.Plan{ID: subscription.PlanPro, Name: "Pro", PriceMonthly: 4990}}
+	svc, repo := newRenewalService(charger, subs, &renewalNotifierStub{})
+	repo.createErr = ErrRenewalInProgress
+
+	result, err := svc.RunRenewals(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Charged != 0 || result.Failed != 0 || charger.req.InvID != 0 || subs.failures != 0 {
+		t.Fatalf("a renewal pending on another instance must be skipped, got %+v", result)
+	}
+}
+
+func TestInitRobokassaPayment_RecurringFlag(t *testing.T) {
+	repo := &captureRepo{}
+	svc := NewService(repo, nil)
+	svc.SetRobokassaConfig(RobokassaConfig{MerchantLogin: "merchant", Password1: "p1", Password2: "p2", HashAlgo: "sha256"})
+
+	out, err := svc.InitRobokassaPayment(context.Background(), InitRobokassaPaymentRequest{UserID: uuid.New(), Amount: "4990", Plan: "pro", Recurring: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, _ := url.Parse(out.PaymentURL)
+	if parsed.Query().Get("Recurring") != "true" || !repo.created.Recurring {
+		t.Fatalf("expected recurring payment link, got %s", out.PaymentURL)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // Repository defines payment data access
@@ -41,13 +42,15 @@ func NewRepository(db *sqlx.DB) Repository {
 
 func (r *repository) Create(ctx context.Context, p *Payment) error {
 	query := `
-		INSERT INTO payments (id, user_id, subscription_id, amount, currency, status, provider, external_id, description, metadata, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO payments (id, user_id, subscription_id, type, sku, amount, currency, status, provider, external_id, description, metadata, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 	_, err := r.db.ExecContext(ctx, query,
 		p.ID,
 		p.UserID,
 		p.SubscriptionID,
+		p.Type,
+		p.SKU,
 		p.Amount,
 		p.Currency,
 		p.Status,
@@ -86,20 +89,30 @@ func (r *repository) GetByExternalID(ctx context.Context, provider, externalID s
 	return &p, nil
 }
 
+// UpdateStatus sets the payment status. A payment is completed only from pending
+// or failed and failed only from pending; otherwise ErrPaymentStateChanged is returned.
 func (r *repository) UpdateStatus(ctx context.Context, id uuid.UUID, status Status) error {
 	var query string
 	switch status {
 	case StatusCompleted:
-		query = `UPDATE payments SET status = $2, paid_at = NOW() WHERE id = $1`
+		query = `UPDATE payments SET status = $2, paid_at = NOW() WHERE id = $1 AND status IN ('pending', 'failed')`
 	case StatusFailed:
-		query = `UPDATE payments SET status = $2, failed_at = NOW() WHERE id = $1`
+		query = `UPDATE payments SET status = $2, failed_at = NOW() WHERE id = $1 AND status = 'pending'`
 	case StatusRefunded:
 		query = `UPDATE payments SET status = $2, refunded_at = NOW() WHERE id = $1`
 	default:
 		query = `UPDATE payments SET status = $2 WHERE id = $1`
 	}
-	_, err := r.db.ExecContext(ctx, query, id, status)
-	return err
+	res, err := r.db.ExecContext(ctx, query, id, status)
+	if err != nil {
+		return err
+	}
+	if status == StatusCompleted || status == StatusFailed {
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrPaymentStateChanged
+		}
+	}
+	return nil
 }
 
 func (r *repository) ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*Payment, error) {
@@ -108,16 +121,16 @@ func (r *repository) ListByUser(ctx context.Context, userID uuid.UUID, limit, of
 			id, user_id, plan_id, subscription_id,
 			COALESCE(type, '') AS type,
 			plan, inv_id,
-			response_package, amount, robokassa_inv_id,
+			response_package, sku, amount, robokassa_inv_id,
 			COALESCE(currency, 'KZT') AS currency,
 			COALESCE(status, 'pending') AS status,
 			provider, external_id, description,
 			COALESCE(metadata, 'null'::jsonb) as metadata,
 			COALESCE(raw_init_payload, 'null'::jsonb) as raw_init_payload,
 			COALESCE(raw_callback_payload, 'null'::jsonb) as raw_callback_payload,
-			paid_at, failed_at, refunded_at, created_at,
+			paid_at, failed_at, refunded_at, refunded_amount, created_at,
 			COALESCE(updated_at, created_at) AS updated_at,
-			promotion_id
+			promotion_id, promo_code_id, original_amount, discount_amount
 		FROM payments 
 		WHERE user_id = $1 
 		ORDER BY created_at DESC 
@@ -179,8 +192,8 @@ func (r *repository) GetByPromotionID(ctx context.Context, promotionID string) (
 
 func (r *repository) CreateRobokassaPending(ctx context.Context, payment *Payment) error {
 	query := `
-		INSERT INTO payments (id, user_id, subscription_id, type, plan, inv_id, response_package, amount, currency, status, provider, external_id, robokassa_inv_id, description, metadata, raw_init_payload, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NOW(), NOW())`
+		INSERT INTO payments (id, user_id, subscription_id, type, plan, inv_id, response_package, sku, amount, currency, status, provider, external_id, robokassa_inv_id, description, metadata, raw_init_payload, promo_code_id, original_amount, discount_amount, recurring, parent_inv_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, NOW(), NOW())`
 	_, err := r.db.ExecContext(ctx, query,
 		payment.ID,
 		payment.UserID,
@@ -189,6 +202,7 @@ func (r *repository) CreateRobokassaPending(ctx context.Context, payment *Paymen
 		payment.Plan,
 		payment.InvID,
 		payment.ResponsePackage,
+		payment.SKU,
 		payment.Amount,
 		payment.Currency,
 		payment.Status,
@@ -198,10 +212,19 @@ func (r *repository) CreateRobokassaPending(ctx context.Context, payment *Paymen
 		payment.Description,
 		payment.Metadata,
 		payment.RawInitPayload,
+		payment.PromoCodeID,
+		payment.OriginalAmount,
+		payment.DiscountAmount,
+		payment.Recurring,
+		payment.ParentInvID,
 	)
 	if err != nil && isUndefinedPaymentsColumnErr(err) {
 		return r.createRobokassaPendingLegacy(ctx, payment)
 	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_payments_pending_renewal" {
+		return ErrRenewalInProgress
+	}
 	return err
 }
 
@@ -261,7 +284,7 @@ func (r *repository) MarkRobokassaSucceeded(ctx context.Context, tx *sqlx.Tx, pa
 	query := `
 		UPDATE payments
 		SET status = 'paid', paid_at = NOW(), updated_at = NOW(), raw_callback_payload = $2
-		WHERE id = $1 AND status = 'pending'`
+		WHERE id = $1 AND status IN ('pending', 'failed')`
 	result, err := tx.ExecContext(ctx, query, paymentID, payloadJSON)
 	if err != nil {
 		return err
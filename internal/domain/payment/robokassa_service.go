@@ -16,12 +16,18 @@ type RobokassaService struct {
 	HashAlgo      robokassa.HashAlgorithm
 }
 
-func (s RobokassaService) GeneratePaymentLink(outSum string, invID string, shp map[string]string) (string, error) {
+// GeneratePaymentLink builds a signed payment form URL. A non-empty receipt is the
+// fiscal receipt JSON and becomes part of the signature.
+func (s RobokassaService) GeneratePaymentLink(outSum string, invID string, receipt string, shp map[string]string) (string, error) {
 	algo := s.HashAlgo
 	if algo == "" {
 		algo = robokassa.HashSHA256
 	}
-	base := robokassa.BuildStartSignatureBase(s.MerchantLogin, outSum, invID, s.Password1, nil, shp)
+	var receiptPtr *string
+	if receipt != "" {
+		receiptPtr = &receipt
+	}
+	base := robokassa.BuildStartSignatureBase(s.MerchantLogin, outSum, invID, s.Password1, receiptPtr, shp)
 	signature, err := robokassa.Sign(base, algo)
 	if err != nil {
 		return "", err
@@ -31,6 +37,9 @@ func (s RobokassaService) GeneratePaymentLink(outSum string, invID string, shp m
 	params.Set("OutSum", outSum)
 	params.Set("InvId", invID)
 	params.Set("SignatureValue", signature)
+	if receipt != "" {
+		params.Set("Receipt", robokassa.ReceiptParam(receipt))
+	}
 	for k, v := range shp {
 		params.Set(k, v)
 	}
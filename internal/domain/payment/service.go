@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/url"
@@ -14,7 +15,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/mwork/mwork-api/internal/domain/credit"
 	"github.com/mwork/mwork-api/internal/domain/subscription"
+	"github.com/mwork/mwork-api/internal/pkg/events"
+	paymentprovider "github.com/mwork/mwork-api/internal/pkg/payment"
 	"github.com/mwork/mwork-api/internal/pkg/robokassa"
+	"github.com/mwork/mwork-api/internal/pkg/storage"
 	"github.com/rs/zerolog/log"
 )
 
@@ -23,9 +27,26 @@ type Service struct {
 	repo            Repository
 	subSvc          *subscription.Service
 	creditSvc       credit.Service // ✅ FIXED: Using credit.Service interface
+	products        ProductRepository
+	refunds         RefundRepository
+	promos          PromoRepository
+	invoices        InvoiceRepository
+	invoiceStore    storage.Storage
+	invoiceMailer   InvoiceMailer
+	invoiceConfig   InvoiceConfig
+	reconcile       ReconcileRepository
+	paymentStates   PaymentStateClient
+	reconcileConfig ReconcileConfig
+	renewals        RenewalRepository
+	renewalSubs     RenewalSubscriptions
+	recurring       RecurringCharger
+	renewalNotifier RenewalNotifier
+	renewalConfig   RenewalConfig
+	providers       *paymentprovider.ProviderFactory
 	robokassaConfig RobokassaConfig
 	roboSvc         RobokassaService
 	robokassaErr    error
+	events          events.Emitter
 }
 
 // RobokassaConfig содержит настройки интеграции с платежной системой Robokassa
@@ -38,6 +59,11 @@ type RobokassaConfig struct {
 	IsTest        bool   // Режим тестирования
 	BaseURL       string
 	HashAlgo      string
+
+	// Fiscal receipt (54-FZ) sent with every payment link
+	ReceiptEnabled bool
+	ReceiptSno     string // taxation system, empty uses the shop default
+	ReceiptTax     string // VAT code for items, "none" by default
 }
 
 // NewService создает новый экземпляр сервиса платежей
@@ -83,6 +109,8 @@ type InitRobokassaPaymentRequest struct {
 	Description    string    // Описание платежа
 	Type           string
 	Plan           string
+	PromoCode      string // optional, applies to Plan
+	Recurring      bool   // save the card for auto-renewal (Robokassa Recurring=true)
 }
 
 // InitRobokassaPaymentResponse содержит данные созданного платежа
@@ -91,6 +119,8 @@ type InitRobokassaPaymentResponse struct {
 	InvID      int64     `json:"inv_id"`      // ID инвойса в Robokassa
 	PaymentURL string    `json:"payment_url"` // URL для оплаты
 	Status     string    `json:"status"`      // Статус платежа
+	Amount     float64   `json:"amount"`      // Сумма к оплате с учетом скидки
+	Discount   float64   `json:"discount,omitempty"`
 }
 
 // InitRobokassaPayment инициирует новый платеж через Robokassa.
@@ -118,9 +148,36 @@ func (s *Service) InitRobokassaPayment(ctx context.Context, req InitRobokassaPay
 	if err != nil {
 		return nil, fmt.Errorf("invalid amount")
 	}
+	paymentID := uuid.New()
+	var applied *AppliedPromo
+	if strings.TrimSpace(req.PromoCode) != "" {
+		if req.Plan == "" {
+			return nil, ErrPromoNotApplicable
+		}
+		applied, err = s.applyPromo(ctx, req.UserID, paymentID, req.PromoCode, PlanTarget(req.Plan), ratToFloat64(amountRat))
+		if err != nil {
+			return nil, err
+		}
+		amountRat, _ = normalizeAmount(fmt.Sprintf("%.2f", applied.Amount))
+	}
 	outSum := amountRat.FloatString(2)
+	itemName := strings.TrimSpace(req.Description)
+	if req.Plan != "" {
+		itemName = planItemName(req.Plan)
+	}
+	receipt, err := s.robokassaReceipt(itemName, ratToFloat64(amountRat))
+	if err != nil {
+		s.releasePromo(ctx, paymentID)
+		return nil, err
+	}
 	shp := buildRobokassaShp(req.UserID, invID)
 	initPayload := map[string]string{"OutSum": outSum, "InvId": invIDString(invID), "IncCurrLabel": "KZT", "Shp_user": shp["Shp_user"], "Shp_nonce": shp["Shp_nonce"]}
+	if receipt != "" {
+		initPayload["Receipt"] = receipt
+	}
+	if req.Recurring {
+		initPayload["Recurring"] = "true"
+	}
 
 	rawInit, err := json.Marshal(initPayload)
 	if err != nil {
@@ -135,7 +192,7 @@ func (s *Service) InitRobokassaPayment(ctx context.Context, req InitRobokassaPay
 		paymentType = "subscription"
 	}
 	payment := &Payment{
-		ID:             uuid.New(),
+		ID:             paymentID,
 		UserID:         req.UserID,
 		SubscriptionID: subscriptionID,
 		Type:           paymentType,
@@ -150,20 +207,27 @@ func (s *Service) InitRobokassaPayment(ctx context.Context, req InitRobokassaPay
 		Description:    sql.NullString{String: req.Description, Valid: req.Description != ""},
 		RawInitPayload: rawInit,
 		Metadata:       JSONRawMessage(rawInit),
+		Recurring:      req.Recurring,
 	}
+	withPromo(payment, applied)
 
 	if err := s.repo.CreateRobokassaPending(ctx, payment); err != nil {
+		s.releasePromo(ctx, paymentID)
 		return nil, err
 	}
 
-	paymentURL, err := s.roboSvc.GeneratePaymentLink(outSum, invIDString(invID), shp)
+	paymentURL, err := s.roboSvc.GeneratePaymentLink(outSum, invIDString(invID), receipt, shp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate robokassa payment link: %w", err)
 	}
-	paymentURL, err = appendQueryParams(paymentURL, map[string]string{
+	extra := map[string]string{
 		"IncCurrLabel": "KZT",
 		"Description":  strings.TrimSpace(req.Description),
-	})
+	}
+	if req.Recurring {
+		extra["Recurring"] = "true"
+	}
+	paymentURL, err = appendQueryParams(paymentURL, extra)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build robokassa payment link: %w", err)
 	}
@@ -173,7 +237,7 @@ func (s *Service) InitRobokassaPayment(ctx context.Context, req InitRobokassaPay
 			return nil, fmt.Errorf("failed to build robokassa test payment link: %w", err)
 		}
 	}
-	return &InitRobokassaPaymentResponse{PaymentID: payment.ID, InvID: invID, PaymentURL: paymentURL, Status: string(StatusPending)}, nil
+	return &InitRobokassaPaymentResponse{PaymentID: payment.ID, InvID: invID, PaymentURL: paymentURL, Status: string(StatusPending), Amount: payment.Amount, Discount: payment.DiscountAmount}, nil
 }
 
 // ProcessRobokassaResult обрабатывает callback от Robokassa (Result URL).
@@ -188,6 +252,7 @@ func (s *Service) InitRobokassaPayment(ctx context.Context, req InitRobokassaPay
 // 6. Создает событие в журнале
 //
 // Идемпотентность: повторные вызовы для уже обработанного платежа не вызывают ошибку.
+// Платеж в статусе failed (например, закрытый сверкой) завершается поздним callback.
 //
 // Возвращаемые ошибки:
 //   - invalid signature: неверная подпись
@@ -242,7 +307,9 @@ func (s *Service) ProcessRobokassaResult(ctx context.Context, outSum, invID, sig
 	if payment.Status == StatusPaid || payment.Status == StatusCompleted {
 		return tx.Commit()
 	}
-	if payment.Status != StatusPending {
+	// A failed payment may still be paid: reconciliation gives up on abandoned invoices,
+	// and a signed callback arriving later proves the money was received
+	if payment.Status != StatusPending && payment.Status != StatusFailed {
 		return fmt.Errorf("invalid payment status")
 	}
 
@@ -273,16 +340,12 @@ func (s *Service) ProcessRobokassaResult(ctx context.Context, outSum, invID, sig
 		return err
 	}
 
-	if payment.Type == "responses" && s.creditSvc != nil && payment.ResponsePackage.Valid {
-		paymentIDStr := payment.ID.String()
-		meta := credit.TransactionMeta{RelatedEntityType: "payment", RelatedEntityID: payment.ID, Description: "responses package purchase", PaymentID: &paymentIDStr}
-		if err := s.creditSvc.Add(ctx, payment.UserID, int(payment.ResponsePackage.Int64), credit.TransactionTypePurchase, meta); err != nil {
-			return err
-		}
+	if err := s.fulfilProduct(ctx, payment); err != nil {
+		return err
 	}
 
 	if payment.SubscriptionID.Valid {
-		if err := s.subSvc.ActivateSubscription(ctx, payment.SubscriptionID.UUID); err != nil {
+		if err := s.fulfilSubscription(ctx, payment); err != nil {
 			return err
 		}
 	}
@@ -298,6 +361,9 @@ func (s *Service) ProcessRobokassaResult(ctx context.Context, outSum, invID, sig
 	if err := tx.Commit(); err != nil {
 		return err
 	}
+	s.redeemPromo(ctx, payment)
+	s.issueInvoice(ctx, payment)
+	s.emitCompleted(ctx, payment)
 	log.Info().Str("inv_id", invID).Str("payment_id", payment.ID.String()).Msg("robokassa payment callback processed")
 	return nil
 }
@@ -318,63 +384,149 @@ func (s *Service) VerifyRobokassaSuccessRedirect(outSum, invID, signature string
 	return nil
 }
 
-func (s *Service) CreateSubscriptionPayment(ctx context.Context, userID uuid.UUID, plan string) (*InitRobokassaPaymentResponse, error) {
+// CreateSubscriptionPayment starts a Robokassa checkout for a plan, charging the
+// prorated quote amount. A checkout fully covered by proration is activated without payment.
+// With autoRenew the payment saves the card for later renewal charges. The checkout
+// and promo code are validated before the pending subscription is created, and the
+// subscription is cancelled if the checkout still fails.
+func (s *Service) CreateSubscriptionPayment(ctx context.Context, userID uuid.UUID, plan, period, promoCode string, autoRenew bool) (*InitRobokassaPaymentResponse, error) {
 	plan = strings.ToLower(strings.TrimSpace(plan))
+	if period == "" {
+		period = string(subscription.BillingMonthly)
+	}
 	planData, err := s.subSvc.GetPlan(ctx, subscription.PlanID(plan))
 	if err != nil || planData == nil {
 		return nil, fmt.Errorf("invalid plan")
 	}
+	if err := s.checkSubscriptionCheckout(ctx, userID, plan, period, promoCode); err != nil {
+		return nil, err
+	}
 
-	sub, err := s.subSvc.Subscribe(ctx, userID, &subscription.SubscribeRequest{
+	sub, quote, err := s.subSvc.SubscribeWithQuote(ctx, userID, &subscription.SubscribeRequest{
 		PlanID:        plan,
-		BillingPeriod: string(subscription.BillingMonthly),
+		BillingPeriod: period,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return s.InitRobokassaPayment(ctx, InitRobokassaPaymentRequest{
+	if quote.Amount <= 0 {
+		if err := s.subSvc.ActivateSubscription(ctx, sub.ID); err != nil {
+			return nil, err
+		}
+		return &InitRobokassaPaymentResponse{Status: string(StatusCompleted)}, nil
+	}
+
+	resp, err := s.InitRobokassaPayment(ctx, InitRobokassaPaymentRequest{
 		UserID:         userID,
 		SubscriptionID: sub.ID,
-		Amount:         fmt.Sprintf("%.2f", planData.PriceMonthly),
+		Amount:         fmt.Sprintf("%.2f", quote.Amount),
 		Description:    "subscription " + plan,
 		Type:           "subscription",
 		Plan:           plan,
+		PromoCode:      promoCode,
+		Recurring:      autoRenew,
 	})
+	if err != nil {
+		if revokeErr := s.subSvc.Revoke(ctx, sub.ID, "checkout failed"); revokeErr != nil {
+			log.Error().Err(revokeErr).Str("subscription_id", sub.ID.String()).Msg("Failed to cancel subscription after failed checkout")
+		}
+		return nil, err
+	}
+	return resp, nil
 }
 
-func (s *Service) CreateResponsePayment(ctx context.Context, userID uuid.UUID, pack int) (*InitRobokassaPaymentResponse, error) {
+// checkSubscriptionCheckout fails early when a paid plan checkout can't be started
+func (s *Service) checkSubscriptionCheckout(ctx context.Context, userID uuid.UUID, plan, period, promoCode string) error {
+	quote, err := s.subSvc.QuoteChange(ctx, userID, subscription.PlanID(plan), subscription.BillingPeriod(period))
+	if err != nil || quote.Amount <= 0 {
+		// Invalid changes are reported by SubscribeWithQuote; free changes need no checkout
+		return nil
+	}
 	if s.robokassaErr != nil {
-		return nil, s.robokassaErr
+		return s.robokassaErr
+	}
+	if strings.TrimSpace(promoCode) == "" {
+		return nil
 	}
-	prices := map[int]float64{10: 990, 20: 1790, 50: 3990}
-	amount, ok := prices[pack]
-	if !ok {
+	_, err = s.QuotePromo(ctx, userID, promoCode, PlanTarget(plan), quote.Amount)
+	return err
+}
+
+// CreateResponsePayment starts a Robokassa checkout for the response pack of the given size
+func (s *Service) CreateResponsePayment(ctx context.Context, userID uuid.UUID, role string, pack int, promoCode string) (*InitRobokassaPaymentResponse, error) {
+	product, err := s.findPackage(ctx, ProductKindResponses, pack, role)
+	if err != nil {
 		return nil, fmt.Errorf("invalid package")
 	}
+	return s.checkoutProduct(ctx, userID, product, promoCode)
+}
+
+// CreateProductPayment starts a Robokassa checkout for a catalog product
+func (s *Service) CreateProductPayment(ctx context.Context, userID uuid.UUID, role, sku, promoCode string) (*InitRobokassaPaymentResponse, error) {
+	product, err := s.ResolveProduct(ctx, sku, role)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkoutProduct(ctx, userID, product, promoCode)
+}
+
+func (s *Service) checkoutProduct(ctx context.Context, userID uuid.UUID, product *Product, promoCode string) (*InitRobokassaPaymentResponse, error) {
+	if s.robokassaErr != nil {
+		return nil, s.robokassaErr
+	}
+	if !strings.EqualFold(product.Currency, "KZT") {
+		return nil, fmt.Errorf("currency %s is not supported by robokassa", product.Currency)
+	}
 	invID, err := s.repo.NextRobokassaInvID(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate invoice id: %w", err)
 	}
+	paymentID := uuid.New()
+	var applied *AppliedPromo
+	if strings.TrimSpace(promoCode) != "" {
+		applied, err = s.applyPromo(ctx, userID, paymentID, promoCode, ProductTarget(product.SKU), product.Price)
+		if err != nil {
+			return nil, err
+		}
+	}
+	amount := product.Price
+	if applied != nil {
+		amount = applied.Amount
+	}
+	outSum := fmt.Sprintf("%.2f", amount)
+	receipt, err := s.robokassaReceipt(product.Title, amount)
+	if err != nil {
+		s.releasePromo(ctx, paymentID)
+		return nil, err
+	}
 	shp := buildRobokassaShp(userID, invID)
 	initPayload := map[string]string{
-		"OutSum":       fmt.Sprintf("%.2f", amount),
+		"OutSum":       outSum,
 		"InvId":        invIDString(invID),
 		"IncCurrLabel": "KZT",
 		"Shp_user":     shp["Shp_user"],
 		"Shp_nonce":    shp["Shp_nonce"],
 	}
+	if receipt != "" {
+		initPayload["Receipt"] = receipt
+	}
 	rawInit, err := json.Marshal(initPayload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal init payload: %w", err)
 	}
-	payment := &Payment{ID: uuid.New(), UserID: userID, Type: "responses", ResponsePackage: sql.NullInt64{Int64: int64(pack), Valid: true}, InvID: sql.NullString{String: invIDString(invID), Valid: true}, Amount: amount, Currency: "KZT", Status: StatusPending, Provider: sql.NullString{String: "robokassa", Valid: true}, ExternalID: sql.NullString{String: invIDString(invID), Valid: true}, RobokassaInvID: sql.NullInt64{Int64: invID, Valid: true}, Description: sql.NullString{String: fmt.Sprintf("responses package %d", pack), Valid: true}}
+	payment := &Payment{ID: paymentID, UserID: userID, Type: string(product.Kind), SKU: sql.NullString{String: product.SKU, Valid: true}, InvID: sql.NullString{String: invIDString(invID), Valid: true}, Amount: product.Price, Currency: "KZT", Status: StatusPending, Provider: sql.NullString{String: "robokassa", Valid: true}, ExternalID: sql.NullString{String: invIDString(invID), Valid: true}, RobokassaInvID: sql.NullInt64{Int64: invID, Valid: true}, Description: sql.NullString{String: product.Title, Valid: true}}
+	if product.Kind == ProductKindResponses {
+		payment.ResponsePackage = sql.NullInt64{Int64: int64(product.Quantity), Valid: true}
+	}
 	payment.RawInitPayload = rawInit
 	payment.Metadata = JSONRawMessage(rawInit)
+	withPromo(payment, applied)
 	if err := s.repo.CreateRobokassaPending(ctx, payment); err != nil {
+		s.releasePromo(ctx, paymentID)
 		return nil, err
 	}
-	url, err := s.roboSvc.GeneratePaymentLink(fmt.Sprintf("%.2f", amount), invIDString(invID), shp)
+	url, err := s.roboSvc.GeneratePaymentLink(outSum, invIDString(invID), receipt, shp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate robokassa payment link: %w", err)
 	}
@@ -388,7 +540,59 @@ func (s *Service) CreateResponsePayment(ctx context.Context, userID uuid.UUID, p
 			return nil, fmt.Errorf("failed to build robokassa test payment link: %w", err)
 		}
 	}
-	return &InitRobokassaPaymentResponse{PaymentID: payment.ID, InvID: invID, PaymentURL: url, Status: string(StatusPending)}, nil
+	return &InitRobokassaPaymentResponse{PaymentID: payment.ID, InvID: invID, PaymentURL: url, Status: string(StatusPending), Amount: payment.Amount, Discount: payment.DiscountAmount}, nil
+}
+
+// robokassaReceipt builds the fiscal receipt for a single-item payment, or "" when disabled
+func (s *Service) robokassaReceipt(itemName string, amount float64) (string, error) {
+	if !s.robokassaConfig.ReceiptEnabled {
+		return "", nil
+	}
+	if itemName == "" {
+		itemName = "Услуги платформы MWork"
+	}
+	receipt, err := robokassa.Receipt{
+		Sno: s.robokassaConfig.ReceiptSno,
+		Items: []robokassa.ReceiptItem{{
+			Name:          itemName,
+			Quantity:      1,
+			Sum:           roundAmount(amount),
+			PaymentMethod: robokassa.PaymentMethodFullPayment,
+			PaymentObject: robokassa.PaymentObjectService,
+			Tax:           s.robokassaConfig.ReceiptTax,
+		}},
+	}.Encode(roundAmount(amount))
+	if err != nil {
+		return "", fmt.Errorf("failed to build fiscal receipt: %w", err)
+	}
+	return receipt, nil
+}
+
+// fulfilProduct grants what the purchased SKU sells. The quantity comes from the
+// catalog, never from the paid amount. Payments without a SKU grant nothing.
+func (s *Service) fulfilProduct(ctx context.Context, payment *Payment) error {
+	if !payment.SKU.Valid || s.creditSvc == nil {
+		return nil
+	}
+	if s.products == nil {
+		return fmt.Errorf("product catalog is not configured")
+	}
+	product, err := s.products.GetBySKU(ctx, payment.SKU.String)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return fmt.Errorf("unknown sku %q", payment.SKU.String)
+	}
+
+	paymentIDStr := payment.ID.String()
+	meta := credit.TransactionMeta{
+		RelatedEntityType: "payment",
+		RelatedEntityID:   payment.ID,
+		Description:       fmt.Sprintf("%s package purchase (%s)", product.Kind, product.SKU),
+		PaymentID:         &paymentIDStr,
+	}
+	return s.creditSvc.Add(ctx, payment.UserID, product.Quantity, credit.TransactionTypePurchase, meta)
 }
 
 func appendQueryParams(rawURL string, params map[string]string) (string, error) {
@@ -418,6 +622,24 @@ func (s *Service) SetCreditService(creditSvc credit.Service) { // ✅ FIXED: Usi
 	s.creditSvc = creditSvc
 }
 
+// SetEventEmitter включает продуктовые события об успешных платежах (опционально)
+func (s *Service) SetEventEmitter(e events.Emitter) {
+	s.events = e
+}
+
+func (s *Service) emitCompleted(ctx context.Context, payment *Payment) {
+	if s.events == nil {
+		return
+	}
+	s.events.Emit(ctx, events.PaymentCompleted{
+		PaymentID: payment.ID,
+		UserID:    payment.UserID,
+		Type:      payment.Type,
+		Provider:  payment.Provider.String,
+		Amount:    payment.Amount,
+	})
+}
+
 // CreatePayment создает новый платеж для подписки.
 // Платеж создается в статусе pending и требует подтверждения через ConfirmPayment.
 //
@@ -446,37 +668,33 @@ func (s *Service) CreatePayment(ctx context.Context, userID, subscriptionID uuid
 	return payment, nil
 }
 
-// CreateCreditPayment создает новый платеж для покупки кредитов.
-// Поддерживаются только фиксированные пакеты: 5, 10, 25, 50 кредитов.
-//
-// Валидация:
-//   - Пакет кредитов должен быть одним из: 5, 10, 25, 50
-//   - Платеж создается в статусе pending
+// CreateCreditPayment создает новый платеж для покупки пакета кредитов из каталога.
+// Цена берется из каталога, платеж создается в статусе pending.
 //
 // Возвращаемые ошибки:
-//   - invalid credit package: неподдерживаемый пакет кредитов
-func (s *Service) CreateCreditPayment(ctx context.Context, userID uuid.UUID, creditAmount int, totalPrice float64, provider Provider) (*Payment, error) {
-	// Validate credit amount - only allow specific packages (5, 10, 25, 50)
-	validPackages := map[int]bool{
-		5:  true,
-		10: true,
-		25: true,
-		50: true,
+//   - ErrProductNotFound: SKU отсутствует в каталоге или это не пакет кредитов
+//   - ErrProductUnavailable: пакет не продается сейчас или недоступен для роли
+func (s *Service) CreateCreditPayment(ctx context.Context, userID uuid.UUID, role, sku string, provider Provider) (*Payment, error) {
+	product, err := s.ResolveProduct(ctx, sku, role)
+	if err != nil {
+		return nil, err
 	}
-
-	if !validPackages[creditAmount] {
-		return nil, fmt.Errorf("invalid credit package: must be one of 5, 10, 25, or 50")
+	if product.Kind != ProductKindCredits {
+		return nil, ErrProductNotFound
 	}
 
 	now := time.Now()
 	payment := &Payment{
-		ID:        uuid.New(),
-		UserID:    userID,
-		Amount:    totalPrice,
-		Currency:  "KZT",
-		Status:    StatusPending,
-		Provider:  sql.NullString{String: string(provider), Valid: true},
-		CreatedAt: now,
+		ID:          uuid.New(),
+		UserID:      userID,
+		Type:        string(product.Kind),
+		SKU:         sql.NullString{String: product.SKU, Valid: true},
+		Amount:      product.Price,
+		Currency:    product.Currency,
+		Status:      StatusPending,
+		Provider:    sql.NullString{String: string(provider), Valid: true},
+		Description: sql.NullString{String: product.Title, Valid: true},
+		CreatedAt:   now,
 	}
 
 	if err := s.repo.Create(ctx, payment); err != nil {
@@ -490,7 +708,7 @@ func (s *Service) CreateCreditPayment(ctx context.Context, userID uuid.UUID, cre
 //
 // Действия в зависимости от типа платежа:
 //   - Платеж за подписку: активирует подписку
-//   - Платеж за кредиты: начисляет кредиты на баланс пользователя
+//   - Платеж за пакет: начисляет пакет по SKU из каталога
 //
 // Идемпотентность:
 // Метод идемпотентен - повторные вызовы для уже обработанного платежа не приводят к дублированию кредитов или подписок.
@@ -508,73 +726,44 @@ func (s *Service) ConfirmPayment(ctx context.Context, paymentID uuid.UUID) error
 		return nil // Already processed - no duplicate credits
 	}
 
-	// Update payment status
+	// Update payment status; a concurrent callback may have processed it already
 	if err := s.repo.UpdateStatus(ctx, paymentID, StatusCompleted); err != nil {
+		if errors.Is(err, ErrPaymentStateChanged) {
+			return nil
+		}
 		return err
 	}
+	s.redeemPromo(ctx, payment)
 
-	// Activate subscription if this is a subscription payment
+	// Activate or renew subscription if this is a subscription payment
 	if payment.SubscriptionID.Valid {
-		if err := s.subSvc.ActivateSubscription(ctx, payment.SubscriptionID.UUID); err != nil {
+		if err := s.fulfilSubscription(ctx, payment); err != nil {
 			log.Error().Err(err).Msg("Failed to activate subscription after payment")
 		}
-	} else if s.creditSvc != nil {
-		// B4: GRANT CREDITS FOR CREDIT PURCHASE
-		creditAmount := s.determineCreditAmount(payment.Amount)
-		if creditAmount > 0 {
-			paymentIDStr := payment.ID.String()
-			creditMeta := credit.TransactionMeta{ // ✅ FIXED: Using credit.TransactionMeta
-				RelatedEntityType: "payment",
-				RelatedEntityID:   payment.ID,
-				Description:       fmt.Sprintf("Purchase via %s: payment %s", payment.Provider.String, payment.ID.String()),
-				PaymentID:         &paymentIDStr,
-			}
-
-			// Grant credits - idempotent at payment service level
-			err := s.creditSvc.Add(ctx, payment.UserID, creditAmount, credit.TransactionTypePurchase, creditMeta) // ✅ FIXED: Using credit.TransactionTypePurchase
-			if err != nil {
-				log.Error().Err(err).Str("payment_id", payment.ID.String()).Msg("Failed to grant credits after payment")
-			}
-		}
+	} else if err := s.fulfilProduct(ctx, payment); err != nil {
+		// B4: GRANT PACKAGE FOR PRODUCT PURCHASE - idempotent at payment service level
+		log.Error().Err(err).Str("payment_id", payment.ID.String()).Msg("Failed to fulfil product after payment")
 	}
+	s.issueInvoice(ctx, payment)
+	s.emitCompleted(ctx, payment)
 
 	return nil
 }
 
-// determineCreditAmount определяет количество кредитов по сумме платежа.
-// Валидирует соответствие суммы ожидаемым пакетам кредитов.
-//
-// Тарифы (KZT):
-//   - 5 кредитов = 500 ₸
-//   - 10 кредитов = 900 ₸
-//   - 25 кредитов = 2000 ₸
-//   - 50 кредитов = 3500 ₸
-//
-// Возвращает 0, если сумма не соответствует ни одному пакету.
-func (s *Service) determineCreditAmount(amount float64) int {
-	// Allowed credit packages with pricing (KZT):
-	// 5 credits = 500 KZT
-	// 10 credits = 900 KZT
-	// 25 credits = 2000 KZT
-	// 50 credits = 3500 KZT
-	priceToCredits := map[float64]int{
-		500.0:  5,
-		900.0:  10,
-		2000.0: 25,
-		3500.0: 50,
-	}
-
-	if credits, ok := priceToCredits[amount]; ok {
-		return credits
-	}
-
-	return 0
-}
-
 // FailPayment отмечает платеж как неудавшийся.
-// Обновляет статус платежа на failed.
+// Обновляет статус платежа на failed; уже обработанные платежи не меняются.
 func (s *Service) FailPayment(ctx context.Context, paymentID uuid.UUID) error {
-	return s.repo.UpdateStatus(ctx, paymentID, StatusFailed)
+	if err := s.repo.UpdateStatus(ctx, paymentID, StatusFailed); err != nil {
+		if errors.Is(err, ErrPaymentStateChanged) {
+			return nil
+		}
+		return err
+	}
+	s.releasePromo(ctx, paymentID)
+	if payment, err := s.repo.GetByID(ctx, paymentID); err == nil && payment != nil && payment.Type == PaymentTypeRenewal && payment.SubscriptionID.Valid {
+		s.renewalFailed(ctx, payment.SubscriptionID.UUID, payment.UserID, s.renewalPlanName(ctx, subscription.PlanID(payment.Plan.String)), "charge declined")
+	}
+	return nil
 }
 
 // HandleWebhook обрабатывает webhook от платежного провайдера.
@@ -643,6 +832,10 @@ func (s *Service) validateRobokassaRuntimeConfig() error {
 // Errors
 var (
 	ErrPaymentNotFound = subscription.ErrPaymentFailed
+	// ErrPaymentStateChanged means the payment left pending before the update
+	ErrPaymentStateChanged = errors.New("payment is no longer pending")
+	// ErrRenewalInProgress means the subscription already has a pending renewal charge
+	ErrRenewalInProgress = errors.New("renewal charge already in progress")
 )
 
 func normalizeAmount(raw string) (*big.Rat, error) {
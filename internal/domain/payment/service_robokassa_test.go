@@ -50,6 +50,24 @@ func (r *captureRepo) CreatePaymentEvent(ctx context.Context, tx *sqlx.Tx, payme
 func (r *captureRepo) BeginTxx(ctx context.Context) (*sqlx.Tx, error)        { return nil, nil }
 func (r *captureRepo) NextRobokassaInvID(ctx context.Context) (int64, error) { return 1001, nil }
 
+type productStub struct {
+	ProductRepository
+	items []*Product
+}
+
+func (r *productStub) List(ctx context.Context, activeOnly bool) ([]*Product, error) {
+	return r.items, nil
+}
+
+func (r *productStub) GetBySKU(ctx context.Context, sku string) (*Product, error) {
+	for _, p := range r.items {
+		if p.SKU == sku {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
 func TestIsTestCallback(t *testing.T) {
 	if !isTestCallback(map[string]string{"IsTest": "1"}) {
 		t.Fatal("expected true for IsTest=1")
@@ -128,15 +146,21 @@ func TestCreateResponsePayment_PersistsInitPayloadWithShp(t *testing.T) {
 	repo := &captureRepo{}
 	svc := NewService(repo, nil)
 	svc.SetRobokassaConfig(RobokassaConfig{MerchantLogin: "merchant", Password1: "p1", Password2: "p2", HashAlgo: "sha256"})
+	svc.SetProductRepository(&productStub{items: []*Product{
+		{SKU: "responses_10", Kind: ProductKindResponses, Title: "10", Quantity: 10, Price: 990, Currency: "KZT", Audience: AudienceAll, IsActive: true},
+	}})
 
 	userID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
-	_, err := svc.CreateResponsePayment(context.Background(), userID, 10)
+	_, err := svc.CreateResponsePayment(context.Background(), userID, "model", 10, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if repo.created == nil {
 		t.Fatal("expected pending payment to be created")
 	}
+	if repo.created.SKU.String != "responses_10" || repo.created.Amount != 990 {
+		t.Fatalf("expected catalog sku and price, got %q %.2f", repo.created.SKU.String, repo.created.Amount)
+	}
 
 	var payload map[string]string
 	if err := json.Unmarshal(repo.created.RawInitPayload, &payload); err != nil {
@@ -116,6 +116,7 @@ type ModelProfileResponse struct {
 	Skills           []string  `json:"skills,omitempty"`
 	IsPublic         bool      `json:"is_public"`
 	IsPromoted       bool      `json:"is_promoted"`
+	IsSponsored      bool      `json:"is_sponsored"`
 	Visibility       *string   `json:"visibility,omitempty"`
 	ProfileViews     int       `json:"profile_views"`
 	Rating           float64   `json:"rating"`
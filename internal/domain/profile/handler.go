@@ -14,6 +14,7 @@ import (
 	"github.com/mwork/mwork-api/internal/middleware"
 	"github.com/mwork/mwork-api/internal/pkg/errorhandler"
 	"github.com/mwork/mwork-api/internal/pkg/response"
+	"github.com/mwork/mwork-api/internal/pkg/sponsored"
 	"github.com/mwork/mwork-api/internal/pkg/validator"
 
 	attachmentDomain "github.com/mwork/mwork-api/internal/domain/attachment"
@@ -23,6 +24,18 @@ import (
 type Handler struct {
 	service           *Service
 	attachmentService *attachmentDomain.Service
+	impressions       ImpressionTracker
+	sponsored         SponsoredSelector
+}
+
+// ImpressionTracker records impressions of promoted profiles
+type ImpressionTracker interface {
+	TrackProfileImpressions(r *http.Request, profileIDs []uuid.UUID)
+}
+
+// SponsoredSelector picks promoted profiles for sponsored slots in list results
+type SponsoredSelector interface {
+	SponsoredProfiles(r *http.Request, city string) []uuid.UUID
 }
 
 // NewHandler creates profile handler
@@ -30,6 +43,16 @@ func NewHandler(service *Service, attachmentService *attachmentDomain.Service) *
 	return &Handler{service: service, attachmentService: attachmentService}
 }
 
+// SetImpressionTracker enables impression tracking for promoted profiles
+func (h *Handler) SetImpressionTracker(t ImpressionTracker) {
+	h.impressions = t
+}
+
+// SetSponsoredSelector enables sponsored slots in the model list
+func (h *Handler) SetSponsoredSelector(s SponsoredSelector) {
+	h.sponsored = s
+}
+
 // GetMe handles GET /profiles/me
 // @Summary Мой профиль
 // @Description Возвращает профиль текущего пользователя (модель или работодатель).
@@ -113,6 +136,7 @@ func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
 // @Tags Profile
 // @Produce json
 // @Param id path string true "ID профиля"
+// @Param source query string false "Источник просмотра: search, casting, promotion"
 // @Success 200 {object} response.Response{data=ModelProfileResponse}
 // @Failure 400 {object} response.Response
 // @Failure 404 {object} response.Response
@@ -130,7 +154,15 @@ func (h *Handler) GetModelByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	go h.service.IncrementModelViewCount(context.Background(), id)
+	view := ProfileView{
+		ViewerKey:  middleware.ViewerKey(r),
+		ViewerRole: middleware.GetRole(r.Context()),
+		Source:     ParseViewSource(r.URL.Query().Get("source")),
+	}
+	if viewerID := middleware.GetUserID(r.Context()); viewerID != uuid.Nil {
+		view.ViewerID = uuid.NullUUID{UUID: viewerID, Valid: true}
+	}
+	go h.service.RecordModelView(context.Background(), profile, view)
 
 	resp := ModelProfileResponseFromEntity(profile)
 	if h.attachmentService != nil {
@@ -334,8 +366,14 @@ func (h *Handler) ListModels(w http.ResponseWriter, r *http.Request) {
 	}
 
 	items := make([]*ModelProfileResponse, len(profiles))
+	shown := make([]uuid.UUID, len(profiles))
 	for i, p := range profiles {
 		items[i] = ModelProfileResponseFromEntity(p)
+		shown[i] = p.ID
+	}
+	if len(items) > 0 {
+		go h.service.RecordSearchAppearances(context.Background(), shown)
+		items = sponsored.Inject(items, h.sponsoredProfiles(r, query.Get("city")), func(p *ModelProfileResponse) uuid.UUID { return p.ID })
 	}
 
 	response.WithMeta(w, items, response.Meta{
@@ -348,6 +386,24 @@ func (h *Handler) ListModels(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// sponsoredProfiles loads the profiles picked for sponsored slots
+func (h *Handler) sponsoredProfiles(r *http.Request, city string) []*ModelProfileResponse {
+	if h.sponsored == nil {
+		return nil
+	}
+	var out []*ModelProfileResponse
+	for _, id := range h.sponsored.SponsoredProfiles(r, city) {
+		p, err := h.service.GetModelProfileByID(r.Context(), id)
+		if err != nil {
+			continue
+		}
+		resp := ModelProfileResponseFromEntity(p)
+		resp.IsSponsored = true
+		out = append(out, resp)
+	}
+	return out
+}
+
 // ListPromotedModels handles GET /profiles/models/promoted
 // @Summary Список продвигаемых моделей
 // @Description Возвращает список продвигаемых профилей моделей.
@@ -380,8 +436,13 @@ func (h *Handler) ListPromotedModels(w http.ResponseWriter, r *http.Request) {
 	}
 
 	items := make([]*ModelProfileResponse, len(profiles))
+	ids := make([]uuid.UUID, len(profiles))
 	for i, p := range profiles {
 		items[i] = ModelProfileResponseFromEntity(p)
+		ids[i] = p.ID
+	}
+	if h.impressions != nil {
+		h.impressions.TrackProfileImpressions(r, ids)
 	}
 
 	response.OK(w, map[string]interface{}{
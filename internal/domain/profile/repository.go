@@ -48,6 +48,8 @@ type ModelRepository interface {
 	List(ctx context.Context, filter *Filter, pagination *Pagination) ([]*ModelProfile, int, error)
 	ListPromoted(ctx context.Context, city *string, limit int) ([]*ModelProfile, error)
 	IncrementViewCount(ctx context.Context, id uuid.UUID) error
+	RecordView(ctx context.Context, view *ProfileView) (bool, error)
+	RecordSearchAppearances(ctx context.Context, ids []uuid.UUID) error
 }
 
 // EmployerRepository defines employer profile data access interface
@@ -242,6 +244,38 @@ func (r *modelRepository) IncrementViewCount(ctx context.Context, id uuid.UUID)
 	_, err := r.db.ExecContext(ctx, `UPDATE model_profiles SET profile_views = profile_views + 1 WHERE id=$1`, id)
 	return err
 }
+
+// RecordView stores the first view of the day per viewer and bumps the view counter.
+// It reports whether the view was new.
+func (r *modelRepository) RecordView(ctx context.Context, view *ProfileView) (bool, error) {
+	var recorded bool
+	err := r.db.GetContext(ctx, &recorded, `
+		WITH inserted AS (
+			INSERT INTO profile_view_events (profile_id, viewer_key, viewer_id, viewer_role, organization_id, source)
+			VALUES ($1, $2, $3, NULLIF($4, ''), (SELECT organization_id FROM users WHERE id = $3), $5)
+			ON CONFLICT (profile_id, viewer_key, view_date) DO NOTHING
+			RETURNING profile_id
+		), counted AS (
+			UPDATE model_profiles SET profile_views = profile_views + 1
+			WHERE id IN (SELECT profile_id FROM inserted)
+			RETURNING id
+		)
+		SELECT EXISTS (SELECT 1 FROM counted)
+	`, view.ProfileID, view.ViewerKey, view.ViewerID, view.ViewerRole, string(view.Source))
+	return recorded, err
+}
+
+// RecordSearchAppearances adds one search appearance today to each profile
+func (r *modelRepository) RecordSearchAppearances(ctx context.Context, ids []uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO profile_search_appearances (profile_id, date, appearances)
+		SELECT u.id, CURRENT_DATE, 1 FROM UNNEST($1::uuid[]) AS u(id)
+		WHERE EXISTS (SELECT 1 FROM model_profiles p WHERE p.id = u.id)
+		ON CONFLICT (profile_id, date) DO UPDATE SET appearances = profile_search_appearances.appearances + 1
+	`, pq.Array(ids))
+	return err
+}
+
 func (r *modelRepository) ListPromoted(ctx context.Context, city *string, limit int) ([]*ModelProfile, error) {
 	q := `SELECT DISTINCT ON (p.id)
 		p.id,p.user_id,p.name,p.bio,p.description,p.age,p.height,p.weight,p.gender,p.clothing_size,p.shoe_size,p.experience,
@@ -18,6 +18,7 @@ type Service struct {
 	adminRepo     AdminRepository
 	userRepo      user.Repository
 	uploadBaseURL string
+	viewNotifier  ViewNotifier
 }
 
 // NewService creates profile service
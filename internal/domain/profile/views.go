@@ -0,0 +1,74 @@
+package profile
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ViewSource is where a profile view came from
+type ViewSource string
+
+const (
+	ViewSourceSearch    ViewSource = "search"
+	ViewSourceCasting   ViewSource = "casting"
+	ViewSourcePromotion ViewSource = "promotion"
+	ViewSourceDirect    ViewSource = "direct"
+)
+
+// ParseViewSource returns the view source, defaulting to direct for unknown values
+func ParseViewSource(s string) ViewSource {
+	switch ViewSource(s) {
+	case ViewSourceSearch, ViewSourceCasting, ViewSourcePromotion:
+		return ViewSource(s)
+	}
+	return ViewSourceDirect
+}
+
+// ProfileView is one view of a model profile
+type ProfileView struct {
+	ProfileID  uuid.UUID
+	ViewerKey  string        // signed-in user or anonymous fingerprint, views are deduplicated by it per day
+	ViewerID   uuid.NullUUID // empty for anonymous viewers
+	ViewerRole string
+	Source     ViewSource
+}
+
+// ViewNotifier tells a model that their profile was viewed
+type ViewNotifier interface {
+	NotifyProfileViewed(ctx context.Context, modelUserID, profileID uuid.UUID, viewerRole string)
+}
+
+// SetViewNotifier enables profile-view notifications
+func (s *Service) SetViewNotifier(n ViewNotifier) {
+	s.viewNotifier = n
+}
+
+// RecordModelView stores a profile view and bumps the view counter. Each viewer is
+// counted, and the model notified, at most once per day; the owner's own views are ignored.
+func (s *Service) RecordModelView(ctx context.Context, profile *ModelProfile, view ProfileView) {
+	if view.ViewerID.Valid && view.ViewerID.UUID == profile.UserID {
+		return
+	}
+	view.ProfileID = profile.ID
+
+	recorded, err := s.modelRepo.RecordView(ctx, &view)
+	if err != nil {
+		log.Warn().Err(err).Str("profile_id", profile.ID.String()).Msg("Failed to record profile view")
+		return
+	}
+	if recorded && view.ViewerID.Valid && s.viewNotifier != nil {
+		s.viewNotifier.NotifyProfileViewed(ctx, profile.UserID, profile.ID, view.ViewerRole)
+	}
+}
+
+// RecordSearchAppearances counts profiles shown in search results today
+func (s *Service) RecordSearchAppearances(ctx context.Context, profileIDs []uuid.UUID) {
+	if len(profileIDs) == 0 {
+		return
+	}
+	if err := s.modelRepo.RecordSearchAppearances(ctx, profileIDs); err != nil {
+		log.Warn().Err(err).Msg("Failed to record search appearances")
+	}
+}
@@ -0,0 +1,65 @@
+package profile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type viewRepoStub struct {
+	ModelRepository
+	seen  map[string]bool // profile and viewer key, within the day
+	views int
+}
+
+func (r *viewRepoStub) RecordView(ctx context.Context, view *ProfileView) (bool, error) {
+	key := view.ProfileID.String() + ":" + view.ViewerKey
+	if r.seen[key] {
+		return false, nil
+	}
+	r.seen[key] = true
+	r.views++
+	return true, nil
+}
+
+type viewNotifierStub struct {
+	notified []string
+}
+
+func (n *viewNotifierStub) NotifyProfileViewed(ctx context.Context, modelUserID, profileID uuid.UUID, viewerRole string) {
+	n.notified = append(n.notified, viewerRole)
+}
+
+func TestRecordModelView(t *testing.T) {
+	repo := &viewRepoStub{seen: map[string]bool{}}
+	notifier := &viewNotifierStub{}
+	svc := NewService(repo, nil, nil, nil, "")
+	svc.SetViewNotifier(notifier)
+	profile := &ModelProfile{ID: uuid.New(), UserID: uuid.New()}
+
+	owner := ProfileView{ViewerKey: "u:" + profile.UserID.String(), ViewerID: uuid.NullUUID{UUID: profile.UserID, Valid: true}}
+	svc.RecordModelView(context.Background(), profile, owner)
+	if repo.views != 0 {
+		t.Fatalf("expected the owner's own view to be ignored")
+	}
+
+	employerID := uuid.New()
+	employer := ProfileView{ViewerKey: "u:" + employerID.String(), ViewerID: uuid.NullUUID{UUID: employerID, Valid: true}, ViewerRole: "employer"}
+	svc.RecordModelView(context.Background(), profile, employer)
+	svc.RecordModelView(context.Background(), profile, employer)
+	if repo.views != 1 || len(notifier.notified) != 1 || notifier.notified[0] != "employer" {
+		t.Fatalf("expected one counted view and one notification per viewer and day, got %d views, %v", repo.views, notifier.notified)
+	}
+
+	svc.RecordModelView(context.Background(), profile, ProfileView{ViewerKey: "a:fingerprint", Source: ViewSourceSearch})
+	if repo.views != 2 || len(notifier.notified) != 1 {
+		t.Fatalf("expected an anonymous view to count without notifying, got %d views, %v", repo.views, notifier.notified)
+	}
+}
+
+func TestParseViewSource(t *testing.T) {
+	if ParseViewSource("promotion") != ViewSourcePromotion || ParseViewSource("bogus") != ViewSourceDirect {
+		t.Fatalf("unexpected view source parsing")
+	}
+}
@@ -0,0 +1,317 @@
+package promotion
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/middleware"
+)
+
+// Candidate is a running promotion that may fill a sponsored slot
+type Candidate struct {
+	PromotionID uuid.UUID
+	EntityID    uuid.UUID // promoted profile or casting
+	Audience    string    // employers, agencies, models or all
+	Cities      []string  // empty means every city
+	Remaining   int64     // unspent budget, the rotation weight
+}
+
+// Viewer is who a sponsored slot is selected for
+type Viewer struct {
+	Key  string // signed-in user or anonymous fingerprint, for frequency capping
+	Role string // empty for anonymous viewers
+	City string
+}
+
+// Matches reports whether the candidate's targeting includes the viewer
+func (c Candidate) Matches(v Viewer) bool {
+	return matchesAudience(c.Audience, v.Role) && matchesCity(c.Cities, v.City)
+}
+
+// matchesAudience checks the promotion audience against the viewer role.
+// Anonymous viewers see every audience since their role is unknown.
+func matchesAudience(audience, role string) bool {
+	if audience == "" || audience == "all" || role == "" || role == "admin" {
+		return true
+	}
+	switch audience {
+	case "employers":
+		return role == "employer" || role == "agency"
+	case "agencies":
+		return role == "agency"
+	case "models":
+		return role == "model"
+	}
+	return false
+}
+
+func matchesCity(cities []string, city string) bool {
+	if len(cities) == 0 || city == "" {
+		return true
+	}
+	for _, c := range cities {
+		if strings.EqualFold(strings.TrimSpace(c), strings.TrimSpace(city)) {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate orders candidates randomly, weighted by remaining budget
+// (weighted sampling without replacement)
+func rotate(cands []Candidate, rnd *rand.Rand) []Candidate {
+	type keyed struct {
+		c   Candidate
+		key float64
+	}
+	ks := make([]keyed, len(cands))
+	for i, c := range cands {
+		w := float64(c.Remaining)
+		if w < 1 {
+			w = 1
+		}
+		ks[i] = keyed{c: c, key: math.Pow(rnd.Float64(), 1/w)}
+	}
+	sort.SliceStable(ks, func(i, j int) bool { return ks[i].key > ks[j].key })
+	out := make([]Candidate, len(ks))
+	for i, k := range ks {
+		out[i] = k.c
+	}
+	return out
+}
+
+// FrequencyCapper counts how often a viewer was shown a promotion
+type FrequencyCapper interface {
+	// Allow records one more view of key and reports whether it is within limit for the window
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error)
+}
+
+// AdConfig controls sponsored slot selection
+type AdConfig struct {
+	Slots           int // sponsored slots per list page
+	FrequencyCap    int // views of one promotion per viewer within FrequencyWindow
+	FrequencyWindow time.Duration
+	CandidateTTL    time.Duration // how long candidate lists are cached
+}
+
+type candidateCache struct {
+	items     []Candidate
+	fetchedAt time.Time
+}
+
+// AdServer picks promotions for sponsored slots in list results: it filters
+// running promotions by targeting, rotates them weighted by remaining budget
+// and caps how often one viewer sees the same promotion
+type AdServer struct {
+	repo    *AdRepository
+	capper  FrequencyCapper
+	tracker *Tracker
+	cfg     AdConfig
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	cache map[Target]candidateCache
+}
+
+// NewAdServer creates an ad server. Served slots are recorded as impressions
+// when tracker is set.
+func NewAdServer(repo *AdRepository, capper FrequencyCapper, tracker *Tracker, cfg AdConfig) *AdServer {
+	if cfg.Slots <= 0 {
+		cfg.Slots = 2
+	}
+	if cfg.FrequencyCap <= 0 {
+		cfg.FrequencyCap = 5
+	}
+	if cfg.FrequencyWindow <= 0 {
+		cfg.FrequencyWindow = time.Hour
+	}
+	if cfg.CandidateTTL <= 0 {
+		cfg.CandidateTTL = 30 * time.Second
+	}
+	if capper == nil {
+		capper = NewMemoryFrequencyCapper()
+	}
+	return &AdServer{
+		repo:    repo,
+		capper:  capper,
+		tracker: tracker,
+		cfg:     cfg,
+		rnd:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		cache:   make(map[Target]candidateCache),
+	}
+}
+
+// SponsoredProfiles picks promoted model profiles for the request's sponsored slots.
+// city is the viewer's city filter; the viewer's profile city is used when empty.
+func (s *AdServer) SponsoredProfiles(r *http.Request, city string) []uuid.UUID {
+	ids := s.serve(r, TargetProfile, city)
+	if s.tracker != nil && len(ids) > 0 {
+		s.tracker.TrackProfileImpressions(r, ids)
+	}
+	return ids
+}
+
+// SponsoredCastings picks promoted castings for the request's sponsored slots
+func (s *AdServer) SponsoredCastings(r *http.Request, city string) []uuid.UUID {
+	ids := s.serve(r, TargetCasting, city)
+	if s.tracker != nil && len(ids) > 0 {
+		s.tracker.TrackCastingImpressions(r, ids)
+	}
+	return ids
+}
+
+func (s *AdServer) serve(r *http.Request, target Target, city string) []uuid.UUID {
+	if IsBot(r.UserAgent()) {
+		return nil
+	}
+	ctx := r.Context()
+	cands, err := s.candidates(ctx, target)
+	if err != nil {
+		log.Warn().Err(err).Str("target", string(target)).Msg("Failed to load sponsored candidates")
+		return nil
+	}
+	if len(cands) == 0 {
+		return nil
+	}
+
+	viewer := Viewer{Key: middleware.ViewerKey(r), Role: middleware.GetRole(ctx), City: city}
+	if viewer.City == "" {
+		if userID := middleware.GetUserID(ctx); userID != uuid.Nil {
+			viewer.City, _ = s.repo.ViewerCity(ctx, userID)
+		}
+	}
+	return s.pick(ctx, cands, viewer)
+}
+
+// pick selects up to the configured number of slots for the viewer
+func (s *AdServer) pick(ctx context.Context, cands []Candidate, viewer Viewer) []uuid.UUID {
+	eligible := make([]Candidate, 0, len(cands))
+	for _, c := range cands {
+		if c.Matches(viewer) {
+			eligible = append(eligible, c)
+		}
+	}
+	s.mu.Lock()
+	ordered := rotate(eligible, s.rnd)
+	s.mu.Unlock()
+
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, c := range ordered {
+		if len(ids) >= s.cfg.Slots {
+			break
+		}
+		if seen[c.EntityID] {
+			continue
+		}
+		allowed, err := s.capper.Allow(ctx, "promo:freq:"+c.PromotionID.String()+":"+viewer.Key, s.cfg.FrequencyCap, s.cfg.FrequencyWindow)
+		if err != nil {
+			log.Warn().Err(err).Msg("Promotion frequency cap check failed")
+		} else if !allowed {
+			continue
+		}
+		seen[c.EntityID] = true
+		ids = append(ids, c.EntityID)
+	}
+	return ids
+}
+
+func (s *AdServer) candidates(ctx context.Context, target Target) ([]Candidate, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[target]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < s.cfg.CandidateTTL {
+		return cached.items, nil
+	}
+
+	var items []Candidate
+	var err error
+	if target == TargetProfile {
+		items, err = s.repo.ListProfileCandidates(ctx)
+	} else {
+		items, err = s.repo.ListCastingCandidates(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[target] = candidateCache{items: items, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return items, nil
+}
+
+// MemoryFrequencyCapper counts views in process memory; used when Redis is not configured
+type MemoryFrequencyCapper struct {
+	mu     sync.Mutex
+	counts map[string]*windowCount
+}
+
+type windowCount struct {
+	n     int
+	until time.Time
+}
+
+// NewMemoryFrequencyCapper creates an in-memory frequency capper
+func NewMemoryFrequencyCapper() *MemoryFrequencyCapper {
+	return &MemoryFrequencyCapper{counts: make(map[string]*windowCount)}
+}
+
+func (m *MemoryFrequencyCapper) Allow(_ context.Context, key string, limit int, window time.Duration) (bool, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.counts[key]
+	if !ok || !now.Before(c.until) {
+		if len(m.counts) > 100000 {
+			m.prune(now)
+		}
+		c = &windowCount{until: now.Add(window)}
+		m.counts[key] = c
+	}
+	if c.n >= limit {
+		return false, nil
+	}
+	c.n++
+	return true, nil
+}
+
+func (m *MemoryFrequencyCapper) prune(now time.Time) {
+	for k, c := range m.counts {
+		if !now.Before(c.until) {
+			delete(m.counts, k)
+		}
+	}
+}
+
+// RedisFrequencyCapper shares view counts between API instances
+type RedisFrequencyCapper struct {
+	client *redis.Client
+}
+
+// NewRedisFrequencyCapper creates a Redis-backed frequency capper
+func NewRedisFrequencyCapper(client *redis.Client) *RedisFrequencyCapper {
+	return &RedisFrequencyCapper{client: client}
+}
+
+func (c *RedisFrequencyCapper) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	n, err := c.client.Incr(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if n == 1 {
+		c.client.Expire(ctx, key, window)
+	}
+	return n <= int64(limit), nil
+}
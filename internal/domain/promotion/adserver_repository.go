@@ -0,0 +1,105 @@
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// AdRepository loads servable promotions for the ad server
+type AdRepository struct {
+	db *sqlx.DB
+}
+
+// NewAdRepository creates a new ad repository
+func NewAdRepository(db *sqlx.DB) *AdRepository {
+	return &AdRepository{db: db}
+}
+
+type candidateRow struct {
+	PromotionID uuid.UUID      `db:"promotion_id"`
+	EntityID    uuid.UUID      `db:"entity_id"`
+	Audience    string         `db:"target_audience"`
+	Cities      pq.StringArray `db:"target_cities"`
+	Remaining   int64          `db:"remaining"`
+}
+
+func (c candidateRow) toCandidate() Candidate {
+	return Candidate{
+		PromotionID: c.PromotionID,
+		EntityID:    c.EntityID,
+		Audience:    c.Audience,
+		Cities:      []string(c.Cities),
+		Remaining:   c.Remaining,
+	}
+}
+
+// ListProfileCandidates returns running profile promotions that pacing currently allows to serve
+func (r *AdRepository) ListProfileCandidates(ctx context.Context) ([]Candidate, error) {
+	var rows []candidateRow
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT pr.id AS promotion_id, pr.profile_id AS entity_id,
+			COALESCE(pr.target_audience, 'all') AS target_audience,
+			COALESCE(pr.target_cities, '{}') AS target_cities,
+			pr.budget_amount - COALESCE(pr.spent_amount, 0) AS remaining
+		FROM profile_promotions pr
+		JOIN model_profiles p ON p.id = pr.profile_id
+		WHERE pr.status = 'active'
+		  AND pr.starts_at <= NOW() AND pr.ends_at >= NOW()
+		  AND COALESCE(pr.spent_amount, 0) < pr.budget_amount
+		  AND p.is_promoted = true AND COALESCE(p.is_public, true) = true
+	`)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Candidate, len(rows))
+	for i, row := range rows {
+		out[i] = row.toCandidate()
+	}
+	return out, nil
+}
+
+// ListCastingCandidates returns running casting promotions that pacing currently
+// allows to serve. Casting promotions target models in the casting's city.
+func (r *AdRepository) ListCastingCandidates(ctx context.Context) ([]Candidate, error) {
+	var rows []candidateRow
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT cp.id AS promotion_id, cp.casting_id AS entity_id,
+			'models' AS target_audience,
+			ARRAY[c.city]::text[] AS target_cities,
+			cp.budget_amount - COALESCE(cp.spent_amount, 0) AS remaining
+		FROM casting_promotions cp
+		JOIN castings c ON c.id = cp.casting_id
+		WHERE cp.status = 'active'
+		  AND cp.starts_at <= NOW() AND cp.ends_at >= NOW()
+		  AND COALESCE(cp.spent_amount, 0) < cp.budget_amount
+		  AND c.is_promoted = true AND c.status = 'active'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Candidate, len(rows))
+	for i, row := range rows {
+		out[i] = row.toCandidate()
+	}
+	return out, nil
+}
+
+// ViewerCity returns the city from the viewer's own profile, if any
+func (r *AdRepository) ViewerCity(ctx context.Context, userID uuid.UUID) (string, error) {
+	var city sql.NullString
+	err := r.db.GetContext(ctx, &city, `
+		SELECT COALESCE(
+			(SELECT city FROM model_profiles WHERE user_id = $1 LIMIT 1),
+			(SELECT city FROM employer_profiles WHERE user_id = $1 LIMIT 1)
+		)
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return city.String, err
+}
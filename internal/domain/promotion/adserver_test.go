@@ -0,0 +1,71 @@
+package promotion
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCandidateMatchesTargeting(t *testing.T) {
+	c := Candidate{Audience: "employers", Cities: []string{"Алматы", "Астана"}}
+	cases := []struct {
+		viewer Viewer
+		want   bool
+	}{
+		{Viewer{Role: "employer", City: "алматы"}, true},
+		{Viewer{Role: "agency", City: "Астана"}, true},
+		{Viewer{Role: "model", City: "Алматы"}, false},
+		{Viewer{Role: "employer", City: "Шымкент"}, false},
+		{Viewer{City: ""}, true},
+	}
+	for _, tc := range cases {
+		if got := c.Matches(tc.viewer); got != tc.want {
+			t.Fatalf("Matches(%+v) = %v, want %v", tc.viewer, got, tc.want)
+		}
+	}
+}
+
+func TestRotateFavoursRemainingBudget(t *testing.T) {
+	big := Candidate{EntityID: uuid.New(), Remaining: 9000}
+	small := Candidate{EntityID: uuid.New(), Remaining: 1000}
+	rnd := rand.New(rand.NewSource(1))
+
+	first := 0
+	for i := 0; i < 1000; i++ {
+		if rotate([]Candidate{small, big}, rnd)[0].EntityID == big.EntityID {
+			first++
+		}
+	}
+	if first < 850 || first > 950 {
+		t.Fatalf("expected the bigger budget first about 90%% of the time, got %d/1000", first)
+	}
+}
+
+func TestPickAppliesFrequencyCap(t *testing.T) {
+	s := NewAdServer(nil, nil, nil, AdConfig{Slots: 2, FrequencyCap: 2, FrequencyWindow: time.Hour})
+	cands := []Candidate{
+		{PromotionID: uuid.New(), EntityID: uuid.New(), Remaining: 100},
+		{PromotionID: uuid.New(), EntityID: uuid.New(), Remaining: 100},
+		{PromotionID: uuid.New(), EntityID: uuid.New(), Remaining: 100},
+	}
+	viewer := Viewer{Key: "u:1"}
+
+	shown := map[uuid.UUID]int{}
+	for i := 0; i < 10; i++ {
+		ids := s.pick(context.Background(), cands, viewer)
+		if len(ids) > 2 {
+			t.Fatalf("expected at most two slots, got %d", len(ids))
+		}
+		for _, id := range ids {
+			shown[id]++
+		}
+	}
+	for _, c := range cands {
+		if shown[c.EntityID] != 2 {
+			t.Fatalf("expected each promotion shown exactly twice (the cap), got %v", shown)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package promotion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/domain/credit"
+)
+
+// Pricing is what served promotions pay per event, in budget units (credits)
+type Pricing struct {
+	CPM int64 // per 1000 impressions
+	CPC int64 // per click
+}
+
+// Cost returns the spend for a day's counters. Impressions are billed in
+// whole credits, so the fractional remainder carries over within the day.
+func (p Pricing) Cost(impressions, clicks int) int64 {
+	return int64(impressions)*p.CPM/1000 + int64(clicks)*p.CPC
+}
+
+// chargeable caps an increment of spend by the room left in the total and daily budgets
+func chargeable(increment, budget, spent int64, daily *int64, spentToday int64) int64 {
+	if room := budget - spent; increment > room {
+		increment = room
+	}
+	if daily != nil {
+		if room := *daily - spentToday; increment > room {
+			increment = room
+		}
+	}
+	if increment < 0 {
+		return 0
+	}
+	return increment
+}
+
+// pacingHeadroom lets a promotion spend slightly ahead of an even pace so it
+// is served right after midnight
+const pacingHeadroom = time.Hour
+
+// pacedShare returns the part of a daily budget that may be spent by now
+// when spend is spread evenly across the day
+func pacedShare(now time.Time) float64 {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	share := float64(now.Sub(midnight)+pacingHeadroom) / float64(24*time.Hour)
+	if share > 1 {
+		return 1
+	}
+	return share
+}
+
+// refundCastingPromotion returns the unspent prepaid budget of a cancelled or
+// completed casting promotion to the employer. Safe to call repeatedly.
+func refundCastingPromotion(ctx context.Context, repo *CastingRepository, creditSvc credit.Service, id uuid.UUID) (int64, error) {
+	userID, amount, err := repo.ClaimRefund(ctx, id)
+	if err != nil || amount == 0 {
+		return 0, err
+	}
+	if err := creditSvc.Add(ctx, userID, int(amount), credit.TransactionTypeRefund, credit.TransactionMeta{
+		Description:       fmt.Sprintf("Возврат неизрасходованного бюджета продвижения (ID: %s)", id),
+		RelatedEntityType: "casting_promotion",
+		RelatedEntityID:   id,
+	}); err != nil {
+		if releaseErr := repo.ReleaseRefundClaim(ctx, id); releaseErr != nil {
+			return 0, fmt.Errorf("refund failed: %v; release claim: %w", err, releaseErr)
+		}
+		return 0, err
+	}
+	return amount, nil
+}
@@ -0,0 +1,50 @@
+package promotion
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPricingCost(t *testing.T) {
+	p := Pricing{CPM: 50, CPC: 5}
+	if got := p.Cost(1999, 3); got != 99+15 {
+		t.Fatalf("expected 114, got %d", got)
+	}
+	if got := p.Cost(19, 0); got != 0 {
+		t.Fatalf("expected partial impressions to stay unbilled, got %d", got)
+	}
+}
+
+func TestChargeableCapsByBudgets(t *testing.T) {
+	daily := int64(100)
+	cases := []struct {
+		name                               string
+		increment, budget, spent, spentDay int64
+		daily                              *int64
+		want                               int64
+	}{
+		{"within budgets", 10, 1000, 0, 0, &daily, 10},
+		{"total budget", 50, 1000, 980, 0, nil, 20},
+		{"daily budget", 50, 1000, 0, 90, &daily, 10},
+		{"exhausted", 50, 1000, 1000, 0, nil, 0},
+		{"negative increment", -5, 1000, 0, 0, nil, 0},
+	}
+	for _, c := range cases {
+		if got := chargeable(c.increment, c.budget, c.spent, c.daily, c.spentDay); got != c.want {
+			t.Fatalf("%s: expected %d, got %d", c.name, c.want, got)
+		}
+	}
+}
+
+func TestPacedShare(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got := pacedShare(day); got != 1.0/24 {
+		t.Fatalf("expected one hour of headroom at midnight, got %v", got)
+	}
+	if got := pacedShare(day.Add(11 * time.Hour)); got != 0.5 {
+		t.Fatalf("expected half the daily budget at 11:00, got %v", got)
+	}
+	if got := pacedShare(day.Add(23*time.Hour + 30*time.Minute)); got != 1 {
+		t.Fatalf("expected the full daily budget late in the day, got %v", got)
+	}
+}
@@ -15,40 +15,42 @@ type CreateCastingPromotionRequest struct {
 
 // CastingPromotionResponse for API response
 type CastingPromotionResponse struct {
-	ID           string  `json:"id"`
-	CastingID    string  `json:"casting_id"`
-	EmployerID   string  `json:"employer_id"`
-	CustomTitle  string  `json:"custom_title,omitempty"`
-	BudgetAmount int64   `json:"budget_amount"`
-	DailyBudget  *int64  `json:"daily_budget,omitempty"`
-	DurationDays int     `json:"duration_days"`
-	Status       string  `json:"status"`
-	StartsAt     string  `json:"starts_at,omitempty"`
-	EndsAt       string  `json:"ends_at,omitempty"`
-	Impressions  int     `json:"impressions"`
-	Clicks       int     `json:"clicks"`
-	Responses    int     `json:"responses"`
-	SpentAmount  int64   `json:"spent_amount"`
-	CTR          float64 `json:"ctr"`
-	CreatedAt    string  `json:"created_at"`
-	UpdatedAt    string  `json:"updated_at"`
+	ID             string  `json:"id"`
+	CastingID      string  `json:"casting_id"`
+	EmployerID     string  `json:"employer_id"`
+	CustomTitle    string  `json:"custom_title,omitempty"`
+	BudgetAmount   int64   `json:"budget_amount"`
+	DailyBudget    *int64  `json:"daily_budget,omitempty"`
+	DurationDays   int     `json:"duration_days"`
+	Status         string  `json:"status"`
+	StartsAt       string  `json:"starts_at,omitempty"`
+	EndsAt         string  `json:"ends_at,omitempty"`
+	Impressions    int     `json:"impressions"`
+	Clicks         int     `json:"clicks"`
+	Responses      int     `json:"responses"`
+	SpentAmount    int64   `json:"spent_amount"`
+	RefundedAmount int64   `json:"refunded_amount"`
+	CTR            float64 `json:"ctr"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
 }
 
 // ToCastingPromotionResponse converts entity to response DTO
 func (cp *CastingPromotion) ToCastingPromotionResponse() *CastingPromotionResponse {
 	resp := &CastingPromotionResponse{
-		ID:           cp.ID.String(),
-		CastingID:    cp.CastingID.String(),
-		EmployerID:   cp.EmployerID.String(),
-		BudgetAmount: cp.BudgetAmount,
-		DurationDays: cp.DurationDays,
-		Status:       string(cp.Status),
-		Impressions:  cp.Impressions,
-		Clicks:       cp.Clicks,
-		Responses:    cp.Responses,
-		SpentAmount:  cp.SpentAmount,
-		CreatedAt:    cp.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    cp.UpdatedAt.Format(time.RFC3339),
+		ID:             cp.ID.String(),
+		CastingID:      cp.CastingID.String(),
+		EmployerID:     cp.EmployerID.String(),
+		BudgetAmount:   cp.BudgetAmount,
+		DurationDays:   cp.DurationDays,
+		Status:         string(cp.Status),
+		Impressions:    cp.Impressions,
+		Clicks:         cp.Clicks,
+		Responses:      cp.Responses,
+		SpentAmount:    cp.SpentAmount,
+		RefundedAmount: cp.RefundedAmount,
+		CreatedAt:      cp.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      cp.UpdatedAt.Format(time.RFC3339),
 	}
 
 	if cp.CustomTitle.Valid {
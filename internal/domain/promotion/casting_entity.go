@@ -34,7 +34,9 @@ type CastingPromotion struct {
 	SpentAmount int64 `db:"spent_amount"`
 
 	// Payment
-	PaymentID *uuid.UUID `db:"payment_id"`
+	PaymentID      *uuid.UUID `db:"payment_id"`
+	PrepaidAmount  int64      `db:"prepaid_amount"`  // credits charged on first activation
+	RefundedAmount int64      `db:"refunded_amount"` // unspent credits returned on cancel/complete
 
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
@@ -50,6 +52,11 @@ func (cp *CastingPromotion) CanBeActivated() bool {
 	return cp.Status == StatusDraft || cp.Status == StatusPaused
 }
 
+// BudgetExhausted returns true once spend has reached the budget
+func (cp *CastingPromotion) BudgetExhausted() bool {
+	return cp.SpentAmount >= cp.BudgetAmount
+}
+
 // CastingPromotionDailyStats represents daily stats for a casting promotion
 type CastingPromotionDailyStats struct {
 	ID          uuid.UUID `db:"id"`
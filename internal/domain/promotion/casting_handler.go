@@ -14,6 +14,7 @@ import (
 	"github.com/mwork/mwork-api/internal/domain/credit"
 	"github.com/mwork/mwork-api/internal/middleware"
 	"github.com/mwork/mwork-api/internal/pkg/response"
+	"github.com/rs/zerolog/log"
 )
 
 // CastingPromotionHandler handles HTTP endpoints for casting promotions
@@ -217,19 +218,35 @@ func (h *CastingPromotionHandler) Activate(w http.ResponseWriter, r *http.Reques
 		response.BadRequest(w, "promotion cannot be activated in its current status")
 		return
 	}
-
-	// Deduct credits from user balance
-	cost := int(cp.BudgetAmount)
-	if err := h.creditService.Deduct(r.Context(), userID, cost, credit.TransactionMeta{
-		Description:       fmt.Sprintf("Продвижение кастинга (ID: %s, %d дней)", cp.CastingID, cp.DurationDays),
-		RelatedEntityType: "casting_promotion",
-		RelatedEntityID:   cp.ID,
-	}); err != nil {
-		response.Error(w, http.StatusPaymentRequired, "INSUFFICIENT_CREDITS",
-			"Недостаточно кредитов для активации продвижения")
+	if cp.BudgetExhausted() {
+		response.BadRequest(w, "promotion budget is exhausted")
 		return
 	}
 
+	// The budget is charged once; resuming a paused promotion spends what is left of it.
+	// Claiming before the charge keeps concurrent activations from charging twice.
+	if cp.PrepaidAmount == 0 {
+		claimed, err := h.repo.ClaimPrepaid(r.Context(), id, cp.BudgetAmount)
+		if err != nil {
+			response.InternalError(w)
+			return
+		}
+		if claimed {
+			if err := h.creditService.Deduct(r.Context(), userID, int(cp.BudgetAmount), credit.TransactionMeta{
+				Description:       fmt.Sprintf("Продвижение кастинга (ID: %s, %d дней)", cp.CastingID, cp.DurationDays),
+				RelatedEntityType: "casting_promotion",
+				RelatedEntityID:   cp.ID,
+			}); err != nil {
+				if releaseErr := h.repo.ReleasePrepaid(r.Context(), id, cp.BudgetAmount); releaseErr != nil {
+					log.Error().Err(releaseErr).Str("promotion_id", id.String()).Msg("Failed to release casting promotion prepaid claim")
+				}
+				response.Error(w, http.StatusPaymentRequired, "INSUFFICIENT_CREDITS",
+					"Недостаточно кредитов для активации продвижения")
+				return
+			}
+		}
+	}
+
 	now := time.Now()
 	startsAt := sql.NullTime{Time: now, Valid: true}
 	endsAt := sql.NullTime{Time: now.AddDate(0, 0, cp.DurationDays), Valid: true}
@@ -239,7 +256,11 @@ func (h *CastingPromotionHandler) Activate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	cp, _ = h.repo.GetByID(r.Context(), id)
+	cp, err = h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
 	response.OK(w, cp.ToCastingPromotionResponse())
 }
 
@@ -271,6 +292,67 @@ func (h *CastingPromotionHandler) Pause(w http.ResponseWriter, r *http.Request)
 	response.OK(w, map[string]string{"status": "paused"})
 }
 
+// Cancel stops a casting promotion for good and refunds the unspent budget
+// @Summary Отменить продвижение кастинга (возврат неизрасходованного бюджета)
+// @Tags CastingPromotion
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID продвижения"
+// @Success 200 {object} response.Response{data=CastingPromotionResponse}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /casting-promotions/{id}/cancel [post]
+func (h *CastingPromotionHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid promotion id")
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+
+	cp, err := h.repo.GetByID(r.Context(), id)
+	if err == ErrPromotionNotFound {
+		response.NotFound(w, "promotion not found")
+		return
+	}
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	if err := h.repo.VerifyCastingOwner(r.Context(), cp.CastingID, userID); err != nil {
+		if err == ErrNotPromotionOwner {
+			response.Forbidden(w, "you can only cancel promotions of your own castings")
+			return
+		}
+		response.InternalError(w)
+		return
+	}
+	if cp.Status == StatusCompleted || cp.Status == StatusCancelled {
+		response.BadRequest(w, "promotion is already finished")
+		return
+	}
+
+	if err := h.repo.UpdateStatus(r.Context(), id, StatusCancelled); err != nil {
+		response.InternalError(w)
+		return
+	}
+	// A failed refund is retried by the promotion worker
+	if _, err := refundCastingPromotion(r.Context(), h.repo, h.creditService, id); err != nil {
+		log.Error().Err(err).Str("promotion_id", id.String()).Msg("Failed to refund cancelled casting promotion")
+	}
+
+	cp, err = h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	response.OK(w, cp.ToCastingPromotionResponse())
+}
+
 // GetStats returns analytics for a casting promotion
 // @Summary Статистика продвижения кастинга
 // @Tags CastingPromotion
@@ -337,6 +419,7 @@ func CastingPromotionRoutes(h *CastingPromotionHandler, authMiddleware func(http
 		r.Post("/", h.Create)
 		r.Post("/{id}/activate", h.Activate)
 		r.Post("/{id}/pause", h.Pause)
+		r.Post("/{id}/cancel", h.Cancel)
 		r.Get("/{id}/stats", h.GetStats)
 	})
 	// Make the r.Context() usable in functions (suppress unused warning)
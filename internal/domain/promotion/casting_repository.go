@@ -75,7 +75,7 @@ func (r *CastingRepository) GetByID(ctx context.Context, id uuid.UUID) (*Casting
 		SELECT id, casting_id, employer_id, custom_title, custom_photo_url,
 			budget_amount, daily_budget, duration_days, status,
 			starts_at, ends_at, impressions, clicks, responses,
-			spent_amount, payment_id, created_at, updated_at
+			spent_amount, payment_id, prepaid_amount, refunded_amount, created_at, updated_at
 		FROM casting_promotions WHERE id = $1
 	`
 	var cp CastingPromotion
@@ -85,7 +85,7 @@ func (r *CastingRepository) GetByID(ctx context.Context, id uuid.UUID) (*Casting
 		&cp.ID, &cp.CastingID, &cp.EmployerID, &cp.CustomTitle, &cp.CustomPhotoURL,
 		&cp.BudgetAmount, &cp.DailyBudget, &cp.DurationDays, &cp.Status,
 		&cp.StartsAt, &cp.EndsAt, &cp.Impressions, &cp.Clicks, &cp.Responses,
-		&cp.SpentAmount, &paymentIDNull, &cp.CreatedAt, &cp.UpdatedAt,
+		&cp.SpentAmount, &paymentIDNull, &cp.PrepaidAmount, &cp.RefundedAmount, &cp.CreatedAt, &cp.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -108,7 +108,7 @@ func (r *CastingRepository) GetByCastingID(ctx context.Context, castingID uuid.U
 		SELECT id, casting_id, employer_id, custom_title, custom_photo_url,
 			budget_amount, daily_budget, duration_days, status,
 			starts_at, ends_at, impressions, clicks, responses,
-			spent_amount, payment_id, created_at, updated_at
+			spent_amount, payment_id, prepaid_amount, refunded_amount, created_at, updated_at
 		FROM casting_promotions WHERE casting_id = $1
 		ORDER BY created_at DESC
 	`
@@ -126,7 +126,7 @@ func (r *CastingRepository) GetByCastingID(ctx context.Context, castingID uuid.U
 			&cp.ID, &cp.CastingID, &cp.EmployerID, &cp.CustomTitle, &cp.CustomPhotoURL,
 			&cp.BudgetAmount, &cp.DailyBudget, &cp.DurationDays, &cp.Status,
 			&cp.StartsAt, &cp.EndsAt, &cp.Impressions, &cp.Clicks, &cp.Responses,
-			&cp.SpentAmount, &paymentIDNull, &cp.CreatedAt, &cp.UpdatedAt,
+			&cp.SpentAmount, &paymentIDNull, &cp.PrepaidAmount, &cp.RefundedAmount, &cp.CreatedAt, &cp.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -146,7 +146,7 @@ func (r *CastingRepository) GetByEmployerID(ctx context.Context, employerID uuid
 		SELECT id, casting_id, employer_id, custom_title, custom_photo_url,
 			budget_amount, daily_budget, duration_days, status,
 			starts_at, ends_at, impressions, clicks, responses,
-			spent_amount, payment_id, created_at, updated_at
+			spent_amount, payment_id, prepaid_amount, refunded_amount, created_at, updated_at
 		FROM casting_promotions WHERE employer_id = $1
 		ORDER BY created_at DESC
 	`
@@ -164,7 +164,7 @@ func (r *CastingRepository) GetByEmployerID(ctx context.Context, employerID uuid
 			&cp.ID, &cp.CastingID, &cp.EmployerID, &cp.CustomTitle, &cp.CustomPhotoURL,
 			&cp.BudgetAmount, &cp.DailyBudget, &cp.DurationDays, &cp.Status,
 			&cp.StartsAt, &cp.EndsAt, &cp.Impressions, &cp.Clicks, &cp.Responses,
-			&cp.SpentAmount, &paymentIDNull, &cp.CreatedAt, &cp.UpdatedAt,
+			&cp.SpentAmount, &paymentIDNull, &cp.PrepaidAmount, &cp.RefundedAmount, &cp.CreatedAt, &cp.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -199,6 +199,79 @@ func (r *CastingRepository) Activate(ctx context.Context, id uuid.UUID, startsAt
 	return nil
 }
 
+// ClaimPrepaid records the credits about to be charged for the promotion budget.
+// Returns false if the budget was already charged, so concurrent activations charge once.
+func (r *CastingRepository) ClaimPrepaid(ctx context.Context, id uuid.UUID, amount int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE casting_promotions SET prepaid_amount = $2, updated_at = NOW()
+		WHERE id = $1 AND prepaid_amount = 0`, id, amount)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleasePrepaid undoes ClaimPrepaid when the charge did not go through
+func (r *CastingRepository) ReleasePrepaid(ctx context.Context, id uuid.UUID, amount int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE casting_promotions SET prepaid_amount = 0, updated_at = NOW()
+		WHERE id = $1 AND prepaid_amount = $2`, id, amount)
+	return err
+}
+
+// ClaimRefund marks the unspent prepaid budget of a cancelled or completed
+// promotion as refunded and returns the employer's user ID and the amount.
+// Returns zero if there is nothing to refund or it was already claimed.
+func (r *CastingRepository) ClaimRefund(ctx context.Context, id uuid.UUID) (uuid.UUID, int64, error) {
+	var out struct {
+		UserID uuid.UUID `db:"user_id"`
+		Amount int64     `db:"refunded_amount"`
+	}
+	err := r.db.GetContext(ctx, &out, `
+		UPDATE casting_promotions cp
+		SET refunded_amount = cp.prepaid_amount - LEAST(COALESCE(cp.spent_amount, 0), cp.prepaid_amount),
+			refunded_at = NOW(),
+			updated_at = NOW()
+		FROM employer_profiles ep
+		WHERE cp.id = $1
+		  AND ep.id = cp.employer_id
+		  AND cp.status IN ('completed', 'cancelled')
+		  AND cp.refunded_at IS NULL
+		  AND cp.prepaid_amount > COALESCE(cp.spent_amount, 0)
+		RETURNING ep.user_id, cp.refunded_amount
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, 0, nil
+	}
+	return out.UserID, out.Amount, err
+}
+
+// ReleaseRefundClaim undoes ClaimRefund after the credit refund failed
+func (r *CastingRepository) ReleaseRefundClaim(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE casting_promotions SET refunded_amount = 0, refunded_at = NULL, updated_at = NOW() WHERE id = $1
+	`, id)
+	return err
+}
+
+// ListRefundable returns cancelled or completed promotions with unspent prepaid budget
+func (r *CastingRepository) ListRefundable(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT id FROM casting_promotions
+		WHERE status IN ('completed', 'cancelled')
+		  AND refunded_at IS NULL
+		  AND prepaid_amount > COALESCE(spent_amount, 0)
+		ORDER BY updated_at
+		LIMIT $1
+	`, limit)
+	return ids, err
+}
+
 // UpdateStatus updates the status of a casting promotion
 func (r *CastingRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status Status) error {
 	query := `UPDATE casting_promotions SET status = $2, updated_at = NOW() WHERE id = $1`
@@ -17,6 +17,12 @@ type CreateRequest struct {
 	DurationDays   int      `json:"duration_days" validate:"required,gte=1,lte=90"`
 }
 
+// ClickRequest reports a click on a promoted profile or casting
+type ClickRequest struct {
+	Target string `json:"target" validate:"required,oneof=profile casting"`
+	ID     string `json:"id" validate:"required,uuid"`
+}
+
 // UpdateRequest for updating promotion
 type UpdateRequest struct {
 	Title          string   `json:"title" validate:"omitempty,min=5,max=255"`
@@ -67,6 +67,11 @@ func (p *Promotion) CanBeActivated() bool {
 	return p.Status == StatusDraft || p.Status == StatusPaused
 }
 
+// BudgetExhausted returns true once spend has reached the budget
+func (p *Promotion) BudgetExhausted() bool {
+	return p.SpentAmount >= p.BudgetAmount
+}
+
 // DailyStats represents daily promotion statistics
 type DailyStats struct {
 	ID          uuid.UUID `db:"id"`
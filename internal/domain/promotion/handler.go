@@ -18,6 +18,7 @@ import (
 type Handler struct {
 	repo      *Repository
 	validator *validator.Validate
+	tracker   *Tracker
 }
 
 // NewHandler creates new promotion handler
@@ -28,6 +29,11 @@ func NewHandler(repo *Repository) *Handler {
 	}
 }
 
+// SetTracker enables click tracking
+func (h *Handler) SetTracker(t *Tracker) {
+	h.tracker = t
+}
+
 // List returns user's promotions
 // @Summary Список промоций пользователя
 // @Tags Promotion
@@ -201,6 +207,10 @@ func (h *Handler) Activate(w http.ResponseWriter, r *http.Request) {
 		response.BadRequest(w, "promotion cannot be activated")
 		return
 	}
+	if promo.BudgetExhausted() {
+		response.BadRequest(w, "promotion budget is exhausted")
+		return
+	}
 
 	// In real implementation, check payment here
 	now := time.Now()
@@ -213,7 +223,11 @@ func (h *Handler) Activate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	promo, _ = h.repo.GetByID(r.Context(), id)
+	promo, err = h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		errorhandler.HandleError(r.Context(), w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", err)
+		return
+	}
 	response.OK(w, promo.ToResponse())
 }
 
@@ -246,6 +260,55 @@ func (h *Handler) Pause(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, map[string]string{"status": "paused"})
 }
 
+// Cancel stops a promotion for good
+// @Summary Отменить промоцию
+// @Tags Promotion
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID промоции"
+// @Success 200 {object} response.Response{data=Response}
+// @Failure 400,401,403,404,500 {object} response.Response
+// @Router /promotions/{id}/cancel [post]
+func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid promotion id")
+		return
+	}
+
+	promo, err := h.repo.GetByID(r.Context(), id)
+	if err == ErrPromotionNotFound {
+		response.NotFound(w, "promotion not found")
+		return
+	}
+	if err != nil {
+		errorhandler.HandleError(r.Context(), w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", err)
+		return
+	}
+
+	profileID, err := h.repo.GetProfileIDByUserID(r.Context(), middleware.GetUserID(r.Context()))
+	if err != nil || profileID != promo.ProfileID {
+		response.Forbidden(w, "you can only cancel your own promotions")
+		return
+	}
+	if promo.Status == StatusCompleted || promo.Status == StatusCancelled {
+		response.BadRequest(w, "promotion is already finished")
+		return
+	}
+
+	if err := h.repo.UpdateStatus(r.Context(), id, StatusCancelled); err != nil {
+		errorhandler.HandleError(r.Context(), w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", err)
+		return
+	}
+
+	promo, err = h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		errorhandler.HandleError(r.Context(), w, http.StatusInternalServerError, "INTERNAL_ERROR", "An unexpected error occurred", err)
+		return
+	}
+	response.OK(w, promo.ToResponse())
+}
+
 // GetStats returns promotion statistics
 // @Summary Статистика промоции
 // @Tags Promotion
@@ -303,12 +366,40 @@ func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, stats)
 }
 
-// Routes returns promotion routes
-func Routes(h *Handler, authMiddleware func(http.Handler) http.Handler) chi.Router {
+// TrackClick records a click on a promoted profile or casting; anonymous clicks are ignored
+// @Summary Зафиксировать клик по продвигаемому объекту
+// @Description Учитываются только клики авторизованных пользователей
+// @Tags Promotion
+// @Accept json
+// @Param request body ClickRequest true "Объект клика"
+// @Success 204
+// @Failure 400 {object} response.Response
+// @Router /promotions/click [post]
+func (h *Handler) TrackClick(w http.ResponseWriter, r *http.Request) {
+	var req ClickRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+	if err := h.validator.Struct(req); err != nil {
+		response.BadRequest(w, "target must be profile or casting and id a valid UUID")
+		return
+	}
+
+	if h.tracker != nil {
+		h.tracker.TrackClick(r, Target(req.Target), uuid.MustParse(req.ID))
+	}
+	response.NoContent(w)
+}
+
+// Routes returns promotion routes; clickLimit rate-limits click tracking and must run
+// after the viewer is identified so the budget is per user
+func Routes(h *Handler, authMiddleware, clickLimit func(http.Handler) http.Handler) chi.Router {
 	r := chi.NewRouter()
 
 	// Public promotion card/details endpoint
 	r.Get("/{id}", h.Get)
+	r.With(clickLimit).Post("/click", h.TrackClick)
 
 	// Authenticated promotion management endpoints
 	r.Group(func(r chi.Router) {
@@ -318,6 +409,7 @@ func Routes(h *Handler, authMiddleware func(http.Handler) http.Handler) chi.Rout
 		r.Post("/", h.Create)
 		r.Post("/{id}/activate", h.Activate)
 		r.Post("/{id}/pause", h.Pause)
+		r.Post("/{id}/cancel", h.Cancel)
 		r.Get("/{id}/stats", h.GetStats)
 	})
 
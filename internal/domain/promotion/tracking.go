@@ -0,0 +1,376 @@
+package promotion
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/middleware"
+)
+
+// Target is the kind of promoted entity an event refers to
+type Target string
+
+const (
+	TargetProfile Target = "profile" // model profile, counted on profile_promotions
+	TargetCasting Target = "casting" // casting, counted on casting_promotions
+)
+
+// EventKind is a tracked promotion event
+type EventKind string
+
+const (
+	EventImpression EventKind = "impression"
+	EventClick      EventKind = "click"
+	EventResponse   EventKind = "response"
+)
+
+// TrackingConfig controls event buffering and filtering
+type TrackingConfig struct {
+	FlushInterval    time.Duration
+	MaxBuffered      int           // distinct counters before an early flush
+	ImpressionWindow time.Duration // one impression per viewer and item within this window
+	ClickWindow      time.Duration // one click per viewer and item within this window
+	MaxFlushAttempts int           // failed flushes before a counter is dropped
+}
+
+// StatDelta is a buffered counter increment for one promoted entity and day
+type StatDelta struct {
+	Target      Target
+	EntityID    uuid.UUID // profile or casting ID; resolved to its active promotion on flush
+	Date        time.Time
+	Impressions int
+	Clicks      int
+	Responses   int
+
+	attempts int // failed flushes so far
+}
+
+type statKey struct {
+	target   Target
+	entityID uuid.UUID
+	date     string
+}
+
+// StatsWriter persists flushed counters
+type StatsWriter interface {
+	ApplyStats(ctx context.Context, deltas []StatDelta) error
+}
+
+// Deduper remembers which viewer already produced an event
+type Deduper interface {
+	// FirstSeen reports whether key was not seen within ttl and remembers it
+	FirstSeen(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// Tracker counts promotion impressions, clicks and responses in memory and
+// flushes them in batches to the daily stats tables
+type Tracker struct {
+	writer StatsWriter
+	dedupe Deduper
+	cfg    TrackingConfig
+
+	mu     sync.Mutex
+	buffer map[statKey]*StatDelta
+	kick   chan struct{}
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewTracker creates a promotion event tracker
+func NewTracker(writer StatsWriter, dedupe Deduper, cfg TrackingConfig) *Tracker {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 30 * time.Second
+	}
+	if cfg.MaxBuffered <= 0 {
+		cfg.MaxBuffered = 5000
+	}
+	if cfg.ImpressionWindow <= 0 {
+		cfg.ImpressionWindow = time.Hour
+	}
+	if cfg.ClickWindow <= 0 {
+		cfg.ClickWindow = 24 * time.Hour
+	}
+	if cfg.MaxFlushAttempts <= 0 {
+		cfg.MaxFlushAttempts = 10
+	}
+	if dedupe == nil {
+		dedupe = NewMemoryDeduper()
+	}
+	return &Tracker{
+		writer: writer,
+		dedupe: dedupe,
+		cfg:    cfg,
+		buffer: make(map[statKey]*StatDelta),
+		kick:   make(chan struct{}, 1),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// TrackProfileImpressions records that promoted model profiles were served
+func (t *Tracker) TrackProfileImpressions(r *http.Request, profileIDs []uuid.UUID) {
+	t.trackRequest(r, TargetProfile, EventImpression, profileIDs)
+}
+
+// TrackCastingImpressions records that promoted castings were served
+func (t *Tracker) TrackCastingImpressions(r *http.Request, castingIDs []uuid.UUID) {
+	t.trackRequest(r, TargetCasting, EventImpression, castingIDs)
+}
+
+// TrackClick records a click on a promoted item. Returns false if it was filtered.
+// Clicks are billed, so only signed-in ones count: anonymous viewer keys come from
+// the IP and user agent, which a caller can vary to drain a competitor's budget.
+func (t *Tracker) TrackClick(r *http.Request, target Target, entityID uuid.UUID) bool {
+	if middleware.GetUserID(r.Context()) == uuid.Nil {
+		return false
+	}
+	return t.trackRequest(r, target, EventClick, []uuid.UUID{entityID}) > 0
+}
+
+// TrackCastingResponse attributes a model's application to the casting's promotion.
+// A model can apply once, so the model is the dedupe key.
+func (t *Tracker) TrackCastingResponse(ctx context.Context, castingID, modelUserID uuid.UUID) {
+	t.record(ctx, TargetCasting, EventResponse, castingID, "u:"+modelUserID.String(), time.Now())
+}
+
+func (t *Tracker) trackRequest(r *http.Request, target Target, kind EventKind, ids []uuid.UUID) int {
+	if len(ids) == 0 || IsBot(r.UserAgent()) {
+		return 0
+	}
+	viewer := middleware.ViewerKey(r)
+	now := time.Now()
+	counted := 0
+	for _, id := range ids {
+		if t.record(r.Context(), target, kind, id, viewer, now) {
+			counted++
+		}
+	}
+	return counted
+}
+
+func (t *Tracker) record(ctx context.Context, target Target, kind EventKind, entityID uuid.UUID, viewer string, at time.Time) bool {
+	window := t.cfg.ImpressionWindow
+	switch kind {
+	case EventClick:
+		window = t.cfg.ClickWindow
+	case EventResponse:
+		window = 30 * 24 * time.Hour
+	}
+	key := "promo:seen:" + string(kind) + ":" + string(target) + ":" + entityID.String() + ":" + viewer
+	first, err := t.dedupe.FirstSeen(ctx, key, window)
+	if err != nil {
+		// Count rather than lose the event when the dedupe store is down
+		log.Warn().Err(err).Msg("Promotion event dedupe failed")
+	} else if !first {
+		return false
+	}
+	t.add(target, kind, entityID, at)
+	return true
+}
+
+func (t *Tracker) add(target Target, kind EventKind, entityID uuid.UUID, at time.Time) {
+	day := at.Format("2006-01-02")
+	k := statKey{target: target, entityID: entityID, date: day}
+
+	t.mu.Lock()
+	d, ok := t.buffer[k]
+	if !ok {
+		date, _ := time.Parse("2006-01-02", day)
+		d = &StatDelta{Target: target, EntityID: entityID, Date: date}
+		t.buffer[k] = d
+	}
+	switch kind {
+	case EventImpression:
+		d.Impressions++
+	case EventClick:
+		d.Clicks++
+	case EventResponse:
+		d.Responses++
+	}
+	full := len(t.buffer) >= t.cfg.MaxBuffered
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush writes buffered counters in a fixed order, so concurrent flushes lock the
+// promotion rows in the same order. On failure they are merged back for the next
+// flush; counters failing MaxFlushAttempts times are dropped so one bad row can't
+// block the rest forever.
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.buffer) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	pending := t.buffer
+	t.buffer = make(map[statKey]*StatDelta, len(pending))
+	t.mu.Unlock()
+
+	deltas := make([]StatDelta, 0, len(pending))
+	for _, d := range pending {
+		deltas = append(deltas, *d)
+	}
+	sortDeltas(deltas)
+	if err := t.writer.ApplyStats(ctx, deltas); err != nil {
+		dropped := 0
+		t.mu.Lock()
+		for k, d := range pending {
+			d.attempts++
+			if d.attempts >= t.cfg.MaxFlushAttempts {
+				dropped++
+				continue
+			}
+			if cur, ok := t.buffer[k]; ok {
+				cur.Impressions += d.Impressions
+				cur.Clicks += d.Clicks
+				cur.Responses += d.Responses
+				cur.attempts = max(cur.attempts, d.attempts)
+			} else {
+				t.buffer[k] = d
+			}
+		}
+		t.mu.Unlock()
+		if dropped > 0 {
+			log.Error().Err(err).Int("dropped", dropped).Msg("Dropped promotion counters after repeated flush failures")
+		}
+		return err
+	}
+	return nil
+}
+
+// sortDeltas orders deltas by target, entity and date
+func sortDeltas(deltas []StatDelta) {
+	sort.Slice(deltas, func(i, j int) bool {
+		a, b := deltas[i], deltas[j]
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		if c := bytes.Compare(a.EntityID[:], b.EntityID[:]); c != 0 {
+			return c < 0
+		}
+		return a.Date.Before(b.Date)
+	})
+}
+
+// Start begins periodic flushing
+func (t *Tracker) Start() {
+	log.Info().Msg("Starting promotion tracker...")
+	go t.loop()
+}
+
+// Stop flushes what is buffered and stops the tracker
+func (t *Tracker) Stop() {
+	log.Info().Msg("Stopping promotion tracker...")
+	close(t.stopCh)
+	<-t.doneCh
+}
+
+func (t *Tracker) loop() {
+	defer close(t.doneCh)
+	ticker := time.NewTicker(t.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.flush()
+		case <-t.kick:
+			t.flush()
+		case <-t.stopCh:
+			t.flush()
+			return
+		}
+	}
+}
+
+func (t *Tracker) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := t.Flush(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush promotion stats")
+	}
+	if m, ok := t.dedupe.(*MemoryDeduper); ok {
+		m.prune(time.Now())
+	}
+}
+
+var botMarkers = []string{
+	"bot", "crawler", "spider", "slurp", "crawl", "headless", "phantomjs", "lighthouse",
+	"curl", "wget", "python-requests", "python-urllib", "go-http-client", "okhttp", "java/", "facebookexternalhit", "preview",
+}
+
+// IsBot reports whether the user agent belongs to a crawler or script
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, marker := range botMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryDeduper keeps seen keys in process memory; used when Redis is not configured
+type MemoryDeduper struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewMemoryDeduper creates an in-memory deduper
+func NewMemoryDeduper() *MemoryDeduper {
+	return &MemoryDeduper{seen: make(map[string]time.Time)}
+}
+
+func (m *MemoryDeduper) FirstSeen(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if until, ok := m.seen[key]; ok && now.Before(until) {
+		return false, nil
+	}
+	m.seen[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (m *MemoryDeduper) prune(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, until := range m.seen {
+		if !now.Before(until) {
+			delete(m.seen, k)
+		}
+	}
+}
+
+// RedisDeduper shares seen keys between API instances
+type RedisDeduper struct {
+	client *redis.Client
+}
+
+// NewRedisDeduper creates a Redis-backed deduper
+func NewRedisDeduper(client *redis.Client) *RedisDeduper {
+	return &RedisDeduper{client: client}
+}
+
+func (d *RedisDeduper) FirstSeen(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return d.client.SetNX(ctx, key, 1, ttl).Result()
+}
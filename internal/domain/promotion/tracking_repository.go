@@ -0,0 +1,171 @@
+package promotion
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// TrackingRepository writes flushed promotion counters to the daily stats tables
+// and bills them against the promotion budget
+type TrackingRepository struct {
+	db      *sqlx.DB
+	pricing Pricing
+}
+
+// NewTrackingRepository creates a new tracking repository
+func NewTrackingRepository(db *sqlx.DB, pricing Pricing) *TrackingRepository {
+	return &TrackingRepository{db: db, pricing: pricing}
+}
+
+// promoTables names the tables behind one promotion target
+type promoTables struct {
+	promotions string // promotions table
+	column     string // promotions column referencing the entity
+	stats      string // daily stats table
+	entities   string // promoted entities, carrying is_promoted
+}
+
+var statsTables = map[Target]promoTables{
+	TargetProfile: {"profile_promotions", "profile_id", "promotion_daily_stats", "model_profiles"},
+	TargetCasting: {"casting_promotions", "casting_id", "casting_promotion_stats", "castings"},
+}
+
+type budgetRow struct {
+	ID          uuid.UUID     `db:"id"`
+	Budget      int64         `db:"budget_amount"`
+	DailyBudget sql.NullInt64 `db:"daily_budget"`
+	Spent       int64         `db:"spent_amount"`
+}
+
+type dayRow struct {
+	Impressions int   `db:"impressions"`
+	Clicks      int   `db:"clicks"`
+	Spent       int64 `db:"spent"`
+}
+
+// ApplyStats adds the deltas to the entity's active promotion, both to its
+// daily stats row and to the denormalized totals, and charges the day's
+// spend within the total and daily budgets. Entities without an active
+// promotion are skipped.
+func (r *TrackingRepository) ApplyStats(ctx context.Context, deltas []StatDelta) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, d := range deltas {
+		tables, ok := statsTables[d.Target]
+		if !ok {
+			continue
+		}
+
+		var promo budgetRow
+		err := tx.GetContext(ctx, &promo, fmt.Sprintf(`
+			SELECT id, budget_amount, daily_budget, COALESCE(spent_amount, 0) AS spent_amount
+			FROM %s
+			WHERE %s = $1 AND status = 'active'
+			ORDER BY starts_at DESC NULLS LAST
+			LIMIT 1
+			FOR UPDATE
+		`, tables.promotions, tables.column), d.EntityID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var day dayRow
+		err = tx.GetContext(ctx, &day, fmt.Sprintf(`
+			INSERT INTO %[1]s (promotion_id, date, impressions, clicks, responses)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (promotion_id, date) DO UPDATE
+			SET impressions = %[1]s.impressions + EXCLUDED.impressions,
+				clicks = %[1]s.clicks + EXCLUDED.clicks,
+				responses = %[1]s.responses + EXCLUDED.responses
+			RETURNING COALESCE(impressions, 0) AS impressions, COALESCE(clicks, 0) AS clicks, COALESCE(spent, 0) AS spent
+		`, tables.stats), promo.ID, d.Date, d.Impressions, d.Clicks, d.Responses)
+		if err != nil {
+			return err
+		}
+
+		var daily *int64
+		if promo.DailyBudget.Valid {
+			daily = &promo.DailyBudget.Int64
+		}
+		charge := chargeable(r.pricing.Cost(day.Impressions, day.Clicks)-day.Spent, promo.Budget, promo.Spent, daily, day.Spent)
+		if charge > 0 {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+				UPDATE %s SET spent = COALESCE(spent, 0) + $3 WHERE promotion_id = $1 AND date = $2
+			`, tables.stats), promo.ID, d.Date, charge); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			UPDATE %s
+			SET impressions = impressions + $2,
+				clicks = clicks + $3,
+				responses = responses + $4,
+				spent_amount = COALESCE(spent_amount, 0) + $5,
+				updated_at = NOW()
+			WHERE id = $1
+		`, tables.promotions), promo.ID, d.Impressions, d.Clicks, d.Responses, charge); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PauseExhausted pauses active promotions whose spend reached their budget
+func (r *TrackingRepository) PauseExhausted(ctx context.Context) (int64, error) {
+	var total int64
+	for _, tables := range statsTables {
+		result, err := r.db.ExecContext(ctx, fmt.Sprintf(`
+			UPDATE %s
+			SET status = 'paused', updated_at = NOW()
+			WHERE status = 'active' AND COALESCE(spent_amount, 0) >= budget_amount
+		`, tables.promotions))
+		if err != nil {
+			return total, err
+		}
+		count, _ := result.RowsAffected()
+		total += count
+	}
+	return total, nil
+}
+
+// SyncPacing sets is_promoted so only entities with an active promotion
+// under its budget and under the paced share of its daily budget are served
+func (r *TrackingRepository) SyncPacing(ctx context.Context, date time.Time, share float64) (int64, error) {
+	var total int64
+	for _, tables := range statsTables {
+		result, err := r.db.ExecContext(ctx, fmt.Sprintf(`
+			WITH eligible AS (
+				SELECT DISTINCT p.%[2]s AS entity_id
+				FROM %[1]s p
+				LEFT JOIN %[3]s s ON s.promotion_id = p.id AND s.date = $1
+				WHERE p.status = 'active'
+				  AND COALESCE(p.spent_amount, 0) < p.budget_amount
+				  AND (p.daily_budget IS NULL OR COALESCE(s.spent, 0) < p.daily_budget * $2)
+			)
+			UPDATE %[4]s e
+			SET is_promoted = e.id IN (SELECT entity_id FROM eligible)
+			WHERE (e.is_promoted OR e.id IN (SELECT entity_id FROM eligible))
+			  AND e.is_promoted IS DISTINCT FROM (e.id IN (SELECT entity_id FROM eligible))
+		`, tables.promotions, tables.column, tables.stats, tables.entities), date, share)
+		if err != nil {
+			return total, err
+		}
+		count, _ := result.RowsAffected()
+		total += count
+	}
+	return total, nil
+}
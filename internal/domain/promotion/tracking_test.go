@@ -0,0 +1,132 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/middleware"
+)
+
+type statsWriterStub struct {
+	batches [][]StatDelta
+	err     error
+}
+
+func (s *statsWriterStub) ApplyStats(ctx context.Context, deltas []StatDelta) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, deltas)
+	return nil
+}
+
+const browserUA = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148"
+
+func TestIsBot(t *testing.T) {
+	cases := map[string]bool{
+		"":                           true,
+		"Googlebot/2.1":              true,
+		"curl/8.4.0":                 true,
+		"Go-http-client/1.1":         true,
+		"Mozilla/5.0 HeadlessChrome": true,
+		browserUA:                    false,
+	}
+	for ua, want := range cases {
+		if got := IsBot(ua); got != want {
+			t.Fatalf("IsBot(%q) = %v, want %v", ua, got, want)
+		}
+	}
+}
+
+func TestTracker_DedupesPerViewerAndSkipsBots(t *testing.T) {
+	writer := &statsWriterStub{}
+	tracker := NewTracker(writer, nil, TrackingConfig{})
+	profileID := uuid.New()
+
+	for _, addr := range []string{"10.0.0.1:1000", "10.0.0.1:2000", "10.0.0.2:1000"} {
+		r := httptest.NewRequest("GET", "/profiles/models/promoted", nil)
+		r.RemoteAddr = addr
+		r.Header.Set("User-Agent", browserUA)
+		tracker.TrackProfileImpressions(r, []uuid.UUID{profileID})
+	}
+	bot := httptest.NewRequest("GET", "/profiles/models/promoted", nil)
+	bot.Header.Set("User-Agent", "Googlebot/2.1")
+	tracker.TrackProfileImpressions(bot, []uuid.UUID{profileID})
+
+	click := httptest.NewRequest("POST", "/promotions/click", nil)
+	click.Header.Set("User-Agent", browserUA)
+	if tracker.TrackClick(click, TargetProfile, profileID) {
+		t.Fatal("anonymous clicks must not be counted")
+	}
+	click = click.WithContext(context.WithValue(click.Context(), middleware.UserIDKey, uuid.New()))
+	if !tracker.TrackClick(click, TargetProfile, profileID) || tracker.TrackClick(click, TargetProfile, profileID) {
+		t.Fatal("expected only the first click from a viewer to count")
+	}
+
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(writer.batches) != 1 || len(writer.batches[0]) != 1 {
+		t.Fatalf("expected one batch with one counter, got %+v", writer.batches)
+	}
+	d := writer.batches[0][0]
+	if d.Target != TargetProfile || d.EntityID != profileID || d.Impressions != 2 || d.Clicks != 1 {
+		t.Fatalf("unexpected delta: %+v", d)
+	}
+}
+
+func TestTracker_FailedFlushKeepsCounters(t *testing.T) {
+	writer := &statsWriterStub{err: errors.New("db down")}
+	tracker := NewTracker(writer, nil, TrackingConfig{})
+	castingID := uuid.New()
+
+	tracker.TrackCastingResponse(context.Background(), castingID, uuid.New())
+	if err := tracker.Flush(context.Background()); err == nil {
+		t.Fatal("expected flush error")
+	}
+	tracker.TrackCastingResponse(context.Background(), castingID, uuid.New())
+
+	writer.err = nil
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(writer.batches) != 1 || writer.batches[0][0].Responses != 2 {
+		t.Fatalf("expected both responses in the retried flush, got %+v", writer.batches)
+	}
+}
+
+func TestTracker_FlushSortsAndDropsFailingCounters(t *testing.T) {
+	writer := &statsWriterStub{}
+	tracker := NewTracker(writer, nil, TrackingConfig{MaxFlushAttempts: 2})
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		tracker.add(TargetCasting, EventImpression, uuid.New(), now)
+		tracker.add(TargetProfile, EventImpression, uuid.New(), now)
+	}
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	batch := writer.batches[0]
+	for i := 1; i < len(batch); i++ {
+		a, b := batch[i-1], batch[i]
+		if a.Target > b.Target || (a.Target == b.Target && a.EntityID.String() > b.EntityID.String()) {
+			t.Fatalf("expected deltas sorted by target and entity, got %v before %v", a, b)
+		}
+	}
+
+	writer.err = errors.New("permanent")
+	tracker.add(TargetCasting, EventClick, uuid.New(), now)
+	for i := 0; i < 2; i++ {
+		if err := tracker.Flush(context.Background()); err == nil {
+			t.Fatal("expected flush error")
+		}
+	}
+	if len(tracker.buffer) != 0 {
+		t.Fatalf("expected the failing counter to be dropped, %d left", len(tracker.buffer))
+	}
+}
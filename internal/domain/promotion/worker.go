@@ -5,12 +5,16 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/domain/credit"
 )
 
 // Worker handles background tasks for promotions
 type Worker struct {
 	profileRepo *Repository
 	castingRepo *CastingRepository
+	spendRepo   *TrackingRepository
+	creditSvc   credit.Service
 	interval    time.Duration
 	stopCh      chan struct{}
 }
@@ -28,6 +32,13 @@ func NewWorker(profileRepo *Repository, castingRepo *CastingRepository, interval
 	}
 }
 
+// SetBudget enables budget pacing, auto-pause on exhausted budgets and
+// refunds of unspent prepaid budgets
+func (w *Worker) SetBudget(spendRepo *TrackingRepository, creditSvc credit.Service) {
+	w.spendRepo = spendRepo
+	w.creditSvc = creditSvc
+}
+
 // Start begins the background worker
 func (w *Worker) Start() {
 	log.Info().Msg("Starting promotion worker...")
@@ -45,18 +56,25 @@ func (w *Worker) loop() {
 	defer ticker.Stop()
 
 	// Run once immediately on startup
-	w.processExpirations()
+	w.run()
 
 	for {
 		select {
 		case <-ticker.C:
-			w.processExpirations()
+			w.run()
 		case <-w.stopCh:
 			return
 		}
 	}
 }
 
+func (w *Worker) run() {
+	w.processExpirations()
+	if w.spendRepo != nil {
+		w.processBudgets()
+	}
+}
+
 func (w *Worker) processExpirations() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -81,3 +99,40 @@ func (w *Worker) processExpirations() {
 
 	log.Debug().Msg("Finished promotion expiration check")
 }
+
+func (w *Worker) processBudgets() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	paused, err := w.spendRepo.PauseExhausted(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to pause promotions with exhausted budget")
+	} else if paused > 0 {
+		log.Info().Int64("count", paused).Msg("Paused promotions with exhausted budget")
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if _, err := w.spendRepo.SyncPacing(ctx, today, pacedShare(now)); err != nil {
+		log.Error().Err(err).Msg("Failed to sync promotion pacing")
+	}
+
+	if w.creditSvc == nil {
+		return
+	}
+	ids, err := w.castingRepo.ListRefundable(ctx, 100)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list refundable casting promotions")
+		return
+	}
+	for _, id := range ids {
+		amount, err := refundCastingPromotion(ctx, w.castingRepo, w.creditSvc, id)
+		if err != nil {
+			log.Error().Err(err).Str("promotion_id", id.String()).Msg("Failed to refund unspent promotion budget")
+			continue
+		}
+		if amount > 0 {
+			log.Info().Str("promotion_id", id.String()).Int64("amount", amount).Msg("Refunded unspent promotion budget")
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/mwork/mwork-api/internal/domain/casting"
 	"github.com/mwork/mwork-api/internal/domain/credit"
 	"github.com/mwork/mwork-api/internal/domain/profile"
+	"github.com/mwork/mwork-api/internal/pkg/events"
 	"github.com/mwork/mwork-api/internal/pkg/featurepayment"
 )
 
@@ -23,6 +24,12 @@ type NotificationService interface {
 	NotifyResponseStatusChange(ctx context.Context, modelUserID uuid.UUID, castingTitle string, status string, castingID uuid.UUID, responseID uuid.UUID) error
 }
 
+// CastingClosedNotifier is optionally implemented by the notification service
+// to react to a casting closing once its last model is accepted
+type CastingClosedNotifier interface {
+	NotifyCastingClosed(ctx context.Context, employerUserID uuid.UUID, castingID uuid.UUID, castingTitle string, reason string) error
+}
+
 // ChatServiceInterface interface for chat room operations
 // This interface matches chat.Service to enable auto-creation of chat rooms on response acceptance
 type ChatServiceInterface interface {
@@ -55,6 +62,13 @@ type Service struct {
 	chatSvc         ChatServiceInterface
 	limitChecker    SubLimitChecker
 	userRepo        UserRepository
+	conversions     ConversionTracker
+	events          events.Emitter
+}
+
+// ConversionTracker attributes applications to casting promotions
+type ConversionTracker interface {
+	TrackCastingResponse(ctx context.Context, castingID, modelUserID uuid.UUID)
 }
 
 // UserRepository is the subset of user.Repository methods the response service needs.
@@ -108,6 +122,16 @@ func (s *Service) SetUserRepo(repo UserRepository) {
 	s.userRepo = repo
 }
 
+// SetConversionTracker enables response attribution for promoted castings.
+func (s *Service) SetConversionTracker(t ConversionTracker) {
+	s.conversions = t
+}
+
+// SetEventEmitter enables product events for applications and acceptances.
+func (s *Service) SetEventEmitter(e events.Emitter) {
+	s.events = e
+}
+
 // Apply applies to a casting using the Two-Buckets connect system:
 //  1. Validate casting requirements (BEFORE billing)
 //  2. Deduct 1 connect (free bucket first, then purchased bucket)
@@ -182,6 +206,13 @@ func (s *Service) Apply(ctx context.Context, userID uuid.UUID, castingID uuid.UU
 		return nil, err
 	}
 
+	if s.conversions != nil && cast.IsPromoted {
+		s.conversions.TrackCastingResponse(ctx, castingID, userID)
+	}
+	if s.events != nil {
+		s.events.Emit(ctx, events.ResponseCreated{ResponseID: resp.ID, CastingID: castingID, ModelUserID: userID})
+	}
+
 	// Send notification to employer about new response (async with panic guard)
 	if s.notifService != nil {
 		go func() {
@@ -265,7 +296,8 @@ func (s *Service) UpdateStatus(ctx context.Context, userID uuid.UUID, responseID
 			return nil, err
 		}
 
-		if _, _, err := s.castingRepo.IncrementAcceptedAndMaybeCloseTx(ctx, tx, cast.ID); err != nil {
+		_, castingStatus, err := s.castingRepo.IncrementAcceptedAndMaybeCloseTx(ctx, tx, cast.ID)
+		if err != nil {
 			if errors.Is(err, casting.ErrCastingFullOrClosed) {
 				return nil, ErrCastingFullOrClosed
 			}
@@ -275,6 +307,14 @@ func (s *Service) UpdateStatus(ctx context.Context, userID uuid.UUID, responseID
 		if err := tx.Commit(); err != nil {
 			return nil, err
 		}
+
+		if notifier, ok := s.notifService.(CastingClosedNotifier); ok && castingStatus == casting.StatusClosed {
+			go func() {
+				if err := notifier.NotifyCastingClosed(context.Background(), cast.CreatorID, cast.ID, cast.Title, "filled"); err != nil {
+					log.Error().Err(err).Str("casting_id", cast.ID.String()).Msg("[update_status goroutine] failed to notify casting closed")
+				}
+			}()
+		}
 	} else {
 		if err := s.repo.UpdateStatus(ctx, responseID, newStatus); err != nil {
 			return nil, err
@@ -284,6 +324,10 @@ func (s *Service) UpdateStatus(ctx context.Context, userID uuid.UUID, responseID
 	resp.Status = newStatus
 	resp.UpdatedAt = time.Now()
 
+	if s.events != nil && newStatus == StatusAccepted && oldStatus != StatusAccepted {
+		s.events.Emit(ctx, events.ResponseAccepted{ResponseID: resp.ID, CastingID: cast.ID, ModelUserID: resp.UserID, EmployerID: userID})
+	}
+
 	// Send notification to model about status change (async with panic guard)
 	if s.notifService != nil && (newStatus == StatusAccepted || newStatus == StatusRejected) {
 		go func() {
@@ -492,6 +536,52 @@ func CheckRequirements(cast *casting.Casting, prof *profile.ModelProfile) map[st
 	return violations
 }
 
+// RequirementMatch returns the share of the casting's requirements the profile
+// verifiably meets. CheckRequirements lets missing profile attributes pass;
+// here they count as unmet. A casting without requirements matches fully.
+func RequirementMatch(cast *casting.Casting, prof *profile.ModelProfile) float64 {
+	type requirement struct {
+		keys  []string // violation keys from CheckRequirements
+		set   bool     // casting sets the requirement
+		known bool     // profile has the attribute
+	}
+	reqs := []requirement{
+		{[]string{"gender"}, cast.RequiredGender.Valid && cast.RequiredGender.String != "", prof.Gender.Valid},
+		{[]string{"age_min", "age_max"}, cast.AgeMin.Valid || cast.AgeMax.Valid, prof.Age.Valid},
+		{[]string{"height_min", "height_max"}, cast.HeightMin.Valid || cast.HeightMax.Valid, prof.Height.Valid},
+		{[]string{"weight_min", "weight_max"}, cast.WeightMin.Valid || cast.WeightMax.Valid, prof.Weight.Valid},
+		{[]string{"hair_color"}, len(cast.RequiredHairColors) > 0, prof.HairColor.Valid},
+		{[]string{"eye_color"}, len(cast.RequiredEyeColors) > 0, prof.EyeColor.Valid},
+		{[]string{"clothing_size"}, len(cast.ClothingSizes) > 0, prof.ClothingSize.Valid},
+		{[]string{"shoe_size"}, len(cast.ShoeSizes) > 0, prof.ShoeSize.Valid},
+	}
+
+	violations := CheckRequirements(cast, prof)
+	total, met := 0, 0
+	for _, req := range reqs {
+		if !req.set {
+			continue
+		}
+		total++
+		if !req.known {
+			continue
+		}
+		violated := false
+		for _, k := range req.keys {
+			if _, ok := violations[k]; ok {
+				violated = true
+			}
+		}
+		if !violated {
+			met++
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(met) / float64(total)
+}
+
 // containsIgnoreCase checks if a string is in a slice (case-insensitive).
 func containsIgnoreCase(list []string, value string) bool {
 	for _, item := range list {
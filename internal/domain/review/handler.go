@@ -1,6 +1,7 @@
 package review
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -10,16 +11,23 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 
 	"github.com/mwork/mwork-api/internal/middleware"
 	"github.com/mwork/mwork-api/internal/pkg/errorhandler"
 	"github.com/mwork/mwork-api/internal/pkg/response"
 )
 
+// Notifier is notified about new reviews.
+type Notifier interface {
+	NotifyReviewReceived(ctx context.Context, targetType string, targetID, reviewID uuid.UUID, rating int) error
+}
+
 // Handler handles review HTTP requests.
 type Handler struct {
 	repo      *Repository
 	validator *validator.Validate
+	notifier  Notifier
 }
 
 // NewHandler creates a new review handler.
@@ -30,6 +38,11 @@ func NewHandler(repo *Repository) *Handler {
 	}
 }
 
+// SetNotifier sets the hook called after a review is created (optional).
+func (h *Handler) SetNotifier(n Notifier) {
+	h.notifier = n
+}
+
 // Create handles POST /reviews
 // @Summary Создать отзыв
 // @Tags Review
@@ -110,6 +123,14 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.notifier != nil {
+		go func() {
+			if err := h.notifier.NotifyReviewReceived(context.Background(), string(rev.TargetType), rev.TargetID, rev.ID, rev.Rating); err != nil {
+				log.Error().Err(err).Str("review_id", rev.ID.String()).Msg("failed to notify review received")
+			}
+		}()
+	}
+
 	response.Created(w, rev.ToResponse())
 }
 
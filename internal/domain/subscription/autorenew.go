@@ -0,0 +1,133 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SetAutoRenew turns auto-renewal of the user's active subscription on or off.
+// Turning it on requires a card saved by an earlier recurring payment.
+func (s *Service) SetAutoRenew(ctx context.Context, userID uuid.UUID, enabled bool) (*Subscription, error) {
+	sub, err := s.repo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil || sub.PlanID == PlanFree || sub.PlanID == PlanFreeEmployer || sub.PlanID == PlanFreeModel {
+		return nil, ErrSubscriptionNotFound
+	}
+	if enabled && !sub.RecurringInvID.Valid {
+		return nil, ErrAutoRenewUnavailable
+	}
+	if sub.AutoRenew == enabled {
+		return sub, nil
+	}
+	if err := s.repo.SetAutoRenew(ctx, sub.ID, enabled); err != nil {
+		return nil, err
+	}
+	sub.AutoRenew = enabled
+	sub.RenewalAttempts = 0
+	sub.NextRenewalAt = sql.NullTime{}
+
+	event := HistoryAutoRenewOff
+	if enabled {
+		event = HistoryAutoRenewOn
+	}
+	s.recordHistory(ctx, sub, transition{event: event, fromStatus: sub.Status, toStatus: sub.Status, fromPlan: sub.PlanID, toPlan: sub.PlanID, reason: "user"})
+	return sub, nil
+}
+
+// EnableRecurring remembers the recurring parent payment of a subscription and turns auto-renewal on
+func (s *Service) EnableRecurring(ctx context.Context, subscriptionID uuid.UUID, invID int64) error {
+	sub, err := s.repo.GetByID(ctx, subscriptionID)
+	if err != nil || sub == nil {
+		return ErrSubscriptionNotFound
+	}
+	if err := s.repo.SetRecurringParent(ctx, sub.ID, invID); err != nil {
+		return err
+	}
+	if !sub.AutoRenew {
+		s.recordHistory(ctx, sub, transition{event: HistoryAutoRenewOn, fromStatus: sub.Status, toStatus: sub.Status, fromPlan: sub.PlanID, toPlan: sub.PlanID, reason: "card saved"})
+	}
+	return nil
+}
+
+// ListDueForRenewal returns subscriptions whose auto-renewal charge is due
+func (s *Service) ListDueForRenewal(ctx context.Context, lead time.Duration, limit int) ([]*Subscription, error) {
+	return s.repo.ListDueForRenewal(ctx, lead, limit)
+}
+
+// RenewSubscription extends a paid renewal by one billing period from the current expiry.
+// A renewal confirmed after the plan already expired restarts it from now,
+// unless the user has moved to another plan meanwhile.
+func (s *Service) RenewSubscription(ctx context.Context, subscriptionID uuid.UUID) (*Subscription, error) {
+	sub, err := s.repo.GetByID(ctx, subscriptionID)
+	if err != nil || sub == nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	now := time.Now()
+
+	switch sub.Status {
+	case StatusActive:
+		base := now
+		if sub.ExpiresAt.Valid {
+			base = sub.ExpiresAt.Time
+		}
+		expiresAt := periodEnd(base, sub.BillingPeriod)
+		if err := s.repo.ExtendPeriod(ctx, sub.ID, expiresAt); err != nil {
+			return nil, err
+		}
+		sub.ExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+	case StatusExpired:
+		if current, err := s.repo.GetActiveByUserID(ctx, sub.UserID); err != nil {
+			return nil, err
+		} else if current != nil {
+			return nil, ErrNotRenewable
+		}
+		expiresAt := periodEnd(now, sub.BillingPeriod)
+		if err := s.repo.ExtendPeriod(ctx, sub.ID, expiresAt); err != nil {
+			return nil, err
+		}
+		sub.ExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+		if err := s.activate(ctx, sub, StatusExpired, StatusCancelled, "Renewed "+string(sub.PlanID)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrNotRenewable
+	}
+
+	sub.GraceUntil = sql.NullTime{}
+	sub.RenewalAttempts = 0
+	sub.NextRenewalAt = sql.NullTime{}
+	s.recordHistory(ctx, sub, transition{event: HistoryRenewed, fromStatus: StatusActive, toStatus: StatusActive, fromPlan: sub.PlanID, toPlan: sub.PlanID, reason: "auto-renewal"})
+	return sub, nil
+}
+
+// RecordRenewalFailure counts a failed auto-renewal charge and schedules the next attempt
+// after retryIntervals[attempt-1]. When the intervals run out auto-renewal is turned off
+// and the plan lapses normally. The updated subscription is returned.
+func (s *Service) RecordRenewalFailure(ctx context.Context, subscriptionID uuid.UUID, retryIntervals []time.Duration, reason string) (*Subscription, error) {
+	sub, err := s.repo.GetByID(ctx, subscriptionID)
+	if err != nil || sub == nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	attempt := sub.RenewalAttempts + 1
+	next := sql.NullTime{}
+	disable := attempt > len(retryIntervals)
+	if !disable {
+		next = sql.NullTime{Time: time.Now().Add(retryIntervals[attempt-1]), Valid: true}
+	}
+	if err := s.repo.RecordRenewalFailure(ctx, sub.ID, next, disable); err != nil {
+		return nil, err
+	}
+	s.recordHistory(ctx, sub, transition{event: HistoryRenewFailed, fromStatus: sub.Status, toStatus: sub.Status, fromPlan: sub.PlanID, toPlan: sub.PlanID, reason: reason})
+	if disable && sub.AutoRenew {
+		s.recordHistory(ctx, sub, transition{event: HistoryAutoRenewOff, fromStatus: sub.Status, toStatus: sub.Status, fromPlan: sub.PlanID, toPlan: sub.PlanID, reason: "renewal attempts exhausted"})
+	}
+	sub.RenewalAttempts = attempt
+	sub.NextRenewalAt = next
+	sub.AutoRenew = sub.AutoRenew && !disable
+	return sub, nil
+}
@@ -0,0 +1,91 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type renewRepoStub struct {
+	repoStub
+	sub      *Subscription
+	active   *Subscription
+	extended time.Time
+}
+
+func (r *renewRepoStub) GetByID(context.Context, uuid.UUID) (*Subscription, error) {
+	return r.sub, nil
+}
+func (r *renewRepoStub) GetActiveByUserID(context.Context, uuid.UUID) (*Subscription, error) {
+	return r.active, nil
+}
+func (r *renewRepoStub) ExtendPeriod(_ context.Context, _ uuid.UUID, expiresAt time.Time) error {
+	r.extended = expiresAt
+	return nil
+}
+
+func TestRenewSubscription_ExtendsFromExpiry(t *testing.T) {
+	expires := time.Now().Add(12 * time.Hour)
+	sub := &Subscription{ID: uuid.New(), PlanID: PlanPro, Status: StatusActive, BillingPeriod: BillingMonthly, ExpiresAt: sql.NullTime{Time: expires, Valid: true}, RenewalAttempts: 2}
+	repo := &renewRepoStub{sub: sub}
+	svc := &Service{repo: repo}
+
+	renewed, err := svc.RenewSubscription(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := expires.AddDate(0, 1, 0)
+	if !repo.extended.Equal(want) || !renewed.ExpiresAt.Time.Equal(want) || renewed.RenewalAttempts != 0 {
+		t.Fatalf("expected expiry %v, got %v (%+v)", want, repo.extended, renewed)
+	}
+}
+
+func TestRenewSubscription_ExpiredReplacedByOtherPlan(t *testing.T) {
+	sub := &Subscription{ID: uuid.New(), UserID: uuid.New(), PlanID: PlanPro, Status: StatusExpired, BillingPeriod: BillingMonthly}
+	repo := &renewRepoStub{sub: sub, active: &Subscription{ID: uuid.New(), PlanID: PlanAgency, Status: StatusActive}}
+	svc := &Service{repo: repo}
+
+	if _, err := svc.RenewSubscription(context.Background(), sub.ID); !errors.Is(err, ErrNotRenewable) {
+		t.Fatalf("expected ErrNotRenewable, got %v", err)
+	}
+	if !repo.extended.IsZero() {
+		t.Fatal("expired subscription must not be extended over another plan")
+	}
+}
+
+func TestRecordRenewalFailure_DisablesAfterLastRetry(t *testing.T) {
+	sub := &Subscription{ID: uuid.New(), PlanID: PlanPro, Status: StatusActive, AutoRenew: true, RenewalAttempts: 1}
+	svc := &Service{repo: &renewRepoStub{sub: sub}}
+	retries := []time.Duration{24 * time.Hour, 72 * time.Hour}
+
+	got, err := svc.RecordRenewalFailure(context.Background(), sub.ID, retries, "declined")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.AutoRenew || got.RenewalAttempts != 2 || !got.NextRenewalAt.Valid || time.Until(got.NextRenewalAt.Time) < 71*time.Hour {
+		t.Fatalf("expected second retry in 72h, got %+v", got)
+	}
+
+	got, err = svc.RecordRenewalFailure(context.Background(), sub.ID, retries, "declined")
+	if err != nil || got.AutoRenew || got.NextRenewalAt.Valid {
+		t.Fatalf("expected auto-renew off after the last retry, got %+v, %v", got, err)
+	}
+}
+
+func TestSetAutoRenew_RequiresSavedCard(t *testing.T) {
+	repo := &renewRepoStub{active: &Subscription{ID: uuid.New(), PlanID: PlanPro, Status: StatusActive}}
+	svc := &Service{repo: repo}
+
+	if _, err := svc.SetAutoRenew(context.Background(), uuid.New(), true); !errors.Is(err, ErrAutoRenewUnavailable) {
+		t.Fatalf("expected ErrAutoRenewUnavailable, got %v", err)
+	}
+	repo.active.RecurringInvID = sql.NullInt64{Int64: 1001, Valid: true}
+	sub, err := svc.SetAutoRenew(context.Background(), uuid.New(), true)
+	if err != nil || !sub.AutoRenew {
+		t.Fatalf("expected auto-renew on, got %+v, %v", sub, err)
+	}
+}
@@ -11,6 +11,13 @@ import (
 type SubscribeRequest struct {
 	PlanID        string `json:"plan_id" validate:"required,oneof=pro agency"`
 	BillingPeriod string `json:"billing_period" validate:"required,oneof=monthly yearly"`
+	PromoCode     string `json:"promo_code,omitempty" validate:"max=50"`
+	AutoRenew     bool   `json:"auto_renew,omitempty"` // save the card and renew automatically
+}
+
+// AutoRenewRequest for PUT /subscriptions/auto-renew
+type AutoRenewRequest struct {
+	Enabled bool `json:"enabled"`
 }
 
 // CancelRequest for POST /subscriptions/cancel
@@ -111,6 +118,8 @@ type SubscriptionResponse struct {
 	ExpiresAt     *string       `json:"expires_at,omitempty"`
 	DaysRemaining int           `json:"days_remaining"` // -1 = unlimited
 	AutoRenew     bool          `json:"auto_renew"`
+	InGrace       bool          `json:"in_grace"`
+	GraceUntil    *string       `json:"grace_until,omitempty"`
 }
 
 // SubscriptionResponseFromEntity converts subscription to response
@@ -122,13 +131,18 @@ func SubscriptionResponseFromEntity(s *Subscription, plan *Plan) *SubscriptionRe
 		BillingPeriod: string(s.BillingPeriod),
 		StartedAt:     s.StartedAt.Format(time.RFC3339),
 		DaysRemaining: s.DaysRemaining(),
-		AutoRenew:     s.Status == StatusActive && s.PlanID != PlanFree,
+		AutoRenew:     s.AutoRenew,
 	}
 
 	if s.ExpiresAt.Valid {
 		exp := s.ExpiresAt.Time.Format(time.RFC3339)
 		resp.ExpiresAt = &exp
 	}
+	if s.GraceUntil.Valid {
+		grace := s.GraceUntil.Time.Format(time.RFC3339)
+		resp.GraceUntil = &grace
+		resp.InGrace = s.InGrace(time.Now())
+	}
 
 	if plan != nil {
 		resp.Plan = PlanResponseFromEntity(plan)
@@ -151,3 +165,36 @@ type LimitsResponse struct {
 	CanSeeViewers      bool   `json:"can_see_viewers"`
 	PrioritySearch     bool   `json:"priority_search"`
 }
+
+// HistoryEntryResponse represents a subscription transition in API
+type HistoryEntryResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"`
+	Event          string     `json:"event"`
+	FromStatus     string     `json:"from_status,omitempty"`
+	ToStatus       string     `json:"to_status,omitempty"`
+	FromPlan       string     `json:"from_plan,omitempty"`
+	ToPlan         string     `json:"to_plan,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+	ByAdmin        bool       `json:"by_admin"`
+	CreatedAt      string     `json:"created_at"`
+}
+
+// HistoryEntryResponseFromEntity converts a history entry to response
+func HistoryEntryResponseFromEntity(e *HistoryEntry) *HistoryEntryResponse {
+	resp := &HistoryEntryResponse{
+		ID:         e.ID,
+		Event:      string(e.Event),
+		FromStatus: e.FromStatus.String,
+		ToStatus:   e.ToStatus.String,
+		FromPlan:   e.FromPlan.String,
+		ToPlan:     e.ToPlan.String,
+		Reason:     e.Reason.String,
+		ByAdmin:    e.ActorID.Valid,
+		CreatedAt:  e.CreatedAt.Format(time.RFC3339),
+	}
+	if e.SubscriptionID.Valid {
+		resp.SubscriptionID = &e.SubscriptionID.UUID
+	}
+	return resp
+}
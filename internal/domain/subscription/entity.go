@@ -34,6 +34,7 @@ const (
 	StatusCancelled Status = "cancelled"
 	StatusExpired   Status = "expired"
 	StatusPending   Status = "pending"
+	StatusScheduled Status = "scheduled" // paid, starts when the current period ends
 )
 
 // BillingPeriod represents billing cycle
@@ -109,6 +110,15 @@ type Subscription struct {
 	BillingPeriod BillingPeriod  `db:"billing_period" json:"billing_period"`
 	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt     time.Time      `db:"updated_at" json:"updated_at"`
+
+	GraceUntil       sql.NullTime  `db:"grace_until" json:"grace_until,omitempty"`
+	LastReminderDays sql.NullInt64 `db:"last_reminder_days" json:"-"`
+
+	// Auto-renewal charges the card saved by the recurring parent payment
+	AutoRenew       bool          `db:"auto_renew" json:"auto_renew"`
+	RecurringInvID  sql.NullInt64 `db:"recurring_inv_id" json:"-"`
+	RenewalAttempts int           `db:"renewal_attempts" json:"-"`
+	NextRenewalAt   sql.NullTime  `db:"next_renewal_at" json:"-"`
 }
 
 // IsExpired checks if subscription has expired
@@ -119,6 +129,12 @@ func (s *Subscription) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt.Time)
 }
 
+// InGrace reports whether the subscription has expired but is still inside its grace period
+func (s *Subscription) InGrace(now time.Time) bool {
+	return s.ExpiresAt.Valid && now.After(s.ExpiresAt.Time) &&
+		s.GraceUntil.Valid && now.Before(s.GraceUntil.Time)
+}
+
 // IsActive checks if subscription is active
 func (s *Subscription) IsActive() bool {
 	return s.Status == StatusActive && !s.IsExpired()
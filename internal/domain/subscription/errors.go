@@ -13,4 +13,7 @@ var (
 	ErrPlanAudienceMismatch = errors.New("plan audience does not match user role")
 	ErrInvalidLimitKey      = errors.New("invalid limit key")
 	ErrLimitWouldBeNegative = errors.New("limit would become negative")
+	ErrPromoCodeRejected    = errors.New("promo code rejected")
+	ErrAutoRenewUnavailable = errors.New("no saved card for auto-renewal")
+	ErrNotRenewable         = errors.New("subscription cannot be renewed")
 )
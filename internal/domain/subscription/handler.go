@@ -3,6 +3,7 @@ package subscription
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -44,6 +45,9 @@ type InitRobokassaPaymentRequest struct {
 	SubscriptionID uuid.UUID
 	Amount         string
 	Description    string
+	PlanID         PlanID
+	PromoCode      string
+	Recurring      bool // save the card for auto-renewal
 }
 
 type InitRobokassaPaymentResponse struct {
@@ -51,6 +55,8 @@ type InitRobokassaPaymentResponse struct {
 	InvID      int64
 	PaymentURL string
 	Status     string
+	Amount     float64 // charged amount after any promo discount
+	Discount   float64
 }
 
 // Config holds application configuration
@@ -117,6 +123,46 @@ func (h *Handler) GetCurrent(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, SubscriptionResponseFromEntity(sub, plan))
 }
 
+// GetHistory handles GET /subscriptions/history
+// @Summary История подписки
+// @Description Возвращает переходы подписки пользователя: оформление, активация, отмена, льготный период, истечение
+// @Tags Subscription
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Количество записей (по умолчанию 20, максимум 100)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} response.Response{data=[]HistoryEntryResponse}
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/history [get]
+func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+
+	limit, offset := 20, 0
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+
+	entries, err := h.service.GetHistory(r.Context(), userID, limit, offset)
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+
+	items := make([]*HistoryEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, HistoryEntryResponseFromEntity(e))
+	}
+	response.OK(w, items)
+}
+
 // GetLimits handles GET /subscriptions/limits
 // @Summary Лимиты и использование подписки
 // @Description Возвращает лимиты текущего тарифа и статистику их использования
@@ -209,47 +255,30 @@ func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get plan details first to calculate amount
-	planID := PlanID(req.PlanID)
-	plan, err := h.service.GetPlan(ctx, planID)
+	// Create subscription (status = pending) priced with proration;
+	// the current plan stays until the payment is confirmed
+	sub, quote, err := h.service.SubscribeWithQuote(ctx, userID, &req)
 	if err != nil {
-		if err == ErrPlanNotFound {
-			response.NotFound(w, "plan not found")
-			return
-		}
-		response.InternalError(w)
+		writeQuoteError(w, err)
 		return
 	}
+	amount := quote.Amount
 
-	// Calculate amount based on billing period
-	var amount float64
-	switch req.BillingPeriod {
-	case "monthly":
-		amount = plan.PriceMonthly
-	case "yearly":
-		// 15% discount for yearly billing
-		amount = plan.PriceMonthly * 12 * 0.85
-	default:
-		response.BadRequest(w, "invalid billing period")
-		return
-	}
-
-	// Create subscription (status = pending)
-	// The service will check for existing subscriptions
-	sub, err := h.service.Subscribe(ctx, userID, &req)
-	if err != nil {
-		switch err {
-		case ErrPlanNotFound:
-			response.NotFound(w, "plan not found")
-		case ErrAlreadySubscribed:
-			response.Conflict(w, "already subscribed")
-		case ErrInvalidBillingPeriod:
-			response.BadRequest(w, "invalid billing period")
-		case ErrPlanAudienceMismatch:
-			response.Forbidden(w, "plan is not available for your profile type")
-		default:
+	// Fully covered by the proration credit: nothing to charge
+	if amount <= 0 {
+		if err := h.service.ActivateSubscription(ctx, sub.ID); err != nil {
 			response.InternalError(w)
+			return
 		}
+		status := StatusActive
+		if quote.Kind == ChangeDowngrade {
+			status = StatusScheduled
+		}
+		response.Created(w, map[string]interface{}{
+			"subscription_id": sub.ID.String(),
+			"status":          status,
+			"amount":          0,
+		})
 		return
 	}
 
@@ -258,11 +287,21 @@ func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
 		SubscriptionID: sub.ID,
 		Amount:         strconv.FormatFloat(amount, 'f', 2, 64),
 		Description:    fmt.Sprintf("MWork %s subscription", req.PlanID),
+		PlanID:         quote.PlanID,
+		PromoCode:      req.PromoCode,
+		Recurring:      req.AutoRenew,
 	})
 	if err != nil {
+		if errors.Is(err, ErrPromoCodeRejected) {
+			response.Error(w, http.StatusUnprocessableEntity, "PROMO_REJECTED", err.Error())
+			return
+		}
 		response.Error(w, http.StatusBadGateway, "GATEWAY_ERROR", "payment gateway error")
 		return
 	}
+	if robokassaResp.Amount > 0 {
+		amount = robokassaResp.Amount
+	}
 
 	// Calculate expiry time (30 minutes from now)
 	expiresAt := time.Now().Add(30 * time.Minute).Format(time.RFC3339)
@@ -285,6 +324,63 @@ func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
 	response.Created(w, subscribeResp)
 }
 
+// Preview handles GET /subscriptions/preview
+// @Summary Предпросмотр стоимости подписки
+// @Description Возвращает точную сумму к оплате с учетом перерасчета за неиспользованные дни текущего тарифа. Понижение тарифа начинается после окончания текущего периода.
+// @Tags Subscription
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id query string true "ID тарифа"
+// @Param billing_period query string false "monthly или yearly" default(monthly)
+// @Success 200 {object} response.Response{data=Quote}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/preview [get]
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+
+	planID := r.URL.Query().Get("plan_id")
+	if planID == "" {
+		response.BadRequest(w, "plan_id is required")
+		return
+	}
+	period := BillingPeriod(r.URL.Query().Get("billing_period"))
+	if period == "" {
+		period = BillingMonthly
+	}
+
+	quote, err := h.service.QuoteChange(r.Context(), userID, PlanID(planID), period)
+	if err != nil {
+		writeQuoteError(w, err)
+		return
+	}
+	response.OK(w, quote)
+}
+
+// writeQuoteError maps checkout pricing errors to responses
+func writeQuoteError(w http.ResponseWriter, err error) {
+	switch err {
+	case ErrPlanNotFound:
+		response.NotFound(w, "plan not found")
+	case ErrAlreadySubscribed:
+		response.Conflict(w, "already subscribed")
+	case ErrInvalidBillingPeriod:
+		response.BadRequest(w, "invalid billing period")
+	case ErrPlanAudienceMismatch:
+		response.Forbidden(w, "plan is not available for your profile type")
+	default:
+		response.InternalError(w)
+	}
+}
+
 // Cancel handles POST /subscriptions/cancel
 // @Summary Отмена подписки
 // @Description Отменяет текущую активную подписку пользователя
@@ -325,6 +421,50 @@ func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, map[string]string{"status": "cancelled"})
 }
 
+// SetAutoRenew handles PUT /subscriptions/auto-renew
+// @Summary Включить или отключить автопродление
+// @Description Автопродление списывает оплату с карты, сохраненной при оплате подписки с auto_renew=true. Включить его можно только при наличии сохраненной карты.
+// @Tags Subscription
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body AutoRenewRequest true "Состояние автопродления"
+// @Success 200 {object} response.Response{data=SubscriptionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /subscriptions/auto-renew [put]
+func (h *Handler) SetAutoRenew(w http.ResponseWriter, r *http.Request) {
+	var req AutoRenewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == uuid.Nil {
+		response.Unauthorized(w, "unauthorized")
+		return
+	}
+	sub, err := h.service.SetAutoRenew(r.Context(), userID, req.Enabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSubscriptionNotFound):
+			response.NotFound(w, "No active subscription")
+		case errors.Is(err, ErrAutoRenewUnavailable):
+			response.Conflict(w, "No saved card: pay for the subscription with auto-renewal enabled")
+		default:
+			response.InternalError(w)
+		}
+		return
+	}
+
+	plan, _ := h.service.GetPlan(r.Context(), sub.PlanID)
+	response.OK(w, SubscriptionResponseFromEntity(sub, plan))
+}
+
 // GetModelCastingLimits handles GET /subscriptions/models/me/castings/limits
 // @Summary Лимит откликов на кастинги для модели
 // @Description Возвращает текущий лимит, использование и время сброса лимита откликов на кастинги для авторизованной модели
@@ -396,9 +536,12 @@ func (h *Handler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Rou
 		r.Use(authMiddleware)
 		r.Get("/current", h.GetCurrent)
 		r.Get("/limits", h.GetLimits)
+		r.Get("/history", h.GetHistory)
+		r.Get("/preview", h.Preview)
 		r.Get("/models/me/castings/limits", h.GetModelCastingLimits)
 		r.Post("/", h.Subscribe)
 		r.Post("/cancel", h.Cancel)
+		r.Put("/auto-renew", h.SetAutoRenew)
 		r.Post("/connects/purchase", h.PurchaseConnects)
 	})
 
@@ -0,0 +1,82 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// HistoryEvent is a subscription lifecycle transition
+type HistoryEvent string
+
+const (
+	HistoryCreated      HistoryEvent = "created"
+	HistoryActivated    HistoryEvent = "activated"
+	HistoryScheduled    HistoryEvent = "scheduled"
+	HistoryCancelled    HistoryEvent = "cancelled"
+	HistoryGraceStarted HistoryEvent = "grace_started"
+	HistoryExpired      HistoryEvent = "expired"
+	HistoryDowngraded   HistoryEvent = "downgraded"
+	HistoryAdminGranted HistoryEvent = "admin_granted"
+	HistoryRenewed      HistoryEvent = "renewed"
+	HistoryRenewFailed  HistoryEvent = "renewal_failed"
+	HistoryAutoRenewOn  HistoryEvent = "auto_renew_enabled"
+	HistoryAutoRenewOff HistoryEvent = "auto_renew_disabled"
+)
+
+// HistoryEntry records one subscription transition
+type HistoryEntry struct {
+	ID             uuid.UUID      `db:"id" json:"id"`
+	SubscriptionID uuid.NullUUID  `db:"subscription_id" json:"subscription_id,omitempty"`
+	UserID         uuid.UUID      `db:"user_id" json:"user_id"`
+	Event          HistoryEvent   `db:"event" json:"event"`
+	FromStatus     sql.NullString `db:"from_status" json:"-"`
+	ToStatus       sql.NullString `db:"to_status" json:"-"`
+	FromPlan       sql.NullString `db:"from_plan" json:"-"`
+	ToPlan         sql.NullString `db:"to_plan" json:"-"`
+	Reason         sql.NullString `db:"reason" json:"-"`
+	ActorID        uuid.NullUUID  `db:"actor_id" json:"-"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+}
+
+// transition describes a change recorded by recordHistory
+type transition struct {
+	event      HistoryEvent
+	fromStatus Status
+	toStatus   Status
+	fromPlan   PlanID
+	toPlan     PlanID
+	reason     string
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// recordHistory stores a transition of sub. Failures are logged, never returned,
+// so history cannot block the lifecycle change itself.
+func (s *Service) recordHistory(ctx context.Context, sub *Subscription, t transition) {
+	entry := &HistoryEntry{
+		ID:             uuid.New(),
+		SubscriptionID: uuid.NullUUID{UUID: sub.ID, Valid: sub.ID != uuid.Nil},
+		UserID:         sub.UserID,
+		Event:          t.event,
+		FromStatus:     nullString(string(t.fromStatus)),
+		ToStatus:       nullString(string(t.toStatus)),
+		FromPlan:       nullString(string(t.fromPlan)),
+		ToPlan:         nullString(string(t.toPlan)),
+		Reason:         nullString(t.reason),
+		CreatedAt:      time.Now(),
+	}
+	if err := s.repo.CreateHistory(ctx, entry); err != nil {
+		log.Error().Err(err).Str("subscription_id", sub.ID.String()).Str("event", string(t.event)).Msg("Failed to record subscription history")
+	}
+}
+
+// GetHistory returns the user's subscription transitions, newest first
+func (s *Service) GetHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*HistoryEntry, error) {
+	return s.repo.ListHistoryByUser(ctx, userID, limit, offset)
+}
@@ -0,0 +1,286 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// LifecycleNotifier tells users about upcoming expiry and downgrades
+type LifecycleNotifier interface {
+	NotifySubscriptionExpiring(ctx context.Context, userID uuid.UUID, planName string, expiresAt time.Time, daysLeft int)
+	NotifySubscriptionDowngraded(ctx context.Context, userID uuid.UUID, planName string)
+}
+
+// LifecycleConfig controls expiry handling
+type LifecycleConfig struct {
+	GracePeriod  time.Duration // paid features stay on this long after expires_at
+	ReminderDays []int         // days before expires_at to remind, descending
+}
+
+// ParseReminderDays parses "7,3,1" into descending unique positive days
+func ParseReminderDays(raw string) []int {
+	seen := map[int]bool{}
+	var days []int
+	for _, part := range strings.Split(raw, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || d <= 0 || seen[d] {
+			continue
+		}
+		seen[d] = true
+		days = append(days, d)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days
+}
+
+// SetLifecycle configures grace period, reminders and the notifier
+func (s *Service) SetLifecycle(cfg LifecycleConfig, notifier LifecycleNotifier) {
+	s.lifecycle = cfg
+	s.notifier = notifier
+}
+
+// withinGrace reports whether an expired subscription still grants its plan.
+// Before the worker sets grace_until the configured period is applied directly.
+func (s *Service) withinGrace(sub *Subscription, now time.Time) bool {
+	if sub.GraceUntil.Valid {
+		return sub.InGrace(now)
+	}
+	if !sub.ExpiresAt.Valid || s.lifecycle.GracePeriod <= 0 {
+		return false
+	}
+	return now.Before(sub.ExpiresAt.Time.Add(s.lifecycle.GracePeriod))
+}
+
+// LifecycleResult summarises one lifecycle pass
+type LifecycleResult struct {
+	Started      int // scheduled subscriptions that began
+	GraceStarted int
+	Expired      int
+	Reminded     int
+}
+
+// RunLifecycle starts grace periods, expires and downgrades lapsed subscriptions
+// and sends expiry reminders
+func (s *Service) RunLifecycle(ctx context.Context) (*LifecycleResult, error) {
+	result := &LifecycleResult{}
+
+	// Scheduled downgrades first, so the plans they replace are not downgraded to free
+	started, err := s.startScheduled(ctx)
+	result.Started = started
+	if err != nil {
+		return result, err
+	}
+
+	if s.lifecycle.GracePeriod > 0 {
+		subs, err := s.repo.StartGracePeriod(ctx, s.lifecycle.GracePeriod)
+		if err != nil {
+			return result, err
+		}
+		for _, sub := range subs {
+			s.recordHistory(ctx, sub, transition{event: HistoryGraceStarted, fromStatus: StatusActive, toStatus: StatusActive, fromPlan: sub.PlanID, toPlan: sub.PlanID})
+		}
+		result.GraceStarted = len(subs)
+	}
+
+	expired, err := s.ExpireOldSubscriptions(ctx)
+	result.Expired = expired
+	if err != nil {
+		return result, err
+	}
+
+	reminded, err := s.sendExpiryReminders(ctx, time.Now())
+	result.Reminded = reminded
+	return result, err
+}
+
+func (s *Service) startScheduled(ctx context.Context) (int, error) {
+	due, err := s.repo.ListScheduledDue(ctx)
+	if err != nil {
+		return 0, err
+	}
+	started := 0
+	for _, sub := range due {
+		if err := s.activate(ctx, sub, StatusScheduled, StatusExpired, "Period ended, switched to "+string(sub.PlanID)); err != nil {
+			log.Error().Err(err).Str("subscription_id", sub.ID.String()).Msg("Failed to start scheduled subscription")
+			continue
+		}
+		started++
+	}
+	return started, nil
+}
+
+// ExpireOldSubscriptions expires lapsed subscriptions and moves their users
+// to the audience's free plan
+func (s *Service) ExpireOldSubscriptions(ctx context.Context) (int, error) {
+	subs, err := s.repo.ExpireOldSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, sub := range subs {
+		s.recordHistory(ctx, sub, transition{event: HistoryExpired, fromStatus: StatusActive, toStatus: StatusExpired, fromPlan: sub.PlanID, toPlan: sub.PlanID})
+		s.downgrade(ctx, sub)
+	}
+	return len(subs), nil
+}
+
+func (s *Service) downgrade(ctx context.Context, sub *Subscription) {
+	// A renewal may already be active; then there is nothing to downgrade
+	if current, err := s.repo.GetActiveByUserID(ctx, sub.UserID); err == nil && current != nil {
+		return
+	}
+	audience, err := s.getUserAudience(ctx, sub.UserID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", sub.UserID.String()).Msg("Failed to resolve audience for downgrade")
+		return
+	}
+	freeID := s.freePlanIDForAudience(audience)
+	s.recordHistory(ctx, sub, transition{event: HistoryDowngraded, fromStatus: StatusExpired, toStatus: StatusActive, fromPlan: sub.PlanID, toPlan: freeID, reason: "subscription expired"})
+
+	if s.notifier != nil {
+		s.notifier.NotifySubscriptionDowngraded(ctx, sub.UserID, s.planName(ctx, sub.PlanID))
+	}
+}
+
+func (s *Service) sendExpiryReminders(ctx context.Context, now time.Time) (int, error) {
+	if s.notifier == nil || len(s.lifecycle.ReminderDays) == 0 {
+		return 0, nil
+	}
+	horizon := time.Duration(s.lifecycle.ReminderDays[0]) * 24 * time.Hour
+	subs, err := s.repo.ListDueForReminder(ctx, horizon)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, sub := range subs {
+		// Auto-renewing subscriptions are charged instead; remind only after a failed charge
+		if sub.AutoRenew && sub.RecurringInvID.Valid && sub.RenewalAttempts == 0 {
+			continue
+		}
+		// Users with a paid scheduled change don't need a renewal reminder
+		if scheduled, err := s.repo.ListByUserStatus(ctx, sub.UserID, StatusScheduled); err == nil && len(scheduled) > 0 {
+			continue
+		}
+		days := daysUntil(now, sub.ExpiresAt.Time)
+		threshold, ok := reminderThreshold(days, sub.LastReminderDays, s.lifecycle.ReminderDays)
+		if !ok {
+			continue
+		}
+		// Claim first so a crash or a concurrent run cannot cause a duplicate reminder
+		claimed, err := s.repo.MarkReminderSent(ctx, sub.ID, threshold)
+		if err != nil {
+			log.Error().Err(err).Str("subscription_id", sub.ID.String()).Msg("Failed to mark subscription reminder")
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.notifier.NotifySubscriptionExpiring(ctx, sub.UserID, s.planName(ctx, sub.PlanID), sub.ExpiresAt.Time, days)
+		sent++
+	}
+	return sent, nil
+}
+
+// daysUntil rounds the time left up to whole days
+func daysUntil(now, t time.Time) int {
+	return int(math.Ceil(t.Sub(now).Hours() / 24))
+}
+
+// reminderThreshold picks the smallest reminder threshold that daysLeft has reached,
+// unless a reminder for that threshold (or a later one) was already sent
+func reminderThreshold(daysLeft int, last sql.NullInt64, thresholds []int) (int, bool) {
+	picked := 0
+	for _, t := range thresholds {
+		if daysLeft <= t && (picked == 0 || t < picked) {
+			picked = t
+		}
+	}
+	if picked == 0 {
+		return 0, false
+	}
+	if last.Valid && int(last.Int64) <= picked {
+		return 0, false
+	}
+	return picked, true
+}
+
+func (s *Service) planName(ctx context.Context, id PlanID) string {
+	if plan, err := s.repo.GetPlanByID(ctx, id); err == nil && plan != nil {
+		return plan.Name
+	}
+	return string(id)
+}
+
+// LifecycleWorker periodically runs the subscription lifecycle
+type LifecycleWorker struct {
+	service  *Service
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewLifecycleWorker creates a new subscription lifecycle worker
+func NewLifecycleWorker(service *Service, interval time.Duration) *LifecycleWorker {
+	if interval == 0 {
+		interval = 1 * time.Hour
+	}
+	return &LifecycleWorker{
+		service:  service,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the background worker
+func (w *LifecycleWorker) Start() {
+	log.Info().Msg("Starting subscription lifecycle worker...")
+	go w.loop()
+}
+
+// Stop gracefully stops the background worker
+func (w *LifecycleWorker) Stop() {
+	log.Info().Msg("Stopping subscription lifecycle worker...")
+	close(w.stopCh)
+}
+
+func (w *LifecycleWorker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Run once immediately on startup
+	w.run()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.run()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *LifecycleWorker) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := w.service.RunLifecycle(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Subscription lifecycle run failed")
+	}
+	if result != nil && (result.Started > 0 || result.GraceStarted > 0 || result.Expired > 0 || result.Reminded > 0) {
+		log.Info().
+			Int("started", result.Started).
+			Int("grace_started", result.GraceStarted).
+			Int("expired", result.Expired).
+			Int("reminded", result.Reminded).
+			Msg("Subscription lifecycle processed")
+	}
+}
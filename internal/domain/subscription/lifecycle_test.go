@@ -0,0 +1,59 @@
+package subscription
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseReminderDays(t *testing.T) {
+	got := ParseReminderDays(" 1,7, 3,x,7,-2")
+	if !reflect.DeepEqual(got, []int{7, 3, 1}) {
+		t.Fatalf("unexpected days: %v", got)
+	}
+}
+
+func TestReminderThreshold(t *testing.T) {
+	thresholds := []int{7, 3, 1}
+	none := sql.NullInt64{}
+
+	cases := []struct {
+		days int
+		last sql.NullInt64
+		want int
+		ok   bool
+	}{
+		{days: 10, last: none, ok: false},
+		{days: 7, last: none, want: 7, ok: true},
+		{days: 5, last: sql.NullInt64{Int64: 7, Valid: true}, ok: false},
+		{days: 3, last: sql.NullInt64{Int64: 7, Valid: true}, want: 3, ok: true},
+		// Worker was down for the 7-day window: only the nearest reminder is sent
+		{days: 2, last: none, want: 3, ok: true},
+		{days: 1, last: sql.NullInt64{Int64: 3, Valid: true}, want: 1, ok: true},
+		{days: 1, last: sql.NullInt64{Int64: 1, Valid: true}, ok: false},
+	}
+	for _, c := range cases {
+		got, ok := reminderThreshold(c.days, c.last, thresholds)
+		if ok != c.ok || got != c.want {
+			t.Fatalf("days=%d last=%v: got (%d, %v), want (%d, %v)", c.days, c.last, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestWithinGrace(t *testing.T) {
+	now := time.Now()
+	svc := &Service{lifecycle: LifecycleConfig{GracePeriod: 72 * time.Hour}}
+	sub := &Subscription{Status: StatusActive, ExpiresAt: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}}
+
+	if !svc.withinGrace(sub, now) {
+		t.Fatal("expected configured grace to apply before the worker sets grace_until")
+	}
+	sub.GraceUntil = sql.NullTime{Time: now.Add(-time.Minute), Valid: true}
+	if svc.withinGrace(sub, now) {
+		t.Fatal("expected grace to end at grace_until")
+	}
+	if (&Service{}).withinGrace(&Subscription{ExpiresAt: sub.ExpiresAt}, now) {
+		t.Fatal("expected no grace when period is zero")
+	}
+}
@@ -12,6 +12,7 @@ var (
 	ErrPhotoLimitReached    = errors.New("photo upload limit reached for your plan")
 	ErrResponseLimitReached = errors.New("monthly response limit reached for your plan")
 	ErrChatNotAllowed       = errors.New("chat is not available on your current plan")
+	ErrViewersNotAllowed    = errors.New("profile viewers are not available on your current plan")
 )
 
 // LimitChecker provides convenience methods for checking subscription limits
@@ -76,6 +77,22 @@ func (c *LimitChecker) CanUseChat(ctx context.Context, userID uuid.UUID) error {
 	return nil
 }
 
+// CanSeeViewers checks if user can see who viewed their profile
+func (c *LimitChecker) CanSeeViewers(ctx context.Context, userID uuid.UUID) error {
+	allowed, plan, err := c.svc.CheckLimit(ctx, userID, "viewers", 0)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &LimitError{
+			Err:       ErrViewersNotAllowed,
+			PlanName:  string(plan.ID),
+			UpgradeTo: c.getUpgradePlan(plan.ID),
+		}
+	}
+	return nil
+}
+
 // GetLimitsStatus returns current limits status for UI display
 func (c *LimitChecker) GetLimitsStatus(ctx context.Context, userID uuid.UUID) (*LimitsStatus, error) {
 	_, plan, err := c.svc.GetCurrentSubscription(ctx, userID)
@@ -0,0 +1,149 @@
+package subscription
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeKind classifies a checkout relative to the current subscription
+type ChangeKind string
+
+const (
+	ChangeNew       ChangeKind = "new"       // from a free plan: starts now, full price
+	ChangeUpgrade   ChangeKind = "upgrade"   // starts now, unused days of the current plan are credited
+	ChangeDowngrade ChangeKind = "downgrade" // starts when the current period ends
+)
+
+// Quote is the exact price and schedule of a plan checkout
+type Quote struct {
+	PlanID          PlanID        `json:"plan_id"`
+	BillingPeriod   BillingPeriod `json:"billing_period"`
+	Kind            ChangeKind    `json:"kind"`
+	CurrentPlanID   PlanID        `json:"current_plan_id,omitempty"`
+	FullPrice       float64       `json:"full_price"`
+	ProrationCredit float64       `json:"proration_credit"`
+	CarriedCredit   float64       `json:"carried_credit,omitempty"` // credit above the price, added as extra days
+	Amount          float64       `json:"amount"`
+	Currency        string        `json:"currency"`
+	StartsAt        time.Time     `json:"starts_at"`
+	ExpiresAt       time.Time     `json:"expires_at"`
+}
+
+// PlanPrice returns the plan price for a billing period
+func PlanPrice(plan *Plan, period BillingPeriod) (float64, error) {
+	switch period {
+	case BillingMonthly:
+		return plan.PriceMonthly, nil
+	case BillingYearly:
+		if !plan.PriceYearly.Valid || plan.PriceYearly.Float64 <= 0 {
+			return 0, ErrInvalidBillingPeriod
+		}
+		return plan.PriceYearly.Float64, nil
+	}
+	return 0, ErrInvalidBillingPeriod
+}
+
+func periodEnd(start time.Time, period BillingPeriod) time.Time {
+	if period == BillingYearly {
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// rank orders plan/period pairs: a higher monthly price wins, yearly beats monthly on the same plan
+func rank(plan *Plan, period BillingPeriod) float64 {
+	r := plan.PriceMonthly * 10
+	if period == BillingYearly {
+		r++
+	}
+	return r
+}
+
+// computeQuote prices moving from current (nil or free) to target.
+// Upgrades credit the unused share of the current period's price; credit above
+// the new price extends the new period at its daily rate instead of being lost.
+// Downgrades are scheduled to start when the current period ends.
+func computeQuote(current *Subscription, currentPlan, target *Plan, period BillingPeriod, now time.Time) (*Quote, error) {
+	price, err := PlanPrice(target, period)
+	if err != nil {
+		return nil, err
+	}
+	q := &Quote{
+		PlanID:        target.ID,
+		BillingPeriod: period,
+		Kind:          ChangeNew,
+		FullPrice:     roundMoney(price),
+		Amount:        roundMoney(price),
+		Currency:      "KZT",
+		StartsAt:      now,
+		ExpiresAt:     periodEnd(now, period),
+	}
+
+	if current == nil || currentPlan == nil || currentPlan.PriceMonthly <= 0 || !current.ExpiresAt.Valid || !current.ExpiresAt.Time.After(now) {
+		return q, nil
+	}
+	q.CurrentPlanID = current.PlanID
+	if current.PlanID == target.ID && current.BillingPeriod == period {
+		return nil, ErrAlreadySubscribed
+	}
+
+	if rank(target, period) < rank(currentPlan, current.BillingPeriod) {
+		q.Kind = ChangeDowngrade
+		q.StartsAt = current.ExpiresAt.Time
+		q.ExpiresAt = periodEnd(q.StartsAt, period)
+		return q, nil
+	}
+
+	q.Kind = ChangeUpgrade
+	currentPrice, err := PlanPrice(currentPlan, current.BillingPeriod)
+	if err != nil {
+		return q, nil
+	}
+	total := current.ExpiresAt.Time.Sub(current.StartedAt)
+	if total <= 0 {
+		total = periodEnd(current.StartedAt, current.BillingPeriod).Sub(current.StartedAt)
+	}
+	unused := current.ExpiresAt.Time.Sub(now)
+	credit := roundMoney(currentPrice * unused.Seconds() / total.Seconds())
+	if credit > q.FullPrice {
+		q.CarriedCredit = roundMoney(credit - q.FullPrice)
+		length := q.ExpiresAt.Sub(q.StartsAt)
+		q.ExpiresAt = q.ExpiresAt.Add(time.Duration(float64(length) * q.CarriedCredit / price))
+		credit = q.FullPrice
+	}
+	q.ProrationCredit = credit
+	q.Amount = roundMoney(q.FullPrice - credit)
+	return q, nil
+}
+
+// QuoteChange previews the price and schedule of subscribing to a plan
+func (s *Service) QuoteChange(ctx context.Context, userID uuid.UUID, planID PlanID, period BillingPeriod) (*Quote, error) {
+	target, err := s.repo.GetPlanByID(ctx, planID)
+	if err != nil || target == nil {
+		return nil, ErrPlanNotFound
+	}
+	audience, err := s.getUserAudience(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Audience != audience {
+		return nil, ErrPlanAudienceMismatch
+	}
+
+	current, err := s.repo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var currentPlan *Plan
+	if current != nil {
+		currentPlan, _ = s.repo.GetPlanByID(ctx, current.PlanID)
+	}
+	return computeQuote(current, currentPlan, target, period, time.Now())
+}
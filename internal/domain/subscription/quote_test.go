@@ -0,0 +1,106 @@
+package subscription
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func quotePlans() (free, pro, agency *Plan) {
+	free = &Plan{ID: PlanFree, PriceMonthly: 0}
+	pro = &Plan{ID: PlanPro, PriceMonthly: 3000, PriceYearly: sql.NullFloat64{Float64: 30000, Valid: true}}
+	agency = &Plan{ID: PlanAgency, PriceMonthly: 9000, PriceYearly: sql.NullFloat64{Float64: 90000, Valid: true}}
+	return
+}
+
+func activeSub(plan PlanID, start time.Time, days int) *Subscription {
+	return &Subscription{
+		PlanID:        plan,
+		StartedAt:     start,
+		ExpiresAt:     sql.NullTime{Time: start.AddDate(0, 0, days), Valid: true},
+		Status:        StatusActive,
+		BillingPeriod: BillingMonthly,
+	}
+}
+
+func TestComputeQuoteNew(t *testing.T) {
+	_, pro, _ := quotePlans()
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	q, err := computeQuote(nil, nil, pro, BillingYearly, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Kind != ChangeNew || q.Amount != 30000 || !q.ExpiresAt.Equal(now.AddDate(1, 0, 0)) {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+}
+
+func TestComputeQuoteUpgradeProrated(t *testing.T) {
+	_, pro, agency := quotePlans()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	current := activeSub(PlanPro, start, 30)
+	now := start.AddDate(0, 0, 10) // 20 of 30 days unused
+
+	q, err := computeQuote(current, pro, agency, BillingMonthly, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Kind != ChangeUpgrade || q.ProrationCredit != 2000 || q.Amount != 7000 {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+	if !q.StartsAt.Equal(now) {
+		t.Fatalf("upgrade should start now, got %v", q.StartsAt)
+	}
+}
+
+func TestComputeQuoteDowngradeScheduled(t *testing.T) {
+	_, pro, agency := quotePlans()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	current := activeSub(PlanAgency, start, 30)
+
+	q, err := computeQuote(current, agency, pro, BillingMonthly, start.AddDate(0, 0, 5))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Kind != ChangeDowngrade || q.ProrationCredit != 0 || q.Amount != 3000 {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+	if !q.StartsAt.Equal(current.ExpiresAt.Time) {
+		t.Fatalf("downgrade should start at period end, got %v", q.StartsAt)
+	}
+}
+
+func TestComputeQuoteSamePlan(t *testing.T) {
+	_, pro, _ := quotePlans()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	current := activeSub(PlanPro, start, 30)
+
+	if _, err := computeQuote(current, pro, pro, BillingMonthly, start.AddDate(0, 0, 1)); err != ErrAlreadySubscribed {
+		t.Fatalf("expected ErrAlreadySubscribed, got %v", err)
+	}
+	q, err := computeQuote(current, pro, pro, BillingYearly, start.AddDate(0, 0, 15))
+	if err != nil || q.Kind != ChangeUpgrade || q.Amount != 28500 {
+		t.Fatalf("monthly to yearly should be a prorated upgrade: %+v, %v", q, err)
+	}
+}
+
+func TestComputeQuoteUpgradeCarriesExcessCredit(t *testing.T) {
+	_, pro, agency := quotePlans()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	current := activeSub(PlanPro, start, 365)
+	current.BillingPeriod = BillingYearly
+
+	// The whole yearly price (30000) is unused; 9000 pays the month, 21000 buys 31*21000/9000 days more
+	q, err := computeQuote(current, pro, agency, BillingMonthly, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Amount != 0 || q.ProrationCredit != 9000 || q.CarriedCredit != 21000 {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+	want := start.AddDate(0, 1, 0).Add(72*24*time.Hour + 8*time.Hour)
+	if d := q.ExpiresAt.Sub(want); d < -time.Second || d > time.Second {
+		t.Fatalf("expected expiry %v, got %v", want, q.ExpiresAt)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -22,7 +23,24 @@ type Repository interface {
 	GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*Subscription, error)
 	Update(ctx context.Context, sub *Subscription) error
 	Cancel(ctx context.Context, id uuid.UUID, reason string) error
-	ExpireOldSubscriptions(ctx context.Context) (int, error)
+	Activate(ctx context.Context, id, userID uuid.UUID, closeStatus Status, reason string) ([]*Subscription, error)
+	ListByUserStatus(ctx context.Context, userID uuid.UUID, status Status) ([]*Subscription, error)
+	ListScheduledDue(ctx context.Context) ([]*Subscription, error)
+	ExpireOldSubscriptions(ctx context.Context) ([]*Subscription, error)
+	StartGracePeriod(ctx context.Context, grace time.Duration) ([]*Subscription, error)
+	ListDueForReminder(ctx context.Context, horizon time.Duration) ([]*Subscription, error)
+	MarkReminderSent(ctx context.Context, id uuid.UUID, days int) (bool, error)
+
+	// Auto-renewal
+	SetAutoRenew(ctx context.Context, id uuid.UUID, enabled bool) error
+	SetRecurringParent(ctx context.Context, id uuid.UUID, invID int64) error
+	ListDueForRenewal(ctx context.Context, lead time.Duration, limit int) ([]*Subscription, error)
+	ExtendPeriod(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	RecordRenewalFailure(ctx context.Context, id uuid.UUID, retryAt sql.NullTime, disable bool) error
+
+	// History
+	CreateHistory(ctx context.Context, entry *HistoryEntry) error
+	ListHistoryByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*HistoryEntry, error)
 
 	GetUserRole(ctx context.Context, userID uuid.UUID) (Audience, error)
 	GetLimitOverrideTotal(ctx context.Context, userID uuid.UUID, limitKey string) (int, error)
@@ -107,7 +125,9 @@ func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Subscription,
 	query := `
 		SELECT
 			id, user_id, plan_id, started_at, expires_at, status,
-			cancelled_at, cancel_reason, billing_period, created_at, updated_at
+			cancelled_at, cancel_reason, billing_period, created_at, updated_at,
+			grace_until, last_reminder_days,
+			auto_renew, recurring_inv_id, renewal_attempts, next_renewal_at
 		FROM subscriptions
 		WHERE id = $1
 	`
@@ -126,7 +146,9 @@ func (r *repository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*
 	query := `
 		SELECT
 			id, user_id, plan_id, started_at, expires_at, status,
-			cancelled_at, cancel_reason, billing_period, created_at, updated_at
+			cancelled_at, cancel_reason, billing_period, created_at, updated_at,
+			grace_until, last_reminder_days,
+			auto_renew, recurring_inv_id, renewal_attempts, next_renewal_at
 		FROM subscriptions
 		WHERE user_id = $1 AND status = 'active'
 		ORDER BY created_at DESC
@@ -169,18 +191,212 @@ func (r *repository) Cancel(ctx context.Context, id uuid.UUID, reason string) er
 	return err
 }
 
-func (r *repository) ExpireOldSubscriptions(ctx context.Context) (int, error) {
+// Activate makes a subscription active and, in the same transaction, closes the
+// user's other active subscription with closeStatus (one active per user is enforced by a unique index)
+func (r *repository) Activate(ctx context.Context, id, userID uuid.UUID, closeStatus Status, reason string) ([]*Subscription, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var closed []*Subscription
+	err = tx.SelectContext(ctx, &closed, `
+		UPDATE subscriptions SET
+			status = $3,
+			cancelled_at = CASE WHEN $3 = 'cancelled' THEN NOW() ELSE cancelled_at END,
+			cancel_reason = CASE WHEN $3 = 'cancelled' THEN $4 ELSE cancel_reason END,
+			updated_at = NOW()
+		WHERE user_id = $1 AND status = 'active' AND id <> $2
+		RETURNING`+subscriptionColumns, userID, id, closeStatus, reason)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE subscriptions SET status = 'active', updated_at = NOW() WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return closed, tx.Commit()
+}
+
+func (r *repository) ListByUserStatus(ctx context.Context, userID uuid.UUID, status Status) ([]*Subscription, error) {
+	query := `SELECT` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE user_id = $1 AND status = $2
+		ORDER BY created_at DESC`
+	var subs []*Subscription
+	if err := r.db.SelectContext(ctx, &subs, query, userID, status); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// ListScheduledDue returns paid scheduled subscriptions whose start has come
+func (r *repository) ListScheduledDue(ctx context.Context) ([]*Subscription, error) {
+	query := `SELECT` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE status = 'scheduled' AND started_at <= NOW()
+		ORDER BY started_at`
+	var subs []*Subscription
+	if err := r.db.SelectContext(ctx, &subs, query); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+const subscriptionColumns = `
+	id, user_id, plan_id, started_at, expires_at, status,
+	cancelled_at, cancel_reason, billing_period, created_at, updated_at,
+	grace_until, last_reminder_days,
+	auto_renew, recurring_inv_id, renewal_attempts, next_renewal_at`
+
+// ExpireOldSubscriptions expires active subscriptions past their grace period
+// (or past expires_at when no grace was applied) and returns them
+func (r *repository) ExpireOldSubscriptions(ctx context.Context) ([]*Subscription, error) {
 	query := `
 		UPDATE subscriptions SET
 			status = 'expired', updated_at = NOW()
-		WHERE status = 'active' AND expires_at IS NOT NULL AND expires_at < NOW()
-	`
-	result, err := r.db.ExecContext(ctx, query)
+		WHERE status = 'active' AND expires_at IS NOT NULL
+			AND COALESCE(grace_until, expires_at) < NOW()
+		RETURNING` + subscriptionColumns
+	var subs []*Subscription
+	if err := r.db.SelectContext(ctx, &subs, query); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// StartGracePeriod sets grace_until on active subscriptions that just passed expires_at
+func (r *repository) StartGracePeriod(ctx context.Context, grace time.Duration) ([]*Subscription, error) {
+	query := `
+		UPDATE subscriptions SET
+			grace_until = expires_at + make_interval(secs => $1), updated_at = NOW()
+		WHERE status = 'active' AND expires_at IS NOT NULL
+			AND expires_at < NOW() AND grace_until IS NULL
+		RETURNING` + subscriptionColumns
+	var subs []*Subscription
+	if err := r.db.SelectContext(ctx, &subs, query, grace.Seconds()); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// ListDueForReminder returns active subscriptions expiring within horizon
+func (r *repository) ListDueForReminder(ctx context.Context, horizon time.Duration) ([]*Subscription, error) {
+	query := `SELECT` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE status = 'active' AND expires_at > NOW()
+			AND expires_at <= NOW() + make_interval(secs => $1)
+		ORDER BY expires_at`
+	var subs []*Subscription
+	if err := r.db.SelectContext(ctx, &subs, query, horizon.Seconds()); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// MarkReminderSent claims the reminder for a threshold; false means another run already sent it
+func (r *repository) MarkReminderSent(ctx context.Context, id uuid.UUID, days int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE subscriptions SET last_reminder_days = $2
+		WHERE id = $1 AND (last_reminder_days IS NULL OR last_reminder_days > $2)`, id, days)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
 	if err != nil {
-		return 0, err
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Auto-renewal
+
+func (r *repository) SetAutoRenew(ctx context.Context, id uuid.UUID, enabled bool) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE subscriptions SET
+			auto_renew = $2, renewal_attempts = 0, next_renewal_at = NULL, updated_at = NOW()
+		WHERE id = $1`, id, enabled)
+	return err
+}
+
+// SetRecurringParent saves the InvID of the payment that stored the card and turns auto-renewal on
+func (r *repository) SetRecurringParent(ctx context.Context, id uuid.UUID, invID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE subscriptions SET
+			recurring_inv_id = $2, auto_renew = TRUE, renewal_attempts = 0, next_renewal_at = NULL, updated_at = NOW()
+		WHERE id = $1`, id, invID)
+	return err
+}
+
+// ListDueForRenewal returns auto-renewing active subscriptions expiring within lead whose
+// retry time has come. Users with a paid scheduled change are skipped: that plan takes over.
+func (r *repository) ListDueForRenewal(ctx context.Context, lead time.Duration, limit int) ([]*Subscription, error) {
+	query := `SELECT` + subscriptionColumns + `
+		FROM subscriptions s
+		WHERE status = 'active' AND auto_renew AND recurring_inv_id IS NOT NULL
+			AND expires_at IS NOT NULL AND expires_at <= NOW() + make_interval(secs => $1)
+			AND (next_renewal_at IS NULL OR next_renewal_at <= NOW())
+			AND NOT EXISTS (
+				SELECT 1 FROM subscriptions n WHERE n.user_id = s.user_id AND n.status = 'scheduled'
+			)
+		ORDER BY expires_at
+		LIMIT $2`
+	var subs []*Subscription
+	if err := r.db.SelectContext(ctx, &subs, query, lead.Seconds(), limit); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// ExtendPeriod moves expires_at after a renewal and clears grace, reminder and retry state
+func (r *repository) ExtendPeriod(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE subscriptions SET
+			expires_at = $2, grace_until = NULL, last_reminder_days = NULL,
+			renewal_attempts = 0, next_renewal_at = NULL, updated_at = NOW()
+		WHERE id = $1`, id, expiresAt)
+	return err
+}
+
+// RecordRenewalFailure counts a failed charge; disable turns auto-renewal off after the last attempt
+func (r *repository) RecordRenewalFailure(ctx context.Context, id uuid.UUID, retryAt sql.NullTime, disable bool) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE subscriptions SET
+			renewal_attempts = renewal_attempts + 1, next_renewal_at = $2,
+			auto_renew = auto_renew AND NOT $3, updated_at = NOW()
+		WHERE id = $1`, id, retryAt, disable)
+	return err
+}
+
+// History
+
+func (r *repository) CreateHistory(ctx context.Context, e *HistoryEntry) error {
+	query := `
+		INSERT INTO subscription_history (id, subscription_id, user_id, event, from_status, to_status,
+			from_plan, to_plan, reason, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		e.ID, e.SubscriptionID, e.UserID, e.Event, e.FromStatus, e.ToStatus,
+		e.FromPlan, e.ToPlan, e.Reason, e.ActorID, e.CreatedAt,
+	)
+	return err
+}
+
+func (r *repository) ListHistoryByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*HistoryEntry, error) {
+	query := `
+		SELECT id, subscription_id, user_id, event, from_status, to_status,
+			from_plan, to_plan, reason, actor_id, created_at
+		FROM subscription_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	var entries []*HistoryEntry
+	if err := r.db.SelectContext(ctx, &entries, query, userID, limit, offset); err != nil {
+		return nil, err
 	}
-	affected, _ := result.RowsAffected()
-	return int(affected), nil
+	return entries, nil
 }
 
 func (r *repository) GetUserRole(ctx context.Context, userID uuid.UUID) (Audience, error) {
@@ -21,6 +21,8 @@ type Service struct {
 	profileRepo   ProfileRepository
 	userRepo      user.Repository
 	creditService CreditService
+	lifecycle     LifecycleConfig
+	notifier      LifecycleNotifier
 }
 
 type CreditService interface {
@@ -163,54 +165,83 @@ func (s *Service) GetCurrentSubscription(ctx context.Context, userID uuid.UUID)
 	return sub, plan, nil
 }
 
+// Subscribe creates a pending subscription priced by QuoteChange
 func (s *Service) Subscribe(ctx context.Context, userID uuid.UUID, req *SubscribeRequest) (*Subscription, error) {
-	planID := PlanID(req.PlanID)
-	plan, err := s.repo.GetPlanByID(ctx, planID)
-	if err != nil || plan == nil {
-		return nil, ErrPlanNotFound
-	}
-	audience, err := s.getUserAudience(ctx, userID)
-	if err != nil {
-		return nil, err
-	}
-	if plan.Audience != audience {
-		return nil, ErrPlanAudienceMismatch
+	sub, _, err := s.SubscribeWithQuote(ctx, userID, req)
+	return sub, err
+}
+
+// SubscribeWithQuote creates a pending subscription and returns the quote to charge.
+// The current plan stays untouched until the new subscription is paid.
+func (s *Service) SubscribeWithQuote(ctx context.Context, userID uuid.UUID, req *SubscribeRequest) (*Subscription, *Quote, error) {
+	period := BillingPeriod(req.BillingPeriod)
+	if period != BillingMonthly && period != BillingYearly {
+		return nil, nil, ErrInvalidBillingPeriod
 	}
-	existing, err := s.repo.GetActiveByUserID(ctx, userID)
+	quote, err := s.QuoteChange(ctx, userID, PlanID(req.PlanID), period)
 	if err != nil {
-		return nil, err
-	}
-	if existing != nil && existing.PlanID == planID {
-		return nil, ErrAlreadySubscribed
-	}
-	period := BillingPeriod(req.BillingPeriod)
-	var expiresAt time.Time
-	switch period {
-	case BillingMonthly:
-		expiresAt = time.Now().AddDate(0, 1, 0)
-	case BillingYearly:
-		expiresAt = time.Now().AddDate(1, 0, 0)
-	default:
-		return nil, ErrInvalidBillingPeriod
-	}
-	if existing != nil {
-		_ = s.repo.Cancel(ctx, existing.ID, "Upgraded to "+string(planID))
+		return nil, nil, err
 	}
+
 	now := time.Now()
-	sub := &Subscription{ID: uuid.New(), UserID: userID, PlanID: planID, StartedAt: now, ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true}, Status: StatusPending, BillingPeriod: period, CreatedAt: now, UpdatedAt: now}
+	sub := &Subscription{ID: uuid.New(), UserID: userID, PlanID: quote.PlanID, StartedAt: quote.StartsAt, ExpiresAt: sql.NullTime{Time: quote.ExpiresAt, Valid: true}, Status: StatusPending, BillingPeriod: period, CreatedAt: now, UpdatedAt: now}
 	if err := s.repo.Create(ctx, sub); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return sub, nil
+	s.recordHistory(ctx, sub, transition{event: HistoryCreated, toStatus: StatusPending, fromPlan: quote.CurrentPlanID, toPlan: quote.PlanID, reason: string(quote.Kind)})
+	return sub, quote, nil
 }
 
+// ActivateSubscription is called once a subscription is paid. Subscriptions starting
+// in the future (scheduled downgrades) become scheduled; others replace the current plan now.
 func (s *Service) ActivateSubscription(ctx context.Context, subscriptionID uuid.UUID) error {
 	sub, err := s.repo.GetByID(ctx, subscriptionID)
 	if err != nil || sub == nil {
 		return ErrSubscriptionNotFound
 	}
+	if sub.Status == StatusActive || sub.Status == StatusScheduled {
+		return nil
+	}
+
+	fromStatus := sub.Status
+	if sub.StartedAt.After(time.Now()) {
+		// A newer scheduled change replaces an earlier one
+		if previous, err := s.repo.ListByUserStatus(ctx, sub.UserID, StatusScheduled); err == nil {
+			for _, p := range previous {
+				if err := s.repo.Cancel(ctx, p.ID, "Replaced by scheduled "+string(sub.PlanID)); err == nil {
+					s.recordHistory(ctx, p, transition{event: HistoryCancelled, fromStatus: StatusScheduled, toStatus: StatusCancelled, fromPlan: p.PlanID, toPlan: p.PlanID, reason: "replaced"})
+				}
+			}
+		}
+		sub.Status = StatusScheduled
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+		s.recordHistory(ctx, sub, transition{event: HistoryScheduled, fromStatus: fromStatus, toStatus: StatusScheduled, toPlan: sub.PlanID})
+		return nil
+	}
+
+	return s.activate(ctx, sub, fromStatus, StatusCancelled, "Upgraded to "+string(sub.PlanID))
+}
+
+// activate makes sub the user's active subscription, closing the previous one with closeStatus
+func (s *Service) activate(ctx context.Context, sub *Subscription, fromStatus, closeStatus Status, reason string) error {
+	closed, err := s.repo.Activate(ctx, sub.ID, sub.UserID, closeStatus, reason)
+	if err != nil {
+		return err
+	}
+	var fromPlan PlanID
+	for _, c := range closed {
+		fromPlan = c.PlanID
+		event := HistoryCancelled
+		if closeStatus == StatusExpired {
+			event = HistoryExpired
+		}
+		s.recordHistory(ctx, c, transition{event: event, fromStatus: StatusActive, toStatus: closeStatus, fromPlan: c.PlanID, toPlan: sub.PlanID, reason: reason})
+	}
 	sub.Status = StatusActive
-	return s.repo.Update(ctx, sub)
+	s.recordHistory(ctx, sub, transition{event: HistoryActivated, fromStatus: fromStatus, toStatus: StatusActive, fromPlan: fromPlan, toPlan: sub.PlanID})
+	return nil
 }
 
 func (s *Service) Cancel(ctx context.Context, userID uuid.UUID, reason string) error {
@@ -221,7 +252,27 @@ func (s *Service) Cancel(ctx context.Context, userID uuid.UUID, reason string) e
 	if sub.PlanID == PlanFree || sub.PlanID == PlanFreeEmployer || sub.PlanID == PlanFreeModel {
 		return ErrCannotCancelFree
 	}
-	return s.repo.Cancel(ctx, sub.ID, reason)
+	if err := s.repo.Cancel(ctx, sub.ID, reason); err != nil {
+		return err
+	}
+	s.recordHistory(ctx, sub, transition{event: HistoryCancelled, fromStatus: sub.Status, toStatus: StatusCancelled, fromPlan: sub.PlanID, toPlan: sub.PlanID, reason: reason})
+	return nil
+}
+
+// Revoke cancels a specific subscription whatever its status, e.g. after its payment is refunded
+func (s *Service) Revoke(ctx context.Context, subscriptionID uuid.UUID, reason string) error {
+	sub, err := s.repo.GetByID(ctx, subscriptionID)
+	if err != nil || sub == nil {
+		return ErrSubscriptionNotFound
+	}
+	if sub.Status == StatusCancelled || sub.Status == StatusExpired {
+		return nil
+	}
+	if err := s.repo.Cancel(ctx, sub.ID, reason); err != nil {
+		return err
+	}
+	s.recordHistory(ctx, sub, transition{event: HistoryCancelled, fromStatus: sub.Status, toStatus: StatusCancelled, fromPlan: sub.PlanID, toPlan: sub.PlanID, reason: reason})
+	return nil
 }
 
 func (s *Service) GetPlanLimits(ctx context.Context, userID uuid.UUID) (*Plan, error) {
@@ -229,7 +280,7 @@ func (s *Service) GetPlanLimits(ctx context.Context, userID uuid.UUID) (*Plan, e
 	if err != nil {
 		return nil, err
 	}
-	if sub.IsExpired() {
+	if sub.IsExpired() && !s.withinGrace(sub, time.Now()) {
 		audience, roleErr := s.getUserAudience(ctx, userID)
 		if roleErr != nil {
 			return nil, roleErr
@@ -385,10 +436,10 @@ func (s *Service) CheckLimit(ctx context.Context, userID uuid.UUID, limitType st
 		if !plan.Features.CanChat {
 			return false, plan, nil
 		}
+	case "viewers":
+		if !plan.Features.CanSeeViewers {
+			return false, plan, nil
+		}
 	}
 	return true, plan, nil
 }
-
-func (s *Service) ExpireOldSubscriptions(ctx context.Context) (int, error) {
-	return s.repo.ExpireOldSubscriptions(ctx)
-}
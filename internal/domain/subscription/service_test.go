@@ -2,7 +2,10 @@ package subscription
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -22,9 +25,42 @@ func (r *repoStub) GetByID(context.Context, uuid.UUID) (*Subscription, error) {
 func (r *repoStub) GetActiveByUserID(context.Context, uuid.UUID) (*Subscription, error) {
 	return nil, nil
 }
-func (r *repoStub) Update(context.Context, *Subscription) error              { return nil }
-func (r *repoStub) Cancel(context.Context, uuid.UUID, string) error          { return nil }
-func (r *repoStub) ExpireOldSubscriptions(context.Context) (int, error)      { return 0, nil }
+func (r *repoStub) Update(context.Context, *Subscription) error     { return nil }
+func (r *repoStub) Cancel(context.Context, uuid.UUID, string) error { return nil }
+func (r *repoStub) Activate(context.Context, uuid.UUID, uuid.UUID, Status, string) ([]*Subscription, error) {
+	return nil, nil
+}
+func (r *repoStub) ListByUserStatus(context.Context, uuid.UUID, Status) ([]*Subscription, error) {
+	return nil, nil
+}
+func (r *repoStub) ListScheduledDue(context.Context) ([]*Subscription, error) { return nil, nil }
+func (r *repoStub) ExpireOldSubscriptions(context.Context) ([]*Subscription, error) {
+	return nil, nil
+}
+func (r *repoStub) StartGracePeriod(context.Context, time.Duration) ([]*Subscription, error) {
+	return nil, nil
+}
+func (r *repoStub) ListDueForReminder(context.Context, time.Duration) ([]*Subscription, error) {
+	return nil, nil
+}
+func (r *repoStub) MarkReminderSent(context.Context, uuid.UUID, int) (bool, error) {
+	return true, nil
+}
+func (r *repoStub) CreateHistory(context.Context, *HistoryEntry) error  { return nil }
+func (r *repoStub) SetAutoRenew(context.Context, uuid.UUID, bool) error { return nil }
+func (r *repoStub) SetRecurringParent(context.Context, uuid.UUID, int64) error {
+	return nil
+}
+func (r *repoStub) ListDueForRenewal(context.Context, time.Duration, int) ([]*Subscription, error) {
+	return nil, nil
+}
+func (r *repoStub) ExtendPeriod(context.Context, uuid.UUID, time.Time) error { return nil }
+func (r *repoStub) RecordRenewalFailure(context.Context, uuid.UUID, sql.NullTime, bool) error {
+	return nil
+}
+func (r *repoStub) ListHistoryByUser(context.Context, uuid.UUID, int, int) ([]*HistoryEntry, error) {
+	return nil, nil
+}
 func (r *repoStub) GetUserRole(context.Context, uuid.UUID) (Audience, error) { return r.audience, nil }
 func (r *repoStub) GetLimitOverrideTotal(context.Context, uuid.UUID, string) (int, error) {
 	return r.delta, nil
@@ -77,3 +113,19 @@ func TestAdjustLimitUpdatesRemaining(t *testing.T) {
 		t.Fatalf("expected remaining 23, got %d", status.Remaining)
 	}
 }
+
+func TestCanSeeViewersFollowsPlanFeature(t *testing.T) {
+	repo := &repoStub{plan: &Plan{ID: PlanFree, Audience: AudienceModel}, audience: AudienceModel}
+	checker := NewLimitChecker(NewService(repo, &photoRepoStub{}, &respRepoStub{}, &castingRepoStub{}, &profileRepoStub{}))
+
+	err := checker.CanSeeViewers(context.Background(), uuid.New())
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Err != ErrViewersNotAllowed || limitErr.UpgradeTo != string(PlanPro) {
+		t.Fatalf("expected viewers limit error with upgrade to pro, got %v", err)
+	}
+
+	repo.plan = &Plan{ID: PlanPro, Audience: AudienceModel, Features: FeaturesConfig{CanSeeViewers: true}}
+	if err := checker.CanSeeViewers(context.Background(), uuid.New()); err != nil {
+		t.Fatalf("expected pro plan to see viewers, got %v", err)
+	}
+}
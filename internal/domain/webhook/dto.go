@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateSubscriptionRequest registers an endpoint
+type CreateSubscriptionRequest struct {
+	URL            string     `json:"url"`
+	EventTypes     []string   `json:"event_types"`
+	Description    string     `json:"description"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"` // deliver events of all organization members
+}
+
+// UpdateSubscriptionRequest changes an endpoint; omitted fields are kept
+type UpdateSubscriptionRequest struct {
+	URL         *string  `json:"url,omitempty"`
+	EventTypes  []string `json:"event_types,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// SubscriptionResponse is an endpoint in API responses
+type SubscriptionResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	URL            string     `json:"url"`
+	EventTypes     []string   `json:"event_types"`
+	Description    string     `json:"description,omitempty"`
+	IsActive       bool       `json:"is_active"`
+	Secret         string     `json:"secret,omitempty"` // only on create and secret rotation
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ToResponse converts an endpoint without its secret
+func (s *Subscription) ToResponse() *SubscriptionResponse {
+	resp := &SubscriptionResponse{
+		ID:          s.ID,
+		URL:         s.URL,
+		EventTypes:  s.EventTypes,
+		Description: s.Description.String,
+		IsActive:    s.IsActive,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if s.OrganizationID.Valid {
+		resp.OrganizationID = &s.OrganizationID.UUID
+	}
+	return resp
+}
+
+// DeliveryResponse is a delivery log entry
+type DeliveryResponse struct {
+	ID             uuid.UUID         `json:"id"`
+	EventID        uuid.UUID         `json:"event_id"`
+	EventType      EventType         `json:"event_type"`
+	Status         DeliveryStatus    `json:"status"`
+	Attempts       int               `json:"attempts"`
+	NextAttemptAt  *time.Time        `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int              `json:"last_status_code,omitempty"`
+	LastError      string            `json:"last_error,omitempty"`
+	ReplayOf       *uuid.UUID        `json:"replay_of,omitempty"`
+	DeliveredAt    *time.Time        `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	Payload        json.RawMessage   `json:"payload,omitempty"`
+	AttemptLog     []AttemptResponse `json:"attempt_log,omitempty"`
+}
+
+// AttemptResponse is one HTTP attempt of a delivery
+type AttemptResponse struct {
+	Attempt      int       `json:"attempt"`
+	StatusCode   *int      `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	DurationMs   int       `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ToResponse converts a delivery; the payload is only included in detail views
+func (d *Delivery) ToResponse(withPayload bool) *DeliveryResponse {
+	resp := &DeliveryResponse{
+		ID:        d.ID,
+		EventID:   d.EventID,
+		EventType: d.EventType,
+		Status:    d.Status,
+		Attempts:  d.Attempts,
+		LastError: d.LastError.String,
+		CreatedAt: d.CreatedAt,
+	}
+	if d.Status == DeliveryPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	if d.LastStatusCode.Valid {
+		code := int(d.LastStatusCode.Int32)
+		resp.LastStatusCode = &code
+	}
+	if d.ReplayOf.Valid {
+		resp.ReplayOf = &d.ReplayOf.UUID
+	}
+	if d.DeliveredAt.Valid {
+		resp.DeliveredAt = &d.DeliveredAt.Time
+	}
+	if withPayload {
+		resp.Payload = d.Payload
+	}
+	return resp
+}
+
+// ToResponse converts an attempt
+func (a *Attempt) ToResponse() AttemptResponse {
+	resp := AttemptResponse{
+		Attempt:      a.Attempt,
+		Error:        a.Error.String,
+		ResponseBody: a.ResponseBody.String,
+		DurationMs:   a.DurationMs,
+		CreatedAt:    a.CreatedAt,
+	}
+	if a.StatusCode.Valid {
+		code := int(a.StatusCode.Int32)
+		resp.StatusCode = &code
+	}
+	return resp
+}
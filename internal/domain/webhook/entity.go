@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// EventType is an event an endpoint can subscribe to
+type EventType string
+
+const (
+	EventResponseCreated       EventType = "response.created"
+	EventResponseStatusChanged EventType = "response.status_changed"
+	EventCastingClosed         EventType = "casting.closed"
+	EventMessageReceived       EventType = "message.received"
+	EventReviewReceived        EventType = "review.received"
+)
+
+// EventTypes lists every subscribable event
+var EventTypes = []EventType{
+	EventResponseCreated,
+	EventResponseStatusChanged,
+	EventCastingClosed,
+	EventMessageReceived,
+	EventReviewReceived,
+}
+
+// IsValid reports whether the event type exists
+func (t EventType) IsValid() bool {
+	for _, e := range EventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed" // attempts exhausted
+)
+
+// Subscription is an endpoint registered by an employer, optionally on
+// behalf of an organization. Organization endpoints receive events of every member.
+type Subscription struct {
+	ID             uuid.UUID      `db:"id"`
+	UserID         uuid.UUID      `db:"user_id"`
+	OrganizationID uuid.NullUUID  `db:"organization_id"`
+	URL            string         `db:"url"`
+	Secret         string         `db:"secret"`
+	EventTypes     pq.StringArray `db:"event_types"`
+	Description    sql.NullString `db:"description"`
+	IsActive       bool           `db:"is_active"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
+}
+
+// Delivery is one event queued for one subscription
+type Delivery struct {
+	ID             uuid.UUID       `db:"id"`
+	SubscriptionID uuid.UUID       `db:"subscription_id"`
+	EventID        uuid.UUID       `db:"event_id"`
+	EventType      EventType       `db:"event_type"`
+	Payload        json.RawMessage `db:"payload"`
+	Status         DeliveryStatus  `db:"status"`
+	Attempts       int             `db:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at"`
+	LastStatusCode sql.NullInt32   `db:"last_status_code"`
+	LastError      sql.NullString  `db:"last_error"`
+	ReplayOf       uuid.NullUUID   `db:"replay_of"`
+	DeliveredAt    sql.NullTime    `db:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at"`
+
+	// Endpoint, filled when claimed for sending
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// Attempt is one HTTP call made for a delivery
+type Attempt struct {
+	DeliveryID   uuid.UUID      `db:"delivery_id"`
+	Attempt      int            `db:"attempt"`
+	StatusCode   sql.NullInt32  `db:"status_code"`
+	Error        sql.NullString `db:"error"`
+	ResponseBody sql.NullString `db:"response_body"`
+	DurationMs   int            `db:"duration_ms"`
+	CreatedAt    time.Time      `db:"created_at"`
+}
+
+// Envelope is the JSON body POSTed to endpoints
+type Envelope struct {
+	ID        uuid.UUID      `json:"id"`
+	Type      EventType      `json:"type"`
+	CreatedAt time.Time      `json:"created_at"`
+	Data      map[string]any `json:"data"`
+}
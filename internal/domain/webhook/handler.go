@@ -0,0 +1,338 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/middleware"
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// Handler handles webhook subscription HTTP requests
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates webhook handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Routes returns webhook subscription routes for employers and agencies
+func (h *Handler) Routes(authMiddleware func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Use(authMiddleware)
+	r.Use(middleware.RequireEmployer())
+
+	r.Get("/event-types", h.ListEventTypes)
+	r.Get("/", h.List)
+	r.Post("/", h.Create)
+	r.Get("/{id}", h.Get)
+	r.Patch("/{id}", h.Update)
+	r.Delete("/{id}", h.Delete)
+	r.Post("/{id}/rotate-secret", h.RotateSecret)
+	r.Get("/{id}/deliveries", h.ListDeliveries)
+	r.Get("/{id}/deliveries/{deliveryId}", h.GetDelivery)
+	r.Post("/{id}/deliveries/{deliveryId}/replay", h.Replay)
+
+	return r
+}
+
+// ListEventTypes returns the subscribable events
+// @Summary Типы событий вебхуков
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]EventType}
+// @Router /integrations/webhooks/event-types [get]
+func (h *Handler) ListEventTypes(w http.ResponseWriter, r *http.Request) {
+	response.OK(w, EventTypes)
+}
+
+// List returns the endpoints the user manages
+// @Summary Список вебхуков
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]SubscriptionResponse}
+// @Failure 401,500 {object} response.Response
+// @Router /integrations/webhooks [get]
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListSubscriptions(r.Context(), middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.InternalError(w)
+		return
+	}
+	items := make([]*SubscriptionResponse, len(subs))
+	for i := range subs {
+		items[i] = subs[i].ToResponse()
+	}
+	response.OK(w, items)
+}
+
+// Create registers an endpoint and returns its signing secret once
+// @Summary Создать вебхук
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateSubscriptionRequest true "Адрес и события"
+// @Success 201 {object} response.Response{data=SubscriptionResponse}
+// @Failure 400,401,403,409,500 {object} response.Response
+// @Router /integrations/webhooks [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(r.Context(), middleware.GetUserID(r.Context()), &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := sub.ToResponse()
+	resp.Secret = sub.Secret
+	response.Created(w, resp)
+}
+
+// Get returns an endpoint
+// @Summary Получить вебхук
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} response.Response{data=SubscriptionResponse}
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/webhooks/{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id")
+	if !ok {
+		return
+	}
+	sub, err := h.service.GetSubscription(r.Context(), middleware.GetUserID(r.Context()), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.OK(w, sub.ToResponse())
+}
+
+// Update changes an endpoint
+// @Summary Изменить вебхук
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param request body UpdateSubscriptionRequest true "Изменения"
+// @Success 200 {object} response.Response{data=SubscriptionResponse}
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/webhooks/{id} [patch]
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req UpdateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "Invalid JSON body")
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(r.Context(), middleware.GetUserID(r.Context()), id, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.OK(w, sub.ToResponse())
+}
+
+// Delete removes an endpoint with its delivery log
+// @Summary Удалить вебхук
+// @Tags Webhooks
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/webhooks/{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteSubscription(r.Context(), middleware.GetUserID(r.Context()), id); err != nil {
+		writeError(w, err)
+		return
+	}
+	response.NoContent(w)
+}
+
+// RotateSecret issues a new signing secret
+// @Summary Сменить секрет вебхука
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} response.Response{data=SubscriptionResponse}
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/webhooks/{id}/rotate-secret [post]
+func (h *Handler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id")
+	if !ok {
+		return
+	}
+	sub, err := h.service.RotateSecret(r.Context(), middleware.GetUserID(r.Context()), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := sub.ToResponse()
+	resp.Secret = sub.Secret
+	response.OK(w, resp)
+}
+
+// ListDeliveries returns the delivery log of an endpoint
+// @Summary Журнал доставок вебхука
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param status query string false "pending, succeeded, failed"
+// @Param page query int false "Страница"
+// @Param limit query int false "Лимит (max 100)"
+// @Success 200 {object} response.Response{data=[]DeliveryResponse}
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/webhooks/{id}/deliveries [get]
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	status := DeliveryStatus(q.Get("status"))
+	switch status {
+	case "", DeliveryPending, DeliverySucceeded, DeliveryFailed:
+	default:
+		response.BadRequest(w, "Invalid status")
+		return
+	}
+	page, limit := 1, 20
+	if v, err := strconv.Atoi(q.Get("page")); err == nil && v > 0 {
+		page = v
+	}
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+
+	deliveries, total, err := h.service.ListDeliveries(r.Context(), middleware.GetUserID(r.Context()), id, status, limit, (page-1)*limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	items := make([]*DeliveryResponse, len(deliveries))
+	for i := range deliveries {
+		items[i] = deliveries[i].ToResponse(false)
+	}
+	response.WithMeta(w, items, response.Meta{
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+		Pages:   (total + limit - 1) / limit,
+		HasNext: page*limit < total,
+		HasPrev: page > 1,
+	})
+}
+
+// GetDelivery returns a delivery with its payload and attempts
+// @Summary Доставка вебхука
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} response.Response{data=DeliveryResponse}
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/webhooks/{id}/deliveries/{deliveryId} [get]
+func (h *Handler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseID(w, r, "deliveryId")
+	if !ok {
+		return
+	}
+
+	d, attempts, err := h.service.GetDelivery(r.Context(), middleware.GetUserID(r.Context()), id, deliveryID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := d.ToResponse(true)
+	for i := range attempts {
+		resp.AttemptLog = append(resp.AttemptLog, attempts[i].ToResponse())
+	}
+	response.OK(w, resp)
+}
+
+// Replay sends a delivery again with the same event body
+// @Summary Повторить доставку вебхука
+// @Tags Webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} response.Response{data=DeliveryResponse}
+// @Failure 400,401,404,500 {object} response.Response
+// @Router /integrations/webhooks/{id}/deliveries/{deliveryId}/replay [post]
+func (h *Handler) Replay(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseID(w, r, "deliveryId")
+	if !ok {
+		return
+	}
+
+	d, err := h.service.Replay(r.Context(), middleware.GetUserID(r.Context()), id, deliveryID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusAccepted, d.ToResponse(false))
+}
+
+func parseID(w http.ResponseWriter, r *http.Request, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		response.BadRequest(w, "Invalid "+param)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrSubscriptionNotFound):
+		response.NotFound(w, "Webhook not found")
+	case errors.Is(err, ErrDeliveryNotFound):
+		response.NotFound(w, "Delivery not found")
+	case errors.Is(err, ErrNotOrganizationAdmin):
+		response.Forbidden(w, err.Error())
+	case errors.Is(err, ErrInvalidURL), errors.Is(err, ErrInvalidEventTypes):
+		response.BadRequest(w, err.Error())
+	case errors.Is(err, ErrTooManySubscriptions):
+		response.Conflict(w, err.Error())
+	default:
+		response.InternalError(w)
+	}
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// publisher queues webhook events; implemented by Service
+type publisher interface {
+	Publish(ctx context.Context, recipientID uuid.UUID, eventType EventType, data map[string]any)
+}
+
+// ResponseNotifier is the notification hook set used by the response service
+type ResponseNotifier interface {
+	NotifyNewResponse(ctx context.Context, employerUserID uuid.UUID, castingID uuid.UUID, responseID uuid.UUID, castingTitle string, modelName string) error
+	NotifyResponseStatusChange(ctx context.Context, modelUserID uuid.UUID, castingTitle string, status string, castingID uuid.UUID, responseID uuid.UUID) error
+}
+
+// ChatNotifier is the notification hook used by the chat service
+type ChatNotifier interface {
+	NotifyNewMessage(ctx context.Context, recipientUserID uuid.UUID, senderName string, messagePreview string, roomID uuid.UUID, messageID uuid.UUID) error
+}
+
+// ResponseHooks passes response notifications through and publishes them as webhooks
+type ResponseHooks struct {
+	next ResponseNotifier
+	hub  publisher
+}
+
+// NewResponseHooks wraps the response service notifier; next may be nil
+func NewResponseHooks(next ResponseNotifier, hub publisher) *ResponseHooks {
+	return &ResponseHooks{next: next, hub: hub}
+}
+
+func (h *ResponseHooks) NotifyNewResponse(ctx context.Context, employerUserID uuid.UUID, castingID uuid.UUID, responseID uuid.UUID, castingTitle string, modelName string) error {
+	h.hub.Publish(ctx, employerUserID, EventResponseCreated, map[string]any{
+		"response_id":   responseID,
+		"casting_id":    castingID,
+		"casting_title": castingTitle,
+		"model_name":    modelName,
+	})
+	if h.next == nil {
+		return nil
+	}
+	return h.next.NotifyNewResponse(ctx, employerUserID, castingID, responseID, castingTitle, modelName)
+}
+
+func (h *ResponseHooks) NotifyResponseStatusChange(ctx context.Context, modelUserID uuid.UUID, castingTitle string, status string, castingID uuid.UUID, responseID uuid.UUID) error {
+	h.hub.Publish(ctx, modelUserID, EventResponseStatusChanged, map[string]any{
+		"response_id":   responseID,
+		"casting_id":    castingID,
+		"casting_title": castingTitle,
+		"status":        status,
+	})
+	if h.next == nil {
+		return nil
+	}
+	return h.next.NotifyResponseStatusChange(ctx, modelUserID, castingTitle, status, castingID, responseID)
+}
+
+// NotifyCastingClosed publishes a casting that filled up or was closed by its owner
+func (h *ResponseHooks) NotifyCastingClosed(ctx context.Context, employerUserID uuid.UUID, castingID uuid.UUID, castingTitle string, reason string) error {
+	h.hub.Publish(ctx, employerUserID, EventCastingClosed, map[string]any{
+		"casting_id":    castingID,
+		"casting_title": castingTitle,
+		"reason":        reason,
+	})
+	return nil
+}
+
+// ChatHooks passes chat notifications through and publishes them as webhooks
+type ChatHooks struct {
+	next ChatNotifier
+	hub  publisher
+}
+
+// NewChatHooks wraps the chat service notifier; next may be nil
+func NewChatHooks(next ChatNotifier, hub publisher) *ChatHooks {
+	return &ChatHooks{next: next, hub: hub}
+}
+
+func (h *ChatHooks) NotifyNewMessage(ctx context.Context, recipientUserID uuid.UUID, senderName string, messagePreview string, roomID uuid.UUID, messageID uuid.UUID) error {
+	h.hub.Publish(ctx, recipientUserID, EventMessageReceived, map[string]any{
+		"room_id":    roomID,
+		"message_id": messageID,
+		"preview":    messagePreview,
+	})
+	if h.next == nil {
+		return nil
+	}
+	return h.next.NotifyNewMessage(ctx, recipientUserID, senderName, messagePreview, roomID, messageID)
+}
+
+// NotifyReviewReceived publishes a review to the owner of the reviewed profile or casting
+func (s *Service) NotifyReviewReceived(ctx context.Context, targetType string, targetID, reviewID uuid.UUID, rating int) error {
+	ownerID, err := s.repo.ReviewTargetOwner(ctx, targetType, targetID)
+	if err != nil || ownerID == uuid.Nil {
+		return err
+	}
+	s.Publish(ctx, ownerID, EventReviewReceived, map[string]any{
+		"review_id":   reviewID,
+		"target_type": targetType,
+		"target_id":   targetID,
+		"rating":      rating,
+	})
+	return nil
+}
@@ -0,0 +1,248 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository handles webhook subscriptions and deliveries
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository creates webhook repository
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const subscriptionColumns = `id, user_id, organization_id, url, secret, event_types, description, is_active, created_at, updated_at`
+
+// CreateSubscription stores a new endpoint
+func (r *Repository) CreateSubscription(ctx context.Context, s *Subscription) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_subscriptions (`+subscriptionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, s.ID, s.UserID, s.OrganizationID, s.URL, s.Secret, s.EventTypes, s.Description, s.IsActive, s.CreatedAt, s.UpdatedAt)
+	return err
+}
+
+// GetSubscription returns a subscription or nil
+func (r *Repository) GetSubscription(ctx context.Context, id uuid.UUID) (*Subscription, error) {
+	var s Subscription
+	err := r.db.GetContext(ctx, &s, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListManageable returns subscriptions the user created or manages as an
+// owner or admin of the organization
+func (r *Repository) ListManageable(ctx context.Context, userID uuid.UUID) ([]Subscription, error) {
+	subs := []Subscription{}
+	err := r.db.SelectContext(ctx, &subs, `
+		SELECT `+subscriptionColumns+` FROM webhook_subscriptions
+		WHERE user_id = $1
+		   OR organization_id IN (
+			SELECT organization_id FROM organization_members WHERE user_id = $1 AND role IN ('owner', 'admin')
+		   )
+		ORDER BY created_at DESC
+	`, userID)
+	return subs, err
+}
+
+// UpdateSubscription saves endpoint settings
+func (r *Repository) UpdateSubscription(ctx context.Context, s *Subscription) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_subscriptions
+		SET url = $2, secret = $3, event_types = $4, description = $5, is_active = $6, updated_at = $7
+		WHERE id = $1
+	`, s.ID, s.URL, s.Secret, s.EventTypes, s.Description, s.IsActive, s.UpdatedAt)
+	return err
+}
+
+// DeleteSubscription removes an endpoint with its delivery log
+func (r *Repository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	return err
+}
+
+// OrganizationRole returns the user's role in the organization, empty if not a member
+func (r *Repository) OrganizationRole(ctx context.Context, orgID, userID uuid.UUID) (string, error) {
+	var role string
+	err := r.db.GetContext(ctx, &role, `
+		SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2
+	`, orgID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+// MatchSubscriptions returns active endpoints of the recipient and of its
+// organizations subscribed to the event type
+func (r *Repository) MatchSubscriptions(ctx context.Context, recipientID uuid.UUID, eventType EventType) ([]Subscription, error) {
+	subs := []Subscription{}
+	err := r.db.SelectContext(ctx, &subs, `
+		SELECT `+subscriptionColumns+` FROM webhook_subscriptions
+		WHERE is_active AND $2 = ANY(event_types)
+		  AND (
+			(organization_id IS NULL AND user_id = $1)
+			OR organization_id IN (
+				SELECT organization_id FROM organization_members WHERE user_id = $1
+				UNION
+				SELECT organization_id FROM users WHERE id = $1 AND organization_id IS NOT NULL
+			)
+		  )
+	`, recipientID, string(eventType))
+	return subs, err
+}
+
+// CreateDeliveries queues deliveries in one transaction
+func (r *Repository) CreateDeliveries(ctx context.Context, deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, d := range deliveries {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, next_attempt_at, replay_of, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, d.ID, d.SubscriptionID, d.EventID, d.EventType, []byte(d.Payload), d.Status, d.NextAttemptAt, d.ReplayOf, d.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ClaimDue leases due pending deliveries so that other instances skip them
+// until the lease expires, and returns them with their endpoint
+func (r *Repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Delivery, error) {
+	deliveries := []Delivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+		WITH due AS (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.is_active
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d
+			SET next_attempt_at = NOW() + make_interval(secs => $2)
+			FROM due WHERE d.id = due.id
+			RETURNING d.*
+		)
+		SELECT c.id, c.subscription_id, c.event_id, c.event_type, c.payload, c.status, c.attempts, c.next_attempt_at,
+			c.last_status_code, c.last_error, c.replay_of, c.delivered_at, c.created_at, s.url, s.secret
+		FROM claimed c
+		JOIN webhook_subscriptions s ON s.id = c.subscription_id
+	`, limit, lease.Seconds())
+	return deliveries, err
+}
+
+// RecordAttempt logs an attempt and saves the delivery outcome
+func (r *Repository) RecordAttempt(ctx context.Context, d *Delivery, a *Attempt) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, response_body, duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, a.DeliveryID, a.Attempt, a.StatusCode, a.Error, a.ResponseBody, a.DurationMs, a.CreatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+		WHERE id = $1
+	`, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListDeliveries returns the delivery log of a subscription, newest first
+func (r *Repository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status DeliveryStatus, limit, offset int) ([]Delivery, int, error) {
+	var total int
+	if err := r.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM webhook_deliveries WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+	`, subscriptionID, string(status)); err != nil {
+		return nil, 0, err
+	}
+
+	deliveries := []Delivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+			last_status_code, last_error, replay_of, delivered_at, created_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`, subscriptionID, string(status), limit, offset)
+	return deliveries, total, err
+}
+
+// GetDelivery returns a delivery or nil
+func (r *Repository) GetDelivery(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	var d Delivery
+	err := r.db.GetContext(ctx, &d, `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+			last_status_code, last_error, replay_of, delivered_at, created_at
+		FROM webhook_deliveries WHERE id = $1
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListAttempts returns the attempts of a delivery in order
+func (r *Repository) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]Attempt, error) {
+	attempts := []Attempt{}
+	err := r.db.SelectContext(ctx, &attempts, `
+		SELECT delivery_id, attempt, status_code, error, response_body, duration_ms, created_at
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY attempt
+	`, deliveryID)
+	return attempts, err
+}
+
+// ReviewTargetOwner returns the user who owns a reviewed profile or casting
+func (r *Repository) ReviewTargetOwner(ctx context.Context, targetType string, targetID uuid.UUID) (uuid.UUID, error) {
+	var query string
+	switch targetType {
+	case "model_profile":
+		query = `SELECT user_id FROM model_profiles WHERE id = $1`
+	case "employer_profile":
+		query = `SELECT user_id FROM employer_profiles WHERE id = $1`
+	case "casting":
+		query = `SELECT creator_id FROM castings WHERE id = $1`
+	default:
+		return uuid.Nil, nil
+	}
+	var ownerID uuid.UUID
+	err := r.db.GetContext(ctx, &ownerID, query, targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	return ownerID, err
+}
@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Signature header: "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+// Receivers should reject timestamps older than a few minutes.
+const (
+	HeaderSignature = "X-MWork-Signature"
+	HeaderEvent     = "X-MWork-Event"
+	HeaderDelivery  = "X-MWork-Delivery"
+)
+
+// ErrPrivateAddress is returned when an endpoint resolves to an internal address
+var ErrPrivateAddress = errors.New("webhook endpoint resolves to a private address")
+
+// Sign returns the signature header value for body sent at ts
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retrySchedule is the wait after each failed attempt
+var retrySchedule = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// backoff returns the wait after the given failed attempt (1-based)
+func backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > len(retrySchedule) {
+		return retrySchedule[len(retrySchedule)-1]
+	}
+	return retrySchedule[attempt-1]
+}
+
+// sendResult is the outcome of one HTTP attempt
+type sendResult struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+	Err        error
+}
+
+// OK reports whether the endpoint accepted the delivery
+func (r sendResult) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// sender POSTs signed deliveries
+type sender struct {
+	client *http.Client
+}
+
+func newSender(timeout time.Duration, allowPrivate bool) *sender {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		// Checked on the resolved address so DNS cannot point an endpoint inside the network
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &sender{client: &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast()
+}
+
+func (s *sender) send(ctx context.Context, d *Delivery, now time.Time) sendResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return sendResult{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MWork-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(d.EventType))
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderSignature, Sign(d.Secret, now, d.Payload))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return sendResult{Duration: time.Since(start), Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	res := sendResult{StatusCode: resp.StatusCode, Body: string(body), Duration: time.Since(start)}
+	if !res.OK() {
+		res.Err = fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return res
+}
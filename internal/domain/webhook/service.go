@@ -0,0 +1,469 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrNotOrganizationAdmin = errors.New("only organization owners and admins can manage its webhooks")
+	ErrInvalidURL           = errors.New("webhook url must be an absolute http(s) url")
+	ErrInvalidEventTypes    = errors.New("unknown or empty webhook event types")
+	ErrTooManySubscriptions = errors.New("webhook subscription limit reached")
+)
+
+// Config controls delivery
+type Config struct {
+	MaxAttempts      int           // attempts before a delivery is marked failed
+	Timeout          time.Duration // per HTTP attempt
+	BatchSize        int           // deliveries sent per worker run
+	AllowPrivate     bool          // allow endpoints on internal addresses, for local development
+	RequireHTTPS     bool
+	MaxSubscriptions int // per user
+}
+
+// Service manages webhook subscriptions and delivers events to them
+type Service struct {
+	repo   *Repository
+	sender *sender
+	cfg    Config
+	now    func() time.Time
+}
+
+// NewService creates webhook service
+func NewService(repo *Repository, cfg Config) *Service {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = len(retrySchedule) + 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxSubscriptions <= 0 {
+		cfg.MaxSubscriptions = 20
+	}
+	return &Service{
+		repo:   repo,
+		sender: newSender(cfg.Timeout, cfg.AllowPrivate),
+		cfg:    cfg,
+		now:    time.Now,
+	}
+}
+
+// CreateSubscription registers an endpoint. The secret is only returned here and on rotation.
+func (s *Service) CreateSubscription(ctx context.Context, userID uuid.UUID, req *CreateSubscriptionRequest) (*Subscription, error) {
+	if err := s.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	types, err := parseEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var orgID uuid.NullUUID
+	if req.OrganizationID != nil {
+		if err := s.requireOrgAdmin(ctx, *req.OrganizationID, userID); err != nil {
+			return nil, err
+		}
+		orgID = uuid.NullUUID{UUID: *req.OrganizationID, Valid: true}
+	}
+
+	existing, err := s.repo.ListManageable(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= s.cfg.MaxSubscriptions {
+		return nil, ErrTooManySubscriptions
+	}
+
+	now := s.now()
+	sub := &Subscription{
+		ID:             uuid.New(),
+		UserID:         userID,
+		OrganizationID: orgID,
+		URL:            strings.TrimSpace(req.URL),
+		Secret:         newSecret(),
+		EventTypes:     types,
+		Description:    sql.NullString{String: req.Description, Valid: req.Description != ""},
+		IsActive:       true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ListSubscriptions returns endpoints the user can manage
+func (s *Service) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]Subscription, error) {
+	return s.repo.ListManageable(ctx, userID)
+}
+
+// GetSubscription returns an endpoint the user can manage
+func (s *Service) GetSubscription(ctx context.Context, userID, id uuid.UUID) (*Subscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	if sub.UserID == userID {
+		return sub, nil
+	}
+	if sub.OrganizationID.Valid {
+		role, err := s.repo.OrganizationRole(ctx, sub.OrganizationID.UUID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if role == "owner" || role == "admin" {
+			return sub, nil
+		}
+	}
+	// Do not reveal endpoints of other accounts
+	return nil, ErrSubscriptionNotFound
+}
+
+// UpdateSubscription changes the URL, event types, description or active flag
+func (s *Service) UpdateSubscription(ctx context.Context, userID, id uuid.UUID, req *UpdateSubscriptionRequest) (*Subscription, error) {
+	sub, err := s.GetSubscription(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL != nil {
+		if err := s.validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		sub.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.EventTypes != nil {
+		types, err := parseEventTypes(req.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		sub.EventTypes = types
+	}
+	if req.Description != nil {
+		sub.Description = sql.NullString{String: *req.Description, Valid: *req.Description != ""}
+	}
+	if req.IsActive != nil {
+		sub.IsActive = *req.IsActive
+	}
+	sub.UpdatedAt = s.now()
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// RotateSecret replaces the signing secret
+func (s *Service) RotateSecret(ctx context.Context, userID, id uuid.UUID) (*Subscription, error) {
+	sub, err := s.GetSubscription(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = newSecret()
+	sub.UpdatedAt = s.now()
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// DeleteSubscription removes an endpoint
+func (s *Service) DeleteSubscription(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.GetSubscription(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries returns the delivery log of an endpoint
+func (s *Service) ListDeliveries(ctx context.Context, userID, subscriptionID uuid.UUID, status DeliveryStatus, limit, offset int) ([]Delivery, int, error) {
+	if _, err := s.GetSubscription(ctx, userID, subscriptionID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, status, limit, offset)
+}
+
+// GetDelivery returns a delivery of the endpoint with its attempts
+func (s *Service) GetDelivery(ctx context.Context, userID, subscriptionID, deliveryID uuid.UUID) (*Delivery, []Attempt, error) {
+	d, err := s.ownedDelivery(ctx, userID, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	attempts, err := s.repo.ListAttempts(ctx, d.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, attempts, nil
+}
+
+// Replay queues a copy of a delivery with the same event body for immediate sending
+func (s *Service) Replay(ctx context.Context, userID, subscriptionID, deliveryID uuid.UUID) (*Delivery, error) {
+	d, err := s.ownedDelivery(ctx, userID, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	replay := Delivery{
+		ID:             uuid.New(),
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         DeliveryPending,
+		NextAttemptAt:  now,
+		ReplayOf:       uuid.NullUUID{UUID: d.ID, Valid: true},
+		CreatedAt:      now,
+	}
+	if err := s.repo.CreateDeliveries(ctx, []Delivery{replay}); err != nil {
+		return nil, err
+	}
+	return &replay, nil
+}
+
+func (s *Service) ownedDelivery(ctx context.Context, userID, subscriptionID, deliveryID uuid.UUID) (*Delivery, error) {
+	if _, err := s.GetSubscription(ctx, userID, subscriptionID); err != nil {
+		return nil, err
+	}
+	d, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil || d.SubscriptionID != subscriptionID {
+		return nil, ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+// Publish queues an event for every endpoint subscribed to it on behalf of
+// the recipient. Failures are logged: webhooks never fail the caller.
+func (s *Service) Publish(ctx context.Context, recipientID uuid.UUID, eventType EventType, data map[string]any) {
+	if recipientID == uuid.Nil {
+		return
+	}
+	subs, err := s.repo.MatchSubscriptions(ctx, recipientID, eventType)
+	if err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to match webhook subscriptions")
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	now := s.now()
+	envelope := Envelope{ID: uuid.New(), Type: eventType, CreatedAt: now.UTC(), Data: data}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to encode webhook event")
+		return
+	}
+
+	deliveries := make([]Delivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, Delivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			EventID:        envelope.ID,
+			EventType:      eventType,
+			Payload:        payload,
+			Status:         DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to queue webhook deliveries")
+	}
+}
+
+// deliveryParallelism is how many deliveries of a batch are sent at once
+const deliveryParallelism = 8
+
+// DeliverDue sends due deliveries and returns how many were attempted
+func (s *Service) DeliverDue(ctx context.Context) (int, error) {
+	// The lease covers the whole batch so no other instance picks the same
+	// deliveries up while they are in flight; a crashed instance's leases expire
+	rounds := s.cfg.BatchSize/deliveryParallelism + 1
+	lease := time.Duration(rounds)*s.cfg.Timeout + time.Minute
+	deliveries, err := s.repo.ClaimDue(ctx, s.cfg.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	sem := make(chan struct{}, deliveryParallelism)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		d := &deliveries[i]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			res := s.sender.send(ctx, d, s.now())
+			attempt := s.applyResult(d, res, s.now())
+			if err := s.repo.RecordAttempt(ctx, d, attempt); err != nil {
+				log.Error().Err(err).Str("delivery_id", d.ID.String()).Msg("Failed to record webhook attempt")
+			}
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// applyResult updates the delivery after an attempt and returns the attempt log entry
+func (s *Service) applyResult(d *Delivery, res sendResult, now time.Time) *Attempt {
+	d.Attempts++
+	a := &Attempt{
+		DeliveryID: d.ID,
+		Attempt:    d.Attempts,
+		DurationMs: int(res.Duration.Milliseconds()),
+		CreatedAt:  now,
+	}
+	if res.StatusCode != 0 {
+		a.StatusCode = sql.NullInt32{Int32: int32(res.StatusCode), Valid: true}
+		d.LastStatusCode = a.StatusCode
+	}
+	if res.Body != "" {
+		a.ResponseBody = sql.NullString{String: res.Body, Valid: true}
+	}
+
+	if res.OK() {
+		d.Status = DeliverySucceeded
+		d.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+		d.LastError = sql.NullString{}
+		return a
+	}
+
+	a.Error = sql.NullString{String: res.Err.Error(), Valid: true}
+	d.LastError = a.Error
+	if d.Attempts >= s.cfg.MaxAttempts {
+		d.Status = DeliveryFailed
+	} else {
+		d.NextAttemptAt = now.Add(backoff(d.Attempts))
+	}
+	return a
+}
+
+func (s *Service) requireOrgAdmin(ctx context.Context, orgID, userID uuid.UUID) error {
+	role, err := s.repo.OrganizationRole(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if role != "owner" && role != "admin" {
+		return ErrNotOrganizationAdmin
+	}
+	return nil
+}
+
+func (s *Service) validateURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	if u.Scheme != "https" && (s.cfg.RequireHTTPS || u.Scheme != "http") {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+func parseEventTypes(raw []string) (pq.StringArray, error) {
+	seen := make(map[EventType]bool, len(raw))
+	types := pq.StringArray{}
+	for _, r := range raw {
+		t := EventType(strings.TrimSpace(r))
+		if !t.IsValid() {
+			return nil, ErrInvalidEventTypes
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, string(t))
+		}
+	}
+	if len(types) == 0 {
+		return nil, ErrInvalidEventTypes
+	}
+	return types, nil
+}
+
+func newSecret() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// Worker sends due webhook deliveries in the background
+type Worker struct {
+	service  *Service
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewWorker creates webhook delivery worker
+func NewWorker(service *Service, interval time.Duration) *Worker {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &Worker{service: service, interval: interval, stopCh: make(chan struct{})}
+}
+
+// Start starts the background worker
+func (w *Worker) Start() {
+	log.Info().Msg("Starting webhook delivery worker...")
+	go w.loop()
+}
+
+// Stop gracefully stops the background worker
+func (w *Worker) Stop() {
+	log.Info().Msg("Stopping webhook delivery worker...")
+	close(w.stopCh)
+}
+
+func (w *Worker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.run()
+	for {
+		select {
+		case <-ticker.C:
+			w.run()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *Worker) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// Keep sending while full batches come back so a backlog drains within one tick
+	for {
+		n, err := w.service.DeliverDue(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Webhook delivery run failed")
+			return
+		}
+		if n < w.service.cfg.BatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// APIKeyPrefix marks API keys sent as a bearer token or in the X-API-Key header
+const APIKeyPrefix = "mwk_"
+
+// APIKeyHeader carries an API key as an alternative to the Authorization header
+const APIKeyHeader = "X-API-Key"
+
+const (
+	APIKeyIDKey contextKey = "api_key_id"
+	ScopesKey   contextKey = "api_key_scopes"
+)
+
+// API key scopes
+const (
+	ScopeCastingsRead   = "castings:read"
+	ScopeCastingsWrite  = "castings:write"
+	ScopeResponsesRead  = "responses:read"
+	ScopeResponsesWrite = "responses:write"
+	ScopeWebhooksManage = "webhooks:manage"
+)
+
+// Scopes lists every scope an API key can be granted
+var Scopes = []string{
+	ScopeCastingsRead,
+	ScopeCastingsWrite,
+	ScopeResponsesRead,
+	ScopeResponsesWrite,
+	ScopeWebhooksManage,
+}
+
+var (
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrAPIKeyRateLimited = errors.New("api key rate limit exceeded")
+)
+
+// APIKeyPrincipal is the acting user an API key authenticates as
+type APIKeyPrincipal struct {
+	KeyID          uuid.UUID
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	Role           string
+	Scopes         []string
+}
+
+// APIKeyAuthenticator resolves API keys; implemented by apikey.Service
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string, ip string) (*APIKeyPrincipal, error)
+}
+
+// scopeRule grants API key access to a route; "*" matches one path segment
+type scopeRule struct {
+	method  string
+	pattern string
+	scope   string
+}
+
+// API keys may only call the routes listed here
+var scopeRules = []scopeRule{
+	{http.MethodGet, "/api/v1/castings/my", ScopeCastingsRead},
+	{http.MethodPost, "/api/v1/castings", ScopeCastingsWrite},
+	{http.MethodPut, "/api/v1/castings/*", ScopeCastingsWrite},
+	{http.MethodPatch, "/api/v1/castings/*/status", ScopeCastingsWrite},
+	{http.MethodDelete, "/api/v1/castings/*", ScopeCastingsWrite},
+	{http.MethodGet, "/api/v1/castings/*/responses", ScopeResponsesRead},
+	{http.MethodPatch, "/api/v1/responses/*/status", ScopeResponsesWrite},
+	{http.MethodGet, "/api/v1/integrations/webhooks", ScopeWebhooksManage},
+	{http.MethodGet, "/api/v1/integrations/webhooks/**", ScopeWebhooksManage},
+	{http.MethodPost, "/api/v1/integrations/webhooks", ScopeWebhooksManage},
+	{http.MethodPost, "/api/v1/integrations/webhooks/**", ScopeWebhooksManage},
+	{http.MethodPatch, "/api/v1/integrations/webhooks/*", ScopeWebhooksManage},
+	{http.MethodDelete, "/api/v1/integrations/webhooks/*", ScopeWebhooksManage},
+}
+
+// RequiredScope returns the scope an API key needs for the request, empty if keys may not call it
+func RequiredScope(method, path string) string {
+	path = strings.TrimSuffix(path, "/")
+	for _, rule := range scopeRules {
+		if rule.method == method && matchPath(rule.pattern, path) {
+			return rule.scope
+		}
+	}
+	return ""
+}
+
+// matchPath matches path segments; "*" matches one segment and a trailing "**" the rest
+func matchPath(pattern, path string) bool {
+	want := strings.Split(pattern, "/")
+	got := strings.Split(path, "/")
+	for i, seg := range want {
+		if seg == "**" {
+			return len(got) > i
+		}
+		if i >= len(got) || (seg != "*" && seg != got[i]) {
+			return false
+		}
+	}
+	return len(got) == len(want)
+}
+
+// extractAPIKey returns the API key of the request, if it carries one
+func extractAPIKey(r *http.Request) (string, bool) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key, true
+	}
+	parts := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" && strings.HasPrefix(parts[1], APIKeyPrefix) {
+		return parts[1], true
+	}
+	return "", false
+}
+
+// authenticateAPIKey checks the key and its scope for the route and serves the request as the acting user
+func authenticateAPIKey(keys APIKeyAuthenticator, key string, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if keys == nil {
+		response.Unauthorized(w, "API keys are not accepted")
+		return
+	}
+
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	principal, err := keys.AuthenticateAPIKey(r.Context(), key, ip)
+	switch {
+	case errors.Is(err, ErrAPIKeyRateLimited):
+		w.Header().Set("Retry-After", "60")
+		response.TooManyRequests(w)
+		return
+	case errors.Is(err, ErrInvalidAPIKey):
+		response.Unauthorized(w, "Invalid API key")
+		return
+	case err != nil:
+		response.InternalError(w)
+		return
+	}
+
+	scope := RequiredScope(r.Method, r.URL.Path)
+	if scope == "" {
+		response.Forbidden(w, "Endpoint is not available for API keys")
+		return
+	}
+	if !hasScope(principal.Scopes, scope) {
+		response.Forbidden(w, "API key is missing scope "+scope)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), UserIDKey, principal.UserID)
+	ctx = context.WithValue(ctx, RoleKey, principal.Role)
+	ctx = context.WithValue(ctx, APIKeyIDKey, principal.KeyID)
+	ctx = context.WithValue(ctx, ScopesKey, principal.Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAPIKeyID returns the API key that authenticated the request, uuid.Nil for user tokens
+func GetAPIKeyID(ctx context.Context) uuid.UUID {
+	if id, ok := ctx.Value(APIKeyIDKey).(uuid.UUID); ok {
+		return id
+	}
+	return uuid.Nil
+}
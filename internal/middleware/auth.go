@@ -2,6 +2,9 @@ package middleware
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net"
 	"net/http"
 	"strings"
 
@@ -18,10 +21,20 @@ const (
 	RoleKey   contextKey = "role"
 )
 
-// Auth returns middleware that validates JWT
-func Auth(jwtService *jwt.Service) func(http.Handler) http.Handler {
+// Auth returns middleware that validates JWT. When an API key authenticator is
+// given, organization API keys are accepted too and act as their mapped user.
+func Auth(jwtService *jwt.Service, apiKeys ...APIKeyAuthenticator) func(http.Handler) http.Handler {
+	var keys APIKeyAuthenticator
+	if len(apiKeys) > 0 {
+		keys = apiKeys[0]
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := extractAPIKey(r); ok {
+				authenticateAPIKey(keys, key, w, r, next)
+				return
+			}
+
 			// Extract token from header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
@@ -62,6 +75,29 @@ func Auth(jwtService *jwt.Service) func(http.Handler) http.Handler {
 	}
 }
 
+// OptionalAuth adds the user to the context when the request carries a valid
+// access token and passes anonymous requests through unchanged
+func OptionalAuth(jwtService *jwt.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parts := strings.Split(r.Header.Get("Authorization"), " ")
+			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			claims, err := jwtService.ValidateAccessToken(parts[1])
+			if err != nil || claims.IsBanned {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, RoleKey, claims.Role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(ctx context.Context) uuid.UUID {
 	if id, ok := ctx.Value(UserIDKey).(uuid.UUID); ok {
@@ -78,6 +114,19 @@ func GetRole(ctx context.Context) string {
 	return ""
 }
 
+// ViewerKey identifies the viewer: the user when signed in, otherwise a hash of IP and user agent
+func ViewerKey(r *http.Request) string {
+	if userID := GetUserID(r.Context()); userID != uuid.Nil {
+		return "u:" + userID.String()
+	}
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	sum := sha1.Sum([]byte(ip + "|" + r.UserAgent()))
+	return "a:" + hex.EncodeToString(sum[:10])
+}
+
 // RequireRole returns middleware that checks user role
 func RequireRole(roles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -31,3 +32,105 @@ func TestAuthMiddlewareAllowsValidAccessToken(t *testing.T) {
 		t.Fatalf("expected 200, got %d", w.Code)
 	}
 }
+
+func TestOptionalAuthPassesAnonymousAndSetsUser(t *testing.T) {
+	jwtSvc := jwt.NewService("secret", time.Minute, time.Hour)
+	userID := uuid.New()
+	token, err := jwtSvc.GenerateAccessToken(userID, "employer", false)
+	if err != nil {
+		t.Fatalf("token gen failed: %v", err)
+	}
+
+	var seen uuid.UUID
+	var role string
+	handler := OptionalAuth(jwtSvc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, role = GetUserID(r.Context()), GetRole(r.Context())
+	}))
+
+	for _, header := range []string{"", "Bearer invalid"} {
+		req := httptest.NewRequest(http.MethodGet, "/public", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || seen != uuid.Nil {
+			t.Fatalf("expected anonymous pass-through for %q, got %d / %s", header, w.Code, seen)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/public", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != userID || role != "employer" {
+		t.Fatalf("expected user from token, got %s / %s", seen, role)
+	}
+}
+
+type fakeAPIKeys struct {
+	principal *APIKeyPrincipal
+	err       error
+}
+
+func (f *fakeAPIKeys) AuthenticateAPIKey(context.Context, string, string) (*APIKeyPrincipal, error) {
+	return f.principal, f.err
+}
+
+func TestAuthAcceptsScopedAPIKeys(t *testing.T) {
+	jwtSvc := jwt.NewService("secret", time.Minute, time.Hour)
+	keys := &fakeAPIKeys{principal: &APIKeyPrincipal{
+		KeyID:  uuid.New(),
+		UserID: uuid.New(),
+		Role:   "agency",
+		Scopes: []string{ScopeCastingsWrite},
+	}}
+
+	var seen uuid.UUID
+	handler := Auth(jwtSvc, keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetUserID(r.Context())
+	}))
+
+	cases := []struct {
+		method, path string
+		header       string
+		want         int
+	}{
+		{http.MethodPost, "/api/v1/castings", "Authorization", http.StatusOK},
+		{http.MethodPost, "/api/v1/castings/", APIKeyHeader, http.StatusOK},
+		{http.MethodGet, "/api/v1/castings/" + uuid.NewString() + "/responses", APIKeyHeader, http.StatusForbidden},
+		{http.MethodGet, "/api/v1/integrations/api-keys", APIKeyHeader, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		seen = uuid.Nil
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.header == "Authorization" {
+			req.Header.Set("Authorization", "Bearer mwk_0011aabb_secret")
+		} else {
+			req.Header.Set(c.header, "mwk_0011aabb_secret")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != c.want {
+			t.Fatalf("%s %s: expected %d, got %d", c.method, c.path, c.want, w.Code)
+		}
+		if c.want == http.StatusOK && seen != keys.principal.UserID {
+			t.Fatalf("expected acting user in context, got %s", seen)
+		}
+	}
+
+	keys.err = ErrAPIKeyRateLimited
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/castings", nil)
+	req.Header.Set(APIKeyHeader, "mwk_0011aabb_secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+
+	// Without an authenticator keys are rejected
+	w = httptest.NewRecorder()
+	Auth(jwtSvc)(handler).ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
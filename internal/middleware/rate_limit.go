@@ -0,0 +1,326 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/mwork/mwork-api/internal/pkg/response"
+)
+
+// RateLimitAlgorithm selects how requests are counted
+type RateLimitAlgorithm string
+
+const (
+	// SlidingWindow weights the previous fixed window by its overlap with the
+	// sliding one; smooth limits with two counters per key
+	SlidingWindow RateLimitAlgorithm = "sliding_window"
+	// TokenBucket refills Limit tokens per Window and allows bursts up to Limit
+	TokenBucket RateLimitAlgorithm = "token_bucket"
+)
+
+// RateLimitKey selects who a limit applies to
+type RateLimitKey string
+
+const (
+	KeyByIP     RateLimitKey = "ip"
+	KeyByUser   RateLimitKey = "user"    // falls back to IP for anonymous requests
+	KeyByAPIKey RateLimitKey = "api_key" // falls back to user, then IP
+	KeyByRoute  RateLimitKey = "route"   // one shared budget for every caller of the route
+)
+
+// RateLimitPolicy is a named limit, optionally bound to routes
+type RateLimitPolicy struct {
+	Name      string
+	Limit     int // requests per window, bucket size for TokenBucket; 0 disables the policy
+	Window    time.Duration
+	Algorithm RateLimitAlgorithm
+	KeyBy     RateLimitKey
+	Routes    []string // "METHOD /path" patterns; "*" matches one path segment
+}
+
+// RateLimitDecision is the outcome of counting one request
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the budget is fully available again
+	RetryAfter time.Duration // until the next request is allowed, when denied
+}
+
+// RateLimitStore counts requests; implemented in memory and on Redis
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, policy RateLimitPolicy, now time.Time) (RateLimitDecision, error)
+}
+
+// DefaultRateLimitPolicies protects credential, verification, lead, click and search endpoints
+// and caps authenticated traffic per user or API key
+func DefaultRateLimitPolicies() map[string]RateLimitPolicy {
+	policies := []RateLimitPolicy{
+		{Name: "auth_login", Limit: 10, Window: time.Minute, KeyBy: KeyByIP, Routes: []string{"POST /api/v1/auth/login"}},
+		{Name: "auth_register", Limit: 5, Window: time.Hour, KeyBy: KeyByIP, Routes: []string{"POST /api/v1/auth/register"}},
+		{Name: "auth_refresh", Limit: 30, Window: time.Minute, KeyBy: KeyByIP, Routes: []string{"POST /api/v1/auth/refresh"}},
+		{Name: "auth_verify", Limit: 5, Window: 10 * time.Minute, KeyBy: KeyByIP, Routes: []string{
+			"POST /api/v1/auth/verify/request",
+			"POST /api/v1/auth/verify/confirm",
+			"POST /api/v1/auth/verify/request/me",
+			"POST /api/v1/auth/verify/confirm/me",
+		}},
+		{Name: "leads", Limit: 5, Window: time.Hour, KeyBy: KeyByIP, Routes: []string{"POST /api/v1/leads/employer"}},
+		{Name: "search", Limit: 60, Window: time.Minute, Algorithm: TokenBucket, KeyBy: KeyByIP, Routes: []string{
+			"GET /api/v1/castings",
+			"GET /api/v1/profiles/models",
+		}},
+		// Attached after authentication rather than by route; API keys have their own per-key limit
+		{Name: "api", Limit: 100, Window: time.Minute, Algorithm: TokenBucket, KeyBy: KeyByUser},
+		{Name: "promotion_click", Limit: 30, Window: time.Minute, KeyBy: KeyByUser},
+	}
+	out := make(map[string]RateLimitPolicy, len(policies))
+	for _, p := range policies {
+		if p.Algorithm == "" {
+			p.Algorithm = SlidingWindow
+		}
+		out[p.Name] = p
+	}
+	return out
+}
+
+// ParseRateLimitPolicies applies a comma separated spec on top of the defaults:
+//
+//	name=limit/window[:key[:algorithm]][@METHOD /path|METHOD /path]
+//
+// e.g. "auth_login=20/1m,search=120/1m:ip:token_bucket,reports=5/1h:user@POST /api/v1/moderation/reports".
+// Omitted parts keep the default; a limit of 0 disables the policy.
+func ParseRateLimitPolicies(spec string, defaults map[string]RateLimitPolicy) (map[string]RateLimitPolicy, error) {
+	policies := make(map[string]RateLimitPolicy, len(defaults))
+	for name, p := range defaults {
+		policies[name] = p
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rule, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("rate limit policy %q: expected name=limit/window", entry)
+		}
+		p, exists := policies[name]
+		if !exists {
+			p = RateLimitPolicy{Name: name, Algorithm: SlidingWindow, KeyBy: KeyByIP}
+		}
+
+		rule, routes, hasRoutes := strings.Cut(rule, "@")
+		if hasRoutes {
+			p.Routes = nil
+			for _, route := range strings.Split(routes, "|") {
+				method, path, ok := strings.Cut(strings.TrimSpace(route), " ")
+				if !ok || !strings.HasPrefix(path, "/") {
+					return nil, fmt.Errorf("rate limit policy %q: invalid route %q", name, route)
+				}
+				p.Routes = append(p.Routes, strings.ToUpper(method)+" "+strings.TrimSpace(path))
+			}
+		}
+
+		parts := strings.Split(strings.TrimSpace(rule), ":")
+		limit, window, ok := strings.Cut(parts[0], "/")
+		n, err := strconv.Atoi(limit)
+		if !ok || err != nil || n < 0 {
+			return nil, fmt.Errorf("rate limit policy %q: invalid limit %q", name, parts[0])
+		}
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("rate limit policy %q: invalid window %q", name, window)
+		}
+		p.Limit, p.Window = n, d
+
+		if len(parts) > 1 {
+			switch key := RateLimitKey(parts[1]); key {
+			case KeyByIP, KeyByUser, KeyByAPIKey, KeyByRoute:
+				p.KeyBy = key
+			default:
+				return nil, fmt.Errorf("rate limit policy %q: unknown key %q", name, parts[1])
+			}
+		}
+		if len(parts) > 2 {
+			switch algo := RateLimitAlgorithm(parts[2]); algo {
+			case SlidingWindow, TokenBucket:
+				p.Algorithm = algo
+			default:
+				return nil, fmt.Errorf("rate limit policy %q: unknown algorithm %q", name, parts[2])
+			}
+		}
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("rate limit policy %q: too many fields", name)
+		}
+		policies[name] = p
+	}
+	return policies, nil
+}
+
+// RateLimiter enforces rate limit policies. It counts in the shared store and
+// falls back to process memory when the store is unavailable.
+type RateLimiter struct {
+	store    RateLimitStore
+	fallback RateLimitStore
+	policies map[string]RateLimitPolicy
+	routes   []routePolicy
+	now      func() time.Time
+	warnedAt atomic.Int64 // unix seconds of the last store failure log
+}
+
+type routePolicy struct {
+	method  string
+	pattern string
+	policy  string
+}
+
+// NewRateLimiter creates a limiter; a nil store counts in process memory only
+func NewRateLimiter(store RateLimitStore, policies map[string]RateLimitPolicy) *RateLimiter {
+	fallback := NewMemoryRateLimitStore()
+	if store == nil {
+		store = fallback
+	}
+	l := &RateLimiter{
+		store:    store,
+		fallback: fallback,
+		policies: policies,
+		now:      time.Now,
+	}
+
+	// Sorted for a deterministic match order across restarts
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, route := range policies[name].Routes {
+			method, pattern, _ := strings.Cut(route, " ")
+			l.routes = append(l.routes, routePolicy{method: method, pattern: pattern, policy: name})
+		}
+	}
+	return l
+}
+
+// Limit returns middleware enforcing one policy on every request it wraps
+func (l *RateLimiter) Limit(policy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l.enforce(w, r, policy, "") {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// LimitUsers is Limit for user tokens only; requests authenticated by an API key
+// pass through since the key's own limit is enforced on authentication
+func (l *RateLimiter) LimitUsers(policy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if GetAPIKeyID(r.Context()) != uuid.Nil || l.enforce(w, r, policy, "") {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// Routes returns middleware enforcing the policy bound to the request route, if any
+func (l *RateLimiter) Routes() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimSuffix(r.URL.Path, "/")
+			for _, route := range l.routes {
+				if route.method == r.Method && matchPath(route.pattern, path) {
+					if !l.enforce(w, r, route.policy, route.method+" "+route.pattern) {
+						return
+					}
+					break
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// enforce counts the request and writes RateLimit headers; false means a 429 was sent.
+// route is the matched policy route, empty when the policy wraps handlers directly.
+func (l *RateLimiter) enforce(w http.ResponseWriter, r *http.Request, name, route string) bool {
+	policy, ok := l.policies[name]
+	if !ok || policy.Limit <= 0 || policy.Window <= 0 {
+		return true
+	}
+
+	key := "ratelimit:" + policy.Name + ":" + rateLimitSubject(r, policy.KeyBy, route)
+	now := l.now()
+	d, err := l.store.Allow(r.Context(), key, policy, now)
+	if err != nil {
+		if last := l.warnedAt.Load(); now.Unix()-last >= 60 && l.warnedAt.CompareAndSwap(last, now.Unix()) {
+			log.Warn().Err(err).Str("policy", policy.Name).Msg("rate limit store unavailable, counting in memory")
+		}
+		if d, err = l.fallback.Allow(r.Context(), key, policy, now); err != nil {
+			return true
+		}
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Window)))
+	if !d.Allowed {
+		h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(d.RetryAfter))))
+		response.TooManyRequests(w)
+		return false
+	}
+	return true
+}
+
+// rateLimitSubject identifies who the request is counted against
+func rateLimitSubject(r *http.Request, keyBy RateLimitKey, route string) string {
+	switch keyBy {
+	case KeyByRoute:
+		if route != "" {
+			return "route:" + route
+		}
+		pattern := r.URL.Path
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			pattern = rctx.RoutePattern()
+		}
+		return "route:" + r.Method + " " + pattern
+	case KeyByAPIKey:
+		if id := GetAPIKeyID(r.Context()); id != uuid.Nil {
+			return "key:" + id.String()
+		}
+		fallthrough
+	case KeyByUser:
+		if id := GetUserID(r.Context()); id != uuid.Nil {
+			return "user:" + id.String()
+		}
+	}
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return "ip:" + ip
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
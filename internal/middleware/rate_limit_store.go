@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowDecision derives the decision from the previous and current
+// window counters; cur includes the request when it was allowed
+func slidingWindowDecision(p RateLimitPolicy, elapsed time.Duration, prev, cur int, allowed bool) RateLimitDecision {
+	weight := float64(p.Window-elapsed) / float64(p.Window)
+	used := int(math.Ceil(float64(prev)*weight)) + cur
+	d := RateLimitDecision{
+		Allowed:   allowed,
+		Limit:     p.Limit,
+		Remaining: max(0, p.Limit-used),
+		Reset:     p.Window - elapsed,
+	}
+	if !allowed {
+		// The previous window's weight decays linearly; wait until the next request fits
+		d.RetryAfter = p.Window - elapsed
+		if prev > 0 && cur < p.Limit {
+			fits := float64(p.Limit-cur) / float64(prev)
+			d.RetryAfter = time.Duration((1-fits)*float64(p.Window)) - elapsed + time.Millisecond
+		}
+	}
+	return d
+}
+
+// tokenBucketDecision derives the decision from the tokens left after the request
+func tokenBucketDecision(p RateLimitPolicy, tokens float64, allowed bool) RateLimitDecision {
+	perToken := float64(p.Window) / float64(p.Limit)
+	d := RateLimitDecision{
+		Allowed:   allowed,
+		Limit:     p.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(p.Limit) - tokens) * perToken),
+	}
+	if !allowed {
+		d.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+	return d
+}
+
+// MemoryRateLimitStore counts requests in process; limits are per instance
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	index     int64 // window number since the epoch
+	prev, cur int
+	expires   time.Time
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	expires time.Time
+}
+
+// NewMemoryRateLimitStore creates in-process store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		windows: make(map[string]*memoryWindow),
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+func (s *MemoryRateLimitStore) Allow(_ context.Context, key string, p RateLimitPolicy, now time.Time) (RateLimitDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > time.Minute {
+		s.sweep(now)
+	}
+
+	if p.Algorithm == TokenBucket {
+		b, ok := s.buckets[key]
+		if !ok {
+			b = &memoryBucket{tokens: float64(p.Limit), updated: now}
+			s.buckets[key] = b
+		}
+		elapsed := now.Sub(b.updated)
+		if elapsed > 0 {
+			b.tokens = math.Min(float64(p.Limit), b.tokens+float64(elapsed)/float64(p.Window)*float64(p.Limit))
+			b.updated = now
+		}
+		allowed := b.tokens >= 1
+		if allowed {
+			b.tokens--
+		}
+		b.expires = now.Add(p.Window)
+		return tokenBucketDecision(p, b.tokens, allowed), nil
+	}
+
+	index := now.UnixNano() / int64(p.Window)
+	elapsed := time.Duration(now.UnixNano() % int64(p.Window))
+	w, ok := s.windows[key]
+	switch {
+	case !ok:
+		w = &memoryWindow{index: index}
+		s.windows[key] = w
+	case w.index == index-1:
+		w.index, w.prev, w.cur = index, w.cur, 0
+	case w.index < index-1:
+		w.index, w.prev, w.cur = index, 0, 0
+	}
+	w.expires = now.Add(2 * p.Window)
+
+	weight := float64(p.Window-elapsed) / float64(p.Window)
+	allowed := float64(w.prev)*weight+float64(w.cur) < float64(p.Limit)
+	if allowed {
+		w.cur++
+	}
+	return slidingWindowDecision(p, elapsed, w.prev, w.cur, allowed), nil
+}
+
+// sweep drops idle keys so per-IP limits do not grow without bound
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for k, w := range s.windows {
+		if now.After(w.expires) {
+			delete(s.windows, k)
+		}
+	}
+	for k, b := range s.buckets {
+		if now.After(b.expires) {
+			delete(s.buckets, k)
+		}
+	}
+	s.lastSweep = now
+}
+
+// RedisRateLimitStore shares counts across API instances
+type RedisRateLimitStore struct {
+	client *redis.Client
+}
+
+// NewRedisRateLimitStore creates Redis-backed store
+func NewRedisRateLimitStore(client *redis.Client) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+// KEYS: current window, previous window. ARGV: limit, window ms, elapsed ms.
+// Returns allowed flag, previous and current counts.
+var slidingWindowScript = redis.NewScript(`
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+if prev * (window - elapsed) / window + cur >= limit then
+  return {0, prev, cur}
+end
+cur = redis.call('INCR', KEYS[1])
+if cur == 1 then
+  redis.call('PEXPIRE', KEYS[1], window * 2)
+end
+return {1, prev, cur}
+`)
+
+// KEYS: bucket. ARGV: capacity, window ms, now ms.
+// Returns allowed flag and the tokens left as a string.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+  tokens = capacity
+  ts = now
+end
+if now > ts then
+  tokens = math.min(capacity, tokens + (now - ts) * capacity / window)
+  ts = now
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, tostring(tokens)}
+`)
+
+func (s *RedisRateLimitStore) Allow(ctx context.Context, key string, p RateLimitPolicy, now time.Time) (RateLimitDecision, error) {
+	windowMs := p.Window.Milliseconds()
+	if windowMs <= 0 {
+		windowMs = 1
+	}
+
+	if p.Algorithm == TokenBucket {
+		res, err := tokenBucketScript.Run(ctx, s.client, []string{key}, p.Limit, windowMs, now.UnixMilli()).Slice()
+		if err != nil {
+			return RateLimitDecision{}, err
+		}
+		allowed, _ := res[0].(int64)
+		raw, _ := res[1].(string)
+		tokens, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return RateLimitDecision{}, err
+		}
+		return tokenBucketDecision(p, tokens, allowed == 1), nil
+	}
+
+	ms := now.UnixMilli()
+	index := ms / windowMs
+	elapsed := ms % windowMs
+	// Hash tag keeps both windows in one cluster slot
+	keys := []string{"{" + key + "}:" + strconv.FormatInt(index, 10), "{" + key + "}:" + strconv.FormatInt(index-1, 10)}
+	res, err := slidingWindowScript.Run(ctx, s.client, keys, p.Limit, windowMs, elapsed).Int64Slice()
+	if err != nil {
+		return RateLimitDecision{}, err
+	}
+	return slidingWindowDecision(p, time.Duration(elapsed)*time.Millisecond, int(res[1]), int(res[2]), res[0] == 1), nil
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryStoreSlidingWindow(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	p := RateLimitPolicy{Name: "t", Limit: 2, Window: time.Minute, Algorithm: SlidingWindow}
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) // window boundary
+
+	for i := 0; i < 2; i++ {
+		if d, _ := store.Allow(context.Background(), "k", p, start); !d.Allowed || d.Remaining != 1-i {
+			t.Fatalf("request %d: unexpected decision %+v", i+1, d)
+		}
+	}
+	d, _ := store.Allow(context.Background(), "k", p, start.Add(time.Second))
+	if d.Allowed || d.RetryAfter <= 0 {
+		t.Fatalf("expected third request to be limited, got %+v", d)
+	}
+
+	// Halfway through the next window the previous one still counts for half
+	if d, _ := store.Allow(context.Background(), "k", p, start.Add(90*time.Second)); !d.Allowed {
+		t.Fatalf("expected one request to fit, got %+v", d)
+	}
+	if d, _ := store.Allow(context.Background(), "k", p, start.Add(90*time.Second)); d.Allowed {
+		t.Fatalf("expected weighted previous window to limit, got %+v", d)
+	}
+}
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	p := RateLimitPolicy{Name: "t", Limit: 3, Window: 3 * time.Second, Algorithm: TokenBucket}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if d, _ := store.Allow(context.Background(), "k", p, now); !d.Allowed {
+			t.Fatalf("burst request %d should be allowed", i+1)
+		}
+	}
+	d, _ := store.Allow(context.Background(), "k", p, now)
+	if d.Allowed || d.RetryAfter != time.Second {
+		t.Fatalf("expected empty bucket with 1s retry, got %+v", d)
+	}
+	if d, _ := store.Allow(context.Background(), "k", p, now.Add(time.Second)); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("expected one refilled token, got %+v", d)
+	}
+}
+
+func TestParseRateLimitPolicies(t *testing.T) {
+	policies, err := ParseRateLimitPolicies("auth_login=20/1m, reports=5/1h:user:token_bucket@POST /api/v1/moderation/reports", DefaultRateLimitPolicies())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	login := policies["auth_login"]
+	if login.Limit != 20 || login.KeyBy != KeyByIP || len(login.Routes) != 1 {
+		t.Fatalf("expected override to keep key and routes, got %+v", login)
+	}
+	reports := policies["reports"]
+	if reports.Window != time.Hour || reports.KeyBy != KeyByUser || reports.Algorithm != TokenBucket || reports.Routes[0] != "POST /api/v1/moderation/reports" {
+		t.Fatalf("unexpected new policy %+v", reports)
+	}
+
+	for _, bad := range []string{"x=ten/1m", "x=1/soon", "x=1/1m:planet", "x=1/1m@/no-method"} {
+		if _, err := ParseRateLimitPolicies(bad, nil); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, RateLimitPolicy, time.Time) (RateLimitDecision, error) {
+	return RateLimitDecision{}, errors.New("redis down")
+}
+
+func TestRateLimiterRoutesHeadersAndFallback(t *testing.T) {
+	policies, _ := ParseRateLimitPolicies("auth_login=1/1m", DefaultRateLimitPolicies())
+	handler := NewRateLimiter(failingStore{}, policies).Routes()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/api/v1/auth/login", "10.0.0.1")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected allowed request with headers, got %d %v", w.Code, w.Header())
+	}
+	w = send("/api/v1/auth/login", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After from the memory fallback, got %d %v", w.Code, w.Header())
+	}
+	if w := send("/api/v1/auth/login", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Fatalf("expected limits per IP, got %d", w.Code)
+	}
+	if w := send("/api/v1/castings/my", "10.0.0.1"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("expected unbound route to pass untouched, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimiterLimitUsersSkipsAPIKeys(t *testing.T) {
+	policies, _ := ParseRateLimitPolicies("api=1/1m", DefaultRateLimitPolicies())
+	handler := NewRateLimiter(nil, policies).LimitUsers("api")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(ctx context.Context) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/castings/my", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	user := context.WithValue(context.Background(), UserIDKey, uuid.New())
+	if send(user) != http.StatusOK || send(user) != http.StatusTooManyRequests {
+		t.Fatalf("expected the api policy to limit user tokens")
+	}
+	key := context.WithValue(user, APIKeyIDKey, uuid.New())
+	for i := 0; i < 3; i++ {
+		if code := send(key); code != http.StatusOK {
+			t.Fatalf("expected API key requests to skip the api policy, got %d", code)
+		}
+	}
+}
+
+func TestRateLimiterLimitKeysByUserBehindOneIP(t *testing.T) {
+	policies, _ := ParseRateLimitPolicies("promotion_click=1/1m", DefaultRateLimitPolicies())
+	handler := NewRateLimiter(nil, policies).Limit("promotion_click")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(userID uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/promotions/click", nil)
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey, userID))
+		req.RemoteAddr = "203.0.113.7:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	first, second := uuid.New(), uuid.New()
+	if send(first) != http.StatusOK || send(second) != http.StatusOK {
+		t.Fatalf("expected users behind one IP to have separate budgets")
+	}
+	if code := send(first); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the first user to be limited, got %d", code)
+	}
+}
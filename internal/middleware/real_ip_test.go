@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIPTrustsOnlyConfiguredProxies(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+
+	var got string
+	h := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.RemoteAddr
+	}))
+	cases := []struct {
+		remote, xff, want string
+	}{
+		// A direct client cannot pick its IP
+		{"203.0.113.7:5000", "1.2.3.4", "203.0.113.7:5000"},
+		// Behind a proxy the rightmost untrusted hop wins, not a spoofed leftmost one
+		{"10.0.0.2:80", "1.2.3.4, 198.51.100.9, 10.0.0.5", "198.51.100.9"},
+		{"127.0.0.1:80", "198.51.100.9", "198.51.100.9"},
+		{"10.0.0.2:80", "", "10.0.0.2:80"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = c.remote
+		if c.xff != "" {
+			req.Header.Set("X-Forwarded-For", c.xff)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if got != c.want {
+			t.Fatalf("remote %s, X-Forwarded-For %q: expected %s, got %s", c.remote, c.xff, c.want, got)
+		}
+	}
+
+	if _, err := ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatalf("expected invalid proxy error")
+	}
+}
@@ -0,0 +1,135 @@
+package email
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EventType is a normalized provider delivery event
+type EventType string
+
+const (
+	EventDelivered    EventType = "delivered"
+	EventBounced      EventType = "bounced"
+	EventComplained   EventType = "complained"
+	EventUnsubscribed EventType = "unsubscribed"
+)
+
+// Event is a delivery event reported by the email provider
+type Event struct {
+	ProviderEventID string
+	Email           string
+	Type            EventType
+	Reason          string
+	OccurredAt      time.Time
+}
+
+// ErrInvalidSignature is returned when a webhook payload fails verification
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// ErrStaleTimestamp is returned when a signed webhook is too old to be accepted
+var ErrStaleTimestamp = errors.New("webhook timestamp outside tolerance")
+
+// WebhookTolerance bounds the age of a signed webhook so captured payloads cannot be replayed
+const WebhookTolerance = 10 * time.Minute
+
+// sendGridEvent is a single item of the SendGrid Event Webhook payload
+type sendGridEvent struct {
+	Email     string `json:"email"`
+	Timestamp int64  `json:"timestamp"`
+	Event     string `json:"event"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	EventID   string `json:"sg_event_id"`
+}
+
+// ParseSendGridEvents parses a SendGrid Event Webhook body.
+// Events we don't act on (processed, open, click, soft blocks...) are skipped.
+func ParseSendGridEvents(body []byte) ([]Event, error) {
+	var raw []sendGridEvent
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("decode sendgrid events: %w", err)
+	}
+
+	events := make([]Event, 0, len(raw))
+	for _, e := range raw {
+		var t EventType
+		switch e.Event {
+		case "delivered":
+			t = EventDelivered
+		case "bounce":
+			// "blocked" is a temporary rejection, not an invalid address
+			if e.Type == "blocked" {
+				continue
+			}
+			t = EventBounced
+		case "spamreport":
+			t = EventComplained
+		case "unsubscribe", "group_unsubscribe":
+			t = EventUnsubscribed
+		default:
+			continue
+		}
+		if e.Email == "" {
+			continue
+		}
+		events = append(events, Event{
+			ProviderEventID: e.EventID,
+			Email:           normalizeAddress(e.Email),
+			Type:            t,
+			Reason:          e.Reason,
+			OccurredAt:      time.Unix(e.Timestamp, 0).UTC(),
+		})
+	}
+	return events, nil
+}
+
+// VerifySendGridSignature checks the ECDSA signature of a signed Event Webhook.
+// publicKey is the base64 DER key from SendGrid settings; signature and timestamp
+// come from the X-Twilio-Email-Event-Webhook-* headers.
+func VerifySendGridSignature(publicKey, signature, timestamp string, body []byte) error {
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("decode webhook public key: %w", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("parse webhook public key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("webhook public key is not ECDSA")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	hash := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(key, hash[:], sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// VerifyWebhookTimestamp checks that a signed webhook timestamp (unix seconds)
+// is within WebhookTolerance of now
+func VerifyWebhookTimestamp(timestamp string, now time.Time) error {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+	age := now.Sub(time.Unix(sec, 0))
+	if age > WebhookTolerance || age < -WebhookTolerance {
+		return ErrStaleTimestamp
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package email
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestParseSendGridEvents(t *testing.T) {
+	body := []byte(`[
+		{"email":"Model@Example.com","timestamp":1700000000,"event":"bounce","type":"bounce","reason":"550 no such user","sg_event_id":"e1"},
+		{"email":"a@example.com","timestamp":1700000000,"event":"bounce","type":"blocked","sg_event_id":"e2"},
+		{"email":"a@example.com","timestamp":1700000000,"event":"spamreport","sg_event_id":"e3"},
+		{"email":"a@example.com","timestamp":1700000000,"event":"open","sg_event_id":"e4"},
+		{"email":"a@example.com","timestamp":1700000000,"event":"group_unsubscribe","sg_event_id":"e5"}
+	]`)
+
+	events, err := ParseSendGridEvents(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Type != EventBounced || events[0].Email != "model@example.com" {
+		t.Fatalf("unexpected bounce event: %+v", events[0])
+	}
+	if events[1].Type != EventComplained || events[2].Type != EventUnsubscribed {
+		t.Fatalf("unexpected event types: %s, %s", events[1].Type, events[2].Type)
+	}
+}
+
+func TestVerifySendGridSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	publicKey := base64.StdEncoding.EncodeToString(der)
+
+	body := []byte(`[{"email":"a@example.com","event":"delivered"}]`)
+	timestamp := "1700000000"
+	hash := sha256.Sum256(append([]byte(timestamp), body...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	signature := base64.StdEncoding.EncodeToString(sig)
+
+	if err := VerifySendGridSignature(publicKey, signature, timestamp, body); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if err := VerifySendGridSignature(publicKey, signature, "1700000001", body); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature for tampered timestamp, got %v", err)
+	}
+
+	sent := time.Unix(1700000000, 0)
+	if err := VerifyWebhookTimestamp(timestamp, sent.Add(time.Minute)); err != nil {
+		t.Fatalf("expected fresh timestamp, got %v", err)
+	}
+	if err := VerifyWebhookTimestamp(timestamp, sent.Add(time.Hour)); err != ErrStaleTimestamp {
+		t.Fatalf("expected replayed payload to be rejected, got %v", err)
+	}
+}
+
+func TestUnsubscribeSigner(t *testing.T) {
+	signer := NewUnsubscribeSigner("secret", "https://api.mwork.kz/webhooks/email/unsubscribe")
+	sig := signer.sign("user@example.com")
+
+	if !signer.Verify("User@Example.com ", sig) {
+		t.Fatal("expected signature to verify regardless of case")
+	}
+	if signer.Verify("other@example.com", sig) {
+		t.Fatal("signature must not verify for another address")
+	}
+}
+
+func TestShouldSuppress(t *testing.T) {
+	if !shouldSuppress(SuppressionBounced, "password_reset") {
+		t.Fatal("bounced addresses must not receive any email")
+	}
+	if shouldSuppress(SuppressionComplained, "password_reset") {
+		t.Fatal("transactional email must still reach complained addresses")
+	}
+	if !shouldSuppress(SuppressionUnsubscribed, "new_message") {
+		t.Fatal("unsubscribed addresses must not receive notification email")
+	}
+}
@@ -3,6 +3,7 @@ package email
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -42,6 +43,15 @@ type EmailMessage struct {
 	Subject     string
 	HTMLContent string
 	TextContent string
+	Headers     map[string]string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
 }
 
 // SendGridRequest represents the SendGrid API request
@@ -50,6 +60,15 @@ type SendGridRequest struct {
 	From             SendGridEmail             `json:"from"`
 	Subject          string                    `json:"subject"`
 	Content          []SendGridContent         `json:"content"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+	Attachments      []SendGridAttachment      `json:"attachments,omitempty"`
+}
+
+type SendGridAttachment struct {
+	Content     string `json:"content"` // base64
+	Type        string `json:"type,omitempty"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition,omitempty"`
 }
 
 type SendGridPersonalization struct {
@@ -90,6 +109,7 @@ func (c *SendGridClient) Send(ctx context.Context, msg *EmailMessage) error {
 		},
 		Subject: msg.Subject,
 		Content: []SendGridContent{},
+		Headers: msg.Headers,
 	}
 
 	// Add HTML content first (preferred)
@@ -108,6 +128,15 @@ func (c *SendGridClient) Send(ctx context.Context, msg *EmailMessage) error {
 		})
 	}
 
+	for _, a := range msg.Attachments {
+		request.Attachments = append(request.Attachments, SendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(a.Content),
+			Type:        a.ContentType,
+			Filename:    a.Filename,
+			Disposition: "attachment",
+		})
+	}
+
 	body, err := json.Marshal(request)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
@@ -22,6 +22,8 @@ type Service struct {
 	baseTemplate *template.Template
 	queue        chan *QueuedEmail
 	wg           sync.WaitGroup
+	suppression  SuppressionChecker
+	unsubscribe  *UnsubscribeSigner
 }
 
 // QueuedEmail represents an email in the send queue
@@ -31,6 +33,7 @@ type QueuedEmail struct {
 	Subject      string
 	TemplateName string
 	Data         interface{}
+	Attachments  []Attachment
 }
 
 // NewService creates email service
@@ -54,6 +57,16 @@ func NewService(config SendGridConfig) *Service {
 	return s
 }
 
+// SetSuppressionChecker makes the service skip suppressed addresses
+func (s *Service) SetSuppressionChecker(checker SuppressionChecker) {
+	s.suppression = checker
+}
+
+// SetUnsubscribeSigner enables List-Unsubscribe headers on non-transactional mail
+func (s *Service) SetUnsubscribeSigner(signer *UnsubscribeSigner) {
+	s.unsubscribe = signer
+}
+
 // loadTemplates loads all email templates
 func (s *Service) loadTemplates() {
 	templates := map[string]string{
@@ -68,6 +81,7 @@ func (s *Service) loadTemplates() {
 		"digest":            DigestTemplate,
 		"verification":      VerificationTemplate,
 		"password_reset":    PasswordResetTemplate,
+		"invoice":           InvoiceTemplate,
 	}
 
 	for name, content := range templates {
@@ -104,6 +118,16 @@ func (s *Service) send(ctx context.Context, email *QueuedEmail) error {
 		return nil
 	}
 
+	if s.suppression != nil {
+		reason, err := s.suppression.SuppressionReason(ctx, email.To)
+		if err != nil {
+			log.Warn().Err(err).Str("to", email.To).Msg("Failed to check email suppression")
+		} else if shouldSuppress(reason, email.TemplateName) {
+			log.Debug().Str("to", email.To).Str("template", email.TemplateName).Str("reason", reason).Msg("Skipping email to suppressed address")
+			return nil
+		}
+	}
+
 	var contentBuf bytes.Buffer
 	if err := tmpl.Execute(&contentBuf, email.Data); err != nil {
 		return err
@@ -122,21 +146,38 @@ func (s *Service) send(ctx context.Context, email *QueuedEmail) error {
 		ToName:      email.ToName,
 		Subject:     email.Subject,
 		HTMLContent: htmlBuf.String(),
+		Headers:     s.listUnsubscribeHeaders(email),
+		Attachments: email.Attachments,
 	})
 }
 
+// listUnsubscribeHeaders returns RFC 8058 one-click unsubscribe headers for non-transactional mail
+func (s *Service) listUnsubscribeHeaders(email *QueuedEmail) map[string]string {
+	if s.unsubscribe == nil || IsTransactional(email.TemplateName) {
+		return nil
+	}
+	return map[string]string{
+		"List-Unsubscribe":      "<" + s.unsubscribe.URL(email.To) + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
 // Queue adds an email to the async send queue
 func (s *Service) Queue(to, toName, templateName, subject string, data interface{}) {
-	select {
-	case s.queue <- &QueuedEmail{
+	s.enqueue(&QueuedEmail{
 		To:           to,
 		ToName:       toName,
 		Subject:      subject,
 		TemplateName: templateName,
 		Data:         data,
-	}:
+	})
+}
+
+func (s *Service) enqueue(email *QueuedEmail) {
+	select {
+	case s.queue <- email:
 	default:
-		log.Warn().Str("to", to).Msg("Email queue full, dropping email")
+		log.Warn().Str("to", email.To).Msg("Email queue full, dropping email")
 	}
 }
 
@@ -223,3 +264,22 @@ func (s *Service) SendLeadRejected(to, contactName, companyName, reason string)
 		"Reason":      reason,
 	})
 }
+
+// SendInvoice sends a payment invoice with its PDF attached
+func (s *Service) SendInvoice(to, toName, number, amount string, pdf []byte) {
+	s.enqueue(&QueuedEmail{
+		To:           to,
+		ToName:       toName,
+		Subject:      "Счет № " + number,
+		TemplateName: "invoice",
+		Data: map[string]string{
+			"Number": number,
+			"Amount": amount,
+		},
+		Attachments: []Attachment{{
+			Filename:    "invoice-" + number + ".pdf",
+			ContentType: "application/pdf",
+			Content:     pdf,
+		}},
+	})
+}
@@ -0,0 +1,43 @@
+package email
+
+import "context"
+
+// Suppression reasons stored on the user
+const (
+	SuppressionBounced      = "bounced"
+	SuppressionComplained   = "complained"
+	SuppressionUnsubscribed = "unsubscribed"
+)
+
+// SuppressionChecker reports why an address must not receive email ("" = allowed)
+type SuppressionChecker interface {
+	SuppressionReason(ctx context.Context, address string) (string, error)
+}
+
+// transactionalTemplates are account emails the user asked for; they carry no
+// unsubscribe link and are still sent after a complaint or unsubscribe.
+var transactionalTemplates = map[string]bool{
+	"verification":   true,
+	"password_reset": true,
+	"lead_approved":  true,
+	"lead_rejected":  true,
+	"invoice":        true,
+}
+
+// IsTransactional reports whether a template is a transactional email
+func IsTransactional(templateName string) bool {
+	return transactionalTemplates[templateName]
+}
+
+// shouldSuppress decides whether a template may be sent given a suppression reason.
+// Hard bounces block everything; complaints and unsubscribes only block non-transactional mail.
+func shouldSuppress(reason, templateName string) bool {
+	switch reason {
+	case "":
+		return false
+	case SuppressionBounced:
+		return true
+	default:
+		return !IsTransactional(templateName)
+	}
+}
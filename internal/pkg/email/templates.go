@@ -232,3 +232,13 @@ const PasswordResetTemplate = `
 <p style="color: #666; margin-top: 20px;">Ссылка действительна в течение 1 часа.</p>
 <p style="color: #666;">Если вы не запрашивали сброс пароля, проигнорируйте это письмо.</p>
 `
+
+// InvoiceTemplate - invoice for a completed payment, PDF attached
+const InvoiceTemplate = `
+<h2>🧾 Счет № {{.Number}}</h2>
+<p>Спасибо за оплату!</p>
+<div class="info-box">
+    <p><strong>Сумма:</strong> {{.Amount}}</p>
+</div>
+<p>Счет во вложении. Его также можно скачать в разделе «Платежи» личного кабинета.</p>
+`
@@ -0,0 +1,45 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// UnsubscribeSigner builds and verifies one-click unsubscribe links
+type UnsubscribeSigner struct {
+	secret  []byte
+	baseURL string
+}
+
+// NewUnsubscribeSigner creates a signer; baseURL is the public unsubscribe endpoint
+func NewUnsubscribeSigner(secret, baseURL string) *UnsubscribeSigner {
+	return &UnsubscribeSigner{secret: []byte(secret), baseURL: baseURL}
+}
+
+// URL returns the signed unsubscribe link for an address
+func (s *UnsubscribeSigner) URL(address string) string {
+	address = normalizeAddress(address)
+	q := url.Values{}
+	q.Set("email", address)
+	q.Set("sig", s.sign(address))
+	return s.baseURL + "?" + q.Encode()
+}
+
+// Verify checks that sig was issued for address
+func (s *UnsubscribeSigner) Verify(address, sig string) bool {
+	expected := s.sign(normalizeAddress(address))
+	return hmac.Equal([]byte(expected), []byte(sig))
+}
+
+func (s *UnsubscribeSigner) sign(address string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("unsubscribe:" + address))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
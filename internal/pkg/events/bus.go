@@ -0,0 +1,140 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Appender persists emitted events
+type Appender interface {
+	Append(ctx context.Context, events []Event) error
+}
+
+// BusConfig controls buffering of emitted events
+type BusConfig struct {
+	BatchSize     int // events per insert; a full batch flushes early
+	FlushInterval time.Duration
+	MaxBuffered   int // events kept in memory while the store is down; oldest are dropped beyond it
+}
+
+// Bus buffers events emitted by domain services and appends them to the
+// events table in batches
+type Bus struct {
+	store Appender
+	cfg   BusConfig
+
+	mu     sync.Mutex
+	buffer []Event
+	kick   chan struct{}
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewBus creates an event bus
+func NewBus(store Appender, cfg BusConfig) *Bus {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 200
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.MaxBuffered < cfg.BatchSize {
+		cfg.MaxBuffered = 50 * cfg.BatchSize
+	}
+	return &Bus{
+		store:  store,
+		cfg:    cfg,
+		kick:   make(chan struct{}, 1),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// Emit buffers an event for the next flush
+func (b *Bus) Emit(_ context.Context, p Payload) {
+	ev, err := NewEvent(p, time.Now())
+	if err != nil {
+		log.Error().Err(err).Str("type", string(p.EventType())).Msg("Failed to encode event")
+		return
+	}
+
+	b.mu.Lock()
+	b.buffer = append(b.buffer, ev)
+	if over := len(b.buffer) - b.cfg.MaxBuffered; over > 0 {
+		b.buffer = b.buffer[over:]
+		log.Warn().Int("dropped", over).Msg("Event buffer full, dropping oldest events")
+	}
+	full := len(b.buffer) >= b.cfg.BatchSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush appends buffered events batch by batch. Events of a failed batch are
+// put back in front of the buffer for the next flush.
+func (b *Bus) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	pending := b.buffer
+	b.buffer = nil
+	b.mu.Unlock()
+
+	for len(pending) > 0 {
+		n := min(len(pending), b.cfg.BatchSize)
+		if err := b.store.Append(ctx, pending[:n]); err != nil {
+			b.mu.Lock()
+			b.buffer = append(pending, b.buffer...)
+			b.mu.Unlock()
+			return err
+		}
+		pending = pending[n:]
+	}
+	return nil
+}
+
+// Start begins periodic flushing
+func (b *Bus) Start() {
+	log.Info().Msg("Starting event bus...")
+	go b.loop()
+}
+
+// Stop flushes what is buffered and stops the bus
+func (b *Bus) Stop() {
+	log.Info().Msg("Stopping event bus...")
+	close(b.stopCh)
+	<-b.doneCh
+}
+
+func (b *Bus) loop() {
+	defer close(b.doneCh)
+	ticker := time.NewTicker(b.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush()
+		case <-b.kick:
+			b.flush()
+		case <-b.stopCh:
+			b.flush()
+			return
+		}
+	}
+}
+
+func (b *Bus) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := b.Flush(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush events")
+	}
+}
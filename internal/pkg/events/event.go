@@ -0,0 +1,146 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type names a product event
+type Type string
+
+const (
+	TypeUserRegistered   Type = "user.registered"
+	TypeCastingPublished Type = "casting.published"
+	TypeResponseCreated  Type = "response.created"
+	TypeResponseAccepted Type = "response.accepted"
+	TypeMessageSent      Type = "message.sent"
+	TypePaymentCompleted Type = "payment.completed"
+)
+
+// Payload is the typed body of an event
+type Payload interface {
+	EventType() Type
+	// Subject returns the user who caused the event and the entity it is about
+	Subject() (actorID, entityID uuid.UUID)
+}
+
+// Emitter publishes product events. Emit never blocks the caller on delivery.
+type Emitter interface {
+	Emit(ctx context.Context, p Payload)
+}
+
+// Event is the stored envelope of a payload
+type Event struct {
+	Seq        int64           `json:"seq" db:"seq"`
+	ID         uuid.UUID       `json:"id" db:"id"`
+	Type       Type            `json:"type" db:"type"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty" db:"actor_id"`
+	EntityID   *uuid.UUID      `json:"entity_id,omitempty" db:"entity_id"`
+	Data       json.RawMessage `json:"data" db:"data"`
+	OccurredAt time.Time       `json:"occurred_at" db:"occurred_at"`
+}
+
+// NewEvent wraps a payload into an envelope
+func NewEvent(p Payload, at time.Time) (Event, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return Event{}, err
+	}
+	actorID, entityID := p.Subject()
+	return Event{
+		ID:         uuid.New(),
+		Type:       p.EventType(),
+		ActorID:    optionalID(actorID),
+		EntityID:   optionalID(entityID),
+		Data:       data,
+		OccurredAt: at.UTC(),
+	}, nil
+}
+
+func optionalID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+// UserRegistered is emitted when an account is created
+type UserRegistered struct {
+	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"`
+	Email  string    `json:"email"`
+}
+
+func (e UserRegistered) EventType() Type { return TypeUserRegistered }
+func (e UserRegistered) Subject() (uuid.UUID, uuid.UUID) {
+	return e.UserID, e.UserID
+}
+
+// CastingPublished is emitted when a casting becomes active
+type CastingPublished struct {
+	CastingID uuid.UUID `json:"casting_id"`
+	CreatorID uuid.UUID `json:"creator_id"`
+	Title     string    `json:"title"`
+	City      string    `json:"city"`
+}
+
+func (e CastingPublished) EventType() Type { return TypeCastingPublished }
+func (e CastingPublished) Subject() (uuid.UUID, uuid.UUID) {
+	return e.CreatorID, e.CastingID
+}
+
+// ResponseCreated is emitted when a model applies to a casting
+type ResponseCreated struct {
+	ResponseID  uuid.UUID `json:"response_id"`
+	CastingID   uuid.UUID `json:"casting_id"`
+	ModelUserID uuid.UUID `json:"model_user_id"`
+}
+
+func (e ResponseCreated) EventType() Type { return TypeResponseCreated }
+func (e ResponseCreated) Subject() (uuid.UUID, uuid.UUID) {
+	return e.ModelUserID, e.ResponseID
+}
+
+// ResponseAccepted is emitted when an employer accepts a response
+type ResponseAccepted struct {
+	ResponseID  uuid.UUID `json:"response_id"`
+	CastingID   uuid.UUID `json:"casting_id"`
+	ModelUserID uuid.UUID `json:"model_user_id"`
+	EmployerID  uuid.UUID `json:"employer_id"`
+}
+
+func (e ResponseAccepted) EventType() Type { return TypeResponseAccepted }
+func (e ResponseAccepted) Subject() (uuid.UUID, uuid.UUID) {
+	return e.EmployerID, e.ResponseID
+}
+
+// MessageSent is emitted for every chat message. Message text is never included.
+type MessageSent struct {
+	MessageID   uuid.UUID `json:"message_id"`
+	RoomID      uuid.UUID `json:"room_id"`
+	SenderID    uuid.UUID `json:"sender_id"`
+	MessageType string    `json:"message_type"`
+	Attachments int       `json:"attachments"`
+}
+
+func (e MessageSent) EventType() Type { return TypeMessageSent }
+func (e MessageSent) Subject() (uuid.UUID, uuid.UUID) {
+	return e.SenderID, e.MessageID
+}
+
+// PaymentCompleted is emitted once a payment is confirmed
+type PaymentCompleted struct {
+	PaymentID uuid.UUID `json:"payment_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Type      string    `json:"type"`
+	Provider  string    `json:"provider"`
+	Amount    float64   `json:"amount"`
+}
+
+func (e PaymentCompleted) EventType() Type { return TypePaymentCompleted }
+func (e PaymentCompleted) Subject() (uuid.UUID, uuid.UUID) {
+	return e.UserID, e.PaymentID
+}
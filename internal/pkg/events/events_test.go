@@ -0,0 +1,133 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeAppender struct {
+	batches [][]Event
+	fail    bool
+}
+
+func (f *fakeAppender) Append(_ context.Context, events []Event) error {
+	if f.fail {
+		return errors.New("db down")
+	}
+	f.batches = append(f.batches, append([]Event(nil), events...))
+	return nil
+}
+
+func TestBusFlushesInBatchesAndKeepsEventsOnFailure(t *testing.T) {
+	store := &fakeAppender{fail: true}
+	bus := NewBus(store, BusConfig{BatchSize: 2})
+	for i := 0; i < 5; i++ {
+		bus.Emit(context.Background(), ResponseCreated{ResponseID: uuid.New(), CastingID: uuid.New(), ModelUserID: uuid.New()})
+	}
+
+	if err := bus.Flush(context.Background()); err == nil {
+		t.Fatalf("expected flush error")
+	}
+	store.fail = false
+	if err := bus.Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(store.batches) != 3 || len(store.batches[0]) != 2 || len(store.batches[2]) != 1 {
+		t.Fatalf("expected batches of 2, 2 and 1, got %d", len(store.batches))
+	}
+	if e := store.batches[0][0]; e.Type != TypeResponseCreated || e.ActorID == nil || e.EntityID == nil {
+		t.Fatalf("unexpected envelope %+v", e)
+	}
+}
+
+func TestRedactorAppliesRules(t *testing.T) {
+	rules, err := ParseRules("email=hash, user.registered:role=drop, casting.published:title=mask")
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	if _, err := NewRedactor(rules, ""); !errors.Is(err, ErrMissingSalt) {
+		t.Fatalf("expected missing salt error, got %v", err)
+	}
+	r, err := NewRedactor(rules, "salt")
+	if err != nil {
+		t.Fatalf("new redactor: %v", err)
+	}
+
+	ev, _ := NewEvent(UserRegistered{UserID: uuid.New(), Role: "model", Email: "anna@example.com"}, time.Now())
+	var data map[string]any
+	_ = json.Unmarshal(r.Apply(ev).Data, &data)
+	if _, ok := data["role"]; ok {
+		t.Fatalf("expected role to be dropped")
+	}
+	if email, _ := data["email"].(string); email == "" || email == "anna@example.com" || len(email) != 16 {
+		t.Fatalf("expected hashed email, got %q", email)
+	}
+
+	ev, _ = NewEvent(CastingPublished{CastingID: uuid.New(), CreatorID: uuid.New(), Title: "Fashion"}, time.Now())
+	_ = json.Unmarshal(r.Apply(ev).Data, &data)
+	if data["title"] != "F*****n" {
+		t.Fatalf("expected masked title, got %v", data["title"])
+	}
+
+	if mask("anna@example.com") != "a**a@example.com" {
+		t.Fatalf("unexpected email mask %q", mask("anna@example.com"))
+	}
+	if _, err := ParseRules("email=encrypt"); err == nil {
+		t.Fatalf("expected invalid action error")
+	}
+}
+
+func TestFileSinkRotatesBySizeAndDay(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, 300)
+	if err != nil {
+		t.Fatalf("new sink: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time { return now }
+
+	ev, _ := NewEvent(MessageSent{MessageID: uuid.New(), RoomID: uuid.New(), SenderID: uuid.New(), MessageType: "text"}, now)
+	if err := sink.Write(context.Background(), []Event{ev, ev}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	now = now.Add(24 * time.Hour)
+	if err := sink.Write(context.Background(), []Event{ev}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_ = sink.Close()
+
+	for _, name := range []string{"events-20261018-001.ndjson", "events-20261018-002.ndjson", "events-20261019-001.ndjson"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+	}
+}
+
+func TestWebhookSinkSignsBody(t *testing.T) {
+	var signature, expected string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get("X-Events-Signature")
+		expected = "sha256=" + Sign("secret", body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	ev, _ := NewEvent(PaymentCompleted{PaymentID: uuid.New(), UserID: uuid.New(), Amount: 4990}, time.Now())
+	if err := NewWebhookSink(srv.URL, "secret").Write(context.Background(), []Event{ev}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if signature == "" || signature != expected {
+		t.Fatalf("signature %q does not match %q", signature, expected)
+	}
+}
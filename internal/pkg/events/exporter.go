@@ -0,0 +1,133 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ExporterConfig controls how events are shipped to a sink
+type ExporterConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	Lag        time.Duration // events younger than this wait for the next run
+	MaxBatches int           // batches per run, so one run cannot hold the lock forever
+}
+
+// Exporter ships events from the events table to a sink, resuming from the
+// sink's checkpoint
+type Exporter struct {
+	store    *Store
+	sink     Sink
+	redactor *Redactor
+	cfg      ExporterConfig
+	stopCh   chan struct{}
+}
+
+// NewExporter creates an exporter for one sink
+func NewExporter(store *Store, sink Sink, redactor *Redactor, cfg ExporterConfig) *Exporter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.Lag <= 0 {
+		cfg.Lag = 5 * time.Second
+	}
+	if cfg.MaxBatches <= 0 {
+		cfg.MaxBatches = 100
+	}
+	return &Exporter{
+		store:    store,
+		sink:     sink,
+		redactor: redactor,
+		cfg:      cfg,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// RunOnce exports pending events and returns how many were delivered
+func (x *Exporter) RunOnce(ctx context.Context) (int, error) {
+	unlock, err := x.store.lock(ctx, x.sink.Name())
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	seq, err := x.store.Checkpoint(ctx, x.sink.Name())
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for i := 0; i < x.cfg.MaxBatches; i++ {
+		batch, err := x.store.ListAfter(ctx, seq, x.cfg.Lag, x.cfg.BatchSize)
+		if err != nil {
+			return exported, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for j := range batch {
+			batch[j] = x.redactor.Apply(batch[j])
+		}
+		if err := x.sink.Write(ctx, batch); err != nil {
+			return exported, err
+		}
+
+		seq = batch[len(batch)-1].Seq
+		if err := x.store.SaveCheckpoint(ctx, x.sink.Name(), seq); err != nil {
+			return exported, err
+		}
+		exported += len(batch)
+		if len(batch) < x.cfg.BatchSize {
+			break
+		}
+	}
+	return exported, nil
+}
+
+// Start begins periodic export
+func (x *Exporter) Start() {
+	log.Info().Str("sink", x.sink.Name()).Msg("Starting event exporter...")
+	go func() {
+		x.run()
+
+		ticker := time.NewTicker(x.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				x.run()
+			case <-x.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the exporter
+func (x *Exporter) Stop() {
+	log.Info().Str("sink", x.sink.Name()).Msg("Stopping event exporter...")
+	close(x.stopCh)
+}
+
+func (x *Exporter) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	n, err := x.RunOnce(ctx)
+	if errors.Is(err, ErrExportInProgress) {
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("sink", x.sink.Name()).Int("exported", n).Msg("Event export failed")
+		return
+	}
+	if n > 0 {
+		log.Info().Str("sink", x.sink.Name()).Int("exported", n).Msg("Events exported")
+	}
+}
@@ -0,0 +1,175 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Sink receives exported events. Write must be all-or-nothing from the
+// exporter's point of view: on error the whole batch is retried.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, events []Event) error
+}
+
+// FileSink appends events as NDJSON to files rotated daily and by size.
+// Files are named events-YYYYMMDD-NNN.ndjson.
+type FileSink struct {
+	dir      string
+	maxBytes int64
+	now      func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	day  string
+	part int
+	size int64
+}
+
+// NewFileSink creates an NDJSON file sink writing into dir
+func NewFileSink(dir string, maxBytes int64) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		maxBytes = 100 << 20
+	}
+	return &FileSink{dir: dir, maxBytes: maxBytes, now: time.Now}, nil
+}
+
+func (s *FileSink) Name() string { return "file" }
+
+func (s *FileSink) Write(_ context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+		if err := s.rotate(int64(len(line))); err != nil {
+			return err
+		}
+		n, err := s.file.Write(line)
+		s.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	if s.file != nil {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+// rotate makes sure the current file is for today and has room for n bytes
+func (s *FileSink) rotate(n int64) error {
+	day := s.now().UTC().Format("20060102")
+	if s.file != nil && s.day == day && (s.size == 0 || s.size+n <= s.maxBytes) {
+		return nil
+	}
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return err
+		}
+		s.file = nil
+	}
+	if s.day != day {
+		s.day, s.part = day, 0
+	}
+
+	// Continue after files left by a previous run
+	for {
+		s.part++
+		path := filepath.Join(s.dir, fmt.Sprintf("events-%s-%03d.ndjson", s.day, s.part))
+		info, err := os.Stat(path)
+		if err == nil && info.Size()+n > s.maxBytes && info.Size() > 0 {
+			continue
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		s.file, s.size = f, 0
+		if info != nil {
+			s.size = info.Size()
+		}
+		return nil
+	}
+}
+
+// Close closes the current file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// WebhookSink POSTs batches of events as JSON to an HTTP endpoint.
+// The body is signed with HMAC-SHA256 in the X-Events-Signature header.
+type WebhookSink struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewWebhookSink creates an HTTP webhook sink
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:        url,
+		secret:     secret,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (s *WebhookSink) Name() string { return "webhook" }
+
+func (s *WebhookSink) Write(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(map[string]any{"events": events})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		req.Header.Set("X-Events-Signature", "sha256="+Sign(s.secret, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
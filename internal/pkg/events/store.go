@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrUnknownSink is returned when replaying a sink that never exported
+var ErrUnknownSink = errors.New("unknown event sink")
+
+// ErrExportInProgress is returned when another instance is exporting to the sink
+var ErrExportInProgress = errors.New("event export already running")
+
+// Checkpoint is the last event a sink has received
+type Checkpoint struct {
+	Sink      string    `json:"sink" db:"sink"`
+	LastSeq   int64     `json:"last_seq" db:"last_seq"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Store keeps the append-only events table and sink checkpoints
+type Store struct {
+	db *sqlx.DB
+}
+
+// NewStore creates an event store
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+// Append inserts events in one transaction
+func (s *Store) Append(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO events (id, type, actor_id, entity_id, data, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		if _, err := stmt.ExecContext(ctx, e.ID, e.Type, e.ActorID, e.EntityID, []byte(e.Data), e.OccurredAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListAfter returns events with seq greater than afterSeq, oldest first.
+// Rows younger than lag are skipped so that a transaction committing a lower
+// seq late is not passed over by the checkpoint.
+func (s *Store) ListAfter(ctx context.Context, afterSeq int64, lag time.Duration, limit int) ([]Event, error) {
+	events := []Event{}
+	err := s.db.SelectContext(ctx, &events, `
+		SELECT seq, id, type, actor_id, entity_id, data, occurred_at
+		FROM events
+		WHERE seq > $1 AND created_at < NOW() - make_interval(secs => $2)
+		ORDER BY seq
+		LIMIT $3
+	`, afterSeq, lag.Seconds(), limit)
+	return events, err
+}
+
+// Checkpoint returns the sink checkpoint, registering the sink on first use
+func (s *Store) Checkpoint(ctx context.Context, sink string) (int64, error) {
+	var seq int64
+	err := s.db.GetContext(ctx, &seq, `
+		WITH ins AS (
+			INSERT INTO event_sink_checkpoints (sink, last_seq, updated_at)
+			VALUES ($1, 0, NOW())
+			ON CONFLICT (sink) DO NOTHING
+			RETURNING last_seq
+		)
+		SELECT last_seq FROM ins
+		UNION ALL
+		SELECT last_seq FROM event_sink_checkpoints WHERE sink = $1
+		LIMIT 1
+	`, sink)
+	return seq, err
+}
+
+// SaveCheckpoint records the last event delivered to the sink
+func (s *Store) SaveCheckpoint(ctx context.Context, sink string, seq int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE event_sink_checkpoints SET last_seq = $2, updated_at = NOW() WHERE sink = $1
+	`, sink, seq)
+	return err
+}
+
+// Checkpoints lists all sinks with their position and the newest event seq
+func (s *Store) Checkpoints(ctx context.Context) ([]Checkpoint, int64, error) {
+	checkpoints := []Checkpoint{}
+	if err := s.db.SelectContext(ctx, &checkpoints, `
+		SELECT sink, last_seq, updated_at FROM event_sink_checkpoints ORDER BY sink
+	`); err != nil {
+		return nil, 0, err
+	}
+	var head int64
+	err := s.db.GetContext(ctx, &head, `SELECT COALESCE(MAX(seq), 0) FROM events`)
+	return checkpoints, head, err
+}
+
+// Replay moves the sink checkpoint so events after fromSeq are exported again
+func (s *Store) Replay(ctx context.Context, sink string, fromSeq int64) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE event_sink_checkpoints SET last_seq = $2, updated_at = NOW() WHERE sink = $1
+	`, sink, max(fromSeq, 0))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUnknownSink
+	}
+	return nil
+}
+
+// lock takes the per-sink advisory lock on a dedicated connection. The
+// returned func releases it.
+func (s *Store) lock(ctx context.Context, sink string) (func(), error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock(hashtext('events:' || $1))`, sink); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrExportInProgress
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext('events:' || $1))`, sink); err != nil {
+			log.Warn().Err(err).Str("sink", sink).Msg("Failed to release event export lock")
+		}
+		conn.Close()
+	}, nil
+}
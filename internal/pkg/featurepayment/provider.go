@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/mwork/mwork-api/internal/domain/credit"
+	"github.com/mwork/mwork-api/internal/domain/ledger"
 	"github.com/mwork/mwork-api/internal/domain/wallet"
 )
 
@@ -52,6 +53,49 @@ func (p *RealPaymentProvider) Charge(ctx context.Context, userID uuid.UUID, amou
 	return p.creditSvc.Deduct(ctx, userID, int(amount), meta)
 }
 
+// LedgerDebiter is the part of the ledger service the provider needs
+type LedgerDebiter interface {
+	Debit(ctx context.Context, p ledger.Posting) (*ledger.Transaction, error)
+}
+
+// LedgerPaymentProvider charges features through the unified ledger.
+// The reference ID doubles as the idempotency key, so a retried charge is applied once.
+type LedgerPaymentProvider struct {
+	ledger LedgerDebiter
+	asset  ledger.Asset
+}
+
+// NewLedgerPaymentProvider charges the wallet asset in demo mode and credits otherwise
+func NewLedgerPaymentProvider(mode string, l LedgerDebiter) (*LedgerPaymentProvider, error) {
+	if l == nil {
+		return nil, fmt.Errorf("ledger service is required for ledger payments")
+	}
+	switch mode {
+	case "", ModeReal:
+		return &LedgerPaymentProvider{ledger: l, asset: ledger.AssetCredits}, nil
+	case ModeDemo:
+		return &LedgerPaymentProvider{ledger: l, asset: ledger.AssetWallet}, nil
+	default:
+		return nil, fmt.Errorf("unsupported payment mode: %s", mode)
+	}
+}
+
+func (p *LedgerPaymentProvider) Charge(ctx context.Context, userID uuid.UUID, amount int64, referenceID string) error {
+	if referenceID == "" {
+		return fmt.Errorf("reference id is required")
+	}
+	_, err := p.ledger.Debit(ctx, ledger.Posting{
+		UserID:         userID,
+		Asset:          p.asset,
+		Amount:         amount,
+		Kind:           ledger.KindSpend,
+		IdempotencyKey: "feature:" + referenceID,
+		Reference:      referenceID,
+		Description:    "charged via ledger payment provider",
+	})
+	return err
+}
+
 func NewPaymentProvider(mode string, walletSvc *wallet.Service, creditSvc credit.Service) (PaymentProvider, error) {
 	switch mode {
 	case "", ModeReal:
@@ -0,0 +1,36 @@
+package featurepayment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/mwork/mwork-api/internal/domain/ledger"
+)
+
+type debiterStub struct{ got ledger.Posting }
+
+func (d *debiterStub) Debit(_ context.Context, p ledger.Posting) (*ledger.Transaction, error) {
+	d.got = p
+	return &ledger.Transaction{ID: uuid.New()}, nil
+}
+
+func TestLedgerPaymentProvider_AssetByMode(t *testing.T) {
+	for mode, asset := range map[string]ledger.Asset{ModeReal: ledger.AssetCredits, ModeDemo: ledger.AssetWallet} {
+		stub := &debiterStub{}
+		p, err := NewLedgerPaymentProvider(mode, stub)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := p.Charge(context.Background(), uuid.New(), 3, "promo-123"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stub.got.Asset != asset || stub.got.Amount != 3 || stub.got.IdempotencyKey != "feature:promo-123" {
+			t.Fatalf("mode %s: unexpected posting %+v", mode, stub.got)
+		}
+	}
+	if _, err := NewLedgerPaymentProvider("bogus", &debiterStub{}); err == nil {
+		t.Fatal("expected error for unsupported mode")
+	}
+}
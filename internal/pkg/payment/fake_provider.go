@@ -0,0 +1,88 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ProviderFake is the name of the local fake provider
+const ProviderFake = "fake"
+
+// FakeProvider is an in-memory PaymentProvider for tests and local development.
+// Payments succeed immediately; refunds are recorded and can be forced to fail
+// or to settle asynchronously.
+type FakeProvider struct {
+	mu            sync.Mutex
+	refunds       []RefundRequest
+	RefundErr     error  // returned by Refund when set
+	RefundPending bool   // Refund answers "pending" instead of completing
+	RefundState   string // returned by RefundStatus, "completed" when empty
+}
+
+// NewFakeProvider creates a fake payment provider
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+// CreatePayment returns a local payment URL
+func (p *FakeProvider) CreatePayment(ctx context.Context, req ProviderPaymentRequest) (*ProviderPaymentResponse, error) {
+	return &ProviderPaymentResponse{
+		PaymentID:  req.OrderID,
+		PaymentURL: fmt.Sprintf("http://localhost/fake-pay/%s", req.OrderID),
+		InvoiceID:  req.InvoiceID,
+		Status:     "pending",
+	}, nil
+}
+
+// VerifyWebhook accepts every webhook
+func (p *FakeProvider) VerifyWebhook(rawData interface{}, signature string) bool {
+	return true
+}
+
+// ParseWebhook accepts a *WebhookEvent as-is
+func (p *FakeProvider) ParseWebhook(rawData interface{}) (*WebhookEvent, error) {
+	event, ok := rawData.(*WebhookEvent)
+	if !ok {
+		return nil, fmt.Errorf("invalid webhook data type for fake provider")
+	}
+	return event, nil
+}
+
+// Refund records the refund and completes it immediately unless RefundPending is set
+func (p *FakeProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.RefundErr != nil {
+		return nil, p.RefundErr
+	}
+	p.refunds = append(p.refunds, req)
+	status := "completed"
+	if p.RefundPending {
+		status = "pending"
+	}
+	return &RefundResponse{RefundID: fmt.Sprintf("fake-refund-%d", len(p.refunds)), Status: status}, nil
+}
+
+// RefundStatus returns RefundState
+func (p *FakeProvider) RefundStatus(ctx context.Context, refundID string) (*RefundResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := p.RefundState
+	if status == "" {
+		status = "completed"
+	}
+	return &RefundResponse{RefundID: refundID, Status: status}, nil
+}
+
+// Refunds returns the refunds recorded so far
+func (p *FakeProvider) Refunds() []RefundRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]RefundRequest(nil), p.refunds...)
+}
+
+// Name returns provider identifier
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
@@ -2,6 +2,7 @@ package payment
 
 import (
 	"context"
+	"errors"
 	"fmt"
 )
 
@@ -11,6 +12,10 @@ const (
 	ProviderKaspi     = "kaspi"
 )
 
+// ErrRefundRejected marks refunds the provider certainly did not accept. Other
+// Refund errors leave the outcome unknown: the refund may still go through.
+var ErrRefundRejected = errors.New("refund rejected by provider")
+
 // PaymentProvider defines the interface that all payment providers must implement
 // This abstraction allows switching between Kaspi, RoboKassa, and future providers
 type PaymentProvider interface {
@@ -23,6 +28,12 @@ type PaymentProvider interface {
 	// ParseWebhook parses provider-specific webhook data into standardized format
 	ParseWebhook(rawData interface{}) (*WebhookEvent, error)
 
+	// Refund returns all or part of a completed payment to the payer
+	Refund(ctx context.Context, req RefundRequest) (*RefundResponse, error)
+
+	// RefundStatus returns the settlement state of a refund by the provider's refund ID
+	RefundStatus(ctx context.Context, refundID string) (*RefundResponse, error)
+
 	// Name returns the provider identifier (e.g., "robokassa", "kaspi")
 	Name() string
 }
@@ -47,6 +58,21 @@ type ProviderPaymentResponse struct {
 	Status     string // Initial payment status
 }
 
+// RefundRequest is a standardized refund request
+type RefundRequest struct {
+	PaymentID  string  // Internal payment ID
+	InvoiceID  int64   // Invoice ID of the original payment (for RoboKassa)
+	ExternalID string  // Provider's operation identifier of the original payment
+	Amount     float64 // Amount to refund
+	Reason     string  // Reason shown in provider reports, if supported
+}
+
+// RefundResponse is a standardized refund response
+type RefundResponse struct {
+	RefundID string // Provider's refund identifier
+	Status   string // "completed", "pending" while the provider settles it, or "failed"
+}
+
 // WebhookEvent is a standardized webhook event across all providers
 type WebhookEvent struct {
 	Provider   string  // Provider name ("robokassa", "kaspi")
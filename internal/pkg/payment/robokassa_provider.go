@@ -3,6 +3,7 @@ package payment
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -120,6 +121,38 @@ func (p *RoboKassaProvider) ParseWebhook(rawData interface{}) (*WebhookEvent, er
 	}, nil
 }
 
+// Refund calls the RoboKassa refund API. ExternalID must hold the operation key (OpKey).
+func (p *RoboKassaProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResponse, error) {
+	resp, err := p.client.Refund(ctx, robokassa.RefundRequest{
+		OpKey:  req.ExternalID,
+		Amount: req.Amount,
+	})
+	if errors.Is(err, robokassa.ErrRefundRejected) {
+		return nil, fmt.Errorf("%w: %v", ErrRefundRejected, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("robokassa refund failed: %w", err)
+	}
+	// RoboKassa settles refunds asynchronously
+	return &RefundResponse{RefundID: resp.RequestID, Status: "pending"}, nil
+}
+
+// RefundStatus maps the RoboKassa refund state label
+func (p *RoboKassaProvider) RefundStatus(ctx context.Context, refundID string) (*RefundResponse, error) {
+	state, err := p.client.RefundState(ctx, refundID)
+	if err != nil {
+		return nil, fmt.Errorf("robokassa refund state failed: %w", err)
+	}
+	status := "pending"
+	switch state.Label {
+	case robokassa.RefundStateFinished:
+		status = "completed"
+	case robokassa.RefundStateCanceled:
+		status = "failed"
+	}
+	return &RefundResponse{RefundID: refundID, Status: status}, nil
+}
+
 // Name returns provider identifier
 func (p *RoboKassaProvider) Name() string {
 	return "robokassa"
@@ -0,0 +1,199 @@
+// Package pdf is a minimal PDF 1.4 writer for generated documents such as invoices.
+//
+// It draws text with the standard Helvetica fonts and lines on A4 pages. Non-ASCII
+// characters (Cyrillic, Kazakh) are mapped to a per-document custom encoding using
+// Adobe glyph names, so viewers render them from their substitute fonts.
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font selects one of the built-in fonts
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+// ErrTooManyGlyphs is returned when a document uses more distinct non-ASCII
+// characters than a single-byte encoding can hold.
+var ErrTooManyGlyphs = errors.New("pdf: too many distinct non-ASCII characters")
+
+const firstCustomCode = 128
+
+// Document is a PDF under construction
+type Document struct {
+	title string
+	pages []*Page
+	codes map[rune]byte
+	runes []rune
+	err   error
+}
+
+// Page is a single page of a document
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+// New creates an empty document
+func New() *Document {
+	return &Document{codes: map[rune]byte{}}
+}
+
+// SetTitle sets the document title shown by viewers
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// AddPage appends a blank A4 page
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Text draws s with its baseline starting at (x, y), measured from the bottom-left corner
+func (p *Page) Text(x, y, size float64, font Font, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n", font+1, num(size), num(x), num(y), p.doc.encode(s))
+}
+
+// Line draws a straight line of the given width
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// encode maps s to the document encoding and escapes it for a literal string
+func (d *Document) encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		var c byte
+		switch {
+		case r >= 32 && r < 127:
+			c = byte(r)
+		case r == '\n' || r == '\t':
+			c = ' '
+		default:
+			code, ok := d.codes[r]
+			if !ok {
+				if len(d.runes) >= 256-firstCustomCode {
+					d.err = ErrTooManyGlyphs
+					c = '?'
+					break
+				}
+				code = byte(firstCustomCode + len(d.runes))
+				d.codes[r] = code
+				d.runes = append(d.runes, r)
+			}
+			c = code
+		}
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= firstCustomCode:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Bytes serializes the document
+func (d *Document) Bytes() ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 page tree, 3 encoding, 4-5 fonts, 6 info, then page/content pairs
+	const firstPageObj = 7
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObj+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj(d.encodingDict())
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding 3 0 R >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding 3 0 R >>")
+	obj(fmt.Sprintf("<< /Producer (mwork) /Title <%s> >>", utf16Hex(d.title)))
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), firstPageObj+2*i+1))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes(), nil
+}
+
+func (d *Document) encodingDict() string {
+	if len(d.runes) == 0 {
+		return "<< /Type /Encoding /BaseEncoding /WinAnsiEncoding >>"
+	}
+	names := make([]string, len(d.runes))
+	for i, r := range d.runes {
+		names[i] = glyphName(r)
+	}
+	return fmt.Sprintf("<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [%d %s] >>", firstCustomCode, strings.Join(names, " "))
+}
+
+// glyphName returns the Adobe Glyph List name for r
+func glyphName(r rune) string {
+	if r > 0xFFFF {
+		return fmt.Sprintf("/u%X", r)
+	}
+	return fmt.Sprintf("/uni%04X", r)
+}
+
+func utf16Hex(s string) string {
+	var b strings.Builder
+	b.WriteString("FEFF")
+	for _, r := range s {
+		if r > 0xFFFF {
+			r -= 0x10000
+			fmt.Fprintf(&b, "%04X%04X", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+			continue
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDocument_BytesStructure(t *testing.T) {
+	doc := New()
+	doc.SetTitle("Счет")
+	page := doc.AddPage()
+	page.Text(40, 800, 14, Bold, "Счет на оплату (INV-1)")
+	page.Line(40, 790, 555, 790, 0.5)
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	// startxref must point at the xref table
+	s := string(out)
+	idx := strings.LastIndex(s, "startxref\n")
+	rest := strings.SplitN(s[idx+len("startxref\n"):], "\n", 2)[0]
+	off, err := strconv.Atoi(rest)
+	if err != nil || !strings.HasPrefix(s[off:], "xref\n") {
+		t.Fatalf("startxref %q does not point at xref", rest)
+	}
+	if !strings.Contains(s, "/Differences [128 /uni0421 /uni0447 /uni0435 /uni0442") {
+		t.Fatal("cyrillic glyphs are not mapped in the encoding")
+	}
+	if !strings.Contains(s, `\(INV-1\)`) {
+		t.Fatal("parentheses must be escaped")
+	}
+}
+
+func TestDocument_TooManyGlyphs(t *testing.T) {
+	doc := New()
+	page := doc.AddPage()
+	var b strings.Builder
+	for r := rune(0x4E00); r < 0x4E00+200; r++ {
+		b.WriteRune(r)
+	}
+	page.Text(0, 0, 10, Regular, b.String())
+	if _, err := doc.Bytes(); err != ErrTooManyGlyphs {
+		t.Fatalf("expected ErrTooManyGlyphs, got %v", err)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 )
 
+// ErrUnregisteredToken is returned when FCM no longer knows the device token
+var ErrUnregisteredToken = errors.New("push token is not registered")
+
 // FCMConfig holds Firebase Cloud Messaging configuration
 type FCMConfig struct {
 	ServerKey string
@@ -153,6 +157,9 @@ func (c *FCMClient) Send(ctx context.Context, msg *PushMessage) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: FCM returned status %d", ErrUnregisteredToken, resp.StatusCode)
+	}
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("FCM returned status %d", resp.StatusCode)
 	}
@@ -11,13 +11,18 @@ import (
 
 // Config holds RoboKassa configuration
 type Config struct {
-	MerchantLogin string        // Merchant login (MrchLogin)
-	Password1     string        // Password #1 for payment initialization
-	Password2     string        // Password #2 for webhook verification (ResultURL)
-	TestMode      bool          // Test mode flag
-	HashAlgo      HashAlgorithm // Hash algorithm: SHA256/MD5 (configured in merchant cabinet)
-	BaseURL       string        // Payment form URL (e.g. https://auth.robokassa.kz/Merchant/Index.aspx)
-	Timeout       time.Duration
+	MerchantLogin  string        // Merchant login (MrchLogin)
+	Password1      string        // Password #1 for payment initialization
+	Password2      string        // Password #2 for webhook verification (ResultURL)
+	TestMode       bool          // Test mode flag
+	HashAlgo       HashAlgorithm // Hash algorithm: SHA256/MD5 (configured in merchant cabinet)
+	BaseURL        string        // Payment form URL (e.g. https://auth.robokassa.kz/Merchant/Index.aspx)
+	Password3      string        // Password #3 for the refund API
+	RefundURL      string        // Refund API URL (defaults to DefaultRefundURL)
+	RefundStateURL string        // Refund state API URL (defaults to DefaultRefundStateURL)
+	OpStateURL     string        // Operation state API URL (defaults to DefaultOpStateURL)
+	RecurringURL   string        // Recurring charge URL (defaults to DefaultRecurringURL)
+	Timeout        time.Duration
 }
 
 // Client represents RoboKassa payment gateway client
@@ -0,0 +1,144 @@
+package robokassa
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultOpStateURL is the Robokassa operation state (OpStateExt) endpoint
+const DefaultOpStateURL = "https://auth.robokassa.ru/Merchant/WebService/Service.asmx/OpStateExt"
+
+// OpState result codes
+const (
+	OpResultOK               = 0
+	OpResultInvalidSignature = 1
+	OpResultShopNotFound     = 2
+	OpResultNotFound         = 3 // no operation for the InvoiceID: the user never paid
+	OpResultInternalError    = 1000
+)
+
+// OpState operation state codes
+const (
+	OpStateInitiated = 5   // invoice created, no money received
+	OpStateCancelled = 10  // cancelled, no money received
+	OpStateHeld      = 20  // funds on hold
+	OpStateRefunded  = 60  // money returned to the payer after hold
+	OpStateSuspended = 80  // processing suspended by Robokassa
+	OpStatePaid      = 100 // payment completed
+)
+
+// OpStateResponse is the parsed OpStateExt answer
+type OpStateResponse struct {
+	ResultCode  int
+	Description string
+	StateCode   int
+	StateDate   time.Time
+	OutSum      string
+	OpKey       string // operation key, required for refunds
+}
+
+// IsPaid reports whether Robokassa received the money
+func (r *OpStateResponse) IsPaid() bool {
+	return r.ResultCode == OpResultOK && r.StateCode == OpStatePaid
+}
+
+// IsFailed reports whether the operation finished without a payment
+func (r *OpStateResponse) IsFailed() bool {
+	return r.ResultCode == OpResultOK && (r.StateCode == OpStateCancelled || r.StateCode == OpStateRefunded)
+}
+
+// NotFound reports whether Robokassa has no operation for the invoice
+func (r *OpStateResponse) NotFound() bool {
+	return r.ResultCode == OpResultNotFound
+}
+
+type opStateXML struct {
+	Result struct {
+		Code        int    `xml:"Code"`
+		Description string `xml:"Description"`
+	} `xml:"Result"`
+	State struct {
+		Code      int    `xml:"Code"`
+		StateDate string `xml:"StateDate"`
+	} `xml:"State"`
+	Info struct {
+		OutSum string `xml:"OutSum"`
+		OpKey  string `xml:"OpKey"`
+	} `xml:"Info"`
+}
+
+// OpState queries the state of an operation by InvID.
+// Signature: Hash(MerchantLogin:InvoiceID:Password2).
+func (c *Client) OpState(ctx context.Context, invID int64) (*OpStateResponse, error) {
+	if invID <= 0 {
+		return nil, fmt.Errorf("validation error: invoice ID must be > 0")
+	}
+	if strings.TrimSpace(c.config.MerchantLogin) == "" || strings.TrimSpace(c.config.Password2) == "" {
+		return nil, fmt.Errorf("robokassa config error: merchant_login and password2 are required")
+	}
+	algo := c.config.HashAlgo
+	if algo == "" {
+		algo = HashSHA256
+	}
+	invoice := strconv.FormatInt(invID, 10)
+	signature, err := Sign(strings.Join([]string{c.config.MerchantLogin, invoice, c.config.Password2}, ":"), algo)
+	if err != nil {
+		return nil, fmt.Errorf("robokassa: failed to sign op state request: %w", err)
+	}
+
+	stateURL := strings.TrimSpace(c.config.OpStateURL)
+	if stateURL == "" {
+		stateURL = DefaultOpStateURL
+	}
+	params := url.Values{}
+	params.Set("MerchantLogin", c.config.MerchantLogin)
+	params.Set("InvoiceID", invoice)
+	params.Set("Signature", signature)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, stateURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := c.config.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("robokassa op state request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("robokassa op state: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var parsed opStateXML
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("robokassa op state: invalid response: %w", err)
+	}
+
+	out := &OpStateResponse{
+		ResultCode:  parsed.Result.Code,
+		Description: strings.TrimSpace(parsed.Result.Description),
+		StateCode:   parsed.State.Code,
+		OutSum:      strings.TrimSpace(parsed.Info.OutSum),
+		OpKey:       strings.TrimSpace(parsed.Info.OpKey),
+	}
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(parsed.State.StateDate)); err == nil {
+		out.StateDate = t
+	}
+	switch out.ResultCode {
+	case OpResultOK, OpResultNotFound:
+		return out, nil
+	default:
+		return out, fmt.Errorf("robokassa op state error %d: %s", out.ResultCode, out.Description)
+	}
+}
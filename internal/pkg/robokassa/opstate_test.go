@@ -0,0 +1,49 @@
+package robokassa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpState_ParsesPaidOperation(t *testing.T) {
+	var query map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		query = map[string]string{"login": q.Get("MerchantLogin"), "inv": q.Get("InvoiceID"), "sig": q.Get("Signature")}
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<OperationStateResponse xmlns="http://merchant.roboxchange.com/WebService/">
+  <Result><Code>0</Code></Result>
+  <State><Code>100</Code><RequestDate>2026-10-18T02:00:00+03:00</RequestDate><StateDate>2026-10-17T21:15:00+03:00</StateDate></State>
+  <Info><IncCurrLabel>BankCard</IncCurrLabel><OutSum>990.00</OutSum><OpKey>op-123</OpKey></Info>
+</OperationStateResponse>`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{MerchantLogin: "shop", Password2: "p2", HashAlgo: HashMD5, OpStateURL: srv.URL})
+	state, err := client.OpState(context.Background(), 1001)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !state.IsPaid() || state.OutSum != "990.00" || state.OpKey != "op-123" || state.StateDate.IsZero() {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	expected, _ := Sign("shop:1001:p2", HashMD5)
+	if query["login"] != "shop" || query["inv"] != "1001" || query["sig"] != expected {
+		t.Fatalf("unexpected request: %v", query)
+	}
+}
+
+func TestOpState_NotFoundIsNotAnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<OperationStateResponse><Result><Code>3</Code><Description>not found</Description></Result></OperationStateResponse>`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{MerchantLogin: "shop", Password2: "p2", OpStateURL: srv.URL})
+	state, err := client.OpState(context.Background(), 7)
+	if err != nil || !state.NotFound() || state.IsPaid() {
+		t.Fatalf("expected not found state, got %+v, %v", state, err)
+	}
+}
@@ -0,0 +1,70 @@
+package robokassa
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+)
+
+// Receipt is the fiscal receipt (54-FZ) passed with a payment request.
+// Robokassa forwards it to the online cash register bound to the shop.
+type Receipt struct {
+	Sno   string        `json:"sno,omitempty"` // taxation system: osn, usn_income, ...
+	Items []ReceiptItem `json:"items"`
+}
+
+// ReceiptItem is a single fiscal line
+type ReceiptItem struct {
+	Name          string  `json:"name"`
+	Quantity      float64 `json:"quantity"`
+	Sum           float64 `json:"sum"` // total for the line, not unit price
+	PaymentMethod string  `json:"payment_method,omitempty"`
+	PaymentObject string  `json:"payment_object,omitempty"`
+	Tax           string  `json:"tax"` // none, vat0, vat12, vat20, ...
+}
+
+// Receipt item defaults for digital services paid in full upfront
+const (
+	PaymentMethodFullPayment = "full_payment"
+	PaymentObjectService     = "service"
+	TaxNone                  = "none"
+	maxReceiptItemNameLength = 128
+)
+
+// Encode validates the receipt against outSum and returns its JSON form
+func (r Receipt) Encode(outSum float64) (string, error) {
+	if len(r.Items) == 0 {
+		return "", fmt.Errorf("robokassa: receipt has no items")
+	}
+	r.Items = append([]ReceiptItem(nil), r.Items...)
+	var total float64
+	for i := range r.Items {
+		item := &r.Items[i]
+		if item.Name == "" || item.Quantity <= 0 || item.Sum < 0 {
+			return "", fmt.Errorf("robokassa: invalid receipt item %d", i)
+		}
+		if runes := []rune(item.Name); len(runes) > maxReceiptItemNameLength {
+			item.Name = string(runes[:maxReceiptItemNameLength])
+		}
+		if item.Tax == "" {
+			item.Tax = TaxNone
+		}
+		total += item.Sum
+	}
+	if math.Abs(total-outSum) >= 0.005 {
+		return "", fmt.Errorf("robokassa: receipt total %.2f does not match OutSum %.2f", total, outSum)
+	}
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("robokassa: failed to encode receipt: %w", err)
+	}
+	return string(raw), nil
+}
+
+// ReceiptParam returns the value of the Receipt query parameter. Robokassa expects the
+// JSON URL-encoded once in the signature and twice in a GET payment link, so the
+// result is encoded once more by url.Values.Encode.
+func ReceiptParam(receipt string) string {
+	return url.QueryEscape(receipt)
+}
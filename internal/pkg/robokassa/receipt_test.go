@@ -0,0 +1,26 @@
+package robokassa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReceipt_Encode(t *testing.T) {
+	r := Receipt{Sno: "usn_income", Items: []ReceiptItem{
+		{Name: "Подписка Pro", Quantity: 1, Sum: 4990, PaymentMethod: PaymentMethodFullPayment, PaymentObject: PaymentObjectService},
+	}}
+	raw, err := r.Encode(4990)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(raw, `"tax":"none"`) || !strings.Contains(raw, `"sno":"usn_income"`) {
+		t.Fatalf("unexpected receipt json: %s", raw)
+	}
+
+	if _, err := r.Encode(5000); err == nil {
+		t.Fatal("expected total mismatch error")
+	}
+	if _, err := (Receipt{}).Encode(0); err == nil {
+		t.Fatal("expected error for empty receipt")
+	}
+}
@@ -0,0 +1,100 @@
+package robokassa
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRecurringURL is the Robokassa endpoint for charging a saved card
+const DefaultRecurringURL = "https://auth.robokassa.ru/Merchant/Recurring"
+
+// RecurringRequest charges the card saved by a parent payment made with Recurring=true.
+// The result arrives on ResultURL like a regular payment.
+type RecurringRequest struct {
+	InvID         int64             // new invoice ID for this charge
+	PreviousInvID int64             // InvID of the parent recurring payment
+	OutSum        string            // amount with 2 decimals
+	Description   string            // optional
+	Receipt       string            // optional fiscal receipt JSON
+	Shp           map[string]string // optional Shp_* params, returned in the result callback
+}
+
+// Recurring submits a child recurring payment.
+// Signature: Hash(MerchantLogin:OutSum:InvoiceID[:Receipt]:Password1[:Shp_params]).
+func (c *Client) Recurring(ctx context.Context, req RecurringRequest) error {
+	if req.InvID <= 0 || req.PreviousInvID <= 0 {
+		return fmt.Errorf("validation error: invoice IDs must be > 0")
+	}
+	if strings.TrimSpace(req.OutSum) == "" {
+		return fmt.Errorf("validation error: amount is required")
+	}
+	if strings.TrimSpace(c.config.MerchantLogin) == "" || strings.TrimSpace(c.config.Password1) == "" {
+		return fmt.Errorf("robokassa config error: merchant_login and password1 are required")
+	}
+	algo := c.config.HashAlgo
+	if algo == "" {
+		algo = HashSHA256
+	}
+	invoice := strconv.FormatInt(req.InvID, 10)
+	var receipt *string
+	if req.Receipt != "" {
+		receipt = &req.Receipt
+	}
+	signature, err := Sign(BuildStartSignatureBase(c.config.MerchantLogin, req.OutSum, invoice, c.config.Password1, receipt, req.Shp), algo)
+	if err != nil {
+		return fmt.Errorf("robokassa: failed to sign recurring request: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("MerchantLogin", c.config.MerchantLogin)
+	form.Set("InvoiceID", invoice)
+	form.Set("PreviousInvoiceID", strconv.FormatInt(req.PreviousInvID, 10))
+	form.Set("OutSum", req.OutSum)
+	form.Set("SignatureValue", signature)
+	if req.Description != "" {
+		form.Set("Description", req.Description)
+	}
+	if req.Receipt != "" {
+		form.Set("Receipt", ReceiptParam(req.Receipt))
+	}
+	for k, v := range req.Shp {
+		form.Set(k, v)
+	}
+
+	recurringURL := strings.TrimSpace(c.config.RecurringURL)
+	if recurringURL == "" {
+		recurringURL = DefaultRecurringURL
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, recurringURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	timeout := c.config.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("robokassa recurring request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	answer := strings.TrimSpace(string(body))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("robokassa recurring: unexpected status %d: %s", resp.StatusCode, answer)
+	}
+	// Accepted charges are answered with "OK+<InvoiceID>"
+	if !strings.HasPrefix(answer, "OK") {
+		return fmt.Errorf("robokassa recurring rejected: %s", answer)
+	}
+	return nil
+}
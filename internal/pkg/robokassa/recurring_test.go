@@ -0,0 +1,50 @@
+package robokassa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecurring_SignsChildPayment(t *testing.T) {
+	var form map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = map[string]string{}
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		_, _ = w.Write([]byte("OK+2002"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{MerchantLogin: "shop", Password1: "p1", HashAlgo: HashMD5, RecurringURL: srv.URL})
+	err := client.Recurring(context.Background(), RecurringRequest{
+		InvID:         2002,
+		PreviousInvID: 1001,
+		OutSum:        "990.00",
+		Shp:           map[string]string{"Shp_user": "u1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, _ := Sign("shop:990.00:2002:p1:Shp_user=u1", HashMD5)
+	if form["InvoiceID"] != "2002" || form["PreviousInvoiceID"] != "1001" || form["SignatureValue"] != expected || form["Shp_user"] != "u1" {
+		t.Fatalf("unexpected form: %v", form)
+	}
+}
+
+func TestRecurring_RejectedAnswer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ERROR: card expired"))
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{MerchantLogin: "shop", Password1: "p1", RecurringURL: srv.URL})
+	err := client.Recurring(context.Background(), RecurringRequest{InvID: 2, PreviousInvID: 1, OutSum: "10.00"})
+	if err == nil || !strings.Contains(err.Error(), "card expired") {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+}
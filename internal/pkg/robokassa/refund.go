@@ -0,0 +1,160 @@
+package robokassa
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultRefundURL is the Robokassa refund API endpoint
+const DefaultRefundURL = "https://services.robokassa.ru/RefundService/Refund/Create"
+
+// DefaultRefundStateURL is the Robokassa refund state endpoint
+const DefaultRefundStateURL = "https://services.robokassa.ru/RefundService/Refund/GetState"
+
+// Refund state labels
+const (
+	RefundStateFinished   = "finished"
+	RefundStateProcessing = "processing"
+	RefundStateCanceled   = "canceled"
+)
+
+// ErrRefundRejected marks refunds Robokassa certainly did not accept;
+// other errors leave the outcome unknown
+var ErrRefundRejected = errors.New("robokassa refund rejected")
+
+// RefundRequest represents a refund of a completed operation
+type RefundRequest struct {
+	OpKey  string  // Operation key of the original payment
+	Amount float64 // Refund amount; must not exceed the paid amount
+}
+
+// RefundResponse represents the refund API answer
+type RefundResponse struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId"`
+}
+
+// Refund creates a refund. The API takes a JWT (HS256) signed with Password #3.
+func (c *Client) Refund(ctx context.Context, req RefundRequest) (*RefundResponse, error) {
+	if strings.TrimSpace(req.OpKey) == "" {
+		return nil, fmt.Errorf("%w: operation key is required", ErrRefundRejected)
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: refund amount must be > 0", ErrRefundRejected)
+	}
+	if strings.TrimSpace(c.config.Password3) == "" {
+		return nil, fmt.Errorf("%w: password3 is empty", ErrRefundRejected)
+	}
+
+	token, err := signRefundJWT(map[string]interface{}{
+		"OpKey":     req.OpKey,
+		"RefundSum": req.Amount,
+	}, c.config.Password3)
+	if err != nil {
+		return nil, err
+	}
+
+	refundURL := strings.TrimSpace(c.config.RefundURL)
+	if refundURL == "" {
+		refundURL = DefaultRefundURL
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, refundURL, bytes.NewBufferString(token))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	timeout := c.config.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("robokassa refund request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("robokassa refund: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var out RefundResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("robokassa refund: invalid response: %w", err)
+	}
+	if !out.Success {
+		return &out, fmt.Errorf("%w: %s", ErrRefundRejected, out.Message)
+	}
+	return &out, nil
+}
+
+// RefundStateResponse is the settlement state of a refund request
+type RefundStateResponse struct {
+	RequestID string  `json:"requestId"`
+	Amount    float64 `json:"amount"`
+	Label     string  `json:"label"` // finished, processing or canceled
+}
+
+// RefundState returns the state of a refund created by Refund
+func (c *Client) RefundState(ctx context.Context, requestID string) (*RefundStateResponse, error) {
+	if strings.TrimSpace(requestID) == "" {
+		return nil, fmt.Errorf("validation error: refund request ID is required")
+	}
+	stateURL := strings.TrimSpace(c.config.RefundStateURL)
+	if stateURL == "" {
+		stateURL = DefaultRefundStateURL
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, stateURL+"?id="+url.QueryEscape(requestID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := c.config.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("robokassa refund state request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("robokassa refund state: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var out RefundStateResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("robokassa refund state: invalid response: %w", err)
+	}
+	out.Label = strings.ToLower(strings.TrimSpace(out.Label))
+	return &out, nil
+}
+
+func signRefundJWT(claims map[string]interface{}, secret string) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + enc.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,84 @@
+package robokassa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRefund_SendsSignedJWT(t *testing.T) {
+	var token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		token = string(body)
+		_ = json.NewEncoder(w).Encode(RefundResponse{Success: true, RequestID: "req-1"})
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{Password3: "p3", RefundURL: srv.URL})
+	resp, err := client.Refund(context.Background(), RefundRequest{OpKey: "op", Amount: 150})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.RequestID != "req-1" {
+		t.Fatalf("unexpected request id %q", resp.RequestID)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected JWT, got %q", token)
+	}
+	expected, _ := signRefundJWT(map[string]interface{}{"OpKey": "op", "RefundSum": 150.0}, "p3")
+	if token != expected {
+		t.Fatalf("signature mismatch:\n got %s\nwant %s", token, expected)
+	}
+}
+
+func TestRefund_Rejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(RefundResponse{Success: false, Message: "not enough funds"})
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{Password3: "p3", RefundURL: srv.URL})
+	if _, err := client.Refund(context.Background(), RefundRequest{OpKey: "op", Amount: 1}); !errors.Is(err, ErrRefundRejected) {
+		t.Fatalf("expected ErrRefundRejected, got %v", err)
+	}
+}
+
+func TestRefund_GatewayErrorIsNotRejection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{Password3: "p3", RefundURL: srv.URL})
+	_, err := client.Refund(context.Background(), RefundRequest{OpKey: "op", Amount: 1})
+	if err == nil || errors.Is(err, ErrRefundRejected) {
+		t.Fatalf("a gateway error must leave the outcome unknown, got %v", err)
+	}
+}
+
+func TestRefundState(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "req-1" {
+			t.Errorf("unexpected id %q", r.URL.Query().Get("id"))
+		}
+		_, _ = w.Write([]byte(`{"requestId":"req-1","amount":150,"label":"finished"}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{RefundStateURL: srv.URL})
+	state, err := client.RefundState(context.Background(), "req-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Label != RefundStateFinished || state.Amount != 150 {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+}
@@ -0,0 +1,35 @@
+// Package sponsored places sponsored items into list results.
+package sponsored
+
+// Inject spreads sponsored items evenly through a page of organic results,
+// the first one on top. Organic copies of sponsored items are dropped so an
+// item never shows twice on a page. An empty page stays empty.
+func Inject[T any, K comparable](organic, sponsored []T, key func(T) K) []T {
+	if len(organic) == 0 || len(sponsored) == 0 {
+		return organic
+	}
+
+	isSponsored := make(map[K]bool, len(sponsored))
+	for _, s := range sponsored {
+		isSponsored[key(s)] = true
+	}
+	rest := make([]T, 0, len(organic))
+	for _, o := range organic {
+		if !isSponsored[key(o)] {
+			rest = append(rest, o)
+		}
+	}
+
+	total := len(rest) + len(sponsored)
+	out := make([]T, 0, total)
+	next := 0
+	for i := 0; i < total; i++ {
+		if next < len(sponsored) && i == next*total/len(sponsored) {
+			out = append(out, sponsored[next])
+			next++
+			continue
+		}
+		out = append(out, rest[i-next])
+	}
+	return out
+}
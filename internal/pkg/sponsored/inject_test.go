@@ -0,0 +1,27 @@
+package sponsored
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInjectSpreadsSlotsAndDropsDuplicates(t *testing.T) {
+	id := func(s string) string { return s }
+	organic := []string{"a", "b", "c", "d", "e", "f"}
+
+	got := Inject(organic, []string{"X", "d"}, id)
+	want := []string{"X", "a", "b", "d", "c", "e", "f"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	got = Inject(organic, []string{"X", "Y"}, id)
+	want = []string{"X", "a", "b", "c", "Y", "d", "e", "f"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if got := Inject(nil, []string{"X"}, id); len(got) != 0 {
+		t.Fatalf("expected empty page to stay empty, got %v", got)
+	}
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_notifications_user_type_room;
+DROP TABLE IF EXISTS notification_deferred_deliveries;
+
+ALTER TABLE user_notification_preferences
+    DROP COLUMN IF EXISTS quiet_hours_timezone;
@@ -0,0 +1,29 @@
+-- Quiet hours timezone and deferred push/email deliveries
+ALTER TABLE user_notification_preferences
+    ADD COLUMN IF NOT EXISTS quiet_hours_timezone VARCHAR(64) DEFAULT 'Asia/Almaty';
+
+-- Push/email deliveries held back by quiet hours, flushed by the notification dispatcher
+CREATE TABLE IF NOT EXISTS notification_deferred_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    notification_id UUID REFERENCES notifications(id) ON DELETE SET NULL,
+
+    type VARCHAR(50) NOT NULL,
+    channel VARCHAR(10) NOT NULL, -- 'email', 'push'
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+
+    deliver_after TIMESTAMPTZ NOT NULL,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT chk_notification_deferred_channel CHECK (channel IN ('email', 'push'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deferred_due
+    ON notification_deferred_deliveries(deliver_after)
+    WHERE delivered_at IS NULL;
+
+-- Coalescing looks up the latest unread notification per chat room
+CREATE INDEX IF NOT EXISTS idx_notifications_user_type_room
+    ON notifications(user_id, type, ((data->>'room_id')), created_at DESC)
+    WHERE is_read = false;
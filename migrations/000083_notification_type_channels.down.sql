@@ -0,0 +1,18 @@
+ALTER TABLE user_notification_preferences
+    ADD COLUMN IF NOT EXISTS new_response_channels JSONB DEFAULT '{"in_app": true, "email": true, "push": true}'::jsonb,
+    ADD COLUMN IF NOT EXISTS response_accepted_channels JSONB DEFAULT '{"in_app": true, "email": true, "push": true}'::jsonb,
+    ADD COLUMN IF NOT EXISTS response_rejected_channels JSONB DEFAULT '{"in_app": true, "email": true, "push": false}'::jsonb,
+    ADD COLUMN IF NOT EXISTS new_message_channels JSONB DEFAULT '{"in_app": true, "email": false, "push": true}'::jsonb,
+    ADD COLUMN IF NOT EXISTS profile_viewed_channels JSONB DEFAULT '{"in_app": true, "email": false, "push": false}'::jsonb,
+    ADD COLUMN IF NOT EXISTS casting_expiring_channels JSONB DEFAULT '{"in_app": true, "email": true, "push": false}'::jsonb;
+
+UPDATE user_notification_preferences
+SET new_response_channels = COALESCE(channels->'new_response', new_response_channels),
+    response_accepted_channels = COALESCE(channels->'response_accepted', response_accepted_channels),
+    response_rejected_channels = COALESCE(channels->'response_rejected', response_rejected_channels),
+    new_message_channels = COALESCE(channels->'new_message', new_message_channels),
+    profile_viewed_channels = COALESCE(channels->'profile_viewed', profile_viewed_channels),
+    casting_expiring_channels = COALESCE(channels->'casting_expiring', casting_expiring_channels);
+
+ALTER TABLE user_notification_preferences
+    DROP COLUMN IF EXISTS channels;
@@ -0,0 +1,24 @@
+-- Generic per-type notification channels: one JSONB map instead of a column per type.
+-- Only user overrides are stored; missing types fall back to role defaults in code.
+ALTER TABLE user_notification_preferences
+    ADD COLUMN IF NOT EXISTS channels JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- Values equal to the old column defaults were never chosen by the user and are
+-- left out, so those users pick up the role defaults.
+UPDATE user_notification_preferences
+SET channels = jsonb_strip_nulls(jsonb_build_object(
+    'new_response', NULLIF(new_response_channels, '{"in_app": true, "email": true, "push": true}'::jsonb),
+    'response_accepted', NULLIF(response_accepted_channels, '{"in_app": true, "email": true, "push": true}'::jsonb),
+    'response_rejected', NULLIF(response_rejected_channels, '{"in_app": true, "email": true, "push": false}'::jsonb),
+    'new_message', NULLIF(new_message_channels, '{"in_app": true, "email": false, "push": true}'::jsonb),
+    'profile_viewed', NULLIF(profile_viewed_channels, '{"in_app": true, "email": false, "push": false}'::jsonb),
+    'casting_expiring', NULLIF(casting_expiring_channels, '{"in_app": true, "email": true, "push": false}'::jsonb)
+));
+
+ALTER TABLE user_notification_preferences
+    DROP COLUMN IF EXISTS new_response_channels,
+    DROP COLUMN IF EXISTS response_accepted_channels,
+    DROP COLUMN IF EXISTS response_rejected_channels,
+    DROP COLUMN IF EXISTS new_message_channels,
+    DROP COLUMN IF EXISTS profile_viewed_channels,
+    DROP COLUMN IF EXISTS casting_expiring_channels;
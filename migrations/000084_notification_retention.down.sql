@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notifications_created_at;
+DROP TABLE IF EXISTS notification_archive;
+DROP TABLE IF EXISTS notification_cleanup_runs;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS notification_cleanup_runs (
+    id UUID PRIMARY KEY,
+    trigger VARCHAR(20) NOT NULL,
+    triggered_by UUID,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    deleted_read BIGINT NOT NULL DEFAULT 0,
+    deleted_unread BIGINT NOT NULL DEFAULT 0,
+    deleted_by_type BIGINT NOT NULL DEFAULT 0,
+    deleted_over_limit BIGINT NOT NULL DEFAULT 0,
+    archived_batches BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_cleanup_runs_started ON notification_cleanup_runs(started_at DESC);
+
+-- Pruned notifications, gzip-compressed JSON per user and run
+CREATE TABLE IF NOT EXISTS notification_archive (
+    id UUID PRIMARY KEY,
+    run_id UUID NOT NULL REFERENCES notification_cleanup_runs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    notification_count INT NOT NULL,
+    oldest_at TIMESTAMPTZ NOT NULL,
+    newest_at TIMESTAMPTZ NOT NULL,
+    payload BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_archive_user ON notification_archive(user_id, newest_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_archive_run ON notification_archive(run_id);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications(created_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS email_events;
+DROP INDEX IF EXISTS idx_users_email_lower;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS email_suppression_reason,
+    DROP COLUMN IF EXISTS email_suppressed_at;
@@ -0,0 +1,20 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_suppressed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS email_suppression_reason VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users(LOWER(email));
+
+-- Delivery events reported by the email provider webhook
+CREATE TABLE IF NOT EXISTS email_events (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    email VARCHAR(255) NOT NULL,
+    event VARCHAR(20) NOT NULL,
+    reason TEXT,
+    provider_event_id VARCHAR(100),
+    occurred_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_events_provider_id ON email_events(provider_event_id) WHERE provider_event_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_email_events_user ON email_events(user_id, occurred_at DESC);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_notifications_user_type_created;
+DROP INDEX IF EXISTS idx_notifications_user_active;
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS action_at,
+    DROP COLUMN IF EXISTS action_taken,
+    DROP COLUMN IF EXISTS archived_at;
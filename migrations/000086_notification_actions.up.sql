@@ -0,0 +1,7 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS action_taken VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS action_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_active ON notifications(user_id, created_at DESC) WHERE archived_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_type_created ON notifications(user_id, type, created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_sku;
+ALTER TABLE payments DROP COLUMN IF EXISTS sku;
+DROP TABLE IF EXISTS products;
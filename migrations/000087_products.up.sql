@@ -0,0 +1,48 @@
+-- Purchasable packages (response packs, credit packs) with prices managed by admins
+CREATE TABLE IF NOT EXISTS products (
+    id UUID PRIMARY KEY,
+    sku VARCHAR(64) NOT NULL UNIQUE,
+    kind VARCHAR(20) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    price NUMERIC(12,2) NOT NULL CHECK (price > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'KZT',
+    audience VARCHAR(20) NOT NULL DEFAULT 'all',
+    active_from TIMESTAMPTZ,
+    active_until TIMESTAMPTZ,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_products_window CHECK (active_until IS NULL OR active_from IS NULL OR active_until > active_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_kind ON products(kind, sort_order) WHERE is_active = TRUE;
+
+-- Packages previously hardcoded in payment.Service
+INSERT INTO products (id, sku, kind, title, quantity, price, sort_order) VALUES
+    (gen_random_uuid(), 'responses_10', 'responses', '10 откликов', 10, 990, 10),
+    (gen_random_uuid(), 'responses_20', 'responses', '20 откликов', 20, 1790, 20),
+    (gen_random_uuid(), 'responses_50', 'responses', '50 откликов', 50, 3990, 30),
+    (gen_random_uuid(), 'credits_5', 'credits', '5 кредитов', 5, 500, 10),
+    (gen_random_uuid(), 'credits_10', 'credits', '10 кредитов', 10, 900, 20),
+    (gen_random_uuid(), 'credits_25', 'credits', '25 кредитов', 25, 2000, 30),
+    (gen_random_uuid(), 'credits_50', 'credits', '50 кредитов', 50, 3500, 40)
+ON CONFLICT (sku) DO NOTHING;
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+-- Backfill SKU on in-flight package payments so fulfilment no longer depends on the amount
+UPDATE payments SET sku = 'responses_' || response_package
+WHERE sku IS NULL AND type = 'responses' AND response_package IN (10, 20, 50);
+
+UPDATE payments SET sku = CASE amount
+        WHEN 500 THEN 'credits_5'
+        WHEN 900 THEN 'credits_10'
+        WHEN 2000 THEN 'credits_25'
+        WHEN 3500 THEN 'credits_50'
+    END
+WHERE sku IS NULL AND subscription_id IS NULL AND status = 'pending'
+    AND COALESCE(type, '') = '' AND amount IN (500, 900, 2000, 3500);
+
+CREATE INDEX IF NOT EXISTS idx_payments_sku ON payments(sku) WHERE sku IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS subscription_history;
+DROP INDEX IF EXISTS idx_subscriptions_active_expires;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS last_reminder_days;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS grace_until;
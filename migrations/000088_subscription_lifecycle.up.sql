@@ -0,0 +1,21 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS grace_until TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS last_reminder_days INT;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_active_expires ON subscriptions(expires_at) WHERE status = 'active';
+
+-- Every subscription status/plan transition, shown to the user and admins
+CREATE TABLE IF NOT EXISTS subscription_history (
+    id UUID PRIMARY KEY,
+    subscription_id UUID REFERENCES subscriptions(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event VARCHAR(30) NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20),
+    from_plan VARCHAR(50),
+    to_plan VARCHAR(50),
+    reason TEXT,
+    actor_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_history_user ON subscription_history(user_id, created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payment_refunds;
+ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
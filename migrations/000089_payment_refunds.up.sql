@@ -0,0 +1,20 @@
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS refunded_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id UUID PRIMARY KEY,
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    admin_id UUID,
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'processing',
+    provider VARCHAR(50),
+    provider_refund_id VARCHAR(255),
+    credits_reversed INT NOT NULL DEFAULT 0,
+    subscription_cancelled BOOLEAN NOT NULL DEFAULT FALSE,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment ON payment_refunds(payment_id, created_at DESC);
@@ -0,0 +1,6 @@
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS original_amount,
+    DROP COLUMN IF EXISTS promo_code_id;
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id UUID PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value NUMERIC(12,2) NOT NULL CHECK (discount_value > 0),
+    applies_to TEXT[] NOT NULL DEFAULT '{}',
+    audience VARCHAR(20) NOT NULL DEFAULT 'all',
+    max_redemptions INT,
+    per_user_limit INT,
+    valid_from TIMESTAMPTZ,
+    valid_until TIMESTAMPTZ,
+    first_purchase_only BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_promo_codes_code ON promo_codes(UPPER(code));
+
+-- A redemption is reserved at checkout and counts towards limits while its
+-- payment is pending (for a limited time) or once the payment succeeds
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id UUID PRIMARY KEY,
+    promo_code_id UUID NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+    payment_id UUID NOT NULL UNIQUE,
+    user_id UUID NOT NULL,
+    target VARCHAR(100) NOT NULL,
+    original_amount NUMERIC(12,2) NOT NULL,
+    discount_amount NUMERIC(12,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    redeemed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code ON promo_redemptions(promo_code_id, status);
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_user ON promo_redemptions(promo_code_id, user_id);
+
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS promo_code_id UUID REFERENCES promo_codes(id),
+    ADD COLUMN IF NOT EXISTS original_amount NUMERIC(12,2),
+    ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(12,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,23 @@
+CREATE SEQUENCE IF NOT EXISTS invoice_number_seq;
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY,
+    payment_id UUID NOT NULL UNIQUE REFERENCES payments(id) ON DELETE RESTRICT,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    number VARCHAR(50) NOT NULL UNIQUE,
+    seller JSONB NOT NULL,
+    buyer JSONB NOT NULL,
+    lines JSONB NOT NULL DEFAULT '[]',
+    subtotal NUMERIC(12,2) NOT NULL,
+    discount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    total NUMERIC(12,2) NOT NULL,
+    vat_rate NUMERIC(5,2) NOT NULL DEFAULT 0,
+    vat_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL DEFAULT 'KZT',
+    file_path TEXT,
+    emailed_at TIMESTAMPTZ,
+    issued_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_user ON invoices(user_id, issued_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_payments_pending_created;
+DROP TABLE IF EXISTS payment_reconciliation_reports;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS payment_reconciliation_reports (
+    id UUID PRIMARY KEY,
+    trigger VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    admin_id UUID,
+    payments_checked INT NOT NULL DEFAULT 0,
+    payments_completed INT NOT NULL DEFAULT 0,
+    payments_failed INT NOT NULL DEFAULT 0,
+    payments_pending INT NOT NULL DEFAULT 0,
+    payments_mismatched INT NOT NULL DEFAULT 0,
+    payments_errored INT NOT NULL DEFAULT 0,
+    credit_mismatch_count INT NOT NULL DEFAULT 0,
+    payments JSONB NOT NULL DEFAULT '[]',
+    credit_mismatches JSONB NOT NULL DEFAULT '[]',
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_reconciliation_reports_started ON payment_reconciliation_reports(started_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_payments_pending_created ON payments(created_at) WHERE status = 'pending';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_payments_pending_renewal;
+DROP INDEX IF EXISTS idx_subscriptions_auto_renew_expires;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS next_renewal_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS renewal_attempts;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS recurring_inv_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS auto_renew;
+ALTER TABLE payments DROP COLUMN IF EXISTS parent_inv_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS recurring;
@@ -0,0 +1,13 @@
+-- Recurring payments save the card; renewal charges reference the parent InvID
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS recurring BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS parent_inv_id BIGINT;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS auto_renew BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS recurring_inv_id BIGINT;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS renewal_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS next_renewal_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_auto_renew_expires ON subscriptions(expires_at)
+    WHERE status = 'active' AND auto_renew;
+CREATE INDEX IF NOT EXISTS idx_payments_pending_renewal ON payments(subscription_id)
+    WHERE type = 'subscription_renewal' AND status = 'pending';
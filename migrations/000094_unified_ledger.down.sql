@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ledger_balance_snapshots;
+DROP TABLE IF EXISTS ledger_holds;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_transactions;
+DROP TABLE IF EXISTS ledger_accounts;
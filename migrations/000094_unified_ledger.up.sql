@@ -0,0 +1,113 @@
+-- Unified double-entry ledger for credits, connects and the demo wallet.
+-- User accounts are balanced by system accounts (issuance, revenue, opening),
+-- so the balances of every asset sum to zero.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id),
+    system_code VARCHAR(32),
+    asset VARCHAR(16) NOT NULL CHECK (asset IN ('credits', 'connects', 'wallet')),
+    balance BIGINT NOT NULL DEFAULT 0,
+    held BIGINT NOT NULL DEFAULT 0 CHECK (held >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((user_id IS NULL) <> (system_code IS NULL))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_user_asset ON ledger_accounts(user_id, asset) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_system_asset ON ledger_accounts(system_code, asset) WHERE system_code IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    idempotency_key VARCHAR(200) NOT NULL UNIQUE,
+    user_id UUID REFERENCES users(id),
+    asset VARCHAR(16) NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    reference VARCHAR(255),
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_user ON ledger_transactions(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES ledger_transactions(id),
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    balance_after BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction ON ledger_entries(transaction_id);
+
+CREATE TABLE IF NOT EXISTS ledger_holds (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    asset VARCHAR(16) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'captured', 'released')),
+    idempotency_key VARCHAR(200) NOT NULL UNIQUE,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    capture_transaction_id UUID REFERENCES ledger_transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_holds_pending_expiry ON ledger_holds(expires_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS ledger_balance_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    balance BIGINT NOT NULL,
+    held BIGINT NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_snapshots_account ON ledger_balance_snapshots(account_id, taken_at DESC);
+
+-- System accounts
+INSERT INTO ledger_accounts (system_code, asset)
+SELECT code, asset
+FROM (VALUES ('issuance'), ('revenue'), ('opening')) AS c(code)
+CROSS JOIN (VALUES ('credits'), ('connects'), ('wallet')) AS a(asset)
+ON CONFLICT (system_code, asset) WHERE system_code IS NOT NULL DO NOTHING;
+
+-- Opening balances from the legacy storage
+INSERT INTO ledger_accounts (user_id, asset, balance)
+SELECT id, 'credits', credit_balance FROM users WHERE credit_balance <> 0
+UNION ALL
+SELECT id, 'connects', model_free_response_connects + model_purchased_response_connects FROM users
+WHERE model_free_response_connects + model_purchased_response_connects <> 0
+UNION ALL
+SELECT user_id, 'wallet', balance FROM user_wallets WHERE balance <> 0
+ON CONFLICT (user_id, asset) WHERE user_id IS NOT NULL DO NOTHING;
+
+INSERT INTO ledger_transactions (idempotency_key, user_id, asset, kind, amount, description)
+SELECT 'opening:' || a.asset || ':' || a.user_id, a.user_id, a.asset, 'opening', ABS(a.balance),
+       'Opening balance migrated from legacy storage'
+FROM ledger_accounts a
+WHERE a.user_id IS NOT NULL AND a.balance <> 0
+ON CONFLICT (idempotency_key) DO NOTHING;
+
+INSERT INTO ledger_entries (transaction_id, account_id, amount, balance_after)
+SELECT t.id, a.id, a.balance, a.balance
+FROM ledger_transactions t
+JOIN ledger_accounts a ON a.user_id = t.user_id AND a.asset = t.asset
+WHERE t.kind = 'opening';
+
+INSERT INTO ledger_entries (transaction_id, account_id, amount, balance_after)
+SELECT t.id, s.id, -e.amount,
+       -SUM(e.amount) OVER (PARTITION BY t.asset ORDER BY e.id)
+FROM ledger_transactions t
+JOIN ledger_entries e ON e.transaction_id = t.id
+JOIN ledger_accounts s ON s.system_code = 'opening' AND s.asset = t.asset
+WHERE t.kind = 'opening';
+
+UPDATE ledger_accounts s
+SET balance = -o.total, updated_at = NOW()
+FROM (
+    SELECT asset, SUM(balance) AS total FROM ledger_accounts WHERE user_id IS NOT NULL GROUP BY asset
+) o
+WHERE s.system_code = 'opening' AND s.asset = o.asset;
+
+INSERT INTO ledger_balance_snapshots (account_id, balance, held)
+SELECT id, balance, held FROM ledger_accounts;
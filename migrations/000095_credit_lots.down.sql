@@ -0,0 +1,6 @@
+ALTER TABLE users DROP COLUMN IF EXISTS credits_low_notified_at;
+DROP TABLE IF EXISTS credit_lots;
+UPDATE credit_transactions SET tx_type = 'deduction' WHERE tx_type = 'expiration';
+ALTER TABLE credit_transactions DROP CONSTRAINT IF EXISTS credit_transactions_tx_type_check;
+ALTER TABLE credit_transactions ADD CONSTRAINT credit_transactions_tx_type_check
+    CHECK (tx_type IN ('deduction', 'refund', 'purchase', 'admin_grant'));
@@ -0,0 +1,25 @@
+-- Lot-based credit tracking: each grant, purchase or refund is a lot that
+-- spending draws down, soonest-expiring first. Admin grants may expire.
+ALTER TABLE credit_transactions DROP CONSTRAINT IF EXISTS credit_transactions_tx_type_check;
+ALTER TABLE credit_transactions ADD CONSTRAINT credit_transactions_tx_type_check
+    CHECK (tx_type IN ('deduction', 'refund', 'purchase', 'admin_grant', 'expiration'));
+
+CREATE TABLE IF NOT EXISTS credit_lots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    source TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    remaining INTEGER NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+    expires_at TIMESTAMPTZ,
+    expiry_notified_at TIMESTAMPTZ,
+    expired_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_credit_lots_user_open ON credit_lots(user_id, expires_at, created_at) WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS idx_credit_lots_expiring ON credit_lots(expires_at) WHERE remaining > 0 AND expires_at IS NOT NULL;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS credits_low_notified_at TIMESTAMPTZ;
+
+-- Existing balances become one non-expiring lot per user
+INSERT INTO credit_lots (user_id, source, amount, remaining)
+SELECT id, 'legacy', credit_balance, credit_balance FROM users WHERE credit_balance > 0;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_casting_promotions_refundable;
+ALTER TABLE casting_promotions DROP COLUMN IF EXISTS refunded_at;
+ALTER TABLE casting_promotions DROP COLUMN IF EXISTS refunded_amount;
+ALTER TABLE casting_promotions DROP COLUMN IF EXISTS prepaid_amount;
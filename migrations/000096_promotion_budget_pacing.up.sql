@@ -0,0 +1,12 @@
+-- Promotion spend engine: impressions and clicks are billed against the
+-- budget; credits prepaid at activation are tracked so the unspent part can
+-- be refunded when the promotion is cancelled or completes.
+ALTER TABLE casting_promotions ADD COLUMN IF NOT EXISTS prepaid_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE casting_promotions ADD COLUMN IF NOT EXISTS refunded_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE casting_promotions ADD COLUMN IF NOT EXISTS refunded_at TIMESTAMP;
+
+-- Running casting promotions were charged their full budget on activation
+UPDATE casting_promotions SET prepaid_amount = budget_amount WHERE status IN ('active', 'paused');
+
+CREATE INDEX IF NOT EXISTS idx_casting_promotions_refundable ON casting_promotions(updated_at)
+    WHERE status IN ('completed', 'cancelled') AND refunded_at IS NULL AND prepaid_amount > 0;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS casting_analytics_city_daily;
+DROP TABLE IF EXISTS casting_analytics_daily;
+ALTER TABLE casting_responses DROP COLUMN IF EXISTS requirement_match;
+DROP TABLE IF EXISTS casting_view_events;
@@ -0,0 +1,44 @@
+-- Employer hiring analytics: raw casting view events, a per-response
+-- requirement match score and daily per-casting aggregates.
+CREATE TABLE IF NOT EXISTS casting_view_events (
+    id BIGSERIAL PRIMARY KEY,
+    casting_id UUID NOT NULL REFERENCES castings(id) ON DELETE CASCADE,
+    viewer_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    viewed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_casting_view_events_casting ON casting_view_events(casting_id, viewed_at);
+CREATE INDEX IF NOT EXISTS idx_casting_view_events_viewed_at ON casting_view_events(viewed_at);
+
+-- Share of the casting's requirements the applicant verifiably meets (0..1)
+ALTER TABLE casting_responses ADD COLUMN IF NOT EXISTS requirement_match REAL;
+
+CREATE TABLE IF NOT EXISTS casting_analytics_daily (
+    casting_id UUID NOT NULL REFERENCES castings(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL,
+    date DATE NOT NULL,
+    views INT NOT NULL DEFAULT 0,
+    unique_viewers INT NOT NULL DEFAULT 0,
+    responses INT NOT NULL DEFAULT 0,
+    viewed INT NOT NULL DEFAULT 0,
+    shortlisted INT NOT NULL DEFAULT 0,
+    accepted INT NOT NULL DEFAULT 0,
+    rejected INT NOT NULL DEFAULT 0,
+    match_score_sum REAL NOT NULL DEFAULT 0,
+    match_scored INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (casting_id, date)
+);
+CREATE INDEX IF NOT EXISTS idx_casting_analytics_daily_creator ON casting_analytics_daily(creator_id, date);
+
+CREATE TABLE IF NOT EXISTS casting_analytics_city_daily (
+    casting_id UUID NOT NULL REFERENCES castings(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL,
+    date DATE NOT NULL,
+    city VARCHAR(100) NOT NULL,
+    views INT NOT NULL DEFAULT 0,
+    responses INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (casting_id, date, city)
+);
+CREATE INDEX IF NOT EXISTS idx_casting_analytics_city_creator ON casting_analytics_city_daily(creator_id, date);
+
+COMMENT ON TABLE casting_analytics_daily IS 'Daily hiring funnel per casting; response counts are by response creation date and current status';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS profile_search_appearances;
+DROP TABLE IF EXISTS profile_view_events;
@@ -0,0 +1,24 @@
+-- Model profile viewer insights: one view event per viewer per profile per day
+-- and daily counts of search result appearances.
+CREATE TABLE IF NOT EXISTS profile_view_events (
+    id BIGSERIAL PRIMARY KEY,
+    profile_id UUID NOT NULL REFERENCES model_profiles(id) ON DELETE CASCADE,
+    viewer_key VARCHAR(64) NOT NULL, -- user ID or anonymous fingerprint, for deduplication
+    viewer_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    viewer_role VARCHAR(20),
+    organization_id UUID REFERENCES organizations(id) ON DELETE SET NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'direct',
+    view_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    viewed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (profile_id, viewer_key, view_date)
+);
+CREATE INDEX IF NOT EXISTS idx_profile_view_events_profile ON profile_view_events(profile_id, viewed_at DESC);
+
+CREATE TABLE IF NOT EXISTS profile_search_appearances (
+    profile_id UUID NOT NULL REFERENCES model_profiles(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    appearances INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (profile_id, date)
+);
+
+COMMENT ON COLUMN profile_view_events.source IS 'search, casting, promotion or direct';
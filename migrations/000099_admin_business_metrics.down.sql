@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS admin_analytics_refreshes;
+DROP MATERIALIZED VIEW IF EXISTS mv_admin_casting_liquidity;
+DROP MATERIALIZED VIEW IF EXISTS mv_admin_revenue_monthly;
+DROP MATERIALIZED VIEW IF EXISTS mv_admin_subscription_monthly;
+DROP MATERIALIZED VIEW IF EXISTS mv_admin_cohort_retention;
+DROP MATERIALIZED VIEW IF EXISTS mv_admin_registration_cohorts;
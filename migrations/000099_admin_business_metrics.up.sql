@@ -0,0 +1,140 @@
+-- Admin business metrics: materialized views refreshed by the API on a schedule.
+-- Each view has a unique index so it can be refreshed CONCURRENTLY.
+
+-- Weekly registration cohorts by role with conversion to a paid plan
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_admin_registration_cohorts AS
+WITH cohorts AS (
+    SELECT u.id AS user_id, u.role::text AS role,
+        date_trunc('week', u.created_at)::date AS cohort_week,
+        u.created_at
+    FROM users u
+    WHERE u.role::text IN ('model', 'employer', 'agency')
+      AND u.created_at >= date_trunc('week', NOW()) - INTERVAL '52 weeks'
+), first_paid AS (
+    SELECT s.user_id, MIN(s.started_at) AS started_at
+    FROM subscriptions s
+    JOIN plans p ON p.id = s.plan_id
+    WHERE p.price_monthly > 0 AND s.status <> 'pending'
+    GROUP BY s.user_id
+)
+SELECT c.cohort_week, c.role,
+    CASE WHEN c.role = 'model' THEN 'model' ELSE 'employer' END AS audience,
+    COUNT(*) AS registered,
+    COUNT(fp.user_id) AS converted,
+    COALESCE(AVG(EXTRACT(EPOCH FROM (fp.started_at - c.created_at)) / 86400) FILTER (WHERE fp.user_id IS NOT NULL), 0) AS avg_days_to_convert
+FROM cohorts c
+LEFT JOIN first_paid fp ON fp.user_id = c.user_id
+GROUP BY c.cohort_week, c.role;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_mv_admin_registration_cohorts ON mv_admin_registration_cohorts(cohort_week, role);
+
+-- Users of each cohort active N weeks after registering. Activity is any login,
+-- response, casting, chat message or profile/casting view.
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_admin_cohort_retention AS
+WITH cohorts AS (
+    SELECT u.id AS user_id, u.role::text AS role, date_trunc('week', u.created_at)::date AS cohort_week
+    FROM users u
+    WHERE u.role::text IN ('model', 'employer', 'agency')
+      AND u.created_at >= date_trunc('week', NOW()) - INTERVAL '52 weeks'
+), activity AS (
+    SELECT user_id, cohort_week AS week FROM cohorts
+    UNION SELECT id, date_trunc('week', last_login_at)::date FROM users WHERE last_login_at IS NOT NULL
+    UNION SELECT user_id, date_trunc('week', created_at)::date FROM casting_responses
+    UNION SELECT creator_id, date_trunc('week', created_at)::date FROM castings
+    UNION SELECT sender_id, date_trunc('week', created_at)::date FROM messages
+    UNION SELECT viewer_id, date_trunc('week', viewed_at)::date FROM profile_view_events WHERE viewer_id IS NOT NULL
+    UNION SELECT viewer_id, date_trunc('week', viewed_at)::date FROM casting_view_events WHERE viewer_id IS NOT NULL
+)
+SELECT c.cohort_week, c.role, ((a.week - c.cohort_week) / 7)::int AS week_offset,
+    COUNT(DISTINCT c.user_id) AS active_users
+FROM cohorts c
+JOIN activity a ON a.user_id = c.user_id AND a.week >= c.cohort_week
+GROUP BY 1, 2, 3;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_mv_admin_cohort_retention ON mv_admin_cohort_retention(cohort_week, role, week_offset);
+
+-- Paid subscriptions per month: active users, MRR, new and churned users
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_admin_subscription_monthly AS
+WITH months AS (
+    SELECT generate_series(date_trunc('month', NOW()) - INTERVAL '23 months', date_trunc('month', NOW()), INTERVAL '1 month')::date AS month
+), paid AS (
+    SELECT s.user_id, p.audience, s.started_at,
+        CASE WHEN s.status IN ('expired', 'cancelled') THEN COALESCE(s.expires_at, s.cancelled_at, s.updated_at) END AS ended_at,
+        CASE WHEN s.billing_period = 'yearly' THEN COALESCE(p.price_yearly, p.price_monthly * 12) / 12 ELSE p.price_monthly END AS monthly_value
+    FROM subscriptions s
+    JOIN plans p ON p.id = s.plan_id
+    WHERE p.price_monthly > 0 AND s.status <> 'pending'
+), churned AS (
+    -- A user churns when a paid subscription ends without another one taking over
+    SELECT p.user_id, p.audience, p.ended_at
+    FROM paid p
+    WHERE p.ended_at IS NOT NULL AND p.ended_at <= NOW()
+      AND NOT EXISTS (
+          SELECT 1 FROM paid n
+          WHERE n.user_id = p.user_id AND n.started_at <= p.ended_at + INTERVAL '1 day'
+            AND (n.ended_at IS NULL OR n.ended_at > p.ended_at)
+      )
+), audiences AS (
+    SELECT unnest(ARRAY['model', 'employer']) AS audience
+)
+SELECT m.month, a.audience,
+    (SELECT COUNT(DISTINCT p.user_id) FROM paid p WHERE p.audience = a.audience
+        AND p.started_at < m.month AND (p.ended_at IS NULL OR p.ended_at >= m.month)) AS active_start,
+    (SELECT COUNT(DISTINCT p.user_id) FROM paid p WHERE p.audience = a.audience
+        AND p.started_at < m.month + INTERVAL '1 month' AND (p.ended_at IS NULL OR p.ended_at >= m.month + INTERVAL '1 month')) AS active_end,
+    (SELECT COALESCE(SUM(p.monthly_value), 0) FROM paid p WHERE p.audience = a.audience
+        AND p.started_at < m.month + INTERVAL '1 month' AND (p.ended_at IS NULL OR p.ended_at >= m.month + INTERVAL '1 month')) AS mrr,
+    (SELECT COUNT(*) FROM (SELECT user_id, MIN(started_at) AS first_started FROM paid WHERE audience = a.audience GROUP BY user_id) f
+        WHERE f.first_started >= m.month AND f.first_started < m.month + INTERVAL '1 month') AS new_paid,
+    (SELECT COUNT(DISTINCT c.user_id) FROM churned c WHERE c.audience = a.audience
+        AND c.ended_at >= m.month AND c.ended_at < m.month + INTERVAL '1 month') AS churned
+FROM months m CROSS JOIN audiences a;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_mv_admin_subscription_monthly ON mv_admin_subscription_monthly(month, audience);
+
+-- Revenue per month by product type, net of refunds
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_admin_revenue_monthly AS
+SELECT date_trunc('month', COALESCE(pm.paid_at, pm.created_at))::date AS month,
+    CASE
+        WHEN pm.type IN ('subscription', 'subscription_renewal') OR pm.subscription_id IS NOT NULL THEN 'subscription'
+        WHEN pm.type IN ('responses', 'connects') OR pm.response_package IS NOT NULL THEN 'connects'
+        WHEN pm.type = 'credits' THEN 'credits'
+        WHEN pm.type LIKE 'promotion%' OR pm.promotion_id IS NOT NULL THEN 'promotions'
+        ELSE 'other'
+    END AS product,
+    COUNT(*) AS payments,
+    SUM(pm.amount) AS gross,
+    SUM(COALESCE(pm.refunded_amount, 0)) AS refunded
+FROM payments pm
+WHERE pm.status IN ('completed', 'paid', 'refunded')
+GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_mv_admin_revenue_monthly ON mv_admin_revenue_monthly(month, product);
+
+-- Casting liquidity per publication month: time to first response and fill rate
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_admin_casting_liquidity AS
+WITH c AS (
+    SELECT c.id, date_trunc('month', c.created_at)::date AS month,
+        EXTRACT(EPOCH FROM ((SELECT MIN(cr.created_at) FROM casting_responses cr WHERE cr.casting_id = c.id) - c.created_at)) / 3600 AS hours_to_first_response,
+        (c.accepted_models_count >= COALESCE(c.required_models_count, 1)) AS filled
+    FROM castings c
+    WHERE c.status NOT IN ('draft', 'deleted')
+      AND c.created_at >= date_trunc('month', NOW()) - INTERVAL '23 months'
+)
+SELECT month,
+    COUNT(*) AS published,
+    COUNT(hours_to_first_response) AS with_response,
+    COALESCE(AVG(hours_to_first_response), 0) AS avg_hours_to_first_response,
+    COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY hours_to_first_response), 0) AS median_hours_to_first_response,
+    COUNT(*) FILTER (WHERE filled) AS filled
+FROM c
+GROUP BY month;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_mv_admin_casting_liquidity ON mv_admin_casting_liquidity(month);
+
+-- Last refresh of each view
+CREATE TABLE IF NOT EXISTS admin_analytics_refreshes (
+    view_name VARCHAR(64) PRIMARY KEY,
+    refreshed_at TIMESTAMPTZ NOT NULL,
+    duration_ms INT NOT NULL DEFAULT 0
+);
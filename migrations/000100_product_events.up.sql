@@ -0,0 +1,21 @@
+-- Product event stream: append-only log of domain events exported to external analytics
+CREATE TABLE IF NOT EXISTS events (
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    type VARCHAR(64) NOT NULL,
+    actor_id UUID,
+    entity_id UUID,
+    data JSONB NOT NULL DEFAULT '{}',
+    occurred_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_type_occurred ON events(type, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_events_actor ON events(actor_id) WHERE actor_id IS NOT NULL;
+
+-- Last event delivered to each export sink
+CREATE TABLE IF NOT EXISTS event_sink_checkpoints (
+    sink VARCHAR(64) PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,8 @@
+ALTER TABLE payment_reconciliation_reports
+    DROP COLUMN IF EXISTS refunds_open,
+    DROP COLUMN IF EXISTS refunds_failed,
+    DROP COLUMN IF EXISTS refunds_settled;
+
+DROP INDEX IF EXISTS idx_payment_refunds_unsettled;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS op_key;
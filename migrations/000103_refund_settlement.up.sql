@@ -0,0 +1,12 @@
+-- Robokassa operation key of a paid payment, fetched with OpState; refunds are sent by it
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS op_key VARCHAR(255);
+
+-- Refunds awaiting provider settlement ('pending') or with an unknown outcome ('unknown')
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_unsettled
+    ON payment_refunds(created_at) WHERE status IN ('pending', 'unknown');
+
+ALTER TABLE payment_reconciliation_reports
+    ADD COLUMN IF NOT EXISTS refunds_settled INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS refunds_failed INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS refunds_open INT NOT NULL DEFAULT 0;
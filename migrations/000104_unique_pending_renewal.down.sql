@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_pending_renewal;
+CREATE INDEX IF NOT EXISTS idx_payments_pending_renewal ON payments(subscription_id)
+    WHERE type = 'subscription_renewal' AND status = 'pending';
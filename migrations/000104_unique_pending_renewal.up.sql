@@ -0,0 +1,5 @@
+-- At most one pending renewal charge per subscription, so instances running
+-- renewals concurrently cannot charge the same card twice
+DROP INDEX IF EXISTS idx_payments_pending_renewal;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_pending_renewal ON payments(subscription_id)
+    WHERE type = 'subscription_renewal' AND status = 'pending';
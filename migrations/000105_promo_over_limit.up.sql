@@ -0,0 +1,4 @@
+-- Uses redeemed by a late payment after their reservation was released may exceed
+-- the code's limits; they are flagged so promo stats can show them
+ALTER TABLE promo_redemptions
+    ADD COLUMN IF NOT EXISTS over_limit BOOLEAN NOT NULL DEFAULT false;
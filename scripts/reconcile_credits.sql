@@ -1,4 +1,5 @@
 -- Read-only reconciliation query for credit consistency checks.
+-- The nightly payment reconciliation job runs the same check; see GET /api/v1/admin/reconciliation.
 SELECT
     u.id AS user_id,
     u.credit_balance AS stored_balance,